@@ -0,0 +1,129 @@
+/*
+Package goaautocert serves goa services over HTTPS using certificates obtained and renewed
+automatically from Let's Encrypt with the ACME protocol. The Manager type implements the
+goa.CertManager interface used by goa.Service.ListenAndServeAutoTLS:
+
+	service := goa.New("api")
+	// ... mount the controllers
+	service.CertManager = goaautocert.NewManager(nil)
+	if err := service.ListenAndServeAutoTLS("api.example.com"); err != nil {
+		service.LogError("startup", "err", err)
+	}
+
+ListenAndServe does the same but makes it possible to listen on other addresses than the
+default HTTP and HTTPS ports. The ACME client lives in this package rather than in goa so that
+the services that do not serve automatic certificates do not depend on it. The HTTPS servers use
+the TLS configuration described by the service TLS options, see goa.Service.TLS.
+*/
+package goaautocert
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Options configures the certificate managers created by NewManager and the servers started by
+// ListenAndServe.
+type Options struct {
+	// CacheDir is the directory where the certificates obtained from Let's Encrypt are
+	// stored so that they survive restarts. Defaults to the "goa/autocert" subdirectory of
+	// the user cache directory.
+	CacheDir string
+	// Email is the contact address given to Let's Encrypt to notify about certificate
+	// expiration and account issues. Optional.
+	Email string
+	// HTTPAddr is the address of the HTTP server started by ListenAndServe, defaults to
+	// ":80". Let's Encrypt only sends the ACME challenges to port 80.
+	HTTPAddr string
+	// HTTPSAddr is the address of the HTTPS server started by ListenAndServe, defaults to
+	// ":443".
+	HTTPSAddr string
+}
+
+// Manager is a goa.CertManager that obtains and renews certificates from Let's Encrypt.
+type Manager struct {
+	cacheDir string
+	email    string
+}
+
+// NewManager returns a certificate manager configured with the CacheDir and Email options. opts
+// may be nil. Using the manager implies accepting the Let's Encrypt terms of service.
+func NewManager(opts *Options) *Manager {
+	m := &Manager{}
+	if opts != nil {
+		m.cacheDir = opts.CacheDir
+		m.email = opts.Email
+	}
+	if m.cacheDir == "" {
+		if cache, err := os.UserCacheDir(); err == nil {
+			m.cacheDir = filepath.Join(cache, "goa", "autocert")
+		}
+	}
+	return m
+}
+
+// Manage returns the TLS configuration that serves the certificates of the given domains and
+// the handler that answers the ACME challenges and redirects all the other requests to HTTPS.
+func (m *Manager) Manage(domains []string) (*tls.Config, http.Handler, error) {
+	if len(domains) == 0 {
+		return nil, nil, errors.New("autocert requires at least one domain")
+	}
+	am := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Email:      m.email,
+	}
+	if m.cacheDir != "" {
+		am.Cache = autocert.DirCache(m.cacheDir)
+	}
+	return am.TLSConfig(), am.HTTPHandler(nil), nil
+}
+
+// ListenAndServe starts a HTTPS server that serves certificates for the given domains obtained
+// and renewed automatically from Let's Encrypt. It also starts a HTTP server that answers the
+// ACME challenges and redirects all the other requests to HTTPS. opts may be nil. Calling
+// ListenAndServe implies accepting the Let's Encrypt terms of service. When one of the servers
+// fails ListenAndServe closes the other one and returns the error.
+func ListenAndServe(service *goa.Service, opts *Options, domains ...string) error {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.HTTPAddr == "" {
+		o.HTTPAddr = ":80"
+	}
+	if o.HTTPSAddr == "" {
+		o.HTTPSAddr = ":443"
+	}
+	certs, challenges, err := NewManager(&o).Manage(domains)
+	if err != nil {
+		return err
+	}
+
+	cfg := service.TLS.TLSConfig()
+	cfg.GetCertificate = certs.GetCertificate
+	cfg.NextProtos = certs.NextProtos
+
+	httpSrv := &http.Server{Addr: o.HTTPAddr, Handler: challenges}
+	httpsSrv := &http.Server{Addr: o.HTTPSAddr, Handler: service.Mux, TLSConfig: cfg}
+	errc := make(chan error, 2)
+	go func() {
+		service.LogInfo("listen", "transport", "http", "addr", o.HTTPAddr, "acme", true)
+		errc <- httpSrv.ListenAndServe()
+	}()
+	go func() {
+		service.LogInfo("listen", "transport", "https", "addr", o.HTTPSAddr, "domains", domains)
+		errc <- httpsSrv.ListenAndServeTLS("", "")
+	}()
+	err = <-errc
+	httpSrv.Close()
+	httpsSrv.Close()
+	<-errc
+	return err
+}
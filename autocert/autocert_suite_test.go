@@ -0,0 +1,13 @@
+package goaautocert_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAutocert(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Autocert Suite")
+}
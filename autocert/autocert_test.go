@@ -0,0 +1,71 @@
+package goaautocert_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/autocert"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ListenAndServe", func() {
+	var service *goa.Service
+	var opts *goaautocert.Options
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		dir, err := ioutil.TempDir("", "autocert")
+		Ω(err).ShouldNot(HaveOccurred())
+		opts = &goaautocert.Options{CacheDir: dir}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(opts.CacheDir)
+	})
+
+	It("requires domains", func() {
+		Ω(goaautocert.ListenAndServe(service, opts)).Should(HaveOccurred())
+	})
+
+	It("implements the goa certificate manager", func() {
+		var m goa.CertManager = goaautocert.NewManager(opts)
+		cfg, _, err := m.Manage([]string{"example.com"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(cfg).ShouldNot(BeNil())
+		_, _, err = m.Manage(nil)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	Context("when a server fails to start", func() {
+		var taken net.Listener
+
+		BeforeEach(func() {
+			var err error
+			taken, err = net.Listen("tcp", "127.0.0.1:0")
+			Ω(err).ShouldNot(HaveOccurred())
+			free, err := net.Listen("tcp", "127.0.0.1:0")
+			Ω(err).ShouldNot(HaveOccurred())
+			free.Close()
+			opts.HTTPAddr = taken.Addr().String()
+			opts.HTTPSAddr = free.Addr().String()
+		})
+
+		AfterEach(func() {
+			taken.Close()
+		})
+
+		It("closes the other server and returns the error", func() {
+			errc := make(chan error)
+			go func() { errc <- goaautocert.ListenAndServe(service, opts, "example.com") }()
+			var err error
+			Eventually(errc).Should(Receive(&err))
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("address already in use"))
+			_, err = net.Dial("tcp", opts.HTTPSAddr)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
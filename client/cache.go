@@ -0,0 +1,372 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// CacheInfo describes the caching metadata of a response as computed from its
+	// Cache-Control, Age and Expires headers.
+	CacheInfo struct {
+		// MaxAge is the freshness lifetime of the response.
+		MaxAge time.Duration
+		// Age is the age of the response, it accounts for the time the response spent in
+		// intermediary caches and in the client cache.
+		Age time.Duration
+		// NoCache is true if the response must be revalidated prior to being reused.
+		NoCache bool
+		// NoStore is true if the response must not be cached.
+		NoStore bool
+		// Private is true if the response is intended for a single user. Shared caches must
+		// not store it, the client cache is private to the client and does.
+		Private bool
+		// MustRevalidate is true if the response must not be used once stale unless it is
+		// successfully revalidated with the server.
+		MustRevalidate bool
+	}
+
+	// Cache is the interface implemented by the response caches used by the client.
+	// The keys are built from the request method and URL and from the values of the request
+	// headers listed in the Vary header of the response.
+	Cache interface {
+		// Get returns the cached response for the given key if any.
+		Get(key string) (*CachedResponse, bool)
+		// Set caches the given response under the given key.
+		Set(key string, resp *CachedResponse)
+	}
+
+	// CachedResponse is a response stored in a client cache.
+	CachedResponse struct {
+		// Dump contains the serialized response as produced by httputil.DumpResponse.
+		Dump []byte
+		// Info is the cache metadata of the response at the time it was stored.
+		Info *CacheInfo
+		// StoredAt records when the response was stored.
+		StoredAt time.Time
+		// Vary lists the request headers the response varies on. When not empty the entry
+		// only records the list and the response is stored under a key that also includes
+		// the values of the headers.
+		Vary []string
+	}
+
+	// MemoryCache is a simple Cache implementation that keeps responses in memory. It holds at
+	// most a given number of entries and evicts the least recently used ones first.
+	MemoryCache struct {
+		sync.Mutex
+		maxEntries int
+		entries    map[string]*list.Element
+		lru        *list.List // Elements are *memoryCacheEntry, most recently used first
+	}
+
+	// memoryCacheEntry is the value of the MemoryCache LRU list elements.
+	memoryCacheEntry struct {
+		key  string
+		resp *CachedResponse
+	}
+
+	// cacheKey is the private type used to store cache settings in contexts.
+	cacheKey int
+)
+
+const (
+	useCacheKey cacheKey = iota + 1
+	cacheHintKey
+)
+
+// DefaultMemoryCacheEntries is the maximum number of entries of the caches created by
+// NewMemoryCache when not given a positive maximum.
+const DefaultMemoryCacheEntries = 1000
+
+// NewMemoryCache returns an empty in-memory response cache that holds at most maxEntries entries.
+// DefaultMemoryCacheEntries is used if maxEntries is not positive.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMemoryCacheEntries
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get returns the cached response for the given key if any.
+func (c *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*memoryCacheEntry).resp, true
+}
+
+// Set caches the given response under the given key. It evicts the least recently used entry if
+// the cache is full.
+func (c *MemoryCache) Set(key string, resp *CachedResponse) {
+	c.Lock()
+	defer c.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*memoryCacheEntry).resp = resp
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&memoryCacheEntry{key: key, resp: resp})
+	if c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *MemoryCache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.lru.Len()
+}
+
+// WithCache returns a context that makes the client serve the request from its cache when the
+// cache contains a fresh copy of the response. Without it the client always makes the request
+// (but still stores cacheable responses).
+func WithCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, useCacheKey, true)
+}
+
+// WithCacheHint returns a context that carries the Cache-Control header value declared in the
+// design for the request response. The client uses the hint to decide whether responses that
+// include neither a Cache-Control nor an Expires header can be cached and for how long. The hint
+// does not modify the responses. The generated clients set the hint for the actions that declare
+// one.
+func WithCacheHint(ctx context.Context, cacheControl string) context.Context {
+	return context.WithValue(ctx, cacheHintKey, cacheControl)
+}
+
+// ResponseCacheInfo returns the cache metadata of the given response.
+func ResponseCacheInfo(resp *http.Response) *CacheInfo {
+	return parseCacheInfo(resp.Header)
+}
+
+// Fresh returns true if the response can be reused without being revalidated.
+func (i *CacheInfo) Fresh() bool {
+	return !i.NoCache && !i.NoStore && i.Age < i.MaxAge
+}
+
+// Freshness returns the remaining freshness lifetime of the response, 0 if the response is stale.
+func (i *CacheInfo) Freshness() time.Duration {
+	if !i.Fresh() {
+		return 0
+	}
+	return i.MaxAge - i.Age
+}
+
+// lookupCache returns a fresh response from the client cache if the context allows it and the
+// cache contains one. Otherwise it returns the cached response that can be revalidated with the
+// server if any, in which case it sets the If-None-Match header of the request to the response
+// ETag. Requests that already carry conditional headers are not revalidated.
+func (c *Client) lookupCache(ctx context.Context, req *http.Request) (*http.Response, *CachedResponse) {
+	if c.Cache == nil || req.Method != "GET" {
+		return nil, nil
+	}
+	cached, ok := c.cachedResponse(req)
+	if !ok {
+		return nil, nil
+	}
+	resp, err := readCachedResponse(cached, req)
+	if err != nil {
+		return nil, nil
+	}
+	info := freshnessInfo(ctx, resp.Header)
+	info.Age += time.Since(cached.StoredAt)
+	if use, _ := ctx.Value(useCacheKey).(bool); use && info.Fresh() {
+		resp.Header.Set("Age", strconv.Itoa(int(info.Age/time.Second)))
+		return resp, nil
+	}
+	resp.Body.Close()
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return nil, nil
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return nil, nil
+	}
+	req.Header.Set("If-None-Match", etag)
+	return nil, cached
+}
+
+// revalidated returns the cached response updated with the headers of the 304 Not Modified
+// response notModified received when revalidating it. It returns notModified if the cached
+// response cannot be read.
+func revalidated(req *http.Request, cached *CachedResponse, notModified *http.Response) *http.Response {
+	resp, err := readCachedResponse(cached, req)
+	if err != nil {
+		return notModified
+	}
+	notModified.Body.Close()
+	for _, h := range []string{"Cache-Control", "Content-Location", "Date", "ETag", "Expires", "Vary"} {
+		if v, ok := notModified.Header[h]; ok {
+			resp.Header[h] = v
+		}
+	}
+	resp.Header.Del("Age")
+	return resp
+}
+
+// staleResponse returns the cached response if the server failed to revalidate it with a 5xx
+// status and the response may be served stale, that is if it does not require revalidation. It
+// returns nil otherwise. The returned response carries a Warning header.
+func staleResponse(req *http.Request, cached *CachedResponse, failed *http.Response) *http.Response {
+	if cached == nil || failed.StatusCode < 500 || cached.Info.MustRevalidate || cached.Info.NoCache {
+		return nil
+	}
+	resp, err := readCachedResponse(cached, req)
+	if err != nil {
+		return nil
+	}
+	failed.Body.Close()
+	resp.Header.Set("Warning", `111 - "Revalidation Failed"`)
+	return resp
+}
+
+// storeCache stores the response in the client cache if it is cacheable, that is if it is fresh
+// for some time or if it can be revalidated using its ETag.
+func (c *Client) storeCache(ctx context.Context, req *http.Request, resp *http.Response) {
+	if c.Cache == nil || req.Method != "GET" || resp.StatusCode != http.StatusOK {
+		return
+	}
+	if info := freshnessInfo(ctx, resp.Header); info.NoStore || (info.MaxAge <= 0 && resp.Header.Get("ETag") == "") {
+		return
+	}
+	info := parseCacheInfo(resp.Header)
+	key := cacheKeyFor(req)
+	vary := varyHeaders(resp.Header)
+	for _, h := range vary {
+		if h == "*" {
+			return
+		}
+	}
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return
+	}
+	if len(vary) > 0 {
+		c.Cache.Set(key, &CachedResponse{Vary: vary, StoredAt: time.Now()})
+		key = varyKeyFor(req, vary)
+	}
+	c.Cache.Set(key, &CachedResponse{Dump: dump, Info: info, StoredAt: time.Now()})
+}
+
+// cachedResponse returns the response cached for the given request if any, taking the headers
+// the response varies on into account.
+func (c *Client) cachedResponse(req *http.Request) (*CachedResponse, bool) {
+	cached, ok := c.Cache.Get(cacheKeyFor(req))
+	if !ok {
+		return nil, false
+	}
+	if len(cached.Vary) > 0 {
+		if cached, ok = c.Cache.Get(varyKeyFor(req, cached.Vary)); !ok {
+			return nil, false
+		}
+	}
+	if cached.Info == nil || cached.Dump == nil {
+		return nil, false
+	}
+	return cached, true
+}
+
+// readCachedResponse deserializes the cached response.
+func readCachedResponse(cached *CachedResponse, req *http.Request) (*http.Response, error) {
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(cached.Dump)), req)
+}
+
+// freshnessInfo returns the cache metadata the client uses to store and serve the response with
+// the given headers. The cache hint stored in the context if any overrides the metadata of
+// responses that include neither a Cache-Control nor an Expires header.
+func freshnessInfo(ctx context.Context, h http.Header) *CacheInfo {
+	info := parseCacheInfo(h)
+	hint, _ := ctx.Value(cacheHintKey).(string)
+	if hint == "" || h.Get("Cache-Control") != "" || h.Get("Expires") != "" {
+		return info
+	}
+	hinted := parseCacheInfo(http.Header{"Cache-Control": {hint}})
+	hinted.Age = info.Age
+	return hinted
+}
+
+// cacheKeyFor computes the cache key of the given request.
+func cacheKeyFor(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+// varyKeyFor computes the cache key of the given request for a response that varies on the
+// given request headers.
+func varyKeyFor(req *http.Request, vary []string) string {
+	key := cacheKeyFor(req)
+	for _, h := range vary {
+		key += "\n" + h + ": " + strings.Join(req.Header[h], ", ")
+	}
+	return key
+}
+
+// varyHeaders returns the sorted canonical names of the request headers listed in the Vary
+// header of a response.
+func varyHeaders(h http.Header) []string {
+	var vary []string
+	for _, v := range h["Vary"] {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				vary = append(vary, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(vary)
+	return vary
+}
+
+// parseCacheInfo computes the cache metadata from the given response headers.
+func parseCacheInfo(h http.Header) *CacheInfo {
+	var info CacheInfo
+	hasMaxAge := false
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache":
+			info.NoCache = true
+		case directive == "no-store":
+			info.NoStore = true
+		case directive == "private" || strings.HasPrefix(directive, "private="):
+			info.Private = true
+		case directive == "must-revalidate":
+			info.MustRevalidate = true
+		case strings.HasPrefix(directive, "max-age="):
+			if secs, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				info.MaxAge = time.Duration(secs) * time.Second
+				hasMaxAge = true
+			}
+		}
+	}
+	if !hasMaxAge {
+		if exp, err := http.ParseTime(h.Get("Expires")); err == nil {
+			date, err := http.ParseTime(h.Get("Date"))
+			if err != nil {
+				date = time.Now()
+			}
+			info.MaxAge = exp.Sub(date)
+		}
+	}
+	if age, err := strconv.Atoi(h.Get("Age")); err == nil {
+		info.Age = time.Duration(age) * time.Second
+	}
+	return &info
+}
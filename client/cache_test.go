@@ -0,0 +1,294 @@
+package client_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		mu       sync.Mutex
+		headers  http.Header
+		requests []*http.Request
+		server   *httptest.Server
+		cache    *client.MemoryCache
+		c        *client.Client
+		failWith int
+	)
+
+	BeforeEach(func() {
+		headers = http.Header{"Cache-Control": {"max-age=60"}, "Etag": {`"v1"`}}
+		requests = nil
+		failWith = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			requests = append(requests, r)
+			for h, v := range headers {
+				w.Header()[h] = v
+			}
+			if failWith != 0 {
+				w.WriteHeader(failWith)
+				return
+			}
+			if etag := headers.Get("ETag"); etag != "" && r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Write([]byte("body " + r.Header.Get("Accept-Language")))
+		}))
+		cache = client.NewMemoryCache(0)
+		c = client.New(nil)
+		c.Cache = cache
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func(ctx context.Context, header ...string) (*http.Response, string) {
+		req, err := http.NewRequest("GET", server.URL+"/bottles", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := c.Do(ctx, req)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		Ω(err).ShouldNot(HaveOccurred())
+		return resp, string(body)
+	}
+
+	served := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(requests)
+	}
+
+	It("stores 200 responses", func() {
+		get(context.Background())
+		cached, ok := cache.Get("GET " + server.URL + "/bottles")
+		Ω(ok).Should(BeTrue())
+		Ω(cached.Info.MaxAge.Seconds()).Should(Equal(60.0))
+	})
+
+	It("does not store responses that forbid it", func() {
+		headers.Set("Cache-Control", "no-store")
+		get(context.Background())
+		_, ok := cache.Get("GET " + server.URL + "/bottles")
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("serves fresh responses from the cache with WithCache", func() {
+		get(context.Background())
+		resp, body := get(client.WithCache(context.Background()))
+		Ω(served()).Should(Equal(1))
+		Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+		Ω(body).Should(Equal("body "))
+		Ω(resp.Header.Get("Age")).Should(Equal("0"))
+	})
+
+	It("revalidates responses without WithCache", func() {
+		get(context.Background())
+		resp, body := get(context.Background())
+		Ω(served()).Should(Equal(2))
+		Ω(requests[1].Header.Get("If-None-Match")).Should(Equal(`"v1"`))
+		Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+		Ω(body).Should(Equal("body "))
+	})
+
+	It("revalidates stale responses with WithCache", func() {
+		headers.Set("Cache-Control", "no-cache")
+		get(context.Background())
+		headers.Set("Cache-Control", "max-age=30")
+		resp, body := get(client.WithCache(context.Background()))
+		Ω(served()).Should(Equal(2))
+		Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+		Ω(body).Should(Equal("body "))
+		Ω(resp.Header.Get("Cache-Control")).Should(Equal("max-age=30"))
+		Ω(resp.Header.Get("Age")).Should(BeEmpty())
+	})
+
+	It("returns new responses when the ETag changed", func() {
+		get(context.Background())
+		headers.Set("ETag", `"v2"`)
+		resp, _ := get(context.Background())
+		Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+		Ω(resp.Header.Get("ETag")).Should(Equal(`"v2"`))
+	})
+
+	It("does not revalidate conditional requests", func() {
+		get(context.Background())
+		resp, _ := get(context.Background(), "If-None-Match", `"v0"`)
+		Ω(requests[1].Header.Get("If-None-Match")).Should(Equal(`"v0"`))
+		Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+	})
+
+	Context("with a server error", func() {
+		BeforeEach(func() {
+			headers.Set("Cache-Control", "max-age=0")
+		})
+
+		It("serves stale responses", func() {
+			get(context.Background())
+			failWith = http.StatusServiceUnavailable
+			resp, body := get(context.Background())
+			Ω(served()).Should(Equal(2))
+			Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+			Ω(resp.Header.Get("Warning")).Should(Equal(`111 - "Revalidation Failed"`))
+			Ω(body).Should(Equal("body "))
+		})
+
+		It("does not serve stale responses that must be revalidated", func() {
+			headers.Set("Cache-Control", "max-age=0, must-revalidate")
+			get(context.Background())
+			failWith = http.StatusServiceUnavailable
+			resp, _ := get(context.Background())
+			Ω(resp.StatusCode).Should(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	Context("with a cache hint", func() {
+		var ctx context.Context
+
+		BeforeEach(func() {
+			headers = http.Header{}
+			ctx = client.WithCacheHint(context.Background(), "max-age=60")
+		})
+
+		It("caches responses that have no caching headers", func() {
+			resp, _ := get(ctx)
+			Ω(resp.Header.Get("Cache-Control")).Should(BeEmpty())
+			resp, body := get(client.WithCache(ctx))
+			Ω(served()).Should(Equal(1))
+			Ω(resp.Header.Get("Cache-Control")).Should(BeEmpty())
+			Ω(body).Should(Equal("body "))
+		})
+
+		It("only applies to the requests that carry it", func() {
+			get(ctx)
+			get(client.WithCache(context.Background()))
+			Ω(served()).Should(Equal(2))
+		})
+
+		It("does not override the response caching headers", func() {
+			headers.Set("Cache-Control", "no-store")
+			get(ctx)
+			_, ok := cache.Get("GET " + server.URL + "/bottles")
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("does not override the response expiration", func() {
+			headers.Set("Expires", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+			get(ctx)
+			get(client.WithCache(ctx))
+			Ω(served()).Should(Equal(2))
+		})
+	})
+
+	Context("with responses that vary", func() {
+		BeforeEach(func() {
+			headers.Set("Vary", "accept-language")
+		})
+
+		It("caches one response per header value", func() {
+			get(context.Background(), "Accept-Language", "en")
+			get(context.Background(), "Accept-Language", "fr")
+			_, en := get(client.WithCache(context.Background()), "Accept-Language", "en")
+			_, fr := get(client.WithCache(context.Background()), "Accept-Language", "fr")
+			Ω(served()).Should(Equal(2))
+			Ω(en).Should(Equal("body en"))
+			Ω(fr).Should(Equal("body fr"))
+		})
+
+		It("does not serve responses cached for other header values", func() {
+			get(context.Background(), "Accept-Language", "en")
+			_, body := get(client.WithCache(context.Background()), "Accept-Language", "de")
+			Ω(served()).Should(Equal(2))
+			Ω(requests[1].Header.Get("If-None-Match")).Should(BeEmpty())
+			Ω(body).Should(Equal("body de"))
+		})
+
+		It("does not store responses that vary on everything", func() {
+			headers.Set("Vary", "*")
+			get(context.Background())
+			_, ok := cache.Get("GET " + server.URL + "/bottles")
+			Ω(ok).Should(BeFalse())
+			get(client.WithCache(context.Background()))
+			Ω(served()).Should(Equal(2))
+		})
+	})
+})
+
+var _ = Describe("MemoryCache", func() {
+	It("evicts the least recently used entries", func() {
+		cache := client.NewMemoryCache(2)
+		cache.Set("a", &client.CachedResponse{Dump: []byte("a")})
+		cache.Set("b", &client.CachedResponse{Dump: []byte("b")})
+		_, ok := cache.Get("a")
+		Ω(ok).Should(BeTrue())
+		cache.Set("c", &client.CachedResponse{Dump: []byte("c")})
+		Ω(cache.Len()).Should(Equal(2))
+		_, ok = cache.Get("b")
+		Ω(ok).Should(BeFalse())
+		resp, ok := cache.Get("a")
+		Ω(ok).Should(BeTrue())
+		Ω(resp.Dump).Should(Equal([]byte("a")))
+		_, ok = cache.Get("c")
+		Ω(ok).Should(BeTrue())
+	})
+
+	It("replaces the responses stored under the same key", func() {
+		cache := client.NewMemoryCache(2)
+		cache.Set("a", &client.CachedResponse{Dump: []byte("a1")})
+		cache.Set("a", &client.CachedResponse{Dump: []byte("a2")})
+		Ω(cache.Len()).Should(Equal(1))
+		resp, _ := cache.Get("a")
+		Ω(resp.Dump).Should(Equal([]byte("a2")))
+	})
+
+	It("defaults the maximum number of entries", func() {
+		cache := client.NewMemoryCache(0)
+		for i := 0; i <= client.DefaultMemoryCacheEntries; i++ {
+			cache.Set(strconv.Itoa(i), &client.CachedResponse{})
+		}
+		Ω(cache.Len()).Should(Equal(client.DefaultMemoryCacheEntries))
+	})
+})
+
+var _ = Describe("ResponseCacheInfo", func() {
+	info := func(cacheControl string) *client.CacheInfo {
+		return client.ResponseCacheInfo(&http.Response{Header: http.Header{"Cache-Control": {cacheControl}, "Age": {"10"}}})
+	}
+
+	It("computes the freshness", func() {
+		i := info("max-age=60")
+		Ω(i.Fresh()).Should(BeTrue())
+		Ω(i.Freshness()).Should(Equal(50 * time.Second))
+		Ω(info("max-age=5").Fresh()).Should(BeFalse())
+		Ω(info("no-cache, max-age=60").Fresh()).Should(BeFalse())
+	})
+
+	It("parses private", func() {
+		Ω(info("private, max-age=60").Private).Should(BeTrue())
+		Ω(info(`private="Set-Cookie"`).Private).Should(BeTrue())
+		Ω(info("public").Private).Should(BeFalse())
+	})
+
+	It("parses must-revalidate", func() {
+		i := info("max-age=60, Must-Revalidate")
+		Ω(i.MustRevalidate).Should(BeTrue())
+		Ω(i.Fresh()).Should(BeTrue())
+		Ω(info("max-age=60").MustRevalidate).Should(BeFalse())
+	})
+})
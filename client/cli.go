@@ -2,7 +2,6 @@ package client
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -21,6 +20,17 @@ import (
 //    404: 4
 //    500+: 5
 func HandleResponse(c *Client, resp *http.Response, pretty bool) {
+	format := FormatRaw
+	if pretty {
+		format = FormatJSON
+	}
+	HandleResponseFormat(c, resp, format, nil)
+}
+
+// HandleResponseFormat is like HandleResponse but writes the body of successful responses using
+// the given output format, see FormatBody. columns lists the attributes rendered by the table
+// format.
+func HandleResponseFormat(c *Client, resp *http.Response, format string, columns []string) {
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -35,22 +45,8 @@ func HandleResponse(c *Client, resp *http.Response, pretty bool) {
 		}
 		fmt.Printf("error: %d%s", resp.StatusCode, sbody)
 	} else if !c.Dump && len(body) > 0 {
-		var out string
-		if pretty {
-			var jbody interface{}
-			err = json.Unmarshal(body, &jbody)
-			if err != nil {
-				out = string(body)
-			} else {
-				var b []byte
-				b, err = json.MarshalIndent(jbody, "", "    ")
-				if err == nil {
-					out = string(b)
-				} else {
-					out = string(body)
-				}
-			}
-		} else {
+		out, err := FormatBody(body, format, columns)
+		if err != nil {
 			out = string(body)
 		}
 		fmt.Print(out)
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"io"
@@ -10,11 +11,21 @@ import (
 	"net/http/httputil"
 	"time"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa"
 )
 
+const (
+	// SDKVersionHeader is the name of the header that carries the version of the generated
+	// client, see Client.SDKVersion.
+	SDKVersionHeader = "X-SDK-Version"
+	// SDKLanguageHeader is the name of the header that carries the language of the generated
+	// client, see Client.SDKLanguage.
+	SDKLanguageHeader = "X-SDK-Language"
+	// RequestIDHeader is the name of the header that carries the ID of the request being
+	// handled by the service making the request, see RequestIDMiddleware.
+	RequestIDHeader = "X-Request-Id"
+)
+
 type (
 	// Client is the common client data structure for all goa service clients.
 	Client struct {
@@ -28,6 +39,36 @@ type (
 		UserAgent string
 		// Dump indicates whether to dump request response.
 		Dump bool
+		// Cache stores cacheable responses if not nil. Requests made with a context
+		// created via WithCache are served from the cache when it has a fresh copy.
+		// Stale responses that carry an ETag are revalidated with If-None-Match and
+		// served from the cache when the server responds with 304 Not Modified. They
+		// are also served when the server fails with a 5xx status unless they require
+		// revalidation (no-cache or must-revalidate).
+		Cache Cache
+		// SDKVersion is the version of the generated client sent in the SDKVersionHeader
+		// header if not empty. Generated clients derive it from the API and goagen versions.
+		SDKVersion string
+		// SDKLanguage is the language of the generated client sent in the SDKLanguageHeader
+		// header if not empty.
+		SDKLanguage string
+		// Retry is the policy used to retry failed requests, requests are not retried if
+		// nil. WithRetryPolicy overrides it for a single request.
+		Retry *RetryPolicy
+		// Middleware wrap the requests made by the client, see Use.
+		Middleware []Middleware
+		// RequestSigner signs all the requests built by the generated clients if not nil,
+		// after the signer of the action security scheme, see HMACSigner and SigV4Signer.
+		RequestSigner Signer
+		// RequestTimeout is the timeout of the requests whose context has no deadline if
+		// not zero. It covers the whole request including reading the response body.
+		RequestTimeout time.Duration
+	}
+
+	// cancelBody cancels the context of a request when its response body is closed.
+	cancelBody struct {
+		io.ReadCloser
+		cancel context.CancelFunc
 	}
 )
 
@@ -41,21 +82,69 @@ func New(c *http.Client) *Client {
 }
 
 // Do wraps the underlying http client Do method and adds logging.
-// The logger should be in the context.
+// The logger should be in the context. The client middleware run around the request.
+// The request is bound to ctx: cancelling ctx or reaching its deadline aborts the request,
+// including dialing, the TLS handshake and reading the response body.
 func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
 	req.Header.Set("User-Agent", c.UserAgent)
+	if c.SDKVersion != "" {
+		req.Header.Set(SDKVersionHeader, c.SDKVersion)
+	}
+	if c.SDKLanguage != "" {
+		req.Header.Set(SDKLanguageHeader, c.SDKLanguage)
+	}
+	var cancel context.CancelFunc
+	if _, ok := ctx.Deadline(); !ok && c.RequestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+	}
+	resp, err := c.roundTripper(RoundTripperFunc(c.do)).RoundTrip(ctx, req.WithContext(ctx))
+	if cancel != nil {
+		if err != nil {
+			cancel()
+		} else {
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		}
+	}
+	return resp, err
+}
+
+// Close closes the body and cancels the request context.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// do makes the request, serving it from the cache when possible and retrying it according to
+// the client retry policy.
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
 	startedAt := time.Now()
 	id := shortID()
 	goa.LogInfo(ctx, "started", "id", id, req.Method, req.URL.String())
 	if c.Dump {
 		c.dumpRequest(ctx, req)
 	}
-	resp, err := c.Client.Do(req)
+	resp, stale := c.lookupCache(ctx, req)
+	if resp != nil {
+		goa.LogInfo(ctx, "completed", "id", id, "status", resp.StatusCode, "cached", true, "time", time.Since(startedAt).String())
+		return resp, nil
+	}
+	resp, err := c.doWithRetry(ctx, req, id)
 	if err != nil {
 		goa.LogError(ctx, "failed", "err", err)
 		return nil, err
 	}
-	goa.LogInfo(ctx, "completed", "id", id, "status", resp.StatusCode, "time", time.Since(startedAt).String())
+	if stale != nil && resp.StatusCode == http.StatusNotModified {
+		resp = revalidated(req, stale, resp)
+		goa.LogInfo(ctx, "completed", "id", id, "status", resp.StatusCode, "revalidated", true, "time", time.Since(startedAt).String())
+		c.storeCache(ctx, req, resp)
+	} else if cached := staleResponse(req, stale, resp); cached != nil {
+		goa.LogInfo(ctx, "completed", "id", id, "status", resp.StatusCode, "stale", true, "time", time.Since(startedAt).String())
+		resp = cached
+	} else {
+		goa.LogInfo(ctx, "completed", "id", id, "status", resp.StatusCode, "time", time.Since(startedAt).String())
+		c.storeCache(ctx, req, resp)
+	}
 	if c.Dump {
 		c.dumpResponse(ctx, resp)
 	}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// CompletionCommand returns the "completion" command of the client tool app. The command writes
+// the completion script of the given shell to stdout. The scripts complete the resource and
+// action sub-commands, the flag names and the values of the flags that list them.
+func CompletionCommand(app *cobra.Command) *cobra.Command {
+	name := app.Name()
+	return &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate the shell completion script",
+		Long: fmt.Sprintf(`Generate the shell completion script, e.g.:
+
+Bash:
+  $ source <(%[1]s completion bash)
+
+Zsh:
+  $ %[1]s completion zsh > "${fpath[1]}/_%[1]s"
+
+Fish:
+  $ %[1]s completion fish > ~/.config/fish/completions/%[1]s.fish
+
+PowerShell:
+  PS> %[1]s completion powershell | Out-String | Invoke-Expression
+`, name),
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch args[0] {
+			case "bash":
+				return app.GenBashCompletion(os.Stdout)
+			case "zsh":
+				return app.GenZshCompletion(os.Stdout)
+			case "fish":
+				return app.GenFishCompletion(os.Stdout, true)
+			case "powershell":
+				return app.GenPowerShellCompletion(os.Stdout)
+			}
+			return fmt.Errorf("unsupported shell %#v, must be one of bash, zsh, fish or powershell", args[0])
+		},
+	}
+}
+
+// CompleteValues returns a flag completion function that completes the given values. The
+// generated client tools use it for the flags whose values are enumerated in the design.
+func CompleteValues(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFlag is the name of the flag that sets the path to the configuration file of the
+// generated client tools.
+const ConfigFlag = "config"
+
+// DefaultConfigPath returns the path to the default configuration file of the client tool of
+// the given API: "config.yaml" in the "<api>" sub-directory of $XDG_CONFIG_HOME or of
+// ~/.config if the variable is not set. It returns an empty string if the home directory
+// cannot be determined.
+func DefaultConfigPath(apiName string) string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, apiName, "config.yaml")
+}
+
+// EnvName returns the name of the environment variable that sets the default value of the given
+// flag of the client tool of the given API, e.g. "CELLAR_HOST" for the "host" flag of the
+// "cellar" API.
+func EnvName(apiName, flag string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, apiName+"_"+flag)
+}
+
+// LoadConfig sets the flags of cmd that are not given on the command line from the environment
+// variables named after the flags (see EnvName) or from the tool configuration file. The path to
+// the file is given by the ConfigFlag flag if cmd defines it and defaults to DefaultConfigPath.
+// The file maps flag names to values, lists are joined with commas, e.g.:
+//
+//	host: api.example.com
+//	scheme: https
+//	key: secret
+//
+// Flags given on the command line take precedence over the environment variables which take
+// precedence over the configuration file. Entries that do not correspond to a flag of cmd are
+// ignored so that the same file can be used with all the commands of the tool. A missing
+// configuration file is not an error unless its path was given explicitly.
+func LoadConfig(cmd *cobra.Command, apiName string) error {
+	flags := cmd.Flags()
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed {
+			return
+		}
+		if v, ok := os.LookupEnv(EnvName(apiName, f.Name)); ok {
+			if serr := flags.Set(f.Name, v); serr != nil {
+				err = fmt.Errorf("invalid value %#v for environment variable %s: %s", v, EnvName(apiName, f.Name), serr)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	path, explicit := DefaultConfigPath(apiName), false
+	if f := flags.Lookup(ConfigFlag); f != nil && f.Value.String() != "" {
+		path, explicit = f.Value.String(), f.Changed
+	}
+	if path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
+		return err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("invalid configuration file %s: %s", path, err)
+	}
+	for name, v := range values {
+		f := flags.Lookup(name)
+		if f == nil || f.Changed || name == ConfigFlag {
+			continue
+		}
+		val, err := configValue(v)
+		if err != nil {
+			return fmt.Errorf("invalid configuration file %s: %s: %s", path, name, err)
+		}
+		if err := flags.Set(name, val); err != nil {
+			return fmt.Errorf("invalid configuration file %s: %s: %s", path, name, err)
+		}
+	}
+	return nil
+}
+
+// configValue returns the command line representation of the value of a configuration entry.
+func configValue(v interface{}) (string, error) {
+	switch actual := v.(type) {
+	case nil:
+		return "", fmt.Errorf("missing value")
+	case map[interface{}]interface{}:
+		return "", fmt.Errorf("nested maps are not supported")
+	case []interface{}:
+		vals := make([]string, len(actual))
+		for i, e := range actual {
+			vals[i] = fmt.Sprint(e)
+		}
+		return strings.Join(vals, ","), nil
+	}
+	return fmt.Sprint(v), nil
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// NewFakeResponse returns a response with the given status code and body for use by the fake
+// clients generated for each resource, e.g.:
+//
+//	fake := client.NewFakeBottleClient()
+//	fake.ShowBottleFunc = func(ctx context.Context, path string) (*http.Response, error) {
+//		return goaclient.NewFakeResponse(200, &client.Bottle{ID: 1}), nil
+//	}
+//
+// The body is encoded with JSON unless it is a byte slice or a string in which case it is used
+// as is. A nil body produces an empty response body. The generated gRPC clients also use it to
+// convert the gRPC responses.
+func NewFakeResponse(status int, body interface{}) *http.Response {
+	var b []byte
+	header := make(http.Header)
+	switch v := body.(type) {
+	case nil:
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			status = http.StatusInternalServerError
+			b = []byte(err.Error())
+			break
+		}
+		header.Set("Content-Type", "application/json")
+	}
+	header.Set("Content-Length", strconv.Itoa(len(b)))
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(b)),
+		ContentLength: int64(len(b)),
+	}
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The output formats supported by the generated client tools.
+const (
+	// FormatRaw writes the response body as received.
+	FormatRaw = ""
+	// FormatJSON writes the response body as indented JSON.
+	FormatJSON = "json"
+	// FormatYAML writes the response body as YAML.
+	FormatYAML = "yaml"
+	// FormatTable writes the response body as a table with one row per element of collections
+	// and a single row for other objects.
+	FormatTable = "table"
+)
+
+// ValidateFormat returns an error if format is not one of the supported output formats.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatRaw, FormatJSON, FormatYAML, FormatTable:
+		return nil
+	}
+	return fmt.Errorf("invalid output format %#v, must be one of %s, %s or %s", format, FormatTable, FormatJSON, FormatYAML)
+}
+
+// FormatBody renders the JSON response body using the given format. columns lists the names of
+// the attributes rendered by the table format in order, the generated client tools derive them
+// from the default view of the response media type. All the attributes of the first row are
+// rendered in alphabetical order if columns is empty.
+func FormatBody(body []byte, format string, columns []string) (string, error) {
+	if format == FormatRaw {
+		return string(body), nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "", err
+	}
+	switch format {
+	case FormatJSON:
+		b, err := json.MarshalIndent(v, "", "    ")
+		if err != nil {
+			return "", err
+		}
+		return string(b) + "\n", nil
+	case FormatYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case FormatTable:
+		return formatTable(v, columns), nil
+	}
+	return "", ValidateFormat(format)
+}
+
+// formatTable renders v as a table.
+func formatTable(v interface{}, columns []string) string {
+	var rows []interface{}
+	if elems, ok := v.([]interface{}); ok {
+		rows = elems
+	} else {
+		rows = []interface{}{v}
+	}
+	if len(columns) == 0 && len(rows) > 0 {
+		if obj, ok := rows[0].(map[string]interface{}); ok {
+			for k := range obj {
+				columns = append(columns, k)
+			}
+			sort.Strings(columns)
+		}
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	if len(columns) == 0 {
+		fmt.Fprintln(w, "VALUE")
+		for _, row := range rows {
+			fmt.Fprintln(w, tableCell(row))
+		}
+		w.Flush()
+		return buf.String()
+	}
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = strings.ToUpper(c)
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		obj, _ := row.(map[string]interface{})
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = tableCell(obj[c])
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// tableCell renders a table cell value, nested objects and arrays are rendered as compact JSON.
+func tableCell(v interface{}) string {
+	switch actual := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return actual
+	case float64:
+		return strconv.FormatFloat(actual, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(actual)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
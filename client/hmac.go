@@ -0,0 +1,321 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type (
+	// HMACSigner signs requests with a shared secret following the HTTP Signatures scheme: the
+	// listed headers are concatenated into a signing string whose HMAC is sent in the
+	// Authorization header, e.g.:
+	//
+	//	Authorization: Signature keyId="key",algorithm="hmac-sha256",headers="(request-target) host date digest",signature="..."
+	//
+	// Set it as the client RequestSigner to sign all the requests built by the generated
+	// clients.
+	HMACSigner struct {
+		// KeyID identifies the secret on the server.
+		KeyID string
+		// Secret is the shared secret.
+		Secret string
+		// Algorithm is "hmac-sha256" (the default) or "hmac-sha512".
+		Algorithm string
+		// Headers lists the lowercase names of the signed headers, defaults to
+		// "(request-target)", "host", "date" and "digest". "(request-target)" stands for the
+		// request method and path and "digest" for the SHA-256 digest of the body which the
+		// signer computes and sets in the Digest header.
+		Headers []string
+		// Header is the name of the header that carries the signature, defaults to
+		// "Authorization".
+		Header string
+		// ClockSkew is added to the current time when setting the Date header to compensate
+		// for the difference between the client and server clocks.
+		ClockSkew time.Duration
+	}
+
+	// SigV4Signer signs requests using the AWS Signature Version 4 scheme. Set it as the
+	// client RequestSigner to sign all the requests built by the generated clients.
+	//
+	// The canonical URI is computed from the request path as sent on the wire: the request
+	// URL Opaque field if set, its escaped path otherwise. Each segment of the path is then
+	// URI-encoded again as required by all AWS services but Amazon S3.
+	SigV4Signer struct {
+		// AccessKeyID is the AWS access key ID.
+		AccessKeyID string
+		// SecretAccessKey is the AWS secret access key.
+		SecretAccessKey string
+		// SessionToken is the optional session token of temporary credentials.
+		SessionToken string
+		// Region is the AWS region, e.g. "us-east-1".
+		Region string
+		// Service is the signing name of the service, e.g. "execute-api".
+		Service string
+		// ClockSkew is added to the current time when computing the signature date to
+		// compensate for the difference between the client and server clocks.
+		ClockSkew time.Duration
+		// DisableDoubleEncoding makes the signer use the path as sent on the wire in the
+		// canonical URI instead of URI-encoding its segments a second time. Amazon S3
+		// requires it, the signer always disables double encoding when Service is "s3".
+		DisableDoubleEncoding bool
+	}
+)
+
+// defaultSignedHeaders is the default value of HMACSigner.Headers.
+var defaultSignedHeaders = []string{"(request-target)", "host", "date", "digest"}
+
+// amzDateFormat is the format of the X-Amz-Date header.
+const amzDateFormat = "20060102T150405Z"
+
+// SignRequest signs the request with the client RequestSigner if any. The generated request
+// builders call it once the request is complete.
+func (c *Client) SignRequest(ctx context.Context, req *http.Request) error {
+	if c.RequestSigner == nil {
+		return nil
+	}
+	return c.RequestSigner.Sign(ctx, req)
+}
+
+// Sign sets the Date and Digest headers if needed and adds the signature header.
+func (s *HMACSigner) Sign(ctx context.Context, req *http.Request) error {
+	var h func() hash.Hash
+	alg := s.Algorithm
+	switch alg {
+	case "", "hmac-sha256":
+		alg, h = "hmac-sha256", sha256.New
+	case "hmac-sha512":
+		h = sha512.New
+	default:
+		return fmt.Errorf("unsupported HMAC algorithm %#v", alg)
+	}
+	headers := s.Headers
+	if len(headers) == 0 {
+		headers = defaultSignedHeaders
+	}
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", time.Now().Add(s.ClockSkew).UTC().Format(http.TimeFormat))
+	}
+	lines := make([]string, len(headers))
+	for i, name := range headers {
+		name = strings.ToLower(name)
+		var value string
+		switch name {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = requestHost(req)
+		case "digest":
+			body, err := requestBody(req)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(body)
+			value = "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+			req.Header.Set("Digest", value)
+		default:
+			value = strings.Join(req.Header.Values(name), ", ")
+		}
+		lines[i] = name + ": " + value
+	}
+	mac := hmac.New(h, []byte(s.Secret))
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	sig := fmt.Sprintf("keyId=%q,algorithm=%q,headers=%q,signature=%q",
+		s.KeyID, alg, strings.ToLower(strings.Join(headers, " ")), base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	header := s.Header
+	if header == "" {
+		header = "Authorization"
+	}
+	if strings.EqualFold(header, "Authorization") {
+		sig = "Signature " + sig
+	}
+	req.Header.Set(header, sig)
+	return nil
+}
+
+// RegisterFlags adds the "--hmac-key-id" and "--hmac-secret" flags to the client tool.
+func (s *HMACSigner) RegisterFlags(app *cobra.Command) {
+	app.Flags().StringVar(&s.KeyID, "hmac-key-id", "", "HMAC signature key ID")
+	app.Flags().StringVar(&s.Secret, "hmac-secret", "", "HMAC signature secret")
+}
+
+// Sign sets the X-Amz-Date header if needed, the X-Amz-Security-Token header if the signer has
+// a session token and, for Amazon S3, the X-Amz-Content-Sha256 header. It then adds the
+// Authorization header.
+func (s *SigV4Signer) Sign(ctx context.Context, req *http.Request) error {
+	body, err := requestBody(req)
+	if err != nil {
+		return err
+	}
+	now, err := time.Parse(amzDateFormat, req.Header.Get("X-Amz-Date"))
+	if err != nil {
+		now = time.Now().Add(s.ClockSkew).UTC()
+	}
+	amzDate := now.Format(amzDateFormat)
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	signed := map[string]string{"host": requestHost(req)}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			signed[name] = strings.Join(trimmed, ",")
+		}
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL, !s.DisableDoubleEncoding && s.Service != "s3"),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	for _, k := range []string{s.Region, s.Service, "aws4_request"} {
+		key = hmacSHA256(key, k)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// RegisterFlags adds the "--aws-access-key-id", "--aws-secret-access-key", "--aws-session-token"
+// and "--aws-region" flags to the client tool.
+func (s *SigV4Signer) RegisterFlags(app *cobra.Command) {
+	app.Flags().StringVar(&s.AccessKeyID, "aws-access-key-id", "", "AWS access key ID")
+	app.Flags().StringVar(&s.SecretAccessKey, "aws-secret-access-key", "", "AWS secret access key")
+	app.Flags().StringVar(&s.SessionToken, "aws-session-token", "", "AWS session token")
+	app.Flags().StringVar(&s.Region, "aws-region", s.Region, "AWS region")
+}
+
+// requestBody returns the request body without consuming it.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// requestHost returns the value of the Host header sent with the request.
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+// canonicalURI returns the path of the request URL as sent on the wire with each segment
+// URI-encoded again if double is true.
+func canonicalURI(u *url.URL, double bool) string {
+	path := u.EscapedPath()
+	if u.Opaque != "" {
+		path = u.Opaque
+		if strings.HasPrefix(path, "//") {
+			// Opaque of the form "//host/path".
+			path = strings.TrimPrefix(path[2:], u.Host)
+		}
+	}
+	if path == "" {
+		return "/"
+	}
+	if !double {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query string with sorted keys and values encoded as required by
+// AWS Signature Version 4.
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			pairs = append(pairs, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes s as defined by RFC 3986.
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,191 @@
+package client_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HMACSigner", func() {
+	var (
+		signer *client.HMACSigner
+		req    *http.Request
+	)
+
+	BeforeEach(func() {
+		signer = &client.HMACSigner{KeyID: "key", Secret: "secret"}
+		var err error
+		req, err = http.NewRequest("POST", "http://example.com/bottles?sort=name", strings.NewReader(`{"name":"x"}`))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Date", "Thu, 15 Oct 2026 10:00:00 GMT")
+	})
+
+	It("signs the default headers", func() {
+		Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+		sum := sha256.Sum256([]byte(`{"name":"x"}`))
+		digest := "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+		Ω(req.Header.Get("Digest")).Should(Equal(digest))
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte("(request-target): post /bottles?sort=name\nhost: example.com\ndate: Thu, 15 Oct 2026 10:00:00 GMT\ndigest: " + digest))
+		Ω(req.Header.Get("Authorization")).Should(Equal(fmt.Sprintf(
+			`Signature keyId="key",algorithm="hmac-sha256",headers="(request-target) host date digest",signature=%q`,
+			base64.StdEncoding.EncodeToString(mac.Sum(nil)))))
+	})
+
+	It("does not consume the body", func() {
+		Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+		body, err := ioutil.ReadAll(req.Body)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(body)).Should(Equal(`{"name":"x"}`))
+	})
+
+	It("sets the Date header", func() {
+		req.Header.Del("Date")
+		Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+		_, err := http.ParseTime(req.Header.Get("Date"))
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("signs the given headers with the given algorithm", func() {
+		signer.Algorithm = "hmac-sha512"
+		signer.Headers = []string{"Date", "X-Tenant"}
+		signer.Header = "Signature"
+		req.Header.Set("X-Tenant", "acme")
+		Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+		mac := hmac.New(sha512.New, []byte("secret"))
+		mac.Write([]byte("date: Thu, 15 Oct 2026 10:00:00 GMT\nx-tenant: acme"))
+		Ω(req.Header.Get("Signature")).Should(Equal(fmt.Sprintf(
+			`keyId="key",algorithm="hmac-sha512",headers="date x-tenant",signature=%q`,
+			base64.StdEncoding.EncodeToString(mac.Sum(nil)))))
+		Ω(req.Header.Get("Digest")).Should(BeEmpty())
+		Ω(req.Header.Get("Authorization")).Should(BeEmpty())
+	})
+
+	It("rejects unknown algorithms", func() {
+		signer.Algorithm = "hmac-md5"
+		Ω(signer.Sign(context.Background(), req)).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("SigV4Signer", func() {
+	const (
+		amzDate    = "20150830T123600Z"
+		scope      = "20150830/us-east-1/service/aws4_request"
+		credential = "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/" + scope
+	)
+
+	var signer *client.SigV4Signer
+
+	BeforeEach(func() {
+		signer = &client.SigV4Signer{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			Region:          "us-east-1",
+			Service:         "service",
+		}
+	})
+
+	// newRequest creates a request for the given path as sent on the wire.
+	newRequest := func(method, path, body string) *http.Request {
+		req, err := http.NewRequest(method, "http://example.amazonaws.com/", strings.NewReader(body))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.URL.Opaque = path
+		req.Header.Set("X-Amz-Date", amzDate)
+		return req
+	}
+
+	// signature computes the signature of the given canonical request.
+	signature := func(service, canonicalRequest string) string {
+		sum := sha256.Sum256([]byte(canonicalRequest))
+		key := []byte("AWS4" + signer.SecretAccessKey)
+		for _, k := range []string{"20150830", "us-east-1", service, "aws4_request"} {
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(k))
+			key = mac.Sum(nil)
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("AWS4-HMAC-SHA256\n" + amzDate + "\n20150830/us-east-1/" + service + "/aws4_request\n" + hex.EncodeToString(sum[:])))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	// Vectors from the AWS Signature Version 4 test suite.
+	vectors := []struct {
+		name, method, path, contentType, body string
+		signedHeaders, signature              string
+	}{
+		{"get-vanilla", "GET", "/", "", "",
+			"host;x-amz-date", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-utf8", "GET", "/ሴ", "", "",
+			"host;x-amz-date", "8318018e0b0f223aa2bbf98705b62bb787dc9c0e678f255a891fd03141be5d85"},
+		{"get-space", "GET", "/example space/", "", "",
+			"host;x-amz-date", "652487583200325589f1fba4c7e578f72c47cb61beeca81406b39ddec1366741"},
+		{"post-x-www-form-urlencoded", "POST", "/", "application/x-www-form-urlencoded", "Param1=value1",
+			"content-type;host;x-amz-date", "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a"},
+	}
+
+	for _, v := range vectors {
+		v := v
+		It("signs "+v.name, func() {
+			req := newRequest(v.method, v.path, v.body)
+			if v.contentType != "" {
+				req.Header.Set("Content-Type", v.contentType)
+			}
+			Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+			Ω(req.Header.Get("Authorization")).Should(Equal(fmt.Sprintf("%s, SignedHeaders=%s, Signature=%s", credential, v.signedHeaders, v.signature)))
+		})
+	}
+
+	Context("with a path that contains escaped characters", func() {
+		const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+		var req *http.Request
+
+		BeforeEach(func() {
+			var err error
+			req, err = http.NewRequest("GET", "http://example.amazonaws.com/documents and settings/", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			req.Header.Set("X-Amz-Date", amzDate)
+		})
+
+		It("encodes the path segments twice", func() {
+			Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+			canonical := "GET\n/documents%2520and%2520settings/\n\nhost:example.amazonaws.com\nx-amz-date:" + amzDate + "\n\nhost;x-amz-date\n" + emptyHash
+			Ω(req.Header.Get("Authorization")).Should(HaveSuffix("Signature=" + signature("service", canonical)))
+		})
+
+		It("encodes the path segments once when double encoding is disabled", func() {
+			signer.DisableDoubleEncoding = true
+			Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+			canonical := "GET\n/documents%20and%20settings/\n\nhost:example.amazonaws.com\nx-amz-date:" + amzDate + "\n\nhost;x-amz-date\n" + emptyHash
+			Ω(req.Header.Get("Authorization")).Should(HaveSuffix("Signature=" + signature("service", canonical)))
+		})
+
+		It("encodes the path segments once for S3", func() {
+			signer.Service = "s3"
+			Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+			Ω(req.Header.Get("X-Amz-Content-Sha256")).Should(Equal(emptyHash))
+			canonical := "GET\n/documents%20and%20settings/\n\nhost:example.amazonaws.com\nx-amz-content-sha256:" + emptyHash + "\nx-amz-date:" + amzDate + "\n\nhost;x-amz-content-sha256;x-amz-date\n" + emptyHash
+			Ω(req.Header.Get("Authorization")).Should(HaveSuffix("Signature=" + signature("s3", canonical)))
+		})
+	})
+
+	It("sets the date and session token headers", func() {
+		signer.SessionToken = "token"
+		req, err := http.NewRequest("GET", "http://example.amazonaws.com/", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+		Ω(req.Header.Get("X-Amz-Date")).Should(MatchRegexp(`^\d{8}T\d{6}Z$`))
+		Ω(req.Header.Get("X-Amz-Security-Token")).Should(Equal("token"))
+		Ω(req.Header.Get("Authorization")).Should(ContainSubstring("SignedHeaders=host;x-amz-date;x-amz-security-token,"))
+	})
+})
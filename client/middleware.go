@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// RoundTripper sends a request and returns its response. The client middleware wrap the
+	// round tripper that makes the actual request.
+	RoundTripper interface {
+		// RoundTrip makes the request.
+		RoundTrip(ctx context.Context, req *http.Request) (*http.Response, error)
+	}
+
+	// RoundTripperFunc is a function that implements RoundTripper.
+	RoundTripperFunc func(ctx context.Context, req *http.Request) (*http.Response, error)
+
+	// Middleware wraps a round tripper to run code before and after the requests made by the
+	// client, e.g. to log them, record metrics or set headers. See Client.Use.
+	Middleware func(RoundTripper) RoundTripper
+
+	// actionKey is the private type used to store the request action in contexts.
+	actionKey int
+)
+
+const (
+	resourceNameKey actionKey = iota + 1
+	actionNameKey
+	requestIDKey
+)
+
+// RoundTrip calls f.
+func (f RoundTripperFunc) RoundTrip(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return f(ctx, req)
+}
+
+// Use adds middleware to the client. The middleware run in the order they are added for each
+// request made by the client.
+func (c *Client) Use(m ...Middleware) {
+	c.Middleware = append(c.Middleware, m...)
+}
+
+// WithAction returns a context that records the name of the resource and action the request is
+// made for. The generated clients set it for each action method so that middleware can retrieve
+// them with ContextAction.
+func WithAction(ctx context.Context, resource, action string) context.Context {
+	ctx = context.WithValue(ctx, resourceNameKey, resource)
+	return context.WithValue(ctx, actionNameKey, action)
+}
+
+// ContextAction returns the names of the resource and action recorded in the context by
+// WithAction, empty strings if there are none.
+func ContextAction(ctx context.Context) (resource, action string) {
+	resource, _ = ctx.Value(resourceNameKey).(string)
+	action, _ = ctx.Value(actionNameKey).(string)
+	return
+}
+
+// WithRequestID returns a context that records the ID of the request being handled by the service
+// so that the requests made with the context forward it, see RequestIDMiddleware. The service
+// RequestID middleware sets it for each request.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// ContextRequestID returns the request ID recorded in the context by WithRequestID, an empty
+// string if there is none.
+func ContextRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// LogMiddleware returns a middleware that logs the resource and action, status and duration of
+// each request using the context logger.
+func LogMiddleware() Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			resource, action := ContextAction(ctx)
+			startedAt := time.Now()
+			resp, err := next.RoundTrip(ctx, req)
+			if err != nil {
+				goa.LogError(ctx, "request failed", "resource", resource, "action", action, "err", err, "time", time.Since(startedAt).String())
+				return resp, err
+			}
+			goa.LogInfo(ctx, "request", "resource", resource, "action", action, "status", resp.StatusCode, "time", time.Since(startedAt).String())
+			return resp, nil
+		})
+	}
+}
+
+// MetricsMiddleware returns a middleware that records the duration of each request and counts
+// the requests per status using the goa metrics. The metric keys start with the given prefix
+// followed by the resource and action names, e.g. "client.bottle.show.200".
+func MetricsMiddleware(prefix string) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			resource, action := ContextAction(ctx)
+			key := []string{prefix, resource, action}
+			startedAt := time.Now()
+			resp, err := next.RoundTrip(ctx, req)
+			goa.MeasureSince(key, startedAt)
+			if err != nil {
+				goa.IncrCounter(append(key, "error"), 1.0)
+				return resp, err
+			}
+			goa.IncrCounter(append(key, strconv.Itoa(resp.StatusCode)), 1.0)
+			return resp, nil
+		})
+	}
+}
+
+// HeaderMiddleware returns a middleware that sets the given headers on each request, replacing
+// any existing value.
+func HeaderMiddleware(headers http.Header) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			for n, vs := range headers {
+				req.Header.Del(n)
+				for _, v := range vs {
+					req.Header.Add(n, v)
+				}
+			}
+			return next.RoundTrip(ctx, req)
+		})
+	}
+}
+
+// TraceMiddleware returns a middleware that propagates the trace of the span stored in the request
+// context (see goa.ContextSpan), e.g. the span created by the service tracer middleware for the
+// request being handled. It starts a client span named after the resource and action, sets the
+// B3 headers of the request with it and finishes it once the response is received. Requests made
+// with contexts that hold no span are sent as is. The generated clients use it by default.
+func TraceMiddleware() Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			parent := goa.ContextSpan(ctx)
+			if parent == nil {
+				return next.RoundTrip(ctx, req)
+			}
+			resource, action := ContextAction(ctx)
+			span := parent.Child(resource+"."+action, "client")
+			span.SetTag("http.method", req.Method)
+			span.SetTag("http.url", req.URL.String())
+			span.Inject(req.Header)
+			resp, err := next.RoundTrip(goa.WithSpan(ctx, span), req)
+			if err != nil {
+				span.SetTag("error", err.Error())
+			} else {
+				span.SetTag("http.status_code", strconv.Itoa(resp.StatusCode))
+			}
+			span.Finish()
+			return resp, err
+		})
+	}
+}
+
+// RequestIDMiddleware returns a middleware that forwards the request ID recorded in the request
+// context (see WithRequestID) in the RequestIDHeader header so that the logs and errors of the
+// services handling a request share its ID. The header is left untouched if already set. The
+// generated clients use it by default.
+func RequestIDMiddleware() Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			if id := ContextRequestID(ctx); id != "" && req.Header.Get(RequestIDHeader) == "" {
+				req.Header.Set(RequestIDHeader, id)
+			}
+			return next.RoundTrip(ctx, req)
+		})
+	}
+}
+
+// roundTripper returns the round tripper that runs the client middleware around rt.
+func (c *Client) roundTripper(rt RoundTripper) RoundTripper {
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		rt = c.Middleware[i](rt)
+	}
+	return rt
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/spf13/cobra"
+)
+
+// OAuth2TokenSource obtains OAuth2 access tokens from a token endpoint as defined in RFC 6749 and
+// refreshes them before they expire. It uses the refresh token grant if RefreshToken is set and
+// the client credentials grant otherwise. The generated clients initialize a token source for
+// the OAuth2 security schemes that define a token URL, e.g.:
+//
+//	c := client.New(nil)
+//	c.OAuth2Signer.TokenSource.ClientID = "id"
+//	c.OAuth2Signer.TokenSource.ClientSecret = "secret"
+type OAuth2TokenSource struct {
+	// TokenURL is the URL of the token endpoint.
+	TokenURL string
+	// ClientID is the OAuth2 client identifier.
+	ClientID string
+	// ClientSecret is the OAuth2 client secret.
+	ClientSecret string
+	// Scopes lists the requested scopes.
+	Scopes []string
+	// RefreshToken is the refresh token used to obtain access tokens if not empty. It is
+	// replaced with the refresh token returned by the token endpoint if any.
+	RefreshToken string
+	// Client makes the token requests, http.DefaultClient is used if nil.
+	Client *http.Client
+	// ExpiryDelta is how long before their expiration tokens are refreshed, defaults to 10s.
+	ExpiryDelta time.Duration
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// defaultExpiryDelta is the default value of OAuth2TokenSource.ExpiryDelta.
+const defaultExpiryDelta = 10 * time.Second
+
+// Configured returns true if the token source has the credentials needed to obtain tokens.
+func (s *OAuth2TokenSource) Configured() bool {
+	return s.ClientID != "" || s.RefreshToken != ""
+}
+
+// Token returns a valid access token, requesting a new one from the token endpoint if needed.
+func (s *OAuth2TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delta := s.ExpiryDelta
+	if delta == 0 {
+		delta = defaultExpiryDelta
+	}
+	if s.accessToken != "" && (s.expiresAt.IsZero() || time.Now().Add(delta).Before(s.expiresAt)) {
+		return s.accessToken, nil
+	}
+	t, err := s.requestToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.accessToken = t.AccessToken
+	s.expiresAt = time.Time{}
+	if t.ExpiresIn > 0 {
+		s.expiresAt = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+	if t.RefreshToken != "" {
+		s.RefreshToken = t.RefreshToken
+	}
+	return s.accessToken, nil
+}
+
+// Invalidate discards the current access token so that the next call to Token requests a new one.
+func (s *OAuth2TokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessToken = ""
+}
+
+// requestToken makes the token request.
+func (s *OAuth2TokenSource) requestToken(ctx context.Context) (*OAuth2Token, error) {
+	form := url.Values{}
+	if s.RefreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", s.RefreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+	if len(s.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(s.ClientID), url.QueryEscape(s.ClientSecret))
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	id := shortID()
+	goa.LogInfo(ctx, "token", "id", id, "url", s.TokenURL, "grant", form.Get("grant_type"))
+	resp, err := client.Do(req)
+	if err != nil {
+		goa.LogError(ctx, "failed", "id", id, "err", err)
+		return nil, err
+	}
+	goa.LogInfo(ctx, "completed", "id", id, "status", resp.Status)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed with status %s: %s", resp.Status, body)
+	}
+	var t OAuth2Token
+	if err := json.Unmarshal(body, &t); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %s", err)
+	}
+	if t.AccessToken == "" {
+		return nil, fmt.Errorf("token response is missing the access token")
+	}
+	return &t, nil
+}
+
+// registerFlags adds the "--client-id", "--client-secret" and "--refresh-token" flags to the
+// client tool.
+func (s *OAuth2TokenSource) registerFlags(app *cobra.Command) {
+	app.Flags().StringVar(&s.ClientID, "client-id", s.ClientID, "OAuth2 client ID")
+	app.Flags().StringVar(&s.ClientSecret, "client-secret", s.ClientSecret, "OAuth2 client secret")
+	app.Flags().StringVar(&s.RefreshToken, "refresh-token", s.RefreshToken, "OAuth2 refresh token, the client credentials grant is used if empty")
+}
@@ -0,0 +1,115 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+
+	"github.com/goadesign/goa/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OAuth2TokenSource", func() {
+	var (
+		mu        sync.Mutex
+		forms     []url.Values
+		users     []string
+		expiresIn int
+		server    *httptest.Server
+		source    *client.OAuth2TokenSource
+	)
+
+	BeforeEach(func() {
+		forms = nil
+		users = nil
+		expiresIn = 3600
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			r.ParseForm()
+			forms = append(forms, r.PostForm)
+			user, _, _ := r.BasicAuth()
+			users = append(users, user)
+			n := len(forms)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"access%d","expires_in":%d,"refresh_token":"refresh%d"}`, n, expiresIn, n)
+		}))
+		source = &client.OAuth2TokenSource{TokenURL: server.URL, RefreshToken: "refresh0"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	token := func() string {
+		t, err := source.Token(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+		return t
+	}
+
+	It("refreshes the access token using the refresh token", func() {
+		Ω(token()).Should(Equal("access1"))
+		Ω(forms).Should(HaveLen(1))
+		Ω(forms[0].Get("grant_type")).Should(Equal("refresh_token"))
+		Ω(forms[0].Get("refresh_token")).Should(Equal("refresh0"))
+		Ω(source.RefreshToken).Should(Equal("refresh1"))
+	})
+
+	It("reuses valid access tokens", func() {
+		token()
+		Ω(token()).Should(Equal("access1"))
+		Ω(forms).Should(HaveLen(1))
+	})
+
+	It("refreshes access tokens that are about to expire", func() {
+		expiresIn = 5
+		token()
+		Ω(token()).Should(Equal("access2"))
+		Ω(forms[1].Get("refresh_token")).Should(Equal("refresh1"))
+	})
+
+	It("requests a new access token once invalidated", func() {
+		token()
+		source.Invalidate()
+		Ω(token()).Should(Equal("access2"))
+		Ω(forms).Should(HaveLen(2))
+		Ω(forms[1].Get("refresh_token")).Should(Equal("refresh1"))
+	})
+
+	It("uses the client credentials grant without refresh token", func() {
+		source.RefreshToken = ""
+		source.ClientID = "id"
+		source.ClientSecret = "secret"
+		source.Scopes = []string{"read", "write"}
+		token()
+		Ω(forms[0].Get("grant_type")).Should(Equal("client_credentials"))
+		Ω(forms[0].Get("scope")).Should(Equal("read write"))
+		Ω(users[0]).Should(Equal("id"))
+	})
+
+	Context("used by a OAuth2Signer", func() {
+		var signer *client.OAuth2Signer
+
+		BeforeEach(func() {
+			signer = &client.OAuth2Signer{TokenSource: source}
+		})
+
+		It("signs requests and obtains a new token once invalidated", func() {
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+			Ω(req.Header.Get("Authorization")).Should(Equal("Bearer access1"))
+			Ω(signer.Invalidate()).Should(BeTrue())
+			Ω(signer.Sign(context.Background(), req)).ShouldNot(HaveOccurred())
+			Ω(req.Header.Get("Authorization")).Should(Equal("Bearer access2"))
+		})
+
+		It("cannot be invalidated without credentials", func() {
+			source.RefreshToken = ""
+			Ω(signer.Invalidate()).Should(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,33 @@
+package client
+
+// Option sets optional request parameters stored in a value of type T. The generated clients
+// accept options for the optional query string parameters and headers of each action, for
+// example:
+//
+//	c.ListBottle(ctx, path, client.WithListBottleSort("name"), client.WithListBottleYears([]int{2016}))
+type Option[T any] func(*T)
+
+// ApplyOptions returns a value of type T initialized by applying opts in order.
+func ApplyOptions[T any](opts []Option[T]) *T {
+	v := new(T)
+	for _, o := range opts {
+		o(v)
+	}
+	return v
+}
+
+// Ptr returns a pointer to v. It makes it possible to initialize optional fields inline, e.g.:
+//
+//	params := &client.ListBottleParams{Sort: goaclient.Ptr("name")}
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// NonZero returns a pointer to v if v is not the zero value of its type, nil otherwise.
+func NonZero[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
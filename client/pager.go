@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type (
+	// PageFunc retrieves the page of items identified by cursor, the empty cursor identifying
+	// the first page. It returns the items of the page and the cursor of the next page, empty
+	// if the page is the last one.
+	PageFunc[T any] func(ctx context.Context, cursor string) (items []T, next string, err error)
+
+	// Pager iterates over the items returned by a paginated action, retrieving the pages as
+	// needed. The generated clients define a pager type for each paginated action, e.g.:
+	//
+	//	p := c.NewListBottlesPager(ctx, client.ListBottlesPath())
+	//	for p.Next() {
+	//		b := p.Value()
+	//		...
+	//	}
+	//	if err := p.Err(); err != nil {
+	//		...
+	//	}
+	Pager[T any] struct {
+		ctx     context.Context
+		fetch   PageFunc[T]
+		cursor  string
+		started bool
+		items   []T
+		item    T
+		err     error
+	}
+)
+
+// NewPager returns a pager that retrieves the pages using fetch and ctx.
+func NewPager[T any](ctx context.Context, fetch PageFunc[T]) *Pager[T] {
+	return &Pager[T]{ctx: ctx, fetch: fetch}
+}
+
+// Next advances the pager to the next item, retrieving the next page if needed. It returns false
+// when there are no more items or when retrieving a page fails, use Err to tell the two apart.
+func (p *Pager[T]) Next() bool {
+	for len(p.items) == 0 {
+		if p.err != nil || (p.started && p.cursor == "") {
+			return false
+		}
+		if err := p.ctx.Err(); err != nil {
+			p.err = err
+			return false
+		}
+		items, next, err := p.fetch(p.ctx, p.cursor)
+		if err != nil {
+			p.err = err
+			return false
+		}
+		if p.started && next != "" && next == p.cursor {
+			p.err = fmt.Errorf("pagination cursor %q did not advance", next)
+			return false
+		}
+		p.started = true
+		p.items, p.cursor = items, next
+	}
+	p.item, p.items = p.items[0], p.items[1:]
+	return true
+}
+
+// Value returns the current item, it must be called after Next returns true.
+func (p *Pager[T]) Value() T {
+	return p.item
+}
+
+// Err returns the error that stopped the iteration if any.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// Cursor returns the cursor of the next page to retrieve, empty if the pager retrieved the last
+// page.
+func (p *Pager[T]) Cursor() string {
+	return p.cursor
+}
+
+// NextLink returns the URL of the "next" link of the Link header of resp, resolved against the
+// request URL. It returns an empty string if the header does not define a valid "next" link.
+func NextLink(resp *http.Response) string {
+	for _, h := range resp.Header["Link"] {
+		for _, link := range strings.Split(h, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if len(target) < 2 || target[0] != '<' || target[len(target)-1] != '>' {
+				continue
+			}
+			for _, param := range parts[1:] {
+				k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(k, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(v, `"`)) {
+					if !strings.EqualFold(rel, "next") {
+						continue
+					}
+					if resp.Request == nil || resp.Request.URL == nil {
+						return target[1 : len(target)-1]
+					}
+					u, err := resp.Request.URL.Parse(target[1 : len(target)-1])
+					if err != nil {
+						return ""
+					}
+					return u.String()
+				}
+			}
+		}
+	}
+	return ""
+}
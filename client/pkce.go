@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa"
+	"github.com/spf13/cobra"
+)
+
+type (
+	// PKCE holds a Proof Key for Code Exchange (RFC 7636) code verifier and the code challenge
+	// derived from it.
+	PKCE struct {
+		// Verifier is the code verifier sent with the token request.
+		Verifier string
+		// Challenge is the code challenge sent with the authorization request.
+		Challenge string
+		// Method is the code challenge method, "S256" or "plain".
+		Method string
+	}
+
+	// OAuth2Endpoints contains the URLs of an OAuth2 authorization server.
+	OAuth2Endpoints struct {
+		// AuthorizationURL is the URL of the authorization endpoint.
+		AuthorizationURL string
+		// TokenURL is the URL of the token endpoint.
+		TokenURL string
+	}
+
+	// PKCELogin implements the OAuth2 authorization code flow with PKCE for command line tools.
+	// The tool prints the authorization URL, the user opens it in a browser and pastes the
+	// resulting authorization code back. The code is then exchanged for tokens.
+	PKCELogin struct {
+		// OAuth2Endpoints contains the default authorization server URLs.
+		OAuth2Endpoints
+		// Environments contains the authorization server URLs indexed by environment name.
+		Environments map[string]*OAuth2Endpoints
+		// Methods lists the code challenge methods supported by the authorization server in
+		// order of preference, defaults to "S256".
+		Methods []string
+		// ClientID is the OAuth2 client identifier.
+		ClientID string
+		// RedirectURL is the redirect URL registered with the client.
+		RedirectURL string
+		// Scopes lists the requested scopes.
+		Scopes []string
+		// Environment is the name of the environment whose URLs are used, the default URLs
+		// are used if empty.
+		Environment string
+	}
+
+	// OAuth2Token is the response of an OAuth2 token request.
+	OAuth2Token struct {
+		// AccessToken is the access token.
+		AccessToken string `json:"access_token"`
+		// TokenType is the type of the access token, e.g. "bearer".
+		TokenType string `json:"token_type,omitempty"`
+		// ExpiresIn is the lifetime in seconds of the access token.
+		ExpiresIn int `json:"expires_in,omitempty"`
+		// RefreshToken is the refresh token if any.
+		RefreshToken string `json:"refresh_token,omitempty"`
+		// Scope lists the granted scopes if different from the requested scopes.
+		Scope string `json:"scope,omitempty"`
+	}
+)
+
+// NewPKCE generates a random code verifier and computes its code challenge using the given
+// method: "S256" (the default) or "plain".
+func NewPKCE(method string) (*PKCE, error) {
+	if method == "" {
+		method = "S256"
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	challenge, err := CodeChallenge(verifier, method)
+	if err != nil {
+		return nil, err
+	}
+	return &PKCE{Verifier: verifier, Challenge: challenge, Method: method}, nil
+}
+
+// CodeChallenge computes the code challenge of the given code verifier using the given method:
+// "S256" or "plain".
+func CodeChallenge(verifier, method string) (string, error) {
+	switch method {
+	case "S256":
+		sum := sha256.Sum256([]byte(verifier))
+		return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+	case "plain":
+		return verifier, nil
+	default:
+		return "", fmt.Errorf("unsupported code challenge method %#v", method)
+	}
+}
+
+// Endpoints returns the authorization server URLs of the login environment.
+func (l *PKCELogin) Endpoints() (*OAuth2Endpoints, error) {
+	if l.Environment == "" {
+		return &l.OAuth2Endpoints, nil
+	}
+	e, ok := l.Environments[l.Environment]
+	if !ok {
+		names := make([]string, 0, len(l.Environments))
+		for n := range l.Environments {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown environment %#v, valid values are %s", l.Environment, strings.Join(names, ", "))
+	}
+	return e, nil
+}
+
+// AuthorizationURL returns the URL of the authorization request that uses the code challenge of
+// p and the given state.
+func (l *PKCELogin) AuthorizationURL(p *PKCE, state string) (string, error) {
+	e, err := l.Endpoints()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(e.AuthorizationURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", l.ClientID)
+	if l.RedirectURL != "" {
+		q.Set("redirect_uri", l.RedirectURL)
+	}
+	if len(l.Scopes) > 0 {
+		q.Set("scope", strings.Join(l.Scopes, " "))
+	}
+	q.Set("state", state)
+	q.Set("code_challenge", p.Challenge)
+	q.Set("code_challenge_method", p.Method)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Exchange exchanges the authorization code for tokens using the code verifier of p.
+func (l *PKCELogin) Exchange(ctx context.Context, code string, p *PKCE) (*OAuth2Token, error) {
+	e, err := l.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_id":     {l.ClientID},
+		"code_verifier": {p.Verifier},
+	}
+	if l.RedirectURL != "" {
+		form.Set("redirect_uri", l.RedirectURL)
+	}
+	req, err := http.NewRequest("POST", e.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	id := shortID()
+	goa.LogInfo(ctx, "token", "id", id, "url", e.TokenURL)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		goa.LogError(ctx, "failed", "id", id, "err", err)
+		return nil, err
+	}
+	goa.LogInfo(ctx, "completed", "id", id, "status", resp.Status)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed with status %s: %s", resp.Status, body)
+	}
+	var t OAuth2Token
+	if err := json.Unmarshal(body, &t); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %s", err)
+	}
+	return &t, nil
+}
+
+// Command returns the command that runs the login flow and prints the resulting tokens as JSON.
+// The refresh token may then be given to the OAuth2Signer "--refreshToken" flag.
+func (l *PKCELogin) Command(name string) *cobra.Command {
+	cc := &cobra.Command{
+		Use:   name,
+		Short: "Log in using the OAuth2 authorization code flow with PKCE",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			method := "S256"
+			if len(l.Methods) > 0 {
+				method = l.Methods[0]
+			}
+			p, err := NewPKCE(method)
+			if err != nil {
+				return err
+			}
+			state := shortID()
+			u, err := l.AuthorizationURL(p, state)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Open the following URL in a browser and authorize the application:\n\n%s\n\nAuthorization code: ", u)
+			code, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("failed to read authorization code: %s", err)
+			}
+			t, err := l.Exchange(context.Background(), strings.TrimSpace(code), p)
+			if err != nil {
+				return err
+			}
+			b, err := json.MarshalIndent(t, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		},
+	}
+	cc.Flags().StringVar(&l.ClientID, "client-id", l.ClientID, "OAuth2 client ID")
+	cc.Flags().StringVar(&l.RedirectURL, "redirect-url", l.RedirectURL, "OAuth2 redirect URL registered with the client")
+	cc.Flags().StringSliceVar(&l.Scopes, "scope", l.Scopes, "OAuth2 scopes to request")
+	if len(l.Environments) > 0 {
+		cc.Flags().StringVar(&l.Environment, "env", l.Environment, "Name of the environment of the authorization server")
+	}
+	return cc
+}
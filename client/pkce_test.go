@@ -0,0 +1,42 @@
+package client_test
+
+import (
+	"github.com/goadesign/goa/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PKCE", func() {
+	// Example verifier and challenge from RFC 7636 Appendix B.
+	const (
+		verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+
+	It("computes the S256 code challenge", func() {
+		c, err := client.CodeChallenge(verifier, "S256")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(c).Should(Equal(challenge))
+	})
+
+	It("computes the plain code challenge", func() {
+		c, err := client.CodeChallenge(verifier, "plain")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(c).Should(Equal(verifier))
+	})
+
+	It("rejects unknown methods", func() {
+		_, err := client.NewPKCE("S512")
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("generates verifiers with matching challenges", func() {
+		p, err := client.NewPKCE("")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(p.Method).Should(Equal("S256"))
+		Ω(p.Verifier).Should(HaveLen(43))
+		c, err := client.CodeChallenge(p.Verifier, "S256")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(p.Challenge).Should(Equal(c))
+	})
+})
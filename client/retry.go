@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// RetryPolicy configures how the client retries failed requests. A request is retried when
+	// the round trip fails or when the response status is one of RetryStatuses. Only requests
+	// made with an idempotent method (GET, HEAD, OPTIONS, TRACE, PUT and DELETE) are retried
+	// unless the request context was created with WithIdempotent or RetryAll is set.
+	RetryPolicy struct {
+		// MaxAttempts is the maximum number of attempts including the first one. Values
+		// lower than 2 disable retries.
+		MaxAttempts int
+		// InitialBackoff is the time waited before the first retry.
+		InitialBackoff time.Duration
+		// MaxBackoff caps the time waited between two attempts.
+		MaxBackoff time.Duration
+		// Multiplier is the factor applied to the backoff after each attempt.
+		Multiplier float64
+		// Jitter is the fraction of the backoff that is randomized, between 0 and 1. A
+		// jitter of 0.2 waits between 80% and 120% of the backoff.
+		Jitter float64
+		// RetryStatuses lists the response status codes that cause a retry.
+		RetryStatuses []int
+		// MaxRetryAfter is the maximum delay requested by a Retry-After response header that
+		// the client honors, responses asking to wait longer are returned as is. A value of 0
+		// means no maximum.
+		MaxRetryAfter time.Duration
+		// RetryAll makes the client retry all requests regardless of their method.
+		RetryAll bool
+	}
+
+	// retryKey is the private type used to store retry settings in contexts.
+	retryKey int
+)
+
+const (
+	idempotentKey retryKey = iota + 1
+	retryPolicyKey
+)
+
+// DefaultRetryPolicy returns a policy that makes up to 3 attempts with a jittered exponential
+// backoff starting at 100 milliseconds and retries on 429, 502, 503 and 504 responses.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryStatuses:  []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		MaxRetryAfter:  time.Minute,
+	}
+}
+
+// WithIdempotent returns a context that marks the request as idempotent so that the client
+// retries it whatever its method. The generated clients use it for the actions that declare the
+// "retry:idempotent" metadata.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey, true)
+}
+
+// WithRetryPolicy returns a context that makes the client use the given retry policy instead of
+// the client policy. A nil policy disables retries.
+func WithRetryPolicy(ctx context.Context, policy *RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey, policy)
+}
+
+// Backoff returns the time to wait before the given retry, 1 being the first retry.
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(mult, float64(retry-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(backoff)
+}
+
+// retryable returns true if the policy retries the given status code.
+func (p *RetryPolicy) retryable(status int) bool {
+	for _, s := range p.RetryStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// retryPolicy returns the retry policy that applies to the request, nil if the request must not
+// be retried.
+func (c *Client) retryPolicy(ctx context.Context, req *http.Request) *RetryPolicy {
+	policy := c.Retry
+	if p, ok := ctx.Value(retryPolicyKey).(*RetryPolicy); ok {
+		policy = p
+	}
+	if policy == nil || policy.MaxAttempts < 2 {
+		return nil
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body cannot be sent again.
+		return nil
+	}
+	if idempotent, _ := ctx.Value(idempotentKey).(bool); idempotent || policy.RetryAll {
+		return policy
+	}
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return policy
+	}
+	return nil
+}
+
+// doWithRetry makes the request using the underlying http client and retries it according to the
+// retry policy that applies to the request.
+func (c *Client) doWithRetry(ctx context.Context, req *http.Request, id string) (*http.Response, error) {
+	policy := c.retryPolicy(ctx, req)
+	if policy == nil {
+		return c.Client.Do(req)
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := c.Client.Do(req)
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+		wait := policy.Backoff(attempt)
+		if err == nil {
+			if !policy.retryable(resp.StatusCode) {
+				return resp, nil
+			}
+			if after, ok := retryAfter(resp); ok {
+				if policy.MaxRetryAfter > 0 && after > policy.MaxRetryAfter {
+					return resp, nil
+				}
+				if after > wait {
+					wait = after
+				}
+			}
+			// Drain the body so that the connection can be reused.
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			goa.LogInfo(ctx, "retrying", "id", id, "attempt", attempt, "status", resp.StatusCode, "wait", wait.String())
+		} else {
+			goa.LogInfo(ctx, "retrying", "id", id, "attempt", attempt, "err", err, "wait", wait.String())
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryAfter returns the delay requested by the response Retry-After header if any. The header
+// value is either a number of seconds or a HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
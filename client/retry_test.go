@@ -0,0 +1,134 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/goadesign/goa/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	Context("Backoff", func() {
+		var policy *client.RetryPolicy
+
+		BeforeEach(func() {
+			policy = &client.RetryPolicy{
+				InitialBackoff: 100 * time.Millisecond,
+				MaxBackoff:     time.Second,
+				Multiplier:     2,
+			}
+		})
+
+		It("grows exponentially", func() {
+			Ω(policy.Backoff(1)).Should(Equal(100 * time.Millisecond))
+			Ω(policy.Backoff(2)).Should(Equal(200 * time.Millisecond))
+			Ω(policy.Backoff(3)).Should(Equal(400 * time.Millisecond))
+		})
+
+		It("is capped by MaxBackoff", func() {
+			Ω(policy.Backoff(10)).Should(Equal(time.Second))
+		})
+
+		It("is jittered", func() {
+			policy.Jitter = 0.2
+			for i := 0; i < 100; i++ {
+				Ω(policy.Backoff(2)).Should(BeNumerically("~", 200*time.Millisecond, 40*time.Millisecond))
+			}
+		})
+	})
+
+	Context("with a failing server", func() {
+		var (
+			status     int
+			retryAfter string
+			requests   int32
+			server     *httptest.Server
+			c          *client.Client
+		)
+
+		BeforeEach(func() {
+			status = http.StatusServiceUnavailable
+			retryAfter = ""
+			atomic.StoreInt32(&requests, 0)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					if retryAfter != "" {
+						w.Header().Set("Retry-After", retryAfter)
+					}
+					w.WriteHeader(status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			c = client.New(nil)
+			c.Retry = &client.RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				Multiplier:     2,
+				RetryStatuses:  []int{http.StatusServiceUnavailable},
+				MaxRetryAfter:  10 * time.Second,
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		do := func(method string) (*http.Response, time.Duration) {
+			req, err := http.NewRequest(method, server.URL, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			start := time.Now()
+			resp, err := c.Do(context.Background(), req)
+			Ω(err).ShouldNot(HaveOccurred())
+			resp.Body.Close()
+			return resp, time.Since(start)
+		}
+
+		It("retries idempotent requests", func() {
+			resp, _ := do("GET")
+			Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+			Ω(atomic.LoadInt32(&requests)).Should(Equal(int32(2)))
+		})
+
+		It("does not retry other requests", func() {
+			resp, _ := do("POST")
+			Ω(resp.StatusCode).Should(Equal(http.StatusServiceUnavailable))
+			Ω(atomic.LoadInt32(&requests)).Should(Equal(int32(1)))
+		})
+
+		It("does not retry other statuses", func() {
+			status = http.StatusInternalServerError
+			resp, _ := do("GET")
+			Ω(resp.StatusCode).Should(Equal(http.StatusInternalServerError))
+			Ω(atomic.LoadInt32(&requests)).Should(Equal(int32(1)))
+		})
+
+		It("honors Retry-After given in seconds", func() {
+			retryAfter = "1"
+			resp, elapsed := do("GET")
+			Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+			Ω(elapsed).Should(BeNumerically(">=", time.Second))
+		})
+
+		It("honors Retry-After given as a HTTP date", func() {
+			retryAfter = time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat)
+			resp, elapsed := do("GET")
+			Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+			// HTTP dates have a one second precision.
+			Ω(elapsed).Should(BeNumerically(">=", time.Second))
+		})
+
+		It("returns the response when Retry-After exceeds MaxRetryAfter", func() {
+			retryAfter = "60"
+			resp, elapsed := do("GET")
+			Ω(resp.StatusCode).Should(Equal(http.StatusServiceUnavailable))
+			Ω(elapsed).Should(BeNumerically("<", time.Second))
+			Ω(atomic.LoadInt32(&requests)).Should(Equal(int32(1)))
+		})
+	})
+})
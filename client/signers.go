@@ -1,14 +1,13 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"time"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa"
 	"github.com/spf13/cobra"
 )
@@ -67,7 +66,10 @@ type (
 	// where the "expires_in" and "refresh_token" properties are optional and additional
 	// properties are ignored. If the response contains a "expires_in" property then the signer
 	// takes care of making refresh requests prior to the token expiration.
+	// The signer uses TokenSource instead when it is configured.
 	OAuth2Signer struct {
+		// TokenSource obtains and refreshes the access tokens if not nil and configured.
+		TokenSource *OAuth2TokenSource
 		// RefreshURLFormat is a format that generates the refresh access token URL given a
 		// refresh token.
 		RefreshURLFormat string
@@ -140,6 +142,17 @@ func (s *JWTSigner) RegisterFlags(app *cobra.Command) {
 
 // Sign refreshes the access token if needed and adds the OAuth header.
 func (s *OAuth2Signer) Sign(ctx context.Context, req *http.Request) error {
+	if s.TokenSource != nil && s.TokenSource.Configured() {
+		token, err := s.TokenSource.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to obtain OAuth token: %s", err)
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		return nil
+	}
+	if s.RefreshURLFormat == "" {
+		return nil
+	}
 	if s.expiresAt.Before(time.Now()) {
 		if err := s.Refresh(ctx); err != nil {
 			return fmt.Errorf("failed to refresh OAuth token: %s", err)
@@ -149,8 +162,23 @@ func (s *OAuth2Signer) Sign(ctx context.Context, req *http.Request) error {
 	return nil
 }
 
-// RegisterFlags adds the "--refreshURL" and "--refreshToken" flags to the client tool.
+// Invalidate discards the current access token so that the next request obtains a new one. It
+// returns false if the signer cannot obtain a new token.
+func (s *OAuth2Signer) Invalidate() bool {
+	if s.TokenSource != nil && s.TokenSource.Configured() {
+		s.TokenSource.Invalidate()
+		return true
+	}
+	s.expiresAt = time.Time{}
+	return s.RefreshURLFormat != ""
+}
+
+// RegisterFlags adds the "--refreshURL" and "--refreshToken" flags to the client tool as well as
+// the token source flags if the signer has a token source.
 func (s *OAuth2Signer) RegisterFlags(app *cobra.Command) {
+	if s.TokenSource != nil {
+		s.TokenSource.registerFlags(app)
+	}
 	app.Flags().StringVar(&s.RefreshURLFormat, "refreshURL", "", "OAuth2 refresh URL format, e.g. https://somewhere.com/token?grant_type=authorization_code&code=%s&client_id=xxx")
 	app.Flags().StringVar(&s.RefreshToken, "refreshToken", "", "OAuth2 refresh token or authorization code")
 }
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+type (
+	// Events iterates over the events of a server-sent events response, decoding the data of
+	// each event. The generated clients define an events type for each streaming action, e.g.:
+	//
+	//	events, err := c.WatchBottleStream(ctx, client.WatchBottlePath())
+	//	if err != nil {
+	//		...
+	//	}
+	//	defer events.Close()
+	//	for events.Next() {
+	//		b := events.Value()
+	//		...
+	//	}
+	//	if err := events.Err(); err != nil {
+	//		...
+	//	}
+	Events[T any] struct {
+		resp    *http.Response
+		scanner *bufio.Scanner
+		decode  func(data []byte) (T, error)
+		value   T
+		lastID  string
+		err     error
+	}
+
+	// Channel exchanges typed messages with a websocket action endpoint. The messages are
+	// encoded and decoded with JSON. The generated clients define a channel type for each
+	// websocket action.
+	Channel[S, R any] struct {
+		conn     *websocket.Conn
+		received chan R
+		cancel   context.CancelFunc
+		mu       sync.Mutex
+		err      error
+	}
+)
+
+// maxEventSize is the maximum size of a line of a server-sent events response.
+const maxEventSize = 1024 * 1024
+
+// NewEvents returns an iterator over the events of resp that decodes the event data with decode.
+// The iterator takes ownership of the response body.
+func NewEvents[T any](resp *http.Response, decode func(data []byte) (T, error)) *Events[T] {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 4096), maxEventSize)
+	return &Events[T]{resp: resp, scanner: scanner, decode: decode}
+}
+
+// Next reads and decodes the next event. It returns false when the stream ends or when reading
+// or decoding an event fails, use Err to tell the two apart.
+func (e *Events[T]) Next() bool {
+	if e.err != nil {
+		return false
+	}
+	var (
+		data    bytes.Buffer
+		hasData bool
+	)
+	for e.scanner.Scan() {
+		line := e.scanner.Text()
+		if line == "" {
+			if !hasData {
+				continue
+			}
+			v, err := e.decode(bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+			if err != nil {
+				e.err = err
+				return false
+			}
+			e.value = v
+			return true
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			e.lastID = value
+		}
+	}
+	e.err = e.scanner.Err()
+	return false
+}
+
+// Value returns the current event, it must be called after Next returns true.
+func (e *Events[T]) Value() T {
+	return e.value
+}
+
+// LastEventID returns the value of the last event ID received, it can be sent in the
+// Last-Event-ID header to resume the stream.
+func (e *Events[T]) LastEventID() string {
+	return e.lastID
+}
+
+// Err returns the error that stopped the iteration if any.
+func (e *Events[T]) Err() error {
+	return e.err
+}
+
+// Close closes the response body, ending the stream.
+func (e *Events[T]) Close() error {
+	return e.resp.Body.Close()
+}
+
+// NewChannel returns a channel that exchanges messages over conn. The connection is closed when
+// ctx is done or when Close is called.
+func NewChannel[S, R any](ctx context.Context, conn *websocket.Conn) *Channel[S, R] {
+	ctx, cancel := context.WithCancel(ctx)
+	c := &Channel[S, R]{conn: conn, received: make(chan R), cancel: cancel}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	go c.receive(ctx)
+	return c
+}
+
+// Send encodes and sends v.
+func (c *Channel[S, R]) Send(v S) error {
+	if err := websocket.JSON.Send(c.conn, v); err != nil {
+		c.fail(err)
+		return err
+	}
+	return nil
+}
+
+// Receive returns the channel of the decoded messages sent by the server. The channel is closed
+// when the connection ends, use Err to retrieve the error that caused it to end.
+func (c *Channel[S, R]) Receive() <-chan R {
+	return c.received
+}
+
+// Err returns the error that ended the connection if any.
+func (c *Channel[S, R]) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close closes the connection.
+func (c *Channel[S, R]) Close() error {
+	c.cancel()
+	return nil
+}
+
+// receive decodes the messages sent by the server until the connection ends.
+func (c *Channel[S, R]) receive(ctx context.Context) {
+	defer close(c.received)
+	for {
+		var v R
+		if err := websocket.JSON.Receive(c.conn, &v); err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				c.fail(err)
+			}
+			return
+		}
+		select {
+		case c.received <- v:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Channel[S, R]) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
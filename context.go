@@ -1,11 +1,11 @@
 package goa
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"strconv"
-
-	"golang.org/x/net/context"
+	"sync"
 )
 
 // Keys used to store data in context.
@@ -19,6 +19,9 @@ const (
 	logContextKey
 	errKey
 	securityScopesKey
+	timingsKey
+	spanKey
+	poolKey
 )
 
 type (
@@ -30,6 +33,13 @@ type (
 		Payload interface{}
 		// Params is the path and querystring request parameters.
 		Params url.Values
+		// Length is the number of request body bytes read so far.
+		Length int64
+		// Principal identifies the authenticated caller, e.g. the subject of the request JWT
+		// token. It is set by the security middleware so that the middleware that run before
+		// the security handlers, such as middleware.Audit, can retrieve it once the request
+		// is handled.
+		Principal string
 	}
 
 	// ResponseData provides access to the underlying HTTP response.
@@ -44,6 +54,13 @@ type (
 		Length int
 	}
 
+	// pooledData tracks whether the pooled request and response data of a request may be put
+	// back into the pools once the request is handled, see detachPooledData.
+	pooledData struct {
+		mu       sync.Mutex
+		detached bool
+	}
+
 	// key is the type used to store internal values in the context.
 	// Context provides typed accessor methods to these values.
 	key int
@@ -63,6 +80,51 @@ func NewContext(ctx context.Context, rw http.ResponseWriter, req *http.Request,
 	return ctx
 }
 
+// Pools of request and response data used by the controllers of services with PoolRequestData
+// set.
+var (
+	requestDataPool  = sync.Pool{New: func() interface{} { return new(RequestData) }}
+	responseDataPool = sync.Pool{New: func() interface{} { return new(ResponseData) }}
+)
+
+// newPooledContext is like NewContext but takes the request and response data from the pools.
+// It returns a function that resets the data and puts it back into the pools, the context must
+// not be used once it is called.
+func newPooledContext(ctx context.Context, rw http.ResponseWriter, req *http.Request, params url.Values) (context.Context, func()) {
+	request := requestDataPool.Get().(*RequestData)
+	request.Request, request.Params = req, params
+	response := responseDataPool.Get().(*ResponseData)
+	response.ResponseWriter = rw
+	pooled := &pooledData{}
+	ctx = context.WithValue(ctx, respKey, response)
+	ctx = context.WithValue(ctx, reqKey, request)
+	ctx = context.WithValue(ctx, poolKey, pooled)
+
+	return ctx, func() {
+		pooled.mu.Lock()
+		defer pooled.mu.Unlock()
+		if pooled.detached {
+			return
+		}
+		*request = RequestData{}
+		*response = ResponseData{}
+		requestDataPool.Put(request)
+		responseDataPool.Put(response)
+	}
+}
+
+// detachPooledData prevents the pooled request and response data of the context, if any, from
+// being reset and put back into the pools when the request completes. It is called when the data
+// may still be used by a goroutine that outlives the request, such as a timed out action. The
+// data is then left to the garbage collector.
+func detachPooledData(ctx context.Context) {
+	if pooled, ok := ctx.Value(poolKey).(*pooledData); ok {
+		pooled.mu.Lock()
+		defer pooled.mu.Unlock()
+		pooled.detached = true
+	}
+}
+
 // WithAction creates a context with the given action name.
 func WithAction(ctx context.Context, action string) context.Context {
 	return context.WithValue(ctx, actionKey, action)
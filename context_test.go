@@ -1,11 +1,10 @@
 package goa_test
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -5,12 +5,11 @@ see https://developer.mozilla.org/en-US/docs/Web/HTTP/Access_control_CORS.
 package cors
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"strings"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa"
 )
 
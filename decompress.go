@@ -0,0 +1,81 @@
+package goa
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decompressReader decompresses a request body and limits the length of the decompressed
+// content to protect against compression bombs.
+type decompressReader struct {
+	body     io.ReadCloser
+	encoding string
+	max      int64
+	r        io.Reader
+	n        int64
+}
+
+// decompressRequest replaces the body of requests whose Content-Encoding header is "gzip" or
+// "deflate" with a reader that decompresses it. The decompressed content may not exceed max bytes
+// unless max is 0. The Content-Encoding header is removed and the content length set to -1 so
+// that the decoders read the decompressed body as if it had been sent as is. decompressRequest
+// returns an ErrUnsupportedEncoding error if the request uses any other content coding.
+func decompressRequest(req *http.Request, max int64) error {
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip", "deflate":
+	default:
+		return ErrUnsupportedEncoding("unsupported content encoding %#v, must be one of gzip or deflate", encoding).
+			WithFields("encoding", encoding)
+	}
+	req.Body = &decompressReader{body: req.Body, encoding: encoding, max: max}
+	req.Header.Del("Content-Encoding")
+	req.ContentLength = -1
+	return nil
+}
+
+// Read reads the decompressed body. The decompressor is created on the first call so that errors
+// reading the compressed stream header are reported by the payload decoder.
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if d.r == nil {
+		r, err := d.decompressor()
+		if err != nil {
+			return 0, err
+		}
+		d.r = r
+	}
+	if d.max > 0 && int64(len(p)) > d.max-d.n+1 {
+		p = p[:d.max-d.n+1]
+	}
+	n, err := d.r.Read(p)
+	d.n += int64(n)
+	if d.max > 0 && d.n > d.max {
+		return n - int(d.n-d.max), ErrRequestBodyTooLarge("decompressed body length exceeds %d bytes", d.max)
+	}
+	return n, err
+}
+
+// Close closes the request body.
+func (d *decompressReader) Close() error {
+	return d.body.Close()
+}
+
+// decompressor returns the reader that decompresses the body. The "deflate" content coding is
+// defined as the zlib format but some clients send raw deflate streams, both are accepted.
+func (d *decompressReader) decompressor() (io.Reader, error) {
+	if d.encoding != "deflate" {
+		return gzip.NewReader(d.body)
+	}
+	br := bufio.NewReader(d.body)
+	if h, err := br.Peek(2); err == nil && h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/goadesign/goa/dslengine"
 )
@@ -85,31 +86,45 @@ var (
 	// KnownEncoders contains the list of encoding packages and factories known by goa indexed
 	// by MIME type.
 	KnownEncoders = map[string]string{
-		"application/json":      "github.com/goadesign/goa",
-		"application/xml":       "github.com/goadesign/goa",
-		"application/gob":       "github.com/goadesign/goa",
-		"application/x-gob":     "github.com/goadesign/goa",
-		"application/binc":      "github.com/goadesign/goa/encoding/binc",
-		"application/x-binc":    "github.com/goadesign/goa/encoding/binc",
-		"application/cbor":      "github.com/goadesign/goa/encoding/cbor",
-		"application/x-cbor":    "github.com/goadesign/goa/encoding/cbor",
-		"application/msgpack":   "github.com/goadesign/goa/encoding/msgpack",
-		"application/x-msgpack": "github.com/goadesign/goa/encoding/msgpack",
+		"application/json":                  "github.com/goadesign/goa",
+		"application/x-ndjson":              "github.com/goadesign/goa",
+		"application/xml":                   "github.com/goadesign/goa",
+		"application/gob":                   "github.com/goadesign/goa",
+		"application/x-gob":                 "github.com/goadesign/goa",
+		"application/binc":                  "github.com/goadesign/goa/encoding/binc",
+		"application/x-binc":                "github.com/goadesign/goa/encoding/binc",
+		"application/cbor":                  "github.com/goadesign/goa/encoding/cbor",
+		"application/x-cbor":                "github.com/goadesign/goa/encoding/cbor",
+		"application/msgpack":               "github.com/goadesign/goa/encoding/msgpack",
+		"application/x-msgpack":             "github.com/goadesign/goa/encoding/msgpack",
+		"application/x-protobuf":            "github.com/goadesign/goa/encoding/protobuf",
+		"application/x-www-form-urlencoded": "github.com/goadesign/goa/encoding/form",
 	}
 
 	// KnownEncoderFunctions contains the list of encoding encoder and decoder functions known
 	// by goa indexed by MIME type.
 	KnownEncoderFunctions = map[string][2]string{
-		"application/json":      {"NewJSONEncoder", "NewJSONDecoder"},
-		"application/xml":       {"NewXMLEncoder", "NewXMLDecoder"},
-		"application/gob":       {"NewGobEncoder", "NewGobDecoder"},
-		"application/x-gob":     {"NewGobEncoder", "NewGobDecoder"},
-		"application/binc":      {"NewEncoder", "NewDecoder"},
-		"application/x-binc":    {"NewEncoder", "NewDecoder"},
-		"application/cbor":      {"NewEncoder", "NewDecoder"},
-		"application/x-cbor":    {"NewEncoder", "NewDecoder"},
-		"application/msgpack":   {"NewEncoder", "NewDecoder"},
-		"application/x-msgpack": {"NewEncoder", "NewDecoder"},
+		"application/json":                  {"NewJSONEncoder", "NewJSONDecoder"},
+		"application/x-ndjson":              {"NewNDJSONEncoder", "NewNDJSONDecoder"},
+		"application/xml":                   {"NewXMLEncoder", "NewXMLDecoder"},
+		"application/gob":                   {"NewGobEncoder", "NewGobDecoder"},
+		"application/x-gob":                 {"NewGobEncoder", "NewGobDecoder"},
+		"application/binc":                  {"NewEncoder", "NewDecoder"},
+		"application/x-binc":                {"NewEncoder", "NewDecoder"},
+		"application/cbor":                  {"NewEncoder", "NewDecoder"},
+		"application/x-cbor":                {"NewEncoder", "NewDecoder"},
+		"application/msgpack":               {"NewEncoder", "NewDecoder"},
+		"application/x-msgpack":             {"NewEncoder", "NewDecoder"},
+		"application/x-protobuf":            {"NewEncoder", "NewDecoder"},
+		"application/x-www-form-urlencoded": {"NewEncoder", "NewDecoder"},
+	}
+
+	// structuredSyntaxSuffixes maps the structured syntax suffixes of MIME types to the known MIME
+	// types whose encoders handle them, see goa.StructuredSyntaxSuffixes.
+	structuredSyntaxSuffixes = map[string]string{
+		"json": "application/json",
+		"xml":  "application/xml",
+		"cbor": "application/cbor",
 	}
 
 	// JSONContentTypes list the Content-Type header values that cause goa to encode or decode
@@ -182,6 +197,51 @@ var (
 		AttributeDefinition: &AttributeDefinition{Type: errorMediaType},
 		Name:                "default",
 	}
+
+	// MaintenanceMediaIdentifier is the media type identifier used for the responses sent by
+	// resources in maintenance mode.
+	MaintenanceMediaIdentifier = "application/vnd.goa.maintenance+json"
+
+	// DefaultMaintenanceRetryAfter is the retry hint sent by resources in maintenance mode
+	// whose design does not specify one.
+	DefaultMaintenanceRetryAfter = 5 * time.Minute
+
+	// MaintenanceMedia is the built-in media type of the 503 responses sent by resources in
+	// maintenance mode, see ResourceDefinition.Maintenance.
+	MaintenanceMedia = &MediaTypeDefinition{
+		UserTypeDefinition: &UserTypeDefinition{
+			AttributeDefinition: &AttributeDefinition{
+				Type:        maintenanceMediaType,
+				Description: "Resource in maintenance response media type",
+				Validation:  &dslengine.ValidationDefinition{Required: []string{"message", "retry_after"}},
+				Example: map[string]interface{}{
+					"message":     "bottle is under maintenance",
+					"retry_after": 300,
+				},
+			},
+			TypeName: "Maintenance",
+		},
+		Identifier: MaintenanceMediaIdentifier,
+		Views:      map[string]*ViewDefinition{"default": maintenanceMediaView},
+	}
+
+	maintenanceMediaType = Object{
+		"message": &AttributeDefinition{
+			Type:        String,
+			Description: "a human-readable explanation of the unavailability.",
+			Example:     "bottle is under maintenance",
+		},
+		"retry_after": &AttributeDefinition{
+			Type:        Integer,
+			Description: "the number of seconds after which the request may be retried, also given in the Retry-After header.",
+			Example:     300,
+		},
+	}
+
+	maintenanceMediaView = &ViewDefinition{
+		AttributeDefinition: &AttributeDefinition{Type: maintenanceMediaType},
+		Name:                "default",
+	}
 )
 
 func init() {
@@ -197,6 +257,7 @@ func init() {
 		{MIMETypes: GobContentTypes, PackagePath: goa, Function: "NewGobDecoder"},
 	}
 	errorMediaView.Parent = ErrorMedia
+	maintenanceMediaView.Parent = MaintenanceMedia
 }
 
 // CanonicalIdentifier returns the media type identifier sans suffix
@@ -216,7 +277,26 @@ func CanonicalIdentifier(identifier string) string {
 // HasKnownEncoder returns true if the encoder for the given MIME type is known by goa.
 // MIME types with unknown encoders must be associated with a package path explicitly in the DSL.
 func HasKnownEncoder(mimeType string) bool {
-	return KnownEncoders[mimeType] != ""
+	return KnownMIMEType(mimeType) != ""
+}
+
+// KnownMIMEType returns the key of KnownEncoders and KnownEncoderFunctions for the given MIME type,
+// an empty string if the encoder of the MIME type is not known. The MIME type parameters such as
+// "charset" are ignored and MIME types with a structured syntax suffix, e.g.
+// "application/vnd.api+json", use the encoder of the suffix, e.g. "application/json".
+func KnownMIMEType(mimeType string) string {
+	if base, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = base
+	}
+	if _, ok := KnownEncoders[mimeType]; ok {
+		return mimeType
+	}
+	if i := strings.LastIndex(mimeType, "+"); i != -1 {
+		if mt, ok := structuredSyntaxSuffixes[mimeType[i+1:]]; ok {
+			return mt
+		}
+	}
+	return ""
 }
 
 // ExtractWildcards returns the names of the wildcards that appear in path.
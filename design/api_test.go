@@ -36,6 +36,26 @@ var _ = Describe("CanonicalIdentifier", func() {
 	})
 })
 
+var _ = Describe("KnownMIMEType", func() {
+	It("returns known MIME types", func() {
+		Ω(design.KnownMIMEType("application/json")).Should(Equal("application/json"))
+	})
+
+	It("ignores the MIME type parameters", func() {
+		Ω(design.KnownMIMEType("application/json; charset=utf-8")).Should(Equal("application/json"))
+	})
+
+	It("uses the encoder of the structured syntax suffix", func() {
+		Ω(design.KnownMIMEType("application/vnd.api+json")).Should(Equal("application/json"))
+		Ω(design.KnownMIMEType("application/atom+xml; charset=utf-8")).Should(Equal("application/xml"))
+	})
+
+	It("returns an empty string for unknown MIME types", func() {
+		Ω(design.KnownMIMEType("text/csv")).Should(BeEmpty())
+		Ω(design.KnownMIMEType("application/vnd.foo+yaml")).Should(BeEmpty())
+	})
+})
+
 var _ = Describe("ExtractWildcards", func() {
 	var path string
 	var wcs []string
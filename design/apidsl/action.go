@@ -2,6 +2,7 @@ package apidsl
 
 import (
 	"fmt"
+	"time"
 	"unicode"
 
 	"github.com/goadesign/goa/design"
@@ -124,6 +125,31 @@ func Routing(routes ...*design.RouteDefinition) {
 	}
 }
 
+// Timeout sets the maximum duration of the requests handled by the action. The value is a duration
+// string as accepted by time.ParseDuration, e.g. "5s" or "1m30s". The generated code cancels the
+// context of the requests that last longer and responds with a 504 "timeout" error, the response
+// written by the action, even partially, is discarded in this case. Example:
+//
+//	Action("export", func() {
+//		Routing(GET("/export"))
+//		Timeout("30s")
+//	})
+//
+func Timeout(d string) {
+	if a, ok := actionDefinition(); ok {
+		timeout, err := time.ParseDuration(d)
+		if err != nil {
+			dslengine.ReportError("invalid timeout %#v: %s", d, err)
+			return
+		}
+		if timeout <= 0 {
+			dslengine.ReportError("invalid timeout %#v: must be positive", d)
+			return
+		}
+		a.Timeout = timeout
+	}
+}
+
 // GET creates a route using the GET HTTP method.
 func GET(path string) *design.RouteDefinition {
 	return &design.RouteDefinition{Verb: "GET", Path: path}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strings"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
@@ -44,6 +45,11 @@ import (
 //			MaxAge(600)                          // How long to cache a prefligh request response
 //			Credentials()                        // Sets Access-Control-Allow-Credentials header
 //		})
+//		Feature("new_checkout", func() {	// Feature flag that requests may set
+//			Description("Redesigned checkout flow")
+//			Variants("control", "treatment")
+//		})
+//		Health()				// Liveness and readiness probe endpoints
 //		Consumes("application/xml") // Built-in encoders and decoders
 //		Consumes("application/json")
 //		Produces("application/gob")
@@ -115,6 +121,8 @@ func Description(d string) {
 		def.Description = d
 	case *design.SecuritySchemeDefinition:
 		def.Description = d
+	case *design.FeatureDefinition:
+		def.Description = d
 	default:
 		dslengine.IncompatibleDSL()
 	}
@@ -224,6 +232,77 @@ func Credentials() {
 	}
 }
 
+// Feature declares a feature flag that clients may set on requests via the X-Feature-Flags header
+// or the feature_flags cookie, see the middleware.FeatureFlags middleware. goagen generates typed
+// accessors for the declared flags so that controllers may branch on the values consistently.
+// The optional DSL may define a description and the list of variants of the flag:
+//
+//	Feature("new_checkout", func() {
+//		Description("Redesigned checkout flow")
+//		Variants("control", "treatment")
+//	})
+//
+func Feature(name string, dsl ...func()) {
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to Feature")
+		return
+	}
+	if a, ok := apiDefinition(); ok {
+		f := &design.FeatureDefinition{Parent: a, Name: name}
+		if len(dsl) == 1 {
+			if !dslengine.Execute(dsl[0], f) {
+				return
+			}
+		}
+		a.Features = append(a.Features, f)
+	}
+}
+
+// Health makes goagen generate the MountHealth function that mounts the liveness and readiness
+// probe endpoints. The endpoints run the checks registered with the service Health checker and
+// respond with their aggregated status as JSON, see goa.HealthChecker. The optional arguments set
+// the paths of the liveness and readiness endpoints, they default to "/healthz" and "/readyz".
+// The paths are not prefixed with the API base path. Example:
+//
+//	API("cellar", func() {
+//		Health()			// Mounts GET /healthz and GET /readyz
+//		Health("/live", "/ready")	// Mounts GET /live and GET /ready
+//	})
+//
+func Health(paths ...string) {
+	if len(paths) > 2 {
+		dslengine.ReportError("too many arguments given to Health")
+		return
+	}
+	if a, ok := apiDefinition(); ok {
+		h := &design.HealthDefinition{LivenessPath: "/healthz", ReadinessPath: "/readyz"}
+		if len(paths) > 0 {
+			h.LivenessPath = paths[0]
+		}
+		if len(paths) > 1 {
+			h.ReadinessPath = paths[1]
+		}
+		if h.LivenessPath == h.ReadinessPath {
+			dslengine.ReportError("liveness and readiness paths must differ, got %#v", h.LivenessPath)
+			return
+		}
+		for _, p := range []string{h.LivenessPath, h.ReadinessPath} {
+			if !strings.HasPrefix(p, "/") {
+				dslengine.ReportError("invalid health path %#v: must start with /", p)
+				return
+			}
+		}
+		a.Health = h
+	}
+}
+
+// Variants lists the possible values of a feature flag. Used in Feature DSL.
+func Variants(vals ...string) {
+	if f, ok := featureDefinition(); ok {
+		f.Variants = append(f.Variants, vals...)
+	}
+}
+
 // TermsOfService describes the API terms of services or links to them.
 func TermsOfService(terms string) {
 	if a, ok := apiDefinition(); ok {
@@ -330,6 +330,89 @@ var _ = Describe("API", func() {
 				Ω(Design.Traits).Should(HaveKey(traitName))
 			})
 		})
+
+		Context("with Features", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Feature("new_checkout", func() {
+						Description("Redesigned checkout flow")
+						Variants("control", "treatment")
+					})
+					Feature("dark_mode")
+				}
+			})
+
+			It("sets the API features in declaration order", func() {
+				Ω(Design.Features).Should(HaveLen(2))
+				Ω(Design.Features[0].Name).Should(Equal("new_checkout"))
+				Ω(Design.Features[0].Description).Should(Equal("Redesigned checkout flow"))
+				Ω(Design.Features[0].Variants).Should(Equal([]string{"control", "treatment"}))
+				Ω(Design.Features[1].Name).Should(Equal("dark_mode"))
+				Ω(Design.Features[1].Variants).Should(BeEmpty())
+			})
+		})
+
+		Context("with Health", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Health("/live")
+				}
+			})
+
+			It("sets the health check paths", func() {
+				Ω(Design.Health).ShouldNot(BeNil())
+				Ω(Design.Health.LivenessPath).Should(Equal("/live"))
+				Ω(Design.Health.ReadinessPath).Should(Equal("/readyz"))
+			})
+		})
+	})
+
+	Context("with a feature defined multiple times", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Feature("dark_mode")
+				Feature("dark_mode")
+			}
+		})
+
+		It("produces a validation error", func() {
+			Ω(Design.Validate()).Should(HaveOccurred())
+		})
+	})
+
+	Context("with features whose names generate the same Go identifier", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Feature("beta-ui")
+				Feature("beta_ui")
+			}
+		})
+
+		It("produces a validation error", func() {
+			err := Design.Validate()
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring(`feature "beta_ui" and feature "beta-ui" generate the same Go identifier`))
+		})
+	})
+
+	Context("with a feature variant generating the same Go identifier as a feature", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Feature("beta", func() {
+					Variants("ui", "api")
+				})
+				Feature("beta_ui")
+			}
+		})
+
+		It("produces a validation error", func() {
+			err := Design.Validate()
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring(`feature "beta_ui" and variant "ui" of feature "beta" generate the same Go identifier`))
+		})
 	})
 
 })
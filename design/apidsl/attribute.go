@@ -316,6 +316,7 @@ var SupportedValidationFormats = []string{
 	"date-time",
 	"email",
 	"hostname",
+	"hostport",
 	"ipv4",
 	"ipv6",
 	"mac",
@@ -342,6 +343,8 @@ var SupportedValidationFormats = []string{
 // "cidr": RFC4632 or RFC4291 CIDR notation IP address
 //
 // "regexp": RE2 regular expression
+//
+// "hostport": "host:port" network address where host is a RFC1123 host name or an IP address
 func Format(f string) {
 	if a, ok := attributeDefinition(); ok {
 		if a.Type != nil && a.Type.Kind() != design.StringKind {
@@ -112,6 +112,16 @@ func corsDefinition() (*design.CORSDefinition, bool) {
 	return cors, ok
 }
 
+// featureDefinition returns true and current context if it is a FeatureDefinition, nil and
+// false otherwise.
+func featureDefinition() (*design.FeatureDefinition, bool) {
+	f, ok := dslengine.CurrentDefinition().(*design.FeatureDefinition)
+	if !ok {
+		dslengine.IncompatibleDSL()
+	}
+	return f, ok
+}
+
 // actionDefinition returns true and current context if it is an ActionDefinition,
 // nil and false otherwise.
 func actionDefinition() (*design.ActionDefinition, bool) {
@@ -183,6 +183,27 @@ func TypeName(name string) {
 	}
 }
 
+// Retention sets the maximum period for which instances of the media type may be stored. period
+// is a positive number followed by a unit: "d" (days), "w" (weeks), "m" (months) or "y" (years).
+// goagen generates a map of the retention periods of the API media types indexed by identifier
+// together with a function that mounts a handler exposing the map, for use by compliance tooling.
+// Retention is a shorthand for Metadata("retention", period). Example:
+//
+//	var UserMedia = MediaType("application/vnd.user+json", func() {
+//		Retention("90d")
+//		Attributes(func() {
+//			Attribute("email")
+//		})
+//	})
+func Retention(period string) {
+	if mt, ok := mediaTypeDefinition(); ok {
+		if mt.Metadata == nil {
+			mt.Metadata = make(map[string][]string)
+		}
+		mt.Metadata["retention"] = []string{period}
+	}
+}
+
 // View adds a new view to a media type. A view has a name and lists attributes that are
 // rendered when the view is used to produce a response. The attribute names must appear in the
 // media type definition. If an attribute is itself a media type then the view may specify which
@@ -18,6 +18,13 @@ import (
 //
 //        Metadata("struct:field:name", "MyName")
 //
+// `struct:field:type`: generates the Go struct field with the given type instead of string. The
+// supported values are "net.IP" and "netip.Addr". Applicable to string attributes of payloads,
+// user types and media types that do not define a default value. Values are validated by the
+// type when decoded so format validations do not generate code for these fields.
+//
+//        Metadata("struct:field:type", "net.IP")
+//
 // `struct:tag:xxx`: sets the struct field tag xxx on generated Go structs.  Overrides tags that
 // goagen would otherwise set.  If the metadata value is a slice then the strings are joined with
 // the space character as separator.
@@ -26,6 +33,14 @@ import (
 //        Metadata("struct:tag:json", "myName,omitempty")
 //        Metadata("struct:tag:xml", "myName,attr")
 //
+// `proto:field:number`: sets the number of the Protocol Buffers message field generated for the
+// attribute by the proto generator. The fields that do not set a number are numbered after the
+// highest number in use in alphabetical order, setting numbers keeps the wire format compatible
+// when attributes are added or removed.
+// Applicable to attributes only.
+//
+//        Metadata("proto:field:number", "3")
+//
 // `swagger:tag:xxx`: sets the Swagger object field tag xxx.
 // Applicable to resources and actions.
 //
@@ -34,11 +49,139 @@ import (
 //        Metadata("swagger:tag:Backend:url", "http://example.com")
 //        Metadata("swagger:tag:Backend:url:desc", "See more docs here")
 //
+// `swagger:tag-order`: lists the Swagger tags in the order they are rendered, the tags that are not
+// listed follow in alphabetical order. The tags set on resources and actions are listed together
+// with the tags of the API.
+// Applicable to API definitions.
+//
+//        Metadata("swagger:tag-order", "Bottles", "Wineries")
+//
+// `swagger:tag-group:xxx`: defines the group of Swagger tags xxx, rendered with the x-tagGroups
+// vendor extension. `swagger:tag-group-order` lists the groups in the order they are rendered.
+// Applicable to API definitions.
+//
+//        Metadata("swagger:tag-group:Cellar", "Bottles", "Wineries")
+//        Metadata("swagger:tag-group-order", "Cellar", "Administration")
+//
+// `rename:proxy`: serves the requests made to the routes kept for renamed resources or actions
+// directly instead of redirecting them, see Renamed.
+// Applicable to resources and actions.
+//
+//        Metadata("rename:proxy")
+//
+// `stream:buffer`: generates helpers that stream the action responses through a bounded buffer
+// holding at most the given number of messages, see goa.Stream. `stream:policy` defines what
+// happens when the buffer is full: "block" (default), "drop_oldest" or "drop_newest".
+// `stream:watermark` sets the number of queued messages above which the stream reports being
+// congested. `stream:format` set to "ndjson" always streams newline delimited JSON, one message
+// per line, instead of negotiating the format with the Accept header ("auto", default).
+// Applicable to actions.
+//
+//        Metadata("stream:buffer", "64")
+//        Metadata("stream:policy", "drop_oldest")
+//        Metadata("stream:watermark", "48")
+//        Metadata("stream:format", "ndjson")
+//
+// `payload:stream`: generates an action context whose payload decodes the elements of the request
+// body array one at a time instead of loading the entire body in memory, see goa.PayloadStream.
+// The payload must be an array of user types, its MinLength and MaxLength validations bound the
+// number of elements. Applicable to actions.
+//
+//        Metadata("payload:stream")
+//
+// `retention`: sets the maximum period for which instances of the media type may be stored, see
+// Retention.
+// Applicable to media types.
+//
+//        Metadata("retention", "90d")
+//
+// `protobuf:message`: names the Go type of the Protocol Buffers message describing the media type,
+// typically generated by protoc from the output of "goagen proto". goagen generates the methods
+// converting the media type structs to and from the message so that the actions may also render
+// and accept "application/x-protobuf" bodies, see the encoding/protobuf package.
+// Applicable to media types.
+//
+//        Metadata("protobuf:message", "github.com/acme/cellar/pb.Bottle")
+//
+// `naming:resources`, `naming:actions` and `naming:attributes`: enable the naming rules checked
+// when the design is validated. "plural" requires resource names to be plural, "verb" requires
+// action names to start with a verb (additional values list verbs accepted on top of
+// design.DefaultActionVerbs) and "snake_case" requires attribute names to be snake_case. The
+// validation errors list the DSL edits that fix the violations when possible.
+// Applicable to API definitions.
+//
+//        Metadata("naming:resources", "plural")
+//        Metadata("naming:actions", "verb", "taste")
+//        Metadata("naming:attributes", "snake_case")
+//
+// `concurrency:limit`: limits the number of requests handled concurrently by the action using
+// the middleware.AdaptiveConcurrency middleware. The value is the initial limit, the limit then
+// adapts to the observed latency. `concurrency:max` sets the highest value the limit can grow to
+// and `concurrency:latency` sets the target latency above which the limit decreases.
+// Applicable to actions.
+//
+//        Metadata("concurrency:limit", "20")
+//        Metadata("concurrency:max", "200")
+//        Metadata("concurrency:latency", "250ms")
+//
+// `compress:skip`: sends the responses of the action uncompressed even if the service uses the
+// middleware.Compress middleware, e.g. for actions that stream events or serve archives.
+// Applicable to actions.
+//
+//        Metadata("compress:skip")
+//
+// `audit:include` and `audit:exclude`: include or exclude the requests made to the action from the
+// audit log recorded by the middleware.Audit middleware. By default the middleware audits all the
+// actions unless configured to only audit the actions that opt in with `audit:include`.
+// Applicable to actions.
+//
+//        Metadata("audit:exclude")
+//
+// `paginate:cursor`: declares the action as paginated, the generated Go client defines a pager
+// type (e.g. ListBottlesPager) that iterates over the items of all the pages. The value names
+// the optional string query string parameter that identifies the requested page and
+// `paginate:next` names the attribute of the response media type that holds the cursor of the
+// next page. Use `paginate:link` instead when the next page is given by the "next" link of the
+// Link response header. `paginate:items` names the response attribute that lists the items of
+// the page, it can be omitted when the response media type is a collection.
+// Applicable to actions.
+//
+//        Metadata("paginate:cursor", "page_token")
+//        Metadata("paginate:next", "next_page_token")
+//        Metadata("paginate:items", "bottles")
+//
+// `maintenance`: makes it possible to put the resource in maintenance mode at runtime, see
+// goa.Service.Maintenance. The actions of a resource in maintenance mode respond with a 503
+// Service Unavailable response whose body is described by the design.MaintenanceMedia media
+// type and whose Retry-After header tells clients when to retry. The optional value is the
+// retry hint used when the maintenance mode does not specify one, it defaults to
+// design.DefaultMaintenanceRetryAfter.
+// Applicable to resources.
+//
+//        Metadata("maintenance", "10m")
+//
 // `swagger:summary`: sets the Swagger operation summary field.
 // Applicable to actions.
 //
 //        Metadata("swagger:summary", "Short summary of what action does")
 //
+// `swagger:extension:x-xxx`: sets the Swagger vendor extension x-xxx. The value is rendered as
+// JSON if it is valid JSON and as a string otherwise, multiple values are rendered as an array.
+// Applicable to the API (Swagger object), resources (path items), actions (operations),
+// responses and attributes (parameters and schemas).
+//
+//        Metadata("swagger:extension:x-amazon-apigateway-integration", `{"type":"http_proxy"}`)
+//        Metadata("swagger:extension:x-kong-plugin-rate-limiting", `{"config":{"minute":10}}`)
+//
+// `convert:to`: lists the media types (identifiers or type names) for which goagen generates
+// functions that create media type instances from instances of the type, e.g.
+// BottlePayloadToBottleMedia. By default such functions are only generated for action payloads
+// that share attributes with the media type of a successful response of the action. Setting the
+// metadata without value disables the generation for the type.
+// Applicable to types and payloads.
+//
+//        Metadata("convert:to", "application/vnd.goa.example.bottle")
+//
 // The special key names listed above may be used as follows:
 //
 //        var Account = Type("Account", func() {
@@ -102,3 +245,51 @@ func Metadata(name string, value ...string) {
 		dslengine.IncompatibleDSL()
 	}
 }
+
+// SwaggerTag assigns the API, resource or action to the Swagger tag with the given name and
+// optional description. The tags of the API are rendered in the order SwaggerTag is called, before
+// the tags only used by resources and actions. SwaggerTag is a shorthand for the
+// "swagger:tag:xxx", "swagger:tag:xxx:desc" and "swagger:tag-order" metadata. Example:
+//
+//        var _ = API("cellar", func() {
+//                SwaggerTag("Bottles", "Manage the bottles of the cellar")
+//                SwaggerTag("Wineries", "Browse the wineries")
+//        })
+//
+//        var _ = Resource("bottle", func() {
+//                SwaggerTag("Bottles")
+//                Action("rate", func() {
+//                        SwaggerTag("Ratings", "Rate bottles")
+//                })
+//        })
+func SwaggerTag(name string, description ...string) {
+	switch dslengine.CurrentDefinition().(type) {
+	case *design.APIDefinition:
+		Metadata("swagger:tag-order", name)
+	case *design.ResourceDefinition, *design.ActionDefinition:
+	default:
+		dslengine.IncompatibleDSL()
+		return
+	}
+	Metadata("swagger:tag:" + name)
+	if len(description) > 0 {
+		Metadata("swagger:tag:"+name+":desc", description[0])
+	}
+}
+
+// SwaggerTagGroup groups the given Swagger tags under the given name. Documentation UIs that
+// support the x-tagGroups vendor extension render the groups in the order SwaggerTagGroup is
+// called. SwaggerTagGroup is a shorthand for the "swagger:tag-group:xxx" and
+// "swagger:tag-group-order" metadata and may only be used in the API definition. Example:
+//
+//        var _ = API("cellar", func() {
+//                SwaggerTagGroup("Cellar", "Bottles", "Wineries")
+//                SwaggerTagGroup("Administration", "Users")
+//        })
+func SwaggerTagGroup(name string, tags ...string) {
+	if _, ok := apiDefinition(); !ok {
+		return
+	}
+	Metadata("swagger:tag-group:"+name, tags...)
+	Metadata("swagger:tag-group-order", name)
+}
@@ -134,4 +134,41 @@ var _ = Describe("Metadata", func() {
 		})
 	})
 
+	Context("with Swagger tags", func() {
+		JustBeforeEach(func() {
+			api = API("Example API", func() {
+				SwaggerTag("Bottles", "Bottle operations")
+				SwaggerTag("Wineries")
+				SwaggerTagGroup("Cellar", "Bottles", "Wineries")
+			})
+
+			rd = Resource("Example Resource", func() {
+				SwaggerTag("Bottles")
+				Action("Example Action", func() {
+					Routing(GET("/"))
+					SwaggerTag("Ratings", "Rate bottles")
+				})
+			})
+
+			dslengine.Run()
+		})
+
+		It("sets the tag metadata", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(api.Metadata).To(Equal(dslengine.MetadataDefinition{
+				"swagger:tag:Bottles":      nil,
+				"swagger:tag:Bottles:desc": {"Bottle operations"},
+				"swagger:tag:Wineries":     nil,
+				"swagger:tag-order":        {"Bottles", "Wineries"},
+				"swagger:tag-group:Cellar": {"Bottles", "Wineries"},
+				"swagger:tag-group-order":  {"Cellar"},
+			}))
+			Ω(rd.Metadata).To(Equal(dslengine.MetadataDefinition{"swagger:tag:Bottles": nil}))
+			Ω(rd.Actions["Example Action"].Metadata).To(Equal(dslengine.MetadataDefinition{
+				"swagger:tag:Ratings":      nil,
+				"swagger:tag:Ratings:desc": {"Rate bottles"},
+			}))
+		})
+	})
+
 })
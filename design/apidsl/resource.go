@@ -111,3 +111,77 @@ func CanonicalActionName(a string) {
 		r.CanonicalActionName = a
 	}
 }
+
+// Renamed records the name of a resource or action prior to a rename. goagen keeps serving the
+// routes that were exposed under the old name: requests sent to the old paths are redirected to
+// the new paths with a 308 Permanent Redirect response (or served directly if the resource or
+// action defines the "rename:proxy" metadata) and the old routes appear as deprecated operations
+// in the generated Swagger specification.
+//
+// The optional second argument sets the resource base path or the action route path prior to the
+// rename. By default the old path is computed by replacing the path segments equal to the current
+// name with the old name.
+//
+//	Resource("wine", func() {
+//		BasePath("/wines")
+//		Renamed("bottle", "/bottles")	// Keep serving requests sent to "/bottles"
+//
+//		Action("taste", func() {
+//			Routing(POST("/:id/taste"))
+//			Renamed("rate")		// Keep serving requests sent to "/bottles/:id/rate"
+//		})
+//	})
+func Renamed(oldName string, oldPath ...string) {
+	if len(oldPath) > 1 {
+		dslengine.ReportError("too many arguments given to Renamed")
+		return
+	}
+	rename := &design.RenameDefinition{OldName: oldName}
+	if len(oldPath) == 1 {
+		rename.OldPath = oldPath[0]
+	}
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ResourceDefinition:
+		def.Renamed = rename
+	case *design.ActionDefinition:
+		def.Renamed = rename
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
+
+// UseMiddleware applies the middleware registered under the given names with Service.UseNamed to
+// all the actions of a resource or to a single action. The middleware run in the order they are
+// listed, the resource middleware first, after the security middleware so that they may rely on
+// the authenticated principal. The generated code fails the requests with a 500 error if no
+// middleware is registered under a name.
+//
+//	Resource("bottle", func() {
+//		UseMiddleware("audit")
+//
+//		Action("delete", func() {
+//			Routing(DELETE("/:id"))
+//			UseMiddleware("authz", "ratelimit")
+//		})
+//	})
+//
+// and in main:
+//
+//	service.UseNamed("audit", middleware.Audit(nil))
+//	service.UseNamed("authz", authz.Middleware())
+func UseMiddleware(names ...string) {
+	for _, n := range names {
+		if n == "" {
+			dslengine.ReportError("middleware name cannot be empty")
+			return
+		}
+	}
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ResourceDefinition:
+		def.Middleware = append(def.Middleware, names...)
+	case *design.ActionDefinition:
+		def.Middleware = append(def.Middleware, names...)
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
@@ -284,4 +284,24 @@ var _ = Describe("Resource", func() {
 			Ω(res.Description).Should(Equal(description))
 		})
 	})
+
+	Context("with named middleware", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				UseMiddleware("audit")
+				Action("delete", func() {
+					Routing(DELETE("/:id"))
+					UseMiddleware("authz", "ratelimit")
+				})
+			}
+		})
+
+		It("sets the middleware of the resource and actions", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(res.Middleware).Should(Equal([]string{"audit"}))
+			Ω(res.Actions).Should(HaveKey("delete"))
+			Ω(res.Actions["delete"].AllMiddleware()).Should(Equal([]string{"audit", "authz", "ratelimit"}))
+		})
+	})
 })
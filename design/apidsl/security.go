@@ -359,6 +359,61 @@ func ImplicitFlow(authorizationURL string) {
 	dslengine.IncompatibleDSL()
 }
 
+// PKCE enables Proof Key for Code Exchange (RFC 7636) for the "access code" OAuth2 flow. The
+// arguments list the supported code challenge methods in order of preference, "S256" or "plain",
+// and default to "S256". Use within an OAuth2Security definition that uses AccessCodeFlow.
+//
+// Example:
+//
+//    OAuth2Security("oauth2", func() {
+//        AccessCodeFlow("/authorization", "/token")
+//        PKCE("S256")
+//    })
+//
+func PKCE(methods ...string) {
+	if parent, ok := dslengine.CurrentDefinition().(*design.SecuritySchemeDefinition); ok {
+		if parent.Kind == design.OAuth2SecurityKind {
+			if len(methods) == 0 {
+				methods = []string{"S256"}
+			}
+			parent.CodeChallengeMethods = methods
+			return
+		}
+	}
+	dslengine.IncompatibleDSL()
+}
+
+// Environment defines the OAuth2 authorization and token URLs used in the deployment environment
+// with the given name, e.g. "staging". The URLs defined by the flow are used by default. Use
+// within an OAuth2Security definition.
+//
+// Example:
+//
+//    OAuth2Security("oauth2", func() {
+//        AccessCodeFlow("https://auth.example.com/authorize", "https://auth.example.com/token")
+//        Environment("staging", "https://auth.staging.example.com/authorize", "https://auth.staging.example.com/token")
+//    })
+//
+func Environment(name, authorizationURL, tokenURL string) {
+	if parent, ok := dslengine.CurrentDefinition().(*design.SecuritySchemeDefinition); ok {
+		if parent.Kind == design.OAuth2SecurityKind {
+			if parent.Environments == nil {
+				parent.Environments = make(map[string]*design.SecurityEnvironmentDefinition)
+			}
+			if _, ok := parent.Environments[name]; ok {
+				dslengine.ReportError("environment %#v defined twice", name)
+				return
+			}
+			parent.Environments[name] = &design.SecurityEnvironmentDefinition{
+				AuthorizationURL: authorizationURL,
+				TokenURL:         tokenURL,
+			}
+			return
+		}
+	}
+	dslengine.IncompatibleDSL()
+}
+
 // TokenURL defines a URL to get an access token.  If you are defining OAuth2 flows, use
 // `ImplicitFlow`, `PasswordFlow`, `AccessCodeFlow` or `ApplicationFlow` instead. This will set an
 // endpoint where you can obtain a JWT with the JWTSecurity scheme. The URL may be a complete URL
@@ -161,6 +161,57 @@ var _ = Describe("Security", func() {
 			Ω(scheme.Scopes["scope:2"]).Should(Equal("Desc 2"))
 		})
 
+		It("should define PKCE and environments", func() {
+			API("", func() {
+				OAuth2Security("googAuthz", func() {
+					AccessCodeFlow("https://example.com/auth", "https://example.com/token")
+					PKCE()
+					Environment("staging", "https://staging.example.com/auth", "https://staging.example.com/token")
+				})
+			})
+			dslengine.Run()
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			scheme := Design.SecuritySchemes[0]
+			Ω(scheme.CodeChallengeMethods).Should(Equal([]string{"S256"}))
+			Ω(scheme.Environments).Should(HaveKeyWithValue("staging", &SecurityEnvironmentDefinition{
+				AuthorizationURL: "https://staging.example.com/auth",
+				TokenURL:         "https://staging.example.com/token",
+			}))
+		})
+
+		It("should fail because of PKCE used without the access code flow", func() {
+			API("", func() {
+				OAuth2Security("googAuthz", func() {
+					ImplicitFlow("/auth")
+					PKCE("S256")
+				})
+			})
+			dslengine.Run()
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+
+		It("should fail because of an invalid code challenge method", func() {
+			API("", func() {
+				OAuth2Security("googAuthz", func() {
+					AccessCodeFlow("/auth", "/token")
+					PKCE("S512")
+				})
+			})
+			dslengine.Run()
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+
+		It("should fail because of a relative environment URL", func() {
+			API("", func() {
+				OAuth2Security("googAuthz", func() {
+					AccessCodeFlow("/auth", "/token")
+					Environment("staging", "/auth", "/token")
+				})
+			})
+			dslengine.Run()
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+
 		It("should fail because of invalid declaration of Header", func() {
 			API("", func() {
 				OAuth2Security("googAuthz", func() {
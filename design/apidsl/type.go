@@ -70,8 +70,16 @@ func Type(name string, dsl func()) *design.UserTypeDefinition {
 // If you are looking to return a collection of elements in a Response
 // clause, refer to CollectionOf.  ArrayOf creates a type, where
 // CollectionOf creates a media type.
-func ArrayOf(t design.DataType) *design.Array {
-	at := design.AttributeDefinition{Type: t}
+//
+// The element type may also be given by name: the name of a type or the identifier of a media type.
+// This makes it possible to define recursive types, the type must be defined by the time ArrayOf
+// is called:
+//
+//	var Node = Type("node", func() {
+//		Attribute("children", ArrayOf("node"))
+//	})
+func ArrayOf(t interface{}) *design.Array {
+	at := design.AttributeDefinition{Type: dataType("ArrayOf", t)}
 	return &design.Array{ElemType: &at}
 }
 
@@ -89,8 +97,30 @@ func ArrayOf(t design.DataType) *design.Array {
 //			Member("ratings", HashOf(String, Integer))  // Artificial examples...
 //			Member("bottles", RatedBottles)
 //	})
-func HashOf(k, v design.DataType) *design.Hash {
-	kat := design.AttributeDefinition{Type: k}
-	vat := design.AttributeDefinition{Type: v}
+//
+// As with ArrayOf the key and element types may also be given by name.
+func HashOf(k, v interface{}) *design.Hash {
+	kat := design.AttributeDefinition{Type: dataType("HashOf", k)}
+	vat := design.AttributeDefinition{Type: dataType("HashOf", v)}
 	return &design.Hash{KeyType: &kat, ElemType: &vat}
 }
+
+// dataType returns the data type t if it is one or the type or media type it names.
+func dataType(fn string, t interface{}) design.DataType {
+	switch actual := t.(type) {
+	case design.DataType:
+		return actual
+	case string:
+		if ut, ok := design.Design.Types[actual]; ok {
+			return ut
+		}
+		if mt := design.Design.MediaTypeWithIdentifier(actual); mt != nil {
+			return mt
+		}
+		dslengine.ReportError("invalid %s argument: %#v is not a known type name or media type identifier", fn, actual)
+	default:
+		dslengine.ReportError("invalid %s argument: must be a type or a type name, got %#v", fn, t)
+	}
+	// don't return nil to avoid panics, the error will get reported at the end
+	return design.String
+}
@@ -106,4 +106,37 @@ var _ = Describe("Type", func() {
 			Ω(o[attName].Type).Should(Equal(DateTime))
 		})
 	})
+
+	Context("with a recursive array attribute", func() {
+		const attName = "children"
+		BeforeEach(func() {
+			name = "node"
+			dsl = func() {
+				Attribute(attName, ArrayOf("node"))
+				Attribute("index", HashOf(String, "node"))
+			}
+		})
+
+		It("looks up the element type by name", func() {
+			Ω(dslengine.Errors).Should(BeEmpty())
+			o := ut.Type.(Object)
+			Ω(o[attName].Type).Should(BeAssignableToTypeOf(&Array{}))
+			Ω(o[attName].Type.(*Array).ElemType.Type).Should(Equal(ut))
+			Ω(o["index"].Type.(*Hash).ElemType.Type).Should(Equal(ut))
+		})
+	})
+
+	Context("with an unknown array element type name", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Attribute("att", ArrayOf("unknown"))
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"unknown" is not a known type name or media type identifier`))
+		})
+	})
 })
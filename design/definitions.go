@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dimfeld/httppath"
 	"github.com/goadesign/goa/dslengine"
@@ -71,6 +73,11 @@ type (
 		// resources and actions, unless overridden by Resource or
 		// Action-level Security() calls.
 		Security *SecurityDefinition
+		// Features lists the feature flags that may be set on requests in the order they
+		// were declared.
+		Features []*FeatureDefinition
+		// Health defines the paths of the health check endpoints if any, see HealthDefinition.
+		Health *HealthDefinition
 
 		// rand is the random generator used to generate examples.
 		rand *RandomGenerator
@@ -141,6 +148,11 @@ type (
 		// Security defines security requirements for the Resource,
 		// for actions that don't define one themselves.
 		Security *SecurityDefinition
+		// Renamed records the previous name and base path of the resource if any.
+		Renamed *RenameDefinition
+		// Middleware lists the names of the middleware applied to all the actions of the
+		// resource, see UseMiddleware.
+		Middleware []string
 	}
 
 	// CORSDefinition contains the definition for a specific origin CORS policy.
@@ -161,6 +173,18 @@ type (
 		Credentials bool
 	}
 
+	// FeatureDefinition describes a feature flag that clients may set on requests.
+	FeatureDefinition struct {
+		// Parent API
+		Parent *APIDefinition
+		// Name of the flag as it appears in requests
+		Name string
+		// Description of the feature
+		Description string
+		// Variants lists the possible values of the flag if any
+		Variants []string
+	}
+
 	// EncodingDefinition defines an encoder supported by the API.
 	EncodingDefinition struct {
 		// MIMETypes is the set of possible MIME types for the content being encoded or decoded.
@@ -244,6 +268,14 @@ type (
 		Metadata dslengine.MetadataDefinition
 		// Security defines security requirements for the action
 		Security *SecurityDefinition
+		// Renamed records the previous name and path of the action if any.
+		Renamed *RenameDefinition
+		// Timeout is the maximum duration of the requests handled by the action, zero if
+		// there is none.
+		Timeout time.Duration
+		// Middleware lists the names of the middleware applied to the action in addition to
+		// the resource middleware, see UseMiddleware.
+		Middleware []string
 	}
 
 	// FileServerDefinition defines an endpoint that servers static assets.
@@ -300,6 +332,81 @@ type (
 		Parent *ActionDefinition
 	}
 
+	// RenameDefinition records the name and path of a resource or action prior to a rename.
+	RenameDefinition struct {
+		// OldName is the name prior to the rename.
+		OldName string
+		// OldPath is the resource base path or the action route path prior to the rename.
+		// If empty the old path is computed by replacing the path segments equal to the
+		// current name with OldName.
+		OldPath string
+	}
+
+	// AliasRouteDefinition describes a route kept after a resource or action rename so that
+	// requests sent to the old path keep being served.
+	AliasRouteDefinition struct {
+		// Route is the route prior to the rename, it is always absolute.
+		Route *RouteDefinition
+		// Target is the current route.
+		Target *RouteDefinition
+		// Proxy is true if requests made to Route are handled directly rather than
+		// redirected to Target.
+		Proxy bool
+	}
+
+	// StreamDefinition describes the flow control of the responses of a streaming action.
+	StreamDefinition struct {
+		// BufferSize is the maximum number of messages queued for a client.
+		BufferSize int
+		// HighWatermark is the number of queued messages above which a stream is
+		// considered congested, 0 means BufferSize.
+		HighWatermark int
+		// Policy is one of "block", "drop_oldest" or "drop_newest".
+		Policy string
+		// NDJSON is true if the responses are always streamed as newline delimited JSON,
+		// false if the format depends on the request Accept header.
+		NDJSON bool
+	}
+
+	// ConcurrencyDefinition describes the adaptive concurrency limit of an action.
+	ConcurrencyDefinition struct {
+		// InitialLimit is the number of concurrent requests allowed initially.
+		InitialLimit int
+		// MaxLimit is the highest value the limit can increase to, 0 means the default.
+		MaxLimit int
+		// Latency is the target latency, 0 means the default.
+		Latency time.Duration
+	}
+
+	// PaginationDefinition describes how the clients iterate over the pages of results returned
+	// by a paginated action.
+	PaginationDefinition struct {
+		// Cursor is the name of the optional query string parameter that identifies the
+		// requested page, empty if the pages are linked with the Link response header.
+		Cursor string
+		// Next is the name of the response media type attribute that holds the cursor of
+		// the next page.
+		Next string
+		// Items is the name of the response media type attribute that lists the items of
+		// the page, empty if the response media type is a collection.
+		Items string
+	}
+
+	// MaintenanceDefinition describes the maintenance mode of a resource.
+	MaintenanceDefinition struct {
+		// RetryAfter is the retry hint sent to clients when the maintenance mode does not
+		// specify one.
+		RetryAfter time.Duration
+	}
+
+	// HealthDefinition describes the health check endpoints mounted by the generated code.
+	HealthDefinition struct {
+		// LivenessPath is the path of the liveness probe endpoint.
+		LivenessPath string
+		// ReadinessPath is the path of the readiness probe endpoint.
+		ReadinessPath string
+	}
+
 	// AttributeDefinition defines a JSON object member with optional description, default
 	// value and validations.
 	AttributeDefinition struct {
@@ -734,6 +841,12 @@ func (r *ResourceDefinition) URITemplate() string {
 // FullPath computes the base path to the resource actions concatenating the API and parent resource
 // base paths as needed.
 func (r *ResourceDefinition) FullPath() string {
+	return r.fullPathWith(r.BasePath)
+}
+
+// fullPathWith computes the resource full path using the given base path in place of the resource
+// base path.
+func (r *ResourceDefinition) fullPathWith(base string) string {
 	var basePath string
 	if p := r.Parent(); p != nil {
 		if ca := p.CanonicalAction(); ca != nil {
@@ -747,7 +860,7 @@ func (r *ResourceDefinition) FullPath() string {
 	} else {
 		basePath = Design.BasePath
 	}
-	return httppath.Clean(path.Join(basePath, r.BasePath))
+	return httppath.Clean(path.Join(basePath, base))
 }
 
 // Parent returns the parent resource if any, nil otherwise.
@@ -818,6 +931,9 @@ func (r *ResourceDefinition) DSL() func() {
 // parameters, initializes querystring parameters, sets path parameters as non zero attributes
 // and sets the fallbacks for security schemes.
 func (r *ResourceDefinition) Finalize() {
+	if r.Maintenance() != nil {
+		r.initMaintenanceResponses()
+	}
 	r.IterateFileServers(func(f *FileServerDefinition) error {
 		f.Finalize()
 		return nil
@@ -828,6 +944,46 @@ func (r *ResourceDefinition) Finalize() {
 	})
 }
 
+// Maintenance returns the maintenance mode settings of the resource if it defines the
+// "maintenance" metadata, nil otherwise.
+func (r *ResourceDefinition) Maintenance() *MaintenanceDefinition {
+	m, ok := r.Metadata["maintenance"]
+	if !ok {
+		return nil
+	}
+	md := &MaintenanceDefinition{RetryAfter: DefaultMaintenanceRetryAfter}
+	if len(m) > 0 {
+		md.RetryAfter, _ = time.ParseDuration(m[0])
+	}
+	return md
+}
+
+// initMaintenanceResponses adds the ServiceUnavailable response sent when the resource is in
+// maintenance mode to the resource actions that do not define one and records the maintenance
+// media type in the API media types.
+func (r *ResourceDefinition) initMaintenanceResponses() {
+	if Design.MediaTypes == nil {
+		Design.MediaTypes = make(map[string]*MediaTypeDefinition)
+	}
+	Design.MediaTypes[CanonicalIdentifier(MaintenanceMediaIdentifier)] = MaintenanceMedia
+	r.IterateActions(func(a *ActionDefinition) error {
+		if _, ok := a.Responses[ServiceUnavailable]; ok {
+			return nil
+		}
+		if a.Responses == nil {
+			a.Responses = make(map[string]*ResponseDefinition)
+		}
+		a.Responses[ServiceUnavailable] = &ResponseDefinition{
+			Name:        ServiceUnavailable,
+			Status:      503,
+			Description: fmt.Sprintf("%s is under maintenance", r.Name),
+			MediaType:   MaintenanceMediaIdentifier,
+			Parent:      a,
+		}
+		return nil
+	})
+}
+
 // UserTypes returns all the user types used by the resource action payloads and parameters.
 func (r *ResourceDefinition) UserTypes() map[string]*UserTypeDefinition {
 	types := make(map[string]*UserTypeDefinition)
@@ -847,6 +1003,11 @@ func (cors *CORSDefinition) Context() string {
 	return fmt.Sprintf("CORS policy for resource %s origin %s", cors.Parent.Context(), cors.Origin)
 }
 
+// Context returns the generic definition name used in error messages.
+func (f *FeatureDefinition) Context() string {
+	return fmt.Sprintf("feature %#v", f.Name)
+}
+
 // Context returns the generic definition name used in error messages.
 func (enc *EncodingDefinition) Context() string {
 	return fmt.Sprintf("encoding for %s", strings.Join(enc.MIMETypes, ", "))
@@ -957,6 +1118,23 @@ func (a *AttributeDefinition) IsPrimitivePointer(attName string) bool {
 	return false
 }
 
+// maxProtoFieldNumber is the largest Protocol Buffers field number.
+const maxProtoFieldNumber = 1<<29 - 1
+
+// ProtoFieldNumber returns the Protocol Buffers field number set with the "proto:field:number"
+// metadata, 0 if the metadata is not set or is invalid.
+func (a *AttributeDefinition) ProtoFieldNumber() int {
+	n := a.Metadata["proto:field:number"]
+	if len(n) != 1 {
+		return 0
+	}
+	num, err := strconv.Atoi(n[0])
+	if err != nil || num < 1 || num > maxProtoFieldNumber || (num >= 19000 && num <= 19999) {
+		return 0
+	}
+	return num
+}
+
 // GenerateExample returns a random instance of the attribute that validates.
 func (a *AttributeDefinition) GenerateExample(r *RandomGenerator) interface{} {
 	if example := newExampleGenerator(a, r).generate(); example != nil {
@@ -1251,6 +1429,25 @@ func (r *ResponseDefinition) Merge(other *ResponseDefinition) {
 	}
 }
 
+// CacheControl returns the value of the Cache-Control header declared by the response and
+// whether the response declares the header at all. The value is the default value of the header
+// attribute if any.
+func (r *ResponseDefinition) CacheControl() (string, bool) {
+	if r.Headers == nil {
+		return "", false
+	}
+	for n, h := range r.Headers.Type.ToObject() {
+		if !strings.EqualFold(n, "Cache-Control") {
+			continue
+		}
+		if v, ok := h.DefaultValue.(string); ok {
+			return v, true
+		}
+		return "", true
+	}
+	return "", false
+}
+
 // Context returns the generic definition name used in error messages.
 func (r *ResponseTemplateDefinition) Context() string {
 	if r.Name != "" {
@@ -1454,6 +1651,191 @@ func (a *ActionDefinition) IterateResponses(it ResponseIterator) error {
 	return nil
 }
 
+// CacheControl returns the value of the Cache-Control header declared by the first successful
+// (2xx) response of the action that declares one, responses being iterated over in alphabetical
+// order. The second return value is false if no successful response declares the header.
+func (a *ActionDefinition) CacheControl() (string, bool) {
+	var (
+		value string
+		found bool
+	)
+	a.IterateResponses(func(r *ResponseDefinition) error {
+		if found || r.Status < 200 || r.Status >= 300 {
+			return nil
+		}
+		value, found = r.CacheControl()
+		return nil
+	})
+	return value, found
+}
+
+// AliasRoutes returns the routes that the action used to be exposed under prior to the rename of
+// the action or of its parent resource, nil if neither were renamed. Proxy is set on the aliases if
+// the action or its resource defines the "rename:proxy" metadata.
+func (a *ActionDefinition) AliasRoutes() []*AliasRouteDefinition {
+	res := a.Parent
+	if a.Renamed == nil && (res == nil || res.Renamed == nil) {
+		return nil
+	}
+	_, proxy := a.Metadata["rename:proxy"]
+	if res != nil && !proxy {
+		_, proxy = res.Metadata["rename:proxy"]
+	}
+	seen := make(map[string]bool)
+	for _, r := range a.Routes {
+		seen[r.Verb+" "+r.FullPath()] = true
+	}
+	var aliases []*AliasRouteDefinition
+	for _, r := range a.Routes {
+		paths := []string{r.Path}
+		if a.Renamed != nil {
+			paths = append(paths, a.Renamed.oldPath(r.Path, a.Name))
+		}
+		var bases []string
+		if res != nil {
+			bases = append(bases, res.FullPath())
+			if res.Renamed != nil {
+				bases = append(bases, res.fullPathWith(res.Renamed.oldPath(res.BasePath, res.Name)))
+			}
+		}
+		for _, base := range bases {
+			for _, p := range paths {
+				var full string
+				if strings.HasPrefix(p, "//") {
+					full = httppath.Clean(p[1:])
+				} else {
+					full = httppath.Clean(path.Join(base, p))
+				}
+				if seen[r.Verb+" "+full] {
+					continue
+				}
+				seen[r.Verb+" "+full] = true
+				aliases = append(aliases, &AliasRouteDefinition{
+					Route:  &RouteDefinition{Verb: r.Verb, Path: "/" + full, Parent: a},
+					Target: r,
+					Proxy:  proxy,
+				})
+			}
+		}
+	}
+	return aliases
+}
+
+// Stream returns the flow control settings of the action responses if the action streams its
+// responses, that is if it defines the "stream:buffer" metadata, nil otherwise.
+func (a *ActionDefinition) Stream() *StreamDefinition {
+	buf, ok := a.Metadata["stream:buffer"]
+	if !ok {
+		return nil
+	}
+	stream := &StreamDefinition{Policy: "block"}
+	if len(buf) > 0 {
+		stream.BufferSize, _ = strconv.Atoi(buf[0])
+	}
+	if wm := a.Metadata["stream:watermark"]; len(wm) > 0 {
+		stream.HighWatermark, _ = strconv.Atoi(wm[0])
+	}
+	if p := a.Metadata["stream:policy"]; len(p) > 0 {
+		stream.Policy = p[0]
+	}
+	if f := a.Metadata["stream:format"]; len(f) > 0 {
+		stream.NDJSON = f[0] == "ndjson"
+	}
+	return stream
+}
+
+// StreamPayload returns true if the action defines the "payload:stream" metadata. The generated
+// code of such actions does not decode the request body up front, instead the action context
+// payload decodes the elements of the array one at a time.
+func (a *ActionDefinition) StreamPayload() bool {
+	_, ok := a.Metadata["payload:stream"]
+	return ok
+}
+
+// Concurrency returns the adaptive concurrency limit settings of the action if it defines the
+// "concurrency:limit" metadata, nil otherwise.
+func (a *ActionDefinition) Concurrency() *ConcurrencyDefinition {
+	l, ok := a.Metadata["concurrency:limit"]
+	if !ok {
+		return nil
+	}
+	c := &ConcurrencyDefinition{}
+	if len(l) > 0 {
+		c.InitialLimit, _ = strconv.Atoi(l[0])
+	}
+	if m := a.Metadata["concurrency:max"]; len(m) > 0 {
+		c.MaxLimit, _ = strconv.Atoi(m[0])
+	}
+	if lat := a.Metadata["concurrency:latency"]; len(lat) > 0 {
+		c.Latency, _ = time.ParseDuration(lat[0])
+	}
+	return c
+}
+
+// SkipCompression returns true if the action defines the "compress:skip" metadata. The generated
+// code exempts the responses of such actions from the middleware.Compress middleware.
+func (a *ActionDefinition) SkipCompression() bool {
+	_, ok := a.Metadata["compress:skip"]
+	return ok
+}
+
+// AuditInclude returns true if the action defines the "audit:include" metadata. The generated code
+// records the requests made to such actions in the middleware.Audit audit log even if the
+// middleware only audits the actions that opt in.
+func (a *ActionDefinition) AuditInclude() bool {
+	_, ok := a.Metadata["audit:include"]
+	return ok
+}
+
+// AuditExclude returns true if the action defines the "audit:exclude" metadata. The generated code
+// excludes the requests made to such actions from the middleware.Audit audit log.
+func (a *ActionDefinition) AuditExclude() bool {
+	_, ok := a.Metadata["audit:exclude"]
+	return ok
+}
+
+// AllMiddleware returns the names of the middleware applied to the action in the order they run:
+// the parent resource middleware followed by the action middleware.
+func (a *ActionDefinition) AllMiddleware() []string {
+	var names []string
+	if a.Parent != nil {
+		names = append(names, a.Parent.Middleware...)
+	}
+	return append(names, a.Middleware...)
+}
+
+// Idempotent returns true if the action declares the "retry:idempotent" metadata. The generated
+// clients retry the requests of idempotent actions even if their method is not idempotent, e.g.
+// POST requests that carry an idempotency key.
+func (a *ActionDefinition) Idempotent() bool {
+	_, ok := a.Metadata["retry:idempotent"]
+	return ok
+}
+
+// Pagination returns the pagination settings of the action if it defines the "paginate:cursor"
+// or "paginate:link" metadata, nil otherwise. "paginate:cursor" names the query string parameter
+// that identifies the requested page and "paginate:next" the response attribute that holds the
+// cursor of the next page. "paginate:link" means the next page is given by the "next" link of
+// the Link response header instead. "paginate:items" optionally names the response attribute
+// that lists the items of the page.
+func (a *ActionDefinition) Pagination() *PaginationDefinition {
+	cursor, ok := a.Metadata["paginate:cursor"]
+	if _, link := a.Metadata["paginate:link"]; !ok && !link {
+		return nil
+	}
+	p := &PaginationDefinition{}
+	if len(cursor) > 0 {
+		p.Cursor = cursor[0]
+	}
+	if n := a.Metadata["paginate:next"]; len(n) > 0 {
+		p.Next = n[0]
+	}
+	if i := a.Metadata["paginate:items"]; len(i) > 0 {
+		p.Items = i[0]
+	}
+	return p
+}
+
 // mergeResponses merges the parent resource and design responses.
 func (a *ActionDefinition) mergeResponses() {
 	for name, resp := range a.Responses {
@@ -1624,6 +2006,20 @@ func (r *RouteDefinition) Context() string {
 	return fmt.Sprintf(`route %s "%s" of %s`, r.Verb, r.Path, r.Parent.Context())
 }
 
+// oldPath returns the path prior to the rename given the current path and name.
+func (r *RenameDefinition) oldPath(current, name string) string {
+	if r.OldPath != "" {
+		return r.OldPath
+	}
+	elems := strings.Split(current, "/")
+	for i, e := range elems {
+		if e == name {
+			elems[i] = r.OldName
+		}
+	}
+	return strings.Join(elems, "/")
+}
+
 // Params returns the route parameters.
 // For example for the route "GET /foo/:fooID" Params returns []string{"fooID"}.
 func (r *RouteDefinition) Params() []string {
@@ -68,3 +68,89 @@ var _ = Describe("IterateHeaders", func() {
 		Ω(names).Should(ConsistOf("a"))
 	})
 })
+
+var _ = Describe("CacheControl", func() {
+	var action *design.ActionDefinition
+
+	BeforeEach(func() {
+		action = &design.ActionDefinition{
+			Responses: map[string]*design.ResponseDefinition{
+				"notFound": {Name: "notFound", Status: 404},
+				"ok": {
+					Name:   "ok",
+					Status: 200,
+					Headers: &design.AttributeDefinition{
+						Type: design.Object{
+							"cache-control": &design.AttributeDefinition{
+								Type:         design.String,
+								DefaultValue: "max-age=60",
+							},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	It("returns the header default value of the successful response", func() {
+		value, ok := action.CacheControl()
+		Ω(ok).Should(BeTrue())
+		Ω(value).Should(Equal("max-age=60"))
+	})
+
+	It("returns false when no successful response declares the header", func() {
+		delete(action.Responses, "ok")
+		_, ok := action.CacheControl()
+		Ω(ok).Should(BeFalse())
+	})
+})
+
+var _ = Describe("AliasRoutes", func() {
+	var resource *design.ResourceDefinition
+	var action *design.ActionDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		resource = &design.ResourceDefinition{Name: "wine", BasePath: "/wines"}
+		action = &design.ActionDefinition{Name: "taste", Parent: resource}
+		action.Routes = []*design.RouteDefinition{{Verb: "POST", Path: "/:id/taste", Parent: action}}
+	})
+
+	It("returns nil when neither the action nor the resource were renamed", func() {
+		Ω(action.AliasRoutes()).Should(BeNil())
+	})
+
+	Context("with a renamed action", func() {
+		BeforeEach(func() {
+			action.Renamed = &design.RenameDefinition{OldName: "rate"}
+		})
+
+		It("returns the route using the old action name", func() {
+			aliases := action.AliasRoutes()
+			Ω(aliases).Should(HaveLen(1))
+			Ω(aliases[0].Route.Verb).Should(Equal("POST"))
+			Ω(aliases[0].Route.FullPath()).Should(Equal("/wines/:id/rate"))
+			Ω(aliases[0].Target).Should(Equal(action.Routes[0]))
+			Ω(aliases[0].Proxy).Should(BeFalse())
+		})
+	})
+
+	Context("with a renamed resource and action", func() {
+		BeforeEach(func() {
+			resource.Renamed = &design.RenameDefinition{OldName: "bottle", OldPath: "/bottles"}
+			action.Renamed = &design.RenameDefinition{OldName: "rate"}
+			resource.Metadata = dslengine.MetadataDefinition{"rename:proxy": nil}
+		})
+
+		It("returns all the combinations of old and new paths", func() {
+			aliases := action.AliasRoutes()
+			Ω(aliases).Should(HaveLen(3))
+			Ω(aliases[0].Route.FullPath()).Should(Equal("/wines/:id/rate"))
+			Ω(aliases[1].Route.FullPath()).Should(Equal("/bottles/:id/taste"))
+			Ω(aliases[2].Route.FullPath()).Should(Equal("/bottles/:id/rate"))
+			for _, a := range aliases {
+				Ω(a.Proxy).Should(BeTrue())
+			}
+		})
+	})
+})
@@ -137,6 +137,9 @@ func (eg *exampleGenerator) generateFormatExample() interface{} {
 		return nil
 	}
 	format := eg.a.Validation.Format
+	if format == "hostport" {
+		return fmt.Sprintf("%s.%s:%d", eg.r.faker.DomainName(), eg.r.faker.DomainSuffix(), eg.r.Int()%65536)
+	}
 	if res, ok := map[string]interface{}{
 		"email":     eg.r.faker.Email(),
 		"hostname":  eg.r.faker.DomainName() + "." + eg.r.faker.DomainSuffix(),
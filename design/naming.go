@@ -0,0 +1,281 @@
+package design
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+const (
+	// NamingResourcesKey is the API metadata key that enables the resource naming rule. The only
+	// supported value is "plural": resource names must be plural nouns.
+	NamingResourcesKey = "naming:resources"
+
+	// NamingActionsKey is the API metadata key that enables the action naming rule. The first
+	// value must be "verb": action names must start with a verb. Additional values list verbs
+	// accepted on top of DefaultActionVerbs.
+	NamingActionsKey = "naming:actions"
+
+	// NamingAttributesKey is the API metadata key that enables the attribute naming rule. The only
+	// supported value is "snake_case": attribute names must be snake_case.
+	NamingAttributesKey = "naming:attributes"
+)
+
+type (
+	// NamingViolation describes a definition whose name breaks one of the naming rules enabled
+	// in the API metadata.
+	NamingViolation struct {
+		// Definition is the definition that breaks the rule.
+		Definition dslengine.Definition
+		// Rule is the metadata key of the rule, e.g. NamingResourcesKey.
+		Rule string
+		// Message describes the violation.
+		Message string
+		// Fix is the DSL edit that fixes the violation, e.g. `Resource("bottle") -> Resource("bottles")`,
+		// empty if no fix can be suggested.
+		Fix string
+	}
+)
+
+// DefaultActionVerbs lists the verbs accepted as first word of action names by the action naming
+// rule.
+var DefaultActionVerbs = []string{
+	"activate", "add", "approve", "archive", "cancel", "check", "clear", "close", "count",
+	"create", "deactivate", "delete", "disable", "download", "enable", "export", "fetch", "find",
+	"get", "import", "invite", "list", "login", "logout", "open", "patch", "post", "publish",
+	"put", "rate", "read", "refresh", "register", "reject", "remove", "rename", "replace",
+	"reset", "restore", "search", "send", "set", "show", "start", "stop", "subscribe", "sync",
+	"unsubscribe", "update", "upload", "upsert", "validate", "verify", "watch", "write",
+}
+
+// actionFixes maps common non verb action names to the verb to use instead.
+var actionFixes = map[string]string{
+	"destroy": "delete",
+	"detail":  "show",
+	"edit":    "update",
+	"index":   "list",
+	"new":     "create",
+	"view":    "show",
+}
+
+// snakeCaseRegex matches snake_case names.
+var snakeCaseRegex = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// NamingViolations checks the names of the API resources, actions and attributes against the
+// naming rules enabled in the API metadata and returns the violations. The rules are disabled by
+// default.
+func (a *APIDefinition) NamingViolations() []*NamingViolation {
+	var (
+		res        = a.Metadata[NamingResourcesKey]
+		actions    = a.Metadata[NamingActionsKey]
+		attributes = a.Metadata[NamingAttributesKey]
+		violations []*NamingViolation
+	)
+	verbs := make(map[string]bool)
+	if len(actions) > 0 && actions[0] == "verb" {
+		for _, v := range DefaultActionVerbs {
+			verbs[v] = true
+		}
+		for _, v := range actions[1:] {
+			verbs[v] = true
+		}
+	}
+	checkAttributes := func(def dslengine.Definition, dsl string, att *AttributeDefinition) {
+		if len(attributes) > 0 && attributes[0] == "snake_case" {
+			violations = append(violations, attributeViolations(def, dsl, att, make(map[*AttributeDefinition]bool))...)
+		}
+	}
+	a.IterateResources(func(r *ResourceDefinition) error {
+		if len(res) > 0 && res[0] == "plural" && !isPlural(r.Name) {
+			violations = append(violations, &NamingViolation{
+				Definition: r,
+				Rule:       NamingResourcesKey,
+				Message:    fmt.Sprintf("resource name %q must be plural", r.Name),
+				Fix:        fmt.Sprintf("Resource(%q) -> Resource(%q)", r.Name, pluralize(r.Name)),
+			})
+		}
+		return r.IterateActions(func(ac *ActionDefinition) error {
+			if len(verbs) > 0 {
+				if first := firstWord(ac.Name); !verbs[first] {
+					v := &NamingViolation{
+						Definition: ac,
+						Rule:       NamingActionsKey,
+						Message:    fmt.Sprintf("action name %q must start with a verb", ac.Name),
+					}
+					if fix, ok := actionFixes[first]; ok {
+						v.Fix = fmt.Sprintf("Action(%q) -> Action(%q)", ac.Name, fix+ac.Name[len(first):])
+					}
+					violations = append(violations, v)
+				}
+			}
+			if ac.Params != nil {
+				checkAttributes(ac, "Param", ac.Params)
+			}
+			if ac.Payload != nil && a.Types[ac.Payload.TypeName] != ac.Payload {
+				checkAttributes(ac, "Attribute", ac.Payload.AttributeDefinition)
+			}
+			return nil
+		})
+	})
+	a.IterateMediaTypes(func(mt *MediaTypeDefinition) error {
+		checkAttributes(mt, "Attribute", mt.AttributeDefinition)
+		return nil
+	})
+	a.IterateUserTypes(func(ut *UserTypeDefinition) error {
+		checkAttributes(ut, "Attribute", ut.AttributeDefinition)
+		return nil
+	})
+	return violations
+}
+
+// validateNaming checks the design against the naming rules enabled in the API metadata.
+func (a *APIDefinition) validateNaming(verr *dslengine.ValidationErrors) {
+	for _, v := range a.NamingViolations() {
+		if v.Fix != "" {
+			verr.Add(v.Definition, "%s (%s), fix: %s", v.Message, v.Rule, v.Fix)
+		} else {
+			verr.Add(v.Definition, "%s (%s)", v.Message, v.Rule)
+		}
+	}
+}
+
+// attributeViolations returns the violations of the attribute naming rule by the attributes of
+// att and their children. Types and media types referenced by att are checked on their own.
+func attributeViolations(def dslengine.Definition, dsl string, att *AttributeDefinition, seen map[*AttributeDefinition]bool) []*NamingViolation {
+	if seen[att] {
+		return nil
+	}
+	seen[att] = true
+	var violations []*NamingViolation
+	switch t := att.Type.(type) {
+	case Object:
+		t.IterateAttributes(func(name string, child *AttributeDefinition) error {
+			if !snakeCaseRegex.MatchString(name) {
+				violations = append(violations, &NamingViolation{
+					Definition: def,
+					Rule:       NamingAttributesKey,
+					Message:    fmt.Sprintf("attribute name %q must be snake_case", name),
+					Fix:        fmt.Sprintf("%s(%q) -> %s(%q)", dsl, name, dsl, snakeCase(name)),
+				})
+			}
+			violations = append(violations, attributeViolations(def, "Attribute", child, seen)...)
+			return nil
+		})
+	case *Array:
+		violations = append(violations, attributeViolations(def, "Attribute", t.ElemType, seen)...)
+	case *Hash:
+		violations = append(violations, attributeViolations(def, "Attribute", t.ElemType, seen)...)
+	}
+	return violations
+}
+
+// irregularPlurals maps the singular form of the nouns with irregular plurals to their plural.
+var irregularPlurals = map[string]string{
+	"child":     "children",
+	"criterion": "criteria",
+	"datum":     "data",
+	"man":       "men",
+	"medium":    "media",
+	"mouse":     "mice",
+	"person":    "people",
+	"woman":     "women",
+}
+
+// singularNouns lists the singular nouns ending with "s" that the suffix rules do not catch.
+var singularNouns = map[string]bool{
+	"alias": true, "atlas": true, "bonus": true, "bus": true, "campus": true, "canvas": true,
+	"census": true, "corpus": true, "focus": true, "gas": true, "radius": true, "status": true,
+	"virus": true,
+}
+
+// isPlural returns true if the last word of name looks like a plural noun.
+func isPlural(name string) bool {
+	word := strings.ToLower(name[lastWord(name):])
+	for s, p := range irregularPlurals {
+		if word == p {
+			return true
+		}
+		if word == s {
+			return false
+		}
+	}
+	if singularNouns[word] || strings.HasSuffix(word, "ss") || strings.HasSuffix(word, "is") {
+		return false
+	}
+	return strings.HasSuffix(word, "s")
+}
+
+// pluralize returns name with its last word replaced with the plural form of the English noun
+// using the irregular plurals and the common suffix rules.
+func pluralize(name string) string {
+	idx := lastWord(name)
+	word := strings.ToLower(name[idx:])
+	if p, ok := irregularPlurals[word]; ok {
+		if unicode.IsUpper(rune(name[idx])) {
+			p = strings.ToUpper(p[:1]) + p[1:]
+		}
+		return name[:idx] + p
+	}
+	switch {
+	case strings.HasSuffix(word, "is") && !singularNouns[word]:
+		return name[:len(name)-2] + "es"
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
+
+// lastWord returns the index of the last word of the camelCase or snake_case name.
+func lastWord(name string) int {
+	idx := 0
+	var prev rune
+	for i, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			idx = i + 1
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			idx = i
+		}
+		prev = r
+	}
+	if idx == len(name) {
+		return 0
+	}
+	return idx
+}
+
+// firstWord returns the first word of the camelCase or snake_case name in lower case.
+func firstWord(name string) string {
+	for i, r := range name {
+		if i > 0 && (unicode.IsUpper(r) || r == '_' || r == '-' || r == ' ') {
+			return strings.ToLower(name[:i])
+		}
+	}
+	return strings.ToLower(name)
+}
+
+// snakeCase returns the snake_case form of the camelCase, kebab-case or space separated name.
+func snakeCase(name string) string {
+	var b []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ' || r == '.':
+			r = '_'
+		case unicode.IsUpper(r):
+			if i > 0 && runes[i-1] != '_' && (!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b = append(b, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b = append(b, r)
+	}
+	return string(b)
+}
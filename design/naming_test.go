@@ -0,0 +1,170 @@
+package design_test
+
+import (
+	"fmt"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NamingViolations", func() {
+	var rules map[string][]string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		rules = nil
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			for k, v := range rules {
+				Metadata(k, v...)
+			}
+		})
+		Resource("bottle", func() {
+			Action("index", func() {
+				Routing(GET(""))
+				Params(func() {
+					Param("sortBy")
+				})
+			})
+			Action("taste", func() {
+				Routing(POST("/:id/taste"))
+				Payload(func() {
+					Member("tastingNotes")
+				})
+			})
+		})
+		Resource("boxes", func() {
+			Action("show", func() {
+				Routing(GET("/:id"))
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with no naming rule", func() {
+		It("does not report violations", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.NamingViolations()).Should(BeEmpty())
+		})
+	})
+
+	Context("with the plural resources rule", func() {
+		BeforeEach(func() {
+			rules = map[string][]string{NamingResourcesKey: {"plural"}}
+		})
+
+		It("suggests the plural name", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			violations := Design.NamingViolations()
+			Ω(violations).Should(HaveLen(1))
+			Ω(violations[0].Definition).Should(Equal(Design.Resources["bottle"]))
+			Ω(violations[0].Fix).Should(Equal(`Resource("bottle") -> Resource("bottles")`))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`resource name "bottle" must be plural (naming:resources), fix: Resource("bottle") -> Resource("bottles")`))
+		})
+	})
+
+	Context("with the verb actions rule", func() {
+		BeforeEach(func() {
+			rules = map[string][]string{NamingActionsKey: {"verb"}}
+		})
+
+		It("reports the actions that do not start with a verb", func() {
+			violations := Design.NamingViolations()
+			Ω(violations).Should(HaveLen(2))
+			Ω(violations[0].Fix).Should(Equal(`Action("index") -> Action("list")`))
+			Ω(violations[1].Message).Should(Equal(`action name "taste" must start with a verb`))
+			Ω(violations[1].Fix).Should(BeEmpty())
+		})
+
+		Context("and additional verbs", func() {
+			BeforeEach(func() {
+				rules[NamingActionsKey] = []string{"verb", "taste"}
+			})
+
+			It("accepts the additional verbs", func() {
+				violations := Design.NamingViolations()
+				Ω(violations).Should(HaveLen(1))
+				Ω(violations[0].Definition).Should(Equal(Design.Resources["bottle"].Actions["index"]))
+			})
+		})
+
+		Context("with an unknown rule value", func() {
+			BeforeEach(func() {
+				rules[NamingActionsKey] = []string{"noun"}
+			})
+
+			It("does not check the action names", func() {
+				Ω(Design.NamingViolations()).Should(BeEmpty())
+			})
+		})
+	})
+
+	Context("with the snake_case attributes rule", func() {
+		BeforeEach(func() {
+			rules = map[string][]string{NamingAttributesKey: {"snake_case"}}
+		})
+
+		It("suggests the snake_case names of params and payload attributes", func() {
+			violations := Design.NamingViolations()
+			Ω(violations).Should(HaveLen(2))
+			Ω(violations[0].Fix).Should(Equal(`Param("sortBy") -> Param("sort_by")`))
+			Ω(violations[1].Fix).Should(Equal(`Attribute("tastingNotes") -> Attribute("tasting_notes")`))
+		})
+	})
+})
+
+var _ = Describe("plural resources rule", func() {
+	cases := []struct {
+		name string
+		fix  string // empty if the name is plural
+	}{
+		{"bottles", ""},
+		{"bottle", "bottles"},
+		{"status", "statuses"},
+		{"statuses", ""},
+		{"order_status", "order_statuses"},
+		{"address", "addresses"},
+		{"addresses", ""},
+		{"billingAddress", "billingAddresses"},
+		{"analysis", "analyses"},
+		{"alias", "aliases"},
+		{"box", "boxes"},
+		{"category", "categories"},
+		{"day", "days"},
+		{"person", "people"},
+		{"people", ""},
+		{"SalesPerson", "SalesPeople"},
+		{"data", ""},
+		{"human", "humans"},
+		{"menus", ""},
+		{"URLs", ""},
+	}
+
+	for _, c := range cases {
+		c := c
+		It(fmt.Sprintf("checks %q", c.name), func() {
+			dslengine.Reset()
+			API("test", func() {
+				Metadata(NamingResourcesKey, "plural")
+			})
+			Resource(c.name, func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+			})
+			dslengine.Run()
+			violations := Design.NamingViolations()
+			if c.fix == "" {
+				Ω(violations).Should(BeEmpty())
+				return
+			}
+			Ω(violations).Should(HaveLen(1))
+			Ω(violations[0].Fix).Should(Equal(fmt.Sprintf("Resource(%q) -> Resource(%q)", c.name, c.fix)))
+		})
+	}
+})
@@ -67,6 +67,20 @@ type SecuritySchemeDefinition struct {
 	TokenURL string `json:"token_url,omitempty"`
 	// AuthorizationURL holds URL for retrieving authorization codes with oauth2
 	AuthorizationURL string `json:"authorization_url,omitempty"`
+	// CodeChallengeMethods lists the PKCE code challenge methods supported by the oauth2
+	// "accessCode" flow in order of preference, PKCE is not used if empty.
+	CodeChallengeMethods []string `json:"code_challenge_methods,omitempty"`
+	// Environments lists the oauth2 URLs specific to each deployment environment indexed by
+	// environment name, e.g. "staging".
+	Environments map[string]*SecurityEnvironmentDefinition `json:"environments,omitempty"`
+}
+
+// SecurityEnvironmentDefinition contains the oauth2 URLs used in a given deployment environment.
+type SecurityEnvironmentDefinition struct {
+	// AuthorizationURL holds URL for retrieving authorization codes.
+	AuthorizationURL string `json:"authorization_url,omitempty"`
+	// TokenURL holds the URL for retrieving tokens.
+	TokenURL string `json:"token_url,omitempty"`
 }
 
 // DSL returns the DSL function
@@ -100,6 +114,21 @@ func (s *SecuritySchemeDefinition) Validate() error {
 	if err != nil {
 		return fmt.Errorf("invalid authorization URL %#v: %s", s.AuthorizationURL, err)
 	}
+	if len(s.CodeChallengeMethods) > 0 && s.Flow != "accessCode" {
+		return fmt.Errorf("PKCE requires the access code flow")
+	}
+	for _, m := range s.CodeChallengeMethods {
+		if m != "S256" && m != "plain" {
+			return fmt.Errorf(`invalid PKCE code challenge method %#v, must be "S256" or "plain"`, m)
+		}
+	}
+	for name, env := range s.Environments {
+		for _, u := range []string{env.AuthorizationURL, env.TokenURL} {
+			if pu, err := url.Parse(u); err != nil || !pu.IsAbs() {
+				return fmt.Errorf("invalid URL %#v for environment %#v, must be an absolute URL", u, name)
+			}
+		}
+	}
 	return nil
 }
 
@@ -687,6 +687,32 @@ func (m *MediaTypeDefinition) IsBuiltIn() bool {
 	return m.Identifier == ErrorMedia.Identifier
 }
 
+// Retention returns the maximum period instances of the media type may be stored for as defined
+// by the "retention" metadata, e.g. "90d". It returns the empty string if the media type does not
+// define a retention period.
+func (m *MediaTypeDefinition) Retention() string {
+	if r := m.Metadata["retention"]; len(r) > 0 {
+		return r[0]
+	}
+	return ""
+}
+
+// ProtoMessage returns the import path of the Go package and the name of the Protocol Buffers
+// message type set with the "protobuf:message" metadata, e.g. "github.com/acme/cellar/pb" and
+// "Bottle" for "github.com/acme/cellar/pb.Bottle". It returns empty strings if the metadata is not
+// set or is invalid.
+func (m *MediaTypeDefinition) ProtoMessage() (pkgPath, typeName string) {
+	v := m.Metadata["protobuf:message"]
+	if len(v) != 1 {
+		return "", ""
+	}
+	idx := strings.LastIndex(v[0], ".")
+	if idx <= strings.LastIndex(v[0], "/") || idx == len(v[0])-1 {
+		return "", ""
+	}
+	return v[0][:idx], v[0][idx+1:]
+}
+
 // ComputeViews returns the media type views recursing as necessary if the media type is a
 // collection.
 func (m *MediaTypeDefinition) ComputeViews() map[string]*ViewDefinition {
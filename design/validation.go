@@ -6,12 +6,17 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/goadesign/goa/dslengine"
 )
 
+// retentionRegex matches the values of the "retention" metadata.
+var retentionRegex = regexp.MustCompile(`^[1-9][0-9]*[dwmy]$`)
+
 type routeInfo struct {
 	Key       string
 	Resource  *ResourceDefinition
@@ -72,6 +77,9 @@ func (a *APIDefinition) Validate() error {
 	a.validateLicense(verr)
 	a.validateDocs(verr)
 	a.validateOrigins(verr)
+	a.validateFeatures(verr)
+	a.validateAliasRoutes(verr)
+	a.validateNaming(verr)
 
 	var allRoutes []*routeInfo
 	a.IterateResources(func(r *ResourceDefinition) error {
@@ -183,6 +191,96 @@ func (a *APIDefinition) validateOrigins(verr *dslengine.ValidationErrors) {
 	}
 }
 
+// validateAliasRoutes checks that the routes kept for renamed resources and actions use the same
+// wildcards as the current routes and do not conflict with any current route.
+func (a *APIDefinition) validateAliasRoutes(verr *dslengine.ValidationErrors) {
+	routes := make(map[string]bool)
+	a.IterateResources(func(r *ResourceDefinition) error {
+		if r.Renamed != nil && r.Renamed.OldName == "" {
+			verr.Add(r, "renamed resource old name cannot be empty")
+		}
+		return r.IterateActions(func(ac *ActionDefinition) error {
+			if ac.Renamed != nil && ac.Renamed.OldName == "" {
+				verr.Add(ac, "renamed action old name cannot be empty")
+			}
+			for _, ro := range ac.Routes {
+				routes[ro.Verb+" "+ro.FullPath()] = true
+			}
+			return nil
+		})
+	})
+	a.IterateResources(func(r *ResourceDefinition) error {
+		return r.IterateActions(func(ac *ActionDefinition) error {
+			for _, alias := range ac.AliasRoutes() {
+				old, current := alias.Route.FullPath(), alias.Target.FullPath()
+				if routes[alias.Route.Verb+" "+old] {
+					verr.Add(ac, `route "%s %s" kept for the rename conflicts with an existing route`, alias.Route.Verb, old)
+				}
+				owcs, cwcs := ExtractWildcards(old), ExtractWildcards(current)
+				sort.Strings(owcs)
+				sort.Strings(cwcs)
+				if strings.Join(owcs, ",") != strings.Join(cwcs, ",") {
+					verr.Add(ac, `route "%s" kept for the rename must define the same wildcards as "%s"`, old, current)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func (a *APIDefinition) validateFeatures(verr *dslengine.ValidationErrors) {
+	names := make(map[string]bool)
+	// idents maps the Go identifiers generated for the features and their variants, as
+	// returned by identKey, to the names that produce them.
+	idents := make(map[string]string)
+	ident := func(f *FeatureDefinition, key, name string) {
+		if prev, ok := idents[key]; ok && prev != name {
+			verr.Add(f, "%s and %s generate the same Go identifier", name, prev)
+			return
+		}
+		idents[key] = name
+	}
+	for _, f := range a.Features {
+		if f.Name == "" {
+			verr.Add(f, "feature name cannot be empty")
+			continue
+		}
+		if names[f.Name] {
+			verr.Add(f, "feature defined multiple times")
+			continue
+		}
+		names[f.Name] = true
+		name := fmt.Sprintf("feature %#v", f.Name)
+		ident(f, "const:"+identKey(f.Name), name)
+		ident(f, "method:"+identKey(f.Name), name)
+		if len(f.Variants) > 0 {
+			ident(f, "method:"+identKey(f.Name)+"variant", name)
+		}
+		variants := make(map[string]bool)
+		for _, v := range f.Variants {
+			if v == "" {
+				verr.Add(f, "feature variant cannot be empty")
+			} else if variants[v] {
+				verr.Add(f, "duplicate variant %#v", v)
+			} else {
+				ident(f, "const:"+identKey(f.Name)+identKey(v), fmt.Sprintf("variant %#v of %s", v, name))
+			}
+			variants[v] = true
+		}
+	}
+}
+
+// identKey returns the letters and digits of s in lower case. Names that generate the same Go
+// identifier once "goified" have the same key.
+func identKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
 // Validate tests whether the resource definition is consistent: action names are valid and each action is
 // valid.
 func (r *ResourceDefinition) Validate() *dslengine.ValidationErrors {
@@ -206,6 +304,9 @@ func (r *ResourceDefinition) Validate() *dslengine.ValidationErrors {
 	for _, origin := range r.Origins {
 		verr.Merge(origin.Validate())
 	}
+	if m := r.Maintenance(); m != nil && m.RetryAfter <= 0 {
+		verr.Add(r, `metadata "maintenance" must be a positive duration, e.g. "5m"`)
+	}
 	return verr.AsError()
 }
 
@@ -303,7 +404,7 @@ func (enc *EncodingDefinition) Validate() *dslengine.ValidationErrors {
 		}
 	} else {
 		for _, m := range enc.MIMETypes {
-			if _, ok := KnownEncoders[m]; !ok {
+			if KnownMIMEType(m) == "" {
 				knownMIMETypes := make([]string, len(KnownEncoders))
 				i := 0
 				for k := range KnownEncoders {
@@ -341,16 +442,138 @@ func (a *ActionDefinition) Validate() *dslengine.ValidationErrors {
 		verr.Merge(r.Validate())
 	}
 	verr.Merge(a.ValidateParams())
+	verr.Merge(a.ValidateHeaders())
 	if a.Payload != nil {
 		verr.Merge(a.Payload.Validate("action payload", a))
 	}
 	if a.Parent == nil {
 		verr.Add(a, "missing parent resource")
 	}
+	a.validateStream(verr)
+	a.validateStreamPayload(verr)
+	a.validateConcurrency(verr)
+	a.validatePagination(verr)
 
 	return verr.AsError()
 }
 
+// validateStream checks the values of the metadata that configure the flow control of streaming
+// actions.
+func (a *ActionDefinition) validateStream(verr *dslengine.ValidationErrors) {
+	stream := a.Stream()
+	if stream == nil {
+		for _, k := range []string{"stream:watermark", "stream:policy", "stream:format"} {
+			if _, ok := a.Metadata[k]; ok {
+				verr.Add(a, `metadata %q requires "stream:buffer"`, k)
+			}
+		}
+		return
+	}
+	if stream.BufferSize <= 0 {
+		verr.Add(a, `metadata "stream:buffer" must be a positive integer`)
+	}
+	if wm := a.Metadata["stream:watermark"]; len(wm) > 0 {
+		if stream.HighWatermark <= 0 || stream.HighWatermark > stream.BufferSize {
+			verr.Add(a, `metadata "stream:watermark" must be a positive integer no greater than the buffer size`)
+		}
+	}
+	switch stream.Policy {
+	case "block", "drop_oldest", "drop_newest":
+	default:
+		verr.Add(a, `metadata "stream:policy" must be one of "block", "drop_oldest" or "drop_newest", got %q`, stream.Policy)
+	}
+	if f := a.Metadata["stream:format"]; len(f) > 0 && f[0] != "auto" && f[0] != "ndjson" {
+		verr.Add(a, `metadata "stream:format" must be "auto" or "ndjson", got %q`, f[0])
+	}
+}
+
+// validateStreamPayload checks that the actions that stream their payload accept arrays of user
+// types.
+func (a *ActionDefinition) validateStreamPayload(verr *dslengine.ValidationErrors) {
+	if !a.StreamPayload() {
+		return
+	}
+	if a.Payload == nil || !a.Payload.IsArray() {
+		verr.Add(a, `metadata "payload:stream" requires the payload to be an array`)
+		return
+	}
+	elem := a.Payload.ToArray().ElemType
+	if _, ok := elem.Type.(*MediaTypeDefinition); ok {
+		verr.Add(a, `metadata "payload:stream" requires the payload elements to be user types, got media type %s`, elem.Type.Name())
+		return
+	}
+	if _, ok := elem.Type.(*UserTypeDefinition); !ok {
+		verr.Add(a, `metadata "payload:stream" requires the payload elements to be user types, got %s`, elem.Type.Name())
+	}
+}
+
+// validateConcurrency checks the values of the metadata that configure the adaptive concurrency
+// limit of actions.
+func (a *ActionDefinition) validateConcurrency(verr *dslengine.ValidationErrors) {
+	c := a.Concurrency()
+	if c == nil {
+		for _, k := range []string{"concurrency:max", "concurrency:latency"} {
+			if _, ok := a.Metadata[k]; ok {
+				verr.Add(a, `metadata %q requires "concurrency:limit"`, k)
+			}
+		}
+		return
+	}
+	if c.InitialLimit <= 0 {
+		verr.Add(a, `metadata "concurrency:limit" must be a positive integer`)
+	}
+	if m := a.Metadata["concurrency:max"]; len(m) > 0 && c.MaxLimit < c.InitialLimit {
+		verr.Add(a, `metadata "concurrency:max" must be an integer no lower than the initial limit`)
+	}
+	if lat := a.Metadata["concurrency:latency"]; len(lat) > 0 && c.Latency <= 0 {
+		verr.Add(a, `metadata "concurrency:latency" must be a positive duration, e.g. "250ms"`)
+	}
+}
+
+// validatePagination checks the values of the metadata that configure the pagination of actions.
+func (a *ActionDefinition) validatePagination(verr *dslengine.ValidationErrors) {
+	p := a.Pagination()
+	if p == nil {
+		for _, k := range []string{"paginate:next", "paginate:items"} {
+			if _, ok := a.Metadata[k]; ok {
+				verr.Add(a, `metadata %q requires "paginate:cursor" or "paginate:link"`, k)
+			}
+		}
+		return
+	}
+	_, cursor := a.Metadata["paginate:cursor"]
+	if _, link := a.Metadata["paginate:link"]; link {
+		if cursor {
+			verr.Add(a, `metadata "paginate:cursor" and "paginate:link" cannot both be set`)
+		}
+		if _, ok := a.Metadata["paginate:next"]; ok {
+			verr.Add(a, `metadata "paginate:next" requires "paginate:cursor"`)
+		}
+		return
+	}
+	if p.Cursor == "" {
+		verr.Add(a, `metadata "paginate:cursor" must name a query string parameter`)
+	} else {
+		var att *AttributeDefinition
+		if a.Params != nil {
+			att = a.Params.Type.ToObject()[p.Cursor]
+		}
+		for _, r := range a.Routes {
+			for _, n := range r.Params() {
+				if n == p.Cursor {
+					att = nil
+				}
+			}
+		}
+		if att == nil || att.Type.Kind() != StringKind || a.Params.IsRequired(p.Cursor) {
+			verr.Add(a, `metadata "paginate:cursor" must name an optional string query string parameter, got %q`, p.Cursor)
+		}
+	}
+	if p.Next == "" {
+		verr.Add(a, `metadata "paginate:cursor" requires "paginate:next"`)
+	}
+}
+
 // Validate checks the file server is properly initialized.
 func (f *FileServerDefinition) Validate() *dslengine.ValidationErrors {
 	verr := new(dslengine.ValidationErrors)
@@ -415,6 +638,29 @@ func (a *ActionDefinition) ValidateParams() *dslengine.ValidationErrors {
 	return verr.AsError()
 }
 
+// ValidateHeaders checks the action headers are of types that can be parsed from HTTP header
+// values, that is primitive types or arrays of primitive types.
+func (a *ActionDefinition) ValidateHeaders() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if a.Headers == nil {
+		return nil
+	}
+	for n, h := range a.Headers.Type.ToObject() {
+		if h == nil || h.Type == nil {
+			verr.Add(a, "type of header %s cannot be nil", n)
+			continue
+		}
+		t := h.Type
+		if arr, ok := t.(*Array); ok {
+			t = arr.ElemType.Type
+		}
+		if !t.IsPrimitive() {
+			verr.Add(a, "header %s must be a primitive type or an array of primitive types", n)
+		}
+	}
+	return verr.AsError()
+}
+
 // validated keeps track of validated attributes to handle cyclical definitions.
 var validated = make(map[*AttributeDefinition]bool)
 
@@ -450,8 +696,38 @@ func (a *AttributeDefinition) Validate(ctx string, parent dslengine.Definition)
 			verr.Add(parent, "%sdefault value %#v is not one of the accepted values: %#v", ctx, a.DefaultValue, a.Validation.Values)
 		}
 	}
+	// Struct field type overrides only apply to string attributes with no default value.
+	if ft, ok := a.Metadata["struct:field:type"]; ok {
+		switch {
+		case len(ft) != 1 || (ft[0] != "net.IP" && ft[0] != "netip.Addr"):
+			verr.Add(parent, `%sinvalid "struct:field:type" metadata value %#v, supported values are "net.IP" and "netip.Addr"`, ctx, ft)
+		case a.Type.Kind() != StringKind:
+			verr.Add(parent, `%s"struct:field:type" metadata can only be used with string attributes`, ctx)
+		case a.DefaultValue != nil:
+			verr.Add(parent, `%s"struct:field:type" metadata cannot be used with attributes that have a default value`, ctx)
+		}
+	}
+	if n, ok := a.Metadata["proto:field:number"]; ok && a.ProtoFieldNumber() == 0 {
+		verr.Add(parent, `%sinvalid "proto:field:number" metadata value %#v, must be a number between 1 and %d excluding 19000 to 19999`, ctx, n, maxProtoFieldNumber)
+	}
 	o := a.Type.ToObject()
 	if o != nil {
+		numbers := make(map[int]string)
+		names := make([]string, 0, len(o))
+		for n := range o {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			num := o[n].ProtoFieldNumber()
+			if num == 0 {
+				continue
+			}
+			if other, ok := numbers[num]; ok {
+				verr.Add(parent, `%sfields "%s" and "%s" use the same "proto:field:number" %d`, ctx, other, n, num)
+			}
+			numbers[num] = n
+		}
 		for _, n := range a.AllRequired() {
 			found := false
 			for an := range o {
@@ -566,6 +842,16 @@ func (m *MediaTypeDefinition) Validate() *dslengine.ValidationErrors {
 	for _, l := range m.Links {
 		verr.Merge(l.Validate())
 	}
+	if _, ok := m.Metadata["retention"]; ok && !retentionRegex.MatchString(m.Retention()) {
+		verr.Add(m, `metadata "retention" must be a positive number followed by "d", "w", "m" or "y", got %q`, m.Retention())
+	}
+	if v, ok := m.Metadata["protobuf:message"]; ok {
+		if _, name := m.ProtoMessage(); name == "" {
+			verr.Add(m, `metadata "protobuf:message" must be the import path of a Go package followed by a dot and the name of a message type, e.g. "github.com/acme/cellar/pb.Bottle", got %q`, strings.Join(v, ", "))
+		} else if !m.Type.IsObject() {
+			verr.Add(m, `metadata "protobuf:message" may only be set on media types whose type is an object`)
+		}
+	}
 	return verr.AsError()
 }
 
@@ -1,6 +1,9 @@
 package design_test
 
 import (
+	"regexp"
+	"time"
+
 	. "github.com/goadesign/goa/design"
 	. "github.com/goadesign/goa/design/apidsl"
 	"github.com/goadesign/goa/dslengine"
@@ -73,8 +76,86 @@ var _ = Describe("Validation", func() {
 			})
 			It("produces an error", func() {
 				Ω(dslengine.Errors).Should(HaveOccurred())
-				Ω(dslengine.Errors.Error()).Should(Equal(
-					`type "bar": field attName - default value 4 is not one of the accepted values: []interface {}{1, 2, 3}`))
+				Ω(dslengine.Errors.Error()).Should(MatchRegexp(`^\[validation_test\.go:\d+\] ` + regexp.QuoteMeta(
+					`type "bar": field attName - default value 4 is not one of the accepted values: []interface {}{1, 2, 3}`) + "$"))
+			})
+		})
+
+		Context("with a struct field type override", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Attribute(attName, String, func() {
+						Format("ipv4")
+						Metadata("struct:field:type", "net.IP")
+					})
+				}
+			})
+
+			It("records the override", func() {
+				Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+				Ω(att.Metadata["struct:field:type"]).Should(Equal([]string{"net.IP"}))
+			})
+		})
+
+		Context("with an unsupported struct field type override", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Attribute(attName, String, func() {
+						Metadata("struct:field:type", "net.IPNet")
+					})
+				}
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+			})
+		})
+
+		Context("with a struct field type override on a non string attribute", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Attribute(attName, Integer, func() {
+						Metadata("struct:field:type", "netip.Addr")
+					})
+				}
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+				Ω(dslengine.Errors.Error()).Should(ContainSubstring("can only be used with string attributes"))
+			})
+		})
+
+		Context("with an invalid Protocol Buffers field number", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Attribute(attName, String, func() {
+						Metadata("proto:field:number", "19001")
+					})
+				}
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+				Ω(dslengine.Errors.Error()).Should(ContainSubstring(`invalid "proto:field:number" metadata value`))
+			})
+		})
+
+		Context("with duplicate Protocol Buffers field numbers", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Attribute(attName, String, func() {
+						Metadata("proto:field:number", "2")
+					})
+					Attribute("other", String, func() {
+						Metadata("proto:field:number", "2")
+					})
+				}
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+				Ω(dslengine.Errors.Error()).Should(ContainSubstring(`fields "attName" and "other" use the same "proto:field:number" 2`))
 			})
 		})
 
@@ -288,3 +369,546 @@ var _ = Describe("Validation", func() {
 		})
 	})
 })
+
+var _ = Describe("ValidateAliasRoutes", func() {
+	var oldPath string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		oldPath = ""
+	})
+
+	JustBeforeEach(func() {
+		Resource("wine", func() {
+			BasePath("/wines")
+			Action("taste", func() {
+				Routing(POST("/:id/taste"))
+				if oldPath != "" {
+					Renamed("rate", oldPath)
+				} else {
+					Renamed("rate")
+				}
+			})
+			Action("rate", func() {
+				Routing(POST("/:id/rating"))
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("accepts the default old path", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+	})
+
+	Context("with an old path using different wildcards", func() {
+		BeforeEach(func() {
+			oldPath = "/:wineID/rate"
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("must define the same wildcards"))
+		})
+	})
+
+	Context("with an old path conflicting with an existing route", func() {
+		BeforeEach(func() {
+			oldPath = "/:id/rating"
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("conflicts with an existing route"))
+		})
+	})
+})
+
+var _ = Describe("ValidateStream", func() {
+	var metadata map[string]string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		metadata = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("bottle", func() {
+			Action("watch", func() {
+				Routing(GET("/watch"))
+				for k, v := range metadata {
+					Metadata(k, v)
+				}
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with valid stream metadata", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"stream:buffer": "64", "stream:watermark": "48", "stream:policy": "drop_oldest"}
+		})
+
+		It("sets the action stream", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			stream := Design.Resources["bottle"].Actions["watch"].Stream()
+			Ω(stream).Should(Equal(&StreamDefinition{BufferSize: 64, HighWatermark: 48, Policy: "drop_oldest"}))
+		})
+	})
+
+	Context("with the ndjson stream format", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"stream:buffer": "64", "stream:format": "ndjson"}
+		})
+
+		It("sets the action stream", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			stream := Design.Resources["bottle"].Actions["watch"].Stream()
+			Ω(stream).Should(Equal(&StreamDefinition{BufferSize: 64, Policy: "block", NDJSON: true}))
+		})
+	})
+
+	Context("with an invalid stream format", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"stream:buffer": "64", "stream:format": "csv"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"stream:format" must be "auto" or "ndjson"`))
+		})
+	})
+
+	Context("with an invalid buffer size", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"stream:buffer": "foo"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"stream:buffer" must be a positive integer`))
+		})
+	})
+
+	Context("with a watermark greater than the buffer size", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"stream:buffer": "16", "stream:watermark": "32"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"stream:watermark" must be a positive integer`))
+		})
+	})
+
+	Context("with an invalid policy", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"stream:buffer": "16", "stream:policy": "wait"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"stream:policy" must be one of`))
+		})
+	})
+
+	Context("with a policy but no buffer", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"stream:policy": "block"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`requires "stream:buffer"`))
+		})
+	})
+})
+
+var _ = Describe("ValidateHeaders", func() {
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		action = &ActionDefinition{Name: "show"}
+	})
+
+	It("accepts primitive and array headers", func() {
+		action.Headers = &AttributeDefinition{
+			Type: Object{
+				"X-Count": &AttributeDefinition{Type: Integer},
+				"X-Ids":   &AttributeDefinition{Type: &Array{ElemType: &AttributeDefinition{Type: Integer}}},
+			},
+		}
+		Ω(action.ValidateHeaders()).Should(BeNil())
+	})
+
+	It("rejects object headers", func() {
+		action.Headers = &AttributeDefinition{
+			Type: Object{
+				"X-Obj": &AttributeDefinition{Type: Object{}},
+			},
+		}
+		Ω(action.ValidateHeaders()).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("ValidateConcurrency", func() {
+	var metadata map[string]string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		metadata = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("bottle", func() {
+			Action("show", func() {
+				Routing(GET("/:id"))
+				for k, v := range metadata {
+					Metadata(k, v)
+				}
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with valid concurrency metadata", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"concurrency:limit": "10", "concurrency:max": "100", "concurrency:latency": "250ms"}
+		})
+
+		It("sets the action concurrency", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			c := Design.Resources["bottle"].Actions["show"].Concurrency()
+			Ω(c).Should(Equal(&ConcurrencyDefinition{InitialLimit: 10, MaxLimit: 100, Latency: 250 * time.Millisecond}))
+		})
+	})
+
+	Context("with a maximum lower than the initial limit", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"concurrency:limit": "10", "concurrency:max": "5"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"concurrency:max" must be an integer no lower than the initial limit`))
+		})
+	})
+
+	Context("with an invalid latency", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"concurrency:limit": "10", "concurrency:latency": "fast"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"concurrency:latency" must be a positive duration`))
+		})
+	})
+
+	Context("with a latency but no limit", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"concurrency:latency": "250ms"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"concurrency:latency" requires "concurrency:limit"`))
+		})
+	})
+})
+
+var _ = Describe("ValidatePagination", func() {
+	var metadata map[string]string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		metadata = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("bottle", func() {
+			Action("list", func() {
+				Routing(GET("/:account"))
+				Params(func() {
+					Param("account", String)
+					Param("page_token", String)
+					Param("limit", Integer)
+				})
+				for k, v := range metadata {
+					Metadata(k, v)
+				}
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with valid cursor metadata", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"paginate:cursor": "page_token", "paginate:next": "next_page_token", "paginate:items": "items"}
+		})
+
+		It("sets the action pagination", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			p := Design.Resources["bottle"].Actions["list"].Pagination()
+			Ω(p).Should(Equal(&PaginationDefinition{Cursor: "page_token", Next: "next_page_token", Items: "items"}))
+		})
+	})
+
+	Context("with a cursor that is not a string query string parameter", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"paginate:cursor": "limit", "paginate:next": "next"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"paginate:cursor" must name an optional string query string parameter, got "limit"`))
+		})
+	})
+
+	Context("with a cursor that is a path parameter", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"paginate:cursor": "account", "paginate:next": "next"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"paginate:cursor" must name an optional string query string parameter, got "account"`))
+		})
+	})
+
+	Context("with both a cursor and links", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"paginate:cursor": "page_token", "paginate:next": "next", "paginate:link": ""}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"paginate:cursor" and "paginate:link" cannot both be set`))
+		})
+	})
+
+	Context("with items but no cursor", func() {
+		BeforeEach(func() {
+			metadata = map[string]string{"paginate:items": "items"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"paginate:items" requires "paginate:cursor" or "paginate:link"`))
+		})
+	})
+})
+
+var _ = Describe("ValidateRetention", func() {
+	var retention string
+	var mt *MediaTypeDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+	})
+
+	JustBeforeEach(func() {
+		mt = MediaType("application/vnd.user+json", func() {
+			Retention(retention)
+			Attributes(func() {
+				Attribute("email")
+			})
+			View("default", func() {
+				Attribute("email")
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with a valid retention period", func() {
+		BeforeEach(func() {
+			retention = "90d"
+		})
+
+		It("sets the media type retention", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(mt.Retention()).Should(Equal("90d"))
+		})
+	})
+
+	Context("with an invalid retention period", func() {
+		BeforeEach(func() {
+			retention = "90 days"
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`metadata "retention" must be a positive number`))
+		})
+	})
+})
+
+var _ = Describe("ValidateProtoMessage", func() {
+	var message string
+	var mt *MediaTypeDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+	})
+
+	JustBeforeEach(func() {
+		mt = MediaType("application/vnd.bottle+json", func() {
+			Metadata("protobuf:message", message)
+			Attributes(func() {
+				Attribute("name")
+			})
+			View("default", func() {
+				Attribute("name")
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with a valid message type", func() {
+		BeforeEach(func() {
+			message = "github.com/acme/cellar/pb.Bottle"
+		})
+
+		It("returns the package path and message name", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			pkgPath, name := mt.ProtoMessage()
+			Ω(pkgPath).Should(Equal("github.com/acme/cellar/pb"))
+			Ω(name).Should(Equal("Bottle"))
+		})
+	})
+
+	Context("with a message type missing the package path", func() {
+		BeforeEach(func() {
+			message = "github.com/acme/cellar/pb"
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`metadata "protobuf:message" must be the import path of a Go package`))
+		})
+	})
+})
+
+var _ = Describe("ValidateMaintenance", func() {
+	var metadata []string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		metadata = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("bottle", func() {
+			Metadata("maintenance", metadata...)
+			Action("show", func() {
+				Routing(GET("/:id"))
+			})
+			Action("delete", func() {
+				Routing(DELETE("/:id"))
+				Response(ServiceUnavailable)
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with no retry hint", func() {
+		It("uses the default retry hint", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			m := Design.Resources["bottle"].Maintenance()
+			Ω(m).Should(Equal(&MaintenanceDefinition{RetryAfter: DefaultMaintenanceRetryAfter}))
+		})
+
+		It("adds the maintenance responses", func() {
+			show := Design.Resources["bottle"].Actions["show"]
+			Ω(show.Responses).Should(HaveKey(ServiceUnavailable))
+			Ω(show.Responses[ServiceUnavailable].Status).Should(Equal(503))
+			Ω(show.Responses[ServiceUnavailable].MediaType).Should(Equal(MaintenanceMediaIdentifier))
+			Ω(Design.MediaTypeWithIdentifier(MaintenanceMediaIdentifier)).Should(Equal(MaintenanceMedia))
+		})
+
+		It("keeps the responses defined in the design", func() {
+			del := Design.Resources["bottle"].Actions["delete"]
+			Ω(del.Responses[ServiceUnavailable].MediaType).ShouldNot(Equal(MaintenanceMediaIdentifier))
+		})
+	})
+
+	Context("with a retry hint", func() {
+		BeforeEach(func() {
+			metadata = []string{"90s"}
+		})
+
+		It("sets the resource retry hint", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.Resources["bottle"].Maintenance().RetryAfter).Should(Equal(90 * time.Second))
+		})
+	})
+
+	Context("with an invalid retry hint", func() {
+		BeforeEach(func() {
+			metadata = []string{"soon"}
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`"maintenance" must be a positive duration`))
+		})
+	})
+})
+
+var _ = Describe("ValidateStreamPayload", func() {
+	var elem string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		elem = ""
+	})
+
+	JustBeforeEach(func() {
+		bottle := Type("BottlePayload", func() {
+			Attribute("name")
+		})
+		Resource("bottle", func() {
+			Action("import", func() {
+				Routing(POST("/import"))
+				Metadata("payload:stream")
+				switch elem {
+				case "user type":
+					Payload(ArrayOf(bottle))
+				case "primitive":
+					Payload(ArrayOf(String))
+				default:
+					Payload(bottle)
+				}
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with an array of user types", func() {
+		BeforeEach(func() {
+			elem = "user type"
+		})
+
+		It("streams the payload", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.Resources["bottle"].Actions["import"].StreamPayload()).Should(BeTrue())
+		})
+	})
+
+	Context("with an array of primitive types", func() {
+		BeforeEach(func() {
+			elem = "primitive"
+		})
+
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`requires the payload elements to be user types`))
+		})
+	})
+
+	Context("with a payload that is not an array", func() {
+		It("returns an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`requires the payload to be an array`))
+		})
+	})
+})
@@ -16,8 +16,11 @@ encoding algorithms.
 Request Context
 
 The RequestData and ResponseData structs provides access to the request and response state. goa request
-handlers also accept a golang.org/x/net/Context interface as first parameter so that deadlines and
-cancelation signals may easily be implemented.
+handlers also accept a context.Context interface as first parameter so that deadlines and
+cancelation signals may easily be implemented. Code generated with the default goagen settings refers
+to the golang.org/x/net/context package which aliases the standard library package on Go 1.9 and
+later, use the "--stdcontext" flag of the "app" and "client" commands to import the standard library
+package directly.
 
 The request state exposes the underlying http.Request object as well as the deserialized payload (request
 body) and parameters (both path and querystring parameters). Generated action specific contexts wrap
@@ -21,6 +21,9 @@ var (
 
 	// DSL package paths used to compute error locations (skip the frames in these packages)
 	dslPackages map[string]bool
+
+	// Source locations of the definition DSLs indexed by definition
+	locations map[Definition]*location
 )
 
 type (
@@ -38,6 +41,12 @@ type (
 
 	// DSL evaluation contexts stack
 	contextStack []Definition
+
+	// location is the position of a DSL in the user code.
+	location struct {
+		file string
+		line int
+	}
 )
 
 func init() {
@@ -72,6 +81,7 @@ func Reset() {
 		r.Reset()
 	}
 	Errors = nil
+	locations = nil
 }
 
 // Run runs the given root definitions. It iterates over the definition sets
@@ -128,6 +138,7 @@ func Execute(dsl func(), def Definition) bool {
 		return true
 	}
 	initCount := len(Errors)
+	recordLocation(dsl, def)
 	ctxStack = append(ctxStack, def)
 	dsl()
 	ctxStack = ctxStack[:len(ctxStack)-1]
@@ -236,6 +247,81 @@ func (de *Error) Error() string {
 	return ""
 }
 
+// Location returns the file name and line number of the DSL that initialized the given definition,
+// that is the position of the anonymous function given to the DSL function that created the
+// definition, e.g. Resource. Location returns an empty string and 0 if the definition has no DSL
+// or if its DSL is not defined in the user code.
+func Location(def Definition) (file string, line int) {
+	if !isPtr(def) {
+		return "", 0
+	}
+	if loc, ok := locations[def]; ok {
+		return loc.file, loc.line
+	}
+	if source, ok := def.(Source); ok {
+		if loc := dslLocation(source.DSL()); loc != nil {
+			return loc.file, loc.line
+		}
+	}
+	// Definitions that embed another definition, e.g. a user type and its attribute, share its
+	// location.
+	v := reflect.ValueOf(def).Elem()
+	if v.Kind() != reflect.Struct {
+		return "", 0
+	}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if !v.Type().Field(i).Anonymous || f.Kind() != reflect.Ptr || f.IsNil() {
+			continue
+		}
+		if embedded, ok := f.Interface().(Definition); ok {
+			if file, line := Location(embedded); file != "" {
+				return file, line
+			}
+		}
+	}
+	return "", 0
+}
+
+// recordLocation records the location of the DSL of the given definition if it is defined in the
+// user code. Only the location of the first DSL executed for a definition is recorded.
+func recordLocation(dsl func(), def Definition) {
+	if !isPtr(def) {
+		return
+	}
+	if _, ok := locations[def]; ok {
+		return
+	}
+	if loc := dslLocation(dsl); loc != nil {
+		if locations == nil {
+			locations = make(map[Definition]*location)
+		}
+		locations[def] = loc
+	}
+}
+
+// dslLocation returns the location of the given DSL function, nil if the function is nil or is
+// not defined in the user code.
+func dslLocation(dsl func()) *location {
+	if dsl == nil {
+		return nil
+	}
+	fn := runtime.FuncForPC(reflect.ValueOf(dsl).Pointer())
+	if fn == nil {
+		return nil
+	}
+	file, line := fn.FileLine(fn.Entry())
+	if isDSLFile(file) {
+		return nil
+	}
+	return &location{file: relativePath(file), line: line}
+}
+
+// isPtr returns true if def is a pointer and thus may be used as a map key.
+func isPtr(def Definition) bool {
+	return def != nil && reflect.TypeOf(def).Kind() == reflect.Ptr
+}
+
 // Current evaluation context, i.e. object being currently built by DSL
 func (s contextStack) Current() Definition {
 	if len(s) == 0 {
@@ -250,38 +336,45 @@ func (s contextStack) Current() Definition {
 // When successful it returns the file name and line number, empty string and
 // 0 otherwise.
 func computeErrorLocation() (file string, line int) {
-	skipFunc := func(file string) bool {
-		if strings.HasSuffix(file, "_test.go") { // Be nice with tests
-			return false
-		}
-		file = filepath.ToSlash(file)
-		for pkg := range dslPackages {
-			if strings.Contains(file, pkg) {
-				return true
-			}
-		}
-		return false
-	}
 	depth := 2
 	_, file, line, _ = runtime.Caller(depth)
-	for skipFunc(file) {
+	for isDSLFile(file) {
 		depth++
 		_, file, line, _ = runtime.Caller(depth)
 	}
+	return relativePath(file), line
+}
+
+// isDSLFile returns true if the given source file belongs to one of the DSL packages.
+func isDSLFile(file string) bool {
+	if strings.HasSuffix(file, "_test.go") { // Be nice with tests
+		return false
+	}
+	file = filepath.ToSlash(file)
+	for pkg := range dslPackages {
+		if strings.Contains(file, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// relativePath returns the path of file relative to the current working directory if possible,
+// file otherwise.
+func relativePath(file string) string {
 	wd, err := os.Getwd()
 	if err != nil {
-		return
+		return file
 	}
 	wd, err = filepath.Abs(wd)
 	if err != nil {
-		return
+		return file
 	}
 	f, err := filepath.Rel(wd, file)
 	if err != nil {
-		return
+		return file
 	}
-	file = f
-	return
+	return f
 }
 
 // runSet executes the DSL for all definitions in the given set. The definition DSLs may append to
@@ -143,4 +143,29 @@ var _ = Describe("DSL errors", func() {
 			Ω(dslengine.Errors[0].Line).Should(Equal(lineNumber))
 		})
 	})
+	Context("with a definition that fails to validate", func() {
+		// See NOTE below.
+		const lineNumber = 154
+
+		BeforeEach(func() {
+			API("foo", func() {})
+			// NOTE: moving the line below requires updating the
+			// constant above to match its number.
+			Type("bar", func() {
+				Attribute("baz", Integer, func() {
+					Enum(1, 2)
+					Default(3)
+				})
+			})
+			dslengine.Run()
+		})
+
+		It("reports the location of the definition", func() {
+			Ω(dslengine.Errors).Should(HaveLen(1))
+			file, line := dslengine.Location(Design.Types["bar"])
+			Ω(file).Should(Equal("runner_test.go"))
+			Ω(line).Should(Equal(lineNumber))
+			Ω(ErrorMsg).Should(HavePrefix(`[runner_test.go:154] type "bar": field baz - default value 3`))
+		})
+	})
 })
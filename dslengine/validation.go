@@ -11,11 +11,16 @@ type ValidationErrors struct {
 	Definitions []Definition
 }
 
-// Error implements the error interface.
+// Error implements the error interface. Each error is prefixed with the location of the DSL of
+// the definition that caused it if known, see Location.
 func (verr *ValidationErrors) Error() string {
 	msg := make([]string, len(verr.Errors))
 	for i, err := range verr.Errors {
-		msg[i] = fmt.Sprintf("%s: %s", verr.Definitions[i].Context(), err)
+		def := verr.Definitions[i]
+		msg[i] = fmt.Sprintf("%s: %s", def.Context(), err)
+		if file, line := Location(def); file != "" {
+			msg[i] = fmt.Sprintf("[%s:%d] %s", file, line, msg[i])
+		}
 	}
 	return strings.Join(msg, "\n")
 }
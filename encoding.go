@@ -7,10 +7,23 @@ import (
 	"fmt"
 	"io"
 	"mime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+// StructuredSyntaxSuffixes maps the structured syntax suffixes of media types (RFC 6839) to the
+// media types whose encoders and decoders also handle the media types using the suffix. For
+// example the decoder registered for "application/json" decodes "application/vnd.bottle+json"
+// bodies unless a decoder is registered for that media type or for the "+json" suffix.
+var StructuredSyntaxSuffixes = map[string]string{
+	"json": "application/json",
+	"xml":  "application/xml",
+	"cbor": "application/cbor",
+}
+
 type (
 	// DecoderFunc instantiates a decoder that decodes data read from the given io reader.
 	DecoderFunc func(r io.Reader) Decoder
@@ -76,6 +89,14 @@ func NewJSONEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
 // NewJSONDecoder is an adapter for the encoding package JSON decoder.
 func NewJSONDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
 
+// NewNDJSONEncoder is an adapter for the encoding package JSON encoder that writes each value on a
+// single line terminated by a newline, the framing of "application/x-ndjson" streams.
+func NewNDJSONEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+
+// NewNDJSONDecoder is an adapter for the encoding package JSON decoder that reads the successive
+// values of a "application/x-ndjson" stream.
+func NewNDJSONDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
 // NewXMLEncoder is an adapter for the encoding package XML encoder.
 func NewXMLEncoder(w io.Writer) Encoder { return xml.NewEncoder(w) }
 
@@ -115,8 +136,9 @@ func (decoder *HTTPDecoder) Decode(v interface{}, body io.Reader, contentType st
 			contentType = mediaType
 		}
 	}
-	p = decoder.pools[contentType]
-	if p == nil {
+	if mt := resolveMediaType(contentType, func(mt string) bool { return decoder.pools[mt] != nil }); mt != "" {
+		p = decoder.pools[mt]
+	} else {
 		p = decoder.pools["*/*"]
 	}
 	if p == nil {
@@ -134,7 +156,9 @@ func (decoder *HTTPDecoder) Decode(v interface{}, body io.Reader, contentType st
 }
 
 // Register sets a specific decoder to be used for the specified content types. If a decoder is
-// already registered, it is overwritten.
+// already registered, it is overwritten. The content type parameters are ignored. A content type
+// may also be a structured syntax suffix such as "+json" in which case the decoder is used for all
+// the media types with that suffix that do not have a decoder registered explicitly.
 func (decoder *HTTPDecoder) Register(f DecoderFunc, contentTypes ...string) {
 	p := newDecodePool(f)
 
@@ -190,16 +214,7 @@ func (p *decoderPool) Put(d Decoder) {
 // using the given writer.
 func (encoder *HTTPEncoder) Encode(v interface{}, resp io.Writer, accept string) error {
 	now := time.Now()
-	if accept == "" {
-		accept = "*/*"
-	}
-	var contentType string
-	for _, t := range encoder.contentTypes {
-		if accept == "*/*" || accept == t {
-			contentType = accept
-			break
-		}
-	}
+	contentType := encoder.negotiate(accept)
 	defer MeasureSince([]string{"goa", "encode", contentType}, now)
 	p := encoder.pools[contentType]
 	if p == nil && contentType != "*/*" {
@@ -220,7 +235,9 @@ func (encoder *HTTPEncoder) Encode(v interface{}, resp io.Writer, accept string)
 }
 
 // Register sets a specific encoder to be used for the specified content types. If an encoder is
-// already registered, it is overwritten.
+// already registered, it is overwritten. The content type parameters are ignored. A content type
+// may also be a structured syntax suffix such as "+json" in which case the encoder is used for all
+// the media types with that suffix that do not have an encoder registered explicitly.
 func (encoder *HTTPEncoder) Register(f EncoderFunc, contentTypes ...string) {
 	p := newEncodePool(f)
 	for _, contentType := range contentTypes {
@@ -236,6 +253,77 @@ func (encoder *HTTPEncoder) Register(f EncoderFunc, contentTypes ...string) {
 	for contentType := range encoder.pools {
 		encoder.contentTypes = append(encoder.contentTypes, contentType)
 	}
+	sort.Strings(encoder.contentTypes)
+}
+
+// negotiate returns the registered content type that best matches the given Accept header value,
+// "*/*" if the header is empty or accepts any media type and "" if no registered content type
+// matches. The media ranges are tried in order of decreasing quality, media ranges with the same
+// quality in the order they appear in the header.
+func (encoder *HTTPEncoder) negotiate(accept string) string {
+	if accept == "" {
+		return "*/*"
+	}
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, r := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(r))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	registered := func(mt string) bool { return encoder.pools[mt] != nil }
+	for _, r := range ranges {
+		if r.mediaType == "*/*" {
+			return "*/*"
+		}
+		if strings.HasSuffix(r.mediaType, "/*") {
+			for _, t := range encoder.contentTypes {
+				if strings.HasPrefix(t, r.mediaType[:len(r.mediaType)-1]) {
+					return t
+				}
+			}
+			continue
+		}
+		if mt := resolveMediaType(r.mediaType, registered); mt != "" {
+			return mt
+		}
+	}
+	return ""
+}
+
+// resolveMediaType returns the registered media type that handles the given media type: the media
+// type itself, its structured syntax suffix or the media type mapped to the suffix by
+// StructuredSyntaxSuffixes. It returns an empty string if none of these is registered.
+func resolveMediaType(mediaType string, registered func(string) bool) string {
+	if registered(mediaType) {
+		return mediaType
+	}
+	i := strings.LastIndex(mediaType, "+")
+	if i == -1 {
+		return ""
+	}
+	suffix := mediaType[i+1:]
+	if registered("+" + suffix) {
+		return "+" + suffix
+	}
+	if mt, ok := StructuredSyntaxSuffixes[suffix]; ok && registered(mt) {
+		return mt
+	}
+	return ""
 }
 
 // newEncodePool checks to see if the EncoderFactory returns reusable encoders and if so, creates
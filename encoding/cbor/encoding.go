@@ -1,7 +1,21 @@
+/*
+Package cbor provides the goa encoder and decoder for the Concise Binary Object Representation
+(RFC 7049), a compact encoding suited to constrained clients such as IoT devices.
+
+Use the Consumes and Produces DSL to register the encoder and decoder with the generated code:
+
+	Consumes("application/json", "application/cbor")
+	Produces("application/json", "application/cbor")
+
+The generated media types and payloads are encoded using their JSON field names. Values of
+attributes of type Any are decoded into map[string]interface{} and []interface{} like with the JSON
+decoder.
+*/
 package cbor
 
 import (
 	"io"
+	"reflect"
 
 	"github.com/goadesign/goa"
 	"github.com/ugorji/go/codec"
@@ -16,6 +30,10 @@ var (
 	_ goa.ResettableEncoder = (*codec.Encoder)(nil)
 )
 
+func init() {
+	Handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+}
+
 // NewDecoder returns a cbor decoder.
 func NewDecoder(r io.Reader) goa.Decoder {
 	return codec.NewDecoder(r, &Handle)
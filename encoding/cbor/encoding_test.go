@@ -0,0 +1,99 @@
+package cbor_test
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/encoding/cbor"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	uuid "github.com/satori/go.uuid"
+)
+
+// winery and bottle mirror the media types generated by goagen.
+type (
+	winery struct {
+		Name string `form:"name" json:"name" xml:"name"`
+	}
+
+	bottle struct {
+		ID        int                    `form:"id" json:"id" xml:"id"`
+		Name      *string                `form:"name,omitempty" json:"name,omitempty" xml:"name,omitempty"`
+		Rating    *float64               `form:"rating,omitempty" json:"rating,omitempty" xml:"rating,omitempty"`
+		CreatedAt *time.Time             `form:"created_at,omitempty" json:"created_at,omitempty" xml:"created_at,omitempty"`
+		Ref       uuid.UUID              `form:"ref" json:"ref" xml:"ref"`
+		Tags      []string               `form:"tags,omitempty" json:"tags,omitempty" xml:"tags,omitempty"`
+		Winery    *winery                `form:"winery,omitempty" json:"winery,omitempty" xml:"winery,omitempty"`
+		Extra     interface{}            `form:"extra,omitempty" json:"extra,omitempty" xml:"extra,omitempty"`
+		Meta      map[string]interface{} `form:"meta,omitempty" json:"meta,omitempty" xml:"meta,omitempty"`
+	}
+)
+
+var _ = Describe("Encoding", func() {
+	var in, out *bottle
+
+	BeforeEach(func() {
+		name := "Number 8"
+		rating := 4.5
+		createdAt := time.Date(2016, 4, 1, 12, 30, 0, 0, time.UTC)
+		in = &bottle{
+			ID:        8,
+			Name:      &name,
+			Rating:    &rating,
+			CreatedAt: &createdAt,
+			Ref:       uuid.NewV4(),
+			Tags:      []string{"red", "dry"},
+			Winery:    &winery{Name: "Merryvale"},
+			Extra:     map[string]interface{}{"vintage": 2012},
+			Meta:      map[string]interface{}{"cellar": map[string]interface{}{"row": 3}},
+		}
+		out = &bottle{}
+	})
+
+	It("round-trips the generated media types", func() {
+		var buf bytes.Buffer
+		Ω(cbor.NewEncoder(&buf).Encode(in)).Should(Succeed())
+		Ω(cbor.NewDecoder(&buf).Decode(out)).Should(Succeed())
+
+		Ω(out.ID).Should(Equal(in.ID))
+		Ω(*out.Name).Should(Equal(*in.Name))
+		Ω(*out.Rating).Should(Equal(*in.Rating))
+		Ω(out.CreatedAt.Equal(*in.CreatedAt)).Should(BeTrue())
+		Ω(out.Ref).Should(Equal(in.Ref))
+		Ω(out.Tags).Should(Equal(in.Tags))
+		Ω(out.Winery).Should(Equal(in.Winery))
+	})
+
+	It("encodes the fields with their JSON names", func() {
+		var buf bytes.Buffer
+		Ω(cbor.NewEncoder(&buf).Encode(in)).Should(Succeed())
+		var fields map[string]interface{}
+		Ω(cbor.NewDecoder(&buf).Decode(&fields)).Should(Succeed())
+		Ω(fields).Should(HaveKey("created_at"))
+		Ω(fields).ShouldNot(HaveKey("CreatedAt"))
+	})
+
+	It("decodes the values of type Any like the JSON decoder", func() {
+		var buf bytes.Buffer
+		Ω(cbor.NewEncoder(&buf).Encode(in)).Should(Succeed())
+		Ω(cbor.NewDecoder(&buf).Decode(out)).Should(Succeed())
+		Ω(out.Extra).Should(BeAssignableToTypeOf(map[string]interface{}{}))
+		Ω(out.Meta["cellar"]).Should(BeAssignableToTypeOf(map[string]interface{}{}))
+	})
+
+	Context("registered with the service", func() {
+		It("round-trips through the HTTP encoder and decoder", func() {
+			encoder := goa.NewHTTPEncoder()
+			encoder.Register(cbor.NewEncoder, "application/cbor")
+			decoder := goa.NewHTTPDecoder()
+			decoder.Register(cbor.NewDecoder, "application/cbor")
+
+			var buf bytes.Buffer
+			Ω(encoder.Encode(in, &buf, "application/cbor")).Should(Succeed())
+			Ω(decoder.Decode(out, &buf, "application/cbor")).Should(Succeed())
+			Ω(out.ID).Should(Equal(in.ID))
+			Ω(*out.Name).Should(Equal(*in.Name))
+		})
+	})
+})
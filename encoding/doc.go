@@ -13,11 +13,14 @@ Use the Consumes and Produces DSL in your design to specify the encoding support
 The built-in encoder and decoder media types are:
 
 	- application/json
+	- application/x-ndjson, newline delimited JSON streams
 	- application/xml
 	- application/gob and application/x-gob
 	- application/msgpack and application/x-msgpack
 	- application/binc and application/x-binc
 	- application/cbor and application/x-cbor
+	- application/x-protobuf, see the protobuf package
+	- application/x-www-form-urlencoded, see the form package
 
 External encoders and decoders can also be specified via the DSL:
 
@@ -0,0 +1,389 @@
+/*
+Package form provides the goa encoder and decoder for the "application/x-www-form-urlencoded" media
+type.
+
+The decoder accepts the bracketed keys sent by HTML forms and most JavaScript libraries so that
+forms may submit payloads with nested objects and arrays:
+
+	name=cellar&address[city]=Napa&address[zip]=94558&tags[]=red&tags[]=dry&bottles[0][name]=Vin
+
+decodes into the payload struct generated for:
+
+	Payload(func() {
+		Attribute("name", String)
+		Attribute("address", func() {
+			Attribute("city", String)
+			Attribute("zip", Integer)
+		})
+		Attribute("tags", ArrayOf(String))
+		Attribute("bottles", ArrayOf(Bottle))
+	})
+
+Keys are matched against the form tags of the struct fields, that is the attribute names, and the
+values are converted to the field types. The generated unmarshal functions then run the payload
+validations as for any other encoding. Unknown keys are ignored. Array elements are either listed with empty brackets or with
+their index, repeated keys without brackets also decode into arrays.
+
+The API must list the media type in its Consumes DSL so that the generated code registers the
+decoder:
+
+	Consumes("application/json", "application/x-www-form-urlencoded")
+
+Unmarshal decodes values that are already parsed, e.g. the query string of a request, using the
+same rules.
+*/
+package form
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+// Enforce that the encoder and decoder satisfy goa.ResettableEncoder and goa.ResettableDecoder at
+// compile time.
+var (
+	_ goa.ResettableDecoder = (*Decoder)(nil)
+	_ goa.ResettableEncoder = (*Encoder)(nil)
+)
+
+type (
+	// Decoder decodes form-urlencoded bodies.
+	Decoder struct {
+		buf *bytes.Buffer
+		r   io.Reader
+	}
+
+	// Encoder encodes values into form-urlencoded bodies.
+	Encoder struct {
+		w io.Writer
+	}
+
+	// node is a value of the tree built from the bracketed keys.
+	node struct {
+		values []string
+		kids   map[string]*node
+	}
+)
+
+// textUnmarshalerType is the type of encoding.TextUnmarshaler.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// NewDecoder returns a form-urlencoded decoder that satisfies goa.Decoder.
+func NewDecoder(r io.Reader) goa.Decoder {
+	return &Decoder{buf: &bytes.Buffer{}, r: r}
+}
+
+// Decode reads the form from the reader and decodes it into v which must be a pointer.
+func (dec *Decoder) Decode(v interface{}) error {
+	if _, err := dec.buf.ReadFrom(dec.r); err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(dec.buf.String())
+	if err != nil {
+		return err
+	}
+	return Unmarshal(values, v)
+}
+
+// Reset stores the new reader and resets the buffer.
+func (dec *Decoder) Reset(r io.Reader) {
+	dec.buf.Reset()
+	dec.r = r
+}
+
+// NewEncoder returns a form-urlencoded encoder that satisfies goa.Encoder.
+func NewEncoder(w io.Writer) goa.Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the form-urlencoded representation of v. v must encode into a JSON object, the
+// keys of nested objects and arrays use the bracket notation accepted by the decoder.
+func (enc *Encoder) Encode(v interface{}) error {
+	values, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(enc.w, values.Encode())
+	return err
+}
+
+// Reset stores the new writer.
+func (enc *Encoder) Reset(w io.Writer) {
+	enc.w = w
+}
+
+// Marshal returns the form values of v. v must encode into a JSON object.
+func Marshal(v interface{}) (url.Values, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&raw); err != nil {
+		return nil, err
+	}
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot encode %T into a form, it must encode into a JSON object", v)
+	}
+	values := make(url.Values)
+	for k, val := range obj {
+		flatten(values, k, val)
+	}
+	return values, nil
+}
+
+// Unmarshal decodes the form values into v which must be a pointer. The keys may use the bracket
+// notation to describe nested objects and arrays.
+func Unmarshal(values url.Values, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cannot decode form into %T, it must be a non-nil pointer", v)
+	}
+	root := &node{}
+	for key, vals := range values {
+		root.insert(parseKey(key), vals)
+	}
+	return root.decode(rv.Elem(), "")
+}
+
+// flatten adds the values of v to values using key as prefix.
+func flatten(values url.Values, key string, v interface{}) {
+	switch actual := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, val := range actual {
+			flatten(values, key+"["+k+"]", val)
+		}
+	case []interface{}:
+		for i, val := range actual {
+			switch val.(type) {
+			case map[string]interface{}, []interface{}:
+				flatten(values, key+"["+strconv.Itoa(i)+"]", val)
+			default:
+				flatten(values, key+"[]", val)
+			}
+		}
+	default:
+		values.Add(key, fmt.Sprint(actual))
+	}
+}
+
+// parseKey splits a bracketed key into its segments, e.g. "a[b][]" into "a", "b" and "".
+func parseKey(key string) []string {
+	i := strings.IndexByte(key, '[')
+	if i <= 0 || !strings.HasSuffix(key, "]") {
+		return []string{key}
+	}
+	segs := []string{key[:i]}
+	for _, s := range strings.Split(key[i+1:len(key)-1], "][") {
+		segs = append(segs, s)
+	}
+	return segs
+}
+
+// insert adds the values to the node identified by the given key segments. An empty last segment
+// appends the values to the parent node.
+func (n *node) insert(segs []string, vals []string) {
+	for _, s := range segs {
+		if s == "" {
+			break
+		}
+		if n.kids == nil {
+			n.kids = make(map[string]*node)
+		}
+		kid, ok := n.kids[s]
+		if !ok {
+			kid = &node{}
+			n.kids[s] = kid
+		}
+		n = kid
+	}
+	n.values = append(n.values, vals...)
+}
+
+// decode initializes v with the content of the node, path is the key of the node used in error
+// messages.
+func (n *node) decode(v reflect.Value, path string) error {
+	if reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) && len(n.values) > 0 {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(n.values[0]))
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return n.decode(v.Elem(), path)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := fieldName(f)
+			if name == "-" {
+				continue
+			}
+			kid, ok := n.kids[name]
+			if !ok {
+				continue
+			}
+			if err := kid.decode(v.Field(i), join(path, name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("cannot decode form field %q into %s, map keys must be strings", path, v.Type())
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for k, kid := range n.kids {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := kid.decode(elem, join(path, k)); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+		return nil
+	case reflect.Slice:
+		elems, err := n.elems(path)
+		if err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, e := range elems {
+			if err := e.decode(s.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return fmt.Errorf("cannot decode form field %q into %s", path, v.Type())
+		}
+		v.Set(reflect.ValueOf(n.raw()))
+		return nil
+	}
+	if len(n.values) == 0 {
+		return fmt.Errorf("form field %q must be a value, got an object", path)
+	}
+	val := n.values[0]
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return invalid(path, val, v.Type())
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return invalid(path, val, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return invalid(path, val, v.Type())
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return invalid(path, val, v.Type())
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot decode form field %q into %s", path, v.Type())
+	}
+	return nil
+}
+
+// elems returns the nodes of the elements of the array described by the node. The elements are
+// either the values of the node or its children sorted by index.
+func (n *node) elems(path string) ([]*node, error) {
+	elems := make([]*node, 0, len(n.values)+len(n.kids))
+	for _, val := range n.values {
+		elems = append(elems, &node{values: []string{val}})
+	}
+	if len(n.kids) == 0 {
+		return elems, nil
+	}
+	indices := make([]int, 0, len(n.kids))
+	for k := range n.kids {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("form field %q must be an array, got key %q", path, k)
+		}
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	for _, i := range indices {
+		elems = append(elems, n.kids[strconv.Itoa(i)])
+	}
+	return elems, nil
+}
+
+// raw returns the generic representation of the node: a map for objects, a slice for repeated
+// values and a string otherwise.
+func (n *node) raw() interface{} {
+	if len(n.kids) > 0 {
+		m := make(map[string]interface{}, len(n.kids))
+		for k, kid := range n.kids {
+			m[k] = kid.raw()
+		}
+		return m
+	}
+	if len(n.values) == 1 {
+		return n.values[0]
+	}
+	vals := make([]interface{}, len(n.values))
+	for i, val := range n.values {
+		vals[i] = val
+	}
+	return vals
+}
+
+// fieldName returns the name of the struct field as given by its form or JSON tag.
+func fieldName(f reflect.StructField) string {
+	for _, key := range []string{"form", "json"} {
+		if tag := f.Tag.Get(key); tag != "" {
+			if name := strings.Split(tag, ",")[0]; name != "" {
+				return name
+			}
+		}
+	}
+	return f.Name
+}
+
+// join returns the bracketed key of the child of the node with the given path.
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "[" + key + "]"
+}
+
+// invalid returns the error produced when a form value cannot be converted to the field type.
+func invalid(path, val string, t reflect.Type) error {
+	return fmt.Errorf("invalid value %q for form field %q, must be a %s", val, path, t)
+}
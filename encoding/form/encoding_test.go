@@ -0,0 +1,149 @@
+package form_test
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/encoding/form"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	uuid "github.com/satori/go.uuid"
+)
+
+// address, bottle and bottlePayload mirror the private payload types generated by goagen.
+type (
+	address struct {
+		City *string `form:"city,omitempty" json:"city,omitempty" xml:"city,omitempty"`
+		Zip  *int    `form:"zip,omitempty" json:"zip,omitempty" xml:"zip,omitempty"`
+	}
+
+	bottle struct {
+		Name   *string  `form:"name,omitempty" json:"name,omitempty" xml:"name,omitempty"`
+		Rating *float64 `form:"rating,omitempty" json:"rating,omitempty" xml:"rating,omitempty"`
+	}
+
+	bottlePayload struct {
+		Name      *string                `form:"name,omitempty" json:"name,omitempty" xml:"name,omitempty"`
+		Sweet     *bool                  `form:"sweet,omitempty" json:"sweet,omitempty" xml:"sweet,omitempty"`
+		CreatedAt *time.Time             `form:"created_at,omitempty" json:"created_at,omitempty" xml:"created_at,omitempty"`
+		Ref       *uuid.UUID             `form:"ref,omitempty" json:"ref,omitempty" xml:"ref,omitempty"`
+		Address   *address               `form:"address,omitempty" json:"address,omitempty" xml:"address,omitempty"`
+		Tags      []string               `form:"tags,omitempty" json:"tags,omitempty" xml:"tags,omitempty"`
+		Years     []int                  `form:"years,omitempty" json:"years,omitempty" xml:"years,omitempty"`
+		Bottles   []*bottle              `form:"bottles,omitempty" json:"bottles,omitempty" xml:"bottles,omitempty"`
+		Meta      map[string]interface{} `form:"meta,omitempty" json:"meta,omitempty" xml:"meta,omitempty"`
+	}
+)
+
+var _ = Describe("Decoder", func() {
+	var body string
+	var payload *bottlePayload
+	var decodeErr error
+
+	JustBeforeEach(func() {
+		payload = &bottlePayload{}
+		decodeErr = form.NewDecoder(strings.NewReader(body)).Decode(payload)
+	})
+
+	Context("with bracketed keys", func() {
+		BeforeEach(func() {
+			body = "name=Number+8&sweet=true&created_at=2016-04-01T12%3A30%3A00Z" +
+				"&ref=6ba7b810-9dad-11d1-80b4-00c04fd430c8" +
+				"&address%5Bcity%5D=Napa&address[zip]=94558" +
+				"&tags[]=red&tags[]=dry&years=2012&years=2014" +
+				"&bottles[1][name]=B&bottles[0][name]=A&bottles[0][rating]=4.5" +
+				"&meta[cellar][row]=3&unknown=1"
+		})
+
+		It("decodes the nested objects and arrays", func() {
+			Ω(decodeErr).ShouldNot(HaveOccurred())
+			Ω(*payload.Name).Should(Equal("Number 8"))
+			Ω(*payload.Sweet).Should(BeTrue())
+			Ω(*payload.CreatedAt).Should(Equal(time.Date(2016, 4, 1, 12, 30, 0, 0, time.UTC)))
+			Ω(payload.Ref.String()).Should(Equal("6ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+			Ω(*payload.Address.City).Should(Equal("Napa"))
+			Ω(*payload.Address.Zip).Should(Equal(94558))
+			Ω(payload.Tags).Should(Equal([]string{"red", "dry"}))
+			Ω(payload.Years).Should(Equal([]int{2012, 2014}))
+			Ω(payload.Bottles).Should(HaveLen(2))
+			Ω(*payload.Bottles[0].Name).Should(Equal("A"))
+			Ω(*payload.Bottles[0].Rating).Should(Equal(4.5))
+			Ω(*payload.Bottles[1].Name).Should(Equal("B"))
+			Ω(payload.Meta).Should(Equal(map[string]interface{}{"cellar": map[string]interface{}{"row": "3"}}))
+		})
+	})
+
+	Context("with a value that does not match the field type", func() {
+		BeforeEach(func() {
+			body = "address[zip]=napa"
+		})
+
+		It("reports the field", func() {
+			Ω(decodeErr).Should(HaveOccurred())
+			Ω(decodeErr.Error()).Should(ContainSubstring(`invalid value "napa" for form field "address[zip]"`))
+		})
+	})
+
+	Context("with an array using keys that are not indices", func() {
+		BeforeEach(func() {
+			body = "bottles[first][name]=A"
+		})
+
+		It("reports the field", func() {
+			Ω(decodeErr).Should(HaveOccurred())
+			Ω(decodeErr.Error()).Should(ContainSubstring(`form field "bottles" must be an array`))
+		})
+	})
+})
+
+var _ = Describe("Encoder", func() {
+	It("round-trips the generated payload types", func() {
+		name, city, zip, rating := "Number 8", "Napa", 94558, 4.5
+		in := &bottlePayload{
+			Name:    &name,
+			Address: &address{City: &city, Zip: &zip},
+			Tags:    []string{"red", "dry"},
+			Bottles: []*bottle{{Name: &name, Rating: &rating}},
+		}
+		var buf bytes.Buffer
+		Ω(form.NewEncoder(&buf).Encode(in)).Should(Succeed())
+		values, err := url.ParseQuery(buf.String())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(values["address[city]"]).Should(Equal([]string{"Napa"}))
+		Ω(values["tags[]"]).Should(Equal([]string{"red", "dry"}))
+		Ω(values["bottles[0][rating]"]).Should(Equal([]string{"4.5"}))
+
+		out := &bottlePayload{}
+		Ω(form.NewDecoder(&buf).Decode(out)).Should(Succeed())
+		Ω(out).Should(Equal(in))
+	})
+
+	It("rejects values that do not encode into objects", func() {
+		Ω(form.NewEncoder(&bytes.Buffer{}).Encode([]string{"a"})).ShouldNot(Succeed())
+	})
+})
+
+var _ = Describe("HTTPDecoder", func() {
+	It("decodes form bodies into the payload", func() {
+		decoder := goa.NewHTTPDecoder()
+		decoder.Register(form.NewDecoder, "application/x-www-form-urlencoded")
+		payload := &bottlePayload{}
+		err := decoder.Decode(payload, strings.NewReader("address[city]=Napa"), "application/x-www-form-urlencoded; charset=utf-8")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(*payload.Address.City).Should(Equal("Napa"))
+	})
+})
+
+var _ = Describe("Unmarshal", func() {
+	It("decodes query strings", func() {
+		values, err := url.ParseQuery("tags[]=red&address[zip]=94558")
+		Ω(err).ShouldNot(HaveOccurred())
+		payload := &bottlePayload{}
+		Ω(form.Unmarshal(values, payload)).Should(Succeed())
+		Ω(payload.Tags).Should(Equal([]string{"red"}))
+		Ω(*payload.Address.Zip).Should(Equal(94558))
+	})
+})
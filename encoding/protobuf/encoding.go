@@ -0,0 +1,109 @@
+/*
+Package protobuf provides the goa encoder and decoder for the "application/x-protobuf" media type.
+
+The encoder and decoder do not depend on a specific Protocol Buffers runtime: they encode the values
+that implement Marshaler and decode into the values that implement Unmarshaler. goagen generates
+these methods for the media types that set the "protobuf:message" metadata to the Go type of the
+message describing them:
+
+	var BottleMedia = MediaType("application/vnd.bottle+json", func() {
+		Metadata("protobuf:message", "github.com/acme/cellar/pb.Bottle")
+		// ...
+	})
+
+The API must also list the media type in its Produces and Consumes DSL so that the generated code
+registers the encoder and decoder:
+
+	Produces("application/json", "application/x-protobuf")
+*/
+package protobuf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/goadesign/goa"
+)
+
+// Enforce that the encoder and decoder satisfy goa.ResettableEncoder and goa.ResettableDecoder at
+// compile time.
+var (
+	_ goa.ResettableDecoder = (*Decoder)(nil)
+	_ goa.ResettableEncoder = (*Encoder)(nil)
+)
+
+type (
+	// Marshaler is the interface implemented by the values that can be encoded into Protocol
+	// Buffers messages.
+	Marshaler interface {
+		// MarshalProto returns the wire encoding of the message describing the value.
+		MarshalProto() ([]byte, error)
+	}
+
+	// Unmarshaler is the interface implemented by the values that can be initialized from
+	// Protocol Buffers messages.
+	Unmarshaler interface {
+		// UnmarshalProto initializes the value from the wire encoding of a message.
+		UnmarshalProto([]byte) error
+	}
+
+	// Decoder decodes Protocol Buffers messages into Unmarshaler values.
+	Decoder struct {
+		buf *bytes.Buffer
+		r   io.Reader
+	}
+
+	// Encoder encodes Marshaler values into Protocol Buffers messages.
+	Encoder struct {
+		w io.Writer
+	}
+)
+
+// NewDecoder returns a Protocol Buffers decoder that satisfies goa.Decoder.
+func NewDecoder(r io.Reader) goa.Decoder {
+	return &Decoder{buf: &bytes.Buffer{}, r: r}
+}
+
+// Decode reads the message from the reader and unmarshals it into v which must implement
+// Unmarshaler.
+func (dec *Decoder) Decode(v interface{}) error {
+	u, ok := v.(Unmarshaler)
+	if !ok {
+		return fmt.Errorf("cannot decode Protocol Buffers message into %T, it does not implement protobuf.Unmarshaler", v)
+	}
+	if _, err := dec.buf.ReadFrom(dec.r); err != nil {
+		return err
+	}
+	return u.UnmarshalProto(dec.buf.Bytes())
+}
+
+// Reset stores the new reader and resets the buffer.
+func (dec *Decoder) Reset(r io.Reader) {
+	dec.buf.Reset()
+	dec.r = r
+}
+
+// NewEncoder returns a Protocol Buffers encoder that satisfies goa.Encoder.
+func NewEncoder(w io.Writer) goa.Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode marshals v which must implement Marshaler and writes the message to the writer.
+func (enc *Encoder) Encode(v interface{}) error {
+	m, ok := v.(Marshaler)
+	if !ok {
+		return fmt.Errorf("cannot encode %T into a Protocol Buffers message, it does not implement protobuf.Marshaler", v)
+	}
+	b, err := m.MarshalProto()
+	if err != nil {
+		return err
+	}
+	_, err = enc.w.Write(b)
+	return err
+}
+
+// Reset stores the new writer.
+func (enc *Encoder) Reset(w io.Writer) {
+	enc.w = w
+}
@@ -0,0 +1,109 @@
+package goa_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// taggedEncoder writes the name of the encoder so that tests can tell which encoder was used.
+type taggedEncoder struct {
+	tag string
+	w   io.Writer
+}
+
+func (e *taggedEncoder) Encode(v interface{}) error {
+	_, err := io.WriteString(e.w, e.tag)
+	return err
+}
+
+func newTaggedEncoder(tag string) goa.EncoderFunc {
+	return func(w io.Writer) goa.Encoder { return &taggedEncoder{tag: tag, w: w} }
+}
+
+var _ = Describe("HTTPDecoder", func() {
+	var decoder *goa.HTTPDecoder
+	var v map[string]interface{}
+
+	BeforeEach(func() {
+		decoder = goa.NewHTTPDecoder()
+		decoder.Register(goa.NewJSONDecoder, "application/json")
+		v = nil
+	})
+
+	It("ignores the content type parameters", func() {
+		err := decoder.Decode(&v, strings.NewReader(`{"a":1}`), "application/json; charset=utf-8")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v).Should(HaveKeyWithValue("a", 1.0))
+	})
+
+	It("decodes media types with a structured syntax suffix", func() {
+		err := decoder.Decode(&v, strings.NewReader(`{"a":1}`), "application/vnd.bottle+json; charset=utf-8")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v).Should(HaveKeyWithValue("a", 1.0))
+	})
+
+	Context("with a decoder registered for a suffix", func() {
+		BeforeEach(func() {
+			decoder.Register(goa.NewXMLDecoder, "+xml")
+		})
+
+		It("uses it for the media types with the suffix", func() {
+			var b struct {
+				XMLName xml.Name `xml:"bottle"`
+				Name    string   `xml:"name"`
+			}
+			err := decoder.Decode(&b, strings.NewReader(`<bottle><name>a</name></bottle>`), "application/atom+xml")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(b.Name).Should(Equal("a"))
+		})
+	})
+})
+
+var _ = Describe("HTTPEncoder", func() {
+	var encoder *goa.HTTPEncoder
+	var accept string
+	var buf bytes.Buffer
+	var encodeErr error
+
+	BeforeEach(func() {
+		encoder = goa.NewHTTPEncoder()
+		encoder.Register(newTaggedEncoder("json"), "application/json", "*/*")
+		encoder.Register(newTaggedEncoder("xml"), "application/xml")
+		encoder.Register(newTaggedEncoder("cbor"), "+cbor")
+		buf.Reset()
+	})
+
+	JustBeforeEach(func() {
+		encodeErr = encoder.Encode(nil, &buf, accept)
+	})
+
+	encodes := func(a, tag string) {
+		Context("with Accept "+a, func() {
+			BeforeEach(func() {
+				accept = a
+			})
+
+			It("uses the "+tag+" encoder", func() {
+				Ω(encodeErr).ShouldNot(HaveOccurred())
+				Ω(buf.String()).Should(Equal(tag))
+			})
+		})
+	}
+
+	encodes("", "json")
+	encodes("application/xml", "xml")
+	encodes("application/xml; charset=utf-8", "xml")
+	encodes("application/vnd.bottle+xml", "xml")
+	encodes("application/vnd.bottle+cbor", "cbor")
+	encodes("text/html, application/xml;q=0.9, */*;q=0.8", "xml")
+	encodes("application/json;q=0.5, application/xml", "xml")
+	encodes("application/xml;q=0, */*", "json")
+	encodes("application/*", "json")
+	encodes("text/csv", "json")
+})
@@ -21,11 +21,31 @@ previously called middleware or action handler into HTTP responses. If the error
 of Error then the corresponding content including the HTTP status is used otherwise an internal
 error is returned. Errors that bubble up all the way to the top (i.e. not handled by the error
 middleware) also generate an internal error response.
+
+Errors support the standard library errors package: errors.Is reports whether an error belongs to
+a given class, the class method Is provides a shorthand:
+
+	if goa.ErrInvalidRequest.Is(err) {
+		// ...
+	}
+
+Errors created from other errors wrap them so that errors.As retrieves the underlying causes, and
+the errors merged with MergeErrors remain available via the Errors method. The helper functions
+also record the values used to build the error detail as fields, for example:
+
+	for _, e := range goa.AsError(err).Errors() {
+		fmt.Println(e.Fields()["param"])
+	}
+
+The causes and fields are not part of the serialized error, the format of error responses is not
+affected.
 */
 package goa
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 )
 
@@ -44,10 +64,22 @@ var (
 	// MaxRequestBodyLength bytes.
 	ErrRequestBodyTooLarge = NewErrorClass("request_too_large", 413)
 
+	// ErrUnsupportedEncoding is the error produced when a request body uses a content coding
+	// other than gzip or deflate.
+	ErrUnsupportedEncoding = NewErrorClass("unsupported_encoding", 415)
+
+	// ErrTimeout is the error produced when an action does not complete within the timeout
+	// defined with the Timeout DSL.
+	ErrTimeout = NewErrorClass("timeout", 504)
+
 	// ErrNoAuthMiddleware is the error produced when no auth middleware is mounted for a
 	// security scheme defined in the design.
 	ErrNoAuthMiddleware = NewErrorClass("no_auth_middleware", 500)
 
+	// ErrNoNamedMiddleware is the error produced when no middleware is registered under a name
+	// referenced in the design, see Service.UseNamed.
+	ErrNoNamedMiddleware = NewErrorClass("no_named_middleware", 500)
+
 	// ErrInvalidFile is the error produced by ServeFiles when requested to serve non-existant
 	// or non-readable files.
 	ErrInvalidFile = NewErrorClass("invalid_file", 404)
@@ -55,6 +87,10 @@ var (
 	// ErrNotFound is the error returned to requests that don't match a registered handler.
 	ErrNotFound = NewErrorClass("not_found", 404)
 
+	// ErrMethodNotAllowed is the error returned to requests that match the path of registered
+	// handlers but not their method.
+	ErrMethodNotAllowed = NewErrorClass("method_not_allowed", 405)
+
 	// ErrInternal is the class of error used for uncaught errors.
 	ErrInternal = NewErrorClass("internal", 500)
 )
@@ -70,6 +106,13 @@ type (
 		Detail string `json:"detail" xml:"detail"`
 		// MetaValues contains additional key/value pairs useful to clients.
 		MetaValues map[string]interface{} `json:"meta,omitempty" xml:"meta,omitempty"`
+
+		// cause is the error wrapped by the error if any.
+		cause error
+		// fields contains the structured information about the error occurrence.
+		fields map[string]interface{}
+		// merged lists the errors merged into the error by MergeErrors.
+		merged []*Error
 	}
 
 	// ErrorClass is an error generating function.
@@ -77,6 +120,7 @@ type (
 	// If the format is a string or a Stringer then the string value is used.
 	// If the format is an error then the string returned by Error() is used.
 	// Otherwise the string produced using fmt.Sprintf("%v") is used.
+	// The errors created from an error wrap it.
 	ErrorClass func(fm interface{}, v ...interface{}) *Error
 )
 
@@ -85,20 +129,28 @@ type (
 func NewErrorClass(code string, status int) ErrorClass {
 	return func(fm interface{}, v ...interface{}) *Error {
 		var f string
+		var cause error
 		switch actual := fm.(type) {
 		case string:
 			f = actual
 		case error:
 			f = actual.Error()
+			cause = actual
 		case fmt.Stringer:
 			f = actual.String()
 		default:
 			f = fmt.Sprintf("%v", actual)
 		}
-		return &Error{Code: code, Status: status, Detail: fmt.Sprintf(f, v...)}
+		return &Error{Code: code, Status: status, Detail: fmt.Sprintf(f, v...), cause: cause}
 	}
 }
 
+// Is returns true if err or one of the errors it wraps was created by an error class with the
+// same code and status as c.
+func (c ErrorClass) Is(err error) bool {
+	return errors.Is(err, c(""))
+}
+
 // MissingPayloadError is the error produced when a request is missing a required payload.
 func MissingPayloadError() *Error {
 	return ErrInvalidRequest("missing required payload")
@@ -106,78 +158,103 @@ func MissingPayloadError() *Error {
 
 // InvalidParamTypeError is the error produced when the type of a parameter does not match the type
 // defined in the design.
+// The error fields are "param", "value" and "expected".
 func InvalidParamTypeError(name string, val interface{}, expected string) *Error {
-	return ErrInvalidRequest("invalid value %#v for parameter %#v, must be a %s", val, name, expected)
+	return ErrInvalidRequest("invalid value %#v for parameter %#v, must be a %s", val, name, expected).
+		WithFields("param", name, "value", val, "expected", expected)
 }
 
 // MissingParamError is the error produced for requests that are missing path or querystring
-// parameters.
+// parameters. The error field is "param".
 func MissingParamError(name string) *Error {
-	return ErrInvalidRequest("missing required parameter %#v", name)
+	return ErrInvalidRequest("missing required parameter %#v", name).WithFields("param", name)
 }
 
 // InvalidAttributeTypeError is the error produced when the type of payload field does not match
-// the type defined in the design.
+// the type defined in the design. The error fields are "context", "value" and "expected".
 func InvalidAttributeTypeError(ctx string, val interface{}, expected string) *Error {
-	return ErrInvalidRequest("type of %s must be %s but got value %#v", ctx, expected, val)
+	return ErrInvalidRequest("type of %s must be %s but got value %#v", ctx, expected, val).
+		WithFields("context", ctx, "value", val, "expected", expected)
 }
 
 // MissingAttributeError is the error produced when a request payload is missing a required field.
+// The error fields are "context" and "attribute".
 func MissingAttributeError(ctx, name string) *Error {
-	return ErrInvalidRequest("attribute %#v of %s is missing and required", name, ctx)
+	return ErrInvalidRequest("attribute %#v of %s is missing and required", name, ctx).
+		WithFields("context", ctx, "attribute", name)
 }
 
-// MissingHeaderError is the error produced when a request is missing a required header.
+// MissingHeaderError is the error produced when a request is missing a required header. The error
+// field is "header".
 func MissingHeaderError(name string) *Error {
-	return ErrInvalidRequest("missing required HTTP header %#v", name)
+	return ErrInvalidRequest("missing required HTTP header %#v", name).WithFields("header", name)
 }
 
 // InvalidEnumValueError is the error produced when the value of a parameter or payload field does
-// not match one the values defined in the design Enum validation.
+// not match one the values defined in the design Enum validation. The error fields are "context",
+// "value" and "allowed".
 func InvalidEnumValueError(ctx string, val interface{}, allowed []interface{}) *Error {
 	elems := make([]string, len(allowed))
 	for i, a := range allowed {
 		elems[i] = fmt.Sprintf("%#v", a)
 	}
-	return ErrInvalidRequest("value of %s must be one of %s but got value %#v", ctx, strings.Join(elems, ", "), val)
+	return ErrInvalidRequest("value of %s must be one of %s but got value %#v", ctx, strings.Join(elems, ", "), val).
+		WithFields("context", ctx, "value", val, "allowed", allowed)
 }
 
 // InvalidFormatError is the error produced when the value of a parameter or payload field does not
-// match the format validation defined in the design.
+// match the format validation defined in the design. The error wraps formatError, its fields are
+// "context", "value" and "format".
 func InvalidFormatError(ctx, target string, format Format, formatError error) *Error {
-	return ErrInvalidRequest("%s must be formatted as a %s but got value %#v, %s", ctx, format, target, formatError.Error())
+	e := ErrInvalidRequest("%s must be formatted as a %s but got value %#v, %s", ctx, format, target, formatError.Error())
+	e.cause = formatError
+	return e.WithFields("context", ctx, "value", target, "format", format)
 }
 
 // InvalidPatternError is the error produced when the value of a parameter or payload field does
-// not match the pattern validation defined in the design.
+// not match the pattern validation defined in the design. The error fields are "context", "value"
+// and "pattern".
 func InvalidPatternError(ctx, target string, pattern string) *Error {
-	return ErrInvalidRequest("%s must match the regexp %#v but got value %#v", ctx, pattern, target)
+	return ErrInvalidRequest("%s must match the regexp %#v but got value %#v", ctx, pattern, target).
+		WithFields("context", ctx, "value", target, "pattern", pattern)
 }
 
 // InvalidRangeError is the error produced when the value of a parameter or payload field does
-// not match the range validation defined in the design.
+// not match the range validation defined in the design. The error fields are "context", "value",
+// "limit" and "min".
 func InvalidRangeError(ctx string, target interface{}, value int, min bool) *Error {
 	comp := "greater or equal"
 	if !min {
 		comp = "lesser or equal"
 	}
-	return ErrInvalidRequest("%s must be %s than %d but got value %#v", ctx, comp, value, target)
+	return ErrInvalidRequest("%s must be %s than %d but got value %#v", ctx, comp, value, target).
+		WithFields("context", ctx, "value", target, "limit", value, "min", min)
 }
 
 // InvalidLengthError is the error produced when the value of a parameter or payload field does
-// not match the length validation defined in the design.
+// not match the length validation defined in the design. The error fields are "context",
+// "value", "length", "limit" and "min".
 func InvalidLengthError(ctx string, target interface{}, ln, value int, min bool) *Error {
 	comp := "greater or equal"
 	if !min {
 		comp = "lesser or equal"
 	}
-	return ErrInvalidRequest("length of %s must be %s than %d but got value %#v (len=%d)", ctx, comp, value, target, ln)
+	return ErrInvalidRequest("length of %s must be %s than %d but got value %#v (len=%d)", ctx, comp, value, target, ln).
+		WithFields("context", ctx, "value", target, "length", ln, "limit", value, "min", min)
 }
 
 // NoAuthMiddleware is the error produced when goa is unable to lookup a auth middleware for a
-// security scheme defined in the design.
+// security scheme defined in the design. The error field is "scheme".
 func NoAuthMiddleware(schemeName string) *Error {
-	return ErrNoAuthMiddleware("Auth middleware for security scheme %s is not mounted", schemeName)
+	return ErrNoAuthMiddleware("Auth middleware for security scheme %s is not mounted", schemeName).
+		WithFields("scheme", schemeName)
+}
+
+// NoNamedMiddleware is the error produced when goa is unable to lookup the middleware registered
+// under a name referenced in the design. The error field is "middleware".
+func NoNamedMiddleware(name string) *Error {
+	return ErrNoNamedMiddleware("middleware %s is not registered", name).
+		WithFields("middleware", name)
 }
 
 // Error returns the error occurrence details.
@@ -190,13 +267,145 @@ func (e *Error) Meta(keyvals ...interface{}) *Error {
 	if e.MetaValues == nil {
 		e.MetaValues = make(map[string]interface{})
 	}
+	setKeyvals(e.MetaValues, keyvals)
+	return e
+}
+
+// WithFields adds key/value pairs to the error fields. Contrary to the metadata the fields are not
+// sent to clients.
+func (e *Error) WithFields(keyvals ...interface{}) *Error {
+	if e.fields == nil {
+		e.fields = make(map[string]interface{})
+	}
+	setKeyvals(e.fields, keyvals)
+	return e
+}
+
+// Fields returns a copy of the structured information about the error occurrence recorded with
+// WithFields, for example the name and value of the invalid parameter for errors created with
+// InvalidParamTypeError.
+func (e *Error) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(e.fields))
+	for k, v := range e.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// Errors returns the errors merged into e with MergeErrors in the order they were merged, or e
+// alone if no error was merged into it.
+func (e *Error) Errors() []*Error {
+	if len(e.merged) == 0 {
+		return []*Error{e}
+	}
+	return e.merged
+}
+
+// Unwrap returns the errors merged into e if any or the error wrapped by e otherwise, see
+// errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	if len(e.merged) > 0 {
+		errs := make([]error, len(e.merged))
+		for i, m := range e.merged {
+			errs[i] = m
+		}
+		return errs
+	}
+	if e.cause != nil {
+		return []error{e.cause}
+	}
+	return nil
+}
+
+// Is returns true if target is an Error with the same code and status as e, that is an error of
+// the same class. This makes it possible to test the class of an error with errors.Is, see also
+// ErrorClass.Is.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t != nil && t.Code == e.Code && t.Status == e.Status
+}
+
+// AsError returns the Error that err is or wraps. It returns an internal error wrapping err if
+// err is not and does not wrap an Error and nil if err is nil.
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	return asError(err)
+}
+
+// setKeyvals sets the given key/value pairs in m.
+func setKeyvals(m map[string]interface{}, keyvals []interface{}) {
 	for i := 0; i < len(keyvals); i += 2 {
 		k := keyvals[i]
 		var v interface{} = "MISSING"
 		if i+1 < len(keyvals) {
 			v = keyvals[i+1]
 		}
-		e.MetaValues[fmt.Sprintf("%v", k)] = v
+		m[fmt.Sprintf("%v", k)] = v
+	}
+}
+
+// clone returns a copy of e that does not share its metadata and fields with e.
+func (e *Error) clone() *Error {
+	c := *e
+	if e.MetaValues != nil {
+		c.MetaValues = make(map[string]interface{}, len(e.MetaValues))
+		for k, v := range e.MetaValues {
+			c.MetaValues[k] = v
+		}
+	}
+	c.fields = e.Fields()
+	return &c
+}
+
+// ActionError builds the error sent by the response helpers generated for error responses. It
+// produces an Error with the given status and records the names of the resource and action as
+// well as the request trace ID (if not empty) in the error metadata. If err is already an Error
+// (e.g. a validation error produced by the generated code) or wraps one its code, detail and
+// metadata are preserved, otherwise the code is derived from the status and the detail is the
+// error message. The resulting error wraps err.
+//
+// The details of the validation errors merged into err - that is the errors created by the
+// ErrInvalidRequest class - are recorded under the "validation" metadata key as a list of maps
+// containing the error detail and fields.
+func ActionError(err error, status int, resource, action, traceID string) *Error {
+	var e *Error
+	var ge *Error
+	if errors.As(err, &ge) {
+		e = ge.clone()
+		if e.MetaValues == nil {
+			e.MetaValues = make(map[string]interface{})
+		}
+		if err != error(ge) {
+			e.cause = err
+		}
+	} else {
+		detail := http.StatusText(status)
+		if err != nil {
+			detail = err.Error()
+		}
+		code := strings.ToLower(strings.Replace(http.StatusText(status), " ", "_", -1))
+		e = &Error{Code: code, Detail: detail, cause: err}
+	}
+	e.Status = status
+	e.Meta("resource", resource, "action", action)
+	if traceID != "" {
+		e.Meta("trace_id", traceID)
+	}
+	if ge != nil {
+		var details []map[string]interface{}
+		for _, m := range ge.Errors() {
+			if !ErrInvalidRequest.Is(m) {
+				continue
+			}
+			d := m.Fields()
+			d["detail"] = m.Detail
+			details = append(details, d)
+		}
+		if len(details) > 0 {
+			e.Meta("validation", details)
+		}
 	}
 	return e
 }
@@ -213,7 +422,8 @@ func (e *Error) Meta(keyvals ...interface{}) *Error {
 // into e's where values in e with identical keys to values in other get overwritten.
 //
 // Merge returns the updated error. This is useful in case the error was initially nil in
-// which case other is returned.
+// which case other is returned. The errors that were merged are available via the Errors method of
+// the result.
 func MergeErrors(err, other error) error {
 	if err == nil {
 		if other == nil {
@@ -226,6 +436,11 @@ func MergeErrors(err, other error) error {
 	}
 	e := asError(err)
 	o := asError(other)
+	merged := e.merged
+	if len(merged) == 0 {
+		merged = []*Error{e.clone()}
+	}
+	e.merged = append(merged, o.Errors()...)
 	switch {
 	case e.Status == 500 || o.Status == 500:
 		if e.Status != 500 {
@@ -237,16 +452,20 @@ func MergeErrors(err, other error) error {
 		e.Code = "bad_request"
 	}
 	e.Detail = e.Detail + "; " + o.Detail
+	if e.MetaValues == nil && len(o.MetaValues) > 0 {
+		e.MetaValues = make(map[string]interface{}, len(o.MetaValues))
+	}
 	for n, v := range o.MetaValues {
 		e.MetaValues[n] = v
 	}
 	return e
 }
 
+// asError returns the Error that err is or wraps, or an internal error wrapping err.
 func asError(err error) *Error {
-	e, ok := err.(*Error)
-	if !ok {
-		return &Error{Status: 500, Code: "internal_error", Detail: err.Error()}
+	var e *Error
+	if !errors.As(err, &e) {
+		return &Error{Status: 500, Code: "internal_error", Detail: err.Error(), cause: err}
 	}
 	return e
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/goadesign/goa"
 	. "github.com/onsi/ginkgo"
@@ -268,6 +269,101 @@ var _ = Describe("InvalidLengthError", func() {
 	})
 })
 
+var _ = Describe("ActionError", func() {
+	var err error
+	var traceID string
+	var actErr *goa.Error
+
+	BeforeEach(func() {
+		err = nil
+		traceID = ""
+	})
+
+	JustBeforeEach(func() {
+		actErr = goa.ActionError(err, 400, "bottle", "show", traceID)
+	})
+
+	Context("with a goa error", func() {
+		BeforeEach(func() {
+			err = goa.InvalidParamTypeError("id", "foo", "integer").Meta("param", "id")
+			traceID = "trace"
+		})
+
+		It("keeps the error details and adds the request metadata", func() {
+			Ω(actErr).ShouldNot(BeIdenticalTo(err))
+			Ω(actErr.Code).Should(Equal("invalid_request"))
+			Ω(actErr.Status).Should(Equal(400))
+			Ω(actErr.Detail).Should(Equal(err.(*goa.Error).Detail))
+			Ω(actErr.MetaValues).Should(HaveKeyWithValue("param", "id"))
+			Ω(actErr.MetaValues).Should(HaveKeyWithValue("resource", "bottle"))
+			Ω(actErr.MetaValues).Should(HaveKeyWithValue("action", "show"))
+			Ω(actErr.MetaValues).Should(HaveKeyWithValue("trace_id", "trace"))
+			Ω(err.(*goa.Error).MetaValues).ShouldNot(HaveKey("resource"))
+		})
+	})
+
+	Context("with merged validation errors", func() {
+		BeforeEach(func() {
+			e := goa.MergeErrors(goa.MissingParamError("id"), goa.InvalidParamTypeError("limit", "foo", "integer"))
+			err = e
+		})
+
+		It("records the validation details", func() {
+			Ω(actErr.MetaValues).Should(HaveKey("validation"))
+			details := actErr.MetaValues["validation"].([]map[string]interface{})
+			Ω(details).Should(HaveLen(2))
+			Ω(details[0]).Should(HaveKeyWithValue("param", "id"))
+			Ω(details[0]).Should(HaveKeyWithValue("detail", `missing required parameter "id"`))
+			Ω(details[1]).Should(HaveKeyWithValue("param", "limit"))
+			Ω(details[1]).Should(HaveKeyWithValue("value", "foo"))
+			Ω(details[1]).Should(HaveKeyWithValue("expected", "integer"))
+		})
+	})
+
+	Context("with an error wrapping a goa error", func() {
+		var gerr *goa.Error
+
+		BeforeEach(func() {
+			gerr = goa.MissingParamError("id")
+			err = fmt.Errorf("show: %w", gerr)
+		})
+
+		It("keeps the goa error details and wraps the error", func() {
+			Ω(actErr.Code).Should(Equal("invalid_request"))
+			Ω(actErr.Detail).Should(Equal(gerr.Detail))
+			Ω(actErr.Fields()).Should(HaveKeyWithValue("param", "id"))
+			Ω(errors.Is(actErr, err)).Should(BeTrue())
+		})
+	})
+
+	Context("with a plain error", func() {
+		BeforeEach(func() {
+			err = errors.New("boom")
+		})
+
+		It("derives the code from the status", func() {
+			Ω(actErr.Code).Should(Equal("bad_request"))
+			Ω(actErr.Status).Should(Equal(400))
+			Ω(actErr.Detail).Should(Equal("boom"))
+			Ω(actErr.MetaValues).ShouldNot(HaveKey("trace_id"))
+		})
+
+		It("wraps the error", func() {
+			Ω(errors.Is(actErr, err)).Should(BeTrue())
+		})
+
+		It("does not record validation details", func() {
+			Ω(actErr.MetaValues).ShouldNot(HaveKey("validation"))
+		})
+	})
+
+	Context("with a nil error", func() {
+		It("uses the status text as detail", func() {
+			Ω(actErr.Detail).Should(Equal("Bad Request"))
+		})
+	})
+})
+
 var _ = Describe("Merge", func() {
 	var err, err2 error
 	var mErr *goa.Error
@@ -422,3 +518,76 @@ var _ = Describe("Merge", func() {
 	})
 
 })
+
+var _ = Describe("Error classes", func() {
+	It("match the errors they create", func() {
+		err := goa.MissingParamError("id")
+		Ω(goa.ErrInvalidRequest.Is(err)).Should(BeTrue())
+		Ω(goa.ErrBadRequest.Is(err)).Should(BeFalse())
+		Ω(errors.Is(err, goa.ErrInvalidRequest("other"))).Should(BeTrue())
+	})
+
+	It("match wrapped errors", func() {
+		err := fmt.Errorf("show: %w", goa.ErrNotFound("bottle"))
+		Ω(goa.ErrNotFound.Is(err)).Should(BeTrue())
+		Ω(goa.ErrInternal.Is(err)).Should(BeFalse())
+	})
+
+	It("wrap the errors they are created from", func() {
+		cause := &os.PathError{Op: "open", Path: "bottle", Err: os.ErrNotExist}
+		err := goa.ErrInvalidFile(cause)
+		Ω(err.Detail).Should(Equal(cause.Error()))
+		var pathErr *os.PathError
+		Ω(errors.As(err, &pathErr)).Should(BeTrue())
+		Ω(pathErr).Should(BeIdenticalTo(cause))
+		Ω(errors.Is(err, os.ErrNotExist)).Should(BeTrue())
+	})
+})
+
+var _ = Describe("Error fields", func() {
+	It("record the values used to build the error", func() {
+		err := goa.InvalidParamTypeError("id", "foo", "integer")
+		Ω(err.Fields()).Should(Equal(map[string]interface{}{"param": "id", "value": "foo", "expected": "integer"}))
+	})
+
+	It("are not serialized", func() {
+		b, err := json.Marshal(goa.MissingParamError("id"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(b)).Should(Equal(`{"code":"invalid_request","status":400,"detail":"missing required parameter \"id\""}`))
+	})
+
+	It("wrap the format error", func() {
+		formatErr := errors.New("invalid email")
+		err := goa.InvalidFormatError("payload.email", "foo", goa.FormatEmail, formatErr)
+		Ω(errors.Is(err, formatErr)).Should(BeTrue())
+		Ω(err.Fields()).Should(HaveKeyWithValue("format", goa.Format(goa.FormatEmail)))
+	})
+})
+
+var _ = Describe("Merged errors", func() {
+	var formatErr error
+	var merged *goa.Error
+
+	BeforeEach(func() {
+		formatErr = errors.New("invalid email")
+		var err error
+		err = goa.MergeErrors(err, goa.MissingParamError("id"))
+		err = goa.MergeErrors(err, goa.InvalidFormatError("payload.email", "foo", goa.FormatEmail, formatErr))
+		err = goa.MergeErrors(err, goa.MissingHeaderError("X-Key"))
+		merged = goa.AsError(err)
+	})
+
+	It("list the merged errors", func() {
+		errs := merged.Errors()
+		Ω(errs).Should(HaveLen(3))
+		Ω(errs[0].Fields()).Should(HaveKeyWithValue("param", "id"))
+		Ω(errs[1].Fields()).Should(HaveKeyWithValue("context", "payload.email"))
+		Ω(errs[2].Fields()).Should(HaveKeyWithValue("header", "X-Key"))
+		Ω(errs[0].Detail).Should(Equal(`missing required parameter "id"`))
+	})
+
+	It("wrap the merged errors", func() {
+		Ω(errors.Is(merged, formatErr)).Should(BeTrue())
+		Ω(goa.ErrInvalidRequest.Is(merged)).Should(BeTrue())
+	})
+})
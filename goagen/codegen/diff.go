@@ -0,0 +1,188 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around the changes in UnifiedDiff hunks.
+const diffContext = 3
+
+// maxDiffCells bounds the size of the table used to compute the longest common subsequence of
+// the changed lines. Changes larger than that are rendered as a single replacement.
+const maxDiffCells = 4 * 1024 * 1024
+
+// diffOp is a line of an edit script: an unchanged (' '), removed ('-') or added ('+') line.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// UnifiedDiff returns the unified diff between the from and to contents of the file at path. From
+// or to may be nil to denote a file that is created or deleted. UnifiedDiff returns an empty
+// string if the contents are identical.
+func UnifiedDiff(path string, from, to []byte) string {
+	if bytes.Equal(from, to) {
+		return ""
+	}
+	path = strings.TrimPrefix(path, "/")
+	fromName, toName := "a/"+path, "b/"+path
+	if from == nil {
+		fromName = "/dev/null"
+	}
+	if to == nil {
+		toName = "/dev/null"
+	}
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of the hunk that contains it.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		end, unchanged := first, 0
+		for end < len(ops) && unchanged <= 2*diffContext {
+			if ops[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			end++
+		}
+		end -= unchanged
+		lo, hi := first-diffContext, end+diffContext
+		if lo < start {
+			lo = start
+		}
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+		writeHunk(&buf, ops, lo, hi)
+		start = hi
+	}
+	return buf.String()
+}
+
+// writeHunk writes the hunk made of the operations ops[lo:hi].
+func writeHunk(buf *bytes.Buffer, ops []diffOp, lo, hi int) {
+	fromLine, toLine := 1, 1
+	for _, op := range ops[:lo] {
+		if op.kind != '+' {
+			fromLine++
+		}
+		if op.kind != '-' {
+			toLine++
+		}
+	}
+	var fromCount, toCount int
+	for _, op := range ops[lo:hi] {
+		if op.kind != '+' {
+			fromCount++
+		}
+		if op.kind != '-' {
+			toCount++
+		}
+	}
+	if fromCount == 0 {
+		fromLine--
+	}
+	if toCount == 0 {
+		toLine--
+	}
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+	for _, op := range ops[lo:hi] {
+		buf.WriteByte(op.kind)
+		buf.WriteString(op.line)
+		buf.WriteByte('\n')
+	}
+}
+
+// splitLines splits content into lines, the last line needs not end with a newline.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+// diffLines computes the edit script that transforms a into b. The common prefix and suffix are
+// trimmed before computing the longest common subsequence of the remaining lines.
+func diffLines(a, b []string) []diffOp {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a[:prefix] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(ma)+1)*(len(mb)+1) > maxDiffCells {
+		for _, l := range ma {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range mb {
+			ops = append(ops, diffOp{'+', l})
+		}
+	} else {
+		ops = append(ops, lcsDiff(ma, mb)...)
+	}
+	for _, l := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+// lcsDiff computes the edit script that transforms a into b using the longest common subsequence
+// of their lines.
+func lcsDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,62 @@
+package codegen_test
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnifiedDiff", func() {
+	var from, to []byte
+	var diff string
+
+	JustBeforeEach(func() {
+		diff = codegen.UnifiedDiff("app/test.go", from, to)
+	})
+
+	Context("with identical contents", func() {
+		BeforeEach(func() {
+			from = []byte("package app\n")
+			to = []byte("package app\n")
+		})
+
+		It("returns an empty diff", func() {
+			Ω(diff).Should(BeEmpty())
+		})
+	})
+
+	Context("with a new file", func() {
+		BeforeEach(func() {
+			from = nil
+			to = []byte("package app\n\nvar a = 1\n")
+		})
+
+		It("adds all the lines", func() {
+			Ω(diff).Should(Equal("--- /dev/null\n+++ b/app/test.go\n@@ -0,0 +1,3 @@\n+package app\n+\n+var a = 1\n"))
+		})
+	})
+
+	Context("with changes far apart", func() {
+		BeforeEach(func() {
+			from = []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n")
+			to = []byte("A\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n")
+		})
+
+		It("produces a hunk per change", func() {
+			Ω(diff).Should(Equal(`--- a/app/test.go
++++ b/app/test.go
+@@ -1,4 +1,4 @@
+-a
++A
+ b
+ c
+ d
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+`))
+		})
+	})
+})
@@ -15,21 +15,38 @@ import (
 func CommandLine() string {
 	// We don't use the full path to the tool so that running goagen multiple times doesn't
 	// end up creating different command line comments (because of the temporary directory it
-	// runs in).
+	// runs in). Paths are also made relative to the working directory or GOPATH so that running
+	// goagen on different machines produces the same comments.
 	var param string
 	if len(os.Args) > 1 {
 		args := make([]string, len(os.Args)-1)
 		gopaths := filepath.SplitList(os.Getenv("GOPATH"))
+		cwd, _ := os.Getwd()
 		for i, a := range os.Args[1:] {
+			args[i] = a
+			prefix, path := "", a
+			if strings.HasPrefix(a, "-") {
+				idx := strings.Index(a, "=")
+				if idx == -1 {
+					continue
+				}
+				prefix, path = a[:idx+1], a[idx+1:]
+			}
+			if !filepath.IsAbs(path) {
+				continue
+			}
+			if cwd != "" && cwd != string(filepath.Separator) {
+				if rel, ok := relPath(cwd, path); ok {
+					args[i] = prefix + "." + rel
+					continue
+				}
+			}
 			for _, p := range gopaths {
-				if strings.Contains(a, p) {
-					args[i] = strings.Replace(a, p, "$(GOPATH)", -1)
+				if rel, ok := relPath(p, path); ok {
+					args[i] = prefix + "$(GOPATH)" + rel
 					break
 				}
 			}
-			if args[i] == "" {
-				args[i] = a
-			}
 		}
 		param = strings.Join(args, " ")
 	}
@@ -37,6 +54,22 @@ func CommandLine() string {
 	return strings.Replace(cmd, " --", "\n\t--", -1)
 }
 
+// relPath returns the path of target relative to base prefixed with the path separator or the
+// empty string if target is base. It returns false if target is not under base.
+func relPath(base, target string) (string, bool) {
+	if base == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(base, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return string(filepath.Separator) + rel, true
+}
+
 // Comment produces line comments by concatenating the given strings and producing 80 characters
 // long lines starting with "//"
 func Comment(elems ...string) string {
@@ -0,0 +1,74 @@
+package codegen_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CommandLine", func() {
+	var args []string
+
+	BeforeEach(func() {
+		args = os.Args
+	})
+
+	AfterEach(func() {
+		os.Args = args
+	})
+
+	Context("with paths under the working directory", func() {
+		BeforeEach(func() {
+			cwd, err := os.Getwd()
+			Ω(err).ShouldNot(HaveOccurred())
+			os.Args = []string{"/tmp/goagen123/goagen", "--design=github.com/foo/design", "--out=" + filepath.Join(cwd, "gen")}
+		})
+
+		It("makes the paths relative to the working directory", func() {
+			Ω(codegen.CommandLine()).Should(Equal("$ goagen\n\t--design=github.com/foo/design\n\t--out=." + string(filepath.Separator) + "gen"))
+		})
+	})
+
+	Context("with paths in a sibling of the working directory", func() {
+		var sibling, gopath string
+
+		BeforeEach(func() {
+			cwd, err := os.Getwd()
+			Ω(err).ShouldNot(HaveOccurred())
+			sibling = cwd + "2" + string(filepath.Separator) + "gen"
+			os.Args = []string{"goagen", "--out=" + sibling}
+			gopath = os.Getenv("GOPATH")
+			os.Setenv("GOPATH", filepath.Join(os.TempDir(), "goagen-gopath"))
+		})
+
+		AfterEach(func() {
+			os.Setenv("GOPATH", gopath)
+		})
+
+		It("does not rewrite the paths", func() {
+			Ω(codegen.CommandLine()).Should(Equal("$ goagen\n\t--out=" + sibling))
+		})
+	})
+
+	Context("with paths under GOPATH", func() {
+		var gopath string
+
+		BeforeEach(func() {
+			gopath = os.Getenv("GOPATH")
+			os.Setenv("GOPATH", filepath.Join(string(filepath.Separator)+"go", "src", "app"))
+			os.Args = []string{"goagen", "--out=" + filepath.Join(string(filepath.Separator)+"go", "src", "app", "gen"), "--design=" + filepath.Join(string(filepath.Separator)+"go", "src", "app2")}
+		})
+
+		AfterEach(func() {
+			os.Setenv("GOPATH", gopath)
+		})
+
+		It("makes the paths relative to GOPATH", func() {
+			sep := string(filepath.Separator)
+			Ω(codegen.CommandLine()).Should(Equal("$ goagen\n\t--out=$(GOPATH)" + sep + "gen\n\t--design=" + sep + "go" + sep + "src" + sep + "app2"))
+		})
+	})
+})
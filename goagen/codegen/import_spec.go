@@ -1,6 +1,15 @@
 package codegen
 
-import "fmt"
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
 
 // ImportSpec defines a generated import statement.
 type ImportSpec struct {
@@ -18,6 +27,16 @@ func SimpleImport(path string) *ImportSpec {
 	return &ImportSpec{Path: path}
 }
 
+// ContextImport returns the import of the package that defines the context.Context interface used
+// by the generated code: the standard library "context" package if std is true, the
+// "golang.org/x/net/context" package otherwise.
+func ContextImport(std bool) *ImportSpec {
+	if std {
+		return SimpleImport("context")
+	}
+	return SimpleImport("golang.org/x/net/context")
+}
+
 // Code returns the Go import statement for the ImportSpec.
 func (s *ImportSpec) Code() string {
 	if len(s.Name) > 0 {
@@ -25,3 +44,54 @@ func (s *ImportSpec) Code() string {
 	}
 	return fmt.Sprintf(`"%s"`, s.Path)
 }
+
+var (
+	// importNames caches the package names computed by ImportName indexed by import path.
+	importNames = make(map[string]string)
+	// importNamesMu protects importNames.
+	importNamesMu sync.Mutex
+)
+
+// ImportName returns the name of the package imported with the given path. The name is read from
+// the package source if it can be found and guessed from the path the same way goimports does
+// otherwise: the major version suffix (e.g. "v2" or ".v2") and "go-" prefix are stripped from the
+// last path element.
+func ImportName(importPath string) string {
+	importNamesMu.Lock()
+	defer importNamesMu.Unlock()
+	if name, ok := importNames[importPath]; ok {
+		return name
+	}
+	name := assumedImportName(importPath)
+	if strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".") {
+		// Not a standard library package, look it up.
+		wd, err := os.Getwd()
+		if err != nil {
+			wd = "."
+		}
+		if p, err := build.Import(importPath, wd, 0); err == nil && p.Name != "" {
+			name = p.Name
+		}
+	}
+	importNames[importPath] = name
+	return name
+}
+
+// assumedImportName returns the package name goimports assumes for the given import path.
+func assumedImportName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			if dir := path.Dir(importPath); dir != "." {
+				base = path.Base(dir)
+			}
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
@@ -0,0 +1,136 @@
+package codegen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestFile is the name of the file that lists the files generated in an output directory.
+const ManifestFile = ".goagen-manifest.json"
+
+// Manifest lists the files generated in a directory. Generators write a manifest in their output
+// directory so that the next runs only delete the files they generated and keep any other file
+// that may have been added to the directory.
+type Manifest struct {
+	// Files maps the slash separated paths of the generated files relative to the manifest
+	// directory to the hex encoded SHA-256 hashes of their content.
+	Files map[string]string `json:"files"`
+}
+
+// ReadManifest reads the manifest of dir. It returns nil and no error if dir has no manifest.
+func ReadManifest(dir string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// WriteManifest writes the manifest of dir listing the regular files in files that are located
+// under dir.
+func WriteManifest(dir string, files []string) error {
+	b, err := manifestContent(dir, files)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, ManifestFile), b, 0644)
+}
+
+// manifestContent returns the JSON encoded manifest of dir listing the regular files in files that
+// are located under dir.
+func manifestContent(dir string, files []string) ([]byte, error) {
+	m := Manifest{Files: make(map[string]string)}
+	for _, f := range files {
+		rel, err := filepath.Rel(dir, f)
+		if err != nil || rel == ManifestFile || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(b)
+		m.Files[filepath.ToSlash(rel)] = hex.EncodeToString(sum[:])
+	}
+	b, err := json.MarshalIndent(&m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// CleanDir deletes the files generated in dir by the previous run of a generator, the manifest
+// and the directories left empty. The files that are not listed in the manifest are kept. CleanDir
+// deletes dir altogether if it has no manifest, for example because it was produced by an older
+// version of goagen, as the generated files cannot be told apart from the others in this case.
+func CleanDir(dir string) error {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return os.RemoveAll(dir)
+	}
+	files := []string{filepath.Join(dir, ManifestFile)}
+	dirs := []string{dir}
+	for rel := range m.Files {
+		f := filepath.Join(dir, filepath.FromSlash(rel))
+		files = append(files, f)
+		for d := filepath.Dir(f); d != dir && d != "."; d = filepath.Dir(d) {
+			dirs = append(dirs, d)
+		}
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	removeEmptyDirs(dirs)
+	return nil
+}
+
+// RemoveGenerated deletes the given generated files, the manifests of the given directories and
+// the directories if they are left empty. It is used to clean up after a failed or interrupted generation without deleting files
+// that goagen did not generate.
+func RemoveGenerated(files []string) {
+	var dirs []string
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			os.Remove(filepath.Join(f, ManifestFile))
+			dirs = append(dirs, f)
+			continue
+		}
+		os.Remove(f)
+	}
+	removeEmptyDirs(dirs)
+}
+
+// removeEmptyDirs deletes the given directories that are empty.
+func removeEmptyDirs(dirs []string) {
+	// Delete the deepest directories first so that parents may become empty.
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, d := range dirs {
+		os.Remove(d) // fails if the directory is not empty
+	}
+}
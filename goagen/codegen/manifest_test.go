@@ -0,0 +1,87 @@
+package codegen_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Manifest", func() {
+	var dir string
+	var generated []string
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Ω(os.MkdirAll(filepath.Dir(path), 0755)).ShouldNot(HaveOccurred())
+		Ω(ioutil.WriteFile(path, []byte(content), 0644)).ShouldNot(HaveOccurred())
+		return path
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "manifest")
+		Ω(err).ShouldNot(HaveOccurred())
+		generated = []string{
+			dir,
+			write("contexts.go", "package app"),
+			write("test/bottle.go", "package test"),
+		}
+		write("notes.txt", "user file")
+		write("extra/helpers.go", "package extra")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("lists the generated files with their hashes", func() {
+		Ω(codegen.WriteManifest(dir, generated)).ShouldNot(HaveOccurred())
+		m, err := codegen.ReadManifest(dir)
+		Ω(err).ShouldNot(HaveOccurred())
+		sum := sha256.Sum256([]byte("package app"))
+		Ω(m.Files).Should(HaveLen(2))
+		Ω(m.Files).Should(HaveKeyWithValue("contexts.go", hex.EncodeToString(sum[:])))
+		Ω(m.Files).Should(HaveKey("test/bottle.go"))
+	})
+
+	It("only deletes the files listed in the manifest", func() {
+		Ω(codegen.WriteManifest(dir, generated)).ShouldNot(HaveOccurred())
+		Ω(codegen.CleanDir(dir)).ShouldNot(HaveOccurred())
+		Ω(exists("contexts.go")).Should(BeFalse())
+		Ω(exists("test")).Should(BeFalse())
+		Ω(exists(codegen.ManifestFile)).Should(BeFalse())
+		Ω(exists("notes.txt")).Should(BeTrue())
+		Ω(exists("extra/helpers.go")).Should(BeTrue())
+	})
+
+	It("deletes the generated files on cleanup", func() {
+		Ω(codegen.WriteManifest(dir, generated)).ShouldNot(HaveOccurred())
+		codegen.RemoveGenerated(generated)
+		Ω(exists(codegen.ManifestFile)).Should(BeFalse())
+		Ω(exists("contexts.go")).Should(BeFalse())
+		Ω(exists("test/bottle.go")).Should(BeFalse())
+		Ω(exists("notes.txt")).Should(BeTrue())
+	})
+
+	Context("with no manifest", func() {
+		It("deletes the directory", func() {
+			m, err := codegen.ReadManifest(dir)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(m).Should(BeNil())
+			Ω(codegen.CleanDir(dir)).ShouldNot(HaveOccurred())
+			_, err = os.Stat(dir)
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
+	})
+})
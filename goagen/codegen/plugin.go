@@ -0,0 +1,85 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+)
+
+type (
+	// Generator is the interface implemented by the code generators. The built-in generators
+	// implement it and so must the generator plugins run with "goagen gen".
+	Generator interface {
+		// Generate generates the code for the given evaluated API design and returns the
+		// paths of the generated files.
+		Generate(api *design.APIDefinition) ([]string, error)
+		// Cleanup removes the files generated by the last invocation of Generate. It is
+		// called when Generate fails.
+		Cleanup()
+	}
+
+	// GeneratorFactory is the signature of the function that generator plugins must expose
+	// under the name "NewGenerator". goagen calls it with the options given on the command
+	// line then runs the returned generator against the evaluated design.
+	GeneratorFactory func(opts *PluginOptions) (Generator, error)
+
+	// PluginOptions contains the options given to a generator plugin.
+	PluginOptions struct {
+		// OutDir is the path to the output directory.
+		OutDir string
+		// DesignPkgPath is the Go import path to the design package.
+		DesignPkgPath string
+		// Options contains the plugin specific options given with the "goagen gen --opt"
+		// flag indexed by name.
+		Options map[string]string
+	}
+)
+
+// ParsePluginOptions parses the command line arguments given to a generator plugin. The arguments
+// must be of the form "--name=value", the "out" and "design" arguments initialize the OutDir and
+// DesignPkgPath fields and any other argument is added to Options.
+func ParsePluginOptions(args []string) (*PluginOptions, error) {
+	opts := &PluginOptions{Options: make(map[string]string)}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			return nil, fmt.Errorf("invalid plugin argument %#v", arg)
+		}
+		elems := strings.SplitN(arg[2:], "=", 2)
+		name, value := elems[0], "true"
+		if len(elems) == 2 {
+			value = elems[1]
+		}
+		switch name {
+		case "":
+			return nil, fmt.Errorf("invalid plugin argument %#v", arg)
+		case "out":
+			opts.OutDir = value
+		case "design":
+			opts.DesignPkgPath = value
+		default:
+			opts.Options[name] = value
+		}
+	}
+	return opts, nil
+}
+
+// RunPlugin creates a generator using factory and the options parsed from args and runs it
+// against the evaluated design. This is the entry point of the tools compiled by goagen for
+// generator plugins.
+func RunPlugin(factory GeneratorFactory, args []string) ([]string, error) {
+	opts, err := ParsePluginOptions(args)
+	if err != nil {
+		return nil, err
+	}
+	g, err := factory(opts)
+	if err != nil {
+		return nil, err
+	}
+	files, err := g.Generate(design.Design)
+	if err != nil {
+		g.Cleanup()
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,79 @@
+package codegen_test
+
+import (
+	"errors"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type testPlugin struct {
+	opts    *codegen.PluginOptions
+	api     *design.APIDefinition
+	err     error
+	cleaned bool
+}
+
+func (p *testPlugin) Generate(api *design.APIDefinition) ([]string, error) {
+	p.api = api
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []string{p.opts.OutDir + "/audit.go"}, nil
+}
+
+func (p *testPlugin) Cleanup() {
+	p.cleaned = true
+}
+
+var _ = Describe("ParsePluginOptions", func() {
+	It("initializes the output directory, the design package and the plugin options", func() {
+		opts, err := codegen.ParsePluginOptions([]string{"--design=github.com/foo/design", "--out=gen", "--table=audit", "--verbose"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(opts.OutDir).Should(Equal("gen"))
+		Ω(opts.DesignPkgPath).Should(Equal("github.com/foo/design"))
+		Ω(opts.Options).Should(Equal(map[string]string{"table": "audit", "verbose": "true"}))
+	})
+
+	It("fails with arguments that are not flags", func() {
+		_, err := codegen.ParsePluginOptions([]string{"audit"})
+		Ω(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("RunPlugin", func() {
+	var plugin *testPlugin
+	var factory codegen.GeneratorFactory
+
+	BeforeEach(func() {
+		plugin = &testPlugin{}
+		factory = func(opts *codegen.PluginOptions) (codegen.Generator, error) {
+			plugin.opts = opts
+			return plugin, nil
+		}
+	})
+
+	It("runs the generator created by the factory against the design", func() {
+		files, err := codegen.RunPlugin(factory, []string{"--out=gen", "--table=audit"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(files).Should(Equal([]string{"gen/audit.go"}))
+		Ω(plugin.opts.Options).Should(HaveKeyWithValue("table", "audit"))
+		Ω(plugin.api).Should(Equal(design.Design))
+		Ω(plugin.cleaned).Should(BeFalse())
+	})
+
+	It("cleans up when the generator fails", func() {
+		plugin.err = errors.New("kaboom")
+		_, err := codegen.RunPlugin(factory, []string{"--out=gen"})
+		Ω(err).Should(MatchError("kaboom"))
+		Ω(plugin.cleaned).Should(BeTrue())
+	})
+
+	It("fails when the factory fails", func() {
+		factory = func(*codegen.PluginOptions) (codegen.Generator, error) { return nil, errors.New("invalid table") }
+		_, err := codegen.RunPlugin(factory, nil)
+		Ω(err).Should(MatchError("invalid table"))
+	})
+})
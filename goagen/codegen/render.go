@@ -0,0 +1,224 @@
+package codegen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Render holds the content of the files generated in an output directory in memory. Generators
+// start a render with BeginRender before writing their files with SourceFile or WriteFile and
+// call Commit once done. Commit only writes the files whose content differs from the files on
+// disk and deletes the files generated by the previous run that were not generated again. The
+// unchanged files are left untouched which keeps build caches and file watchers from seeing
+// changes that did not happen.
+type Render struct {
+	// Dir is the absolute path to the output directory.
+	Dir string
+
+	mu    sync.Mutex
+	files map[string]*bytes.Buffer
+}
+
+var (
+	// rendersMu protects renders.
+	rendersMu sync.Mutex
+	// renders lists the renders that are in progress.
+	renders []*Render
+)
+
+// BeginRender starts rendering the files of dir in memory: the content written to the files
+// located under dir is kept in memory until Commit is called.
+func BeginRender(dir string) (*Render, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	r := &Render{Dir: abs, files: make(map[string]*bytes.Buffer)}
+	rendersMu.Lock()
+	renders = append(renders, r)
+	rendersMu.Unlock()
+	return r, nil
+}
+
+// Commit ends the render and writes the rendered files to disk. The files whose content is
+// identical to the content of the files on disk are not written. The files listed in the manifest
+// of the previous run that were not rendered are deleted, all the files that were not rendered
+// are deleted if the directory has no manifest as the generated files cannot be told apart from
+// the others in this case. Commit writes the manifest listing the rendered files last.
+func (r *Render) Commit() error {
+	r.end()
+	old, err := ReadManifest(r.Dir)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(r.files))
+	for path := range r.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := writeIfChanged(path, r.files[path].Bytes()); err != nil {
+			return err
+		}
+	}
+	manifest := filepath.Join(r.Dir, ManifestFile)
+	dirs := []string{r.Dir}
+	remove := func(f string) error {
+		if _, ok := r.files[f]; ok || f == manifest {
+			return nil
+		}
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for d := filepath.Dir(f); d != r.Dir && d != "."; d = filepath.Dir(d) {
+			dirs = append(dirs, d)
+		}
+		return nil
+	}
+	if old != nil {
+		for rel := range old.Files {
+			if err := remove(filepath.Join(r.Dir, filepath.FromSlash(rel))); err != nil {
+				return err
+			}
+		}
+	} else {
+		err := filepath.Walk(r.Dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			return remove(path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(paths) == 0 {
+		if err := os.Remove(manifest); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		removeEmptyDirs(dirs)
+		return nil
+	}
+	removeEmptyDirs(dirs)
+	b, err := manifestContent(r.Dir, paths)
+	if err != nil {
+		return err
+	}
+	return writeIfChanged(manifest, b)
+}
+
+// Discard ends the render without writing the rendered files. Discard does nothing if the render
+// was committed.
+func (r *Render) Discard() {
+	r.end()
+}
+
+// end removes the render from the list of renders in progress.
+func (r *Render) end() {
+	rendersMu.Lock()
+	defer rendersMu.Unlock()
+	for i, o := range renders {
+		if o == r {
+			renders = append(renders[:i], renders[i+1:]...)
+			return
+		}
+	}
+}
+
+// append appends b to the in-memory content of the file at path.
+func (r *Render) append(path string, b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	buf, ok := r.files[path]
+	if !ok {
+		buf = new(bytes.Buffer)
+		r.files[path] = buf
+	}
+	return buf.Write(b)
+}
+
+// set replaces the in-memory content of the file at path with b.
+func (r *Render) set(path string, b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files[path] = bytes.NewBuffer(b)
+}
+
+// content returns the in-memory content of the file at path.
+func (r *Render) content(path string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	buf, ok := r.files[path]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), buf.Bytes()...), true
+}
+
+// renderFor returns the render in progress whose directory contains the file at path, nil if
+// there is none.
+func renderFor(path string) *Render {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	rendersMu.Lock()
+	defer rendersMu.Unlock()
+	var found *Render
+	for _, r := range renders {
+		rel, err := filepath.Rel(r.Dir, abs)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if found == nil || len(r.Dir) > len(found.Dir) {
+			found = r
+		}
+	}
+	return found
+}
+
+// WriteFile writes data to the file at path like ioutil.WriteFile. The data is kept in memory if
+// the file is located under the directory of a render in progress, see BeginRender.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if r := renderFor(path); r != nil {
+		abs, _ := filepath.Abs(path)
+		r.set(abs, append([]byte(nil), data...))
+		return nil
+	}
+	return ioutil.WriteFile(path, data, perm)
+}
+
+// ReadFile returns the content of the file at path like ioutil.ReadFile. The content is read from
+// memory if the file was written under the directory of a render in progress.
+func ReadFile(path string) ([]byte, error) {
+	if r := renderFor(path); r != nil {
+		abs, _ := filepath.Abs(path)
+		if b, ok := r.content(abs); ok {
+			return b, nil
+		}
+	}
+	return ioutil.ReadFile(path)
+}
+
+// writeIfChanged writes b to the file at path unless the file content hash is the hash of b.
+func writeIfChanged(path string, b []byte) error {
+	if cur, err := ioutil.ReadFile(path); err == nil && sha256.Sum256(cur) == sha256.Sum256(b) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,189 @@
+package codegen_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Render", func() {
+	var workspace *codegen.Workspace
+	var pkg *codegen.Package
+	var dir string
+	var past time.Time
+	var render *codegen.Render
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		Ω(os.MkdirAll(filepath.Dir(path), 0755)).ShouldNot(HaveOccurred())
+		Ω(ioutil.WriteFile(path, []byte(content), 0644)).ShouldNot(HaveOccurred())
+		Ω(os.Chtimes(path, past, past)).ShouldNot(HaveOccurred())
+	}
+
+	read := func(name string) string {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(b)
+	}
+
+	stat := func(name string) os.FileInfo {
+		info, err := os.Stat(filepath.Join(dir, name))
+		Ω(err).ShouldNot(HaveOccurred())
+		return info
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	// generate renders the files produced by the previous run of a generator and commits them so
+	// that the directory has a manifest.
+	generate := func(files map[string]string) {
+		r, err := codegen.BeginRender(dir)
+		Ω(err).ShouldNot(HaveOccurred())
+		for name, content := range files {
+			Ω(codegen.WriteFile(filepath.Join(dir, name), []byte(content), 0644)).ShouldNot(HaveOccurred())
+		}
+		Ω(r.Commit()).ShouldNot(HaveOccurred())
+		for name := range files {
+			Ω(os.Chtimes(filepath.Join(dir, name), past, past)).ShouldNot(HaveOccurred())
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("render")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err = workspace.NewPackage("app")
+		Ω(err).ShouldNot(HaveOccurred())
+		dir = pkg.Abs()
+		past = time.Now().Add(-time.Hour).Truncate(time.Second)
+	})
+
+	AfterEach(func() {
+		if render != nil {
+			render.Discard()
+		}
+		workspace.Delete()
+	})
+
+	Context("with files generated by a previous run", func() {
+		var same os.FileInfo
+
+		BeforeEach(func() {
+			generate(map[string]string{
+				"same.go":          "package same",
+				"changed.go":       "package changed",
+				"stale.go":         "package stale",
+				"stale/removed.go": "package stale",
+			})
+			write("notes.txt", "user file")
+			same = stat("same.go")
+
+			var err error
+			render, err = codegen.BeginRender(dir)
+			Ω(err).ShouldNot(HaveOccurred())
+			f := pkg.CreateSourceFile("same.go")
+			_, err = f.Write([]byte("package same"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(codegen.WriteFile(filepath.Join(dir, "changed.go"), []byte("package changed2"), 0644)).ShouldNot(HaveOccurred())
+			Ω(codegen.WriteFile(filepath.Join(dir, "new", "added.go"), []byte("package added"), 0644)).ShouldNot(HaveOccurred())
+		})
+
+		It("keeps the rendered files in memory until committed", func() {
+			Ω(read("changed.go")).Should(Equal("package changed"))
+			Ω(exists("new/added.go")).Should(BeFalse())
+			b, err := codegen.ReadFile(filepath.Join(dir, "changed.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(Equal("package changed2"))
+		})
+
+		Context("once committed", func() {
+			BeforeEach(func() {
+				Ω(render.Commit()).ShouldNot(HaveOccurred())
+			})
+
+			It("leaves the unchanged files untouched", func() {
+				info := stat("same.go")
+				Ω(os.SameFile(info, same)).Should(BeTrue())
+				Ω(info.ModTime()).Should(BeTemporally("==", past))
+			})
+
+			It("writes the files that changed", func() {
+				Ω(read("changed.go")).Should(Equal("package changed2"))
+				Ω(stat("changed.go").ModTime()).Should(BeTemporally(">", past))
+				Ω(read("new/added.go")).Should(Equal("package added"))
+			})
+
+			It("deletes the stale generated files only", func() {
+				Ω(exists("stale.go")).Should(BeFalse())
+				Ω(exists("stale")).Should(BeFalse())
+				Ω(read("notes.txt")).Should(Equal("user file"))
+			})
+
+			It("lists the rendered files in the manifest", func() {
+				m, err := codegen.ReadManifest(dir)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(m.Files).Should(HaveLen(3))
+				Ω(m.Files).Should(HaveKey("same.go"))
+				Ω(m.Files).Should(HaveKey("changed.go"))
+				Ω(m.Files).Should(HaveKey("new/added.go"))
+			})
+
+			It("writes the files that are not under the render directory directly", func() {
+				other, err := ioutil.TempDir("", "other")
+				Ω(err).ShouldNot(HaveOccurred())
+				defer os.RemoveAll(other)
+				Ω(codegen.WriteFile(filepath.Join(other, "out.json"), []byte("{}"), 0644)).ShouldNot(HaveOccurred())
+				_, err = os.Stat(filepath.Join(other, "out.json"))
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("once discarded", func() {
+			BeforeEach(func() {
+				render.Discard()
+			})
+
+			It("does not write anything", func() {
+				Ω(read("changed.go")).Should(Equal("package changed"))
+				Ω(exists("stale.go")).Should(BeTrue())
+				Ω(exists("new/added.go")).Should(BeFalse())
+			})
+		})
+	})
+
+	Context("with a directory that has no manifest", func() {
+		BeforeEach(func() {
+			write("same.go", "package same")
+			write("old.go", "package old")
+			var err error
+			render, err = codegen.BeginRender(dir)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(codegen.WriteFile(filepath.Join(dir, "same.go"), []byte("package same"), 0644)).ShouldNot(HaveOccurred())
+			Ω(render.Commit()).ShouldNot(HaveOccurred())
+		})
+
+		It("deletes the files that were not rendered", func() {
+			Ω(exists("old.go")).Should(BeFalse())
+			Ω(stat("same.go").ModTime()).Should(BeTemporally("==", past))
+		})
+	})
+
+	Context("with a directory that does not exist", func() {
+		It("creates it", func() {
+			out := filepath.Join(dir, "missing")
+			r, err := codegen.BeginRender(out)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(codegen.WriteFile(filepath.Join(out, "a.go"), []byte("package a"), 0644)).ShouldNot(HaveOccurred())
+			Ω(r.Commit()).ShouldNot(HaveOccurred())
+			Ω(read("missing/a.go")).Should(Equal("package a"))
+		})
+	})
+})
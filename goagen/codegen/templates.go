@@ -0,0 +1,51 @@
+package codegen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// templateOverrides contains the sources of the user templates indexed by template name.
+var templateOverrides map[string]string
+
+// LoadTemplateOverrides loads the user templates that override the built-in templates of the
+// generators. Each file of dir named "<name>.tmpl" overrides the built-in template with the
+// given name, e.g. "controller.tmpl" overrides the "controller" template. The data given to an
+// override is the same as the data given to the built-in template so overrides should start
+// from a copy of the built-in template source. The overrides replace any previously loaded
+// overrides. It is not an error for dir not to exist, in which case no template is overridden.
+func LoadTemplateOverrides(dir string) error {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			templateOverrides = nil
+			return nil
+		}
+		return err
+	}
+	overrides := make(map[string]string)
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != ".tmpl" {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read template override: %s", err)
+		}
+		overrides[strings.TrimSuffix(info.Name(), ".tmpl")] = string(b)
+	}
+	templateOverrides = overrides
+	return nil
+}
+
+// Template returns the source of the user template that overrides the built-in template with the
+// given name if any, source otherwise.
+func Template(name, source string) string {
+	if o, ok := templateOverrides[name]; ok {
+		return o
+	}
+	return source
+}
@@ -0,0 +1,71 @@
+package codegen_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LoadTemplateOverrides", func() {
+	var dir string
+	var loadErr error
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "templates")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ioutil.WriteFile(filepath.Join(dir, "controller.tmpl"), []byte("// {{ .Name }} controller\n"), 0644)).ShouldNot(HaveOccurred())
+		Ω(ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a template"), 0644)).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		loadErr = codegen.LoadTemplateOverrides(dir)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		codegen.LoadTemplateOverrides(filepath.Join(dir, "does-not-exist"))
+	})
+
+	It("overrides the templates by name", func() {
+		Ω(loadErr).ShouldNot(HaveOccurred())
+		Ω(codegen.Template("controller", "built-in")).Should(Equal("// {{ .Name }} controller\n"))
+		Ω(codegen.Template("README", "built-in")).Should(Equal("built-in"))
+		Ω(codegen.Template("mount", "built-in")).Should(Equal("built-in"))
+	})
+
+	It("uses the overrides when executing templates", func() {
+		workspace, err := codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		defer workspace.Delete()
+		pkg, err := workspace.NewPackage("templates")
+		Ω(err).ShouldNot(HaveOccurred())
+		file := pkg.CreateSourceFile("test.go")
+		Ω(file.ExecuteTemplate("controller", "built-in", nil, map[string]string{"Name": "Bottle"})).ShouldNot(HaveOccurred())
+		b, err := ioutil.ReadFile(file.Abs())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(b)).Should(Equal("// Bottle controller\n"))
+	})
+
+	Context("with an invalid override", func() {
+		BeforeEach(func() {
+			Ω(ioutil.WriteFile(filepath.Join(dir, "mount.tmpl"), []byte("{{ .Name "), 0644)).ShouldNot(HaveOccurred())
+		})
+
+		It("returns an error when executing the template", func() {
+			workspace, err := codegen.NewWorkspace("test")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer workspace.Delete()
+			pkg, err := workspace.NewPackage("templates")
+			Ω(err).ShouldNot(HaveOccurred())
+			file := pkg.CreateSourceFile("test.go")
+			err = file.ExecuteTemplate("mount", "built-in", nil, nil)
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring(`invalid template override "mount"`))
+		})
+	})
+})
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
 	"unicode"
 
@@ -16,9 +17,18 @@ import (
 // generating the code that transforms one data structure into another.
 const TransformMapKey = "transform:key"
 
+// FieldTypeKey is the name of the metadata used to override the Go type of string struct fields.
+// The supported values are listed in FieldTypes.
+const FieldTypeKey = "struct:field:type"
+
+// FieldTypes lists the Go types that may be used with the FieldTypeKey metadata.
+var FieldTypes = []string{"net.IP", "netip.Addr"}
+
 var (
 	// TempCount holds the value appended to variable names to make them unique.
 	TempCount int
+	// tempCountMu protects TempCount, generators may render files concurrently.
+	tempCountMu sync.Mutex
 
 	// Templates used by GoTypeTransform
 	transformT       *template.Template
@@ -41,6 +51,7 @@ func init() {
 		"transformHash":      transformHash,
 		"transformObject":    transformObject,
 		"typeName":           typeName,
+		"tempvar":            Tempvar,
 	}
 	if transformT, err = template.New("transform").Funcs(fn).Parse(transformTmpl); err != nil {
 		panic(err) // bug
@@ -111,6 +122,9 @@ func goTypeDefObject(actual design.Object, def *design.AttributeDefinition, tabs
 		WriteTabs(&buffer, tabs+1)
 		field := actual[name]
 		typedef := GoTypeDef(field, tabs+1, jsonTags, private)
+		if ft := FieldType(field); ft != "" {
+			typedef = ft
+		}
 		if (field.Type.IsPrimitive() && private) || field.Type.IsObject() || def.IsPrimitivePointer(name) {
 			typedef = "*" + typedef
 		}
@@ -139,6 +153,18 @@ func goTypeDefObject(actual design.Object, def *design.AttributeDefinition, tabs
 	return buffer.String()
 }
 
+// FieldType returns the Go type the attribute struct field should use in place of string as
+// specified by the FieldTypeKey metadata, the empty string if there is no override.
+func FieldType(att *design.AttributeDefinition) string {
+	if att == nil || att.Metadata == nil {
+		return ""
+	}
+	if ft, ok := att.Metadata[FieldTypeKey]; ok && len(ft) > 0 {
+		return ft[0]
+	}
+	return ""
+}
+
 // attributeTags computes the struct field tags.
 func attributeTags(parent, att *design.AttributeDefinition, name string, private bool) string {
 	var elems []string
@@ -334,6 +360,24 @@ var commonInitialisms = map[string]bool{
 	"XSS":   true,
 }
 
+// mixedCaseInitialisms maps the upper case version of initialisms that are not spelled in all
+// caps to their spelling.
+var mixedCaseInitialisms = map[string]string{
+	"OAUTH": "OAuth",
+}
+
+// initialism returns the spelling of word if it is one of the common initialisms.
+func initialism(word string) (string, bool) {
+	u := strings.ToUpper(word)
+	if commonInitialisms[u] {
+		return u, true
+	}
+	if m, ok := mixedCaseInitialisms[u]; ok {
+		return m, true
+	}
+	return "", false
+}
+
 // removeTrailingInvalid removes trailing invalid identifiers from runes.
 func removeTrailingInvalid(runes []rune) []rune {
 	valid := len(runes) - 1
@@ -400,10 +444,8 @@ func Goify(str string, firstUpper bool) string {
 		// [w,i] is a word.
 		word := string(runes[w:i])
 		// is it one of our initialisms?
-		if u := strings.ToUpper(word); commonInitialisms[u] {
-			if firstUpper {
-				u = strings.ToUpper(u)
-			} else if w == 0 {
+		if u, ok := initialism(word); ok {
+			if w == 0 && !firstUpper {
 				u = strings.ToLower(u)
 			}
 
@@ -505,7 +547,7 @@ func GoTypeTransform(source, target *design.UserTypeDefinition, targetPkg, funcN
 		if !target.IsObject() {
 			return "", fmt.Errorf("source is an object but target type is %s", target.Type.Name())
 		}
-		impl, err = transformObject(source.ToObject(), target.ToObject(), targetPkg, target.TypeName, "source", "target", 1)
+		impl, err = transformObject(source.AttributeDefinition, target.AttributeDefinition, targetPkg, target.TypeName, "source", "target", 1)
 	case source.IsArray():
 		if !target.IsArray() {
 			return "", fmt.Errorf("source is an array but target type is %s", target.Type.Name())
@@ -554,6 +596,8 @@ func WriteTabs(buf *bytes.Buffer, count int) {
 
 // Tempvar generates a unique variable name.
 func Tempvar() string {
+	tempCountMu.Lock()
+	defer tempCountMu.Unlock()
 	TempCount++
 	return fmt.Sprintf("tmp%d", TempCount)
 }
@@ -580,13 +624,15 @@ func transformAttribute(source, target *design.AttributeDefinition, targetPkg, s
 	case source.Type.IsHash():
 		return transformHash(source.Type.ToHash(), target.Type.ToHash(), targetPkg, sctx, tctx, depth)
 	case source.Type.IsObject():
-		return transformObject(source.Type.ToObject(), target.Type.ToObject(), targetPkg, typeName(target), sctx, tctx, depth)
+		return transformObject(source, target, targetPkg, typeName(target), sctx, tctx, depth)
 	default:
 		return fmt.Sprintf("%s%s = %s\n", Tabs(depth), tctx, sctx), nil
 	}
 }
 
-func transformObject(source, target design.Object, targetPkg, targetType, sctx, tctx string, depth int) (string, error) {
+func transformObject(sourceDef, targetDef *design.AttributeDefinition, targetPkg, targetType, sctx, tctx string, depth int) (string, error) {
+	source := sourceDef.Type.ToObject()
+	target := targetDef.Type.ToObject()
 	attributeMap, err := computeMapping(source, target, sctx, tctx)
 	if err != nil {
 		return "", err
@@ -601,18 +647,34 @@ func transformObject(source, target design.Object, targetPkg, targetType, sctx,
 			return "", fmt.Errorf("incompatible attribute types: %s.%s is of type %s but %s.%s is of type %s",
 				sctx, source.Name(), sourceAtt.Type.Name(), tctx, target.Name(), targetAtt.Type.Name())
 		}
+		if sft, tft := FieldType(sourceAtt), FieldType(targetAtt); sft != tft {
+			return "", fmt.Errorf("incompatible attribute types: %s.%s is of Go type %q but %s.%s is of Go type %q",
+				sctx, s, sft, tctx, t, tft)
+		}
+	}
+
+	// Primitive fields may be pointers on one side and values on the other depending on
+	// whether they are required, compute which so the proper assignments get generated.
+	sourcePointers := make(map[string]bool)
+	targetPointers := make(map[string]bool)
+	sdef, tdef := objectDefinition(sourceDef), objectDefinition(targetDef)
+	for s, t := range attributeMap {
+		sourcePointers[s] = sdef.IsPrimitivePointer(s)
+		targetPointers[t] = tdef.IsPrimitivePointer(t)
 	}
 
 	// We're good - generate
 	data := map[string]interface{}{
-		"AttributeMap": attributeMap,
-		"Source":       source,
-		"Target":       target,
-		"TargetPkg":    targetPkg,
-		"TargetType":   targetType,
-		"SourceCtx":    sctx,
-		"TargetCtx":    tctx,
-		"Depth":        depth,
+		"AttributeMap":   attributeMap,
+		"Source":         source,
+		"Target":         target,
+		"SourcePointers": sourcePointers,
+		"TargetPointers": targetPointers,
+		"TargetPkg":      targetPkg,
+		"TargetType":     targetType,
+		"SourceCtx":      sctx,
+		"TargetCtx":      tctx,
+		"Depth":          depth,
 	}
 	return RunTemplate(transformObjectT, data), nil
 }
@@ -690,6 +752,16 @@ func computeMapping(source, target design.Object, sctx, tctx string) (map[string
 	return attributeMap, nil
 }
 
+// objectDefinition returns the attribute definition that describes the fields of the Go struct
+// generated for the given object attribute: the user type definition if the attribute type is a
+// user type, the attribute itself otherwise.
+func objectDefinition(att *design.AttributeDefinition) *design.AttributeDefinition {
+	if ds, ok := att.Type.(design.DataStructure); ok {
+		return ds.Definition()
+	}
+	return att
+}
+
 // toSlice returns Go code that represents the given slice.
 func toSlice(val []interface{}) string {
 	elems := make([]string, len(val))
@@ -717,15 +789,22 @@ const transformTmpl = `func {{ .Name }}(source {{ gotyperef .Source nil 0 false
 const transformObjectTmpl = `{{ tabs .Depth }}{{ .TargetCtx }} = new({{ if .TargetPkg }}{{ .TargetPkg }}.{{ end }}{{ if .TargetType }}{{ .TargetType }}{{ else }}{{ gotyperef .Target.Type .Target.AllRequired 1 false }}{{ end }})
 {{ range $source, $target := .AttributeMap }}{{/*
 */}}{{ $sourceAtt := index $.Source $source }}{{ $targetAtt := index $.Target $target }}{{/*
+*/}}{{ $sourcePtr := index $.SourcePointers $source }}{{ $targetPtr := index $.TargetPointers $target }}{{/*
 */}}{{ $source := goify $source true }}{{ $target := goify $target true }}{{/*
 */}}{{     if $sourceAtt.Type.IsArray }}{{ transformArray  $sourceAtt.Type.ToArray  $targetAtt.Type.ToArray  $.TargetPkg (printf "%s.%s" $.SourceCtx $source) (printf "%s.%s" $.TargetCtx $target) $.Depth }}{{/*
 */}}{{ else if $sourceAtt.Type.IsHash }}{{  transformHash   $sourceAtt.Type.ToHash   $targetAtt.Type.ToHash   $.TargetPkg (printf "%s.%s" $.SourceCtx $source) (printf "%s.%s" $.TargetCtx $target) $.Depth }}{{/*
-*/}}{{ else if $sourceAtt.Type.IsObject }}{{ transformObject $sourceAtt.Type.ToObject $targetAtt.Type.ToObject $.TargetPkg (typeName $targetAtt) (printf "%s.%s" $.SourceCtx $source) (printf "%s.%s" $.TargetCtx $target) $.Depth }}{{/*
-*/}}{{ else }}{{ tabs $.Depth }}{{ $.TargetCtx }}.{{ $target }} = {{ $.SourceCtx }}.{{ $source }}
+*/}}{{ else if $sourceAtt.Type.IsObject }}{{ tabs $.Depth }}if {{ $.SourceCtx }}.{{ $source }} != nil {
+{{ transformObject $sourceAtt $targetAtt $.TargetPkg (typeName $targetAtt) (printf "%s.%s" $.SourceCtx $source) (printf "%s.%s" $.TargetCtx $target) (add $.Depth 1) }}{{ tabs $.Depth }}}
+{{ else if and $sourcePtr (not $targetPtr) }}{{ tabs $.Depth }}if {{ $.SourceCtx }}.{{ $source }} != nil {
+{{ tabs $.Depth }}	{{ $.TargetCtx }}.{{ $target }} = *{{ $.SourceCtx }}.{{ $source }}
+{{ tabs $.Depth }}}
+{{ else if and $targetPtr (not $sourcePtr) }}{{ $tmp := tempvar }}{{ tabs $.Depth }}{{ $tmp }} := {{ $.SourceCtx }}.{{ $source }}
+{{ tabs $.Depth }}{{ $.TargetCtx }}.{{ $target }} = &{{ $tmp }}
+{{ else }}{{ tabs $.Depth }}{{ $.TargetCtx }}.{{ $target }} = {{ $.SourceCtx }}.{{ $source }}
 {{ end }}{{ end }}`
 
 const transformArrayTmpl = `{{ tabs .Depth }}{{ .TargetCtx}} = make([]{{ gotyperef .Target.ElemType.Type nil 0 false }}, len({{ .SourceCtx }}))
-{{ tabs .Depth }}for i, v := range {{ .SourceCtx }} {
+{{ tabs .Depth }}for i := range {{ .SourceCtx }} {
 {{ transformAttribute .Source.ElemType .Target.ElemType .TargetPkg (printf "%s[i]" .SourceCtx) (printf "%s[i]" .TargetCtx) (add .Depth 1) }}{{/*
 */}}{{ tabs .Depth }}}
 `
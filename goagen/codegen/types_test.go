@@ -74,6 +74,26 @@ var _ = Describe("code generation", func() {
 					Ω(goified).Should(Equal(expected))
 				})
 			})
+			Context("with first upper true and OAuth", func() {
+				BeforeEach(func() {
+					firstUpper = true
+					str = "oauth2"
+					expected = "OAuth2"
+				})
+				It("uses the initialism spelling", func() {
+					Ω(goified).Should(Equal(expected))
+				})
+			})
+			Context("with first upper false and OAuth", func() {
+				BeforeEach(func() {
+					firstUpper = false
+					str = "oauth2_token"
+					expected = "oauth2Token"
+				})
+				It("creates a lowercased camelcased string", func() {
+					Ω(goified).Should(Equal(expected))
+				})
+			})
 			Context("with first upper true normal identifier", func() {
 				BeforeEach(func() {
 					firstUpper = true
@@ -253,6 +273,24 @@ var _ = Describe("code generation", func() {
 						Ω(st).Should(Equal(expected))
 					})
 				})
+
+				Context("using struct field type metadata", func() {
+					BeforeEach(func() {
+						object["bar"].Metadata = dslengine.MetadataDefinition{
+							"struct:field:type": []string{"net.IP"},
+						}
+					})
+
+					It("produces the typed field", func() {
+						expected := "struct {\n" +
+							"	Bar *net.IP `json:\"bar,omitempty\" xml:\"bar,omitempty\"`\n" +
+							"	Baz *time.Time `json:\"baz,omitempty\" xml:\"baz,omitempty\"`\n" +
+							"	Foo *int `json:\"foo,omitempty\" xml:\"foo,omitempty\"`\n" +
+							"	Qux *uuid.UUID `json:\"qux,omitempty\" xml:\"qux,omitempty\"`\n" +
+							"}"
+						Ω(st).Should(Equal(expected))
+					})
+				})
 			})
 
 			Context("of hash of primitive types", func() {
@@ -493,7 +531,7 @@ var _ = Describe("GoTypeTransform", func() {
 			Ω(transform).Should(Equal(`func Transform(source *Source) (target *Target) {
 	target = new(Target)
 	target.Att = make([]int, len(source.Att))
-	for i, v := range source.Att {
+	for i := range source.Att {
 		target.Att[i] = source.Att[i]
 	}
 	return
@@ -537,6 +575,35 @@ var _ = Describe("GoTypeTransform", func() {
 		})
 	})
 
+	Context("transforming objects with required attributes", func() {
+		BeforeEach(func() {
+			source = Type("Source", func() {
+				Attribute("foo", Integer)
+				Attribute("bar")
+				Required("bar")
+			})
+			target = Type("Target", func() {
+				Attribute("foo", Integer)
+				Attribute("bar")
+				Required("foo")
+			})
+			funcName = "Transform"
+		})
+
+		It("dereferences and takes the address of primitive fields as needed", func() {
+			Ω(transform).Should(MatchRegexp(`func Transform\(source \*Source\) \(target \*Target\) {
+	target = new\(Target\)
+	(tmp\d+) := source.Bar
+	target.Bar = &(tmp\d+)
+	if source.Foo != nil {
+		target.Foo = \*source.Foo
+	}
+	return
+}
+`))
+		})
+	})
+
 	Context("transforming objects with recursive attributes", func() {
 		const attName = "att"
 		BeforeEach(func() {
@@ -568,27 +635,39 @@ var _ = Describe("GoTypeTransform", func() {
 		It("generates the proper assignments", func() {
 			Ω(transform).Should(Equal(`func Transform(source *Source) (target *Target) {
 	target = new(Target)
-	target.Array = new(Array)
-	target.Array.Elem = make([]*Outer, len(source.Array.Elem))
-	for i, v := range source.Array.Elem {
-		target.Array.Elem[i] = new(Outer)
-		target.Array.Elem[i].In = new(Inner)
-		target.Array.Elem[i].In.Foo = source.Array.Elem[i].In.Foo
+	if source.Array != nil {
+		target.Array = new(Array)
+		target.Array.Elem = make([]*Outer, len(source.Array.Elem))
+		for i := range source.Array.Elem {
+			target.Array.Elem[i] = new(Outer)
+			if source.Array.Elem[i].In != nil {
+				target.Array.Elem[i].In = new(Inner)
+				target.Array.Elem[i].In.Foo = source.Array.Elem[i].In.Foo
+			}
+		}
 	}
-	target.Hash = new(Hash)
-	target.Hash.Elem = make(map[int]*Outer, len(source.Hash.Elem))
-	for k, v := range source.Hash.Elem {
-		var tk int
-		tk = k
-		var tv *Outer
-		tv = new(Outer)
-		tv.In = new(Inner)
-		tv.In.Foo = v.In.Foo
-		target.Hash.Elem[tk] = tv
+	if source.Hash != nil {
+		target.Hash = new(Hash)
+		target.Hash.Elem = make(map[int]*Outer, len(source.Hash.Elem))
+		for k, v := range source.Hash.Elem {
+			var tk int
+			tk = k
+			var tv *Outer
+			tv = new(Outer)
+			if v.In != nil {
+				tv.In = new(Inner)
+				tv.In.Foo = v.In.Foo
+			}
+			target.Hash.Elem[tk] = tv
+		}
+	}
+	if source.Outer != nil {
+		target.Outer = new(Outer)
+		if source.Outer.In != nil {
+			target.Outer.In = new(Inner)
+			target.Outer.In.Foo = source.Outer.In.Foo
+		}
 	}
-	target.Outer = new(Outer)
-	target.Outer.In = new(Inner)
-	target.Outer.In.Foo = source.Outer.In.Foo
 	return
 }
 `))
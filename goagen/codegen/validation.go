@@ -32,6 +32,7 @@ func init() {
 		"goify":            Goify,
 		"add":              Add,
 		"recursiveChecker": RecursiveChecker,
+		"fieldType":        FieldType,
 	}
 	if arrayValT, err = template.New("array").Funcs(fm).Parse(arrayValTmpl); err != nil {
 		panic(err)
@@ -178,6 +179,10 @@ func ValidationChecker(att *design.AttributeDefinition, nonzero, required, hasDe
 	if isPointer && att.Type.IsPrimitive() {
 		t = "*" + t
 	}
+	if FieldType(att) != "" {
+		// Values of typed fields are validated when decoded.
+		return ""
+	}
 	data := map[string]interface{}{
 		"attribute": att,
 		"isPointer": private || isPointer,
@@ -288,6 +293,8 @@ func constant(formatName string) string {
 		return "goa.FormatCIDR"
 	case "regexp":
 		return "goa.FormatRegexp"
+	case "hostport":
+		return "goa.FormatHostPort"
 	}
 	panic("unknown format") // bug
 }
@@ -339,7 +346,13 @@ const (
 {{end}}{{tabs .depth}}}`
 
 	requiredValTmpl = `{{range $r := .required}}{{$catt := index $.attribute.Type.ToObject $r}}{{/*
-*/}}{{if and (not $.private) (eq $catt.Type.Kind 4)}}{{tabs $.depth}}if {{$.target}}.{{goify $r true}} == "" {
+*/}}{{if and (not $.private) (eq (fieldType $catt) "net.IP")}}{{tabs $.depth}}if len({{$.target}}.{{goify $r true}}) == 0 {
+{{tabs $.depth}}	err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`" + `{{$.context}}` + "`" + `, "{{$r}}"))
+{{tabs $.depth}}}
+{{else if and (not $.private) (eq (fieldType $catt) "netip.Addr")}}{{tabs $.depth}}if !{{$.target}}.{{goify $r true}}.IsValid() {
+{{tabs $.depth}}	err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`" + `{{$.context}}` + "`" + `, "{{$r}}"))
+{{tabs $.depth}}}
+{{else if and (not $.private) (eq $catt.Type.Kind 4)}}{{tabs $.depth}}if {{$.target}}.{{goify $r true}} == "" {
 {{tabs $.depth}}	err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`" + `{{$.context}}` + "`" + `, "{{$r}}"))
 {{tabs $.depth}}}
 {{else if or $.private (not $catt.Type.IsPrimitive)}}{{tabs $.depth}}if {{$.target}}.{{goify $r true}} == nil {
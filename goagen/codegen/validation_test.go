@@ -122,6 +122,31 @@ var _ = Describe("validation code generation", func() {
 				})
 			})
 
+			Context("of required typed string fields", func() {
+				BeforeEach(func() {
+					format := &dslengine.ValidationDefinition{Format: "ip"}
+					attType = design.Object{
+						"addr": &design.AttributeDefinition{
+							Type:       design.String,
+							Validation: format,
+							Metadata:   dslengine.MetadataDefinition{"struct:field:type": []string{"netip.Addr"}},
+						},
+						"ip": &design.AttributeDefinition{
+							Type:       design.String,
+							Validation: format,
+							Metadata:   dslengine.MetadataDefinition{"struct:field:type": []string{"net.IP"}},
+						},
+					}
+					validation = &dslengine.ValidationDefinition{
+						Required: []string{"addr", "ip"},
+					}
+				})
+
+				It("checks the fields are set and skips the format validations", func() {
+					Ω(code).Should(Equal(typedFieldsValCode))
+				})
+			})
+
 		})
 	})
 })
@@ -170,4 +195,12 @@ const (
 			}
 		}
 	}`
+
+	typedFieldsValCode = `	if !val.Addr.IsValid() {
+		err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`context`" + `, "addr"))
+	}
+	if len(val.IP) == 0 {
+		err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`context`" + `, "ip"))
+	}
+`
 )
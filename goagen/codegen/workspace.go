@@ -13,6 +13,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"text/template"
@@ -21,10 +22,12 @@ import (
 )
 
 type (
-	// Workspace represents a temporary Go workspace
+	// Workspace represents a temporary Go workspace or a Go module
 	Workspace struct {
-		// Path is the absolute path to the workspace directory.
+		// Path is the absolute path to the workspace directory or module root directory.
 		Path string
+		// Module is the module path if the workspace is a Go module, empty otherwise.
+		Module string
 		// gopath is the original GOPATH
 		gopath string
 	}
@@ -50,6 +53,13 @@ var (
 	// Template used to render Go source file headers.
 	headerTmpl = template.Must(template.New("header").Funcs(DefaultFuncMap).Parse(headerT))
 
+	// Template used to render the banner of Go source file headers, nil if the default banner
+	// should be used.
+	bannerTmpl *template.Template
+
+	// tempvarRegex matches the names of the variables created with Tempvar.
+	tempvarRegex = regexp.MustCompile(`^tmp[0-9]+$`)
+
 	// DefaultFuncMap is the FuncMap used to initialize all source file templates.
 	DefaultFuncMap = template.FuncMap{
 		"add":                 func(a, b int) int { return a + b },
@@ -93,9 +103,18 @@ func NewWorkspace(prefix string) (*Workspace, error) {
 	return &Workspace{Path: dir, gopath: gopath}, nil
 }
 
-// WorkspaceFor returns the Go workspace for the given Go source file.
+// WorkspaceFor returns the Go workspace for the given Go source file. The workspace is the Go
+// module containing the file if any, see ModuleFor, and the GOPATH entry containing the file
+// otherwise.
 func WorkspaceFor(source string) (*Workspace, error) {
 	gopaths := os.Getenv("GOPATH")
+	if root, mod := ModuleFor(filepath.Dir(source)); mod != "" {
+		return &Workspace{
+			gopath: gopaths,
+			Path:   root,
+			Module: mod,
+		}, nil
+	}
 	for _, gp := range filepath.SplitList(gopaths) {
 		gopath, err := filepath.Abs(gp)
 		if err != nil {
@@ -156,6 +175,9 @@ func PackageFor(source string) (*Package, error) {
 	if err != nil {
 		return nil, err
 	}
+	if w.Module != "" {
+		return &Package{Workspace: w, Path: modulePackagePath(w.Path, w.Module, filepath.Dir(source))}, nil
+	}
 	path, err := filepath.Rel(filepath.Join(w.Path, "src"), filepath.Dir(source))
 	if err != nil {
 		return nil, err
@@ -165,6 +187,10 @@ func PackageFor(source string) (*Package, error) {
 
 // Abs returns the absolute path to the package source directory
 func (p *Package) Abs() string {
+	if mod := p.Workspace.Module; mod != "" {
+		rel := strings.TrimPrefix(strings.TrimPrefix(p.Path, mod), "/")
+		return filepath.Join(p.Workspace.Path, filepath.FromSlash(rel))
+	}
 	return filepath.Join(p.Workspace.Path, "src", p.Path)
 }
 
@@ -215,14 +241,60 @@ func SourceFileFor(path string) (*SourceFile, error) {
 	}, nil
 }
 
-// WriteHeader writes the generic generated code header.
+// SetHeaderBanner sets the template used to render the banner written at the top of all generated
+// Go source files in place of the default goagen banner, for example to add a copyright notice or
+// license header. The template is executed with the same data as the default banner: the file
+// title (.Title), the goagen version (.ToolVersion) and the package name (.Pkg). The rendered
+// lines that are not comments are commented out. An empty text restores the default banner.
+func SetHeaderBanner(text string) error {
+	if text == "" {
+		bannerTmpl = nil
+		return nil
+	}
+	tmpl, err := template.New("banner").Funcs(DefaultFuncMap).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid header template: %s", err)
+	}
+	bannerTmpl = tmpl
+	return nil
+}
+
+// WriteHeader writes the generic generated code header. Duplicate imports are written once and
+// imports that end up unused are removed by FormatCode.
 func (f *SourceFile) WriteHeader(title, pack string, imports []*ImportSpec) error {
+	seen := make(map[string]bool, len(imports))
+	unique := make([]*ImportSpec, 0, len(imports))
+	for _, imp := range imports {
+		if code := imp.Code(); !seen[code] {
+			seen[code] = true
+			unique = append(unique, imp)
+		}
+	}
+	imports = unique
 	ctx := map[string]interface{}{
 		"Title":       title,
 		"ToolVersion": Version,
 		"Pkg":         pack,
 		"Imports":     imports,
 	}
+	if bannerTmpl != nil {
+		var buf bytes.Buffer
+		if err := bannerTmpl.Execute(&buf, ctx); err != nil {
+			return fmt.Errorf("failed to generate header: %s", err)
+		}
+		var lines []string
+		for _, l := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+			if l = strings.TrimRight(l, " \t"); !strings.HasPrefix(l, "//") {
+				l = strings.TrimRight("// "+l, " ")
+			}
+			lines = append(lines, l)
+		}
+		// The blank line keeps the banner from becoming the package documentation.
+		if _, err := f.Write([]byte(strings.Join(lines, "\n") + "\n\n")); err != nil {
+			return err
+		}
+		ctx["Title"] = ""
+	}
 	if err := headerTmpl.Execute(f, ctx); err != nil {
 		return fmt.Errorf("failed to generate contexts: %s", err)
 	}
@@ -230,8 +302,12 @@ func (f *SourceFile) WriteHeader(title, pack string, imports []*ImportSpec) erro
 }
 
 // Write implements io.Writer so that variables of type *SourceFile can be
-// used in template.Execute.
+// used in template.Execute. The content is kept in memory if the file is located under the
+// directory of a render in progress, see BeginRender.
 func (f *SourceFile) Write(b []byte) (int, error) {
+	if r := renderFor(f.Abs()); r != nil {
+		return r.append(f.Abs(), b)
+	}
 	file, err := os.OpenFile(f.Abs(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return 0, err
@@ -243,20 +319,23 @@ func (f *SourceFile) Write(b []byte) (int, error) {
 // FormatCode runs "goimports -w" on the source file.
 func (f *SourceFile) FormatCode() error {
 	// Parse file into AST
+	src, err := ReadFile(f.Abs())
+	if err != nil {
+		return err
+	}
 	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, f.Abs(), nil, parser.ParseComments)
+	file, err := parser.ParseFile(fset, f.Abs(), src, parser.ParseComments)
 	if err != nil {
-		content, _ := ioutil.ReadFile(f.Abs())
 		var buf bytes.Buffer
 		scanner.PrintError(&buf, err)
-		return fmt.Errorf("%s\n========\nContent:\n%s", buf.String(), content)
+		return fmt.Errorf("%s\n========\nContent:\n%s", buf.String(), src)
 	}
-	// Clean unused imports
+	// Clean unused imports, generators may declare more imports than the code uses.
 	imports := astutil.Imports(fset, file)
 	for _, group := range imports {
 		for _, imp := range group {
 			path := strings.Trim(imp.Path.Value, `"`)
-			if !astutil.UsesImport(file, path) {
+			if !usesImport(file, imp) {
 				if imp.Name != nil {
 					astutil.DeleteNamedImport(fset, file, imp.Name.Name, path)
 				} else {
@@ -266,14 +345,65 @@ func (f *SourceFile) FormatCode() error {
 		}
 	}
 	ast.SortImports(fset, file)
-	// Open file to be written
-	w, err := os.OpenFile(f.Abs(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
-	if err != nil {
+	// Number the temporary variables in order of appearance so that the code does not depend on
+	// the order in which the files were rendered.
+	renumberTempvars(file)
+	// Write formatted code without unused imports
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
 		return err
 	}
-	defer w.Close()
-	// Write formatted code without unused imports
-	return format.Node(w, fset, file)
+	return WriteFile(f.Abs(), buf.Bytes(), 0644)
+}
+
+// usesImport returns true if the file uses the given import. Contrary to astutil.UsesImport the
+// name of packages imported without an explicit name is not assumed to be the last element of the
+// import path, see ImportName.
+func usesImport(file *ast.File, imp *ast.ImportSpec) bool {
+	var name string
+	if imp.Name != nil {
+		name = imp.Name.Name
+	} else {
+		name = ImportName(strings.Trim(imp.Path.Value, `"`))
+	}
+	if name == "_" || name == "." {
+		return true
+	}
+	used := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == name && id.Obj == nil {
+				used = true
+			}
+		}
+		return !used
+	})
+	return used
+}
+
+// renumberTempvars renames the local variables created with Tempvar to tmp1, tmp2... in the order
+// in which they appear in the file.
+func renumberTempvars(file *ast.File) {
+	names := make(map[string]string)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok || id.Obj == nil || id.Obj.Kind != ast.Var || !tempvarRegex.MatchString(id.Name) {
+				return true
+			}
+			name, ok := names[id.Name]
+			if !ok {
+				name = fmt.Sprintf("tmp%d", len(names)+1)
+				names[id.Name] = name
+			}
+			id.Name = name
+			return true
+		})
+	}
 }
 
 // Abs returne the source file absolute filename
@@ -281,22 +411,31 @@ func (f *SourceFile) Abs() string {
 	return filepath.Join(f.Package.Abs(), f.Name)
 }
 
-// ExecuteTemplate executes the template and writes the output to the file.
+// ExecuteTemplate executes the template and writes the output to the file. The template source
+// is replaced with the user template loaded by LoadTemplateOverrides for name if any.
 func (f *SourceFile) ExecuteTemplate(name, source string, funcMap template.FuncMap, data interface{}) error {
-	tmpl, err := template.New(name).Funcs(DefaultFuncMap).Funcs(funcMap).Parse(source)
+	src := Template(name, source)
+	tmpl, err := template.New(name).Funcs(DefaultFuncMap).Funcs(funcMap).Parse(src)
 	if err != nil {
+		if src != source {
+			return fmt.Errorf("invalid template override %q: %s", name, err)
+		}
 		panic(err) // bug
 	}
 	return tmpl.Execute(f, data)
 }
 
 // PackagePath returns the Go package path for the directory that lives under the given absolute
-// file path.
+// file path. The package path is computed from the module path if the directory belongs to a Go
+// module and from the GOPATH otherwise.
 func PackagePath(path string) (string, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		absPath = path
 	}
+	if root, mod := ModuleFor(absPath); mod != "" {
+		return modulePackagePath(root, mod, absPath), nil
+	}
 	gopaths := filepath.SplitList(os.Getenv("GOPATH"))
 	for _, gopath := range gopaths {
 		if gp, err := filepath.Abs(gopath); err == nil {
@@ -311,7 +450,8 @@ func PackagePath(path string) (string, error) {
 	return "", fmt.Errorf("%s does not contain a Go package", absPath)
 }
 
-// PackageSourcePath returns the absolute path to the given package source.
+// PackageSourcePath returns the absolute path to the given package source. Packages of the Go
+// module containing the working directory are looked up in the module directory.
 func PackageSourcePath(pkg string) (string, error) {
 	buildCtx := build.Default
 	buildCtx.GOPATH = os.Getenv("GOPATH") // Reevaluate each time to be nice to tests
@@ -319,6 +459,13 @@ func PackageSourcePath(pkg string) (string, error) {
 	if err != nil {
 		wd = "."
 	}
+	if root, mod := ModuleFor(wd); mod != "" && (pkg == mod || strings.HasPrefix(pkg, mod+"/")) {
+		dir := filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(pkg[len(mod):], "/")))
+		if _, err := os.Stat(dir); err != nil {
+			return "", fmt.Errorf("cannot find package %q in module %s", pkg, root)
+		}
+		return dir, nil
+	}
 	p, err := buildCtx.Import(pkg, wd, 0)
 	if err != nil {
 		return "", err
@@ -326,6 +473,55 @@ func PackageSourcePath(pkg string) (string, error) {
 	return p.Dir, nil
 }
 
+// ModuleFor returns the root directory and path of the Go module containing the given directory.
+// It returns empty strings if the directory is not in a module or if modules are disabled with
+// GO111MODULE=off.
+func ModuleFor(dir string) (root, modPath string) {
+	if os.Getenv("GO111MODULE") == "off" {
+		return "", ""
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", ""
+	}
+	for {
+		if b, err := ioutil.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			if mod := modulePath(b); mod != "" {
+				return dir, mod
+			}
+			return "", ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// modulePath returns the module path declared in the given go.mod file content.
+func modulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`+"`")
+		}
+	}
+	return ""
+}
+
+// modulePackagePath returns the Go package path of the directory dir of the module with the
+// given root directory and path.
+func modulePackagePath(root, mod, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return mod
+	}
+	return mod + "/" + filepath.ToSlash(rel)
+}
+
 // PackageName returns the name of a package at the given path
 func PackageName(path string) (string, error) {
 	fset := token.NewFileSet()
@@ -0,0 +1,202 @@
+package codegen_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SourceFile", func() {
+	Describe("WriteHeader", func() {
+		var workspace *codegen.Workspace
+		var file *codegen.SourceFile
+		var banner string
+
+		BeforeEach(func() {
+			banner = ""
+			var err error
+			workspace, err = codegen.NewWorkspace("test")
+			Ω(err).ShouldNot(HaveOccurred())
+			pkg, err := workspace.NewPackage("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+			file = pkg.CreateSourceFile("foo.go")
+		})
+
+		JustBeforeEach(func() {
+			Ω(codegen.SetHeaderBanner(banner)).ShouldNot(HaveOccurred())
+			Ω(file.WriteHeader("Foo", "foo", nil)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			codegen.SetHeaderBanner("")
+			workspace.Delete()
+		})
+
+		It("writes the default banner", func() {
+			content, err := ioutil.ReadFile(file.Abs())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(HavePrefix("//************************************************************************//\n// Foo\n"))
+		})
+
+		Context("with a custom banner", func() {
+			BeforeEach(func() {
+				banner = "Copyright Acme\n\n// {{ .Title }} (package {{ .Pkg }})\n"
+			})
+
+			It("writes the rendered banner as comments", func() {
+				content, err := ioutil.ReadFile(file.Abs())
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(Equal("// Copyright Acme\n//\n// Foo (package foo)\n\npackage foo\n\n"))
+			})
+		})
+	})
+
+	Describe("FormatCode", func() {
+		var workspace *codegen.Workspace
+		var file *codegen.SourceFile
+
+		BeforeEach(func() {
+			var err error
+			workspace, err = codegen.NewWorkspace("test")
+			Ω(err).ShouldNot(HaveOccurred())
+			pkg, err := workspace.NewPackage("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+			file = pkg.CreateSourceFile("foo.go")
+			imports := []*codegen.ImportSpec{
+				codegen.SimpleImport("fmt"),
+				codegen.SimpleImport("strconv"),
+				codegen.SimpleImport("fmt"),
+				codegen.SimpleImport("gopkg.in/yaml.v2"),
+				codegen.NewImport("uuid", "github.com/satori/go.uuid"),
+			}
+			Ω(file.WriteHeader("", "foo", imports)).ShouldNot(HaveOccurred())
+			_, err = file.Write([]byte("func f() {\n\tfmt.Println(yaml.Marshal(nil))\n\ttmp7 := 1\n\ttmp3 := &tmp7\n\tfmt.Println(tmp3)\n}\n"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(file.FormatCode()).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			workspace.Delete()
+		})
+
+		It("removes the unused and duplicate imports", func() {
+			content, err := ioutil.ReadFile(file.Abs())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(HavePrefix("package foo\n\nimport (\n\t\"fmt\"\n\t\"gopkg.in/yaml.v2\"\n)\n"))
+		})
+
+		It("numbers the temporary variables in order of appearance", func() {
+			content, err := ioutil.ReadFile(file.Abs())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("\ttmp1 := 1\n\ttmp2 := &tmp1\n\tfmt.Println(tmp2)\n"))
+		})
+	})
+
+	Describe("SetHeaderBanner", func() {
+		It("rejects invalid templates", func() {
+			Ω(codegen.SetHeaderBanner("{{ .Title")).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Go modules", func() {
+	var root string
+	var env map[string]*string
+
+	BeforeEach(func() {
+		env = make(map[string]*string)
+		for _, k := range []string{"GO111MODULE", "GOFLAGS"} {
+			if v, ok := os.LookupEnv(k); ok {
+				env[k] = &v
+			} else {
+				env[k] = nil
+			}
+		}
+		os.Setenv("GO111MODULE", "on")
+		os.Unsetenv("GOFLAGS")
+		var err error
+		root, err = ioutil.TempDir("", "goamod")
+		Ω(err).ShouldNot(HaveOccurred())
+		root, err = filepath.EvalSymlinks(root)
+		Ω(err).ShouldNot(HaveOccurred())
+		gomod := "// Service module\nmodule github.com/acme/cellar // comment\n\ngo 1.12\n"
+		Ω(ioutil.WriteFile(filepath.Join(root, "go.mod"), []byte(gomod), 0644)).ShouldNot(HaveOccurred())
+		Ω(os.MkdirAll(filepath.Join(root, "design"), 0755)).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(root)
+		for k, v := range env {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	})
+
+	It("finds the module containing a directory", func() {
+		r, mod := codegen.ModuleFor(filepath.Join(root, "design"))
+		Ω(r).Should(Equal(root))
+		Ω(mod).Should(Equal("github.com/acme/cellar"))
+	})
+
+	It("computes package paths relative to the module root", func() {
+		Ω(codegen.PackagePath(root)).Should(Equal("github.com/acme/cellar"))
+		Ω(codegen.PackagePath(filepath.Join(root, "app", "test"))).Should(Equal("github.com/acme/cellar/app/test"))
+	})
+
+	It("creates packages in the module directory", func() {
+		pkg, err := codegen.PackageFor(filepath.Join(root, "tool", "main.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(pkg.Workspace.Path).Should(Equal(root))
+		Ω(pkg.Workspace.Module).Should(Equal("github.com/acme/cellar"))
+		Ω(pkg.Path).Should(Equal("github.com/acme/cellar/tool"))
+		Ω(pkg.Abs()).Should(Equal(filepath.Join(root, "tool")))
+	})
+
+	Context("with the working directory in the module", func() {
+		var wd string
+
+		BeforeEach(func() {
+			var err error
+			wd, err = os.Getwd()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(os.Chdir(root)).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.Chdir(wd)
+		})
+
+		It("finds the source of the module packages", func() {
+			Ω(codegen.PackageSourcePath("github.com/acme/cellar/design")).Should(Equal(filepath.Join(root, "design")))
+			_, err := codegen.PackageSourcePath("github.com/acme/cellar/missing")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("with modules disabled", func() {
+		BeforeEach(func() {
+			os.Setenv("GO111MODULE", "off")
+		})
+
+		It("ignores the module", func() {
+			_, mod := codegen.ModuleFor(root)
+			Ω(mod).Should(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("ImportName", func() {
+	It("guesses the name of the packages that cannot be found", func() {
+		Ω(codegen.ImportName("net/http")).Should(Equal("http"))
+		Ω(codegen.ImportName("example.com/acme/go-colorable")).Should(Equal("colorable"))
+		Ω(codegen.ImportName("example.com/acme/cellar/v3")).Should(Equal("cellar"))
+		Ω(codegen.ImportName("example.com/acme/yaml.v2")).Should(Equal("yaml"))
+	})
+})
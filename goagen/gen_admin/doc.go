@@ -0,0 +1,16 @@
+/*
+Package genadmin provides a generator for a minimal admin UI. The generated "admin" package serves
+an HTML page and a script that list, show, create, edit and delete the instances of the resources
+that define CRUD actions, see Resources for how the actions are identified. The create and edit
+forms are derived from the action payloads: the attribute validations become HTML input
+attributes so that the browser validates the forms before submitting them.
+
+The admin UI is mounted under a configurable prefix, "/admin" by default:
+
+	goagen admin -d github.com/acme/cellar/design --prefix /console
+
+and the generated MountController function is called by the service main:
+
+	admin.MountController(service)
+*/
+package genadmin
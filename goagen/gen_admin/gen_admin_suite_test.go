@@ -0,0 +1,13 @@
+package genadmin_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenAdmin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenAdmin Suite")
+}
@@ -0,0 +1,698 @@
+package genadmin
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+)
+
+// DefaultPrefix is the default path under which the admin UI is mounted.
+const DefaultPrefix = "/admin"
+
+type (
+	// Generator is the admin UI generator.
+	Generator struct {
+		genfiles   []string // Generated files
+		outDir     string   // Path to output directory
+		prefix     string   // Path under which the admin UI is mounted
+		stdcontext bool     // Whether to import the standard library context package
+	}
+
+	// Resource describes how the admin UI manages a resource. It is serialized into the
+	// configuration read by the admin UI JavaScript.
+	Resource struct {
+		// Name is the resource name.
+		Name string `json:"name"`
+		// Description is the resource description.
+		Description string `json:"description,omitempty"`
+		// ID is the name of the attribute identifying the resource instances.
+		ID string `json:"id"`
+		// Columns lists the attributes displayed by the list page.
+		Columns []string `json:"columns"`
+		// List, Show, Create, Update and Delete are the routes of the CRUD actions, only List
+		// is always set.
+		List   *Route `json:"list"`
+		Show   *Route `json:"show,omitempty"`
+		Create *Route `json:"create,omitempty"`
+		Update *Route `json:"update,omitempty"`
+		Delete *Route `json:"delete,omitempty"`
+		// CreateFields and UpdateFields describe the create and edit form fields.
+		CreateFields []*Field `json:"createFields,omitempty"`
+		UpdateFields []*Field `json:"updateFields,omitempty"`
+	}
+
+	// Route is the route of a CRUD action.
+	Route struct {
+		// Action is the action name.
+		Action string `json:"action"`
+		// Method is the HTTP method.
+		Method string `json:"method"`
+		// Path is the full path, the show, update and delete paths end with the wildcard
+		// identifying the resource instance.
+		Path string `json:"path"`
+	}
+
+	// Field is a form field derived from a payload attribute and its validations.
+	Field struct {
+		// Name is the attribute name.
+		Name string `json:"name"`
+		// Description is the attribute description.
+		Description string `json:"description,omitempty"`
+		// Input is the HTML input type: "text", "email", "url", "datetime-local", "number",
+		// "checkbox" or "select", or "json" for the attributes edited as JSON values.
+		Input string `json:"input"`
+		// Integer is true if the attribute is an integer.
+		Integer bool `json:"integer,omitempty"`
+		// Required is true if the attribute is required.
+		Required bool `json:"required,omitempty"`
+		// Enum lists the allowed values when Input is "select".
+		Enum []interface{} `json:"enum,omitempty"`
+		// Default is the attribute default value.
+		Default interface{} `json:"default,omitempty"`
+		// Pattern, MinLength and MaxLength are the string validations.
+		Pattern   string `json:"pattern,omitempty"`
+		MinLength *int   `json:"minLength,omitempty"`
+		MaxLength *int   `json:"maxLength,omitempty"`
+		// Minimum and Maximum are the numeric validations.
+		Minimum *float64 `json:"minimum,omitempty"`
+		Maximum *float64 `json:"maximum,omitempty"`
+	}
+)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var (
+		outDir, prefix string
+		stdcontext     bool
+	)
+
+	set := flag.NewFlagSet("admin", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.String("design", "", "")
+	set.StringVar(&prefix, "prefix", DefaultPrefix, "")
+	set.BoolVar(&stdcontext, "stdcontext", false, "")
+	set.Parse(os.Args[2:])
+
+	g := &Generator{outDir: outDir, prefix: prefix, stdcontext: stdcontext}
+
+	return g.Generate(design.Design)
+}
+
+// Generate produces the admin package in the "admin" directory of the output directory.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	prefix := path.Clean("/" + g.prefix)
+	if prefix == "/" {
+		return nil, fmt.Errorf("invalid admin UI prefix %#v, the admin UI cannot be mounted at the root", g.prefix)
+	}
+	resources := Resources(api)
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("no resource defines CRUD actions")
+	}
+	config, err := json.MarshalIndent(resources, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	outDir := filepath.Join(g.outDir, "admin")
+	render, err := codegen.BeginRender(outDir)
+	if err != nil {
+		return nil, err
+	}
+	defer render.Discard()
+	if err = os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, outDir)
+
+	adminFile := filepath.Join(outDir, "admin.go")
+	file, err := codegen.SourceFileFor(adminFile)
+	if err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, adminFile)
+	imports := []*codegen.ImportSpec{
+		codegen.ContextImport(g.stdcontext),
+		codegen.SimpleImport("io"),
+		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	if err = file.WriteHeader(fmt.Sprintf("%s: Admin UI", api.Context()), "admin", imports); err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{
+		"API":    api,
+		"Prefix": prefix,
+		"Config": literal(string(config)),
+		"HTML":   strings.Replace(indexHTML, "{{PREFIX}}", prefix, -1),
+		"JS":     adminJS,
+	}
+	if err = file.ExecuteTemplate("admin", adminT, nil, data); err != nil {
+		return nil, err
+	}
+	if err = file.FormatCode(); err != nil {
+		return nil, err
+	}
+
+	return g.genfiles, render.Commit()
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for i := len(g.genfiles) - 1; i >= 0; i-- {
+		os.Remove(g.genfiles[i])
+	}
+	g.genfiles = nil
+}
+
+// Resources returns the resources managed by the admin UI sorted by name. A resource is managed
+// if it has a list action, a GET action whose path has no wildcard and whose OK response is a
+// collection, and at least one other CRUD action. The other CRUD actions are identified by their
+// method and path relative to the list path P:
+//
+//	show     GET P/:id
+//	create   POST P
+//	update   PUT or PATCH P/:id
+//	delete   DELETE P/:id
+func Resources(api *design.APIDefinition) []*Resource {
+	var resources []*Resource
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		r := &Resource{Name: res.Name, Description: res.Description}
+		var listAction, createAction, updateAction *design.ActionDefinition
+		var routes []*design.RouteDefinition
+		res.IterateActions(func(a *design.ActionDefinition) error {
+			routes = append(routes, a.Routes...)
+			return nil
+		})
+		for _, rt := range routes {
+			if rt.Verb != "GET" || len(rt.Params()) > 0 || !returnsCollection(api, rt.Parent) {
+				continue
+			}
+			if p := rt.FullPath(); r.List == nil || len(p) < len(r.List.Path) {
+				r.List = &Route{Action: rt.Parent.Name, Method: rt.Verb, Path: p}
+				listAction = rt.Parent
+			}
+		}
+		if r.List == nil {
+			return nil
+		}
+		var wildcard string
+		for _, rt := range routes {
+			p := rt.FullPath()
+			route := &Route{Action: rt.Parent.Name, Method: rt.Verb, Path: p}
+			if p == r.List.Path {
+				if rt.Verb == "POST" && r.Create == nil {
+					r.Create = route
+					createAction = rt.Parent
+				}
+				continue
+			}
+			if path.Dir(p) != r.List.Path || !strings.HasPrefix(path.Base(p), ":") {
+				continue
+			}
+			switch {
+			case rt.Verb == "GET" && r.Show == nil:
+				r.Show = route
+			case (rt.Verb == "PUT" || rt.Verb == "PATCH") && r.Update == nil:
+				r.Update = route
+				updateAction = rt.Parent
+			case rt.Verb == "DELETE" && r.Delete == nil:
+				r.Delete = route
+			default:
+				continue
+			}
+			if wildcard == "" {
+				wildcard = path.Base(p)[1:]
+			}
+		}
+		if r.Show == nil && r.Create == nil && r.Update == nil && r.Delete == nil {
+			return nil
+		}
+		r.ID, r.Columns = columns(api, listAction, wildcard)
+		if createAction != nil {
+			r.CreateFields = fields(createAction.Payload)
+		}
+		if updateAction != nil {
+			r.UpdateFields = fields(updateAction.Payload)
+		}
+		resources = append(resources, r)
+		return nil
+	})
+	return resources
+}
+
+// returnsCollection returns true if the action OK response is a collection media type.
+func returnsCollection(api *design.APIDefinition, a *design.ActionDefinition) bool {
+	for _, resp := range a.Responses {
+		if resp.Status == 200 && resp.MediaType != "" {
+			if mt := api.MediaTypeWithIdentifier(resp.MediaType); mt != nil && mt.IsArray() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// literal returns the Go literal of a string, a raw string literal if possible.
+func literal(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// columns returns the name of the identifier attribute and the attributes of the default view of
+// the media type returned by the list action. The identifier is the attribute named after the
+// wildcard of the show, update or delete routes if any, "id" otherwise. It comes first in the
+// list of columns.
+func columns(api *design.APIDefinition, list *design.ActionDefinition, wildcard string) (string, []string) {
+	var obj design.Object
+	for _, resp := range list.Responses {
+		if resp.Status != 200 || resp.MediaType == "" {
+			continue
+		}
+		mt := api.MediaTypeWithIdentifier(resp.MediaType)
+		if mt == nil || !mt.IsArray() {
+			continue
+		}
+		if elem, ok := mt.ToArray().ElemType.Type.(*design.MediaTypeDefinition); ok {
+			mt = elem
+		}
+		if v, ok := mt.Views["default"]; ok {
+			obj = v.Type.ToObject()
+		} else {
+			obj = mt.Type.ToObject()
+		}
+	}
+	id := "id"
+	if _, ok := obj[wildcard]; ok {
+		id = wildcard
+	} else if _, ok := obj[id]; !ok && wildcard != "" {
+		id = wildcard
+	}
+	cols := []string{}
+	if _, ok := obj[id]; ok {
+		cols = append(cols, id)
+	}
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		if n != id && n != "links" {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return id, append(cols, names...)
+}
+
+// fields returns the form fields of the payload attributes sorted by name, the required
+// attributes come first.
+func fields(payload *design.UserTypeDefinition) []*Field {
+	if payload == nil {
+		return nil
+	}
+	obj := payload.Type.ToObject()
+	if obj == nil {
+		return nil
+	}
+	var res []*Field
+	for n, att := range obj {
+		res = append(res, field(n, att, payload.IsRequired(n)))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Required != res[j].Required {
+			return res[i].Required
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// field returns the form field of the given attribute. The attribute validations become the
+// corresponding HTML input attributes so that the browser validates the form before submitting
+// it.
+func field(name string, att *design.AttributeDefinition, required bool) *Field {
+	f := &Field{
+		Name:        name,
+		Description: att.Description,
+		Required:    required,
+		Default:     att.DefaultValue,
+		Input:       "json",
+	}
+	switch att.Type.Kind() {
+	case design.StringKind:
+		f.Input = "text"
+	case design.DateTimeKind:
+		f.Input = "datetime-local"
+	case design.UUIDKind:
+		f.Input = "text"
+		f.Pattern = "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}"
+	case design.IntegerKind:
+		f.Input, f.Integer = "number", true
+	case design.NumberKind:
+		f.Input = "number"
+	case design.BooleanKind:
+		f.Input = "checkbox"
+	}
+	v := att.Validation
+	if v == nil {
+		return f
+	}
+	if len(v.Values) > 0 && f.Input != "json" {
+		f.Input, f.Enum = "select", v.Values
+		return f
+	}
+	switch f.Input {
+	case "text":
+		switch v.Format {
+		case "email":
+			f.Input = "email"
+		case "uri":
+			f.Input = "url"
+		case "date-time":
+			f.Input = "datetime-local"
+		}
+		if f.Pattern == "" {
+			f.Pattern = v.Pattern
+		}
+		f.MinLength, f.MaxLength = v.MinLength, v.MaxLength
+	case "number":
+		f.Minimum, f.Maximum = v.Minimum, v.Maximum
+	}
+	return f
+}
+
+const adminT = `// Prefix is the path under which the admin UI is mounted.
+const Prefix = {{ printf "%q" .Prefix }}
+
+// MountController mounts the admin UI under Prefix. The UI lists, shows, creates, edits and
+// deletes the instances of the resources that define CRUD actions by making requests to the
+// service routes.
+func MountController(service *goa.Service) {
+	ctrl := service.NewController("Admin")
+	serve := func(contentType, content string) goa.MuxHandler {
+		return ctrl.MuxHandler("serve", func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.Header().Set("Content-Type", contentType)
+			_, err := io.WriteString(rw, content)
+			return err
+		}, nil)
+	}
+	service.Mux.Handle("GET", Prefix, serve("text/html; charset=utf-8", indexHTML))
+	service.Mux.Handle("GET", Prefix+"/admin.js", serve("application/javascript", adminJS))
+	service.Mux.Handle("GET", Prefix+"/config.json", serve("application/json", adminConfig))
+	service.LogInfo("mount", "ctrl", "Admin", "action", "UI", "route", "GET "+Prefix)
+}
+
+// adminConfig describes the resources managed by the admin UI.
+const adminConfig = {{ .Config }}
+
+// indexHTML is the admin UI page.
+const indexHTML = ` + "`{{ .HTML }}`" + `
+
+// adminJS is the admin UI script.
+const adminJS = ` + "`{{ .JS }}`" + `
+`
+
+const indexHTML = `<!doctype html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Admin</title>
+  <style>
+    body { font-family: sans-serif; margin: 0; display: flex; }
+    nav { width: 200px; padding: 1em; background: #f4f4f4; min-height: 100vh; }
+    nav a { display: block; margin: .3em 0; }
+    main { flex: 1; padding: 1em 2em; }
+    table { border-collapse: collapse; }
+    th, td { border: 1px solid #ddd; padding: .3em .6em; text-align: left; }
+    form div { margin: .6em 0; }
+    label { display: block; font-weight: bold; }
+    .error { color: #b00; white-space: pre-wrap; }
+    .actions a, .actions button { margin-right: .6em; }
+  </style>
+</head>
+<body>
+  <nav>
+    <div id="resources"></div>
+    <p><label for="authorization">Authorization</label><input id="authorization" size="18"></p>
+  </nav>
+  <main id="main"></main>
+  <script src="{{PREFIX}}/admin.js"></script>
+</body>
+</html>
+`
+
+const adminJS = `(function () {
+  "use strict";
+
+  var prefix = document.currentScript.src.replace(/\/admin\.js$/, "");
+  var main = document.getElementById("main");
+  var auth = document.getElementById("authorization");
+  var resources = {};
+
+  auth.value = localStorage.getItem("admin-authorization") || "";
+  auth.addEventListener("change", function () {
+    localStorage.setItem("admin-authorization", auth.value);
+  });
+
+  // el creates an element with the given attributes and children.
+  function el(tag, attrs, children) {
+    var e = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (k) {
+      if (attrs[k] !== undefined && attrs[k] !== null && attrs[k] !== false) {
+        e.setAttribute(k, attrs[k] === true ? "" : attrs[k]);
+      }
+    });
+    (children || []).forEach(function (c) {
+      e.appendChild(typeof c === "string" ? document.createTextNode(c) : c);
+    });
+    return e;
+  }
+
+  // url returns the path of a route with the trailing wildcard replaced with the given id.
+  function url(route, id) {
+    return route.path.replace(/\/:[a-zA-Z0-9_]+$/, "/" + encodeURIComponent(id));
+  }
+
+  // request sends a request to the service and returns a promise resolving to the decoded body.
+  function request(route, path, body) {
+    var headers = {"Accept": "application/json"};
+    if (auth.value) {
+      headers["Authorization"] = auth.value;
+    }
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    return fetch(path, {method: route.method, headers: headers, body: body === undefined ? undefined : JSON.stringify(body)})
+      .then(function (resp) {
+        return resp.text().then(function (text) {
+          if (!resp.ok) {
+            throw new Error(resp.status + " " + resp.statusText + "\n" + text);
+          }
+          return text ? JSON.parse(text) : null;
+        });
+      });
+  }
+
+  function render(title, children) {
+    main.innerHTML = "";
+    main.appendChild(el("h1", {}, [title]));
+    children.forEach(function (c) { main.appendChild(c); });
+  }
+
+  function fail(err) {
+    main.appendChild(el("p", {"class": "error"}, [err.message]));
+  }
+
+  function format(v) {
+    if (v === undefined || v === null) {
+      return "";
+    }
+    return typeof v === "object" ? JSON.stringify(v) : String(v);
+  }
+
+  function list(res) {
+    request(res.list, res.list.path).then(function (items) {
+      var head = el("tr", {}, res.columns.map(function (c) { return el("th", {}, [c]); }).concat([el("th")]));
+      var rows = (items || []).map(function (item) {
+        var links = [];
+        if (res.show) {
+          links.push(el("a", {href: "#/" + res.name + "/" + encodeURIComponent(item[res.id])}, ["show"]));
+        }
+        if (res.update) {
+          links.push(el("a", {href: "#/" + res.name + "/" + encodeURIComponent(item[res.id]) + "/edit"}, ["edit"]));
+        }
+        return el("tr", {}, res.columns.map(function (c) { return el("td", {}, [format(item[c])]); })
+          .concat([el("td", {"class": "actions"}, links)]));
+      });
+      var children = [el("table", {}, [head].concat(rows))];
+      if (res.create) {
+        children.unshift(el("p", {}, [el("a", {href: "#/" + res.name + "/new"}, ["New " + res.name])]));
+      }
+      if (res.description) {
+        children.unshift(el("p", {}, [res.description]));
+      }
+      render(res.name, children);
+    }).catch(function (err) { render(res.name, []); fail(err); });
+  }
+
+  function show(res, id) {
+    request(res.show, url(res.show, id)).then(function (item) {
+      var rows = Object.keys(item || {}).map(function (k) {
+        return el("tr", {}, [el("th", {}, [k]), el("td", {}, [format(item[k])])]);
+      });
+      var actions = [el("a", {href: "#/" + res.name}, ["back"])];
+      if (res.update) {
+        actions.push(el("a", {href: "#/" + res.name + "/" + encodeURIComponent(id) + "/edit"}, ["edit"]));
+      }
+      if (res["delete"]) {
+        var del = el("button", {}, ["delete"]);
+        del.addEventListener("click", function () {
+          if (confirm("Delete " + res.name + " " + id + "?")) {
+            request(res["delete"], url(res["delete"], id)).then(function () {
+              location.hash = "#/" + res.name;
+            }).catch(fail);
+          }
+        });
+        actions.push(del);
+      }
+      render(res.name + " " + id, [el("table", {}, rows), el("p", {"class": "actions"}, actions)]);
+    }).catch(function (err) { render(res.name + " " + id, []); fail(err); });
+  }
+
+  // input returns the form input of a field initialized with the given value.
+  function input(f, value) {
+    var attrs = {name: f.name, id: "field-" + f.name, required: f.required && f.input !== "checkbox"};
+    if (value === undefined) {
+      value = f["default"];
+    }
+    if (f.input === "select") {
+      var options = [el("option", {value: ""}, [""])].concat(f["enum"].map(function (v, i) {
+        return el("option", {value: String(i), selected: v === value}, [format(v)]);
+      }));
+      return el("select", attrs, options);
+    }
+    if (f.input === "json") {
+      return el("textarea", attrs, [value === undefined ? "" : JSON.stringify(value, null, 2)]);
+    }
+    attrs.type = f.input;
+    attrs.pattern = f.pattern;
+    attrs.minlength = f.minLength;
+    attrs.maxlength = f.maxLength;
+    attrs.min = f.minimum;
+    attrs.max = f.maximum;
+    if (f.input === "number") {
+      attrs.step = f.integer ? "1" : "any";
+    }
+    if (f.input === "checkbox") {
+      attrs.checked = value === true;
+    } else if (value !== undefined && value !== null) {
+      attrs.value = f.input === "datetime-local" ? String(value).slice(0, 16) : String(value);
+    }
+    return el("input", attrs);
+  }
+
+  // value returns the payload value of a field or undefined if the field is empty.
+  function value(f, e) {
+    if (f.input === "checkbox") {
+      return e.checked;
+    }
+    if (e.value === "") {
+      return undefined;
+    }
+    switch (f.input) {
+    case "select":
+      return f["enum"][Number(e.value)];
+    case "number":
+      return Number(e.value);
+    case "datetime-local":
+      return new Date(e.value).toISOString();
+    case "json":
+      return JSON.parse(e.value);
+    }
+    return e.value;
+  }
+
+  function form(res, id) {
+    var route = id === undefined ? res.create : res.update;
+    var fields = (id === undefined ? res.createFields : res.updateFields) || [];
+    var title = id === undefined ? "New " + res.name : "Edit " + res.name + " " + id;
+    var load = id === undefined || !res.show ? Promise.resolve({}) : request(res.show, url(res.show, id));
+    load.then(function (item) {
+      var f = el("form", {}, fields.map(function (fd) {
+        var label = [fd.name + (fd.required ? " *" : "")];
+        var children = [el("label", {"for": "field-" + fd.name}, label), input(fd, (item || {})[fd.name])];
+        if (fd.description) {
+          children.push(el("small", {}, [fd.description]));
+        }
+        return el("div", {}, children);
+      }).concat([el("button", {type: "submit"}, ["save"]), el("a", {href: "#/" + res.name}, [" cancel"])]));
+      f.addEventListener("submit", function (ev) {
+        ev.preventDefault();
+        var body = {};
+        try {
+          fields.forEach(function (fd) {
+            var v = value(fd, f.elements[fd.name]);
+            if (v !== undefined) {
+              body[fd.name] = v;
+            }
+          });
+        } catch (err) {
+          fail(err);
+          return;
+        }
+        request(route, id === undefined ? route.path : url(route, id), body).then(function () {
+          location.hash = "#/" + res.name;
+        }).catch(fail);
+      });
+      render(title, [f]);
+    }).catch(function (err) { render(title, []); fail(err); });
+  }
+
+  // route renders the page identified by the location hash: #/resource, #/resource/new,
+  // #/resource/id or #/resource/id/edit.
+  function route() {
+    var parts = location.hash.replace(/^#\/?/, "").split("/").map(decodeURIComponent);
+    var res = resources[parts[0]];
+    if (!res) {
+      render("Admin", [el("p", {}, ["Select a resource."])]);
+    } else if (parts.length === 1 || parts[1] === "") {
+      list(res);
+    } else if (parts[1] === "new" && res.create) {
+      form(res);
+    } else if (parts[2] === "edit" && res.update) {
+      form(res, parts[1]);
+    } else if (res.show) {
+      show(res, parts[1]);
+    } else {
+      list(res);
+    }
+  }
+
+  fetch(prefix + "/config.json").then(function (resp) { return resp.json(); }).then(function (config) {
+    var nav = document.getElementById("resources");
+    config.forEach(function (res) {
+      resources[res.name] = res;
+      nav.appendChild(el("a", {href: "#/" + res.name}, [res.name]));
+    });
+    window.addEventListener("hashchange", route);
+    route();
+  });
+})();
+`
@@ -0,0 +1,198 @@
+package genadmin_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_admin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	const outPackagePath = "github.com/goadesign/goa/goagen/gen_admin/admintest"
+
+	var outDir string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		gopath := filepath.SplitList(os.Getenv("GOPATH"))[0]
+		outDir = filepath.Join(gopath, "src", outPackagePath)
+		Ω(os.MkdirAll(outDir, 0777)).ShouldNot(HaveOccurred())
+		os.Args = []string{"goagen", "admin", "--out=" + outDir, "--design=foo", "--prefix=/console"}
+
+		dslengine.Reset()
+		API("cellar", func() {})
+		var Bottle = MediaType("application/vnd.bottle", func() {
+			Attributes(func() {
+				Attribute("name", String)
+				Attribute("id", Integer)
+				Attribute("color", String)
+				Attribute("links", HashOf(String, String))
+			})
+			View("default", func() {
+				Attribute("name")
+				Attribute("id")
+				Attribute("color")
+				Attribute("links")
+			})
+		})
+		var BottlePayload = Type("BottlePayload", func() {
+			Attribute("name", String, func() { MinLength(2) })
+			Attribute("color", String, func() { Enum("red", "white") })
+			Attribute("vintage", Integer, func() { Minimum(1900) })
+			Attribute("contact", String, func() { Format("email") })
+			Attribute("tags", ArrayOf(String))
+			Required("name", "vintage")
+		})
+		Resource("bottles", func() {
+			BasePath("/bottles")
+			Action("list", func() {
+				Routing(GET(""))
+				Response(OK, CollectionOf(Bottle))
+			})
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Response(OK, Bottle)
+			})
+			Action("create", func() {
+				Routing(POST(""))
+				Payload(BottlePayload)
+				Response(Created)
+			})
+			Action("update", func() {
+				Routing(PATCH("/:id"))
+				Payload(BottlePayload)
+				Response(NoContent)
+			})
+			Action("delete", func() {
+				Routing(DELETE("/:id"))
+				Response(NoContent)
+			})
+		})
+		Resource("health", func() {
+			Action("ping", func() {
+				Routing(GET("/ping"))
+				Response(OK)
+			})
+		})
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genadmin.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	It("generates the admin package", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(files).Should(Equal([]string{
+			filepath.Join(outDir, "admin"),
+			filepath.Join(outDir, "admin", "admin.go"),
+		}))
+		b, err := ioutil.ReadFile(filepath.Join(outDir, "admin", "admin.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		code := string(b)
+		Ω(code).Should(ContainSubstring("package admin"))
+		Ω(code).Should(ContainSubstring(`const Prefix = "/console"`))
+		Ω(code).Should(ContainSubstring(`service.Mux.Handle("GET", Prefix, serve("text/html; charset=utf-8", indexHTML))`))
+		Ω(code).Should(ContainSubstring(`service.Mux.Handle("GET", Prefix+"/config.json", serve("application/json", adminConfig))`))
+		Ω(code).Should(ContainSubstring(`<script src="/console/admin.js"></script>`))
+		Ω(code).Should(ContainSubstring(`"name": "bottles"`))
+		Ω(code).ShouldNot(ContainSubstring(`"name": "health"`))
+	})
+
+	It("imports the golang.org/x/net/context package without --stdcontext", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		b, err := ioutil.ReadFile(filepath.Join(outDir, "admin", "admin.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(b)).Should(ContainSubstring(`"golang.org/x/net/context"`))
+	})
+
+	Context("with --stdcontext", func() {
+		BeforeEach(func() {
+			os.Args = append(os.Args, "--stdcontext")
+		})
+
+		It("imports the standard library context package", func() {
+			Ω(genErr).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filepath.Join(outDir, "admin", "admin.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(ContainSubstring("\t\"context\"\n"))
+			Ω(string(b)).ShouldNot(ContainSubstring(`"golang.org/x/net/context"`))
+		})
+	})
+
+	Context("with a design that defines no CRUD actions", func() {
+		BeforeEach(func() {
+			dslengine.Reset()
+			API("cellar", func() {})
+			Resource("health", func() {
+				Action("ping", func() {
+					Routing(GET("/ping"))
+					Response(OK)
+				})
+			})
+			Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		})
+
+		It("fails", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(genErr.Error()).Should(ContainSubstring("no resource defines CRUD actions"))
+		})
+	})
+
+	Context("with the root prefix", func() {
+		BeforeEach(func() {
+			os.Args = []string{"goagen", "admin", "--out=" + outDir, "--design=foo", "--prefix=/"}
+		})
+
+		It("fails", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(genErr.Error()).Should(ContainSubstring("cannot be mounted at the root"))
+		})
+	})
+
+	Describe("Resources", func() {
+		It("identifies the CRUD actions and derives the columns and form fields", func() {
+			resources := genadmin.Resources(Design)
+			Ω(resources).Should(HaveLen(1))
+			r := resources[0]
+			Ω(r.Name).Should(Equal("bottles"))
+			Ω(r.List).Should(Equal(&genadmin.Route{Action: "list", Method: "GET", Path: "/bottles"}))
+			Ω(r.Show).Should(Equal(&genadmin.Route{Action: "show", Method: "GET", Path: "/bottles/:id"}))
+			Ω(r.Create).Should(Equal(&genadmin.Route{Action: "create", Method: "POST", Path: "/bottles"}))
+			Ω(r.Update).Should(Equal(&genadmin.Route{Action: "update", Method: "PATCH", Path: "/bottles/:id"}))
+			Ω(r.Delete).Should(Equal(&genadmin.Route{Action: "delete", Method: "DELETE", Path: "/bottles/:id"}))
+			Ω(r.ID).Should(Equal("id"))
+			Ω(r.Columns).Should(Equal([]string{"id", "color", "name"}))
+
+			Ω(r.CreateFields).Should(HaveLen(5))
+			var names []string
+			for _, f := range r.CreateFields {
+				names = append(names, f.Name)
+			}
+			Ω(names).Should(Equal([]string{"name", "vintage", "color", "contact", "tags"}))
+			name, vintage, color, contact, tags := r.CreateFields[0], r.CreateFields[1], r.CreateFields[2], r.CreateFields[3], r.CreateFields[4]
+			Ω(name.Input).Should(Equal("text"))
+			Ω(name.Required).Should(BeTrue())
+			Ω(*name.MinLength).Should(Equal(2))
+			Ω(vintage.Input).Should(Equal("number"))
+			Ω(vintage.Integer).Should(BeTrue())
+			Ω(*vintage.Minimum).Should(Equal(1900.0))
+			Ω(color.Input).Should(Equal("select"))
+			Ω(color.Enum).Should(Equal([]interface{}{"red", "white"}))
+			Ω(contact.Input).Should(Equal("email"))
+			Ω(tags.Input).Should(Equal("json"))
+			Ω(r.UpdateFields).Should(Equal(r.CreateFields))
+		})
+	})
+})
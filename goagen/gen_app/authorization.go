@@ -0,0 +1,433 @@
+package genapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+type (
+	// AuthScheme is the data used to generate the test security scheme of a security scheme
+	// declared in the design, see goatest.AuthScheme.
+	AuthScheme struct {
+		Name    string
+		Kind    string
+		In      string
+		Key     string
+		UseFunc string
+	}
+
+	// AuthController is the data used to generate the stub controller of a resource that the
+	// authorization tests mount.
+	AuthController struct {
+		Name    string
+		VarName string
+		Mount   string
+		Actions []*AuthAction
+	}
+
+	// AuthAction is the data used to generate the authorization test cases of an action.
+	AuthAction struct {
+		Name        string
+		ContextType string
+		Builder     string
+		RouteVerb   string
+		URL         string
+		Headers     []*AuthHeader
+		Body        string
+		Status      int
+		Cases       []*AuthCase
+	}
+
+	// AuthHeader is a request header sent by the authorization tests.
+	AuthHeader struct {
+		Name  string
+		Value string
+	}
+
+	// AuthCase is an authorization test case: the request is signed with the credentials of
+	// the scheme granting the scopes and the response must have the given status.
+	AuthCase struct {
+		Name   string
+		Scheme string
+		Scopes []string
+		Status int
+	}
+)
+
+// generateAuthorizationTest generates the test helpers and the test that check the authorization
+// of every action: for each action the test sends requests without credentials, with the
+// credentials of the other security schemes, with credentials missing each of the required scopes
+// and with valid credentials and checks that the responses have the status 401, 403 or the
+// action success status as appropriate.
+func (g *Generator) generateAuthorizationTest(api *design.APIDefinition) error {
+	if len(api.SecuritySchemes) == 0 || len(api.Resources) == 0 {
+		return nil
+	}
+	outDir := filepath.Join(g.outDir, "test")
+	appPkg, err := codegen.PackagePath(g.outDir)
+	if err != nil {
+		return err
+	}
+
+	schemes := authSchemes(api)
+	var controllers []*AuthController
+	err = api.IterateResources(func(res *design.ResourceDefinition) error {
+		if len(res.Actions) == 0 {
+			return nil
+		}
+		name := codegen.Goify(res.Name, true)
+		ctrl := &AuthController{
+			Name:    name,
+			VarName: codegen.Goify(res.Name, false) + "AuthController",
+			Mount:   fmt.Sprintf("%s.Mount%sController", g.target, name),
+		}
+		err := res.IterateActions(func(action *design.ActionDefinition) error {
+			ctrl.Actions = append(ctrl.Actions, g.authAction(res, action, api.SecuritySchemes))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		controllers = append(controllers, ctrl)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	helpersFile := filepath.Join(outDir, "authorization.go")
+	file, err := codegen.SourceFileFor(helpersFile)
+	if err != nil {
+		return err
+	}
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("io"),
+		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("strings"),
+		codegen.SimpleImport(appPkg),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("github.com/goadesign/goa/goatest"),
+	}
+	if err := file.WriteHeader("", "test", imports); err != nil {
+		return err
+	}
+	g.addFile(helpersFile)
+	data := map[string]interface{}{
+		"Schemes":     schemes,
+		"Controllers": controllers,
+		"Target":      g.target,
+	}
+	tmpl := template.Must(template.New("authHelpers").Parse(codegen.Template("authHelpers", authHelpersTmpl)))
+	if err := tmpl.Execute(file, data); err != nil {
+		panic(err) // bug
+	}
+	if err := file.FormatCode(); err != nil {
+		return err
+	}
+
+	testFile := filepath.Join(outDir, "authorization_test.go")
+	file, err = codegen.SourceFileFor(testFile)
+	if err != nil {
+		return err
+	}
+	imports = []*codegen.ImportSpec{
+		codegen.SimpleImport("testing"),
+		codegen.SimpleImport(appPkg),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("github.com/goadesign/goa/goatest"),
+	}
+	if err := file.WriteHeader("", "test", imports); err != nil {
+		return err
+	}
+	g.addFile(testFile)
+	tmpl = template.Must(template.New("authTest").Parse(codegen.Template("authTest", authTestTmpl)))
+	if err := tmpl.Execute(file, data); err != nil {
+		panic(err) // bug
+	}
+	return file.FormatCode()
+}
+
+// authSchemes returns the data used to generate the test security schemes sorted by name.
+func authSchemes(api *design.APIDefinition) []*AuthScheme {
+	schemes := make([]*AuthScheme, len(api.SecuritySchemes))
+	for i, s := range api.SecuritySchemes {
+		scheme := &AuthScheme{
+			Name:    s.SchemeName,
+			In:      "goa.LocHeader",
+			Key:     s.Name,
+			UseFunc: fmt.Sprintf("Use%sMiddleware", codegen.Goify(s.SchemeName, true)),
+		}
+		switch s.Kind {
+		case design.OAuth2SecurityKind:
+			scheme.Kind = "goatest.OAuth2Kind"
+		case design.BasicAuthSecurityKind:
+			scheme.Kind = "goatest.BasicAuthKind"
+		case design.APIKeySecurityKind:
+			scheme.Kind = "goatest.APIKeyKind"
+		case design.JWTSecurityKind:
+			scheme.Kind = "goatest.JWTKind"
+		}
+		if s.In == "query" {
+			scheme.In = "goa.LocQuery"
+		}
+		schemes[i] = scheme
+	}
+	sort.Slice(schemes, func(i, j int) bool { return schemes[i].Name < schemes[j].Name })
+	return schemes
+}
+
+// authAction returns the data used to generate the authorization test cases of the given action.
+// The requests are sent to the first route of the action and use example values for the route
+// parameters, the required querystring parameters and headers and the payload. Actions without
+// routes have no test cases.
+func (g *Generator) authAction(res *design.ResourceDefinition, action *design.ActionDefinition, schemes []*design.SecuritySchemeDefinition) *AuthAction {
+	resName := codegen.Goify(res.Name, true)
+	actName := codegen.Goify(action.Name, true)
+	a := &AuthAction{
+		Name:        actName,
+		ContextType: fmt.Sprintf("%s.%s%sContext", g.target, actName, resName),
+		Status:      successStatus(action),
+	}
+	if len(action.Routes) == 0 {
+		return a
+	}
+	r := design.NewRandomGenerator(res.Name + "#" + action.Name)
+	route := action.Routes[0]
+	params := map[string]*design.AttributeDefinition{}
+	if action.Params != nil {
+		params = action.Params.Type.ToObject()
+	}
+	path := design.WildcardRegex.ReplaceAllStringFunc(route.FullPath(), func(wc string) string {
+		name := design.WildcardRegex.FindStringSubmatch(wc)[1]
+		if att, ok := params[name]; ok {
+			return "/" + url.PathEscape(exampleString(att.GenerateExample(r)))
+		}
+		return "/" + name
+	})
+	routeParams := make(map[string]bool)
+	for _, p := range route.Params() {
+		routeParams[p] = true
+	}
+	query := url.Values{}
+	for _, name := range sortedKeys(params) {
+		if !routeParams[name] && action.Params.IsRequired(name) {
+			query.Set(name, exampleString(params[name].GenerateExample(r)))
+		}
+	}
+	var headers []*AuthHeader
+	if action.Headers != nil {
+		atts := action.Headers.Type.ToObject()
+		for _, name := range sortedKeys(atts) {
+			if action.Headers.IsRequired(name) {
+				headers = append(headers, &AuthHeader{Name: name, Value: exampleString(atts[name].GenerateExample(r))})
+			}
+		}
+	}
+	var body string
+	if action.Payload != nil {
+		b, err := json.Marshal(toStringMap(action.Payload.GenerateExample(r)))
+		if err != nil {
+			panic(err) // bug
+		}
+		body = string(b)
+	}
+
+	a.Builder = fmt.Sprintf("new%s%sAuthRequest", actName, resName)
+	a.RouteVerb = route.Verb
+	a.URL = path
+	if len(query) > 0 {
+		a.URL += "?" + query.Encode()
+	}
+	a.Headers = headers
+	a.Body = body
+	label := resName + "." + actName
+	sec := action.Security
+	if sec == nil {
+		a.Cases = []*AuthCase{{Name: label + " without credentials", Status: a.Status}}
+		return a
+	}
+	a.Cases = []*AuthCase{{Name: label + " without credentials", Status: 401}}
+	for _, s := range schemes {
+		if s.SchemeName == sec.Scheme.SchemeName {
+			continue
+		}
+		a.Cases = append(a.Cases, &AuthCase{
+			Name:   fmt.Sprintf("%s with %s credentials", label, s.SchemeName),
+			Scheme: s.SchemeName,
+			Scopes: sortedKeys(s.Scopes),
+			Status: 401,
+		})
+	}
+	for i, scope := range sec.Scopes {
+		scopes := make([]string, 0, len(sec.Scopes)-1)
+		scopes = append(scopes, sec.Scopes[:i]...)
+		scopes = append(scopes, sec.Scopes[i+1:]...)
+		a.Cases = append(a.Cases, &AuthCase{
+			Name:   fmt.Sprintf("%s without scope %s", label, scope),
+			Scheme: sec.Scheme.SchemeName,
+			Scopes: scopes,
+			Status: 403,
+		})
+	}
+	a.Cases = append(a.Cases, &AuthCase{
+		Name:   fmt.Sprintf("%s with %s credentials", label, sec.Scheme.SchemeName),
+		Scheme: sec.Scheme.SchemeName,
+		Scopes: sec.Scopes,
+		Status: a.Status,
+	})
+	return a
+}
+
+// successStatus returns the lowest 2xx status of the action responses, 200 if there is none.
+func successStatus(action *design.ActionDefinition) int {
+	status := 0
+	for _, resp := range action.Responses {
+		if resp.Status >= 200 && resp.Status < 300 && (status == 0 || resp.Status < status) {
+			status = resp.Status
+		}
+	}
+	if status == 0 {
+		return 200
+	}
+	return status
+}
+
+// exampleString returns the string representation of an example value used in paths, querystrings
+// and headers.
+func exampleString(v interface{}) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	if val := reflect.ValueOf(v); val.Kind() == reflect.Slice {
+		elems := make([]string, val.Len())
+		for i := range elems {
+			elems[i] = exampleString(val.Index(i).Interface())
+		}
+		return strings.Join(elems, ",")
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// toStringMap converts map[interface{}]interface{} to a map[string]interface{} when possible.
+func toStringMap(val interface{}) interface{} {
+	switch actual := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, v := range actual {
+			m[toString(k)] = toStringMap(v)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for k, v := range actual {
+			m[k] = toStringMap(v)
+		}
+		return m
+	case []interface{}:
+		mapSlice := make([]interface{}, len(actual))
+		for i, e := range actual {
+			mapSlice[i] = toStringMap(e)
+		}
+		return mapSlice
+	default:
+		return actual
+	}
+}
+
+// toString returns the string representation of the given type.
+func toString(val interface{}) string {
+	switch actual := val.(type) {
+	case string:
+		return actual
+	case int:
+		return strconv.Itoa(actual)
+	case float64:
+		return strconv.FormatFloat(actual, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(actual)
+	default:
+		return fmt.Sprintf("%v", actual)
+	}
+}
+
+// sortedKeys returns the keys of m in alphabetical order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const authHelpersTmpl = `
+// AuthSchemes returns the test security schemes indexed by name. The schemes accept the
+// credentials they sign only, see goatest.AuthScheme.
+func AuthSchemes() map[string]*goatest.AuthScheme {
+	return map[string]*goatest.AuthScheme{
+{{ range .Schemes }}		{{ printf "%q" .Name }}: {Name: {{ printf "%q" .Name }}, Kind: {{ .Kind }}, In: {{ .In }}, Key: {{ printf "%q" .Key }}},
+{{ end }}	}
+}
+
+// MountAuthMiddlewares mounts the middlewares of the given test security schemes onto the
+// service. It must be called before the controllers are created.
+func MountAuthMiddlewares(service *goa.Service, schemes map[string]*goatest.AuthScheme) {
+{{ range .Schemes }}	{{ $.Target }}.{{ .UseFunc }}(service, schemes[{{ printf "%q" .Name }}].Middleware())
+{{ end }}}
+
+// AuthCases returns the authorization test cases of every action: requests without credentials,
+// with the credentials of the other schemes, missing each required scope and with the required
+// scopes.
+func AuthCases() []*goatest.AuthCase {
+	return []*goatest.AuthCase{
+{{ range .Controllers }}{{ range $action := .Actions }}{{ range .Cases }}		{Name: {{ printf "%q" .Name }}, Request: {{ $action.Builder }}{{ if .Scheme }}, Scheme: {{ printf "%q" .Scheme }}{{ end }}{{ if .Scopes }}, Scopes: []string{ {{- range $i, $s := .Scopes }}{{ if $i }}, {{ end }}{{ printf "%q" $s }}{{ end }}}{{ end }}, Status: {{ .Status }}},
+{{ end }}{{ end }}{{ end }}	}
+}
+{{ range .Controllers }}{{ range .Actions }}{{ if .Builder }}
+// {{ .Builder }} builds the request sent by the authorization tests to the {{ .Name }} action.
+func {{ .Builder }}() *http.Request {
+	var body io.Reader
+	{{ if .Body }}body = strings.NewReader({{ printf "%q" .Body }})
+	{{ end }}req, err := http.NewRequest({{ printf "%q" .RouteVerb }}, {{ printf "%q" .URL }}, body)
+	if err != nil {
+		panic("invalid test " + err.Error()) // bug
+	}
+	{{ if .Body }}req.Header.Set("Content-Type", "application/json")
+	{{ end }}{{ range .Headers }}req.Header.Set({{ printf "%q" .Name }}, {{ printf "%q" .Value }})
+	{{ end }}return req
+}
+{{ end }}{{ end }}{{ end }}`
+
+const authTestTmpl = `{{ range .Controllers }}
+// {{ .VarName }} is a stub controller that responds with the success status of each action.
+type {{ .VarName }} struct {
+	*goa.Controller
+}
+{{ $ctrl := . }}{{ range .Actions }}
+// {{ .Name }} responds with {{ .Status }}.
+func (c *{{ $ctrl.VarName }}) {{ .Name }}(ctx *{{ .ContextType }}) error {
+	ctx.ResponseData.WriteHeader({{ .Status }})
+	return nil
+}
+{{ end }}{{ end }}
+// TestAuthorizationMatrix checks the authorization of every action with every security scheme and
+// scope combination.
+func TestAuthorizationMatrix(t *testing.T) {
+	srv := goatest.NewServer(t)
+	schemes := AuthSchemes()
+	MountAuthMiddlewares(srv.Service, schemes)
+{{ range .Controllers }}	{{ .Mount }}(srv.Service, &{{ .VarName }}{Controller: srv.Service.NewController({{ printf "%q" (printf "%sController" .Name) }})})
+{{ end }}	srv.RunAuthCases(schemes, AuthCases())
+}
+`
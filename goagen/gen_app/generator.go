@@ -1,30 +1,55 @@
 package genapp
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_swagger"
 	"github.com/goadesign/goa/goagen/utils"
 )
 
+// convertToKey is the name of the metadata that lists the media types the generated conversion
+// functions of a user type or payload target.
+const convertToKey = "convert:to"
+
 // Generator is the application code generator.
 type Generator struct {
-	outDir   string   // Path to output directory
-	target   string   // Name of generated package
-	notest   bool     // Whether to skip test generation
-	genfiles []string // Generated files
+	outDir     string     // Path to output directory
+	target     string     // Name of generated package
+	notest     bool       // Whether to skip test generation
+	pool       bool       // Whether to pool action contexts
+	stdcontext bool       // Whether to import the standard library context package
+	head       bool       // Whether to mount HEAD handlers for GET routes
+	intercept  bool       // Whether to generate the controller interceptor interfaces
+	shard      bool       // Whether to generate one package per resource
+	swagger    bool       // Whether to generate the handlers serving the Swagger spec and UI
+	genfiles   []string   // Generated files
+	mu         sync.Mutex // Protects genfiles
+
+	// resource is the resource whose package is generated when sharding, nil for the shared
+	// and application packages.
+	resource *design.ResourceDefinition
 }
 
 // Generate is the generator entry point called by the meta generator.
 func Generate() (files []string, err error) {
 	var (
 		outDir, target string
-		notest         bool
+		notest, pool   bool
+		stdcontext     bool
+		head           bool
+		intercept      bool
+		shard          bool
+		swagger        bool
 	)
 
 	set := flag.NewFlagSet("app", flag.PanicOnError)
@@ -32,12 +57,21 @@ func Generate() (files []string, err error) {
 	set.StringVar(&outDir, "out", "", "")
 	set.StringVar(&target, "pkg", "app", "")
 	set.BoolVar(&notest, "notest", false, "")
+	set.BoolVar(&pool, "pool", false, "")
+	set.BoolVar(&stdcontext, "stdcontext", false, "")
+	set.BoolVar(&head, "head", false, "")
+	set.BoolVar(&intercept, "interceptors", false, "")
+	set.BoolVar(&shard, "shard", false, "")
+	set.BoolVar(&swagger, "swagger", false, "")
 	set.Parse(os.Args[2:])
 	outDir = filepath.Join(outDir, target)
 
 	target = codegen.Goify(target, false)
-	g := &Generator{outDir: outDir, target: target, notest: notest}
+	g := &Generator{outDir: outDir, target: target, notest: notest, pool: pool, stdcontext: stdcontext, head: head, intercept: intercept, shard: shard, swagger: swagger}
+	// Reserve the package name for the duration of the generation only so that generating
+	// again in the same process produces the same package name.
 	codegen.Reserved[target] = true
+	defer delete(codegen.Reserved, target)
 
 	return g.Generate(design.Design)
 }
@@ -56,45 +90,106 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		}
 	}()
 
-	os.RemoveAll(g.outDir)
-
-	if err := os.MkdirAll(g.outDir, 0755); err != nil {
-		return nil, err
-	}
-	g.genfiles = []string{g.outDir}
-	if err := g.generateContexts(api); err != nil {
-		return nil, err
-	}
-	if err := g.generateControllers(api); err != nil {
-		return nil, err
-	}
-	if err := g.generateSecurity(api); err != nil {
-		return nil, err
-	}
-	if err := g.generateHrefs(api); err != nil {
+	render, err := codegen.BeginRender(g.outDir)
+	if err != nil {
 		return nil, err
 	}
-	if err := g.generateMediaTypes(api); err != nil {
+	defer render.Discard()
+	if err := os.MkdirAll(g.outDir, 0755); err != nil {
 		return nil, err
 	}
-	if err := g.generateUserTypes(api); err != nil {
+	g.genfiles = []string{g.outDir}
+	if g.shard {
+		if err := g.generateShards(api); err != nil {
+			return nil, err
+		}
+	} else if err := g.generateSources(api); err != nil {
 		return nil, err
 	}
 	if !g.notest {
 		if err := g.generateResourceTest(api); err != nil {
 			return nil, err
 		}
+		if err := g.generateAuthorizationTest(api); err != nil {
+			return nil, err
+		}
 	}
 
-	return g.genfiles, nil
+	return g.genfiles, render.Commit()
+}
+
+// generateSources generates the application package source files.
+func (g *Generator) generateSources(api *design.APIDefinition) error {
+	projectMediaTypes(api)
+	err := g.parallel(api,
+		g.generateContexts,
+		g.generateControllers,
+		g.generateSecurity,
+		g.generateFeatures,
+		g.generateRetention,
+		g.generateHealth,
+		g.generateProtobuf,
+		g.generateHrefs,
+		g.generateMediaTypes,
+		g.generateUserTypes,
+	)
+	if err != nil {
+		return err
+	}
+	return g.generateSwagger(api)
 }
 
-// Cleanup removes the entire "app" directory if it was created by this generator.
+// parallel runs the given generation functions concurrently, each function renders its files with
+// its own writers. parallel returns the error returned by the first function in the list that
+// failed if any.
+func (g *Generator) parallel(api *design.APIDefinition, fns ...func(*design.APIDefinition) error) error {
+	n := len(g.genfiles)
+	errs := make([]error, len(fns))
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func(i int, fn func(*design.APIDefinition) error) {
+			defer wg.Done()
+			errs[i] = fn(api)
+		}(i, fn)
+	}
+	wg.Wait()
+	// Keep the list of generated files independent of the order in which they were rendered.
+	sort.Strings(g.genfiles[n:])
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectMediaTypes computes the projections of all the media type views. The projections are
+// cached in the design the first time they are computed so that the generation functions run by
+// parallel only read the cache.
+func projectMediaTypes(api *design.APIDefinition) {
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		for view := range mt.Views {
+			mt.Project(view)
+		}
+		return nil
+	})
+}
+
+// addFile records the generated file with the given path, it may be called concurrently.
+func (g *Generator) addFile(path string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.genfiles = append(g.genfiles, path)
+}
+
+// Cleanup removes the files generated by this generator during the last invocation of Generate
+// and the "app" directory if it is left empty.
 func (g *Generator) Cleanup() {
 	if len(g.genfiles) == 0 {
 		return
 	}
-	os.RemoveAll(g.outDir)
+	codegen.RemoveGenerated(g.genfiles)
 	g.genfiles = nil
 }
 
@@ -109,16 +204,23 @@ func (g *Generator) generateContexts(api *design.APIDefinition) error {
 	title := fmt.Sprintf("%s: Application Contexts", api.Context())
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("fmt"),
-		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.ContextImport(g.stdcontext),
 		codegen.SimpleImport("strconv"),
 		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("sync"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("net"),
+		codegen.SimpleImport("net/netip"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("github.com/goadesign/goa/middleware"),
 		codegen.NewImport("uuid", "github.com/satori/go.uuid"),
 	}
-	g.genfiles = append(g.genfiles, ctxFile)
+	g.addFile(ctxFile)
 	ctxWr.WriteHeader(title, g.target, imports)
 	err = api.IterateResources(func(r *design.ResourceDefinition) error {
+		if g.resource != nil && r != g.resource {
+			return nil
+		}
 		return r.IterateActions(func(a *design.ActionDefinition) error {
 			ctxName := codegen.Goify(a.Name, true) + codegen.Goify(a.Parent.Name, true) + "Context"
 			headers := r.Headers.Merge(a.Headers)
@@ -136,18 +238,41 @@ func (g *Generator) generateContexts(api *design.APIDefinition) error {
 					non101[k] = v
 				}
 			}
+			var convs []*ConversionTemplateData
+			var stream string
+			if a.StreamPayload() {
+				stream = codegen.Goify(a.Payload.TypeName, true) + "Stream"
+			} else if a.Payload != nil {
+				var candidates []*design.MediaTypeDefinition
+				a.IterateResponses(func(resp *design.ResponseDefinition) error {
+					if resp.Status >= 200 && resp.Status < 300 {
+						if mt := api.MediaTypeWithIdentifier(resp.MediaType); mt != nil {
+							candidates = append(candidates, mt)
+						}
+					}
+					return nil
+				})
+				var err error
+				if convs, err = conversions(api, a.Payload, candidates); err != nil {
+					return err
+				}
+			}
 			ctxData := ContextTemplateData{
-				Name:         ctxName,
-				ResourceName: r.Name,
-				ActionName:   a.Name,
-				Payload:      a.Payload,
-				Params:       params,
-				Headers:      headers,
-				Routes:       a.Routes,
-				Responses:    non101,
-				API:          api,
-				DefaultPkg:   g.target,
-				Security:     a.Security,
+				Name:          ctxName,
+				ResourceName:  r.Name,
+				ActionName:    a.Name,
+				Payload:       a.Payload,
+				Params:        params,
+				Headers:       headers,
+				Routes:        a.Routes,
+				Responses:     non101,
+				API:           api,
+				DefaultPkg:    g.target,
+				Security:      a.Security,
+				Pool:          g.pool,
+				Conversions:   convs,
+				Stream:        a.Stream(),
+				PayloadStream: stream,
 			}
 			return ctxWr.Execute(&ctxData)
 		})
@@ -158,6 +283,32 @@ func (g *Generator) generateContexts(api *design.APIDefinition) error {
 	return ctxWr.FormatCode()
 }
 
+// headRoutes returns the GET routes of the action that should also be mounted as HEAD routes, that
+// is all GET routes for which the API does not define a HEAD route if the head flag is set.
+func (g *Generator) headRoutes(api *design.APIDefinition, a *design.ActionDefinition) []*design.RouteDefinition {
+	if !g.head {
+		return nil
+	}
+	heads := make(map[string]bool)
+	api.IterateResources(func(r *design.ResourceDefinition) error {
+		return r.IterateActions(func(ac *design.ActionDefinition) error {
+			for _, ro := range ac.Routes {
+				if ro.Verb == "HEAD" {
+					heads[ro.FullPath()] = true
+				}
+			}
+			return nil
+		})
+	})
+	var routes []*design.RouteDefinition
+	for _, ro := range a.Routes {
+		if ro.Verb == "GET" && !heads[ro.FullPath()] {
+			routes = append(routes, ro)
+		}
+	}
+	return routes
+}
+
 // generateControllers iterates through the API resources and generates the low level
 // controllers.
 func (g *Generator) generateControllers(api *design.APIDefinition) error {
@@ -170,9 +321,11 @@ func (g *Generator) generateControllers(api *design.APIDefinition) error {
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("fmt"),
-		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("strconv"),
+		codegen.ContextImport(g.stdcontext),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("github.com/goadesign/goa/cors"),
+		codegen.SimpleImport("github.com/goadesign/goa/middleware"),
 	}
 	encoders, err := BuildEncoders(api.Produces, true)
 	if err != nil {
@@ -182,33 +335,26 @@ func (g *Generator) generateControllers(api *design.APIDefinition) error {
 	if err != nil {
 		return err
 	}
-	encoderImports := make(map[string]bool)
-	for _, data := range encoders {
-		encoderImports[data.PackagePath] = true
-	}
-	for _, data := range decoders {
-		encoderImports[data.PackagePath] = true
-	}
-	var packagePaths []string
-	for packagePath := range encoderImports {
-		if packagePath != "github.com/goadesign/goa" {
-			packagePaths = append(packagePaths, packagePath)
-		}
-	}
-	sort.Strings(packagePaths)
-	for _, packagePath := range packagePaths {
-		imports = append(imports, codegen.SimpleImport(packagePath))
-	}
+	imports = append(imports, encoderImports(encoders, decoders)...)
 	ctlWr.WriteHeader(title, g.target, imports)
-	ctlWr.WriteInitService(encoders, decoders)
+	if !g.shard {
+		ctlWr.WriteInitService(encoders, decoders)
+	}
 
 	var controllersData []*ControllerTemplateData
 	err = api.IterateResources(func(r *design.ResourceDefinition) error {
+		if g.resource != nil && r != g.resource {
+			return nil
+		}
 		data := &ControllerTemplateData{
 			API:            api,
 			Resource:       codegen.Goify(r.Name, true),
 			PreflightPaths: r.PreflightPaths(),
 			FileServers:    r.FileServers,
+			Pool:           g.pool,
+			Interceptors:   g.intercept,
+			ResourceName:   r.Name,
+			Maintenance:    r.Maintenance(),
 		}
 		ierr := r.IterateActions(func(a *design.ActionDefinition) error {
 			context := fmt.Sprintf("%s%sContext", codegen.Goify(a.Name, true), codegen.Goify(r.Name, true))
@@ -216,12 +362,34 @@ func (g *Generator) generateControllers(api *design.APIDefinition) error {
 			action := map[string]interface{}{
 				"Name":            codegen.Goify(a.Name, true),
 				"Routes":          a.Routes,
+				"Aliases":         a.AliasRoutes(),
+				"HeadRoutes":      g.headRoutes(api, a),
+				"QualifiedName":   fmt.Sprintf("%s.%s", r.Name, a.Name),
+				"Concurrency":     a.Concurrency(),
+				"SkipCompression": a.SkipCompression(),
+				"Timeout":         a.Timeout,
+				"AuditInclude":    a.AuditInclude(),
+				"AuditExclude":    a.AuditExclude(),
+				"Middleware":      reverse(a.AllMiddleware()),
+				"Interceptor":     codegen.Goify(a.Name, true) + codegen.Goify(r.Name, true),
 				"Context":         context,
 				"Unmarshal":       unmarshal,
 				"Payload":         a.Payload,
 				"PayloadOptional": a.PayloadOptional,
 				"Security":        a.Security,
 			}
+			if a.StreamPayload() {
+				action["PayloadStream"] = codegen.Goify(a.Payload.TypeName, true) + "Stream"
+				action["PayloadStreamMin"], action["PayloadStreamMax"] = 0, 0
+				if v := a.Payload.Validation; v != nil {
+					if v.MinLength != nil {
+						action["PayloadStreamMin"] = *v.MinLength
+					}
+					if v.MaxLength != nil {
+						action["PayloadStreamMax"] = *v.MaxLength
+					}
+				}
+			}
 			data.Actions = append(data.Actions, action)
 			return nil
 		})
@@ -239,13 +407,47 @@ func (g *Generator) generateControllers(api *design.APIDefinition) error {
 	if err != nil {
 		return err
 	}
-	g.genfiles = append(g.genfiles, ctlFile)
+	g.addFile(ctlFile)
 	if err = ctlWr.Execute(controllersData); err != nil {
 		return err
 	}
 	return ctlWr.FormatCode()
 }
 
+// reverse returns the names of the named middleware in the order the generated code wraps the
+// action handler with them: the first middleware listed runs first so it must wrap the others.
+func reverse(names []string) []string {
+	reversed := make([]string, len(names))
+	for i, n := range names {
+		reversed[len(names)-1-i] = n
+	}
+	return reversed
+}
+
+// encoderImports returns the imports of the packages that implement the given encoders and
+// decoders.
+func encoderImports(encoders, decoders []*EncoderTemplateData) []*codegen.ImportSpec {
+	paths := make(map[string]bool)
+	for _, data := range encoders {
+		paths[data.PackagePath] = true
+	}
+	for _, data := range decoders {
+		paths[data.PackagePath] = true
+	}
+	var packagePaths []string
+	for packagePath := range paths {
+		if packagePath != "github.com/goadesign/goa" {
+			packagePaths = append(packagePaths, packagePath)
+		}
+	}
+	sort.Strings(packagePaths)
+	imports := make([]*codegen.ImportSpec, len(packagePaths))
+	for i, packagePath := range packagePaths {
+		imports[i] = codegen.SimpleImport(packagePath)
+	}
+	return imports
+}
+
 // generateControllers iterates through the API resources and generates the low level
 // controllers.
 func (g *Generator) generateSecurity(api *design.APIDefinition) error {
@@ -263,12 +465,12 @@ func (g *Generator) generateSecurity(api *design.APIDefinition) error {
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("errors"),
-		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.ContextImport(g.stdcontext),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 	}
 	secWr.WriteHeader(title, g.target, imports)
 
-	g.genfiles = append(g.genfiles, secFile)
+	g.addFile(secFile)
 
 	if err = secWr.Execute(design.Design.SecuritySchemes); err != nil {
 		return err
@@ -277,6 +479,224 @@ func (g *Generator) generateSecurity(api *design.APIDefinition) error {
 	return secWr.FormatCode()
 }
 
+// generateFeatures generates the accessors for the feature flags declared in the API design.
+func (g *Generator) generateFeatures(api *design.APIDefinition) error {
+	if len(api.Features) == 0 {
+		return nil
+	}
+
+	featFile := filepath.Join(g.outDir, "features.go")
+	featWr, err := NewFeaturesWriter(featFile)
+	if err != nil {
+		panic(err) // bug
+	}
+
+	title := fmt.Sprintf("%s: Application Feature Flags", api.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.ContextImport(g.stdcontext),
+		codegen.SimpleImport("github.com/goadesign/goa/middleware"),
+	}
+	featWr.WriteHeader(title, g.target, imports)
+
+	g.addFile(featFile)
+
+	if err = featWr.Execute(api.Features); err != nil {
+		return err
+	}
+
+	return featWr.FormatCode()
+}
+
+// generateRetention generates the map of the media type retention periods and the function that
+// mounts the handler exposing it.
+func (g *Generator) generateRetention(api *design.APIDefinition) error {
+	var mts []*design.MediaTypeDefinition
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if mt.Retention() != "" {
+			mts = append(mts, mt)
+		}
+		return nil
+	})
+	if len(mts) == 0 {
+		return nil
+	}
+
+	retFile := filepath.Join(g.outDir, "retention.go")
+	retWr, err := NewRetentionWriter(retFile)
+	if err != nil {
+		panic(err) // bug
+	}
+
+	title := fmt.Sprintf("%s: Application Data Retention", api.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("net/http"),
+		codegen.ContextImport(g.stdcontext),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	retWr.WriteHeader(title, g.target, imports)
+
+	g.addFile(retFile)
+
+	if err = retWr.Execute(mts); err != nil {
+		return err
+	}
+
+	return retWr.FormatCode()
+}
+
+// generateHealth generates the function that mounts the health check endpoints if the API design
+// defines them.
+func (g *Generator) generateHealth(api *design.APIDefinition) error {
+	if api.Health == nil {
+		return nil
+	}
+
+	healthFile := filepath.Join(g.outDir, "health.go")
+	healthWr, err := NewHealthWriter(healthFile)
+	if err != nil {
+		panic(err) // bug
+	}
+
+	title := fmt.Sprintf("%s: Health Checks", api.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	healthWr.WriteHeader(title, g.target, imports)
+
+	g.addFile(healthFile)
+
+	if err = healthWr.Execute(api.Health); err != nil {
+		return err
+	}
+
+	return healthWr.FormatCode()
+}
+
+// generateProtobuf generates the methods converting the views of the media types that set the
+// "protobuf:message" metadata to and from their Protocol Buffers messages.
+func (g *Generator) generateProtobuf(api *design.APIDefinition) error {
+	var data []*ProtobufTemplateData
+	aliases := make(map[string]string)
+	err := api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		pkgPath, msg := mt.ProtoMessage()
+		if msg == "" {
+			return nil
+		}
+		alias, ok := aliases[pkgPath]
+		if !ok {
+			alias = protoPackageAlias(pkgPath, aliases)
+			aliases[pkgPath] = alias
+		}
+		return mt.IterateViews(func(view *design.ViewDefinition) error {
+			p, _, err := mt.Project(view.Name)
+			if err != nil {
+				return err
+			}
+			data = append(data, &ProtobufTemplateData{
+				Type:    codegen.GoTypeName(p, p.AllRequired(), 0, false),
+				Message: alias + "." + msg,
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	pbFile := filepath.Join(g.outDir, "protobuf.go")
+	pbWr, err := NewProtobufWriter(pbFile)
+	if err != nil {
+		panic(err) // bug
+	}
+
+	title := fmt.Sprintf("%s: Protocol Buffers Adapters", api.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("google.golang.org/protobuf/encoding/protojson"),
+		codegen.SimpleImport("google.golang.org/protobuf/proto"),
+		codegen.SimpleImport("google.golang.org/protobuf/reflect/protoreflect"),
+	}
+	pkgPaths := make([]string, 0, len(aliases))
+	for p := range aliases {
+		pkgPaths = append(pkgPaths, p)
+	}
+	sort.Strings(pkgPaths)
+	for _, p := range pkgPaths {
+		imports = append(imports, codegen.NewImport(aliases[p], p))
+	}
+	pbWr.WriteHeader(title, g.target, imports)
+
+	g.addFile(pbFile)
+
+	if err = pbWr.Execute(data); err != nil {
+		return err
+	}
+
+	return pbWr.FormatCode()
+}
+
+// protoPackageAlias returns the name used to import the package of Protocol Buffers messages with
+// the given path, e.g. "protopb" for "github.com/acme/cellar/proto". The name ends with "pb" so that
+// it does not clash with the other imports and is unique among the given aliases.
+func protoPackageAlias(pkgPath string, aliases map[string]string) string {
+	base := codegen.Goify(path.Base(pkgPath), false)
+	if !strings.HasSuffix(base, "pb") {
+		base += "pb"
+	}
+	taken := make(map[string]bool, len(aliases))
+	for _, a := range aliases {
+		taken[a] = true
+	}
+	alias := base
+	for i := 2; taken[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", base, i)
+	}
+	return alias
+}
+
+// generateSwagger generates the Swagger specification of the API and the function that mounts the
+// handlers serving it together with the Swagger UI and ReDoc pages. The Swagger generator relies on
+// the global JSON schema definitions so generateSwagger must not run concurrently with the other
+// generation functions.
+func (g *Generator) generateSwagger(api *design.APIDefinition) error {
+	if !g.swagger {
+		return nil
+	}
+	s, err := genswagger.New(api)
+	if err != nil {
+		return err
+	}
+	spec, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	swaggerFile := filepath.Join(g.outDir, "swagger.go")
+	swaggerWr, err := NewSwaggerWriter(swaggerFile)
+	if err != nil {
+		panic(err) // bug
+	}
+
+	title := fmt.Sprintf("%s: Swagger Specification", api.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("net/http"),
+		codegen.ContextImport(g.stdcontext),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	swaggerWr.WriteHeader(title, g.target, imports)
+
+	g.addFile(swaggerFile)
+
+	if err = swaggerWr.Execute(string(spec)); err != nil {
+		return err
+	}
+
+	return swaggerWr.FormatCode()
+}
+
 // generateHrefs iterates through the API resources and generates the href factory methods.
 func (g *Generator) generateHrefs(api *design.APIDefinition) error {
 	hrefFile := filepath.Join(g.outDir, "hrefs.go")
@@ -307,7 +727,7 @@ func (g *Generator) generateHrefs(api *design.APIDefinition) error {
 		}
 		return resWr.Execute(&data)
 	})
-	g.genfiles = append(g.genfiles, hrefFile)
+	g.addFile(hrefFile)
 	if err != nil {
 		return err
 	}
@@ -327,6 +747,8 @@ func (g *Generator) generateMediaTypes(api *design.APIDefinition) error {
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("net"),
+		codegen.SimpleImport("net/netip"),
 		codegen.NewImport("uuid", "github.com/satori/go.uuid"),
 	}
 	mtWr.WriteHeader(title, g.target, imports)
@@ -339,7 +761,7 @@ func (g *Generator) generateMediaTypes(api *design.APIDefinition) error {
 		}
 		return nil
 	})
-	g.genfiles = append(g.genfiles, mtFile)
+	g.addFile(mtFile)
 	if err != nil {
 		return err
 	}
@@ -359,14 +781,103 @@ func (g *Generator) generateUserTypes(api *design.APIDefinition) error {
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("net"),
+		codegen.SimpleImport("net/netip"),
 	}
 	utWr.WriteHeader(title, g.target, imports)
 	err = api.IterateUserTypes(func(t *design.UserTypeDefinition) error {
-		return utWr.Execute(t)
+		if err := utWr.Execute(t); err != nil {
+			return err
+		}
+		convs, err := conversions(api, t, nil)
+		if err != nil {
+			return err
+		}
+		for _, conv := range convs {
+			if err := utWr.ExecuteConversion(conv); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
-	g.genfiles = append(g.genfiles, utFile)
+	g.addFile(utFile)
 	if err != nil {
 		return err
 	}
 	return utWr.FormatCode()
 }
+
+// conversions computes the data needed to render the functions that create instances of media
+// types from instances of the given user type. The candidates are the media types considered by
+// default, only the candidates whose default view shares attributes with the user type and that
+// are compatible with it are retained. The "convert:to" metadata of the user type overrides the
+// candidates when present, in this case the listed media types must all be compatible.
+func conversions(api *design.APIDefinition, ut *design.UserTypeDefinition, candidates []*design.MediaTypeDefinition) ([]*ConversionTemplateData, error) {
+	if !ut.IsObject() {
+		return nil, nil
+	}
+	ids, explicit := ut.Metadata[convertToKey]
+	if explicit {
+		candidates = nil
+		for _, id := range ids {
+			mt := api.MediaTypeWithIdentifier(id)
+			if mt == nil {
+				api.IterateMediaTypes(func(m *design.MediaTypeDefinition) error {
+					if mt == nil && m.TypeName == id {
+						mt = m
+					}
+					return nil
+				})
+			}
+			if mt == nil {
+				return nil, fmt.Errorf("%s: unknown media type %#v in %s metadata", ut.TypeName, id, convertToKey)
+			}
+			candidates = append(candidates, mt)
+		}
+	}
+	var convs []*ConversionTemplateData
+	seen := make(map[string]bool)
+	for _, mt := range candidates {
+		target, _, err := mt.Project("default")
+		if err == nil && !target.IsObject() {
+			err = fmt.Errorf("media type is not an object")
+		}
+		if err == nil && !explicit && !sharesAttributes(ut, target.UserTypeDefinition) {
+			continue
+		}
+		var code, name string
+		if err == nil {
+			name = codegen.GoTypeTransformName(ut, target.UserTypeDefinition, "Media")
+			code, err = codegen.GoTypeTransform(ut, target.UserTypeDefinition, "", name)
+		}
+		if err != nil {
+			if explicit {
+				return nil, fmt.Errorf("%s: cannot convert to media type %s: %s", ut.TypeName, mt.Identifier, err)
+			}
+			continue
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		convs = append(convs, &ConversionTemplateData{
+			Name:   name,
+			Source: codegen.GoTypeName(ut, nil, 0, false),
+			Target: codegen.GoTypeName(target, nil, 0, false),
+			Code:   code,
+		})
+	}
+	return convs, nil
+}
+
+// sharesAttributes returns true if the source and target objects have at least one attribute
+// name in common.
+func sharesAttributes(source, target *design.UserTypeDefinition) bool {
+	t := target.ToObject()
+	for n := range source.ToObject() {
+		if _, ok := t[n]; ok {
+			return true
+		}
+	}
+	return false
+}
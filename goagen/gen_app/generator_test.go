@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
@@ -194,6 +195,121 @@ var _ = Describe("Generate", func() {
 			})
 		})
 
+		Context("generated twice", func() {
+			BeforeEach(func() {
+				for _, name := range []string{"Bolt", "Gadget", "Sprocket"} {
+					res := *design.Design.Resources["Widget"]
+					res.Name = name
+					res.BasePath = "/" + strings.ToLower(name) + "s"
+					get := *res.Actions["get"]
+					get.Parent = &res
+					res.Actions = map[string]*design.ActionDefinition{"get": &get}
+					design.Design.Resources[name] = &res
+				}
+			})
+
+			It("produces identical files and leaves them untouched", func() {
+				Ω(genErr).Should(BeNil())
+				past := time.Now().Add(-time.Hour).Truncate(time.Second)
+				infos := make(map[string]os.FileInfo)
+				for _, f := range files {
+					Ω(os.Chtimes(f, past, past)).ShouldNot(HaveOccurred())
+					info, err := os.Stat(f)
+					Ω(err).ShouldNot(HaveOccurred())
+					infos[f] = info
+				}
+				read := func(files []string) map[string]string {
+					contents := make(map[string]string, len(files))
+					for _, f := range files {
+						if info, err := os.Stat(f); err == nil && info.IsDir() {
+							continue
+						}
+						b, err := ioutil.ReadFile(f)
+						Ω(err).ShouldNot(HaveOccurred())
+						contents[f] = string(b)
+					}
+					return contents
+				}
+				first := read(files)
+				again, err := genapp.Generate()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(again).Should(Equal(files))
+				for f, content := range read(again) {
+					Ω(content).Should(Equal(first[f]), f)
+				}
+				for _, f := range again {
+					info, err := os.Stat(f)
+					Ω(err).ShouldNot(HaveOccurred())
+					if info.IsDir() {
+						continue
+					}
+					Ω(os.SameFile(info, infos[f])).Should(BeTrue(), f)
+					Ω(info.ModTime()).Should(BeTemporally("==", past), f)
+				}
+			})
+		})
+
+		Context("with the stdcontext flag", func() {
+			BeforeEach(func() {
+				os.Args = append(os.Args, "--stdcontext")
+			})
+
+			It("imports the standard library context package", func() {
+				Ω(genErr).Should(BeNil())
+				for _, f := range []string{"contexts.go", "controllers.go"} {
+					content, err := ioutil.ReadFile(filepath.Join(outDir, "app", f))
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(string(content)).Should(ContainSubstring(`	"context"`))
+					Ω(string(content)).ShouldNot(ContainSubstring("golang.org/x/net/context"))
+				}
+			})
+		})
+
+		Context("with the head flag", func() {
+			BeforeEach(func() {
+				os.Args = append(os.Args, "--head")
+			})
+
+			It("mounts HEAD handlers for the GET routes", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "controllers.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring(`service.Mux.Handle("HEAD", "/:id", ctrl.MuxHandler("Get", goa.HeadHandler(h), nil))`))
+			})
+
+			Context("and an action defining a HEAD route", func() {
+				BeforeEach(func() {
+					get := design.Design.Resources["Widget"].Actions["get"]
+					get.Routes = append(get.Routes, &design.RouteDefinition{Verb: "HEAD", Path: "/:id"})
+				})
+
+				It("does not mount a HEAD handler for the GET route", func() {
+					Ω(genErr).Should(BeNil())
+					content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "controllers.go"))
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(string(content)).ShouldNot(ContainSubstring("goa.HeadHandler"))
+				})
+			})
+		})
+
+		Context("with the swagger flag", func() {
+			BeforeEach(func() {
+				os.Args = append(os.Args, "--swagger")
+				get := design.Design.Resources["Widget"].Actions["get"]
+				get.Routes[0].Parent = get
+			})
+
+			It("generates the handlers serving the Swagger specification", func() {
+				Ω(genErr).Should(BeNil())
+				Ω(files).Should(ContainElement(filepath.Join(outDir, "app", "swagger.go")))
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "swagger.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring(`const SwaggerJSON = "{\"swagger\":\"2.0\"`))
+				Ω(string(content)).Should(ContainSubstring("func MountSwagger(service *goa.Service) {"))
+				Ω(string(content)).Should(ContainSubstring(`service.Mux.Handle("GET", "/swagger.json", ctrl.MuxHandler("Spec", serve("application/json", SwaggerJSON), nil))`))
+			})
+		})
+
 		Context("with a slice payload", func() {
 			BeforeEach(func() {
 				elemType := &design.AttributeDefinition{Type: design.Integer}
@@ -240,8 +356,326 @@ var _ = Describe("Generate", func() {
 		})
 
 	})
+
+	Context("with a payload sharing attributes with a response media type", func() {
+		var convertTo []string
+
+		BeforeEach(func() {
+			convertTo = nil
+		})
+
+		JustBeforeEach(func() {
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "Bottle",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{
+							"id":   {Type: design.Integer},
+							"name": {Type: design.String},
+						},
+						Validation: &dslengine.ValidationDefinition{Required: []string{"id", "name"}},
+					},
+				},
+				Identifier: "application/vnd.bottle",
+			}
+			bottle.Views = map[string]*design.ViewDefinition{
+				"default": {AttributeDefinition: bottle.AttributeDefinition, Name: "default", Parent: bottle},
+			}
+			bottlePayload := &design.UserTypeDefinition{
+				TypeName: "BottlePayload",
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"name": {Type: design.String}},
+				},
+			}
+			if convertTo != nil {
+				bottlePayload.Metadata = dslengine.MetadataDefinition{"convert:to": convertTo}
+			}
+			res := &design.ResourceDefinition{Name: "bottle", BasePath: "/bottles"}
+			create := &design.ActionDefinition{
+				Name:   "create",
+				Parent: res,
+				Responses: map[string]*design.ResponseDefinition{
+					"Created": {Name: "Created", Status: 201, MediaType: "application/vnd.bottle"},
+				},
+				Payload: &design.UserTypeDefinition{
+					TypeName: "CreateBottlePayload",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{
+							"name":    {Type: design.String},
+							"vintage": {Type: design.Integer},
+						},
+					},
+				},
+			}
+			create.Routes = []*design.RouteDefinition{{Verb: "POST", Path: "", Parent: create}}
+			res.Actions = map[string]*design.ActionDefinition{"create": create}
+			design.Design = &design.APIDefinition{
+				Name:       "test api",
+				Resources:  map[string]*design.ResourceDefinition{"bottle": res},
+				MediaTypes: map[string]*design.MediaTypeDefinition{"application/vnd.bottle": bottle},
+				Types:      map[string]*design.UserTypeDefinition{"BottlePayload": bottlePayload},
+			}
+			design.GeneratedMediaTypes = make(design.MediaTypeRoot)
+			files, genErr = genapp.Generate()
+		})
+
+		It("generates a conversion function for the payload", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "contexts.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(payloadConversionCode))
+		})
+
+		It("does not generate conversion functions for user types by default", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "user_types.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).ShouldNot(ContainSubstring("ToBottleMedia"))
+		})
+
+		Context("with convert:to metadata", func() {
+			BeforeEach(func() {
+				convertTo = []string{"Bottle"}
+			})
+
+			It("generates a conversion function for the user type", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "user_types.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring("func BottlePayloadToBottleMedia(source *BottlePayload) (target *Bottle) {"))
+			})
+		})
+
+		Context("with convert:to metadata listing an unknown media type", func() {
+			BeforeEach(func() {
+				convertTo = []string{"application/vnd.unknown"}
+			})
+
+			It("returns an error", func() {
+				Ω(genErr).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("with a media type defining multiple views", func() {
+		var metadata dslengine.MetadataDefinition
+
+		BeforeEach(func() {
+			metadata = nil
+		})
+
+		JustBeforeEach(func() {
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "Bottle",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{
+							"id":     {Type: design.Integer},
+							"name":   {Type: design.String},
+							"rating": {Type: design.Integer},
+						},
+						Validation: &dslengine.ValidationDefinition{Required: []string{"id", "name"}},
+					},
+				},
+				Identifier: "application/vnd.bottle",
+			}
+			bottle.Metadata = metadata
+			tiny := &design.AttributeDefinition{
+				Type: design.Object{
+					"id":   {Type: design.Integer},
+					"name": {Type: design.String},
+				},
+			}
+			bottle.Views = map[string]*design.ViewDefinition{
+				"default": {AttributeDefinition: bottle.AttributeDefinition, Name: "default", Parent: bottle},
+				"tiny":    {AttributeDefinition: tiny, Name: "tiny", Parent: bottle},
+			}
+			design.Design = &design.APIDefinition{
+				Name:       "test api",
+				MediaTypes: map[string]*design.MediaTypeDefinition{"application/vnd.bottle": bottle},
+			}
+			design.GeneratedMediaTypes = make(design.MediaTypeRoot)
+			files, genErr = genapp.Generate()
+		})
+
+		It("generates an interface implemented by all the views", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "media_types.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(mediaTypeInterfaceCode))
+			Ω(string(content)).Should(ContainSubstring(mediaTypeAccessorCode))
+			Ω(string(content)).ShouldNot(ContainSubstring("GetRating"))
+			Ω(files).ShouldNot(ContainElement(filepath.Join(outDir, "app", "protobuf.go")))
+		})
+
+		Context("with protobuf:message metadata", func() {
+			BeforeEach(func() {
+				metadata = dslengine.MetadataDefinition{"protobuf:message": {"github.com/acme/cellar/proto.Bottle"}}
+			})
+
+			It("generates the Protocol Buffers adapters of all the views", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "protobuf.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring(`protopb "github.com/acme/cellar/proto"`))
+				Ω(string(content)).Should(ContainSubstring("func (mt *Bottle) MarshalProto() ([]byte, error) {\n\treturn marshalProto(mt, &protopb.Bottle{})"))
+				Ω(string(content)).Should(ContainSubstring("func (mt *BottleTiny) UnmarshalProto(b []byte) error {\n\treturn unmarshalProto(b, &protopb.Bottle{}, mt)"))
+			})
+		})
+	})
+
+	Context("with the shard flag", func() {
+		BeforeEach(func() {
+			os.Args = append(os.Args, "--shard", "--notest")
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "Bottle",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"id": {Type: design.Integer}},
+					},
+				},
+				Identifier: "application/vnd.bottle",
+			}
+			bottle.Views = map[string]*design.ViewDefinition{
+				"default": {AttributeDefinition: bottle.AttributeDefinition, Name: "default", Parent: bottle},
+			}
+			address := &design.UserTypeDefinition{
+				TypeName: "Address",
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"street": {Type: design.String}},
+				},
+			}
+			res := &design.ResourceDefinition{Name: "bottle", BasePath: "/bottles"}
+			show := &design.ActionDefinition{
+				Name:   "show",
+				Parent: res,
+				Responses: map[string]*design.ResponseDefinition{
+					"OK": {Name: "OK", Status: 200, MediaType: "application/vnd.bottle"},
+				},
+			}
+			show.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: show}}
+			res.Actions = map[string]*design.ActionDefinition{"show": show}
+			design.Design = &design.APIDefinition{
+				Name:       "test api",
+				Resources:  map[string]*design.ResourceDefinition{"bottle": res},
+				MediaTypes: map[string]*design.MediaTypeDefinition{"application/vnd.bottle": bottle},
+				Types:      map[string]*design.UserTypeDefinition{"Address": address},
+			}
+			design.GeneratedMediaTypes = make(design.MediaTypeRoot)
+		})
+
+		readFile := func(elems ...string) string {
+			content, err := ioutil.ReadFile(filepath.Join(append([]string{outDir, "app"}, elems...)...))
+			Ω(err).ShouldNot(HaveOccurred())
+			return string(content)
+		}
+
+		It("generates the shared package", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(readFile("shared", "media_types.go")).Should(ContainSubstring("package shared"))
+			Ω(readFile("shared", "user_types.go")).Should(ContainSubstring("type Address struct"))
+			Ω(readFile("shared", "shard.go")).Should(ContainSubstring(sharedShardCode))
+		})
+
+		It("generates the resource packages", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(readFile("bottle", "contexts.go")).Should(ContainSubstring("type ShowBottleContext struct"))
+			controllers := readFile("bottle", "controllers.go")
+			Ω(controllers).Should(ContainSubstring("func MountBottleController("))
+			Ω(controllers).ShouldNot(ContainSubstring("func initService("))
+			Ω(readFile("bottle", "shard.go")).Should(ContainSubstring(resourceShardCode))
+		})
+
+		It("generates the application package aliases", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(readFile("shards.go")).Should(ContainSubstring(shardAliasesCode))
+			_, err := os.Stat(filepath.Join(outDir, "app", "contexts.go"))
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
+	})
+
+	Context("with the shard flag and a resource whose package name is reserved", func() {
+		BeforeEach(func() {
+			os.Args = append(os.Args, "--shard", "--notest")
+			res := &design.ResourceDefinition{Name: "shared"}
+			design.Design = &design.APIDefinition{
+				Name:      "test api",
+				Resources: map[string]*design.ResourceDefinition{"shared": res},
+			}
+		})
+
+		It("returns an error", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(genErr.Error()).Should(ContainSubstring("reserved"))
+		})
+	})
 })
 
+const sharedShardCode = `
+// Aliases of the private user types used by the resource packages to decode request payloads.
+type (
+	RawAddress = address
+)
+`
+
+const resourceShardCode = `// initService sets up the service encoders, decoders and mux.
+func initService(service *goa.Service) {
+	shared.InitService(service)
+}
+
+// Aliases of the private user types of the shared package.
+type (
+	address = shared.RawAddress
+)
+
+// Aliases of the types of the shared package.
+type (
+	Address = shared.Address
+	Bottle  = shared.Bottle
+)
+`
+
+const shardAliasesCode = `// Aliases of the types of the bottle package.
+type (
+	BottleController  = bottle.BottleController
+	ShowBottleContext = bottle.ShowBottleContext
+)
+
+// Aliases of the functions and variables of the bottle package.
+var (
+	MountBottleController = bottle.MountBottleController
+	NewShowBottleContext  = bottle.NewShowBottleContext
+)
+`
+
+const mediaTypeInterfaceCode = `// BottleViewer is the interface implemented by all the views of the application/vnd.bottle media type.
+// Controllers may use it to render any view without having to switch on the view type.
+type BottleViewer interface {
+	// GetID returns the value of the id attribute.
+	GetID() int
+	// GetName returns the value of the name attribute.
+	GetName() string
+}
+`
+
+const mediaTypeAccessorCode = `// GetName returns the value of the name attribute.
+func (mt *BottleTiny) GetName() string {
+	return mt.Name
+}
+`
+
+const payloadConversionCode = `
+// CreateBottlePayloadToBottleMedia creates a Bottle media type instance from a CreateBottlePayload.
+// Fields are matched by attribute name or by value of the "transform:key" attribute metadata.
+func CreateBottlePayloadToBottleMedia(source *CreateBottlePayload) (target *Bottle) {
+	target = new(Bottle)
+	if source.Name != nil {
+		target.Name = *source.Name
+	}
+	return
+}
+`
+
 const contextsCodeTmpl = `//************************************************************************//
 // API "test api": Application Contexts
 //
@@ -328,13 +762,17 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 	var h goa.Handler
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		done := goa.MeasurePhase(ctx, goa.PhaseValidate)
 		rctx, err := NewGetWidgetContext(ctx, service)
+		done()
 		if err != nil {
 			return err
 		}
+		defer goa.MeasurePhase(ctx, goa.PhaseController)()
 		return ctrl.Get(rctx)
 	}
 	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("Get", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/:id", Resource: "Widget", Controller: goa.ControllerName(ctrl), Action: "Get"})
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
 }
 `
@@ -381,7 +819,9 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 	var h goa.Handler
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		done := goa.MeasurePhase(ctx, goa.PhaseValidate)
 		rctx, err := NewGetWidgetContext(ctx, service)
+		done()
 		if err != nil {
 			return err
 		}
@@ -390,9 +830,11 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 		} else {
 			return goa.ErrInvalidEncoding(goa.MissingPayloadError())
 		}
+		defer goa.MeasurePhase(ctx, goa.PhaseController)()
 		return ctrl.Get(rctx)
 	}
 	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("Get", h, unmarshalGetWidgetPayload))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/:id", Resource: "Widget", Controller: goa.ControllerName(ctrl), Action: "Get"})
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
 }
 
@@ -414,16 +856,20 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 	var h goa.Handler
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		done := goa.MeasurePhase(ctx, goa.PhaseValidate)
 		rctx, err := NewGetWidgetContext(ctx, service)
+		done()
 		if err != nil {
 			return err
 		}
 		if rawPayload := goa.ContextRequest(ctx).Payload; rawPayload != nil {
 			rctx.Payload = rawPayload.(Collection)
 		}
+		defer goa.MeasurePhase(ctx, goa.PhaseController)()
 		return ctrl.Get(rctx)
 	}
 	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("Get", h, unmarshalGetWidgetPayload))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/:id", Resource: "Widget", Controller: goa.ControllerName(ctrl), Action: "Get"})
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
 }
 
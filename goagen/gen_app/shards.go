@@ -0,0 +1,459 @@
+package genapp
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+const (
+	// sharedPkg is the name of the package that contains the code shared by the resource
+	// packages when sharding.
+	sharedPkg = "shared"
+
+	// shardFile is the name of the file that contains the glue code of the packages generated
+	// when sharding.
+	shardFile = "shard.go"
+)
+
+type (
+	// ShardsWriter generates the glue code of the packages generated when sharding.
+	ShardsWriter struct {
+		*codegen.SourceFile
+	}
+
+	// ShardAliases lists the declarations of a package that another package declares aliases
+	// for.
+	ShardAliases struct {
+		// Package is the name of the package that contains the declarations.
+		Package string
+		// Types lists the names of the types.
+		Types []string
+		// Consts lists the names of the constants.
+		Consts []string
+		// Vars lists the names of the variables and functions.
+		Vars []string
+	}
+
+	// PrivateTypeAlias is an exported alias of a private user type of the shared package. The
+	// resource packages use the private user types to decode the request payloads.
+	PrivateTypeAlias struct {
+		// Name is the name of the private type.
+		Name string
+		// Export is the name of the exported alias.
+		Export string
+	}
+)
+
+// NewShardsWriter returns a shard glue code writer.
+func NewShardsWriter(filename string) (*ShardsWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &ShardsWriter{SourceFile: file}, nil
+}
+
+// WriteShared writes the functions and aliases exported by the shared package for the resource
+// packages.
+func (w *ShardsWriter) WriteShared(encoders, decoders []*EncoderTemplateData, security bool, private []*PrivateTypeAlias) error {
+	ctx := map[string]interface{}{
+		"API":      design.Design,
+		"Encoders": encoders,
+		"Decoders": decoders,
+	}
+	if err := w.ExecuteTemplate("service", serviceT, nil, ctx); err != nil {
+		return err
+	}
+	data := map[string]interface{}{
+		"Security": security,
+		"Private":  private,
+	}
+	return w.ExecuteTemplate("sharedShard", sharedShardT, nil, data)
+}
+
+// WriteResource writes the glue code of a resource package: the functions that delegate to the
+// shared package and the aliases of the shared declarations.
+func (w *ShardsWriter) WriteResource(security bool, private []*PrivateTypeAlias, shared *ShardAliases) error {
+	data := map[string]interface{}{
+		"Security": security,
+		"Private":  private,
+		"Package":  sharedPkg,
+	}
+	if err := w.ExecuteTemplate("resourceShard", resourceShardT, nil, data); err != nil {
+		return err
+	}
+	return w.WriteAliases([]*ShardAliases{shared})
+}
+
+// WriteAliases writes the aliases of the given declarations.
+func (w *ShardsWriter) WriteAliases(aliases []*ShardAliases) error {
+	return w.ExecuteTemplate("shardAliases", shardAliasesT, nil, aliases)
+}
+
+// generateShards generates the code of the application in multiple packages: the "shared" package
+// contains the media types, user types and other API wide code, each resource gets its own
+// package containing its contexts and controller and the application package consists of aliases
+// of the declarations of the other packages. Changing the design of a resource only changes the
+// code of its package so that the other resource packages need not be recompiled.
+func (g *Generator) generateShards(api *design.APIDefinition) error {
+	names := make(map[string]*design.ResourceDefinition)
+	var resources []*design.ResourceDefinition
+	err := api.IterateResources(func(r *design.ResourceDefinition) error {
+		name := shardName(r)
+		if name == sharedPkg || name == "test" {
+			return fmt.Errorf("resource %#v: package name %#v is reserved when sharding", r.Name, name)
+		}
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("resources %#v and %#v have the same package name %#v", other.Name, r.Name, name)
+		}
+		names[name] = r
+		resources = append(resources, r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Generate the shared package.
+	projectMediaTypes(api)
+	shared, err := g.shardGenerator(sharedPkg, nil)
+	if err != nil {
+		return err
+	}
+	security := len(api.SecuritySchemes) > 0
+	sharedDecls, private, err := shared.generateShared(api, security)
+	g.genfiles = append(g.genfiles, shared.genfiles...)
+	if err != nil {
+		return err
+	}
+	sharedPath, err := codegen.PackagePath(shared.outDir)
+	if err != nil {
+		return err
+	}
+
+	// Generate the resource packages.
+	aliases := []*ShardAliases{sharedDecls}
+	imports := []*codegen.ImportSpec{codegen.SimpleImport(sharedPath)}
+	for _, r := range resources {
+		res, err := g.shardGenerator(shardName(r), r)
+		if err != nil {
+			return err
+		}
+		err = res.parallel(api, res.generateContexts, res.generateControllers)
+		if err == nil {
+			err = res.generateResourceGlue(api, security, private, sharedDecls, sharedPath)
+		}
+		g.genfiles = append(g.genfiles, res.genfiles...)
+		if err != nil {
+			return err
+		}
+		decls, err := packageDecls(res.outDir, res.target, res.genfiles)
+		if err != nil {
+			return err
+		}
+		aliases = append(aliases, decls)
+		path, err := codegen.PackagePath(res.outDir)
+		if err != nil {
+			return err
+		}
+		imports = append(imports, codegen.SimpleImport(path))
+	}
+
+	// Generate the application package aliases.
+	aliasFile := filepath.Join(g.outDir, "shards.go")
+	aliasWr, err := NewShardsWriter(aliasFile)
+	if err != nil {
+		panic(err) // bug
+	}
+	title := fmt.Sprintf("%s: Application Shards", api.Context())
+	aliasWr.WriteHeader(title, g.target, imports)
+	g.genfiles = append(g.genfiles, aliasFile)
+	if err := aliasWr.WriteAliases(aliases); err != nil {
+		return err
+	}
+	return aliasWr.FormatCode()
+}
+
+// shardGenerator returns a generator that generates the code of the given package of the sharded
+// application. r is the resource whose contexts and controller are generated, nil for the shared
+// package.
+func (g *Generator) shardGenerator(pkg string, r *design.ResourceDefinition) (*Generator, error) {
+	sg := &Generator{
+		outDir:     filepath.Join(g.outDir, pkg),
+		target:     pkg,
+		notest:     g.notest,
+		pool:       g.pool,
+		stdcontext: g.stdcontext,
+		head:       g.head,
+		intercept:  g.intercept,
+		shard:      g.shard,
+		swagger:    g.swagger,
+		resource:   r,
+	}
+	if err := os.MkdirAll(sg.outDir, 0755); err != nil {
+		return nil, err
+	}
+	sg.genfiles = []string{sg.outDir}
+	return sg, nil
+}
+
+// generateShared generates the shared package and returns its exported declarations and the
+// aliases of its private user types.
+func (g *Generator) generateShared(api *design.APIDefinition, security bool) (*ShardAliases, []*PrivateTypeAlias, error) {
+	err := g.parallel(api,
+		g.generateSecurity,
+		g.generateFeatures,
+		g.generateRetention,
+		g.generateProtobuf,
+		g.generateHrefs,
+		g.generateMediaTypes,
+		g.generateUserTypes,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := g.generateSwagger(api); err != nil {
+		return nil, nil, err
+	}
+	decls, err := packageDecls(g.outDir, g.target, g.genfiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	private, err := privateTypeAliases(filepath.Join(g.outDir, "user_types.go"), decls)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := g.generateSharedGlue(api, security, private); err != nil {
+		return nil, nil, err
+	}
+	return decls, private, nil
+}
+
+// generateSharedGlue generates the service initialization function and the declarations exported
+// by the shared package for the resource packages.
+func (g *Generator) generateSharedGlue(api *design.APIDefinition, security bool, private []*PrivateTypeAlias) error {
+	glueFile := filepath.Join(g.outDir, shardFile)
+	glueWr, err := NewShardsWriter(glueFile)
+	if err != nil {
+		panic(err) // bug
+	}
+	title := fmt.Sprintf("%s: Application Shared Code", api.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("net/http"),
+		codegen.ContextImport(g.stdcontext),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	encoders, err := BuildEncoders(api.Produces, true)
+	if err != nil {
+		return err
+	}
+	decoders, err := BuildEncoders(api.Consumes, false)
+	if err != nil {
+		return err
+	}
+	imports = append(imports, encoderImports(encoders, decoders)...)
+	glueWr.WriteHeader(title, g.target, imports)
+	g.genfiles = append(g.genfiles, glueFile)
+	if err := glueWr.WriteShared(encoders, decoders, security, private); err != nil {
+		return err
+	}
+	return glueWr.FormatCode()
+}
+
+// generateResourceGlue generates the functions and aliases that make the shared package
+// declarations available to the code of a resource package.
+func (g *Generator) generateResourceGlue(api *design.APIDefinition, security bool, private []*PrivateTypeAlias, shared *ShardAliases, sharedPath string) error {
+	glueFile := filepath.Join(g.outDir, shardFile)
+	glueWr, err := NewShardsWriter(glueFile)
+	if err != nil {
+		panic(err) // bug
+	}
+	title := fmt.Sprintf("%s: %s Resource Shard", api.Context(), codegen.Goify(g.resource.Name, true))
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("net/http"),
+		codegen.ContextImport(g.stdcontext),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport(sharedPath),
+	}
+	glueWr.WriteHeader(title, g.target, imports)
+	g.genfiles = append(g.genfiles, glueFile)
+	if err := glueWr.WriteResource(security, private, shared); err != nil {
+		return err
+	}
+	return glueWr.FormatCode()
+}
+
+// shardName returns the name of the package generated for the given resource when sharding.
+func shardName(r *design.ResourceDefinition) string {
+	return strings.ToLower(codegen.Goify(r.Name, false))
+}
+
+// packageDecls returns the exported top level declarations of the Go package in dir declared in
+// the given generated files. The declarations of the shard glue file are omitted.
+func packageDecls(dir, pkg string, files []string) (*ShardAliases, error) {
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, file := range files {
+		if filepath.Dir(file) != dir || filepath.Ext(file) != ".go" || filepath.Base(file) == shardFile {
+			continue
+		}
+		src, err := codegen.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parser.ParseFile(fset, file, src, 0)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name.Name == pkg {
+			parsed = append(parsed, f)
+		}
+	}
+	decls := &ShardAliases{Package: pkg}
+	for _, f := range parsed {
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Name.IsExported() {
+					decls.Vars = append(decls.Vars, d.Name.Name)
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						if s.Name.IsExported() {
+							decls.Types = append(decls.Types, s.Name.Name)
+						}
+					case *ast.ValueSpec:
+						for _, n := range s.Names {
+							if !n.IsExported() {
+								continue
+							}
+							if d.Tok == token.CONST {
+								decls.Consts = append(decls.Consts, n.Name)
+							} else {
+								decls.Vars = append(decls.Vars, n.Name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(decls.Types)
+	sort.Strings(decls.Consts)
+	sort.Strings(decls.Vars)
+	return decls, nil
+}
+
+// privateTypeAliases returns the exported aliases of the private types declared in the given user
+// types file. The alias of a private type is its name prefixed with "Raw".
+func privateTypeAliases(filename string, exported *ShardAliases) ([]*PrivateTypeAlias, error) {
+	src, err := codegen.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool)
+	for _, names := range [][]string{exported.Types, exported.Consts, exported.Vars} {
+		for _, n := range names {
+			taken[n] = true
+		}
+	}
+	var aliases []*PrivateTypeAlias
+	for _, decl := range f.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range d.Specs {
+			s := spec.(*ast.TypeSpec)
+			if s.Name.IsExported() {
+				continue
+			}
+			export := "Raw" + codegen.Goify(s.Name.Name, true)
+			if taken[export] {
+				return nil, fmt.Errorf("alias %s of private type %s conflicts with an existing declaration", export, s.Name.Name)
+			}
+			taken[export] = true
+			aliases = append(aliases, &PrivateTypeAlias{Name: s.Name.Name, Export: export})
+		}
+	}
+	return aliases, nil
+}
+
+const (
+	// sharedShardT generates the declarations exported by the shared package for the resource
+	// packages.
+	// template input: map[string]interface{}
+	sharedShardT = `
+// InitService sets up the service encoders, decoders and mux. It is called by the Mount functions
+// of the resource packages.
+func InitService(service *goa.Service) {
+	initService(service)
+}
+{{ if .Security }}
+// HandleSecurity creates a handler that runs the auth middleware for the security scheme. It is
+// used by the resource packages.
+func HandleSecurity(schemeName string, h goa.Handler, scopes ...string) goa.Handler {
+	return handleSecurity(schemeName, h, scopes...)
+}
+{{ end }}{{ if .Private }}
+// Aliases of the private user types used by the resource packages to decode request payloads.
+type (
+{{ range .Private }}	{{ .Export }} = {{ .Name }}
+{{ end }})
+{{ end }}`
+
+	// resourceShardT generates the functions of a resource package that delegate to the shared
+	// package.
+	// template input: map[string]interface{}
+	resourceShardT = `
+// initService sets up the service encoders, decoders and mux.
+func initService(service *goa.Service) {
+	{{ .Package }}.InitService(service)
+}
+{{ if .Security }}
+// handleSecurity creates a handler that runs the auth middleware for the security scheme.
+func handleSecurity(schemeName string, h goa.Handler, scopes ...string) goa.Handler {
+	return {{ .Package }}.HandleSecurity(schemeName, h, scopes...)
+}
+{{ end }}{{ $pkg := .Package }}{{ if .Private }}
+// Aliases of the private user types of the {{ $pkg }} package.
+type (
+{{ range .Private }}	{{ .Name }} = {{ $pkg }}.{{ .Export }}
+{{ end }})
+{{ end }}`
+
+	// shardAliasesT generates the aliases of the declarations of packages.
+	// template input: []*ShardAliases
+	shardAliasesT = `{{ range . }}{{ $pkg := .Package }}{{ if .Types }}
+// Aliases of the types of the {{ $pkg }} package.
+type (
+{{ range .Types }}	{{ . }} = {{ $pkg }}.{{ . }}
+{{ end }})
+{{ end }}{{ if .Consts }}
+// Aliases of the constants of the {{ $pkg }} package.
+const (
+{{ range .Consts }}	{{ . }} = {{ $pkg }}.{{ . }}
+{{ end }})
+{{ end }}{{ if .Vars }}
+// Aliases of the functions and variables of the {{ $pkg }} package.
+var (
+{{ range .Vars }}	{{ . }} = {{ $pkg }}.{{ . }}
+{{ end }})
+{{ end }}{{ end }}`
+)
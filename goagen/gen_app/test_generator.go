@@ -2,6 +2,7 @@ package genapp
 
 import (
 	"fmt"
+	"mime"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -14,9 +15,6 @@ import (
 
 func makeTestDir(g *Generator, apiName string) (outDir string, err error) {
 	outDir = filepath.Join(g.outDir, "test")
-	if err = os.RemoveAll(outDir); err != nil {
-		return
-	}
 	if err = os.MkdirAll(outDir, 0755); err != nil {
 		return
 	}
@@ -36,11 +34,23 @@ type TestMethod struct {
 	RouteVerb      string
 	FullPath       string
 	Status         int
+	ContentType    string
 	ReturnType     *ObjectType
 	Params         []ObjectType
 	Payload        *ObjectType
 }
 
+// RequestBuilder is the data used to generate the function that builds the request sent to an
+// action route by tests using a goatest.Server.
+type RequestBuilder struct {
+	Name      string
+	RouteVerb string
+	Path      string
+	FullPath  string
+	Params    []ObjectType
+	Payload   *ObjectType
+}
+
 // ObjectType structure
 type ObjectType struct {
 	Label       string
@@ -48,13 +58,15 @@ type ObjectType struct {
 	Type        string
 	Pointer     string
 	Validatable bool
+	Stream      string // Payload stream type if the action streams its payload
 }
 
 func (g *Generator) generateResourceTest(api *design.APIDefinition) error {
 	if len(api.Resources) == 0 {
 		return nil
 	}
-	testTmpl := template.Must(template.New("resources").Parse(testTmpl))
+	testTmpl := template.Must(template.New("tests").Parse(codegen.Template("tests", testTmpl)))
+	requestTmpl := template.Must(template.New("requestBuilders").Parse(codegen.Template("requestBuilders", requestBuildersTmpl)))
 	outDir, err := makeTestDir(g, api.Name)
 	if err != nil {
 		return err
@@ -65,7 +77,10 @@ func (g *Generator) generateResourceTest(api *design.APIDefinition) error {
 	}
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("bytes"),
+		codegen.SimpleImport("encoding/json"),
 		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("io"),
+		codegen.SimpleImport("mime"),
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("net/http/httptest"),
 		codegen.SimpleImport("net/url"),
@@ -73,7 +88,7 @@ func (g *Generator) generateResourceTest(api *design.APIDefinition) error {
 		codegen.SimpleImport(appPkg),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("github.com/goadesign/goa/goatest"),
-		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.ContextImport(g.stdcontext),
 	}
 
 	return api.IterateResources(func(res *design.ResourceDefinition) error {
@@ -87,14 +102,23 @@ func (g *Generator) generateResourceTest(api *design.APIDefinition) error {
 		}
 
 		var methods = []TestMethod{}
+		var builders = []RequestBuilder{}
 
 		if err := res.IterateActions(func(action *design.ActionDefinition) error {
+			for routeIndex, route := range action.Routes {
+				builders = append(builders, g.createRequestBuilder(res, action, route, routeIndex))
+			}
 			if err := action.IterateResponses(func(response *design.ResponseDefinition) error {
 				if response.Status == 101 { // SwitchingProtocols, Don't currently handle WebSocket endpoints
 					return nil
 				}
 				for routeIndex, route := range action.Routes {
 					mediaType := design.Design.MediaTypeWithIdentifier(response.MediaType)
+					if mediaType == nil {
+						// Built-in media types such as the error media type may not be
+						// registered with the API.
+						mediaType, _ = response.Type.(*design.MediaTypeDefinition)
+					}
 					if mediaType == nil {
 						methods = append(methods, g.createTestMethod(res, action, response, route, routeIndex, nil, nil))
 					} else {
@@ -119,6 +143,10 @@ func (g *Generator) generateResourceTest(api *design.APIDefinition) error {
 		if err != nil {
 			panic(err)
 		}
+		err = requestTmpl.Execute(file, builders)
+		if err != nil {
+			panic(err)
+		}
 		return file.FormatCode()
 	})
 }
@@ -141,6 +169,10 @@ func (g *Generator) createTestMethod(resource *design.ResourceDefinition, action
 	method.ContextType = fmt.Sprintf("%s.New%s%sContext", g.target, codegen.Goify(action.Name, true), codegen.Goify(resource.Name, true))
 	method.RouteVerb = route.Verb
 	method.Status = response.Status
+	method.ContentType = response.MediaType
+	if ct, _, err := mime.ParseMediaType(response.MediaType); err == nil {
+		method.ContentType = ct
+	}
 	method.FullPath = goPathFormat(route.FullPath())
 
 	if view != nil && mediaType != nil {
@@ -162,40 +194,67 @@ func (g *Generator) createTestMethod(resource *design.ResourceDefinition, action
 		method.ReturnType = &returnType
 	}
 
-	if len(route.Params()) > 0 {
-		var params = []ObjectType{}
-		for _, paramName := range route.Params() {
-			for name, att := range action.Params.Type.ToObject() {
-				if name == paramName {
-					param := ObjectType{}
-					param.Label = name
-					param.Name = codegen.Goify(name, false)
-					param.Type = codegen.GoTypeRef(att.Type, nil, 0, false)
-					if att.Type.IsPrimitive() && action.Params.IsPrimitivePointer(name) {
-						param.Pointer = "*"
-					}
-					params = append(params, param)
+	method.Params = g.testParams(action, route)
+	method.Payload = g.testPayload(action)
+	return method
+}
+
+// testParams returns the route parameters of the given action route.
+func (g *Generator) testParams(action *design.ActionDefinition, route *design.RouteDefinition) []ObjectType {
+	if len(route.Params()) == 0 {
+		return nil
+	}
+	var params = []ObjectType{}
+	for _, paramName := range route.Params() {
+		for name, att := range action.Params.Type.ToObject() {
+			if name == paramName {
+				param := ObjectType{}
+				param.Label = name
+				param.Name = codegen.Goify(name, false)
+				param.Type = codegen.GoTypeRef(att.Type, nil, 0, false)
+				if att.Type.IsPrimitive() && action.Params.IsPrimitivePointer(name) {
+					param.Pointer = "*"
 				}
+				params = append(params, param)
 			}
 		}
-		method.Params = params
 	}
+	return params
+}
 
-	if action.Payload != nil {
-		payload := ObjectType{}
-		payload.Name = "payload"
-		payload.Type = fmt.Sprintf("%s.%s", g.target, codegen.Goify(action.Payload.TypeName, true))
-		if !action.Payload.IsPrimitive() && !action.Payload.IsArray() && !action.Payload.IsHash() {
-			payload.Pointer = "*"
-		}
+// testPayload returns the payload of the given action, nil if the action has no payload.
+func (g *Generator) testPayload(action *design.ActionDefinition) *ObjectType {
+	if action.Payload == nil {
+		return nil
+	}
+	payload := ObjectType{}
+	payload.Name = "payload"
+	payload.Type = fmt.Sprintf("%s.%s", g.target, codegen.Goify(action.Payload.TypeName, true))
+	if !action.Payload.IsPrimitive() && !action.Payload.IsArray() && !action.Payload.IsHash() {
+		payload.Pointer = "*"
+	}
 
-		validate := codegen.RecursiveChecker(action.Payload.AttributeDefinition, false, false, false, "payload", "raw", 1, false)
-		if validate != "" {
-			payload.Validatable = true
-		}
-		method.Payload = &payload
+	validate := codegen.RecursiveChecker(action.Payload.AttributeDefinition, false, false, false, "payload", "raw", 1, false)
+	if validate != "" {
+		payload.Validatable = true
+	}
+	if action.StreamPayload() {
+		payload.Stream = fmt.Sprintf("%s.%sStream", g.target, codegen.Goify(action.Payload.TypeName, true))
+	}
+	return &payload
+}
+
+// createRequestBuilder returns the data used to generate the request builder of the given action
+// route.
+func (g *Generator) createRequestBuilder(resource *design.ResourceDefinition, action *design.ActionDefinition, route *design.RouteDefinition, routeIndex int) RequestBuilder {
+	return RequestBuilder{
+		Name:      fmt.Sprintf("New%s%sRequest%s", codegen.Goify(action.Name, true), codegen.Goify(resource.Name, true), suffixRoute(action.Routes, routeIndex)),
+		RouteVerb: route.Verb,
+		Path:      route.FullPath(),
+		FullPath:  goPathFormat(route.FullPath()),
+		Params:    g.testParams(action, route),
+		Payload:   g.testPayload(action),
 	}
-	return method
 }
 
 func goPathFormat(path string) string {
@@ -257,22 +316,36 @@ func {{ $test.Name }}Ctx(t *testing.T, ctx context.Context, ctrl {{ $test.Contro
 	if err != nil {
 		panic("invalid test data " + err.Error()) // bug
 	}
-	{{ if $test.Payload }}{{ $test.ContextVarName }}.Payload = {{ $test.Payload.Name }}{{ end }}
+	{{ if $test.Payload }}{{ if $test.Payload.Stream }}b, err := json.Marshal({{ $test.Payload.Name }})
+	if err != nil {
+		panic("invalid test data " + err.Error()) // bug
+	}
+	{{ $test.ContextVarName }}.Payload = &{{ $test.Payload.Stream }}{PayloadStream: goa.NewPayloadStream(httptest.NewRequest("{{ $test.RouteVerb }}", "/", bytes.NewReader(b)), 0, 0)}{{/*
+*/}}{{ else }}{{ $test.ContextVarName }}.Payload = {{ $test.Payload.Name }}{{ end }}{{ end }}
 
 	err = ctrl.{{ $test.ActionName}}({{ $test.ContextVarName }})
 	if err != nil {
 		t.Fatalf("controller returned %s, logs:\n%s", err, logBuf.String())
 	}
-	{{if $test.ReturnType }}
+	if rw.Code != {{ $test.Status }} {
+		t.Errorf("invalid response status code: got %+v, expected {{ $test.Status }}", rw.Code)
+	}
+	{{ if $test.ContentType }}if ct, _, err := mime.ParseMediaType(rw.Header().Get("Content-Type")); err != nil || ct != "{{ $test.ContentType }}" {
+		t.Errorf("invalid response content type: got %#v, expected {{ $test.ContentType }}", rw.Header().Get("Content-Type"))
+	}
+	{{ end }}{{if $test.ReturnType }}
+	if resp == nil && rw.Body.Len() > 0 {
+		var decoded {{ $test.ReturnType.Type }}
+		if err := service.Decoder.Decode(&decoded, rw.Body, rw.Header().Get("Content-Type")); err != nil {
+			t.Fatalf("failed to decode response body: %s", err)
+		}
+		resp = &decoded
+	}
 	a, ok := resp.({{ $test.ReturnType.Pointer }}{{ $test.ReturnType.Type }})
 	if !ok {
 		t.Errorf("invalid response media: got %+v, expected instance of {{ $test.ReturnType.Type }}", resp)
 	}
-	{{ end }}
-	if rw.Code != {{ $test.Status }} {
-		t.Errorf("invalid response status code: got %+v, expected {{ $test.Status }}", rw.Code)
-	}
-	{{ if $test.ReturnType }}{{ if $test.ReturnType.Validatable }}
+	{{ end }}	{{ if $test.ReturnType }}{{ if $test.ReturnType.Validatable }}
 	err = a.Validate()
 	if err != nil {
 		t.Errorf("invalid response payload: got %v", err)
@@ -281,3 +354,23 @@ func {{ $test.Name }}Ctx(t *testing.T, ctx context.Context, ctrl {{ $test.Contro
 	{{ end }}
 }
 {{ end }}`
+
+var requestBuildersTmpl = `
+{{ range $req := . }}
+// {{ $req.Name }} builds a {{ $req.RouteVerb }} {{ $req.Path }} request to send to a goatest.Server.
+func {{ $req.Name }}({{ range $i, $param := $req.Params }}{{ if $i }}, {{ end }}{{ $param.Name }} {{ $param.Pointer }}{{ $param.Type }}{{ end }}{{/*
+*/}}{{ if $req.Payload }}{{ if $req.Params }}, {{ end }}{{ $req.Payload.Name }} {{ $req.Payload.Pointer }}{{ $req.Payload.Type }}{{ end }}) *http.Request {
+	var body io.Reader
+	{{ if $req.Payload }}b, err := json.Marshal({{ $req.Payload.Name }})
+	if err != nil {
+		panic("invalid test data " + err.Error()) // bug
+	}
+	body = bytes.NewReader(b)
+	{{ end }}req, err := http.NewRequest("{{ $req.RouteVerb }}", fmt.Sprintf("{{ $req.FullPath }}"{{ range $param := $req.Params }}, {{ $param.Name }}{{ end }}), body)
+	if err != nil {
+		panic("invalid test " + err.Error()) // bug
+	}
+	{{ if $req.Payload }}req.Header.Set("Content-Type", "application/json")
+	{{ end }}return req
+}
+{{ end }}`
@@ -80,6 +80,12 @@ var _ = Describe("Generate", func() {
 									"ok": {
 										Name: "ok",
 									},
+									"notFound": {
+										Name:      "notFound",
+										Status:    404,
+										Type:      design.ErrorMedia,
+										MediaType: "application/vnd.api.error+json",
+									},
 								},
 							},
 							"get": {
@@ -132,6 +138,22 @@ var _ = Describe("Generate", func() {
 			Ω(content).Should(ContainSubstring("GetFooOK(t *testing.T, ctrl app.FooController, payload app.CustomName) *goa.Error"))
 		})
 
+		It("generates test methods for non OK responses", func() {
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "test", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(content).Should(ContainSubstring("ShowFooNotFound(t *testing.T, ctrl app.FooController, param *int, uuid *uuid.UUID) *goa.Error"))
+			Ω(content).Should(ContainSubstring("if rw.Code != 404 {"))
+		})
+
+		It("generates content type and response body assertions", func() {
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "test", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(content).Should(ContainSubstring(`ct != "application/vnd.api.error+json"`))
+			Ω(content).Should(ContainSubstring("service.Decoder.Decode(&decoded, rw.Body"))
+		})
+
 		It("generates the route path parameters", func() {
 			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "test", "foo.go"))
 			Ω(err).ShouldNot(HaveOccurred())
@@ -162,5 +184,92 @@ var _ = Describe("Generate", func() {
 			Ω(content).Should(ContainSubstring(", payload app.CustomName)"))
 		})
 
+		It("generates request builders for each action route", func() {
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "test", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(content).Should(ContainSubstring("func NewShowFooRequest(param *int, uuid *uuid.UUID) *http.Request {"))
+			Ω(content).Should(ContainSubstring("func NewShowFooRequest1() *http.Request {"))
+			Ω(content).Should(ContainSubstring("func NewGetFooRequest(payload app.CustomName) *http.Request {"))
+			Ω(content).Should(ContainSubstring("b, err := json.Marshal(payload)"))
+			Ω(content).Should(ContainSubstring(`req.Header.Set("Content-Type", "application/json")`))
+		})
+
+	})
+
+	Context("with secured actions", func() {
+		BeforeEach(func() {
+			jwt := &design.SecuritySchemeDefinition{
+				Kind:       design.JWTSecurityKind,
+				SchemeName: "jwt",
+				In:         "header",
+				Name:       "Authorization",
+				Scopes:     map[string]string{"read": "", "write": ""},
+			}
+			key := &design.SecuritySchemeDefinition{
+				Kind:       design.APIKeySecurityKind,
+				SchemeName: "key",
+				In:         "query",
+				Name:       "k",
+			}
+			design.Design = &design.APIDefinition{
+				Name:            "testapi",
+				SecuritySchemes: []*design.SecuritySchemeDefinition{key, jwt},
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"show": {
+								Name: "show",
+								Params: &design.AttributeDefinition{
+									Type: design.Object{
+										"id": &design.AttributeDefinition{Type: design.Integer},
+									},
+								},
+								Routes: []*design.RouteDefinition{{Verb: "GET", Path: "/:id"}},
+								Responses: map[string]*design.ResponseDefinition{
+									"noContent": {Name: "noContent", Status: 204},
+								},
+								Security: &design.SecurityDefinition{Scheme: jwt, Scopes: []string{"read", "write"}},
+							},
+							"list": {
+								Name:   "list",
+								Routes: []*design.RouteDefinition{{Verb: "GET", Path: ""}},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			for _, a := range fooRes.Actions {
+				a.Parent = fooRes
+				a.Routes[0].Parent = a
+			}
+		})
+
+		It("generates the authorization test cases of every action", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "test", "authorization.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(content).Should(ContainSubstring(`"key": {Name: "key", Kind: goatest.APIKeyKind, In: goa.LocQuery, Key: "k"},`))
+			Ω(content).Should(ContainSubstring(`app.UseJWTMiddleware(service, schemes["jwt"].Middleware())`))
+			Ω(content).Should(ContainSubstring(`{Name: "Foo.List without credentials", Request: newListFooAuthRequest, Status: 200},`))
+			Ω(content).Should(ContainSubstring(`{Name: "Foo.Show without credentials", Request: newShowFooAuthRequest, Status: 401},`))
+			Ω(content).Should(ContainSubstring(`{Name: "Foo.Show with key credentials", Request: newShowFooAuthRequest, Scheme: "key", Status: 401},`))
+			Ω(content).Should(ContainSubstring(`{Name: "Foo.Show without scope read", Request: newShowFooAuthRequest, Scheme: "jwt", Scopes: []string{"write"}, Status: 403},`))
+			Ω(content).Should(ContainSubstring(`{Name: "Foo.Show with jwt credentials", Request: newShowFooAuthRequest, Scheme: "jwt", Scopes: []string{"read", "write"}, Status: 204},`))
+			Ω(content).Should(MatchRegexp(`http.NewRequest\("GET", "/\d+", body\)`))
+		})
+
+		It("generates the authorization test", func() {
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "test", "authorization_test.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(content).Should(ContainSubstring("func (c *fooAuthController) Show(ctx *app.ShowFooContext) error {"))
+			Ω(content).Should(ContainSubstring("ctx.ResponseData.WriteHeader(204)"))
+			Ω(content).Should(ContainSubstring("func TestAuthorizationMatrix(t *testing.T) {"))
+			Ω(content).Should(ContainSubstring(`app.MountFooController(srv.Service, &fooAuthController{Controller: srv.Service.NewController("FooController")})`))
+		})
 	})
 })
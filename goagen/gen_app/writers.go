@@ -2,6 +2,7 @@ package genapp
 
 import (
 	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 	"text/template"
@@ -15,6 +16,13 @@ import (
 // WildcardRegex is the regex used to capture path parameters.
 var WildcardRegex = regexp.MustCompile("(?:[^/]*/:([^/]+))+")
 
+// streamPolicies maps the values of the "stream:policy" metadata to the goa stream policies.
+var streamPolicies = map[string]string{
+	"block":       "goa.StreamBlock",
+	"drop_oldest": "goa.StreamDropOldest",
+	"drop_newest": "goa.StreamDropNewest",
+}
+
 type (
 	// ContextsWriter generate codes for a goa application contexts.
 	ContextsWriter struct {
@@ -41,6 +49,32 @@ type (
 		SecurityTmpl *template.Template
 	}
 
+	// FeaturesWriter generate code for the API feature flags accessors.
+	FeaturesWriter struct {
+		*codegen.SourceFile
+		FeaturesTmpl *template.Template
+	}
+
+	// RetentionWriter generate code for the API media types data retention periods.
+	RetentionWriter struct {
+		*codegen.SourceFile
+	}
+
+	// HealthWriter generate code for the health check endpoints.
+	HealthWriter struct {
+		*codegen.SourceFile
+	}
+
+	// ProtobufWriter generate code for the media types Protocol Buffers adapters.
+	ProtobufWriter struct {
+		*codegen.SourceFile
+	}
+
+	// SwaggerWriter generate code for the handlers serving the API Swagger specification.
+	SwaggerWriter struct {
+		*codegen.SourceFile
+	}
+
 	// ResourcesWriter generate code for a goa application resources.
 	// Resources are data structures initialized by the application handlers and passed to controller
 	// actions.
@@ -66,29 +100,37 @@ type (
 	// ContextTemplateData contains all the information used by the template to render the context
 	// code for an action.
 	ContextTemplateData struct {
-		Name         string // e.g. "ListBottleContext"
-		ResourceName string // e.g. "bottles"
-		ActionName   string // e.g. "list"
-		Params       *design.AttributeDefinition
-		Payload      *design.UserTypeDefinition
-		Headers      *design.AttributeDefinition
-		Routes       []*design.RouteDefinition
-		Responses    map[string]*design.ResponseDefinition
-		API          *design.APIDefinition
-		DefaultPkg   string
-		Security     *design.SecurityDefinition
+		Name          string // e.g. "ListBottleContext"
+		ResourceName  string // e.g. "bottles"
+		ActionName    string // e.g. "list"
+		Params        *design.AttributeDefinition
+		Payload       *design.UserTypeDefinition
+		Headers       *design.AttributeDefinition
+		Routes        []*design.RouteDefinition
+		Responses     map[string]*design.ResponseDefinition
+		API           *design.APIDefinition
+		DefaultPkg    string
+		Security      *design.SecurityDefinition
+		Pool          bool                      // Whether contexts are pooled
+		Conversions   []*ConversionTemplateData // Payload to media type conversion functions
+		Stream        *design.StreamDefinition  // Flow control of streamed responses if any
+		PayloadStream string                    // Name of payload stream type if the payload is streamed
 	}
 
 	// ControllerTemplateData contains the information required to generate an action handler.
 	ControllerTemplateData struct {
 		API            *design.APIDefinition          // API definition
 		Resource       string                         // Lower case plural resource name, e.g. "bottles"
-		Actions        []map[string]interface{}       // Array of actions, each action has keys "Name", "Routes", "Context" and "Unmarshal"
+		Actions        []map[string]interface{}       // Array of actions, see Generator.generateControllers for the keys of each action
 		FileServers    []*design.FileServerDefinition // File servers
 		Encoders       []*EncoderTemplateData         // Encoder data
 		Decoders       []*EncoderTemplateData         // Decoder data
 		Origins        []*design.CORSDefinition       // CORS policies
 		PreflightPaths []string
+		Pool           bool                          // Whether contexts are pooled
+		Interceptors   bool                          // Whether controllers may implement the action interceptor interfaces
+		ResourceName   string                        // Name of resource as defined in the design
+		Maintenance    *design.MaintenanceDefinition // Maintenance mode settings if enabled
 	}
 
 	// ResourceData contains the information required to generate the resource GoGenerator
@@ -101,6 +143,40 @@ type (
 		CanonicalParams   []string                    // CanonicalParams is the list of parameter names that appear in the resource canonical path in order.
 	}
 
+	// ConversionTemplateData contains the information required to generate a function that
+	// creates a media type instance from a user type instance.
+	ConversionTemplateData struct {
+		Name   string // Name of function, e.g. "CreateBottlePayloadToBottleMedia"
+		Source string // Name of source user type Go struct, e.g. "CreateBottlePayload"
+		Target string // Name of target media type Go struct, e.g. "Bottle"
+		Code   string // Function code
+	}
+
+	// MediaTypeInterfaceTemplateData contains the information required to generate the interface
+	// implemented by all the views of a media type.
+	MediaTypeInterfaceTemplateData struct {
+		Name       string   // e.g. "BottleViewer"
+		Identifier string   // e.g. "application/vnd.goa.example.bottle"
+		Types      []string // e.g. ["Bottle", "BottleTiny"]
+		Accessors  []*AccessorTemplateData
+	}
+
+	// AccessorTemplateData contains the information required to generate a media type attribute
+	// accessor.
+	AccessorTemplateData struct {
+		Name      string // e.g. "GetName"
+		Field     string // e.g. "Name"
+		Attribute string // e.g. "name"
+		Type      string // e.g. "*string"
+	}
+
+	// ProtobufTemplateData contains the information required to generate the methods converting
+	// a media type view to and from its Protocol Buffers message.
+	ProtobufTemplateData struct {
+		Type    string // Name of media type Go struct, e.g. "GoaExampleBottleTiny"
+		Message string // Qualified name of message Go type, e.g. "pb.Bottle"
+	}
+
 	// EncoderTemplateData contains the data needed to render the registration code for a single
 	// encoder or decoder package.
 	EncoderTemplateData struct {
@@ -140,6 +216,20 @@ func (c *ContextTemplateData) IsPathParam(param string) bool {
 	return pp
 }
 
+// HeaderField returns the name of the context field holding the value of the given header. The
+// name is suffixed with "Header" if it would otherwise be the same as the field of a parameter.
+func (c *ContextTemplateData) HeaderField(name string) string {
+	field := codegen.Goify(name, true)
+	if c.Params != nil {
+		for p := range c.Params.Type.ToObject() {
+			if codegen.Goify(p, true) == field {
+				return field + "Header"
+			}
+		}
+	}
+	return field
+}
+
 // MustValidate returns true if code that checks for the presence of the given param must be
 // generated.
 func (c *ContextTemplateData) MustValidate(name string) bool {
@@ -180,16 +270,33 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 		return err
 	}
 	fn := template.FuncMap{
-		"newCoerceData":  newCoerceData,
-		"arrayAttribute": arrayAttribute,
+		"newCoerceData":      newCoerceData,
+		"newElemCoerceData":  newElemCoerceData,
+		"arrayAttribute":     arrayAttribute,
+		"canonicalHeaderKey": http.CanonicalHeaderKey,
 	}
 	if err := w.ExecuteTemplate("new", ctxNewT, fn, data); err != nil {
 		return err
 	}
+	if data.Pool {
+		if err := w.ExecuteTemplate("pool", ctxPoolT, nil, data); err != nil {
+			return err
+		}
+	}
 	if data.Payload != nil {
 		if err := w.ExecuteTemplate("payload", payloadT, nil, data); err != nil {
 			return err
 		}
+		if data.PayloadStream != "" {
+			if err := w.ExecuteTemplate("payloadStream", payloadStreamT, fn, data); err != nil {
+				return err
+			}
+		}
+	}
+	for _, conv := range data.Conversions {
+		if err := w.ExecuteTemplate("conversion", conversionT, nil, conv); err != nil {
+			return err
+		}
 	}
 	fn = template.FuncMap{
 		"project": func(mt *design.MediaTypeDefinition, v string) *design.MediaTypeDefinition {
@@ -202,12 +309,20 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 			"Context":  data,
 			"Response": resp,
 		}
-		if resp.Type != nil {
+		if mt, ok := resp.Type.(*design.MediaTypeDefinition); ok && mt.IsBuiltIn() {
+			if err := w.ExecuteTemplate("errorResponse", ctxErrRespT, fn, respData); err != nil {
+				return err
+			}
+		} else if resp.Type != nil {
 			respData["Type"] = resp.Type
-			if err := w.ExecuteTemplate("response", ctxTRespT, fn, respData); err != nil {
+			if err := w.ExecuteTemplate("typedResponse", ctxTRespT, fn, respData); err != nil {
 				return err
 			}
-		} else if mt := design.Design.MediaTypeWithIdentifier(resp.MediaType); mt != nil {
+		} else if mt := design.Design.MediaTypeWithIdentifier(resp.MediaType); mt != nil && mt.IsBuiltIn() {
+			if err := w.ExecuteTemplate("errorResponse", ctxErrRespT, fn, respData); err != nil {
+				return err
+			}
+		} else if mt != nil {
 			respData["MediaType"] = mt
 			fn["respName"] = func(resp *design.ResponseDefinition, view string) string {
 				if view == "default" {
@@ -216,9 +331,17 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 				base := fmt.Sprintf("%s%s", resp.Name, strings.Title(view))
 				return codegen.Goify(base, true)
 			}
-			if err := w.ExecuteTemplate("response", ctxMTRespT, fn, respData); err != nil {
+			if err := w.ExecuteTemplate("mediaTypeResponse", ctxMTRespT, fn, respData); err != nil {
 				return err
 			}
+			if data.Stream != nil {
+				respData["Stream"] = data.Stream
+				respData["StreamName"] = strings.TrimSuffix(data.Name, "Context") + codegen.Goify(resp.Name, true) + "Stream"
+				respData["Policy"] = streamPolicies[data.Stream.Policy]
+				if err := w.ExecuteTemplate("stream", ctxStreamT, fn, respData); err != nil {
+					return err
+				}
+			}
 		} else {
 			if err := w.ExecuteTemplate("response", ctxNoMTRespT, fn, respData); err != nil {
 				return err
@@ -269,6 +392,11 @@ func (w *ControllersWriter) Execute(data []*ControllerTemplateData) error {
 				return err
 			}
 		}
+		if d.Maintenance != nil {
+			if err := w.ExecuteTemplate("handleMaintenance", handleMaintenanceT, nil, d); err != nil {
+				return err
+			}
+		}
 		if err := w.ExecuteTemplate("unmarshal", unmarshalT, nil, d); err != nil {
 			return err
 		}
@@ -291,6 +419,79 @@ func (w *SecurityWriter) Execute(schemes []*design.SecuritySchemeDefinition) err
 	return w.ExecuteTemplate("security_schemes", securitySchemesT, nil, schemes)
 }
 
+// NewFeaturesWriter returns a feature flags code writer.
+// Feature flags are set on requests by the FeatureFlags middleware and read by the controllers.
+func NewFeaturesWriter(filename string) (*FeaturesWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &FeaturesWriter{SourceFile: file}, nil
+}
+
+// Execute writes the feature flag constants and accessors.
+func (w *FeaturesWriter) Execute(features []*design.FeatureDefinition) error {
+	return w.ExecuteTemplate("features", featuresT, nil, features)
+}
+
+// NewRetentionWriter returns a data retention code writer.
+func NewRetentionWriter(filename string) (*RetentionWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &RetentionWriter{SourceFile: file}, nil
+}
+
+// Execute writes the retention periods map and the function mounting the handler exposing it.
+func (w *RetentionWriter) Execute(mts []*design.MediaTypeDefinition) error {
+	return w.ExecuteTemplate("retention", retentionT, nil, mts)
+}
+
+// NewHealthWriter returns a health check endpoints code writer.
+func NewHealthWriter(filename string) (*HealthWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &HealthWriter{SourceFile: file}, nil
+}
+
+// Execute writes the function mounting the health check endpoints.
+func (w *HealthWriter) Execute(health *design.HealthDefinition) error {
+	return w.ExecuteTemplate("health", healthT, nil, health)
+}
+
+// NewProtobufWriter returns a Protocol Buffers adapters code writer.
+func NewProtobufWriter(filename string) (*ProtobufWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &ProtobufWriter{SourceFile: file}, nil
+}
+
+// Execute writes the methods converting the media types to and from their Protocol Buffers
+// messages.
+func (w *ProtobufWriter) Execute(data []*ProtobufTemplateData) error {
+	return w.ExecuteTemplate("protobuf", protobufT, nil, data)
+}
+
+// NewSwaggerWriter returns a Swagger specification code writer.
+func NewSwaggerWriter(filename string) (*SwaggerWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &SwaggerWriter{SourceFile: file}, nil
+}
+
+// Execute writes the Swagger specification constant and the function mounting the handlers
+// serving it.
+func (w *SwaggerWriter) Execute(spec string) error {
+	return w.ExecuteTemplate("swagger", swaggerT, nil, spec)
+}
+
 // NewResourcesWriter returns a contexts code writer.
 // Resources provide the glue between the underlying request data and the user controller.
 func NewResourcesWriter(filename string) (*ResourcesWriter, error) {
@@ -319,6 +520,7 @@ func NewMediaTypesWriter(filename string) (*MediaTypesWriter, error) {
 // Execute writes the code for the context types to the writer.
 func (w *MediaTypesWriter) Execute(mt *design.MediaTypeDefinition) error {
 	var mLinks *design.UserTypeDefinition
+	var views []*design.MediaTypeDefinition
 	viewMT := mt
 	err := mt.IterateViews(func(view *design.ViewDefinition) error {
 		p, links, err := mt.Project(view.Name)
@@ -329,6 +531,7 @@ func (w *MediaTypesWriter) Execute(mt *design.MediaTypeDefinition) error {
 			return err
 		}
 		viewMT = p
+		views = append(views, p)
 		if err := w.ExecuteTemplate("mediatype", mediaTypeT, nil, viewMT); err != nil {
 			return err
 		}
@@ -337,6 +540,13 @@ func (w *MediaTypesWriter) Execute(mt *design.MediaTypeDefinition) error {
 	if err != nil {
 		return err
 	}
+	if mt.Type.IsObject() && len(views) > 1 {
+		if data := mediaTypeInterface(mt, views); data != nil {
+			if err := w.ExecuteTemplate("mediatypeinterface", mediaTypeInterfaceT, nil, data); err != nil {
+				return err
+			}
+		}
+	}
 	if mLinks != nil {
 		if err := w.ExecuteTemplate("mediatypelink", mediaTypeLinkT, nil, mLinks); err != nil {
 			return err
@@ -345,6 +555,68 @@ func (w *MediaTypesWriter) Execute(mt *design.MediaTypeDefinition) error {
 	return nil
 }
 
+// mediaTypeInterface computes the data used to render the interface implemented by all the
+// given views of the media type. The interface exposes accessors for the attributes that are
+// rendered with the same Go type by all the views. mediaTypeInterface returns nil if the interface
+// name is already taken by one of the views.
+func mediaTypeInterface(mt *design.MediaTypeDefinition, views []*design.MediaTypeDefinition) *MediaTypeInterfaceTemplateData {
+	var accessors []*AccessorTemplateData
+	first := views[0]
+	first.Type.ToObject().IterateAttributes(func(n string, att *design.AttributeDefinition) error {
+		if _, ok := att.Type.(design.Object); ok {
+			// Anonymous structs are defined separately by each view.
+			return nil
+		}
+		typ := accessorType(first.AttributeDefinition, n, att)
+		for _, v := range views[1:] {
+			vatt, ok := v.Type.ToObject()[n]
+			if !ok || accessorType(v.AttributeDefinition, n, vatt) != typ {
+				return nil
+			}
+		}
+		field := n
+		if fname, ok := att.Metadata["struct:field:name"]; ok && len(fname) > 0 {
+			field = fname[0]
+		}
+		field = codegen.Goify(field, true)
+		accessors = append(accessors, &AccessorTemplateData{
+			Name:      "Get" + field,
+			Field:     field,
+			Attribute: n,
+			Type:      typ,
+		})
+		return nil
+	})
+	name := codegen.Goify(mt.TypeName, true) + "Viewer"
+	types := make([]string, len(views))
+	for i, v := range views {
+		types[i] = codegen.GoTypeName(v, v.AllRequired(), 0, false)
+		if types[i] == name {
+			// A view named "viewer" already uses the interface name.
+			return nil
+		}
+	}
+	return &MediaTypeInterfaceTemplateData{
+		Name:       name,
+		Identifier: mt.Identifier,
+		Types:      types,
+		Accessors:  accessors,
+	}
+}
+
+// accessorType returns the Go type of the struct field generated for the attribute with the given
+// name of the given parent object.
+func accessorType(parent *design.AttributeDefinition, name string, att *design.AttributeDefinition) string {
+	typ := codegen.GoTypeDef(att, 0, false, false)
+	if ft := codegen.FieldType(att); ft != "" {
+		typ = ft
+	}
+	if att.Type.IsObject() || parent.IsPrimitivePointer(name) {
+		typ = "*" + typ
+	}
+	return typ
+}
+
 // NewUserTypesWriter returns a contexts code writer.
 // User types contain custom data structured defined in the DSL with "Type".
 func NewUserTypesWriter(filename string) (*UserTypesWriter, error) {
@@ -360,10 +632,17 @@ func (w *UserTypesWriter) Execute(t *design.UserTypeDefinition) error {
 	return w.ExecuteTemplate("types", userTypeT, nil, t)
 }
 
+// ExecuteConversion writes the code of a function that creates a media type instance from a user
+// type instance.
+func (w *UserTypesWriter) ExecuteConversion(data *ConversionTemplateData) error {
+	return w.ExecuteTemplate("conversion", conversionT, nil, data)
+}
+
 // newCoerceData is a helper function that creates a map that can be given to the "Coerce" template.
 func newCoerceData(name string, att *design.AttributeDefinition, pointer bool, pkg string, depth int) map[string]interface{} {
 	return map[string]interface{}{
 		"Name":      name,
+		"ErrName":   fmt.Sprintf("%q", name),
 		"VarName":   codegen.Goify(name, false),
 		"Pointer":   pointer,
 		"Attribute": att,
@@ -372,6 +651,14 @@ func newCoerceData(name string, att *design.AttributeDefinition, pointer bool, p
 	}
 }
 
+// newElemCoerceData creates the data given to the "Coerce" template for the elements of the array
+// named name. The errors are reported with the name and index of the invalid elements.
+func newElemCoerceData(name string, att *design.AttributeDefinition, pkg string, depth int) map[string]interface{} {
+	data := newCoerceData("elem", att, false, pkg, depth)
+	data["ErrName"] = fmt.Sprintf(`%q + strconv.Itoa(i) + "]"`, name+"[")
+	return data
+}
+
 // arrayAttribute returns the array element attribute definition.
 func arrayAttribute(a *design.AttributeDefinition) *design.AttributeDefinition {
 	return a.Type.(*design.Array).ElemType
@@ -388,7 +675,9 @@ type {{ .Name }} struct {
 	Service *goa.Service
 {{ if .Params }}{{ range $name, $att := .Params.Type.ToObject }}{{/*
 */}}	{{ goify $name true }} {{ if and $att.Type.IsPrimitive ($.Params.IsPrimitivePointer $name) }}*{{ end }}{{ gotyperef .Type nil 0 false }}
-{{ end }}{{ end }}{{ if .Payload }}	Payload {{ gotyperef .Payload nil 0 false }}
+{{ end }}{{ end }}{{ if .Headers }}{{ range $name, $att := .Headers.Type.ToObject }}{{/*
+*/}}	{{ $.HeaderField $name }} {{ if and $att.Type.IsPrimitive ($.Headers.IsPrimitivePointer $name) }}*{{ end }}{{ gotyperef .Type nil 0 false }}
+{{ end }}{{ end }}{{ if .Payload }}	Payload {{ if .PayloadStream }}*{{ .PayloadStream }}{{ else }}{{ gotyperef .Payload nil 0 false }}{{ end }}
 {{ end }}}
 `
 	// coerceT generates the code that coerces the generic deserialized
@@ -402,7 +691,7 @@ type {{ .Name }} struct {
 {{ if .Pointer }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
 {{ end }}{{ tabs .Depth }}	{{ .Pkg }} = {{ $varName }}
 {{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "boolean"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError({{ .ErrName }}, raw{{ goify .Name true }}, "boolean"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 2 }}{{/*
 
@@ -414,7 +703,7 @@ type {{ .Name }} struct {
 {{ tabs .Depth }}	{{ .Pkg }} = {{ $tmp }}
 {{ else }}{{ tabs .Depth }}	{{ .Pkg }} = {{ .VarName }}
 {{ end }}{{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "integer"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError({{ .ErrName }}, raw{{ goify .Name true }}, "integer"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 3 }}{{/*
 
@@ -424,7 +713,7 @@ type {{ .Name }} struct {
 {{ if .Pointer }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
 {{ end }}{{ tabs .Depth }}	{{ .Pkg }} = {{ $varName }}
 {{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "number"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError({{ .ErrName }}, raw{{ goify .Name true }}, "number"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 4 }}{{/*
 
@@ -434,11 +723,11 @@ type {{ .Name }} struct {
 
 */}}{{/* DateTimeType */}}{{/*
 */}}{{ $varName := or (and (not .Pointer) .VarName) tempvar }}{{/*
-*/}}{{ tabs .Depth }}if {{ .VarName }}, err2 := time.Parse("RFC3339", raw{{ goify .Name true }}); err2 == nil {
+*/}}{{ tabs .Depth }}if {{ .VarName }}, err2 := time.Parse(time.RFC3339, raw{{ goify .Name true }}); err2 == nil {
 {{ if .Pointer }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
 {{ end }}{{ tabs .Depth }}	{{ .Pkg }} = {{ $varName }}
 {{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "datetime"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError({{ .ErrName }}, raw{{ goify .Name true }}, "datetime"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 6 }}{{/*
 
@@ -448,7 +737,7 @@ type {{ .Name }} struct {
 {{ if .Pointer }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
 {{ end }}{{ tabs .Depth }}	{{ .Pkg }} = {{ $varName }}
 {{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "uuid"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError({{ .ErrName }}, raw{{ goify .Name true }}, "uuid"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 7 }}{{/*
 
@@ -463,7 +752,7 @@ type {{ .Name }} struct {
 {{ if eq (arrayAttribute .Attribute).Type.Kind 4 }}{{ tabs .Depth }}{{ .Pkg }} = elems{{ goify .Name true }}
 {{ else }}{{ tabs .Depth }}elems{{ goify .Name true }}2 := make({{ gotyperef .Attribute.Type nil .Depth false }}, len(elems{{ goify .Name true }}))
 {{ tabs .Depth }}for i, rawElem := range elems{{ goify .Name true }} {
-{{ template "Coerce" (newCoerceData "elem" (arrayAttribute .Attribute) false (printf "elems%s2[i]" (goify .Name true)) (add .Depth 1)) }}{{ tabs .Depth }}}
+{{ template "Coerce" (newElemCoerceData .Name (arrayAttribute .Attribute) (printf "elems%s2[i]" (goify .Name true)) (add .Depth 1)) }}{{ tabs .Depth }}}
 {{ tabs .Depth }}{{ .Pkg }} = elems{{ goify .Name true }}2
 {{ end }}{{ end }}`
 
@@ -475,13 +764,22 @@ type {{ .Name }} struct {
 func New{{ .Name }}(ctx context.Context, service *goa.Service) (*{{ .Name }}, error) {
 	var err error
 	req := goa.ContextRequest(ctx)
-	rctx := {{ .Name }}{Context: ctx, ResponseData: goa.ContextResponse(ctx), RequestData: req, Service: service}
-{{ if .Headers }}{{ $headers := .Headers }}{{ range $name, $att := $headers.Type.ToObject }}	raw{{ goify $name true }} := req.Header.Get("{{ $name }}")
-{{ if $headers.IsRequired $name }}	if raw{{ goify $name true }} == "" {
+{{ if .Pool }}	rctx := {{ goify .Name false }}Pool.Get().(*{{ .Name }})
+	rctx.Context, rctx.ResponseData, rctx.RequestData, rctx.Service = ctx, goa.ContextResponse(ctx), req, service
+{{ else }}	rctx := {{ .Name }}{Context: ctx, ResponseData: goa.ContextResponse(ctx), RequestData: req, Service: service}
+{{ end }}{{ if .Headers }}{{ $headers := .Headers }}{{ range $name, $att := $headers.Type.ToObject }}	header{{ goify $name true }} := req.Header["{{ canonicalHeaderKey $name }}"]
+{{ if $headers.IsRequired $name }}	if len(header{{ goify $name true }}) == 0 {
 		err = goa.MergeErrors(err, goa.MissingHeaderError("{{ $name }}"))
 	} else {
-{{ else }}	if raw{{ goify $name true }} != "" {
-{{ end }}{{ $validation := validationChecker $att ($headers.IsNonZero $name) ($headers.IsRequired $name) ($headers.HasDefaultValue $name) (printf "raw%s" (goify $name true)) $name 2 false }}{{/*
+{{ else }}	if len(header{{ goify $name true }}) > 0 {
+{{ end }}{{ if $att.Type.IsArray }}		var headers {{ gotypedef $att 2 true false }}
+		for _, raw{{ goify $name true}} := range header{{ goify $name true}} {
+{{ template "Coerce" (newCoerceData $name $att ($headers.IsPrimitivePointer $name) "headers" 3) }}{{/*
+*/}}			rctx.{{ $.HeaderField $name }} = append(rctx.{{ $.HeaderField $name }}, headers...)
+		}
+{{ else }}		raw{{ goify $name true}} := header{{ goify $name true}}[0]
+{{ template "Coerce" (newCoerceData $name $att ($headers.IsPrimitivePointer $name) (printf "rctx.%s" ($.HeaderField $name)) 2) }}{{ end }}{{/*
+*/}}{{ $validation := validationChecker $att ($headers.IsNonZero $name) ($headers.IsRequired $name) ($headers.HasDefaultValue $name) (printf "rctx.%s" ($.HeaderField $name)) $name 2 false }}{{/*
 */}}{{ if $validation }}{{ $validation }}
 {{ end }}	}
 {{ end }}{{ end }}{{/*
@@ -500,10 +798,66 @@ func New{{ .Name }}(ctx context.Context, service *goa.Service) (*{{ .Name }}, er
 */}}{{ $validation := validationChecker $att ($.Params.IsNonZero $name) ($.Params.IsRequired $name) ($.Params.HasDefaultValue $name) (printf "rctx.%s" (goify $name true)) $name 2 false }}{{/*
 */}}{{ if $validation }}{{ $validation }}
 {{ end }}	}
-{{ end }}{{ end }}{{/* if .Params */}}	return &rctx, err
+{{ end }}{{ end }}{{/* if .Params */}}	return {{ if not .Pool }}&{{ end }}rctx, err
 }
 `
 
+	// ctxPoolT generates the code that pools the contexts of an action.
+	// template input: *ContextTemplateData
+	ctxPoolT = `{{ $pool := printf "%sPool" (goify .Name false) }}
+// {{ $pool }} holds the {{ .Name }} instances available for reuse.
+var {{ $pool }} = sync.Pool{New: func() interface{} { return new({{ .Name }}) }}
+
+// Reset clears all the context fields so that it can be reused.
+func (ctx *{{ .Name }}) Reset() {
+	*ctx = {{ .Name }}{}
+}
+
+// Release{{ .Name }} resets the context and puts it back into the pool.
+// The context must not be used once released.
+func Release{{ .Name }}(ctx *{{ .Name }}) {
+	ctx.Reset()
+	{{ $pool }}.Put(ctx)
+}
+`
+
+	// featuresT generates the feature flag constants and accessors.
+	// template input: []*design.FeatureDefinition
+	featuresT = `// Feature flag names and variants.
+const (
+{{ range . }}{{ $name := goify .Name true }}	// Feature{{ $name }} is the name of the "{{ .Name }}" feature flag.
+{{ if .Description }}{{ tabs 1 }}{{ comment .Description }}
+{{ end }}	Feature{{ $name }} = {{ printf "%q" .Name }}
+{{ range .Variants }}	// Feature{{ $name }}{{ goify . true }} is the "{{ . }}" variant of the {{ $name }} feature.
+	Feature{{ $name }}{{ goify . true }} = {{ printf "%q" . }}
+{{ end }}{{ end }})
+
+// Features provides access to the feature flags set on a request.
+type Features struct {
+	flags middleware.Features
+}
+
+// ContextFeatures returns the feature flags set on the request by the FeatureFlags middleware.
+func ContextFeatures(ctx context.Context) *Features {
+	return &Features{flags: middleware.ContextFeatures(ctx)}
+}
+{{ range . }}{{ $name := goify .Name true }}
+// {{ $name }} returns true if the "{{ .Name }}" feature flag is set on the request.
+func (f *Features) {{ $name }}() bool {
+	return f.flags.Enabled(Feature{{ $name }})
+}
+{{ if .Variants }}
+// {{ $name }}Variant returns the variant of the "{{ .Name }}" feature flag set on the request, the
+// empty string if the flag is not set or if the variant is not one of the declared variants.
+func (f *Features) {{ $name }}Variant() string {
+	switch v := f.flags.Variant(Feature{{ $name }}); v {
+	case {{ range $i, $v := .Variants }}{{ if $i }}, {{ end }}Feature{{ $name }}{{ goify $v true }}{{ end }}:
+		return v
+	}
+	return ""
+}
+{{ end }}{{ end }}`
+
 	// ctxMTRespT generates the response helpers for responses with media types.
 	// template input: map[string]interface{}
 	ctxMTRespT = `{{ $ctx := .Context }}{{ $resp := .Response }}{{ $mt := .MediaType }}{{/*
@@ -514,6 +868,43 @@ func (ctx *{{ $ctx.Name }}) {{ respName $resp $name }}(r {{ gotyperef $projected
 	return ctx.Service.Send(ctx.Context, {{ $resp.Status }}, r)
 }
 {{ end }}{{ end }}
+`
+
+	// ctxStreamT generates the helpers that stream responses with media types.
+	// template input: map[string]interface{}
+	ctxStreamT = `{{ $mt := project .MediaType "default" }}// {{ .StreamName }} streams the {{ goify .Response.Name true }} responses of the {{ .Context.ActionName }} action.
+type {{ .StreamName }} struct {
+	*goa.Stream
+}
+
+// Send queues r for sending, see goa.Stream for the flow control semantics.
+func (s *{{ .StreamName }}) Send(r {{ gotyperef $mt $mt.AllRequired 0 false }}) error {
+	return s.Stream.Send(r)
+}
+
+// {{ goify .Response.Name true }}Stream writes the response headers with status code {{ .Response.Status }} and returns the
+// stream used to send the response messages. The stream must be closed once all messages are sent.
+func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}Stream() *{{ .StreamName }} {
+	ctx.ResponseData.Header().Set("Content-Type", "{{ if .Stream.NDJSON }}application/x-ndjson{{ else }}{{ .Response.MediaType }}{{ end }}")
+	return &{{ .StreamName }}{ctx.Service.NewStream(ctx.Context, {{ .Response.Status }}, &goa.StreamOptions{
+		Name:          "{{ .Context.ResourceName }}.{{ .Context.ActionName }}",
+		BufferSize:    {{ .Stream.BufferSize }},
+		HighWatermark: {{ .Stream.HighWatermark }},
+		Policy:        {{ .Policy }},{{ if .Stream.NDJSON }}
+		NDJSON:        true,{{ end }}
+	})}
+}
+`
+
+	// ctxErrRespT generates the response helpers for responses using the error media type.
+	// template input: map[string]interface{}
+	ctxErrRespT = `// {{ goify .Response.Name true }} sends a HTTP response with status code {{ .Response.Status }}.
+// The response error records the names of the resource and action, the request trace ID and the
+// details of the validation errors.
+func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}(r error) error {
+	ctx.ResponseData.Header().Set("Content-Type", "{{ .Response.MediaType }}")
+	return ctx.Service.Send(ctx.Context, {{ .Response.Status }}, goa.ActionError(r, {{ .Response.Status }}, {{ printf "%q" .Context.ResourceName }}, {{ printf "%q" .Context.ActionName }}, middleware.ContextRequestID(ctx.Context)))
+}
 `
 
 	// ctxTRespT generates the response helpers for responses with overridden types.
@@ -536,6 +927,30 @@ func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}({{ if .Response.
 	return err{{ else }}
 	return nil{{ end }}
 }
+`
+
+	// payloadStreamT generates the type that decodes the elements of a streamed payload.
+	// template input: *ContextTemplateData
+	payloadStreamT = `{{ $elem := (arrayAttribute .Payload.AttributeDefinition).Type }}
+// {{ .PayloadStream }} decodes the elements of the {{ .ResourceName }} {{ .ActionName }} action payload one
+// at a time.
+type {{ .PayloadStream }} struct {
+	*goa.PayloadStream
+}
+
+// Next decodes and validates the next element of the payload. It returns io.EOF once all the
+// elements have been decoded.
+func (s *{{ .PayloadStream }}) Next() ({{ gotyperef $elem $elem.AllRequired 0 false }}, error) {
+	var elem {{ gotypename $elem $elem.AllRequired 0 true }}
+	if err := s.PayloadStream.Next(&elem); err != nil {
+		return nil, err
+	}{{ if recursiveFinalizer $elem.AttributeDefinition "ut" 1 }}
+	elem.Finalize(){{ end }}{{ if recursiveValidate $elem.AttributeDefinition false false false "ut" "response" 1 true }}
+	if err := elem.Validate(); err != nil {
+		return nil, err
+	}{{ end }}
+	return elem.Publicize(), nil
+}
 `
 
 	// payloadT generates the payload type definition GoGenerator
@@ -571,6 +986,13 @@ func (payload {{ gotyperef .Payload .Payload.AllRequired 0 false }}) Validate()
 	return
 }{{ end }}
 `
+	// conversionT generates the code of a user type to media type conversion function.
+	// template input: *ConversionTemplateData
+	conversionT = `
+// {{ .Name }} creates a {{ .Target }} media type instance from a {{ .Source }}.
+// Fields are matched by attribute name or by value of the "transform:key" attribute metadata.
+{{ .Code }}`
+
 	// ctrlT generates the controller interface for a given resource.
 	// template input: *ControllerTemplateData
 	ctrlT = `// {{ .Resource }}Controller is the controller interface for the {{ .Resource }} actions.
@@ -579,7 +1001,16 @@ type {{ .Resource }}Controller interface {
 {{ if .FileServers }}	goa.FileServer
 {{ end }}{{ range .Actions }}	{{ .Name }}(*{{ .Context }}) error
 {{ end }}}
-`
+{{ if .Interceptors }}{{ range .Actions }}
+// {{ .Interceptor }}Interceptor is implemented by the {{ $.Resource }}Controller implementations
+// that run code before and after the {{ .Name }} action. Before{{ .Interceptor }} is called once
+// the request context is initialized, the action does not run if it returns an error.
+// After{{ .Interceptor }} is called with the error returned by the action.
+type {{ .Interceptor }}Interceptor interface {
+	Before{{ .Interceptor }}(*{{ .Context }}) error
+	After{{ .Interceptor }}(*{{ .Context }}, error)
+}
+{{ end }}{{ end }}`
 
 	// serviceT generates the service initialization code.
 	// template input: *ControllerTemplateData
@@ -607,32 +1038,89 @@ func initService(service *goa.Service) {
 // Mount{{ .Resource }}Controller "mounts" a {{ .Resource }} resource controller on the given service.
 func Mount{{ .Resource }}Controller(service *goa.Service, ctrl {{ .Resource }}Controller) {
 	initService(service)
-	var h goa.Handler
+{{ if .Pool }}	service.PoolRequestData = true
+{{ end }}	var h goa.Handler
 {{ $res := .Resource }}{{ if .Origins }}{{ range .PreflightPaths }}	service.Mux.Handle("OPTIONS", "{{ . }}", cors.HandlePreflight(service.Context, handle{{ $res }}Origin))
 {{ end }}{{ end }}{{ range .Actions }}{{ $action := . }}
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		done := goa.MeasurePhase(ctx, goa.PhaseValidate)
 		rctx, err := New{{ .Context }}(ctx, service)
-		if err != nil {
+		done()
+{{ if $.Pool }}		defer Release{{ .Context }}(rctx)
+{{ end }}		if err != nil {
 			return err
 		}
 {{ if .Payload }}if rawPayload := goa.ContextRequest(ctx).Payload; rawPayload != nil {
-			rctx.Payload = rawPayload.({{ gotyperef .Payload nil 1 false }})
+			rctx.Payload = rawPayload.({{ if .PayloadStream }}*{{ .PayloadStream }}{{ else }}{{ gotyperef .Payload nil 1 false }}{{ end }})
 {{ if not .PayloadOptional }}} else {
 			return goa.ErrInvalidEncoding(goa.MissingPayloadError())
 {{ end }}}
-		{{ end }}		return ctrl.{{ .Name }}(rctx)
+		{{ end }}		defer goa.MeasurePhase(ctx, goa.PhaseController)()
+{{ if $.Interceptors }}		if ic, ok := ctrl.({{ .Interceptor }}Interceptor); ok {
+			if err := ic.Before{{ .Interceptor }}(rctx); err != nil {
+				return err
+			}
+			err = ctrl.{{ .Name }}(rctx)
+			ic.After{{ .Interceptor }}(rctx, err)
+			return err
+		}
+{{ end }}		return ctrl.{{ .Name }}(rctx)
 	}
-{{ if $.Origins }}	h = handle{{ $res }}Origin(h)
+{{ with .Timeout }}	h = goa.TimeoutHandler({{ .Nanoseconds }}, h) // {{ . }}
+{{ end }}{{ range .Middleware }}	h = service.NamedHandler({{ printf "%q" . }}, h)
+{{ end }}{{ if $.Origins }}	h = handle{{ $res }}Origin(h)
 {{ end }}{{ if .Security }}	h = handleSecurity({{ printf "%q" .Security.Scheme.SchemeName }}, h{{ range .Security.Scopes }}, {{ printf "%q" . }}{{ end }})
+{{ end }}{{ with .Concurrency }}	h = middleware.AdaptiveConcurrency(middleware.NewConcurrencyLimiter(&middleware.ConcurrencyOptions{
+		Name:         {{ printf "%q" $action.QualifiedName }},
+		InitialLimit: {{ .InitialLimit }},{{ if .MaxLimit }}
+		MaxLimit:     {{ .MaxLimit }},{{ end }}{{ if .Latency }}
+		Latency:      {{ .Latency.Nanoseconds }}, // {{ .Latency }}{{ end }}
+	}))(h)
+{{ end }}{{ if .SkipCompression }}	h = middleware.SkipCompression(h)
+{{ end }}{{ if .AuditInclude }}	h = middleware.AuditHandler(true, h)
+{{ else if .AuditExclude }}	h = middleware.AuditHandler(false, h)
+{{ end }}{{ if $.Maintenance }}	h = handle{{ $res }}Maintenance(service, h)
 {{ end }}{{ range .Routes }}	service.Mux.Handle("{{ .Verb }}", {{ printf "%q" .FullPath }}, ctrl.MuxHandler({{ printf "%q" $action.Name }}, h, {{ if $action.Payload }}{{ $action.Unmarshal }}{{ else }}nil{{ end }}))
+	service.AddRoute(goa.Route{Method: "{{ .Verb }}", Path: {{ printf "%q" .FullPath }}, Resource: {{ printf "%q" $.ResourceName }}, Controller: goa.ControllerName(ctrl), Action: {{ printf "%q" $action.Name }}})
 	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "action", {{ printf "%q" $action.Name }}, "route", {{ printf "%q" (printf "%s %s" .Verb .FullPath) }}{{ with $action.Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
+{{ end }}{{ range .HeadRoutes }}	service.Mux.Handle("HEAD", {{ printf "%q" .FullPath }}, ctrl.MuxHandler({{ printf "%q" $action.Name }}, goa.HeadHandler(h), nil))
+	service.AddRoute(goa.Route{Method: "HEAD", Path: {{ printf "%q" .FullPath }}, Resource: {{ printf "%q" $.ResourceName }}, Controller: goa.ControllerName(ctrl), Action: {{ printf "%q" $action.Name }}})
+	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "action", {{ printf "%q" $action.Name }}, "route", {{ printf "%q" (printf "HEAD %s" .FullPath) }}{{ with $action.Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
+{{ end }}{{ range .Aliases }}{{ if .Proxy }}	service.Mux.Handle("{{ .Route.Verb }}", {{ printf "%q" .Route.FullPath }}, ctrl.MuxHandler({{ printf "%q" $action.Name }}, h, {{ if $action.Payload }}{{ $action.Unmarshal }}{{ else }}nil{{ end }}))
+	service.AddRoute(goa.Route{Method: "{{ .Route.Verb }}", Path: {{ printf "%q" .Route.FullPath }}, Resource: {{ printf "%q" $.ResourceName }}, Controller: goa.ControllerName(ctrl), Action: {{ printf "%q" $action.Name }}})
+{{ else }}	service.Mux.Handle("{{ .Route.Verb }}", {{ printf "%q" .Route.FullPath }}, goa.PermanentRedirectHandler({{ printf "%q" .Target.FullPath }}))
+	service.AddRoute(goa.Route{Method: "{{ .Route.Verb }}", Path: {{ printf "%q" .Route.FullPath }}, Resource: {{ printf "%q" $.ResourceName }}, Controller: goa.ControllerName(ctrl), Action: {{ printf "%q" $action.Name }}, Redirect: {{ printf "%q" .Target.FullPath }}})
+{{ end }}	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "action", {{ printf "%q" $action.Name }}, "route", {{ printf "%q" (printf "%s %s" .Route.Verb .Route.FullPath) }}, "renamed", {{ printf "%q" .Target.FullPath }})
 {{ end }}{{ end }}{{ range .FileServers }}
 	h = ctrl.FileHandler("{{ .RequestPath }}", "{{ .FilePath }}")
 {{ if $.Origins }}	h = handle{{ $res }}Origin(h)
 {{ end }}{{ if .Security }}	h = handleSecurity({{ printf "%q" .Security.Scheme.SchemeName }}, h{{ range .Security.Scopes }}, {{ printf "%q" . }}{{ end }})
+{{ end }}{{ if $.Maintenance }}	h = handle{{ $res }}Maintenance(service, h)
 {{ end }}	service.Mux.Handle("GET", "{{ .RequestPath }}", ctrl.MuxHandler("serve", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "{{ .RequestPath }}", Resource: {{ printf "%q" $.ResourceName }}, Controller: goa.ControllerName(ctrl), Action: "serve"})
 	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "files", {{ printf "%q" .FilePath }}, "route", {{ printf "%q" (printf "GET %s" .RequestPath) }}{{ with .Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
 {{ end }}}
+`
+
+	// handleMaintenanceT generates the code that responds to the requests made to resources in
+	// maintenance mode.
+	// template input: *ControllerTemplateData
+	handleMaintenanceT = `// handle{{ .Resource }}Maintenance responds with a 503 Service Unavailable response when the
+// {{ .ResourceName }} resource is in maintenance mode, see goa.Service.Maintenance.
+func handle{{ .Resource }}Maintenance(service *goa.Service, h goa.Handler) goa.Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		retryAfter, ok := service.InMaintenance({{ printf "%q" .ResourceName }}, {{ .Maintenance.RetryAfter.Nanoseconds }}) // {{ .Maintenance.RetryAfter }}
+		if !ok {
+			return h(ctx, rw, req)
+		}
+		rw.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		rw.Header().Set("Content-Type", "application/vnd.goa.maintenance+json")
+		return service.Send(ctx, 503, &Maintenance{
+			Message:    {{ printf "%q" (printf "%s is under maintenance" .ResourceName) }},
+			RetryAfter: retryAfter,
+		})
+	}
+}
 `
 
 	// handleCORST generates the code that checks whether a CORS request is authorized
@@ -667,7 +1155,13 @@ func handle{{ .Resource }}Origin(h goa.Handler) goa.Handler {
 
 	// unmarshalT generates the code for an action payload unmarshal function.
 	// template input: *ControllerTemplateData
-	unmarshalT = `{{ range .Actions }}{{ if .Payload }}
+	unmarshalT = `{{ range .Actions }}{{ if .PayloadStream }}
+// {{ .Unmarshal }} streams the request body into the context request data Payload field.
+func {{ .Unmarshal }}(ctx context.Context, service *goa.Service, req *http.Request) error {
+	goa.ContextRequest(ctx).Payload = &{{ .PayloadStream }}{goa.NewPayloadStream(req, {{ .PayloadStreamMin }}, {{ .PayloadStreamMax }})}
+	return nil
+}
+{{ else if .Payload }}
 // {{ .Unmarshal }} unmarshals the request body into the context request data Payload field.
 func {{ .Unmarshal }}(ctx context.Context, service *goa.Service, req *http.Request) error {
 	{{ if .Payload.IsObject }}payload := &{{ gotypename .Payload nil 1 true }}{}
@@ -678,7 +1172,10 @@ func {{ .Unmarshal }}(ctx context.Context, service *goa.Service, req *http.Reque
 	if err := service.DecodeRequest(req, &payload); err != nil {
 		return err
 	}{{ end }}{{ $validation := recursiveValidate .Payload.AttributeDefinition false false false "payload" "raw" 1 false }}{{ if $validation }}
-	if err := payload.Validate(); err != nil {
+	done := goa.MeasurePhase(ctx, goa.PhaseValidate)
+	err := payload.Validate()
+	done()
+	if err != nil {
 		return err
 	}{{ end }}
 	goa.ContextRequest(ctx).Payload = payload{{ if .Payload.IsObject }}.Publicize(){{ end }}
@@ -708,6 +1205,22 @@ func (mt {{ gotyperef . .AllRequired 0 false }}) Validate() (err error) {
 	return
 }
 {{ end }}
+`
+
+	// mediaTypeInterfaceT generates the interface implemented by all the views of a media type.
+	// template input: MediaTypeInterfaceTemplateData
+	mediaTypeInterfaceT = `// {{ .Name }} is the interface implemented by all the views of the {{ .Identifier }} media type.
+// Controllers may use it to render any view without having to switch on the view type.
+type {{ .Name }} interface {
+{{ range .Accessors }}	// {{ .Name }} returns the value of the {{ .Attribute }} attribute.
+	{{ .Name }}() {{ .Type }}
+{{ end }}}
+{{ range $t := .Types }}{{ range $.Accessors }}
+// {{ .Name }} returns the value of the {{ .Attribute }} attribute.
+func (mt *{{ $t }}) {{ .Name }}() {{ .Type }} {
+	return mt.{{ .Field }}
+}
+{{ end }}{{ end }}
 `
 
 	// mediaTypeLinkT generates the code for a media type link.
@@ -806,5 +1319,206 @@ func handleSecurity(schemeName string, h goa.Handler, scopes ...string) goa.Hand
 		return am(h)(ctx, rw, req)
 	}
 }
+`
+
+	// retentionT generates the data retention periods map and the function mounting the
+	// handler exposing it.
+	// template input: []*design.MediaTypeDefinition
+	retentionT = `// RetentionPeriods lists the maximum periods for which instances of the API media types may be
+// stored indexed by media type identifier. A period consists of a number followed by a unit: "d"
+// (days), "w" (weeks), "m" (months) or "y" (years).
+var RetentionPeriods = map[string]string{
+{{ range . }}	{{ printf "%q" .Identifier }}: {{ printf "%q" .Retention }},
+{{ end }}}
+
+// MountRetentionHandler mounts a handler that responds to GET requests made to path with the
+// retention periods, e.g. MountRetentionHandler(service, "/admin/retention").
+func MountRetentionHandler(service *goa.Service, path string) {
+	ctrl := service.NewController("RetentionController")
+	h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		return service.Send(ctx, 200, RetentionPeriods)
+	}
+	service.Mux.Handle("GET", path, ctrl.MuxHandler("Show", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: path, Controller: ctrl.Name, Action: "Show"})
+	service.LogInfo("mount", "ctrl", "Retention", "action", "Show", "route", "GET "+path)
+}
+`
+
+	// healthT generates the function that mounts the health check endpoints.
+	// template input: *design.HealthDefinition
+	healthT = `// MountHealth mounts the handlers that respond to the liveness and readiness probes at
+// {{ .LivenessPath }} and {{ .ReadinessPath }}. The handlers run the checks registered with service.Health and
+// respond with their aggregated status, see goa.HealthChecker.
+func MountHealth(service *goa.Service) {
+	service.Health.Paths = []string{ {{- printf "%q" .LivenessPath }}, {{ printf "%q" .ReadinessPath -}} }
+	ctrl := service.NewController("HealthController")
+	service.Mux.Handle("GET", {{ printf "%q" .LivenessPath }}, ctrl.MuxHandler("Liveness", service.Health.LivenessHandler(), nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: {{ printf "%q" .LivenessPath }}, Controller: ctrl.Name, Action: "Liveness"})
+	service.LogInfo("mount", "ctrl", "Health", "action", "Liveness", "route", {{ printf "%q" (printf "GET %s" .LivenessPath) }})
+	service.Mux.Handle("GET", {{ printf "%q" .ReadinessPath }}, ctrl.MuxHandler("Readiness", service.Health.ReadinessHandler(), nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: {{ printf "%q" .ReadinessPath }}, Controller: ctrl.Name, Action: "Readiness"})
+	service.LogInfo("mount", "ctrl", "Health", "action", "Readiness", "route", {{ printf "%q" (printf "GET %s" .ReadinessPath) }})
+}
+`
+
+	// protobufT generates the methods implementing the protobuf.Marshaler and
+	// protobuf.Unmarshaler interfaces of the encoding/protobuf package. The media types and the
+	// messages generated by "goagen proto" share the same JSON representation which the methods
+	// use to convert one into the other.
+	// template input: []*ProtobufTemplateData
+	protobufT = `{{ range . }}// MarshalProto returns the wire encoding of the {{ .Message }} message describing mt.
+func (mt *{{ .Type }}) MarshalProto() ([]byte, error) {
+	return marshalProto(mt, &{{ .Message }}{})
+}
+
+// UnmarshalProto initializes mt from the wire encoding of a {{ .Message }} message.
+func (mt *{{ .Type }}) UnmarshalProto(b []byte) error {
+	return unmarshalProto(b, &{{ .Message }}{}, mt)
+}
+
+{{ end }}// marshalProto initializes m from the JSON representation of v and returns its wire encoding.
+func marshalProto(v interface{}, m proto.Message) ([]byte, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(js, m); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(m)
+}
+
+// unmarshalProto decodes the wire encoding b into m and initializes v from the JSON
+// representation of m.
+func unmarshalProto(b []byte, m proto.Message, v interface{}) error {
+	if err := proto.Unmarshal(b, m); err != nil {
+		return err
+	}
+	js, err := protojson.Marshal(m)
+	if err != nil {
+		return err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(js, &raw); err != nil {
+		return err
+	}
+	if js, err = json.Marshal(unquoteInt64(raw, m.ProtoReflect().Descriptor())); err != nil {
+		return err
+	}
+	return json.Unmarshal(js, v)
+}
+
+// unquoteInt64 converts the 64-bit integers that protojson renders as JSON strings back into
+// numbers in the decoded JSON representation of a message with descriptor md.
+func unquoteInt64(v interface{}, md protoreflect.MessageDescriptor) interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok || md.FullName().Parent() == "google.protobuf" {
+		return v
+	}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		val, ok := obj[fd.JSONName()]
+		if !ok {
+			continue
+		}
+		switch {
+		case fd.IsList():
+			if elems, ok := val.([]interface{}); ok {
+				for j, e := range elems {
+					elems[j] = unquoteField(e, fd)
+				}
+			}
+		case fd.IsMap():
+			if elems, ok := val.(map[string]interface{}); ok {
+				for k, e := range elems {
+					elems[k] = unquoteField(e, fd.MapValue())
+				}
+			}
+		default:
+			obj[fd.JSONName()] = unquoteField(val, fd)
+		}
+	}
+	return obj
+}
+
+// unquoteField converts the JSON representation v of a single value of the field fd.
+func unquoteField(v interface{}, fd protoreflect.FieldDescriptor) interface{} {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if s, ok := v.(string); ok {
+			return json.Number(s)
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return unquoteInt64(v, fd.Message())
+	}
+	return v
+}
+`
+
+	// swaggerT generates the Swagger specification constant and the function mounting the handlers
+	// serving it and the documentation pages.
+	// template input: string
+	swaggerT = `// SwaggerJSON is the Swagger specification of the API in JSON.
+const SwaggerJSON = {{ printf "%q" . }}
+
+// swaggerUIPage renders the Swagger UI page documenting the spec served at /swagger.json.
+const swaggerUIPage = ` + "`" + `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>API Documentation</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3/swagger-ui.css">
+  </head>
+  <body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-bundle.js"></script>
+    <script>
+      window.onload = function() {
+        SwaggerUIBundle({url: "/swagger.json", dom_id: "#swagger-ui"});
+      };
+    </script>
+  </body>
+</html>
+` + "`" + `
+
+// redocPage renders the ReDoc page documenting the spec served at /swagger.json.
+const redocPage = ` + "`" + `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>API Documentation</title>
+  </head>
+  <body>
+    <redoc spec-url="/swagger.json"></redoc>
+    <script src="https://unpkg.com/redoc@2/bundles/redoc.standalone.js"></script>
+  </body>
+</html>
+` + "`" + `
+
+// MountSwagger mounts the handlers serving the Swagger specification at /swagger.json and the
+// Swagger UI and ReDoc pages documenting it at /swagger and /redoc. The specification is generated
+// together with the application code so that it always describes the running service.
+func MountSwagger(service *goa.Service) {
+	ctrl := service.NewController("SwaggerController")
+	serve := func(contentType, body string) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.Header().Set("Content-Type", contentType)
+			rw.WriteHeader(http.StatusOK)
+			_, err := rw.Write([]byte(body))
+			return err
+		}
+	}
+	service.Mux.Handle("GET", "/swagger.json", ctrl.MuxHandler("Spec", serve("application/json", SwaggerJSON), nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/swagger.json", Controller: ctrl.Name, Action: "Spec"})
+	service.LogInfo("mount", "ctrl", "Swagger", "action", "Spec", "route", "GET /swagger.json")
+	service.Mux.Handle("GET", "/swagger", ctrl.MuxHandler("UI", serve("text/html; charset=utf-8", swaggerUIPage), nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/swagger", Controller: ctrl.Name, Action: "UI"})
+	service.LogInfo("mount", "ctrl", "Swagger", "action", "UI", "route", "GET /swagger")
+	service.Mux.Handle("GET", "/redoc", ctrl.MuxHandler("ReDoc", serve("text/html; charset=utf-8", redocPage), nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/redoc", Controller: ctrl.Name, Action: "ReDoc"})
+	service.LogInfo("mount", "ctrl", "Swagger", "action", "ReDoc", "route", "GET /redoc")
+}
 `
 )
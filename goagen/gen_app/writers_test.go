@@ -3,8 +3,10 @@ package genapp_test
 import (
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/design/apidsl"
 	"github.com/goadesign/goa/dslengine"
 	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/gen_app"
@@ -12,6 +14,10 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// designRoot is the API definition registered with the DSL engine, the specs running the DSL
+// restore it as other specs replace design.Design.
+var designRoot = design.Design
+
 var _ = Describe("ContextsWriter", func() {
 	var writer *genapp.ContextsWriter
 	var filename string
@@ -85,6 +91,112 @@ var _ = Describe("ContextsWriter", func() {
 					Ω(written).Should(ContainSubstring(emptyContext))
 					Ω(written).Should(ContainSubstring(emptyContextFactory))
 				})
+
+				It("writes the pooled contexts code", func() {
+					data.Pool = true
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(pooledContextFactory))
+					Ω(written).Should(ContainSubstring(contextPool))
+				})
+			})
+
+			Context("with an error response", func() {
+				var api *design.APIDefinition
+
+				BeforeEach(func() {
+					api = design.Design
+					design.Design = designRoot
+					dslengine.Reset()
+					apidsl.API("test", nil)
+					apidsl.Resource("bottles", func() {
+						apidsl.Action("list", func() {
+							apidsl.Routing(apidsl.GET("/"))
+							apidsl.Response(design.BadRequest, design.ErrorMedia)
+						})
+					})
+					Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+					responses = design.Design.Resources["bottles"].Actions["list"].Responses
+				})
+
+				AfterEach(func() {
+					design.Design = api
+				})
+
+				It("writes the error response helper", func() {
+					Ω(responses).Should(HaveKey("BadRequest"))
+					Ω(responses["BadRequest"].Type).Should(Equal(design.ErrorMedia))
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(errorResponseHelper))
+					Ω(written).ShouldNot(ContainSubstring("BadRequest(r *goa.ErrorResponse)"))
+				})
+			})
+
+			Context("with a streamed response", func() {
+				var api *design.APIDefinition
+
+				BeforeEach(func() {
+					api = design.Design
+					bottle := &design.MediaTypeDefinition{
+						UserTypeDefinition: &design.UserTypeDefinition{
+							TypeName: "Bottle",
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{"id": {Type: design.Integer}},
+							},
+						},
+						Identifier: "application/vnd.bottle",
+					}
+					bottle.Views = map[string]*design.ViewDefinition{
+						"default": {AttributeDefinition: bottle.AttributeDefinition, Name: "default", Parent: bottle},
+					}
+					design.Design = &design.APIDefinition{
+						MediaTypes: map[string]*design.MediaTypeDefinition{"application/vnd.bottle": bottle},
+					}
+					design.GeneratedMediaTypes = make(design.MediaTypeRoot)
+					responses = map[string]*design.ResponseDefinition{
+						"OK": {Name: "OK", Status: 200, MediaType: "application/vnd.bottle"},
+					}
+				})
+
+				AfterEach(func() {
+					design.Design = api
+				})
+
+				It("writes the stream helpers", func() {
+					data.Stream = &design.StreamDefinition{BufferSize: 64, HighWatermark: 48, Policy: "drop_oldest"}
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(streamHelpers))
+				})
+
+				It("writes the stream helpers of NDJSON streams", func() {
+					data.Stream = &design.StreamDefinition{BufferSize: 64, HighWatermark: 48, Policy: "drop_oldest", NDJSON: true}
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`ctx.ResponseData.Header().Set("Content-Type", "application/x-ndjson")`))
+					Ω(written).Should(ContainSubstring("		Policy:        goa.StreamDropOldest,\n		NDJSON:        true,\n	})}"))
+				})
+
+				It("does not write stream helpers for actions that do not stream", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(string(b)).ShouldNot(ContainSubstring("Stream"))
+				})
 			})
 
 			Context("with an integer param", func() {
@@ -231,6 +343,75 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with an integer header", func() {
+				BeforeEach(func() {
+					headers = &design.AttributeDefinition{
+						Type: design.Object{
+							"X-Count": &design.AttributeDefinition{Type: design.Integer},
+						},
+					}
+				})
+
+				It("writes the typed header contexts code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(intHeaderContext))
+					Ω(written).Should(ContainSubstring(intHeaderContextFactory))
+				})
+			})
+
+			Context("with a required integer array header", func() {
+				BeforeEach(func() {
+					i := &design.AttributeDefinition{Type: design.Integer}
+					headers = &design.AttributeDefinition{
+						Type: design.Object{
+							"X-Ids": &design.AttributeDefinition{Type: &design.Array{ElemType: i}},
+						},
+						Validation: &dslengine.ValidationDefinition{Required: []string{"X-Ids"}},
+					}
+				})
+
+				It("parses repeated and comma separated header values", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(intArrayHeaderContextFactory))
+				})
+			})
+
+			Context("with a header and a param with the same Go name", func() {
+				BeforeEach(func() {
+					params = &design.AttributeDefinition{
+						Type: design.Object{
+							"x_count": &design.AttributeDefinition{Type: design.Integer},
+						},
+					}
+					headers = &design.AttributeDefinition{
+						Type: design.Object{
+							"X-Count": &design.AttributeDefinition{Type: design.Integer},
+						},
+					}
+				})
+
+				It("suffixes the header field", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("	XCount *int\n	XCountHeader *int\n"))
+					Ω(written).Should(ContainSubstring("rctx.XCountHeader = tmp"))
+					Ω(written).Should(ContainSubstring("rctx.XCount = tmp"))
+				})
+			})
+
 			Context("with an param using a reserved keyword as name", func() {
 				BeforeEach(func() {
 					intParam := &design.AttributeDefinition{Type: design.Integer}
@@ -301,6 +482,33 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with a streamed payload", func() {
+				BeforeEach(func() {
+					elem := &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{
+							Type:       design.Object{"name": &design.AttributeDefinition{Type: design.String}},
+							Validation: &dslengine.ValidationDefinition{Required: []string{"name"}},
+						},
+						TypeName: "BottlePayload",
+					}
+					payload = &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{Type: &design.Array{ElemType: &design.AttributeDefinition{Type: elem}}},
+						TypeName:            "ListBottlePayload",
+					}
+				})
+
+				It("writes the payload stream", func() {
+					data.PayloadStream = "ListBottlePayloadStream"
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("	Payload *ListBottlePayloadStream\n"))
+					Ω(written).Should(ContainSubstring(payloadStreamContext))
+				})
+			})
+
 			Context("with a object payload", func() {
 				BeforeEach(func() {
 					intParam := &design.AttributeDefinition{Type: design.Integer}
@@ -412,8 +620,9 @@ var _ = Describe("ControllersWriter", func() {
 				codegen.TempCount = 0
 				api := &design.APIDefinition{}
 				d := &genapp.ControllerTemplateData{
-					Resource: "Bottles",
-					Origins:  origins,
+					Resource:     "Bottles",
+					ResourceName: "bottles",
+					Origins:      origins,
 				}
 				as := make([]map[string]interface{}, len(actions))
 				for i, a := range actions {
@@ -477,6 +686,121 @@ var _ = Describe("ControllersWriter", func() {
 					Ω(written).Should(ContainSubstring(simpleController))
 					Ω(written).Should(ContainSubstring(simpleMount))
 				})
+
+				It("releases pooled contexts", func() {
+					data[0].Pool = true
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`		done := goa.MeasurePhase(ctx, goa.PhaseValidate)
+		rctx, err := NewListBottleContext(ctx, service)
+		done()
+		defer ReleaseListBottleContext(rctx)
+		if err != nil {`))
+					Ω(written).Should(ContainSubstring(`	initService(service)
+	service.PoolRequestData = true
+`))
+				})
+
+				It("mounts the HEAD routes", func() {
+					data[0].Actions[0]["HeadRoutes"] = data[0].Actions[0]["Routes"]
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(headMount))
+				})
+
+				It("mounts the alias routes of renamed actions", func() {
+					target := data[0].Actions[0]["Routes"].([]*design.RouteDefinition)[0]
+					data[0].Actions[0]["Aliases"] = []*design.AliasRouteDefinition{
+						{Route: &design.RouteDefinition{Verb: "GET", Path: "//accounts/:accountID/wines"}, Target: target},
+						{Route: &design.RouteDefinition{Verb: "GET", Path: "//accounts/:accountID/old_bottles"}, Target: target, Proxy: true},
+					}
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(aliasMount))
+				})
+
+				It("mounts with an adaptive concurrency limit", func() {
+					data[0].Actions[0]["QualifiedName"] = "bottles.list"
+					data[0].Actions[0]["Concurrency"] = &design.ConcurrencyDefinition{InitialLimit: 10, Latency: 50 * time.Millisecond}
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(concurrencyMount))
+				})
+
+				It("exempts the actions that skip compression", func() {
+					data[0].Actions[0]["SkipCompression"] = true
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(skipCompressionMount))
+				})
+
+				It("excludes the actions from the audit log", func() {
+					data[0].Actions[0]["AuditExclude"] = true
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(auditExcludeMount))
+				})
+
+				It("mounts with the action timeout", func() {
+					data[0].Actions[0]["Timeout"] = 5 * time.Second
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(timeoutMount))
+				})
+
+				It("wraps the handler with the named middleware", func() {
+					data[0].Actions[0]["Middleware"] = []string{"ratelimit", "authz"}
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(namedMiddlewareMount))
+				})
+
+				It("responds to the requests made to resources in maintenance mode", func() {
+					data[0].Maintenance = &design.MaintenanceDefinition{RetryAfter: 90 * time.Second}
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(maintenanceMount))
+					Ω(written).Should(ContainSubstring(maintenanceHandler))
+				})
+
+				It("calls the interceptor hooks of controllers that implement them", func() {
+					data[0].Interceptors = true
+					data[0].Actions[0]["Interceptor"] = "ListBottles"
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(interceptorController))
+					Ω(written).Should(ContainSubstring(interceptorMount))
+				})
 			})
 
 			Context("with actions that take a payload", func() {
@@ -544,6 +868,33 @@ var _ = Describe("ControllersWriter", func() {
 				})
 			})
 
+			Context("with actions that stream their payload", func() {
+				BeforeEach(func() {
+					actions = []string{"Import"}
+					verbs = []string{"POST"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ImportBottleContext"}
+					unmarshals = []string{"unmarshalImportBottlePayload"}
+					payloads = []*design.UserTypeDefinition{{
+						TypeName:            "ImportBottlePayload",
+						AttributeDefinition: &design.AttributeDefinition{Type: &design.Array{ElemType: &design.AttributeDefinition{Type: design.String}}},
+					}}
+				})
+
+				It("writes the payload stream unmarshal function", func() {
+					data[0].Actions[0]["PayloadStream"] = "ImportBottlePayloadStream"
+					data[0].Actions[0]["PayloadStreamMin"] = 1
+					data[0].Actions[0]["PayloadStreamMax"] = 1000
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("rctx.Payload = rawPayload.(*ImportBottlePayloadStream)"))
+					Ω(written).Should(ContainSubstring(payloadStreamUnmarshal))
+				})
+			})
+
 			Context("with multiple controllers", func() {
 				BeforeEach(func() {
 					actions = []string{"List", "Show"}
@@ -752,7 +1103,183 @@ var _ = Describe("HrefWriter", func() {
 	})
 })
 
+var _ = Describe("FeaturesWriter", func() {
+	var writer *genapp.FeaturesWriter
+	var workspace *codegen.Workspace
+	var filename string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("app")
+		Ω(err).ShouldNot(HaveOccurred())
+		src := pkg.CreateSourceFile("features.go")
+		filename = src.Abs()
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		writer, err = genapp.NewFeaturesWriter(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("with features", func() {
+		var features []*design.FeatureDefinition
+
+		BeforeEach(func() {
+			features = []*design.FeatureDefinition{
+				{Name: "new_checkout", Description: "Redesigned checkout flow", Variants: []string{"control", "treatment"}},
+				{Name: "dark_mode"},
+			}
+		})
+
+		It("writes the feature flag constants and accessors", func() {
+			imports := []*codegen.ImportSpec{
+				codegen.SimpleImport("golang.org/x/net/context"),
+				codegen.SimpleImport("github.com/goadesign/goa/middleware"),
+			}
+			writer.WriteHeader("Features", "app", imports)
+			err := writer.Execute(features)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(writer.FormatCode()).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filename)
+			Ω(err).ShouldNot(HaveOccurred())
+			written := string(b)
+			Ω(written).Should(ContainSubstring(featuresConsts))
+			Ω(written).Should(ContainSubstring(featuresAccessors))
+		})
+	})
+})
+
+var _ = Describe("RetentionWriter", func() {
+	var writer *genapp.RetentionWriter
+	var workspace *codegen.Workspace
+	var filename string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("app")
+		Ω(err).ShouldNot(HaveOccurred())
+		src := pkg.CreateSourceFile("retention.go")
+		filename = src.Abs()
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		writer, err = genapp.NewRetentionWriter(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("with media types defining a retention period", func() {
+		var mts []*design.MediaTypeDefinition
+
+		BeforeEach(func() {
+			newMT := func(identifier, retention string) *design.MediaTypeDefinition {
+				return &design.MediaTypeDefinition{
+					UserTypeDefinition: &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{
+							Type:     design.Object{},
+							Metadata: dslengine.MetadataDefinition{"retention": {retention}},
+						},
+					},
+					Identifier: identifier,
+				}
+			}
+			mts = []*design.MediaTypeDefinition{
+				newMT("application/vnd.order+json", "7y"),
+				newMT("application/vnd.user+json", "90d"),
+			}
+		})
+
+		It("writes the retention periods and the handler mount function", func() {
+			imports := []*codegen.ImportSpec{
+				codegen.SimpleImport("net/http"),
+				codegen.SimpleImport("golang.org/x/net/context"),
+				codegen.SimpleImport("github.com/goadesign/goa"),
+			}
+			writer.WriteHeader("Retention", "app", imports)
+			err := writer.Execute(mts)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(writer.FormatCode()).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filename)
+			Ω(err).ShouldNot(HaveOccurred())
+			written := string(b)
+			Ω(written).Should(ContainSubstring(retentionPeriods))
+			Ω(written).Should(ContainSubstring(retentionMount))
+		})
+	})
+})
+
+var _ = Describe("HealthWriter", func() {
+	var writer *genapp.HealthWriter
+	var workspace *codegen.Workspace
+	var filename string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("app")
+		Ω(err).ShouldNot(HaveOccurred())
+		src := pkg.CreateSourceFile("health.go")
+		filename = src.Abs()
+		writer, err = genapp.NewHealthWriter(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	It("writes the health check endpoints mount function", func() {
+		writer.WriteHeader("Health", "app", []*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa")})
+		err := writer.Execute(&design.HealthDefinition{LivenessPath: "/healthz", ReadinessPath: "/readyz"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(writer.FormatCode()).ShouldNot(HaveOccurred())
+		b, err := ioutil.ReadFile(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(b)).Should(ContainSubstring(healthMount))
+	})
+})
+
 const (
+	healthMount = `func MountHealth(service *goa.Service) {
+	service.Health.Paths = []string{"/healthz", "/readyz"}
+	ctrl := service.NewController("HealthController")
+	service.Mux.Handle("GET", "/healthz", ctrl.MuxHandler("Liveness", service.Health.LivenessHandler(), nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/healthz", Controller: ctrl.Name, Action: "Liveness"})
+	service.LogInfo("mount", "ctrl", "Health", "action", "Liveness", "route", "GET /healthz")
+	service.Mux.Handle("GET", "/readyz", ctrl.MuxHandler("Readiness", service.Health.ReadinessHandler(), nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/readyz", Controller: ctrl.Name, Action: "Readiness"})
+	service.LogInfo("mount", "ctrl", "Health", "action", "Readiness", "route", "GET /readyz")
+}`
+
+	retentionPeriods = `var RetentionPeriods = map[string]string{
+	"application/vnd.order+json": "7y",
+	"application/vnd.user+json":  "90d",
+}`
+
+	retentionMount = `func MountRetentionHandler(service *goa.Service, path string) {
+	ctrl := service.NewController("RetentionController")
+	h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		return service.Send(ctx, 200, RetentionPeriods)
+	}
+	service.Mux.Handle("GET", path, ctrl.MuxHandler("Show", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: path, Controller: ctrl.Name, Action: "Show"})
+	service.LogInfo("mount", "ctrl", "Retention", "action", "Show", "route", "GET "+path)
+}`
+
 	emptyContext = `
 type ListBottleContext struct {
 	context.Context
@@ -769,6 +1296,33 @@ func NewListBottleContext(ctx context.Context, service *goa.Service) (*ListBottl
 	rctx := ListBottleContext{Context: ctx, ResponseData: goa.ContextResponse(ctx), RequestData: req, Service: service}
 	return &rctx, err
 }
+`
+
+	pooledContextFactory = `
+func NewListBottleContext(ctx context.Context, service *goa.Service) (*ListBottleContext, error) {
+	var err error
+	req := goa.ContextRequest(ctx)
+	rctx := listBottleContextPool.Get().(*ListBottleContext)
+	rctx.Context, rctx.ResponseData, rctx.RequestData, rctx.Service = ctx, goa.ContextResponse(ctx), req, service
+	return rctx, err
+}
+`
+
+	contextPool = `
+// listBottleContextPool holds the ListBottleContext instances available for reuse.
+var listBottleContextPool = sync.Pool{New: func() interface{} { return new(ListBottleContext) }}
+
+// Reset clears all the context fields so that it can be reused.
+func (ctx *ListBottleContext) Reset() {
+	*ctx = ListBottleContext{}
+}
+
+// ReleaseListBottleContext resets the context and puts it back into the pool.
+// The context must not be used once released.
+func ReleaseListBottleContext(ctx *ListBottleContext) {
+	ctx.Reset()
+	listBottleContextPool.Put(ctx)
+}
 `
 
 	intContext = `
@@ -908,6 +1462,64 @@ func NewListBottleContext(ctx context.Context, service *goa.Service) (*ListBottl
 	}
 	return &rctx, err
 }
+`
+
+	intHeaderContext = `
+type ListBottleContext struct {
+	context.Context
+	*goa.ResponseData
+	*goa.RequestData
+	Service *goa.Service
+	XCount *int
+}
+`
+
+	intHeaderContextFactory = `
+func NewListBottleContext(ctx context.Context, service *goa.Service) (*ListBottleContext, error) {
+	var err error
+	req := goa.ContextRequest(ctx)
+	rctx := ListBottleContext{Context: ctx, ResponseData: goa.ContextResponse(ctx), RequestData: req, Service: service}
+	headerXCount := req.Header["X-Count"]
+	if len(headerXCount) > 0 {
+		rawXCount := headerXCount[0]
+		if xCount, err2 := strconv.Atoi(rawXCount); err2 == nil {
+			tmp2 := xCount
+			tmp1 := &tmp2
+			rctx.XCount = tmp1
+		} else {
+			err = goa.MergeErrors(err, goa.InvalidParamTypeError("X-Count", rawXCount, "integer"))
+		}
+	}
+	return &rctx, err
+}
+`
+
+	intArrayHeaderContextFactory = `
+func NewListBottleContext(ctx context.Context, service *goa.Service) (*ListBottleContext, error) {
+	var err error
+	req := goa.ContextRequest(ctx)
+	rctx := ListBottleContext{Context: ctx, ResponseData: goa.ContextResponse(ctx), RequestData: req, Service: service}
+	headerXIds := req.Header["X-Ids"]
+	if len(headerXIds) == 0 {
+		err = goa.MergeErrors(err, goa.MissingHeaderError("X-Ids"))
+	} else {
+		var headers []int
+		for _, rawXIds := range headerXIds {
+			elemsXIds := strings.Split(rawXIds, ",")
+			elemsXIds2 := make([]int, len(elemsXIds))
+			for i, rawElem := range elemsXIds {
+				if elem, err2 := strconv.Atoi(rawElem); err2 == nil {
+					elemsXIds2[i] = elem
+				} else {
+					err = goa.MergeErrors(err, goa.InvalidParamTypeError("X-Ids[" + strconv.Itoa(i) + "]", rawElem, "integer"))
+				}
+			}
+			headers = elemsXIds2
+			rctx.XIds = append(rctx.XIds, headers...)
+		}
+	}
+	return &rctx, err
+}
 `
 
 	intArrayContext = `
@@ -935,7 +1547,7 @@ func NewListBottleContext(ctx context.Context, service *goa.Service) (*ListBottl
 				if elem, err2 := strconv.Atoi(rawElem); err2 == nil {
 					elemsParam2[i] = elem
 				} else {
-					err = goa.MergeErrors(err, goa.InvalidParamTypeError("elem", rawElem, "integer"))
+					err = goa.MergeErrors(err, goa.InvalidParamTypeError("param[" + strconv.Itoa(i) + "]", rawElem, "integer"))
 				}
 			}
 			params = elemsParam2
@@ -1032,6 +1644,35 @@ type ListBottleContext struct {
 	Service *goa.Service
 	Payload *ListBottlePayload
 }
+`
+
+	payloadStreamContext = `
+// ListBottlePayloadStream decodes the elements of the bottles list action payload one
+// at a time.
+type ListBottlePayloadStream struct {
+	*goa.PayloadStream
+}
+
+// Next decodes and validates the next element of the payload. It returns io.EOF once all the
+// elements have been decoded.
+func (s *ListBottlePayloadStream) Next() (*BottlePayload, error) {
+	var elem bottlePayload
+	if err := s.PayloadStream.Next(&elem); err != nil {
+		return nil, err
+	}
+	if err := elem.Validate(); err != nil {
+		return nil, err
+	}
+	return elem.Publicize(), nil
+}
+`
+
+	payloadStreamUnmarshal = `
+// unmarshalImportBottlePayload streams the request body into the context request data Payload field.
+func unmarshalImportBottlePayload(ctx context.Context, service *goa.Service, req *http.Request) error {
+	goa.ContextRequest(ctx).Payload = &ImportBottlePayloadStream{goa.NewPayloadStream(req, 1, 1000)}
+	return nil
+}
 `
 
 	payloadObjUnmarshal = `
@@ -1040,7 +1681,10 @@ func unmarshalListBottlePayload(ctx context.Context, service *goa.Service, req *
 	if err := service.DecodeRequest(req, payload); err != nil {
 		return err
 	}
-	if err := payload.Validate(); err != nil {
+	done := goa.MeasurePhase(ctx, goa.PhaseValidate)
+	err := payload.Validate()
+	done()
+	if err != nil {
 		return err
 	}
 	goa.ContextRequest(ctx).Payload = payload.Publicize()
@@ -1115,15 +1759,51 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 	var h goa.Handler
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		done := goa.MeasurePhase(ctx, goa.PhaseValidate)
 		rctx, err := NewListBottleContext(ctx, service)
+		done()
 		if err != nil {
 			return err
 		}
+		defer goa.MeasurePhase(ctx, goa.PhaseController)()
 		return ctrl.List(rctx)
 	}
 	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Resource: "bottles", Controller: goa.ControllerName(ctrl), Action: "List"})
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
 }
+`
+
+	streamHelpers = `// ListBottleOKStream streams the OK responses of the list action.
+type ListBottleOKStream struct {
+	*goa.Stream
+}
+
+// Send queues r for sending, see goa.Stream for the flow control semantics.
+func (s *ListBottleOKStream) Send(r *Bottle) error {
+	return s.Stream.Send(r)
+}
+
+// OKStream writes the response headers with status code 200 and returns the
+// stream used to send the response messages. The stream must be closed once all messages are sent.
+func (ctx *ListBottleContext) OKStream() *ListBottleOKStream {
+	ctx.ResponseData.Header().Set("Content-Type", "application/vnd.bottle")
+	return &ListBottleOKStream{ctx.Service.NewStream(ctx.Context, 200, &goa.StreamOptions{
+		Name:          "bottles.list",
+		BufferSize:    64,
+		HighWatermark: 48,
+		Policy:        goa.StreamDropOldest,
+	})}
+}
+`
+
+	errorResponseHelper = `// BadRequest sends a HTTP response with status code 400.
+// The response error records the names of the resource and action, the request trace ID and the
+// details of the validation errors.
+func (ctx *ListBottleContext) BadRequest(r error) error {
+	ctx.ResponseData.Header().Set("Content-Type", "application/vnd.api.error+json")
+	return ctx.Service.Send(ctx.Context, 400, goa.ActionError(r, 400, "bottles", "list", middleware.ContextRequestID(ctx.Context)))
+}
 `
 
 	simpleMount = `func MountBottlesController(service *goa.Service, ctrl BottlesController) {
@@ -1131,14 +1811,96 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 	var h goa.Handler
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		done := goa.MeasurePhase(ctx, goa.PhaseValidate)
 		rctx, err := NewListBottleContext(ctx, service)
+		done()
 		if err != nil {
 			return err
 		}
+		defer goa.MeasurePhase(ctx, goa.PhaseController)()
 		return ctrl.List(rctx)
 	}
 	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Resource: "bottles", Controller: goa.ControllerName(ctrl), Action: "List"})
+	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
+}
+`
+
+	headMount = `	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Resource: "bottles", Controller: goa.ControllerName(ctrl), Action: "List"})
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
+	service.Mux.Handle("HEAD", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", goa.HeadHandler(h), nil))
+	service.AddRoute(goa.Route{Method: "HEAD", Path: "/accounts/:accountID/bottles", Resource: "bottles", Controller: goa.ControllerName(ctrl), Action: "List"})
+	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "HEAD /accounts/:accountID/bottles")
+}
+`
+
+	interceptorController = `type ListBottlesInterceptor interface {
+	BeforeListBottles(*ListBottleContext) error
+	AfterListBottles(*ListBottleContext, error)
+}`
+
+	interceptorMount = `		if ic, ok := ctrl.(ListBottlesInterceptor); ok {
+			if err := ic.BeforeListBottles(rctx); err != nil {
+				return err
+			}
+			err = ctrl.List(rctx)
+			ic.AfterListBottles(rctx, err)
+			return err
+		}
+		return ctrl.List(rctx)`
+
+	concurrencyMount = `	h = middleware.AdaptiveConcurrency(middleware.NewConcurrencyLimiter(&middleware.ConcurrencyOptions{
+		Name:         "bottles.list",
+		InitialLimit: 10,
+		Latency:      50000000, // 50ms
+	}))(h)
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`
+
+	skipCompressionMount = `	h = middleware.SkipCompression(h)
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`
+
+	auditExcludeMount = `	h = middleware.AuditHandler(false, h)
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`
+
+	timeoutMount = `		return ctrl.List(rctx)
+	}
+	h = goa.TimeoutHandler(5000000000, h) // 5s
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`
+
+	namedMiddlewareMount = `		return ctrl.List(rctx)
+	}
+	h = service.NamedHandler("ratelimit", h)
+	h = service.NamedHandler("authz", h)
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`
+
+	maintenanceMount = `	h = handleBottlesMaintenance(service, h)
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`
+
+	maintenanceHandler = `func handleBottlesMaintenance(service *goa.Service, h goa.Handler) goa.Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		retryAfter, ok := service.InMaintenance("bottles", 90000000000) // 1m30s
+		if !ok {
+			return h(ctx, rw, req)
+		}
+		rw.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		rw.Header().Set("Content-Type", "application/vnd.goa.maintenance+json")
+		return service.Send(ctx, 503, &Maintenance{
+			Message:    "bottles is under maintenance",
+			RetryAfter: retryAfter,
+		})
+	}
+}`
+
+	aliasMount = `	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Resource: "bottles", Controller: goa.ControllerName(ctrl), Action: "List"})
+	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
+	service.Mux.Handle("GET", "/accounts/:accountID/wines", goa.PermanentRedirectHandler("/accounts/:accountID/bottles"))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/accounts/:accountID/wines", Resource: "bottles", Controller: goa.ControllerName(ctrl), Action: "List", Redirect: "/accounts/:accountID/bottles"})
+	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/wines", "renamed", "/accounts/:accountID/bottles")
+	service.Mux.Handle("GET", "/accounts/:accountID/old_bottles", ctrl.MuxHandler("List", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/accounts/:accountID/old_bottles", Resource: "bottles", Controller: goa.ControllerName(ctrl), Action: "List"})
+	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/old_bottles", "renamed", "/accounts/:accountID/bottles")
 }
 `
 
@@ -1155,23 +1917,31 @@ type BottlesController interface {
 	var h goa.Handler
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		done := goa.MeasurePhase(ctx, goa.PhaseValidate)
 		rctx, err := NewListBottleContext(ctx, service)
+		done()
 		if err != nil {
 			return err
 		}
+		defer goa.MeasurePhase(ctx, goa.PhaseController)()
 		return ctrl.List(rctx)
 	}
 	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Resource: "bottles", Controller: goa.ControllerName(ctrl), Action: "List"})
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		done := goa.MeasurePhase(ctx, goa.PhaseValidate)
 		rctx, err := NewShowBottleContext(ctx, service)
+		done()
 		if err != nil {
 			return err
 		}
+		defer goa.MeasurePhase(ctx, goa.PhaseController)()
 		return ctrl.Show(rctx)
 	}
 	service.Mux.Handle("GET", "/accounts/:accountID/bottles/:id", ctrl.MuxHandler("Show", h, nil))
+	service.AddRoute(goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles/:id", Resource: "bottles", Controller: goa.ControllerName(ctrl), Action: "Show"})
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "Show", "route", "GET /accounts/:accountID/bottles/:id")
 }
 `
@@ -1180,4 +1950,31 @@ type BottlesController interface {
 	return fmt.Sprintf("/bottles/%v", id)
 }
 `
+
+	featuresConsts = `const (
+	// FeatureNewCheckout is the name of the "new_checkout" feature flag.
+	// Redesigned checkout flow
+	FeatureNewCheckout = "new_checkout"
+	// FeatureNewCheckoutControl is the "control" variant of the NewCheckout feature.
+	FeatureNewCheckoutControl = "control"
+	// FeatureNewCheckoutTreatment is the "treatment" variant of the NewCheckout feature.
+	FeatureNewCheckoutTreatment = "treatment"
+	// FeatureDarkMode is the name of the "dark_mode" feature flag.
+	FeatureDarkMode = "dark_mode"
+)`
+
+	featuresAccessors = `// NewCheckoutVariant returns the variant of the "new_checkout" feature flag set on the request, the
+// empty string if the flag is not set or if the variant is not one of the declared variants.
+func (f *Features) NewCheckoutVariant() string {
+	switch v := f.flags.Variant(FeatureNewCheckout); v {
+	case FeatureNewCheckoutControl, FeatureNewCheckoutTreatment:
+		return v
+	}
+	return ""
+}
+
+// DarkMode returns true if the "dark_mode" feature flag is set on the request.
+func (f *Features) DarkMode() bool {
+	return f.flags.Enabled(FeatureDarkMode)
+}`
 )
@@ -0,0 +1,386 @@
+package genasyncapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_schema"
+)
+
+type (
+	// AsyncAPI represents an AsyncAPI 2.x document.
+	// See https://www.asyncapi.com/docs/reference/specification/v2.6.0
+	AsyncAPI struct {
+		AsyncAPI           string              `json:"asyncapi"`
+		Info               *Info               `json:"info"`
+		Servers            map[string]*Server  `json:"servers,omitempty"`
+		DefaultContentType string              `json:"defaultContentType,omitempty"`
+		Channels           map[string]*Channel `json:"channels"`
+		Components         *Components         `json:"components,omitempty"`
+	}
+
+	// Info provides metadata about the API.
+	Info struct {
+		Title          string                    `json:"title"`
+		Version        string                    `json:"version"`
+		Description    string                    `json:"description,omitempty"`
+		TermsOfService string                    `json:"termsOfService,omitempty"`
+		Contact        *design.ContactDefinition `json:"contact,omitempty"`
+		License        *design.LicenseDefinition `json:"license,omitempty"`
+	}
+
+	// Server describes a message broker, here the API host reached with a given protocol.
+	Server struct {
+		URL      string `json:"url"`
+		Protocol string `json:"protocol"`
+	}
+
+	// Channel describes the messages exchanged on a path of the API.
+	Channel struct {
+		// Description of the channel, the action description.
+		Description string `json:"description,omitempty"`
+		// Servers lists the names of the servers the channel is available on.
+		Servers []string `json:"servers,omitempty"`
+		// Subscribe describes the messages sent by the API to the clients.
+		Subscribe *Operation `json:"subscribe,omitempty"`
+		// Publish describes the messages sent by the clients to the API.
+		Publish *Operation `json:"publish,omitempty"`
+		// Parameters describes the path parameters of the channel address.
+		Parameters map[string]*Parameter `json:"parameters,omitempty"`
+		// Bindings holds the protocol specific channel information.
+		Bindings *ChannelBindings `json:"bindings,omitempty"`
+	}
+
+	// Operation describes a message flow of a channel.
+	Operation struct {
+		OperationID string             `json:"operationId,omitempty"`
+		Summary     string             `json:"summary,omitempty"`
+		Description string             `json:"description,omitempty"`
+		Bindings    *OperationBindings `json:"bindings,omitempty"`
+		Message     *Reference         `json:"message,omitempty"`
+	}
+
+	// Parameter describes a parameter of a channel address.
+	Parameter struct {
+		Description string                `json:"description,omitempty"`
+		Schema      *genschema.JSONSchema `json:"schema,omitempty"`
+	}
+
+	// Message describes a message exchanged on a channel.
+	Message struct {
+		Name        string                `json:"name,omitempty"`
+		Title       string                `json:"title,omitempty"`
+		Summary     string                `json:"summary,omitempty"`
+		ContentType string                `json:"contentType,omitempty"`
+		Payload     *genschema.JSONSchema `json:"payload,omitempty"`
+	}
+
+	// Components holds the messages and schemas referenced by the channels.
+	Components struct {
+		Messages map[string]*Message              `json:"messages,omitempty"`
+		Schemas  map[string]*genschema.JSONSchema `json:"schemas,omitempty"`
+	}
+
+	// Reference is a JSON reference to a component.
+	Reference struct {
+		Ref string `json:"$ref"`
+	}
+
+	// ChannelBindings holds the protocol specific information of a channel.
+	ChannelBindings struct {
+		WS *WebSocketChannelBinding `json:"ws,omitempty"`
+	}
+
+	// WebSocketChannelBinding describes the handshake request of a websocket channel.
+	WebSocketChannelBinding struct {
+		Method         string                `json:"method,omitempty"`
+		Query          *genschema.JSONSchema `json:"query,omitempty"`
+		Headers        *genschema.JSONSchema `json:"headers,omitempty"`
+		BindingVersion string                `json:"bindingVersion"`
+	}
+
+	// OperationBindings holds the protocol specific information of an operation.
+	OperationBindings struct {
+		HTTP *HTTPOperationBinding `json:"http,omitempty"`
+	}
+
+	// HTTPOperationBinding describes the request that opens a server-sent events stream.
+	HTTPOperationBinding struct {
+		Type           string                `json:"type"`
+		Method         string                `json:"method,omitempty"`
+		Query          *genschema.JSONSchema `json:"query,omitempty"`
+		BindingVersion string                `json:"bindingVersion"`
+	}
+)
+
+const (
+	// Version is the version of the AsyncAPI specification implemented by the documents.
+	Version = "2.6.0"
+
+	// bindingVersion is the version of the websocket and HTTP bindings.
+	bindingVersion = "0.1.0"
+)
+
+// New creates an AsyncAPI document from an API definition. The document describes the actions
+// that use websockets or stream their responses with server-sent events, the other actions are
+// described by the Swagger specification.
+func New(api *design.APIDefinition) (*AsyncAPI, error) {
+	if api == nil {
+		return nil, nil
+	}
+	title := api.Title
+	if title == "" {
+		title = api.Name
+	}
+	s := &AsyncAPI{
+		AsyncAPI: Version,
+		Info: &Info{
+			Title:          title,
+			Version:        api.Version,
+			Description:    api.Description,
+			TermsOfService: api.TermsOfService,
+			Contact:        api.Contact,
+			License:        api.License,
+		},
+		DefaultContentType: "application/json",
+		Channels:           make(map[string]*Channel),
+		Components:         &Components{Messages: make(map[string]*Message)},
+	}
+	err := api.IterateResources(func(res *design.ResourceDefinition) error {
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			if !a.WebSocket() && a.Stream() == nil {
+				return nil
+			}
+			for i, r := range a.Routes {
+				if err := buildChannel(s, api, r, i); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(genschema.Definitions) > 0 {
+		s.Components.Schemas = make(map[string]*genschema.JSONSchema)
+		for n, d := range genschema.Definitions {
+			// AsyncAPI schemas are not hyper schemas
+			d.Media = nil
+			d.Links = nil
+			rewriteRefs(d)
+			s.Components.Schemas[n] = d
+		}
+	}
+	return s, nil
+}
+
+// buildChannel adds the channel corresponding to the given route of a streaming action.
+func buildChannel(s *AsyncAPI, api *design.APIDefinition, route *design.RouteDefinition, index int) error {
+	a := route.Parent
+	address := design.WildcardRegex.ReplaceAllStringFunc(
+		route.FullPath(),
+		func(w string) string {
+			return fmt.Sprintf("/{%s}", w[2:])
+		},
+	)
+	if address == "" {
+		address = "/"
+	}
+	if _, ok := s.Channels[address]; ok {
+		return fmt.Errorf("asyncapi: action %s of resource %s uses channel %s already defined by another action",
+			a.Name, a.Parent.Name, address)
+	}
+	operationID := codegen.Goify(a.Name+"_"+a.Parent.Name, false)
+	if index > 0 {
+		operationID = fmt.Sprintf("%s%d", operationID, index)
+	}
+	ch := &Channel{Description: a.Description}
+
+	var query *genschema.JSONSchema
+	pathParams := route.Params()
+	if params := a.AllParams(); params != nil {
+		obj := params.Type.ToObject()
+		for _, n := range pathParams {
+			if at, ok := obj[n]; ok {
+				if ch.Parameters == nil {
+					ch.Parameters = make(map[string]*Parameter)
+				}
+				ch.Parameters[n] = &Parameter{
+					Description: at.Description,
+					Schema:      schema(api, at),
+				}
+			}
+		}
+		query = querySchema(api, params, pathParams)
+	}
+
+	var protocols []string
+	if a.WebSocket() {
+		protocols = a.EffectiveSchemes()
+		binding := &WebSocketChannelBinding{
+			Method:         "GET",
+			Query:          query,
+			BindingVersion: bindingVersion,
+		}
+		if a.Headers != nil {
+			binding.Headers = schema(api, a.Headers)
+		}
+		ch.Bindings = &ChannelBindings{WS: binding}
+		if a.Payload != nil {
+			name := codegen.Goify(a.Name, true) + codegen.Goify(a.Parent.Name, true) + "Message"
+			s.Components.Messages[name] = &Message{
+				Name:        name,
+				Title:       a.Payload.TypeName,
+				Summary:     a.Payload.Description,
+				ContentType: "application/json",
+				Payload:     schema(api, &design.AttributeDefinition{Type: a.Payload}),
+			}
+			ch.Publish = &Operation{
+				OperationID: "send" + codegen.Goify(operationID, true),
+				Message:     &Reference{Ref: "#/components/messages/" + name},
+			}
+		}
+	} else {
+		for _, sc := range a.EffectiveSchemes() {
+			if sc == "http" || sc == "https" {
+				protocols = append(protocols, sc)
+			}
+		}
+		if len(protocols) == 0 {
+			protocols = []string{"http"}
+		}
+	}
+
+	sub := &Operation{OperationID: operationID, Description: a.Description}
+	if !a.WebSocket() {
+		sub.Bindings = &OperationBindings{HTTP: &HTTPOperationBinding{
+			Type:           "request",
+			Method:         route.Verb,
+			Query:          query,
+			BindingVersion: bindingVersion,
+		}}
+	}
+	if mt := streamedMediaType(api, a); mt != nil {
+		name := codegen.Goify(a.Name, true) + codegen.Goify(a.Parent.Name, true) + "Event"
+		s.Components.Messages[name] = &Message{
+			Name:        name,
+			Title:       mt.TypeName,
+			Summary:     mt.Description,
+			ContentType: mt.Identifier,
+			Payload:     schema(api, &design.AttributeDefinition{Type: mt}),
+		}
+		sub.Message = &Reference{Ref: "#/components/messages/" + name}
+	}
+	if a.WebSocket() && sub.Message == nil {
+		// Nothing is documented for a websocket action without response media type.
+		sub = nil
+	}
+	ch.Subscribe = sub
+
+	for _, p := range protocols {
+		if s.Servers == nil {
+			s.Servers = make(map[string]*Server)
+		}
+		if _, ok := s.Servers[p]; !ok {
+			s.Servers[p] = &Server{URL: api.Host + api.BasePath, Protocol: p}
+		}
+		ch.Servers = append(ch.Servers, p)
+	}
+	sort.Strings(ch.Servers)
+	s.Channels[address] = ch
+	return nil
+}
+
+// streamedMediaType returns the media type of the messages sent by the API, that is the media
+// type of the first successful response that defines one.
+func streamedMediaType(api *design.APIDefinition, a *design.ActionDefinition) *design.MediaTypeDefinition {
+	var names []string
+	for n := range a.Responses {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var found *design.MediaTypeDefinition
+	status := 0
+	for _, n := range names {
+		r := a.Responses[n]
+		if r.Status >= 400 || r.MediaType == "" {
+			continue
+		}
+		if found != nil && r.Status >= status {
+			continue
+		}
+		if mt := api.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			found, status = mt, r.Status
+		}
+	}
+	return found
+}
+
+// querySchema returns the schema of the object holding the query string parameters, nil if the
+// action has none.
+func querySchema(api *design.APIDefinition, params *design.AttributeDefinition, pathParams []string) *genschema.JSONSchema {
+	query := make(design.Object)
+	for n, at := range params.Type.ToObject() {
+		isPath := false
+		for _, p := range pathParams {
+			if p == n {
+				isPath = true
+				break
+			}
+		}
+		if !isPath {
+			query[n] = at
+		}
+	}
+	if len(query) == 0 {
+		return nil
+	}
+	s := schema(api, &design.AttributeDefinition{Type: query})
+	if params.Validation != nil {
+		for _, n := range params.Validation.Required {
+			if _, ok := query[n]; ok {
+				s.Required = append(s.Required, n)
+			}
+		}
+	}
+	return s
+}
+
+// schema returns the JSON schema of the given attribute with the references rewritten to point
+// to the document components.
+func schema(api *design.APIDefinition, at *design.AttributeDefinition) *genschema.JSONSchema {
+	s := genschema.TypeSchema(api, at.Type)
+	if s.Description == "" {
+		s.Description = at.Description
+	}
+	if at.Validation != nil && len(at.Validation.Required) > 0 {
+		s.Required = at.Validation.Required
+	}
+	rewriteRefs(s)
+	return s
+}
+
+// rewriteRefs rewrites the references to the JSON schema definitions so that they point to the
+// schemas of the document components.
+func rewriteRefs(s *genschema.JSONSchema) {
+	if s == nil {
+		return
+	}
+	if strings.HasPrefix(s.Ref, "#/definitions/") {
+		s.Ref = "#/components/schemas/" + strings.TrimPrefix(s.Ref, "#/definitions/")
+	}
+	rewriteRefs(s.Items)
+	for _, p := range s.Properties {
+		rewriteRefs(p)
+	}
+	for _, d := range s.Definitions {
+		rewriteRefs(d)
+	}
+	for _, a := range s.AnyOf {
+		rewriteRefs(a)
+	}
+}
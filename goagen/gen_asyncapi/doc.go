@@ -0,0 +1,13 @@
+/*
+Package genasyncapi provides a generator for the AsyncAPI specification of the streaming actions.
+
+Swagger cannot describe the actions that use websockets or stream their responses with server-sent
+events. The generator produces an AsyncAPI 2.x document with one channel per route of these actions
+so that the consumers get a machine-readable contract for them too. The "subscribe" operation of a
+channel describes the messages sent by the API, that is the media type of the first successful
+response, and the "publish" operation of a websocket channel describes the messages sent by the
+clients, that is the action payload. The query string and headers of the request opening the
+stream are described by the "ws" channel binding for websockets and by the "http" operation
+binding for server-sent events.
+*/
+package genasyncapi
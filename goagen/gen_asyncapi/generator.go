@@ -0,0 +1,97 @@
+package genasyncapi
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+)
+
+// Generator is the AsyncAPI generator.
+type Generator struct {
+	genfiles []string // Generated files
+	outDir   string   // Path to output directory
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir string
+	set := flag.NewFlagSet("asyncapi", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.String("design", "", "")
+	set.Parse(os.Args[2:])
+
+	g := &Generator{outDir: outDir}
+
+	return g.Generate(design.Design)
+}
+
+// Generate produces the AsyncAPI JSON and YAML documents.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	s, err := New(api)
+	if err != nil {
+		return nil, err
+	}
+
+	asyncDir := filepath.Join(g.outDir, "asyncapi")
+	render, err := codegen.BeginRender(asyncDir)
+	if err != nil {
+		return nil, err
+	}
+	defer render.Discard()
+	if err = os.MkdirAll(asyncDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, asyncDir)
+
+	// JSON
+	rawJSON, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	asyncFile := filepath.Join(asyncDir, "asyncapi.json")
+	if err := codegen.WriteFile(asyncFile, rawJSON, 0644); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, asyncFile)
+
+	// YAML
+	var yamlSource interface{}
+	if err = json.Unmarshal(rawJSON, &yamlSource); err != nil {
+		return nil, err
+	}
+
+	rawYAML, err := yaml.Marshal(yamlSource)
+	if err != nil {
+		return nil, err
+	}
+	asyncFile = filepath.Join(asyncDir, "asyncapi.yaml")
+	if err := codegen.WriteFile(asyncFile, rawYAML, 0644); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, asyncFile)
+
+	return g.genfiles, render.Commit()
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
@@ -0,0 +1,166 @@
+package genasyncapi_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_asyncapi"
+	"github.com/goadesign/goa/goagen/gen_schema"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	const testgenPackagePath = "github.com/goadesign/goa/goagen/gen_asyncapi/test_"
+
+	var outDir string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		gopath := filepath.SplitList(os.Getenv("GOPATH"))[0]
+		outDir = filepath.Join(gopath, "src", testgenPackagePath)
+		err := os.MkdirAll(outDir, 0777)
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"goagen", "asyncapi", "--out=" + outDir, "--design=foo"}
+		genschema.Definitions = make(map[string]*genschema.JSONSchema)
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genasyncapi.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	Context("with streaming actions", func() {
+		BeforeEach(func() {
+			event := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "Event",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"message": {Type: design.String}},
+					},
+				},
+				Identifier: "application/vnd.event+json",
+				Views: map[string]*design.ViewDefinition{"default": {
+					Name: "default",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"message": {Type: design.String}},
+					},
+				}},
+			}
+			event.Views["default"].Parent = event
+			chat := &design.ActionDefinition{
+				Name:        "chat",
+				Description: "Chat about a bottle",
+				Schemes:     []string{"ws"},
+				Payload: &design.UserTypeDefinition{
+					TypeName: "ChatPayload",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"text": {Type: design.String}},
+					},
+				},
+				Routes: []*design.RouteDefinition{{Verb: "GET", Path: "/bottles/:id/chat"}},
+				Params: &design.AttributeDefinition{
+					Type: design.Object{
+						"id":   {Type: design.Integer, Description: "Bottle ID"},
+						"nick": {Type: design.String},
+					},
+					Validation: &dslengine.ValidationDefinition{Required: []string{"nick"}},
+				},
+				Responses: map[string]*design.ResponseDefinition{
+					"SwitchingProtocols": {Name: "SwitchingProtocols", Status: 101, MediaType: event.Identifier},
+				},
+			}
+			watch := &design.ActionDefinition{
+				Name:     "watch",
+				Metadata: dslengine.MetadataDefinition{"stream:buffer": {"10"}},
+				Routes:   []*design.RouteDefinition{{Verb: "GET", Path: "/bottles/events"}},
+				Responses: map[string]*design.ResponseDefinition{
+					"OK": {Name: "OK", Status: 200, MediaType: event.Identifier},
+				},
+			}
+			show := &design.ActionDefinition{
+				Name:   "show",
+				Routes: []*design.RouteDefinition{{Verb: "GET", Path: "/bottles/:id"}},
+			}
+			design.Design = &design.APIDefinition{
+				Name:     "testapi",
+				Host:     "api.example.com",
+				BasePath: "/v1",
+				Schemes:  []string{"https"},
+				Resources: map[string]*design.ResourceDefinition{
+					"bottle": {
+						Name:    "bottle",
+						Actions: map[string]*design.ActionDefinition{"chat": chat, "watch": watch, "show": show},
+					},
+				},
+				MediaTypes: map[string]*design.MediaTypeDefinition{event.Identifier: event},
+			}
+			for _, a := range []*design.ActionDefinition{chat, watch, show} {
+				a.Parent = design.Design.Resources["bottle"]
+				a.Routes[0].Parent = a
+			}
+		})
+
+		It("generates the JSON and YAML documents", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(ContainElement(filepath.Join(outDir, "asyncapi", "asyncapi.json")))
+			Ω(files).Should(ContainElement(filepath.Join(outDir, "asyncapi", "asyncapi.yaml")))
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "asyncapi", "asyncapi.yaml"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("asyncapi: 2.6.0"))
+		})
+
+		It("describes the servers and channels", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "asyncapi", "asyncapi.json"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`"info":{"title":"testapi","version":""}`))
+			Ω(content).Should(ContainSubstring(`"https":{"url":"api.example.com/v1","protocol":"https"}`))
+			Ω(content).Should(ContainSubstring(`"ws":{"url":"api.example.com/v1","protocol":"ws"}`))
+			Ω(content).ShouldNot(ContainSubstring(`"/v1/bottles/{id}":`))
+		})
+
+		It("describes the websocket channel", func() {
+			Ω(genErr).Should(BeNil())
+			s, err := genasyncapi.New(design.Design)
+			Ω(err).ShouldNot(HaveOccurred())
+			ch := s.Channels["/v1/bottles/{id}/chat"]
+			Ω(ch).ShouldNot(BeNil())
+			Ω(ch.Servers).Should(Equal([]string{"ws"}))
+			Ω(ch.Parameters).Should(HaveKey("id"))
+			Ω(ch.Parameters["id"].Description).Should(Equal("Bottle ID"))
+			Ω(ch.Bindings.WS.Method).Should(Equal("GET"))
+			Ω(ch.Bindings.WS.Query.Properties).Should(HaveKey("nick"))
+			Ω(ch.Bindings.WS.Query.Properties).ShouldNot(HaveKey("id"))
+			Ω(ch.Bindings.WS.Query.Required).Should(Equal([]string{"nick"}))
+			Ω(ch.Subscribe.OperationID).Should(Equal("chatBottle"))
+			Ω(ch.Subscribe.Message.Ref).Should(Equal("#/components/messages/ChatBottleEvent"))
+			Ω(ch.Publish.Message.Ref).Should(Equal("#/components/messages/ChatBottleMessage"))
+			Ω(s.Components.Messages["ChatBottleMessage"].Payload.Ref).Should(Equal("#/components/schemas/ChatPayload"))
+			Ω(s.Components.Schemas).Should(HaveKey("ChatPayload"))
+		})
+
+		It("describes the server-sent events channel", func() {
+			Ω(genErr).Should(BeNil())
+			s, err := genasyncapi.New(design.Design)
+			Ω(err).ShouldNot(HaveOccurred())
+			ch := s.Channels["/v1/bottles/events"]
+			Ω(ch).ShouldNot(BeNil())
+			Ω(ch.Servers).Should(Equal([]string{"https"}))
+			Ω(ch.Publish).Should(BeNil())
+			Ω(ch.Subscribe.Bindings.HTTP.Type).Should(Equal("request"))
+			Ω(ch.Subscribe.Bindings.HTTP.Method).Should(Equal("GET"))
+			msg := s.Components.Messages["WatchBottleEvent"]
+			Ω(msg.ContentType).Should(Equal("application/vnd.event+json"))
+			Ω(msg.Payload.Ref).Should(Equal("#/components/schemas/Event"))
+			Ω(s.Components.Schemas).Should(HaveKey("Event"))
+		})
+	})
+})
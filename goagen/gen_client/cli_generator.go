@@ -16,9 +16,6 @@ import (
 
 func (g *Generator) makeToolDir(apiName string) (toolDir string, err error) {
 	g.outDir = filepath.Join(g.outDir, g.target)
-	if err = os.RemoveAll(g.outDir); err != nil {
-		return
-	}
 	g.genfiles = append(g.genfiles, g.outDir)
 	apiName = strings.Replace(apiName, " ", "-", -1)
 	toolDir = filepath.Join(g.outDir, fmt.Sprintf("%s-cli", codegen.SnakeCase(apiName)))
@@ -26,6 +23,7 @@ func (g *Generator) makeToolDir(apiName string) (toolDir string, err error) {
 		return
 	}
 	g.genfiles = append(g.genfiles, toolDir)
+	g.render, err = codegen.BeginRender(g.outDir)
 	return
 }
 
@@ -39,11 +37,16 @@ func (g *Generator) generateMain(mainFile string, clientPkg string, funcs templa
 		codegen.SimpleImport("time"),
 		codegen.SimpleImport(clientPkg),
 		codegen.SimpleImport("github.com/spf13/cobra"),
+		codegen.NewImport("goaclient", "github.com/goadesign/goa/client"),
 	}
 	funcs["defaultRouteParams"] = defaultRouteParams
 	funcs["defaultRouteTemplate"] = defaultRouteTemplate
 	funcs["joinNames"] = joinNames
+	funcs["requiredNames"] = requiredNames
+	funcs["optionalParams"] = optionalParams
 	funcs["routes"] = routes
+	funcs["tableColumns"] = tableColumns
+	funcs["enumValues"] = enumValues
 	file, err := codegen.SourceFileFor(mainFile)
 	if err != nil {
 		return err
@@ -81,10 +84,17 @@ func (g *Generator) generateMain(mainFile string, clientPkg string, funcs templa
 			return nil
 		})
 	})
+	var pkce []*design.SecuritySchemeDefinition
+	for _, scheme := range api.SecuritySchemes {
+		if len(scheme.CodeChallengeMethods) > 0 {
+			pkce = append(pkce, scheme)
+		}
+	}
 	data = map[string]interface{}{
 		"Actions":      actions,
 		"Package":      g.target,
 		"HasDownloads": hasDownloads,
+		"PKCESchemes":  pkce,
 	}
 	if err := file.ExecuteTemplate("registerCmds", registerCmdsT, funcs, data); err != nil {
 		return err
@@ -98,11 +108,11 @@ func (g *Generator) generateCommands(commandsFile string, clientPkg string, func
 	if err != nil {
 		return err
 	}
-	commandTypesTmpl := template.Must(template.New("commandTypes").Funcs(funcs).Parse(commandTypesTmpl))
-	commandsTmpl := template.Must(template.New("commands").Funcs(funcs).Parse(commandsTmpl))
-	commandsTmplWS := template.Must(template.New("commandsWS").Funcs(funcs).Parse(commandsTmplWS))
-	downloadCommandTmpl := template.Must(template.New("download").Funcs(funcs).Parse(downloadCommandTmpl))
-	registerTmpl := template.Must(template.New("register").Funcs(funcs).Parse(registerTmpl))
+	commandTypesTmpl := template.Must(template.New("commandTypes").Funcs(funcs).Parse(codegen.Template("commandTypes", commandTypesTmpl)))
+	commandsTmpl := template.Must(template.New("commands").Funcs(funcs).Parse(codegen.Template("commands", commandsTmpl)))
+	commandsTmplWS := template.Must(template.New("commandsWS").Funcs(funcs).Parse(codegen.Template("commandsWS", commandsTmplWS)))
+	downloadCommandTmpl := template.Must(template.New("download").Funcs(funcs).Parse(codegen.Template("download", downloadCommandTmpl)))
+	registerTmpl := template.Must(template.New("register").Funcs(funcs).Parse(codegen.Template("register", registerTmpl)))
 
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("encoding/json"),
@@ -116,11 +126,9 @@ func (g *Generator) generateCommands(commandsFile string, clientPkg string, func
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("github.com/spf13/cobra"),
 		codegen.SimpleImport(clientPkg),
-		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.ContextImport(g.stdcontext),
 		codegen.SimpleImport("golang.org/x/net/websocket"),
-	}
-	if len(api.Resources) > 0 {
-		imports = append(imports, codegen.NewImport("goaclient", "github.com/goadesign/goa/client"))
+		codegen.NewImport("goaclient", "github.com/goadesign/goa/client"),
 	}
 	if err := file.WriteHeader("", "main", imports); err != nil {
 		return err
@@ -258,6 +266,59 @@ func joinNames(atts ...*design.AttributeDefinition) string {
 	return strings.Join(elems, ", ")
 }
 
+// requiredNames is a code generation helper function that generates a string built from
+// concatenating the command fields holding the required parameters of the given attribute types
+// (assuming they are objects).
+func requiredNames(atts ...*design.AttributeDefinition) string {
+	var elems []string
+	for _, att := range atts {
+		if att == nil {
+			continue
+		}
+		var names []string
+		for n := range att.Type.ToObject() {
+			if att.IsRequired(n) {
+				names = append(names, n)
+			}
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			elems = append(elems, fmt.Sprintf("cmd.%s", codegen.Goify(n, true)))
+		}
+	}
+	return strings.Join(elems, ", ")
+}
+
+// optionalParams is a code generation helper function that generates the client option setting
+// the optional query string parameters and headers of the given action from the command fields.
+// It returns an empty string if the action has no optional parameter.
+func optionalParams(a *design.ActionDefinition, pkg string) string {
+	var fields []string
+	for _, att := range []*design.AttributeDefinition{a.QueryParams, a.Headers} {
+		if att == nil {
+			continue
+		}
+		for n, p := range att.Type.ToObject() {
+			if att.IsRequired(n) {
+				continue
+			}
+			field := codegen.Goify(n, true)
+			value := "cmd." + field
+			if p.Type.IsPrimitive() {
+				// Flags that are not set are not sent.
+				value = "goaclient.NonZero(" + value + ")"
+			}
+			fields = append(fields, fmt.Sprintf("%s: %s", field, value))
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	sort.Strings(fields)
+	name := codegen.Goify(a.Name+strings.Title(a.Parent.Name), true)
+	return fmt.Sprintf("%s.With%sParams(&%s.%sParams{%s})", pkg, name, pkg, name, strings.Join(fields, ", "))
+}
+
 // routes create the action command "Use" suffix.
 func routes(action *design.ActionDefinition) string {
 	var buf bytes.Buffer
@@ -284,10 +345,70 @@ func routes(action *design.ActionDefinition) string {
 	return buf.String()
 }
 
+// tableColumns returns the Go literal listing the attributes rendered by the table output format
+// for the action responses: the attributes of the default view of the media type of the "OK"
+// response or of its elements if it is a collection.
+func tableColumns(action *design.ActionDefinition) string {
+	r, ok := action.Responses["OK"]
+	if !ok {
+		return "nil"
+	}
+	mt := design.Design.MediaTypeWithIdentifier(r.MediaType)
+	if mt == nil {
+		return "nil"
+	}
+	if mt.IsArray() {
+		elem, ok := mt.ToArray().ElemType.Type.(*design.MediaTypeDefinition)
+		if !ok {
+			return "nil"
+		}
+		mt = elem
+	}
+	var att *design.AttributeDefinition
+	if v, ok := mt.Views["default"]; ok {
+		att = v.AttributeDefinition
+	} else {
+		att = mt.AttributeDefinition
+	}
+	if att == nil || !att.Type.IsObject() {
+		return "nil"
+	}
+	obj := att.Type.ToObject()
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, fmt.Sprintf("%q", n))
+	}
+	if len(names) == 0 {
+		return "nil"
+	}
+	sort.Strings(names)
+	return "[]string{" + strings.Join(names, ", ") + "}"
+}
+
+// enumValues returns the comma separated list of the quoted values enumerated by the attribute
+// validation or by the validation of its elements if it is an array, empty string if none.
+func enumValues(att *design.AttributeDefinition) string {
+	if arr := att.Type.ToArray(); arr != nil {
+		att = arr.ElemType
+	}
+	if att.Validation == nil || len(att.Validation.Values) == 0 {
+		return ""
+	}
+	values := make([]string, len(att.Validation.Values))
+	for i, v := range att.Validation.Values {
+		values[i] = fmt.Sprintf("%q", fmt.Sprint(v))
+	}
+	return strings.Join(values, ", ")
+}
+
 const mainTmpl = `
 // PrettyPrint is true if the tool output should be formatted for human consumption.
 var PrettyPrint bool
 
+// OutputFormat is the format of the response bodies written by the tool: "table", "json",
+// "yaml" or empty to write the bodies as received.
+var OutputFormat string
+
 func main() {
 	// Create command line parser
 	app := &cobra.Command{
@@ -301,12 +422,35 @@ func main() {
 	app.PersistentFlags().DurationVarP(&c.Timeout, "timeout", "t", time.Duration(20) * time.Second, "Set the request timeout")
 	app.PersistentFlags().BoolVar(&c.Dump, "dump", false, "Dump HTTP request and response.")
 	app.PersistentFlags().BoolVar(&PrettyPrint, "pp", false, "Pretty print response body")
+	app.PersistentFlags().StringVar(&OutputFormat, "format", "", "Output format: table, json or yaml")
+	app.PersistentFlags().String(goaclient.ConfigFlag, goaclient.DefaultConfigPath("{{ .API.Name }}"), "Configuration file providing default flag values")
+	app.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(cmd); err != nil {
+			return err
+		}
+		return initOutputFormat()
+	}
 	RegisterCommands(app, c)
+	app.AddCommand(goaclient.CompletionCommand(app))
 	if err := app.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "request failed: %s", err)
 		os.Exit(-1)
 	}
 }
+
+// loadConfig reads the flags not given on the command line from the environment and the
+// configuration file.
+func loadConfig(cmd *cobra.Command) error {
+	return goaclient.LoadConfig(cmd, "{{ .API.Name }}")
+}
+
+// initOutputFormat defaults the output format to JSON when pretty printing and validates it.
+func initOutputFormat() error {
+	if OutputFormat == "" && PrettyPrint {
+		OutputFormat = goaclient.FormatJSON
+	}
+	return goaclient.ValidateFormat(OutputFormat)
+}
 `
 
 const commandTypesTmpl = `{{ $cmdName := goify (printf "%s%s%s" .Name (title .Parent.Name) "Command") true }}	// {{ $cmdName }} is the command line data structure for the {{ .Name }} action of {{ .Parent.Name }}
@@ -343,7 +487,8 @@ func (cmd *{{ $cmdName }}) Run(c *{{ .Package }}.Client, args []string) error {
 	logger := goa.NewLogger(log.New(os.Stderr, "", log.LstdFlags))
 	ctx := goa.WithLogger(context.Background(), logger)
 	ws, err := c.{{ goify (printf "%s%s" .Action.Name (title .Resource.Name)) true }}(ctx, path{{/*
-	*/}}{{ $params := joinNames .Action.QueryParams .Action.Headers }}{{ if $params }}, {{ $params }}{{ end }})
+	*/}}{{ $params := requiredNames .Action.QueryParams .Action.Headers }}{{ if $params }}, {{ $params }}{{ end }}{{/*
+	*/}}{{ $opts := optionalParams .Action .Package }}{{ if $opts }}, {{ $opts }}{{ end }})
 	if err != nil {
 		goa.LogError(ctx, "failed", "err", err)
 		return err
@@ -411,14 +556,18 @@ func (cmd *{{ $cmdName }}) RegisterFlags(cc *cobra.Command, c *{{ .Package }}.Cl
 */}}{{ if not $pparam.DefaultValue }}	var {{ $tmp }} {{ cmdFieldType $pparam.Type false }}
 {{ end }}	cc.Flags().{{ flagType $pparam }}Var(&cmd.{{ goify $pname true }}, "{{ $pname }}", {{/*
 */}}{{ if $pparam.DefaultValue }}{{ printf "%#v" $pparam.DefaultValue }}{{ else }}{{ $tmp }}{{ end }}, ` + "`" + `{{ escapeBackticks $pparam.Description }}` + "`" + `)
-{{ end }}{{ end }}{{ $params := .Action.QueryParams }}{{ if $params }}{{ range $name, $param := $params.Type.ToObject }}{{ $tmp := goify $name false }}{{/*
+{{ with enumValues $pparam }}	cc.RegisterFlagCompletionFunc("{{ $pname }}", goaclient.CompleteValues({{ . }}))
+{{ end }}{{ end }}{{ end }}{{ $params := .Action.QueryParams }}{{ if $params }}{{ range $name, $param := $params.Type.ToObject }}{{ $tmp := goify $name false }}{{/*
 */}}{{ if not $param.DefaultValue }}	var {{ $tmp }} {{ cmdFieldType $param.Type false }}
 {{ end }}	cc.Flags().{{ flagType $param }}Var(&cmd.{{ goify $name true }}, "{{ $name }}", {{/*
 */}}{{ if $param.DefaultValue }}{{ printf "%#v" $param.DefaultValue }}{{ else }}{{ $tmp }}{{ end }}, ` + "`" + `{{ escapeBackticks $param.Description }}` + "`" + `)
-{{ end }}{{ end }}{{ $headers := .Action.Headers }}{{ if $headers }}{{ range $name, $header := $headers.Type.ToObject }}{{/*
-*/}} cc.Flags().StringVar(&cmd.{{ goify $name true }}, "{{ $name }}", {{/*
-*/}}{{ if $header.DefaultValue }}{{ printf "%q" $header.DefaultValue }}{{ else }}""{{ end }}, ` + "`" + `{{ escapeBackticks $header.Description }}` + "`" + `)
-{{ end }}{{ end }}{{ if .Action.Security }}   c.{{ goify .Action.Security.Scheme.SchemeName true }}Signer.RegisterFlags(cc){{ end }}}`
+{{ with enumValues $param }}	cc.RegisterFlagCompletionFunc("{{ $name }}", goaclient.CompleteValues({{ . }}))
+{{ end }}{{ end }}{{ end }}{{ $headers := .Action.Headers }}{{ if $headers }}{{ range $name, $header := $headers.Type.ToObject }}{{ $tmp := goify $name false }}{{/*
+*/}}{{ if not $header.DefaultValue }}	var {{ $tmp }} {{ cmdFieldType $header.Type false }}
+{{ end }}	cc.Flags().{{ flagType $header }}Var(&cmd.{{ goify $name true }}, "{{ $name }}", {{/*
+*/}}{{ if $header.DefaultValue }}{{ printf "%#v" $header.DefaultValue }}{{ else }}{{ $tmp }}{{ end }}, ` + "`" + `{{ escapeBackticks $header.Description }}` + "`" + `)
+{{ with enumValues $header }}	cc.RegisterFlagCompletionFunc("{{ $name }}", goaclient.CompleteValues({{ . }}))
+{{ end }}{{ end }}{{ end }}{{ if .Action.Security }}   c.{{ goify .Action.Security.Scheme.SchemeName true }}Signer.RegisterFlags(cc){{ end }}}`
 
 const commandsTmpl = `
 {{ $cmdName := goify (printf "%s%sCommand" .Action.Name (title .Resource.Name)) true }}// Run makes the HTTP request corresponding to the {{ $cmdName }} command.
@@ -442,13 +591,14 @@ func (cmd *{{ $cmdName }}) Run(c *{{ .Package }}.Client, args []string) error {
 	ctx := goa.WithLogger(context.Background(), logger)
 	resp, err := c.{{ goify (printf "%s%s" .Action.Name (title .Resource.Name)) true }}(ctx, path{{ if .Action.Payload }}, {{/*
 	*/}}{{ if or .Action.Payload.Type.IsObject .Action.Payload.IsPrimitive }}&{{ end }}payload{{ else }}{{ end }}{{/*
-	*/}}{{ $params := joinNames .Action.QueryParams .Action.Headers }}{{ if $params }}, {{ $params }}{{ end }})
+	*/}}{{ $params := requiredNames .Action.QueryParams .Action.Headers }}{{ if $params }}, {{ $params }}{{ end }}{{/*
+	*/}}{{ $opts := optionalParams .Action .Package }}{{ if $opts }}, {{ $opts }}{{ end }})
 	if err != nil {
 		goa.LogError(ctx, "failed", "err", err)
 		return err
 	}
 
-	goaclient.HandleResponse(c.Client, resp, PrettyPrint)
+	goaclient.HandleResponseFormat(c.Client, resp, OutputFormat, {{ tableColumns .Action }})
 	return nil
 }
 `
@@ -482,4 +632,19 @@ func RegisterCommands(app *cobra.Command, c *{{ .Package }}.Client) {
 	}
 	dlc.Flags().StringVar(&dl.OutFile, "out", "", "Output file")
 	app.AddCommand(dlc)
+{{ end }}{{ $single := eq (len .PKCESchemes) 1 }}{{ range .PKCESchemes }}
+	{{ goify .SchemeName false }}Login := &goaclient.PKCELogin{
+		OAuth2Endpoints: goaclient.OAuth2Endpoints{
+			AuthorizationURL: {{ printf "%q" .AuthorizationURL }},
+			TokenURL:         {{ printf "%q" .TokenURL }},
+		},{{ if .Environments }}
+		Environments: map[string]*goaclient.OAuth2Endpoints{ {{ range $name, $env := .Environments }}
+			{{ printf "%q" $name }}: {
+				AuthorizationURL: {{ printf "%q" $env.AuthorizationURL }},
+				TokenURL:         {{ printf "%q" $env.TokenURL }},
+			},{{ end }}
+		},{{ end }}
+		Methods: []string{ {{ range $i, $m := .CodeChallengeMethods }}{{ if $i }}, {{ end }}{{ printf "%q" $m }}{{ end }} },
+	}
+	app.AddCommand({{ goify .SchemeName false }}Login.Command({{ if $single }}"login"{{ else }}{{ printf "%q" (printf "login-%s" .SchemeName) }}{{ end }}))
 {{ end }}}`
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
 	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/gen_client"
 	. "github.com/onsi/ginkgo"
@@ -56,6 +57,23 @@ var _ = Describe("Generate", func() {
 			_, err = gexec.Build(filepath.Join(testgenPackagePath, "client", "testapi-cli"))
 			Ω(err).ShouldNot(HaveOccurred())
 		})
+
+		It("reads the flag defaults from the environment and validates the output format", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`app.PersistentFlags().String(goaclient.ConfigFlag, goaclient.DefaultConfigPath("testapi"),`))
+			Ω(string(content)).Should(ContainSubstring(`return goaclient.LoadConfig(cmd, "testapi")`))
+			Ω(string(content)).Should(ContainSubstring(`app.PersistentFlags().StringVar(&OutputFormat, "format", "", "Output format: table, json or yaml")`))
+			Ω(string(content)).Should(ContainSubstring("return goaclient.ValidateFormat(OutputFormat)"))
+		})
+
+		It("adds the completion command", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("app.AddCommand(goaclient.CompletionCommand(app))"))
+		})
 	})
 
 	Context("with an action with an integer parameter with no default value", func() {
@@ -108,6 +126,68 @@ var _ = Describe("Generate", func() {
 
 		})
 
+		It("writes the responses using the output format", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "commands.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("goaclient.HandleResponseFormat(c.Client, resp, OutputFormat, nil)"))
+		})
+
+		Context("with an action with a response media type", func() {
+			BeforeEach(func() {
+				bottle := &design.MediaTypeDefinition{
+					UserTypeDefinition: &design.UserTypeDefinition{
+						TypeName: "Bottle",
+						AttributeDefinition: &design.AttributeDefinition{
+							Type: design.Object{
+								"id":      {Type: design.Integer},
+								"name":    {Type: design.String},
+								"vintage": {Type: design.Integer},
+							},
+						},
+					},
+					Identifier: "application/vnd.bottle+json",
+				}
+				bottle.Views = map[string]*design.ViewDefinition{
+					"default": {
+						AttributeDefinition: &design.AttributeDefinition{
+							Type: design.Object{
+								"id":   {Type: design.Integer},
+								"name": {Type: design.String},
+							},
+						},
+						Name:   "default",
+						Parent: bottle,
+					},
+				}
+				design.Design.MediaTypes = map[string]*design.MediaTypeDefinition{bottle.Identifier: bottle}
+				design.Design.Resources["foo"].Actions["show"].Responses = map[string]*design.ResponseDefinition{
+					"OK": {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+				}
+			})
+
+			It("renders the attributes of the default view in tables", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "commands.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring(`goaclient.HandleResponseFormat(c.Client, resp, OutputFormat, []string{"id", "name"})`))
+			})
+		})
+
+		Context("with a parameter that enumerates its values", func() {
+			BeforeEach(func() {
+				params := design.Design.Resources["foo"].Actions["show"].QueryParams.Type.ToObject()
+				params["param"].Validation = &dslengine.ValidationDefinition{Values: []interface{}{1, 2}}
+			})
+
+			It("completes the flag values", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "commands.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring(`cc.RegisterFlagCompletionFunc("param", goaclient.CompleteValues("1", "2"))`))
+			})
+		})
+
 		Context("with an action with a multiline description", func() {
 			const multiline = "multi\nline"
 
@@ -196,4 +276,48 @@ var _ = Describe("Generate", func() {
 			Ω(content).Should(ContainSubstring("c.JWT1Signer.RegisterFlags(cc)"))
 		})
 	})
+
+	Context("with an OAuth2 security scheme that uses PKCE", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			design.Design = &design.APIDefinition{
+				Name:        "testapi",
+				Title:       "dummy API with no resource",
+				Description: "I told you it's dummy",
+				SecuritySchemes: []*design.SecuritySchemeDefinition{
+					{
+						SchemeName:           "oauth2",
+						Kind:                 design.OAuth2SecurityKind,
+						Flow:                 "accessCode",
+						AuthorizationURL:     "https://example.com/auth",
+						TokenURL:             "https://example.com/token",
+						CodeChallengeMethods: []string{"S256"},
+						Environments: map[string]*design.SecurityEnvironmentDefinition{
+							"staging": {AuthorizationURL: "https://staging.example.com/auth", TokenURL: "https://staging.example.com/token"},
+						},
+					},
+				},
+			}
+		})
+
+		It("generates the login command", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`	oauth2Login := &goaclient.PKCELogin{
+		OAuth2Endpoints: goaclient.OAuth2Endpoints{
+			AuthorizationURL: "https://example.com/auth",
+			TokenURL:         "https://example.com/token",
+		},
+		Environments: map[string]*goaclient.OAuth2Endpoints{
+			"staging": {
+				AuthorizationURL: "https://staging.example.com/auth",
+				TokenURL:         "https://staging.example.com/token",
+			},
+		},
+		Methods: []string{"S256"},
+	}
+	app.AddCommand(oauth2Login.Command("login"))`))
+		})
+	})
 })
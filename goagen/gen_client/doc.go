@@ -5,10 +5,13 @@ service.
 
 The generated code includes a client package with:
 
-    * One client method per resource action
+    * One client method per resource action, the optional query string parameters and headers
+      are given with functional options validated against the design
     * Helper functions to build the corresponding request paths
     * Structs for the action payloads and dependent types
     * Structs for the action media types and corresponding decoder functions
+    * With the --grpc flag, one gRPC client per resource that implements the same interface as
+      the HTTP client by calling the gRPC service described by the proto generator
 
 The generated code also includes a CLI tool with commands for each action and sub-commands for
 each resource.
@@ -13,36 +13,53 @@ import (
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/gen_app"
+	"github.com/goadesign/goa/goagen/gen_proto"
 	"github.com/goadesign/goa/goagen/utils"
 )
 
 // Filename used to generate all data types (without the ".go" extension)
 const typesFileName = "datatypes"
 
+// grpcFileName is the name of the file containing the gRPC clients.
+const grpcFileName = "grpc"
+
 // Generator is the application code generator.
 type Generator struct {
 	outDir         string // Path to output directory
 	target         string // Name of generated package
 	genfiles       []string
 	generatedTypes map[string]bool // Keeps track of names of user types that correspond to action payloads.
+	clientMethods  []*clientMethod // Action methods of the resource client being generated.
 	encoders       []*genapp.EncoderTemplateData
 	decoders       []*genapp.EncoderTemplateData
 	encoderImports []string
+	stdcontext     bool            // Whether to import the standard library context package
+	grpcPkg        string          // Import path of the protoc generated package, empty if no gRPC client
+	grpcClients    []*grpcClient   // gRPC clients of the resources
+	render         *codegen.Render // Render of the output directory
 }
 
 // Generate is the generator entry point called by the meta generator.
 func Generate() (files []string, err error) {
-	var outDir, target string
+	var (
+		outDir, target, grpcPkg string
+		stdcontext              bool
+	)
 
 	set := flag.NewFlagSet("client", flag.PanicOnError)
 	set.String("design", "", "")
 	set.StringVar(&outDir, "out", "", "")
 	set.StringVar(&target, "pkg", "client", "")
+	set.BoolVar(&stdcontext, "stdcontext", false, "")
+	set.StringVar(&grpcPkg, "grpc", "", "")
 	set.Parse(os.Args[2:])
 
 	target = codegen.Goify(target, false)
-	g := &Generator{outDir: outDir, target: target}
+	g := &Generator{outDir: outDir, target: target, stdcontext: stdcontext, grpcPkg: grpcPkg}
+	// Reserve the package name for the duration of the generation only so that generating
+	// again in the same process produces the same package name.
 	codegen.Reserved[target] = true
+	defer delete(codegen.Reserved, target)
 
 	return g.Generate(design.Design)
 }
@@ -63,6 +80,7 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 	if err != nil {
 		return
 	}
+	defer g.render.Discard()
 
 	// Setup generation
 	funcs := template.FuncMap{
@@ -88,12 +106,13 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		"toString":        toString,
 		"typeName":        typeName,
 		"signerType":      signerType,
+		"signerInit":      signerInit,
 	}
 	clientPkg, err := codegen.PackagePath(g.outDir)
 	if err != nil {
 		return
 	}
-	arrayToStringTmpl = template.Must(template.New("client").Funcs(funcs).Parse(arrayToStringT))
+	arrayToStringTmpl = template.Must(template.New("arrayToString").Funcs(funcs).Parse(codegen.Template("arrayToString", arrayToStringT)))
 
 	// Generate client/client-cli/main.go
 	if err = g.generateMain(filepath.Join(toolDir, "main.go"), clientPkg, funcs, api); err != nil {
@@ -115,7 +134,14 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		return
 	}
 
-	return g.genfiles, nil
+	// Generate client/grpc.go
+	if g.grpcPkg != "" && len(g.grpcClients) > 0 {
+		if err = g.generateGRPC(filepath.Join(g.outDir, grpcFileName+".go"), funcs, api); err != nil {
+			return
+		}
+	}
+
+	return g.genfiles, g.render.Commit()
 }
 
 // Cleanup removes all the files generated by this generator during the last invokation of Generate.
@@ -131,7 +157,7 @@ func (g *Generator) generateClient(clientFile string, clientPkg string, funcs te
 	if err != nil {
 		return err
 	}
-	clientTmpl := template.Must(template.New("client").Funcs(funcs).Parse(clientTmpl))
+	clientTmpl := template.Must(template.New("client").Funcs(funcs).Parse(codegen.Template("client", clientTmpl)))
 
 	// Compute list of encoders and decoders
 	encoders, err := genapp.BuildEncoders(api.Produces, true)
@@ -160,6 +186,7 @@ func (g *Generator) generateClient(clientFile string, clientPkg string, funcs te
 	// Setup codegen
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("time"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.NewImport("goaclient", "github.com/goadesign/goa/client"),
 	}
@@ -173,13 +200,15 @@ func (g *Generator) generateClient(clientFile string, clientPkg string, funcs te
 
 	// Generate
 	data := struct {
-		API      *design.APIDefinition
-		Encoders []*genapp.EncoderTemplateData
-		Decoders []*genapp.EncoderTemplateData
+		API        *design.APIDefinition
+		Encoders   []*genapp.EncoderTemplateData
+		Decoders   []*genapp.EncoderTemplateData
+		SDKVersion string
 	}{
-		API:      api,
-		Encoders: encoders,
-		Decoders: decoders,
+		API:        api,
+		Encoders:   encoders,
+		Decoders:   decoders,
+		SDKVersion: sdkVersion(api),
 	}
 	if err := clientTmpl.Execute(file, data); err != nil {
 		return err
@@ -188,9 +217,19 @@ func (g *Generator) generateClient(clientFile string, clientPkg string, funcs te
 	return file.FormatCode()
 }
 
+// sdkVersion returns the version of the generated client: the API version followed by the goagen
+// version as semver build metadata, e.g. "1.0+goagen.1.2.0".
+func sdkVersion(api *design.APIDefinition) string {
+	version := api.Version
+	if version == "" {
+		version = "0"
+	}
+	return version + "+goagen." + codegen.Version
+}
+
 func (g *Generator) generateClientResources(clientPkg string, funcs template.FuncMap, api *design.APIDefinition) error {
-	userTypeTmpl := template.Must(template.New("userType").Funcs(funcs).Parse(userTypeTmpl))
-	typeDecodeTmpl := template.Must(template.New("typeDecode").Funcs(funcs).Parse(typeDecodeTmpl))
+	userTypeTmpl := template.Must(template.New("userType").Funcs(funcs).Parse(codegen.Template("userType", userTypeTmpl)))
+	typeDecodeTmpl := template.Must(template.New("typeDecode").Funcs(funcs).Parse(codegen.Template("typeDecode", typeDecodeTmpl)))
 
 	err := api.IterateResources(func(res *design.ResourceDefinition) error {
 		return g.generateResourceClient(res, funcs)
@@ -215,6 +254,8 @@ func (g *Generator) generateClientResources(clientPkg string, funcs template.Fun
 		codegen.SimpleImport("io"),
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("net"),
+		codegen.SimpleImport("net/netip"),
 		codegen.NewImport("uuid", "github.com/satori/go.uuid"),
 	}
 	if err := file.WriteHeader("User Types", g.target, imports); err != nil {
@@ -289,12 +330,12 @@ func (g *Generator) generateClientResources(clientPkg string, funcs template.Fun
 }
 
 func (g *Generator) generateResourceClient(res *design.ResourceDefinition, funcs template.FuncMap) error {
-	payloadTmpl := template.Must(template.New("payload").Funcs(funcs).Parse(payloadTmpl))
-	pathTmpl := template.Must(template.New("pathTemplate").Funcs(funcs).Parse(pathTmpl))
+	payloadTmpl := template.Must(template.New("payload").Funcs(funcs).Parse(codegen.Template("payload", payloadTmpl)))
+	pathTmpl := template.Must(template.New("pathTemplate").Funcs(funcs).Parse(codegen.Template("pathTemplate", pathTmpl)))
 
 	resFilename := codegen.SnakeCase(res.Name)
-	if resFilename == typesFileName {
-		// Avoid clash with datatypes.go
+	if resFilename == typesFileName || resFilename == grpcFileName {
+		// Avoid clash with datatypes.go and grpc.go
 		resFilename += "_client"
 	}
 	filename := filepath.Join(g.outDir, resFilename+".go")
@@ -315,8 +356,12 @@ func (g *Generator) generateResourceClient(res *design.ResourceDefinition, funcs
 		codegen.SimpleImport("strconv"),
 		codegen.SimpleImport("strings"),
 		codegen.SimpleImport("time"),
-		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("net"),
+		codegen.SimpleImport("net/netip"),
+		codegen.ContextImport(g.stdcontext),
 		codegen.SimpleImport("golang.org/x/net/websocket"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.NewImport("goaclient", "github.com/goadesign/goa/client"),
 		codegen.NewImport("uuid", "github.com/satori/go.uuid"),
 	}
 	if err := file.WriteHeader("", g.target, imports); err != nil {
@@ -324,6 +369,7 @@ func (g *Generator) generateResourceClient(res *design.ResourceDefinition, funcs
 	}
 	g.genfiles = append(g.genfiles, filename)
 	g.generatedTypes = make(map[string]bool)
+	g.clientMethods = nil
 
 	err = res.IterateFileServers(func(fs *design.FileServerDefinition) error {
 		return g.generateFileServer(file, fs, funcs)
@@ -344,14 +390,6 @@ func (g *Generator) generateResourceClient(res *design.ResourceDefinition, funcs
 			}
 			action.QueryParams.Type = params
 		}
-		if action.Headers != nil {
-			headers := make(design.Object, len(action.Headers.Type.ToObject()))
-			for n, header := range action.Headers.Type.ToObject() {
-				name := codegen.Goify(n, false)
-				headers[name] = header
-			}
-			action.Headers.Type = headers
-		}
 		for i, r := range action.Routes {
 			data := struct {
 				Route *design.RouteDefinition
@@ -369,15 +407,77 @@ func (g *Generator) generateResourceClient(res *design.ResourceDefinition, funcs
 	if err != nil {
 		return err
 	}
+	if len(g.clientMethods) > 0 {
+		fakeTmpl := template.Must(template.New("fake").Funcs(funcs).Parse(codegen.Template("fake", fakeTmpl)))
+		data := struct {
+			Name    string
+			Methods []*clientMethod
+		}{
+			Name:    codegen.Goify(res.Name, true),
+			Methods: g.clientMethods,
+		}
+		if err := fakeTmpl.Execute(file, data); err != nil {
+			return err
+		}
+		for _, m := range g.clientMethods {
+			if m.GRPC != nil {
+				g.grpcClients = append(g.grpcClients, &grpcClient{
+					Name:    data.Name,
+					Service: m.GRPC.RPC.Service,
+					Methods: g.clientMethods,
+				})
+				break
+			}
+		}
+	}
 
 	return file.FormatCode()
 }
 
+// generateGRPC generates the gRPC clients that implement the resource client interfaces by calling
+// the methods of the gRPC services described by the proto generator.
+func (g *Generator) generateGRPC(filename string, funcs template.FuncMap, api *design.APIDefinition) error {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("bytes"),
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("net/url"),
+		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("time"),
+		codegen.ContextImport(g.stdcontext),
+		codegen.SimpleImport("golang.org/x/net/websocket"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.NewImport("goaclient", "github.com/goadesign/goa/client"),
+		codegen.NewImport("pb", strings.SplitN(g.grpcPkg, ";", 2)[0]),
+		codegen.SimpleImport("google.golang.org/grpc"),
+		codegen.SimpleImport("google.golang.org/grpc/codes"),
+		codegen.SimpleImport("google.golang.org/grpc/status"),
+		codegen.SimpleImport("google.golang.org/protobuf/encoding/protojson"),
+		codegen.SimpleImport("google.golang.org/protobuf/proto"),
+		codegen.SimpleImport("google.golang.org/protobuf/reflect/protoreflect"),
+	}
+	title := fmt.Sprintf("%s: gRPC Clients", api.Context())
+	if err := file.WriteHeader(title, g.target, imports); err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	grpcTmpl := template.Must(template.New("grpc").Funcs(funcs).Parse(codegen.Template("grpc", grpcTmpl)))
+	if err := grpcTmpl.Execute(file, g.grpcClients); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
 func (g *Generator) generateFileServer(file *codegen.SourceFile, fs *design.FileServerDefinition, funcs template.FuncMap) error {
 	var (
 		dir string
 
-		fsTmpl = template.Must(template.New("fileserver").Funcs(funcs).Parse(fsTmpl))
+		fsTmpl = template.Must(template.New("fileserver").Funcs(funcs).Parse(codegen.Template("fileserver", fsTmpl)))
 		name   = g.fileServerMethod(fs)
 		wcs    = design.ExtractWildcards(fs.RequestPath)
 		scheme = "http"
@@ -422,15 +522,20 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		queryParams   []*paramData
 		headers       []*paramData
 		signer        string
-		clientsTmpl   = template.Must(template.New("clients").Funcs(funcs).Parse(clientsTmpl))
-		requestsTmpl  = template.Must(template.New("requests").Funcs(funcs).Parse(requestsTmpl))
-		clientsWSTmpl = template.Must(template.New("clientsws").Funcs(funcs).Parse(clientsWSTmpl))
+		clientsTmpl   = template.Must(template.New("clients").Funcs(funcs).Parse(codegen.Template("clients", clientsTmpl)))
+		requestsTmpl  = template.Must(template.New("requests").Funcs(funcs).Parse(codegen.Template("requests", requestsTmpl)))
+		clientsWSTmpl = template.Must(template.New("clientsws").Funcs(funcs).Parse(codegen.Template("clientsws", clientsWSTmpl)))
+		optionsTmpl   = template.Must(template.New("options").Funcs(funcs).Parse(codegen.Template("options", optionsTmpl)))
+		pagerTmpl     = template.Must(template.New("pager").Funcs(funcs).Parse(codegen.Template("pager", pagerTmpl)))
+		streamTmpl    = template.Must(template.New("stream").Funcs(funcs).Parse(codegen.Template("stream", streamTmpl)))
+		channelTmpl   = template.Must(template.New("channel").Funcs(funcs).Parse(codegen.Template("channel", channelTmpl)))
 	)
 	if action.Payload != nil {
 		params = append(params, "payload "+codegen.GoTypeRef(action.Payload, action.Payload.AllRequired(), 1, false))
 		names = append(names, "payload")
 	}
-	initParams := func(att *design.AttributeDefinition) []*paramData {
+	var optional []*paramData
+	initParams := func(att *design.AttributeDefinition, location string) []*paramData {
 		if att == nil {
 			return nil
 		}
@@ -443,27 +548,29 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 				Name:      n,
 				VarName:   varName,
 				Attribute: q,
+				Location:  location,
 			}
-			if q.Type.IsPrimitive() {
-				param.MustToString = q.Type.Kind() != design.StringKind
-				if att.IsRequired(n) {
-					param.ValueName = varName
-					pdata = append(pdata, param)
-				} else {
-					param.ValueName = "*" + varName
-					param.CheckNil = true
-					optData = append(optData, param)
-				}
-			} else {
-				param.MustToString = true
+			if att.IsRequired(n) {
+				param.MustToString = !q.Type.IsPrimitive() || q.Type.Kind() != design.StringKind
 				param.ValueName = varName
-				param.CheckNil = true
-				if att.IsRequired(n) {
-					pdata = append(pdata, param)
-				} else {
-					optData = append(optData, param)
-				}
+				param.CheckNil = !q.Type.IsPrimitive()
+				pdata = append(pdata, param)
+				continue
 			}
+			// Optional parameters are set with functional options and stored in the
+			// fields of the action parameters struct.
+			param.FieldName = codegen.Goify(n, true)
+			param.FieldType = cmdFieldType(q.Type, q.Type.IsPrimitive())
+			param.ArgType = cmdFieldType(q.Type, false)
+			param.VarName = "params." + param.FieldName
+			param.ValueName = param.VarName
+			param.MustToString = !q.Type.IsPrimitive() || q.Type.Kind() != design.StringKind
+			param.CheckNil = true
+			if q.Type.IsPrimitive() {
+				param.ValueName = "*" + param.VarName
+				param.Generic = q.Type.Kind() == design.AnyKind
+			}
+			optData = append(optData, param)
 		}
 
 		sort.Sort(byParamName(pdata))
@@ -474,18 +581,41 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 			names = append(names, p.VarName)
 			params = append(params, p.VarName+" "+cmdFieldType(p.Attribute.Type, false))
 		}
-		for _, p := range optData {
-			names = append(names, p.VarName)
-			params = append(params, p.VarName+" "+cmdFieldType(p.Attribute.Type, p.Attribute.Type.IsPrimitive()))
-		}
+		optional = append(optional, optData...)
 
 		return append(pdata, optData...)
 	}
-	queryParams = initParams(action.QueryParams)
-	headers = initParams(action.Headers)
+	queryParams = initParams(action.QueryParams, "query string parameter")
+	headers = initParams(action.Headers, "header")
+	fields := make(map[string]string, len(optional))
+	var validations []string
+	for _, p := range optional {
+		if other, ok := fields[p.FieldName]; ok {
+			return fmt.Errorf("%s: optional parameters %q and %q map to the same field %s", action.Context(), other, p.Name, p.FieldName)
+		}
+		fields[p.FieldName] = p.Name
+		if v := codegen.RecursiveChecker(p.Attribute, false, false, false, p.VarName, p.Name, 1, false); v != "" {
+			validations = append(validations, v)
+		}
+	}
+	if len(optional) > 0 {
+		names = append(names, "opts...")
+		params = append(params, "opts ..."+codegen.Goify(action.Name+strings.Title(action.Parent.Name), true)+"Option")
+	}
+	var oauth2 bool
 	if action.Security != nil {
 		signer = codegen.Goify(action.Security.Scheme.SchemeName, true)
+		oauth2 = action.Security.Scheme.Kind == design.OAuth2SecurityKind
+	}
+	cacheControl, cacheable := action.CacheControl()
+	var pager *pagerData
+	if p := action.Pagination(); p != nil && !action.WebSocket() {
+		var err error
+		if pager, err = newPagerData(action, p); err != nil {
+			return err
+		}
 	}
+	stream := newStreamData(action, params)
 	data := struct {
 		Name            string
 		ResourceName    string
@@ -496,8 +626,16 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		ParamNames      string
 		CanonicalScheme string
 		Signer          string
+		OAuth2          bool
 		QueryParams     []*paramData
 		Headers         []*paramData
+		Cacheable       bool
+		CacheControl    string
+		Idempotent      bool
+		Optional        []*paramData
+		Validation      string
+		Pager           *pagerData
+		Stream          *streamData
 	}{
 		Name:            action.Name,
 		ResourceName:    action.Parent.Name,
@@ -508,16 +646,182 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		ParamNames:      strings.Join(names, ", "),
 		CanonicalScheme: action.CanonicalScheme(),
 		Signer:          signer,
+		OAuth2:          oauth2,
 		QueryParams:     queryParams,
 		Headers:         headers,
+		Cacheable:       cacheable && action.Routes[0].Verb == "GET",
+		CacheControl:    cacheControl,
+		Idempotent:      action.Idempotent(),
+		Optional:        optional,
+		Validation:      strings.Join(validations, "\n"),
+		Pager:           pager,
+		Stream:          stream,
+	}
+	method := &clientMethod{
+		Name:       codegen.Goify(action.Name+strings.Title(action.Parent.Name), true),
+		Params:     data.Params,
+		ParamNames: data.ParamNames,
+		Result:     "*http.Response",
 	}
 	if action.WebSocket() {
-		return clientsWSTmpl.Execute(file, data)
+		method.Result = "*websocket.Conn"
+	} else if g.grpcPkg != "" {
+		method.GRPC = newGRPCMethod(action, queryParams, headers, len(optional) > 0, len(validations) > 0)
+	}
+	g.clientMethods = append(g.clientMethods, method)
+	if len(optional) > 0 {
+		if err := optionsTmpl.Execute(file, data); err != nil {
+			return err
+		}
+	}
+	if action.WebSocket() {
+		if err := clientsWSTmpl.Execute(file, data); err != nil {
+			return err
+		}
+		return channelTmpl.Execute(file, data)
 	}
 	if err := clientsTmpl.Execute(file, data); err != nil {
 		return err
 	}
-	return requestsTmpl.Execute(file, data)
+	if err := requestsTmpl.Execute(file, data); err != nil {
+		return err
+	}
+	if pager != nil {
+		if err := pagerTmpl.Execute(file, data); err != nil {
+			return err
+		}
+	}
+	if stream != nil && stream.Status != 0 {
+		return streamTmpl.Execute(file, data)
+	}
+	return nil
+}
+
+// newGRPCMethod computes the data needed to generate the gRPC client method of the given action.
+// The request message fields are named after the attributes: the path parameters are extracted
+// from the path given to the method and the other parameters are taken from the method arguments.
+func newGRPCMethod(action *design.ActionDefinition, queryParams, headers []*paramData, options, validate bool) *grpcMethod {
+	m := &grpcMethod{RPC: genproto.ActionRPC(action), Options: options, Validate: validate}
+	all := action.AllParams()
+	var params design.Object
+	if all != nil && all.Type.IsObject() {
+		params = all.Type.ToObject()
+	}
+	seen := make(map[string]bool)
+	for _, r := range action.Routes {
+		for _, n := range r.Params() {
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			value := fmt.Sprintf("p[%q]", n)
+			if att, ok := params[n]; ok {
+				switch {
+				case att.Type.Kind() == design.BooleanKind:
+					value += ` == "true"`
+				case att.Type.IsArray():
+					value = fmt.Sprintf("strings.Split(%s, \",\")", value)
+				}
+			}
+			m.Fields = append(m.Fields, &grpcField{Name: n, Value: value})
+		}
+	}
+	if len(seen) > 0 {
+		for _, r := range action.Routes {
+			m.Routes = append(m.Routes, r.FullPath())
+		}
+	}
+	// The names of the query parameters are Goified by generateResourceClient.
+	names := make(map[string]string, len(params))
+	for n := range params {
+		if !seen[n] {
+			names[codegen.Goify(n, false)] = n
+		}
+	}
+	for _, p := range queryParams {
+		name := p.Name
+		if n, ok := names[name]; ok {
+			name = n
+		}
+		m.Fields = append(m.Fields, &grpcField{Name: name, Value: p.VarName})
+	}
+	for _, p := range headers {
+		m.Fields = append(m.Fields, &grpcField{Name: p.Name, Value: p.VarName})
+	}
+	if action.Payload != nil {
+		m.Fields = append(m.Fields, &grpcField{Name: "payload", Value: "payload"})
+	}
+	sort.Slice(m.Fields, func(i, j int) bool { return m.Fields[i].Name < m.Fields[j].Name })
+	return m
+}
+
+// newStreamData computes the data needed to generate the methods that stream the messages of
+// websocket and streaming actions, it returns nil for other actions. The received messages are
+// described by the media type of the first successful or informational response that defines
+// one.
+func newStreamData(action *design.ActionDefinition, params []string) *streamData {
+	if !action.WebSocket() && action.Stream() == nil {
+		return nil
+	}
+	data := &streamData{Receive: "interface{}", ReceiveName: "interface{}", Send: "interface{}"}
+	action.IterateResponses(func(r *design.ResponseDefinition) error {
+		if data.Status != 0 || r.Status < 100 || r.Status >= 400 {
+			return nil
+		}
+		if mt := design.Design.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			data.Status = r.Status
+			data.Receive = codegen.GoTypeRef(mt, mt.AllRequired(), 0, false)
+			data.ReceiveName = codegen.GoTypeName(mt, mt.AllRequired(), 0, false)
+			data.ReceivePointer = mt.IsObject()
+		}
+		return nil
+	})
+	if action.Payload != nil {
+		data.Send = codegen.GoTypeRef(action.Payload, action.Payload.AllRequired(), 1, false)
+		params = params[1:]
+	}
+	data.Params = strings.Join(params, ", ")
+	return data
+}
+
+// newPagerData computes the data needed to generate the pager of a paginated action from its
+// "OK" response media type.
+func newPagerData(action *design.ActionDefinition, p *design.PaginationDefinition) (*pagerData, error) {
+	var mt *design.MediaTypeDefinition
+	for _, r := range action.Responses {
+		if r.Status == 200 {
+			mt = design.Design.MediaTypeWithIdentifier(r.MediaType)
+		}
+	}
+	if mt == nil {
+		return nil, fmt.Errorf("%s: paginated action must define a 200 response with a media type", action.Context())
+	}
+	data := &pagerData{Decode: "Decode" + typeName(mt)}
+	if p.Cursor != "" {
+		data.Cursor = codegen.Goify(p.Cursor, true)
+	}
+	elems := mt.Type
+	if p.Items != "" {
+		if !mt.Type.IsObject() || mt.Type.ToObject()[p.Items] == nil {
+			return nil, fmt.Errorf("%s: paginated action response media type %s has no attribute %q", action.Context(), mt.Identifier, p.Items)
+		}
+		elems = mt.Type.ToObject()[p.Items].Type
+		data.Items = codegen.Goify(p.Items, true)
+	}
+	if !elems.IsArray() {
+		return nil, fmt.Errorf("%s: paginated action must list the page items in an array, use \"paginate:items\" to name the attribute", action.Context())
+	}
+	elem := elems.ToArray().ElemType
+	data.Item = codegen.GoTypeRef(elem.Type, elem.AllRequired(), 0, false)
+	if p.Next != "" {
+		next := mt.Type.ToObject()[p.Next]
+		if !mt.Type.IsObject() || next == nil || next.Type.Kind() != design.StringKind {
+			return nil, fmt.Errorf("%s: paginated action response media type %s has no string attribute %q", action.Context(), mt.Identifier, p.Next)
+		}
+		data.Next = codegen.Goify(p.Next, true)
+		data.NextPointer = mt.IsPrimitivePointer(p.Next)
+	}
+	return data, nil
 }
 
 // fileServerMethod returns the name of the client method for downloading assets served by the given
@@ -708,6 +1012,15 @@ func signerType(scheme *design.SecuritySchemeDefinition) string {
 	return ""
 }
 
+// signerInit returns the Go expression that initializes the signer of the given security scheme.
+// The signers of OAuth2 schemes that define a token URL obtain the tokens from it.
+func signerInit(scheme *design.SecuritySchemeDefinition) string {
+	if scheme.Kind == design.OAuth2SecurityKind && scheme.TokenURL != "" {
+		return fmt.Sprintf("&goaclient.OAuth2Signer{TokenSource: &goaclient.OAuth2TokenSource{TokenURL: %q}}", scheme.TokenURL)
+	}
+	return "&" + signerType(scheme) + "{}"
+}
+
 // pathTemplate returns a fmt format suitable to build a request path to the reoute.
 func pathTemplate(r *design.RouteDefinition) string {
 	return design.WildcardRegex.ReplaceAllLiteralString(r.FullPath(), "/%v")
@@ -750,6 +1063,103 @@ type paramData struct {
 	Attribute    *design.AttributeDefinition
 	MustToString bool
 	CheckNil     bool
+	// Location describes where the parameter is sent, "query string parameter" or "header".
+	Location string
+	// FieldName is the name of the action parameters struct field holding the optional
+	// parameter, empty for required parameters.
+	FieldName string
+	// FieldType is the Go type of the action parameters struct field.
+	FieldType string
+	// ArgType is the Go type of the value given to the option setting the parameter.
+	ArgType string
+	// Generic is true if the option setting the parameter accepts values of any type.
+	Generic bool
+}
+
+// clientMethod describes a generated action method, it is used to generate the resource client
+// interface and fake.
+type clientMethod struct {
+	// Name is the name of the method.
+	Name string
+	// Params lists the method parameters that follow the context and path.
+	Params string
+	// ParamNames lists the names of the parameters in Params.
+	ParamNames string
+	// Result is the type of the value returned with the error.
+	Result string
+	// GRPC describes the call made by the gRPC client method, nil for websocket actions and when
+	// no gRPC client is generated.
+	GRPC *grpcMethod
+}
+
+// grpcClient describes the gRPC client of a resource.
+type grpcClient struct {
+	// Name is the Goified resource name.
+	Name string
+	// Service is the name of the gRPC service of the resource.
+	Service string
+	// Methods lists the methods of the resource client interface.
+	Methods []*clientMethod
+}
+
+// grpcMethod describes the call made by the gRPC client method of an action.
+type grpcMethod struct {
+	// RPC is the gRPC method of the action.
+	RPC *genproto.RPC
+	// Routes lists the full paths of the action routes used to extract the path parameters,
+	// empty if the action has none.
+	Routes []string
+	// Fields lists the request message fields sorted by name.
+	Fields []*grpcField
+	// Options is true if the method accepts optional parameters.
+	Options bool
+	// Validate is true if the optional parameters must be validated.
+	Validate bool
+}
+
+// grpcField describes a request message field set by a gRPC client method.
+type grpcField struct {
+	// Name is the name of the attribute, it is also the JSON name of the field.
+	Name string
+	// Value is the Go expression computing the field value.
+	Value string
+}
+
+// pagerData is the data structure holding the information needed to generate the pager of a
+// paginated action.
+type pagerData struct {
+	// Cursor is the name of the optional parameters struct field holding the page cursor,
+	// empty if the pages are linked with the Link response header.
+	Cursor string
+	// Next is the name of the response media type field holding the cursor of the next page.
+	Next string
+	// NextPointer is true if the Next field is a pointer.
+	NextPointer bool
+	// Items is the name of the response media type field listing the page items, empty if
+	// the response media type is a collection.
+	Items string
+	// Item is the Go type of the page items.
+	Item string
+	// Decode is the name of the client method that decodes the response media type.
+	Decode string
+}
+
+// streamData is the data structure holding the information needed to generate the methods
+// that stream the messages of websocket and streaming actions.
+type streamData struct {
+	// Status is the status code of the response that streams the messages, 0 if the action
+	// does not describe the messages.
+	Status int
+	// Receive is the Go type of the messages received from the service.
+	Receive string
+	// ReceiveName is the name of the Go type of the messages received from the service.
+	ReceiveName string
+	// ReceivePointer is true if Receive is a pointer to ReceiveName.
+	ReceivePointer bool
+	// Send is the Go type of the messages sent to websocket actions, the action payload type.
+	Send string
+	// Params lists the method parameters, the payload is sent as messages instead.
+	Params string
 }
 
 type byParamName []*paramData
@@ -788,22 +1198,80 @@ func {{ $funcName }}({{ pathParams . }}) string {
 }
 {{ end }}`
 
+const optionsTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{/*
+*/}}// {{ $funcName }}Params contains the optional parameters of the {{ .Name }} action endpoint of the {{ .ResourceName }} resource.
+type {{ $funcName }}Params struct {
+{{ range .Optional }}	// {{ .FieldName }} is the "{{ .Name }}" {{ .Location }}.
+	{{ .FieldName }} {{ .FieldType }}
+{{ end }}}
+
+// {{ $funcName }}Option sets an optional parameter of the {{ .Name }} action endpoint of the {{ .ResourceName }} resource.
+type {{ $funcName }}Option = goaclient.Option[{{ $funcName }}Params]
+
+// With{{ $funcName }}Params sets all the optional parameters of the {{ .Name }} action endpoint of the {{ .ResourceName }} resource,
+// overriding the values set by the previous options.
+func With{{ $funcName }}Params(params *{{ $funcName }}Params) {{ $funcName }}Option {
+	return func(p *{{ $funcName }}Params) {
+		*p = *params
+	}
+}
+{{ range .Optional }}
+// With{{ $funcName }}{{ .FieldName }} sets the "{{ .Name }}" {{ .Location }}.
+{{ if .Generic }}func With{{ $funcName }}{{ .FieldName }}[T any](v T) {{ $funcName }}Option {
+	return func(p *{{ $funcName }}Params) {
+		var i interface{} = v
+		p.{{ .FieldName }} = &i
+	}
+}
+{{ else }}func With{{ $funcName }}{{ .FieldName }}(v {{ .ArgType }}) {{ $funcName }}Option {
+	return func(p *{{ $funcName }}Params) {
+		p.{{ .FieldName }} = {{ if .Attribute.Type.IsPrimitive }}&{{ end }}v
+	}
+}
+{{ end }}{{ end }}{{ if .Validation }}
+// Validate validates the optional parameters against the {{ .Name }} action design.
+func (params *{{ $funcName }}Params) Validate() (err error) {
+{{ .Validation }}
+	return
+}
+{{ end }}`
+
 const clientsTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{ $desc := .Description }}{{/*
 */}}{{ if $desc }}{{ multiComment $desc }}{{ else }}{{/*
-*/}}// {{ $funcName }} makes a request to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource{{ end }}
+*/}}// {{ $funcName }} makes a request to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource{{ end }}{{ if .Cacheable }}
+// The response is cacheable: it is served from the client cache if the cache holds a fresh copy
+// and ctx was created with goaclient.WithCache, stale copies are revalidated using their ETag.
+// Use goaclient.ResponseCacheInfo to retrieve its freshness and age.{{ end }}{{ if .Idempotent }}
+// The action is idempotent: the request is retried according to the client retry policy.{{ end }}
 func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params}},  {{ .Params }}{{ end }}) (*http.Response, error) {
 	req, err := c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }})
 	if err != nil {
 		return nil, err
 	}
-	return c.Client.Do(ctx, req)
+	ctx = goaclient.WithAction(ctx, {{ printf "%q" .ResourceName }}, {{ printf "%q" .Name }})
+{{ if .CacheControl }}	ctx = goaclient.WithCacheHint(ctx, {{ printf "%q" .CacheControl }})
+{{ end }}{{ if .Idempotent }}	ctx = goaclient.WithIdempotent(ctx)
+{{ end }}{{ if .OAuth2 }}	resp, err := c.Client.Do(ctx, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !c.{{ .Signer }}Signer.Invalidate() {
+		return resp, err
+	}
+	// The access token was rejected, retry once with a new token.
+	resp.Body.Close()
+	if req, err = c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }}); err != nil {
+		return nil, err
+	}
+{{ end }}	return c.Client.Do(ctx, req)
 }
 `
 
 const clientsWSTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{ $desc := .Description }}{{/*
 */}}{{ if $desc }}{{ multiComment $desc }}{{ else }}// {{ $funcName }} establishes a websocket connection to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource{{ end }}
 func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}) (*websocket.Conn, error) {
-	scheme := c.Scheme
+{{ if .Optional }}	params := goaclient.ApplyOptions(opts)
+{{ if .Validation }}	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+{{ end }}{{ end }}	scheme := c.Scheme
 	if scheme == "" {
 		scheme = "{{ .CanonicalScheme }}"
 	}
@@ -815,10 +1283,137 @@ func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }
 {{ else }}	values.Set("{{ .Name }}", {{ .ValueName }})
 {{ end }}{{ if .CheckNil }}	}
 {{ end }}{{ end }}	u.RawQuery = values.Encode()
-{{ end }}	return websocket.Dial(u.String(), "", u.String())
+{{ end }}	config, err := websocket.NewConfig(u.String(), u.String())
+	if err != nil {
+		return nil, err
+	}
+	return config.DialContext(ctx)
+}
+`
+
+const pagerTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{/*
+*/}}// {{ $funcName }}Pager iterates over the items returned by the {{ .Name }} action endpoint of the {{ .ResourceName }} resource.
+type {{ $funcName }}Pager = goaclient.Pager[{{ .Pager.Item }}]
+
+// New{{ $funcName }}Pager returns a pager that iterates over the items returned by the {{ .Name }} action endpoint of the
+// {{ .ResourceName }} resource, {{ if .Pager.Cursor }}retrieving the next pages using the cursor of the previous page{{ else }}following the "next" links of the Link response header{{ end }}.
+func (c *Client) New{{ $funcName }}Pager(ctx context.Context, path string{{ if .Params}}, {{ .Params }}{{ end }}) *{{ $funcName }}Pager {
+	return goaclient.NewPager(ctx, func(ctx context.Context, cursor string) ([]{{ .Pager.Item }}, string, error) {
+{{ if .Pager.Cursor }}		opts := opts
+		if cursor != "" {
+			opts = append(opts[:len(opts):len(opts)], With{{ $funcName }}{{ .Pager.Cursor }}(cursor))
+		}
+		resp, err := c.{{ $funcName }}(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }})
+{{ else }}		req, err := c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }})
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor != "" {
+			if req.URL, err = url.Parse(cursor); err != nil {
+				return nil, "", err
+			}
+		}
+		ctx = goaclient.WithAction(ctx, {{ printf "%q" .ResourceName }}, {{ printf "%q" .Name }})
+{{ if .CacheControl }}		ctx = goaclient.WithCacheHint(ctx, {{ printf "%q" .CacheControl }})
+{{ end }}		resp, err := c.Client.Do(ctx, req)
+{{ end }}		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			return nil, "", fmt.Errorf("unexpected response status %s", resp.Status)
+		}
+		decoded, err := c.{{ .Pager.Decode }}(resp)
+		if err != nil {
+			return nil, "", err
+		}
+{{ if .Pager.Cursor }}{{ if .Pager.NextPointer }}		var next string
+		if decoded.{{ .Pager.Next }} != nil {
+			next = *decoded.{{ .Pager.Next }}
+		}
+		return decoded{{ if .Pager.Items }}.{{ .Pager.Items }}{{ end }}, next, nil
+{{ else }}		return decoded{{ if .Pager.Items }}.{{ .Pager.Items }}{{ end }}, decoded.{{ .Pager.Next }}, nil
+{{ end }}{{ else }}		return decoded{{ if .Pager.Items }}.{{ .Pager.Items }}{{ end }}, goaclient.NextLink(resp), nil
+{{ end }}	})
+}
+`
+
+const streamTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{/*
+*/}}// {{ $funcName }}Events iterates over the messages streamed by the {{ .Name }} action endpoint of the {{ .ResourceName }} resource.
+type {{ $funcName }}Events = goaclient.Events[{{ .Stream.Receive }}]
+
+// {{ $funcName }}Stream makes a request to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource that streams the
+// responses as server-sent events and returns an iterator over the decoded messages. The iterator must be closed.
+func (c *Client) {{ $funcName }}Stream(ctx context.Context, path string{{ if .Params}}, {{ .Params }}{{ end }}) (*{{ $funcName }}Events, error) {
+	req, err := c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	ctx = goaclient.WithAction(ctx, {{ printf "%q" .ResourceName }}, {{ printf "%q" .Name }})
+	resp, err := c.Client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != {{ .Stream.Status }} {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return goaclient.NewEvents(resp, func(data []byte) ({{ .Stream.Receive }}, error) {
+		var decoded {{ .Stream.ReceiveName }}
+		err := c.Decoder.Decode(&decoded, bytes.NewReader(data), "")
+		return {{ if .Stream.ReceivePointer }}&{{ end }}decoded, err
+	}), nil
+}
+`
+
+const channelTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{/*
+*/}}// {{ $funcName }}Channel exchanges messages with the {{ .Name }} action endpoint of the {{ .ResourceName }} resource.
+type {{ $funcName }}Channel = goaclient.Channel[{{ .Stream.Send }}, {{ .Stream.Receive }}]
+
+// Open{{ $funcName }}Channel establishes a websocket connection to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource
+// and returns the channel used to send and receive typed messages. The connection is closed when ctx is done or when the
+// channel is closed.
+func (c *Client) Open{{ $funcName }}Channel(ctx context.Context, path string{{ if .Stream.Params }}, {{ .Stream.Params }}{{ end }}) (*{{ $funcName }}Channel, error) {
+{{ if .HasPayload }}	var payload {{ .Stream.Send }}
+{{ end }}	conn, err := c.{{ $funcName }}(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }})
+	if err != nil {
+		return nil, err
+	}
+	return goaclient.NewChannel[{{ .Stream.Send }}, {{ .Stream.Receive }}](ctx, conn), nil
 }
 `
 
+const fakeTmpl = `// {{ .Name }}Client is the interface implemented by the {{ .Name }} resource client methods. Code
+// that depends on it can be tested using a Fake{{ .Name }}Client instead of making HTTP requests.
+type {{ .Name }}Client interface {
+{{ range .Methods }}	{{ .Name }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}) ({{ .Result }}, error)
+{{ end }}}
+
+// Fake{{ .Name }}Client is an in-memory implementation of {{ .Name }}Client. Each method calls
+// the corresponding function field and returns an error if the field is nil. Use
+// goaclient.NewFakeResponse to build the responses returned by the functions, the embedded
+// client decodes them.
+type Fake{{ .Name }}Client struct {
+	*Client
+{{ range .Methods }}	// {{ .Name }}Func implements {{ .Name }}.
+	{{ .Name }}Func func(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}) ({{ .Result }}, error)
+{{ end }}}
+
+// NewFake{{ .Name }}Client returns a fake {{ .Name }} resource client whose methods are not implemented.
+func NewFake{{ .Name }}Client() *Fake{{ .Name }}Client {
+	return &Fake{{ .Name }}Client{Client: New(nil)}
+}
+{{ $name := .Name }}{{ range .Methods }}
+// {{ .Name }} calls {{ .Name }}Func.
+func (f *Fake{{ $name }}Client) {{ .Name }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}) ({{ .Result }}, error) {
+	if f.{{ .Name }}Func == nil {
+		return nil, fmt.Errorf("Fake{{ $name }}Client: {{ .Name }} not implemented")
+	}
+	return f.{{ .Name }}Func(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }})
+}
+{{ end }}`
+
 const fsTmpl = `// {{ .Name }} downloads {{ if .DirName }}{{ .DirName }}files with the given filename{{ else }}{{ .FileName }}{{ end }} and writes it to the file dest.
 // It returns the number of bytes downloaded in case of success.
 func (c * Client) {{ .Name }}(ctx context.Context, {{ if .DirName }}filename, {{ end }}dest string) (int64, error) {
@@ -828,7 +1423,7 @@ func (c * Client) {{ .Name }}(ctx context.Context, {{ if .DirName }}filename, {{
 	}
 {{ if .DirName }}	p := path.Join("{{ .RequestDir }}", filename)
 {{ end }}	u := url.URL{Host: c.Host, Scheme: scheme, Path: {{ if .DirName }}p{{ else }}"{{ .RequestPath }}"{{ end }}}
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
 		return 0, err
 	}
@@ -863,7 +1458,11 @@ func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode body: %s", err)
 	}
-{{ end }}	scheme := c.Scheme
+{{ end }}{{ if .Optional }}	params := goaclient.ApplyOptions(opts)
+{{ if .Validation }}	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+{{ end }}{{ end }}	scheme := c.Scheme
 	if scheme == "" {
 		scheme = "{{ .CanonicalScheme }}"
 	}
@@ -875,8 +1474,8 @@ func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }
 {{ else }}	values.Set("{{ .Name }}", {{ .ValueName }})
 {{ end }}{{ if .CheckNil }}	}
 {{ end }}{{ end }}	u.RawQuery = values.Encode()
-{{ end }}{{ if .HasPayload }}	req, err := http.NewRequest({{ $route := index .Routes 0 }}"{{ $route.Verb }}", u.String(), &body)
-{{ else }}	req, err := http.NewRequest({{ $route := index .Routes 0 }}"{{ $route.Verb }}", u.String(), nil)
+{{ end }}{{ if .HasPayload }}	req, err := http.NewRequestWithContext(ctx, {{ $route := index .Routes 0 }}"{{ $route.Verb }}", u.String(), &body)
+{{ else }}	req, err := http.NewRequestWithContext(ctx, {{ $route := index .Routes 0 }}"{{ $route.Verb }}", u.String(), nil)
 {{ end }}	if err != nil {
 		return nil, err
 	}
@@ -886,12 +1485,35 @@ func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }
 	header.Set("{{ .Name }}", {{ $tmp }}){{ else }}
 	header.Set("{{ .Name }}", {{ .ValueName }})
 {{ end }}{{ if .CheckNil }}	}
-{{ end }}{{ end }}{{ end }}{{ if .Signer }}	c.{{ .Signer }}Signer.Sign(ctx, req)
-{{ end }}	return req, nil
+{{ end }}{{ end }}{{ end }}{{ if .Signer }}	if err := c.{{ .Signer }}Signer.Sign(ctx, req); err != nil {
+		return nil, err
+	}
+{{ end }}	if err := c.SignRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
 }
 `
 
-const clientTmpl = `// Client is the {{ .API.Name }} service client.
+const clientTmpl = `// SDKVersion is the version of the client sent in the X-SDK-Version header, it is derived from the
+// API version and the version of goagen that generated the client.
+const SDKVersion = {{ printf "%q" .SDKVersion }}
+
+// Client is the {{ .API.Name }} service client. Set Retry to retry the failed requests, set Cache
+// to cache the responses of GET requests and revalidate them using their ETag, set RequestSigner
+// to sign all the requests (e.g. with HMAC or AWS SigV4 signatures) and use Use to add middleware
+// that run around each request, e.g.:
+//
+//	c := New(nil)
+//	c.Retry = goaclient.DefaultRetryPolicy()
+//	c.Cache = goaclient.NewMemoryCache(0)
+//	c.RequestSigner = &goaclient.HMACSigner{KeyID: "key", Secret: "secret"}
+//	c.Use(goaclient.LogMiddleware(), goaclient.MetricsMiddleware("client"))
+//
+// The requests are bound to the context given to the client methods, use WithTimeout to set a
+// default timeout for the requests whose context has no deadline. The trace of the span held by
+// the context, e.g. the span created by the service tracer middleware, is propagated to the
+// service so that traces span service boundaries, as is the ID of the request being handled.
 type Client struct {
 	*goaclient.Client{{range $security := .API.SecuritySchemes }}{{ $signer := signerType $security }}{{ if $signer }}
 	{{ goify $security.SchemeName true }}Signer *{{ $signer }}{{ end }}{{ end }}
@@ -899,14 +1521,31 @@ type Client struct {
 	Decoder *goa.HTTPDecoder
 }
 
-// New instantiates the client.
-func New(c *http.Client) *Client {
+// ClientOption configures the client created by New.
+type ClientOption = goaclient.Option[goaclient.Client]
+
+// WithTimeout sets the timeout of the requests whose context has no deadline. The timeout covers
+// the whole request including dialing, the TLS handshake and reading the response body.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *goaclient.Client) {
+		c.RequestTimeout = d
+	}
+}
+
+// New instantiates the client and applies the given options.
+func New(c *http.Client, opts ...ClientOption) *Client {
 	client := &Client{
 		Client: goaclient.New(c),{{range $security := .API.SecuritySchemes }}{{ $signer := signerType $security }}{{ if $signer }}
-		{{ goify $security.SchemeName true }}Signer: &{{ $signer }}{},{{ end }}{{ end }}
+		{{ goify $security.SchemeName true }}Signer: {{ signerInit $security }},{{ end }}{{ end }}
 		Encoder: goa.NewHTTPEncoder(),
 		Decoder: goa.NewHTTPDecoder(),
 	}
+	client.SDKVersion = SDKVersion
+	client.SDKLanguage = "go"
+	client.Use(goaclient.TraceMiddleware(), goaclient.RequestIDMiddleware())
+	for _, o := range opts {
+		o(client.Client)
+	}
 
 {{ if .Encoders }}	// Setup encoders and decoders
 {{ range .Encoders }}{{/*
@@ -923,4 +1562,209 @@ func New(c *http.Client) *Client {
 {{ end }}{{ end }}
 {{ end }}	return client
 }
+
+// NewInProcess instantiates a client that sends the requests directly to the service mux instead
+// of going through the network. The controllers must be mounted on the service before the client
+// is used.
+func NewInProcess(service *goa.Service) *Client {
+	client := New(goaclient.NewInProcessClient(service.Mux))
+	client.Host = {{ if .API.Host }}{{ printf "%q" .API.Host }}{{ else }}"localhost"{{ end }}
+	return client
+}
+`
+
+const grpcTmpl = `{{ range . }}{{ $type := printf "GRPC%sClient" .Name }}
+// {{ $type }} implements {{ .Name }}Client by calling the methods of the {{ .Service }} gRPC
+// service instead of making HTTP requests, so that callers may switch transports without code
+// changes. The response messages are converted to HTTP responses that the embedded client decodes
+// and the gRPC status errors to goa error responses.
+type {{ $type }} struct {
+	*Client
+	svc pb.{{ .Service }}Client
+}
+
+// New{{ $type }} returns a {{ .Name }} resource client that calls the {{ .Service }} gRPC service
+// through cc.
+func New{{ $type }}(cc grpc.ClientConnInterface) *{{ $type }} {
+	return &{{ $type }}{Client: New(nil), svc: pb.New{{ .Service }}Client(cc)}
+}
+
+var _ {{ .Name }}Client = (*{{ $type }})(nil)
+{{ range .Methods }}{{ if .GRPC }}{{ $rpc := .GRPC.RPC }}
+// {{ .Name }} calls the {{ $rpc.Name }} method of the {{ $rpc.Service }} gRPC service.
+func (c *{{ $type }}) {{ .Name }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}) ({{ .Result }}, error) {
+{{ if .GRPC.Options }}	params := goaclient.ApplyOptions(opts)
+{{ if .GRPC.Validate }}	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+{{ end }}{{ end }}{{ if .GRPC.Routes }}	p, err := grpcPathParams(path{{ range .GRPC.Routes }}, {{ printf "%q" . }}{{ end }})
+	if err != nil {
+		return nil, err
+	}
+{{ end }}	in := &pb.{{ $rpc.Request }}{}
+{{ if .GRPC.Fields }}	if err := grpcRequest(map[string]interface{}{
+{{ range .GRPC.Fields }}		{{ printf "%q" .Name }}: {{ .Value }},
+{{ end }}	}, in); err != nil {
+		return nil, err
+	}
+{{ end }}{{ if eq $rpc.Response "google.protobuf.Empty" }}	if _, err := c.svc.{{ $rpc.Name }}(ctx, in); err != nil {
+		return grpcErrorResponse(ctx, err)
+	}
+	return goaclient.NewFakeResponse({{ $rpc.Status }}, nil), nil
+{{ else }}	out, err := c.svc.{{ $rpc.Name }}(ctx, in)
+	if err != nil {
+		return grpcErrorResponse(ctx, err)
+	}
+	return grpcResponse({{ $rpc.Status }}, {{ printf "%q" $rpc.ContentType }}, out, {{ printf "%q" $rpc.Unwrap }})
+{{ end }}}
+{{ else }}
+// {{ .Name }} returns an error, websocket actions are not available over gRPC.
+func (c *{{ $type }}) {{ .Name }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}) ({{ .Result }}, error) {
+	return nil, fmt.Errorf("{{ $type }}: {{ .Name }} is not available over gRPC")
+}
+{{ end }}{{ end }}{{ end }}
+// grpcPathParams returns the values of the parameters of the first route pattern matching path.
+func grpcPathParams(path string, patterns ...string) (map[string]string, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, pattern := range patterns {
+		params := make(map[string]string)
+		elems := strings.Split(strings.Trim(pattern, "/"), "/")
+		for i, e := range elems {
+			if strings.HasPrefix(e, "*") && i < len(segments) {
+				params[e[1:]] = strings.Join(segments[i:], "/")
+				return params, nil
+			}
+			if i >= len(segments) {
+				break
+			}
+			if strings.HasPrefix(e, ":") {
+				v, err := url.PathUnescape(segments[i])
+				if err != nil {
+					return nil, err
+				}
+				params[e[1:]] = v
+			} else if e != segments[i] {
+				break
+			}
+			if i == len(elems)-1 && len(elems) == len(segments) {
+				return params, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("path %q does not match %s", path, strings.Join(patterns, " or "))
+}
+
+// grpcRequest initializes the request message m from the method parameters and payload.
+func grpcRequest(fields map[string]interface{}, m proto.Message) error {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, m)
+}
+
+// grpcResponse returns a HTTP response whose body is the JSON representation of m. unwrap names
+// the field holding the body of the messages that wrap arrays or primitive values.
+func grpcResponse(code int, contentType string, m proto.Message, unwrap string) (*http.Response, error) {
+	b, err := protojson.MarshalOptions{EmitUnpopulated: unwrap != ""}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	// protojson encodes 64-bit integers as strings, decode them as numbers instead.
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var fields map[string]interface{}
+	if err := d.Decode(&fields); err != nil {
+		return nil, err
+	}
+	grpcNumbers(m.ProtoReflect().Descriptor(), fields)
+	var body interface{} = fields
+	if unwrap != "" {
+		body = fields[unwrap]
+	}
+	if b, err = json.Marshal(body); err != nil {
+		return nil, err
+	}
+	resp := goaclient.NewFakeResponse(code, b)
+	resp.Header.Set("Content-Type", contentType)
+	return resp, nil
+}
+
+// grpcNumbers replaces the strings encoding the 64-bit integer fields of the JSON representation
+// of the message described by md with numbers.
+func grpcNumbers(md protoreflect.MessageDescriptor, fields map[string]interface{}) {
+	for name, v := range fields {
+		fd := md.Fields().ByJSONName(name)
+		if fd == nil {
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			if m, ok := v.(map[string]interface{}); ok {
+				for k, e := range m {
+					m[k] = grpcNumber(fd.MapValue(), e)
+				}
+			}
+		case fd.IsList():
+			if l, ok := v.([]interface{}); ok {
+				for i, e := range l {
+					l[i] = grpcNumber(fd, e)
+				}
+			}
+		default:
+			fields[name] = grpcNumber(fd, v)
+		}
+	}
+}
+
+// grpcNumber converts a single value of the field described by fd.
+func grpcNumber(fd protoreflect.FieldDescriptor, v interface{}) interface{} {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if s, ok := v.(string); ok {
+			return json.Number(s)
+		}
+	case protoreflect.MessageKind:
+		m, ok := v.(map[string]interface{})
+		if ok && fd.Message().ParentFile().Package() != "google.protobuf" {
+			grpcNumbers(fd.Message(), m)
+		}
+	}
+	return v
+}
+
+// grpcHTTPStatus maps the gRPC status codes to HTTP statuses.
+var grpcHTTPStatus = map[codes.Code]int{
+	codes.Canceled:           499,
+	codes.InvalidArgument:    400,
+	codes.DeadlineExceeded:   504,
+	codes.NotFound:           404,
+	codes.AlreadyExists:      409,
+	codes.PermissionDenied:   403,
+	codes.Unauthenticated:    401,
+	codes.ResourceExhausted:  429,
+	codes.FailedPrecondition: 400,
+	codes.Aborted:            409,
+	codes.OutOfRange:         400,
+	codes.Unimplemented:      501,
+	codes.Unavailable:        503,
+}
+
+// grpcErrorResponse converts the gRPC status errors into goa error responses whose status
+// corresponds to the gRPC status code. It returns the other errors and the errors caused by ctx
+// being done as is, as the HTTP client does.
+func grpcErrorResponse(ctx context.Context, err error) (*http.Response, error) {
+	s, ok := status.FromError(err)
+	if !ok || ctx.Err() != nil {
+		return nil, err
+	}
+	code, ok := grpcHTTPStatus[s.Code()]
+	if !ok {
+		code = http.StatusInternalServerError
+	}
+	resp := goaclient.NewFakeResponse(code, goa.NewErrorClass(s.Code().String(), code)(s.Message()))
+	resp.Header.Set("Content-Type", goa.ErrorMediaIdentifier)
+	return resp, nil
+}
 `
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
 	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/gen_client"
 	. "github.com/onsi/ginkgo"
@@ -80,6 +81,39 @@ var _ = Describe("Generate", func() {
 			Ω(strings.Count(string(content), "func ShowFooPath2(")).Should(Equal(1))
 		})
 
+		It("does not generate gRPC clients", func() {
+			Ω(genErr).Should(BeNil())
+			_, err := os.Stat(filepath.Join(outDir, "client", "grpc.go"))
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
+
+		It("sets the SDK version and language headers", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`const SDKVersion = "0+goagen.` + codegen.Version + `"`))
+			Ω(content).Should(ContainSubstring("client.SDKVersion = SDKVersion"))
+			Ω(content).Should(ContainSubstring(`client.SDKLanguage = "go"`))
+		})
+
+		It("propagates the traces and request IDs", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("client.Use(goaclient.TraceMiddleware(), goaclient.RequestIDMiddleware())"))
+		})
+
+		It("binds the requests to the context and sets the default timeout", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)`))
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func New(c *http.Client, opts ...ClientOption) *Client {"))
+			Ω(content).Should(ContainSubstring("func WithTimeout(d time.Duration) ClientOption {"))
+		})
+
 		Context("with a file server", func() {
 			BeforeEach(func() {
 				res := design.Design.Resources["foo"]
@@ -103,6 +137,551 @@ var _ = Describe("Generate", func() {
 		})
 	})
 
+	Context("with an action with a cacheable response", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"show": {
+								Name: "show",
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "",
+									},
+								},
+								Responses: map[string]*design.ResponseDefinition{
+									"ok": {
+										Name:   "ok",
+										Status: 200,
+										Headers: &design.AttributeDefinition{
+											Type: design.Object{
+												"Cache-Control": &design.AttributeDefinition{
+													Type:         design.String,
+													DefaultValue: "max-age=60",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			showAct := fooRes.Actions["show"]
+			showAct.Parent = fooRes
+			showAct.Routes[0].Parent = showAct
+		})
+
+		It("sets the cache hint declared in the design", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`ctx = goaclient.WithCacheHint(ctx, "max-age=60")`))
+			Ω(content).Should(ContainSubstring("goaclient.WithCache"))
+			Ω(content).Should(ContainSubstring("stale copies are revalidated using their ETag"))
+		})
+	})
+
+	Context("with an idempotent action", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"create": {
+								Name:     "create",
+								Metadata: dslengine.MetadataDefinition{"retry:idempotent": nil},
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "POST",
+										Path: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			createAct := fooRes.Actions["create"]
+			createAct.Parent = fooRes
+			createAct.Routes[0].Parent = createAct
+		})
+
+		It("marks the requests as idempotent", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("ctx = goaclient.WithIdempotent(ctx)\n\treturn c.Client.Do(ctx, req)"))
+		})
+
+		It("records the action for the client middleware", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`ctx = goaclient.WithAction(ctx, "foo", "create")`))
+		})
+	})
+
+	Context("with an action with typed headers", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"show": {
+								Name: "show",
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "",
+									},
+								},
+								Headers: &design.AttributeDefinition{
+									Type: design.Object{
+										"X-Count": &design.AttributeDefinition{Type: design.Integer},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			showAct := fooRes.Actions["show"]
+			showAct.Parent = fooRes
+			showAct.Routes[0].Parent = showAct
+		})
+
+		It("formats the header values using the header names", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("XCount *int"))
+			Ω(content).Should(ContainSubstring("func WithShowFooXCount(v int) ShowFooOption {"))
+			Ω(content).Should(ContainSubstring("params := goaclient.ApplyOptions(opts)"))
+			Ω(content).Should(ContainSubstring(`header.Set("X-Count", tmp`))
+		})
+	})
+
+	Context("with an action with optional query string parameters", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			query := &design.AttributeDefinition{
+				Type: design.Object{
+					"region": &design.AttributeDefinition{Type: design.String},
+					"sort": &design.AttributeDefinition{
+						Type:       design.String,
+						Validation: &dslengine.ValidationDefinition{Values: []interface{}{"name", "year"}},
+					},
+				},
+				Validation: &dslengine.ValidationDefinition{Required: []string{"region"}},
+			}
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"list": {
+								Name: "list",
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "",
+									},
+								},
+								Params:      query,
+								QueryParams: query,
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			listAct := fooRes.Actions["list"]
+			listAct.Parent = fooRes
+			listAct.Routes[0].Parent = listAct
+		})
+
+		It("sets the optional parameters with functional options", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (c *Client) ListFoo(ctx context.Context, path string, region string, opts ...ListFooOption) (*http.Response, error) {"))
+			Ω(content).Should(ContainSubstring("type ListFooOption = goaclient.Option[ListFooParams]"))
+			Ω(content).Should(ContainSubstring("func WithListFooSort(v string) ListFooOption {"))
+			Ω(content).Should(ContainSubstring(`values.Set("sort", *params.Sort)`))
+		})
+
+		It("validates the optional parameters against the design", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (params *ListFooParams) Validate() (err error) {"))
+			Ω(content).Should(ContainSubstring("goa.InvalidEnumValueError(`sort`, *params.Sort"))
+			Ω(content).Should(ContainSubstring("if err := params.Validate(); err != nil {"))
+		})
+	})
+
+	Context("with a paginated action", func() {
+		var metadata dslengine.MetadataDefinition
+
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			metadata = dslengine.MetadataDefinition{
+				"paginate:cursor": {"page_token"},
+				"paginate:next":   {"next_page_token"},
+				"paginate:items":  {"items"},
+			}
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "Bottle",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"name": {Type: design.String}},
+					},
+				},
+				Identifier: "application/vnd.bottle+json",
+			}
+			bottle.Views = map[string]*design.ViewDefinition{
+				"default": {AttributeDefinition: bottle.AttributeDefinition, Name: "default", Parent: bottle},
+			}
+			page := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "BottlePage",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{
+							"items":           {Type: &design.Array{ElemType: &design.AttributeDefinition{Type: bottle}}},
+							"next_page_token": {Type: design.String},
+						},
+					},
+				},
+				Identifier: "application/vnd.bottle-page+json",
+			}
+			page.Views = map[string]*design.ViewDefinition{
+				"default": {AttributeDefinition: page.AttributeDefinition, Name: "default", Parent: page},
+			}
+			query := &design.AttributeDefinition{
+				Type: design.Object{"page_token": &design.AttributeDefinition{Type: design.String}},
+			}
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"list": {
+								Name:     "list",
+								Metadata: metadata,
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "",
+									},
+								},
+								Params:      query,
+								QueryParams: query,
+								Responses: map[string]*design.ResponseDefinition{
+									"OK": {
+										Name:      "OK",
+										Status:    200,
+										MediaType: page.Identifier,
+									},
+								},
+							},
+						},
+					},
+				},
+				MediaTypes: map[string]*design.MediaTypeDefinition{
+					bottle.Identifier: bottle,
+					page.Identifier:   page,
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			listAct := fooRes.Actions["list"]
+			listAct.Parent = fooRes
+			listAct.Routes[0].Parent = listAct
+		})
+
+		It("generates a pager following the page cursors", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("type ListFooPager = goaclient.Pager[*Bottle]"))
+			Ω(content).Should(ContainSubstring("func (c *Client) NewListFooPager(ctx context.Context, path string, opts ...ListFooOption) *ListFooPager {"))
+			Ω(content).Should(ContainSubstring("opts = append(opts[:len(opts):len(opts)], WithListFooPageToken(cursor))"))
+			Ω(content).Should(ContainSubstring("decoded, err := c.DecodeBottlePage(resp)"))
+			Ω(content).Should(ContainSubstring("next = *decoded.NextPageToken"))
+			Ω(content).Should(ContainSubstring("return decoded.Items, next, nil"))
+		})
+
+		Context("using the Link response header", func() {
+			BeforeEach(func() {
+				delete(metadata, "paginate:cursor")
+				delete(metadata, "paginate:next")
+				metadata["paginate:link"] = nil
+			})
+
+			It("generates a pager following the next links", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring("if req.URL, err = url.Parse(cursor); err != nil {"))
+				Ω(content).Should(ContainSubstring("return decoded.Items, goaclient.NextLink(resp), nil"))
+			})
+		})
+
+		Context("with items that are not an array", func() {
+			BeforeEach(func() {
+				metadata["paginate:items"] = []string{"next_page_token"}
+			})
+
+			It("returns an error", func() {
+				Ω(genErr).Should(HaveOccurred())
+				Ω(genErr.Error()).Should(ContainSubstring("paginated action must list the page items in an array"))
+			})
+		})
+	})
+
+	Context("with streaming actions", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "Bottle",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"name": {Type: design.String}},
+					},
+				},
+				Identifier: "application/vnd.bottle+json",
+			}
+			bottle.Views = map[string]*design.ViewDefinition{
+				"default": {AttributeDefinition: bottle.AttributeDefinition, Name: "default", Parent: bottle},
+			}
+			payload := &design.UserTypeDefinition{
+				TypeName: "TastePayload",
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"rating": {Type: design.Integer}},
+				},
+			}
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"watch": {
+								Name:     "watch",
+								Metadata: dslengine.MetadataDefinition{"stream:buffer": {"16"}},
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "/watch",
+									},
+								},
+								Responses: map[string]*design.ResponseDefinition{
+									"OK": {
+										Name:      "OK",
+										Status:    200,
+										MediaType: bottle.Identifier,
+									},
+								},
+							},
+							"taste": {
+								Name:    "taste",
+								Schemes: []string{"ws"},
+								Payload: payload,
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "/taste",
+									},
+								},
+								Responses: map[string]*design.ResponseDefinition{
+									"SwitchingProtocols": {
+										Name:      "SwitchingProtocols",
+										Status:    101,
+										MediaType: bottle.Identifier,
+									},
+								},
+							},
+						},
+					},
+				},
+				MediaTypes: map[string]*design.MediaTypeDefinition{
+					bottle.Identifier: bottle,
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			for _, a := range fooRes.Actions {
+				a.Parent = fooRes
+				a.Routes[0].Parent = a
+			}
+		})
+
+		It("generates an iterator over the streamed messages", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("type WatchFooEvents = goaclient.Events[*Bottle]"))
+			Ω(content).Should(ContainSubstring("func (c *Client) WatchFooStream(ctx context.Context, path string) (*WatchFooEvents, error) {"))
+			Ω(content).Should(ContainSubstring(`req.Header.Set("Accept", "text/event-stream")`))
+			Ω(content).Should(ContainSubstring("return goaclient.NewEvents(resp, func(data []byte) (*Bottle, error) {"))
+		})
+
+		It("generates typed channels for websocket actions", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("type TasteFooChannel = goaclient.Channel[*TastePayload, *Bottle]"))
+			Ω(content).Should(ContainSubstring("func (c *Client) OpenTasteFooChannel(ctx context.Context, path string) (*TasteFooChannel, error) {"))
+			Ω(content).Should(ContainSubstring("conn, err := c.TasteFoo(ctx, path, payload)"))
+		})
+
+		It("generates the resource client interface and fake", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("type FooClient interface {"))
+			Ω(content).Should(ContainSubstring("TasteFoo(ctx context.Context, path string, payload *TastePayload) (*websocket.Conn, error)"))
+			Ω(content).Should(ContainSubstring("WatchFoo(ctx context.Context, path string) (*http.Response, error)"))
+			Ω(content).Should(ContainSubstring("WatchFooFunc func(ctx context.Context, path string) (*http.Response, error)"))
+			Ω(content).Should(ContainSubstring("return &FakeFooClient{Client: New(nil)}"))
+			Ω(content).Should(ContainSubstring("func (f *FakeFooClient) TasteFoo(ctx context.Context, path string, payload *TastePayload) (*websocket.Conn, error) {"))
+			Ω(content).Should(ContainSubstring("return f.TasteFooFunc(ctx, path, payload)"))
+		})
+	})
+
+	Context("with the gRPC flag", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			os.Args = append(os.Args, "--grpc="+testgenPackagePath+"/proto;pb")
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "Bottle",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"id": {Type: design.Integer}},
+					},
+				},
+				Identifier: "application/vnd.bottle+json",
+			}
+			bottle.Views = map[string]*design.ViewDefinition{
+				"default": {AttributeDefinition: bottle.AttributeDefinition, Name: "default", Parent: bottle},
+			}
+			params := &design.AttributeDefinition{
+				Type: design.Object{
+					"id":      {Type: design.Integer},
+					"dry_run": {Type: design.Boolean},
+				},
+			}
+			query := &design.AttributeDefinition{
+				Type: design.Object{"dry_run": {Type: design.Boolean}},
+			}
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"bottle": {
+						Name: "bottle",
+						Actions: map[string]*design.ActionDefinition{
+							"update": {
+								Name: "update",
+								Payload: &design.UserTypeDefinition{
+									TypeName: "UpdateBottlePayload",
+									AttributeDefinition: &design.AttributeDefinition{
+										Type: design.Object{"name": {Type: design.String}},
+									},
+								},
+								Routes:      []*design.RouteDefinition{{Verb: "PUT", Path: "/bottles/:id"}},
+								Params:      params,
+								QueryParams: query,
+								Headers: &design.AttributeDefinition{
+									Type:       design.Object{"X-Request-Id": {Type: design.String}},
+									Validation: &dslengine.ValidationDefinition{Required: []string{"X-Request-Id"}},
+								},
+								Responses: map[string]*design.ResponseDefinition{
+									"OK": {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+								},
+							},
+							"delete": {
+								Name:        "delete",
+								Routes:      []*design.RouteDefinition{{Verb: "DELETE", Path: "/bottles/:id"}},
+								Params:      &design.AttributeDefinition{Type: design.Object{"id": {Type: design.Integer}}},
+								QueryParams: &design.AttributeDefinition{Type: design.Object{}},
+								Responses: map[string]*design.ResponseDefinition{
+									"NoContent": {Name: "NoContent", Status: 204},
+								},
+							},
+							"watch": {
+								Name:    "watch",
+								Schemes: []string{"ws"},
+								Routes:  []*design.RouteDefinition{{Verb: "GET", Path: "/bottles/watch"}},
+							},
+						},
+					},
+				},
+				MediaTypes: map[string]*design.MediaTypeDefinition{bottle.Identifier: bottle},
+			}
+			res := design.Design.Resources["bottle"]
+			for _, a := range res.Actions {
+				a.Parent = res
+				a.Routes[0].Parent = a
+			}
+		})
+
+		It("generates a gRPC client implementing the resource client interface", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(ContainElement(filepath.Join(outDir, "client", "grpc.go")))
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "grpc.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`pb "` + testgenPackagePath + `/proto"`))
+			Ω(content).Should(ContainSubstring("func NewGRPCBottleClient(cc grpc.ClientConnInterface) *GRPCBottleClient {"))
+			Ω(content).Should(ContainSubstring("return &GRPCBottleClient{Client: New(nil), svc: pb.NewBottleServiceClient(cc)}"))
+			Ω(content).Should(ContainSubstring("var _ BottleClient = (*GRPCBottleClient)(nil)"))
+		})
+
+		It("builds the request messages from the method parameters", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "grpc.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (c *GRPCBottleClient) UpdateBottle(ctx context.Context, path string, payload *UpdateBottlePayload, xRequestID string, opts ...UpdateBottleOption) (*http.Response, error) {"))
+			Ω(content).Should(ContainSubstring(`p, err := grpcPathParams(path, "/bottles/:id")`))
+			Ω(content).Should(ContainSubstring(`"X-Request-Id": xRequestID,`))
+			Ω(content).Should(ContainSubstring(`"dry_run":      params.DryRun,`))
+			Ω(content).Should(ContainSubstring(`"id":           p["id"],`))
+			Ω(content).Should(ContainSubstring(`"payload":      payload,`))
+			Ω(content).Should(ContainSubstring("out, err := c.svc.Update(ctx, in)"))
+		})
+
+		It("converts the responses to HTTP responses", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "grpc.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`return grpcResponse(200, "application/vnd.bottle+json", out, "")`))
+			Ω(content).Should(ContainSubstring("if _, err := c.svc.Delete(ctx, in); err != nil {"))
+			Ω(content).Should(ContainSubstring("return goaclient.NewFakeResponse(204, nil), nil"))
+			Ω(content).Should(ContainSubstring("return grpcErrorResponse(ctx, err)"))
+		})
+
+		It("does not call gRPC for websocket actions", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "grpc.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (c *GRPCBottleClient) WatchBottle(ctx context.Context, path string) (*websocket.Conn, error) {"))
+			Ω(content).Should(ContainSubstring(`fmt.Errorf("GRPCBottleClient: WatchBottle is not available over gRPC")`))
+		})
+	})
+
 	Context("with an action with security configured", func() {
 		BeforeEach(func() {
 			codegen.TempCount = 0
@@ -159,6 +738,15 @@ var _ = Describe("Generate", func() {
 			Ω(content).Should(ContainSubstring("JWT1Signer: &goaclient.JWTSigner{},"))
 		})
 
+		It("generates the in-process client constructor", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func NewInProcess(service *goa.Service) *Client {"))
+			Ω(content).Should(ContainSubstring("client := New(goaclient.NewInProcessClient(service.Mux))"))
+			Ω(content).Should(ContainSubstring(`client.Host = "localhost"`))
+		})
+
 		It("generates the Signer.Sign call from Action", func() {
 			Ω(genErr).Should(BeNil())
 			Ω(files).Should(HaveLen(7))
@@ -166,5 +754,63 @@ var _ = Describe("Generate", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(content).Should(ContainSubstring("c.JWT1Signer.Sign(ctx, req)"))
 		})
+
+		It("signs the requests with the client request signer", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("if err := c.SignRequest(ctx, req); err != nil {\n\t\treturn nil, err\n\t}\n\treturn req, nil"))
+		})
+	})
+
+	Context("with an action secured with OAuth2", func() {
+		BeforeEach(func() {
+			scheme := &design.SecuritySchemeDefinition{
+				SchemeName: "oauth2",
+				Kind:       design.OAuth2SecurityKind,
+				Flow:       "application",
+				TokenURL:   "https://auth.example.com/token",
+			}
+			design.Design = &design.APIDefinition{
+				Name:            "testapi",
+				SecuritySchemes: []*design.SecuritySchemeDefinition{scheme},
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"show": {
+								Name: "show",
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "",
+									},
+								},
+								Security: &design.SecurityDefinition{Scheme: scheme},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			showAct := fooRes.Actions["show"]
+			showAct.Parent = fooRes
+			showAct.Routes[0].Parent = showAct
+		})
+
+		It("obtains the tokens from the token URL", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`OAuth2Signer: &goaclient.OAuth2Signer{TokenSource: &goaclient.OAuth2TokenSource{TokenURL: "https://auth.example.com/token"}},`))
+		})
+
+		It("retries once with a new token on 401", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("resp.StatusCode != http.StatusUnauthorized || !c.OAuth2Signer.Invalidate()"))
+			Ω(content).Should(ContainSubstring("if req, err = c.NewShowFooRequest(ctx, path); err != nil {"))
+		})
 	})
 })
@@ -0,0 +1,14 @@
+/*
+Package gendesign provides a generator that writes a design package approximating a Swagger 2.0 or
+OpenAPI 3 document. It is the reverse of the genswagger generator: the operations become actions
+grouped into resources by tag, the object definitions used by responses become media types and
+the other object definitions types. The parameters, payloads, security schemes and validations
+are imported when they can be described with the DSL, the response bodies that are not media
+types are not imported.
+
+The generated package is a starting point that should be reviewed before generating the service.
+The goagen "bootstrap" command runs the generator when given the --from-swagger flag:
+
+	goagen bootstrap --from-swagger swagger.json -o $GOPATH/src/github.com/acme/cellar
+*/
+package gendesign
@@ -0,0 +1,13 @@
+package gendesign_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenDesign(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenDesign Suite")
+}
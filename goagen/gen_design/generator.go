@@ -0,0 +1,77 @@
+package gendesign
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+// Generator is the design package generator.
+type Generator struct {
+	genfiles []string // Generated files
+	specPath string   // Path to the Swagger or OpenAPI document
+	outDir   string   // Path to output directory
+}
+
+// NewGenerator returns a generator that writes the design package produced from the Swagger or
+// OpenAPI document at specPath in the "design" directory of outDir.
+func NewGenerator(specPath, outDir string) *Generator {
+	return &Generator{specPath: specPath, outDir: outDir}
+}
+
+// Generate writes the design package. It does not overwrite an existing design package.
+func (g *Generator) Generate() (_ []string, err error) {
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	s, err := loadSpec(g.specPath)
+	if err != nil {
+		return nil, err
+	}
+	code := newImporter(s).design()
+
+	designDir := filepath.Join(g.outDir, "design")
+	designFile := filepath.Join(designDir, "design.go")
+	if _, err := os.Stat(designFile); err == nil {
+		return nil, fmt.Errorf("%s already exists, remove it or use a different output directory", designFile)
+	}
+	if _, err = os.Stat(designDir); err != nil {
+		if err = os.MkdirAll(designDir, 0755); err != nil {
+			return nil, err
+		}
+		g.genfiles = append(g.genfiles, designDir)
+	}
+	file, err := codegen.SourceFileFor(designFile)
+	if err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, designFile)
+	imports := []*codegen.ImportSpec{
+		codegen.NewImport(".", "github.com/goadesign/goa/design"),
+		codegen.NewImport(".", "github.com/goadesign/goa/design/apidsl"),
+	}
+	if err = file.WriteHeader("", "design", imports); err != nil {
+		return nil, err
+	}
+	if _, err = file.Write(code); err != nil {
+		return nil, err
+	}
+	if err = file.FormatCode(); err != nil {
+		return nil, err
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for i := len(g.genfiles) - 1; i >= 0; i-- {
+		os.Remove(g.genfiles[i])
+	}
+	g.genfiles = nil
+}
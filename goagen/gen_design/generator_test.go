@@ -0,0 +1,307 @@
+package gendesign_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/gen_design"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	const outPackagePath = "github.com/goadesign/goa/goagen/gen_design/imported"
+
+	var specFile, specContent, outDir string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		gopath := filepath.SplitList(os.Getenv("GOPATH"))[0]
+		outDir = filepath.Join(gopath, "src", outPackagePath)
+		specFile = filepath.Join(outDir, "swagger.json")
+		specContent = swaggerSpec
+	})
+
+	JustBeforeEach(func() {
+		Ω(os.MkdirAll(outDir, 0777)).ShouldNot(HaveOccurred())
+		Ω(ioutil.WriteFile(specFile, []byte(specContent), 0644)).ShouldNot(HaveOccurred())
+		files, genErr = gendesign.NewGenerator(specFile, outDir).Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	// design returns the content of the generated design file.
+	design := func() string {
+		b, err := ioutil.ReadFile(filepath.Join(outDir, "design", "design.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(b)
+	}
+
+	Context("with a Swagger 2.0 document", func() {
+		It("generates the design package", func() {
+			Ω(genErr).ShouldNot(HaveOccurred())
+			Ω(files).Should(Equal([]string{
+				filepath.Join(outDir, "design"),
+				filepath.Join(outDir, "design", "design.go"),
+			}))
+			code := design()
+			Ω(code).Should(ContainSubstring(`. "github.com/goadesign/goa/design/apidsl"`))
+			Ω(code).Should(ContainSubstring(apiDSL))
+			Ω(code).Should(ContainSubstring(securityDSL))
+			Ω(code).Should(ContainSubstring(mediaTypeDSL))
+			Ω(code).Should(ContainSubstring(typeDSL))
+			Ω(code).Should(ContainSubstring(resourceDSL))
+		})
+
+		It("does not overwrite an existing design package", func() {
+			Ω(genErr).ShouldNot(HaveOccurred())
+			_, err := gendesign.NewGenerator(specFile, outDir).Generate()
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("already exists"))
+		})
+	})
+
+	Context("with an OpenAPI 3 YAML document", func() {
+		BeforeEach(func() {
+			specFile = filepath.Join(outDir, "openapi.yaml")
+			specContent = openAPISpec
+		})
+
+		It("generates the design package", func() {
+			Ω(genErr).ShouldNot(HaveOccurred())
+			code := design()
+			Ω(code).Should(ContainSubstring(openAPIDSL))
+		})
+	})
+
+	Context("with a document that is not a Swagger or OpenAPI document", func() {
+		BeforeEach(func() {
+			specContent = `{"info": {"title": "foo"}}`
+		})
+
+		It("fails without writing the design package", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(genErr.Error()).Should(ContainSubstring("is not a Swagger 2.0 or OpenAPI 3 document"))
+			_, err := os.Stat(filepath.Join(outDir, "design"))
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
+	})
+})
+
+const swaggerSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "The Cellar", "version": "1.0"},
+  "host": "cellar.example.com",
+  "basePath": "/api",
+  "consumes": ["application/json", "text/csv"],
+  "securityDefinitions": {
+    "key": {"type": "apiKey", "in": "header", "name": "X-Key"}
+  },
+  "security": [{"key": []}],
+  "paths": {
+    "/bottles/{bottleID}": {
+      "parameters": [{"name": "bottleID", "in": "path", "required": true, "type": "integer", "minimum": 1}],
+      "get": {
+        "tags": ["bottle"],
+        "operationId": "bottle#show",
+        "summary": "Show a bottle",
+        "responses": {
+          "200": {"description": "OK", "schema": {"$ref": "#/definitions/Bottle"}},
+          "404": {"description": "Not Found"}
+        }
+      },
+      "put": {
+        "tags": ["bottle"],
+        "operationId": "bottle#update",
+        "security": [],
+        "parameters": [{"name": "payload", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Vintage"}}],
+        "responses": {
+          "204": {"description": "No Content"},
+          "419": {"description": "Expired"}
+        }
+      }
+    },
+    "/bottles": {
+      "get": {
+        "tags": ["bottle"],
+        "operationId": "bottle#list",
+        "parameters": [{"name": "color", "in": "query", "type": "string", "enum": ["red", "white"]}],
+        "responses": {
+          "200": {"description": "OK", "schema": {"type": "array", "items": {"$ref": "#/definitions/Bottle"}}}
+        }
+      }
+    }
+  },
+  "definitions": {
+    "Bottle": {
+      "type": "object",
+      "properties": {
+        "id": {"type": "integer", "example": 1},
+        "name": {"type": "string", "minLength": 2},
+        "vintage": {"$ref": "#/definitions/Vintage"}
+      },
+      "required": ["id"]
+    },
+    "Vintage": {
+      "type": "object",
+      "properties": {
+        "year": {"type": "integer", "minimum": 1900},
+        "notes": {"type": "array", "items": {"type": "object", "properties": {"text": {"type": "string"}}}}
+      }
+    }
+  }
+}`
+
+const apiDSL = `var _ = API("the_cellar", func() {
+	Title("The Cellar")
+	Version("1.0")
+	Host("cellar.example.com")
+	BasePath("/api")
+	Consumes("application/json")
+	Security("key")
+})`
+
+const securityDSL = `var _ = APIKeySecurity("key", func() {
+	Header("X-Key")
+})`
+
+const mediaTypeDSL = `var BottleMedia = MediaType("application/vnd.bottle+json", func() {
+	TypeName("Bottle")
+	Attributes(func() {
+		Attribute("id", Integer, func() {
+			Example(1)
+		})
+		Attribute("name", String, func() {
+			MinLength(2)
+		})
+		Attribute("vintage", "Vintage")
+		Required("id")
+	})
+	View("default", func() {
+		Attribute("id")
+		Attribute("name")
+		Attribute("vintage")
+	})
+})`
+
+const typeDSL = `var VintageType = Type("Vintage", func() {
+	Attribute("notes", ArrayOf("VintageNotesItem"))
+	Attribute("year", Integer, func() {
+		Minimum(1900)
+	})
+})`
+
+const resourceDSL = `var _ = Resource("bottle", func() {
+	Action("list", func() {
+		Routing(GET("/bottles"))
+		Params(func() {
+			Param("color", String, func() {
+				Enum("red", "white")
+			})
+		})
+		Response(OK, CollectionOf(BottleMedia))
+	})
+	Action("show", func() {
+		Description("Show a bottle")
+		Routing(GET("/bottles/:bottleID"))
+		Params(func() {
+			Param("bottleID", Integer, func() {
+				Minimum(1)
+			})
+			Required("bottleID")
+		})
+		Response(OK, BottleMedia)
+		Response(NotFound)
+	})
+	Action("update", func() {
+		Routing(PUT("/bottles/:bottleID"))
+		Params(func() {
+			Param("bottleID", Integer, func() {
+				Minimum(1)
+			})
+			Required("bottleID")
+		})
+		Payload(VintageType)
+		NoSecurity()
+		Response(NoContent)
+		Response("Status419", func() {
+			Status(419)
+			Description("Expired")
+		})
+	})
+})
+
+var VintageNotesItemType = Type("VintageNotesItem", func() {
+	Attribute("text", String)
+})`
+
+const openAPISpec = `openapi: 3.0.0
+info:
+  title: Pets
+servers:
+  - url: https://pets.example.com/v1
+components:
+  securitySchemes:
+    bearer:
+      type: http
+      scheme: bearer
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      security:
+        - bearer: []
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Pet"
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+`
+
+const openAPIDSL = `var _ = API("pets", func() {
+	Title("Pets")
+	Host("pets.example.com")
+	Scheme("https")
+	BasePath("/v1")
+})
+
+var _ = JWTSecurity("bearer", func() {
+	Header("Authorization")
+})
+
+var PetMedia = MediaType("application/vnd.pet+json", func() {
+	TypeName("Pet")
+	Attributes(func() {
+		Attribute("name", String)
+	})
+	View("default", func() {
+		Attribute("name")
+	})
+})
+
+var _ = Resource("pets", func() {
+	Action("createPet", func() {
+		Routing(POST("/pets"))
+		Payload(PetMedia)
+		Security("bearer")
+		Response(Created, PetMedia)
+	})
+})`
@@ -0,0 +1,968 @@
+package gendesign
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+type (
+	// importer renders the design DSL that approximates a spec.
+	importer struct {
+		spec      *spec
+		buf       bytes.Buffer
+		media     map[string]bool    // Names of the definitions rendered as media types
+		vars      map[string]string  // Go variable names of the rendered types indexed by type name
+		varNames  map[string]bool    // Go variable names in use
+		hoisted   []*hoistedType     // Inline object types hoisted into named types
+		hoistedBy map[*schema]string // Names of the hoisted types indexed by schema
+		schemes   map[string]string  // Types of the rendered security schemes indexed by name
+		wildcards map[string]string  // Path wildcard names indexed by route prefix
+	}
+
+	// hoistedType is an inline object schema rendered as a named type because the DSL cannot
+	// describe it inline, e.g. the element of an array.
+	hoistedType struct {
+		name   string
+		schema *schema
+	}
+
+	// endpoint is an operation with its path and HTTP method.
+	endpoint struct {
+		verb string
+		path string
+		item *pathItem
+		op   *operation
+	}
+)
+
+var (
+	// verbs lists the HTTP methods in the order their operations are rendered.
+	verbs = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+	// primitives lists the DSL primitive type expressions with their type.
+	primitives = map[string]design.Primitive{
+		"String":   design.String,
+		"Integer":  design.Integer,
+		"Number":   design.Number,
+		"Boolean":  design.Boolean,
+		"DateTime": design.DateTime,
+		"UUID":     design.UUID,
+		"Any":      design.Any,
+	}
+
+	// invalidChars matches the characters that cannot appear in resource, action and
+	// wildcard names.
+	invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+	// underscores matches consecutive underscores.
+	underscores = regexp.MustCompile(`__+`)
+
+	// responseNames lists the names of the built-in responses indexed by status.
+	responseNames = make(map[int]string)
+
+	// reserved lists the identifiers of the design and apidsl packages that may conflict with
+	// the names of the type variables, both packages are dot imported.
+	reserved = []string{"DataType", "DefaultMedia", "ErrorMedia", "MaintenanceMedia", "MediaType", "UnsupportedMediaType"}
+)
+
+func init() {
+	for name, r := range design.NewAPIDefinition().DefaultResponses {
+		responseNames[r.Status] = name
+	}
+}
+
+// newImporter returns an importer for the given spec.
+func newImporter(s *spec) *importer {
+	g := &importer{
+		spec:      s,
+		media:     make(map[string]bool),
+		vars:      make(map[string]string),
+		varNames:  make(map[string]bool),
+		hoistedBy: make(map[*schema]string),
+		schemes:   make(map[string]string),
+		wildcards: make(map[string]string),
+	}
+	for _, n := range reserved {
+		g.varNames[n] = true
+	}
+	return g
+}
+
+// design renders the design DSL: the API, the security schemes, the types and media types and
+// finally the resources.
+func (g *importer) design() []byte {
+	// The security schemes are rendered first so that the API security requirements can be
+	// checked against them, they are written after the API definition.
+	g.securitySchemes()
+	schemes := append([]byte(nil), g.buf.Bytes()...)
+	g.buf.Reset()
+	g.api()
+	g.buf.Write(schemes)
+	g.userTypes()
+	g.resources()
+	for i := 0; i < len(g.hoisted); i++ {
+		h := g.hoisted[i]
+		g.userType(h.name, h.schema)
+	}
+	return g.buf.Bytes()
+}
+
+// p writes a line of code.
+func (g *importer) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format+"\n", args...)
+}
+
+// api renders the API definition.
+func (g *importer) api() {
+	s := g.spec
+	in := s.Info
+	if in == nil {
+		in = &info{}
+	}
+	name := sanitize(codegen.SnakeCase(in.Title), "api")
+	g.p("var _ = API(%q, func() {", name)
+	if in.Title != "" {
+		g.p("Title(%q)", in.Title)
+	}
+	if in.Description != "" {
+		g.p("Description(%q)", in.Description)
+	}
+	if in.Version != "" {
+		g.p("Version(%q)", in.Version)
+	}
+	if in.TermsOfService != "" {
+		g.p("TermsOfService(%q)", in.TermsOfService)
+	}
+	if s.Host != "" {
+		g.p("Host(%q)", s.Host)
+	}
+	if len(s.Schemes) > 0 {
+		g.p("Scheme(%s)", quoteAll(s.Schemes))
+	}
+	if s.BasePath != "" && s.BasePath != "/" {
+		g.p("BasePath(%q)", s.BasePath)
+	}
+	// Only the MIME types supported by the built-in encoders can be used without specifying an
+	// encoding package.
+	for fn, mimeTypes := range map[string][]string{"Consumes": s.Consumes, "Produces": s.Produces} {
+		var known []string
+		for _, m := range mimeTypes {
+			if design.HasKnownEncoder(m) {
+				known = append(known, m)
+			}
+		}
+		if len(known) > 0 {
+			g.p("%s(%s)", fn, quoteAll(known))
+		}
+	}
+	if len(s.Security) > 0 {
+		g.security(s.Security[0])
+	}
+	g.p("})")
+	g.p("")
+}
+
+// securitySchemes renders the security schemes.
+func (g *importer) securitySchemes() {
+	for _, name := range sortedKeys(g.spec.SecurityDefinitions) {
+		s := g.spec.SecurityDefinitions[name]
+		var fn string
+		var body []string
+		if s.Description != "" {
+			body = append(body, fmt.Sprintf("Description(%q)", s.Description))
+		}
+		switch s.Type {
+		case "basic":
+			fn = "BasicAuthSecurity"
+		case "apiKey", "jwt":
+			fn = "APIKeySecurity"
+			if s.Type == "jwt" {
+				fn = "JWTSecurity"
+			}
+			switch s.In {
+			case "header":
+				body = append(body, fmt.Sprintf("Header(%q)", s.Name))
+			case "query":
+				body = append(body, fmt.Sprintf("Query(%q)", s.Name))
+			default:
+				continue
+			}
+		case "oauth2":
+			fn = "OAuth2Security"
+			switch s.Flow {
+			case "accessCode":
+				body = append(body, fmt.Sprintf("AccessCodeFlow(%q, %q)", s.AuthorizationURL, s.TokenURL))
+			case "implicit":
+				body = append(body, fmt.Sprintf("ImplicitFlow(%q)", s.AuthorizationURL))
+			case "password":
+				body = append(body, fmt.Sprintf("PasswordFlow(%q)", s.TokenURL))
+			case "application":
+				body = append(body, fmt.Sprintf("ApplicationFlow(%q)", s.TokenURL))
+			}
+			for _, scope := range sortedKeys(s.Scopes) {
+				body = append(body, fmt.Sprintf("Scope(%q, %q)", scope, s.Scopes[scope]))
+			}
+		default:
+			continue
+		}
+		g.schemes[name] = s.Type
+		if len(body) == 0 {
+			g.p("var _ = %s(%q)", fn, name)
+		} else {
+			g.p("var _ = %s(%q, func() {", fn, name)
+			for _, l := range body {
+				g.p(l)
+			}
+			g.p("})")
+		}
+		g.p("")
+	}
+}
+
+// security renders the first supported security requirement of the given list.
+func (g *importer) security(req map[string][]string) {
+	for _, name := range sortedKeys(req) {
+		typ, ok := g.schemes[name]
+		if !ok {
+			continue
+		}
+		if scopes := req[name]; len(scopes) > 0 && (typ == "oauth2" || typ == "jwt") {
+			g.p("Security(%q, func() {", name)
+			for _, s := range scopes {
+				g.p("Scope(%q)", s)
+			}
+			g.p("})")
+		} else {
+			g.p("Security(%q)", name)
+		}
+		return
+	}
+}
+
+// userTypes renders the object definitions. The definitions used by responses are rendered as
+// media types, the others as types. The definitions that are not objects are not rendered, their
+// type is used in place of the references.
+func (g *importer) userTypes() {
+	mark := func(s *schema) {
+		if s = g.resolve(s); s == nil {
+			return
+		}
+		if s.Type == "array" && s.Items != nil {
+			s = g.resolve(s.Items)
+		}
+		if s.Ref != "" && isObject(g.spec.Definitions[refName(s.Ref)]) {
+			g.media[refName(s.Ref)] = true
+		}
+	}
+	for _, r := range g.spec.Responses {
+		mark(r.Schema)
+	}
+	for _, item := range g.spec.Paths {
+		for _, op := range item.operations() {
+			for _, r := range op.Responses {
+				mark(r.Schema)
+			}
+		}
+	}
+	names := sortedKeys(g.spec.Definitions)
+	for _, n := range names {
+		if isObject(g.spec.Definitions[n]) {
+			suffix := "Type"
+			if g.media[n] {
+				suffix = "Media"
+			}
+			g.vars[n] = g.varName(codegen.Goify(n, true) + suffix)
+		}
+	}
+	for _, n := range names {
+		if s := g.spec.Definitions[n]; isObject(s) {
+			g.userType(n, s)
+		}
+	}
+}
+
+// userType renders a type or media type definition.
+func (g *importer) userType(name string, s *schema) {
+	if g.media[name] {
+		g.p("var %s = MediaType(%q, func() {", g.vars[name], identifier(name))
+		if s.Description != "" {
+			g.p("Description(%q)", s.Description)
+		}
+		g.p("TypeName(%q)", codegen.Goify(name, true))
+		g.p("Attributes(func() {")
+		g.children(s, codegen.Goify(name, true))
+		g.p("})")
+		g.p(`View("default", func() {`)
+		props, _ := g.properties(s)
+		for _, n := range sortedKeys(props) {
+			g.p("Attribute(%q)", n)
+		}
+		g.p("})")
+	} else {
+		g.p("var %s = Type(%q, func() {", g.vars[name], name)
+		if s.Description != "" {
+			g.p("Description(%q)", s.Description)
+		}
+		g.children(s, codegen.Goify(name, true))
+	}
+	g.p("})")
+	g.p("")
+}
+
+// children renders the attributes of an object schema followed by the list of required
+// attributes.
+func (g *importer) children(s *schema, hint string) {
+	props, required := g.properties(s)
+	for _, n := range sortedKeys(props) {
+		g.attribute("Attribute", n, props[n], true, hint+codegen.Goify(n, true))
+	}
+	if len(required) > 0 {
+		g.p("Required(%s)", quoteAll(required))
+	}
+}
+
+// attribute renders an attribute definition using the given DSL function: Attribute, Param or
+// Header. inType is true if the attribute is rendered inside a type definition: the other types
+// are then referred to by name so that recursive types do not cause initialization cycles. hint
+// is used to name the inline object types that must be hoisted into named types.
+func (g *importer) attribute(fn, name string, s *schema, inType bool, hint string) {
+	s = g.resolve(s)
+	expr := g.typeExpr(s, inType, hint)
+	args := []string{strconv.Quote(name)}
+	if expr != "" {
+		args = append(args, expr)
+		if s.Description != "" {
+			args = append(args, strconv.Quote(s.Description))
+		}
+	}
+	body := g.validations(s, expr)
+	if expr == "" && s.Description != "" {
+		body = append([]string{fmt.Sprintf("Description(%q)", s.Description)}, body...)
+	}
+	if expr != "" && len(body) == 0 {
+		g.p("%s(%s)", fn, strings.Join(args, ", "))
+		return
+	}
+	g.p("%s(%s, func() {", fn, strings.Join(args, ", "))
+	for _, l := range body {
+		g.p(l)
+	}
+	if expr == "" {
+		g.children(s, hint)
+	}
+	g.p("})")
+}
+
+// typeExpr returns the DSL expression of the schema type or the empty string if the schema is
+// an object that must be described inline.
+func (g *importer) typeExpr(s *schema, inType bool, hint string) string {
+	if s == nil {
+		return "Any"
+	}
+	if s.Ref != "" {
+		return g.refExpr(refName(s.Ref), inType, hint)
+	}
+	if len(s.AllOf) == 1 && s.AllOf[0].Ref != "" && len(s.Properties) == 0 {
+		return g.refExpr(refName(s.AllOf[0].Ref), inType, hint)
+	}
+	if isObject(s) {
+		return ""
+	}
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "DateTime"
+		case "uuid":
+			return "UUID"
+		}
+		return "String"
+	case "integer":
+		return "Integer"
+	case "number":
+		return "Number"
+	case "boolean":
+		return "Boolean"
+	case "file":
+		return "String"
+	case "array":
+		return "ArrayOf(" + g.elemExpr(s.Items, inType, hint+"Item") + ")"
+	case "object", "":
+		if add := s.additional(); add != nil {
+			return "HashOf(String, " + g.elemExpr(add, inType, hint+"Value") + ")"
+		}
+	}
+	return "Any"
+}
+
+// elemExpr returns the DSL expression of an array or hash element type. Inline objects are
+// hoisted into named types.
+func (g *importer) elemExpr(s *schema, inType bool, hint string) string {
+	s = g.resolve(s)
+	expr := g.typeExpr(s, inType, hint)
+	if expr != "" {
+		return expr
+	}
+	if name, ok := g.hoistedBy[s]; ok {
+		return g.refExpr(name, inType, hint)
+	}
+	name := hint
+	for i := 2; g.spec.Definitions[name] != nil || g.vars[name] != ""; i++ {
+		name = fmt.Sprintf("%s%d", hint, i)
+	}
+	g.vars[name] = g.varName(name + "Type")
+	g.hoisted = append(g.hoisted, &hoistedType{name: name, schema: s})
+	g.hoistedBy[s] = name
+	return g.refExpr(name, inType, hint)
+}
+
+// refExpr returns the DSL expression of a reference to the named definition.
+func (g *importer) refExpr(name string, inType bool, hint string) string {
+	v, ok := g.vars[name]
+	if !ok {
+		return "Any"
+	}
+	if !inType {
+		return v
+	}
+	if g.media[name] {
+		return strconv.Quote(identifier(name))
+	}
+	return strconv.Quote(name)
+}
+
+// resolve returns the definition referred to by s if it is not an object. The description of s
+// overrides the description of the definition.
+func (g *importer) resolve(s *schema) *schema {
+	for i := 0; s != nil && s.Ref != "" && i < 10; i++ {
+		def, ok := g.spec.Definitions[refName(s.Ref)]
+		if !ok || isObject(def) {
+			return s
+		}
+		res := *def
+		if s.Description != "" {
+			res.Description = s.Description
+		}
+		s = &res
+	}
+	return s
+}
+
+// properties returns the properties of an object schema merged with the properties of the
+// schemas listed in allOf as well as the sorted names of the required properties.
+func (g *importer) properties(s *schema) (map[string]*schema, []string) {
+	props := make(map[string]*schema)
+	req := make(map[string]bool)
+	var merge func(s *schema, depth int)
+	merge = func(s *schema, depth int) {
+		if s == nil || depth > 10 {
+			return
+		}
+		if s.Ref != "" {
+			merge(g.spec.Definitions[refName(s.Ref)], depth+1)
+			return
+		}
+		for n, p := range s.Properties {
+			props[n] = p
+		}
+		for _, n := range s.Required {
+			req[n] = true
+		}
+		for _, a := range s.AllOf {
+			merge(a, depth+1)
+		}
+	}
+	merge(s, 0)
+	var required []string
+	for n := range req {
+		if _, ok := props[n]; ok {
+			required = append(required, n)
+		}
+	}
+	sort.Strings(required)
+	return props, required
+}
+
+// validations returns the validation DSL of the schema given the DSL expression of its type.
+func (g *importer) validations(s *schema, expr string) []string {
+	var lines []string
+	if strings.HasPrefix(expr, "ArrayOf(") {
+		if s.MinItems != nil {
+			lines = append(lines, fmt.Sprintf("MinLength(%d)", *s.MinItems))
+		}
+		if s.MaxItems != nil {
+			lines = append(lines, fmt.Sprintf("MaxLength(%d)", *s.MaxItems))
+		}
+		return lines
+	}
+	prim, ok := primitives[expr]
+	if !ok || prim == design.Any {
+		return nil
+	}
+	var enum []string
+	for _, v := range s.Enum {
+		if l, ok := literal(v, prim); ok {
+			enum = append(enum, l)
+		}
+	}
+	if len(enum) > 0 {
+		lines = append(lines, fmt.Sprintf("Enum(%s)", strings.Join(enum, ", ")))
+	}
+	switch prim {
+	case design.String:
+		for _, f := range apidsl.SupportedValidationFormats {
+			if f == s.Format {
+				lines = append(lines, fmt.Sprintf("Format(%q)", f))
+			}
+		}
+		if s.Pattern != "" {
+			lines = append(lines, fmt.Sprintf("Pattern(%q)", s.Pattern))
+		}
+		if s.MinLength != nil {
+			lines = append(lines, fmt.Sprintf("MinLength(%d)", *s.MinLength))
+		}
+		if s.MaxLength != nil {
+			lines = append(lines, fmt.Sprintf("MaxLength(%d)", *s.MaxLength))
+		}
+	case design.Integer, design.Number:
+		if s.Minimum != nil {
+			if l, ok := literal(*s.Minimum, prim); ok {
+				lines = append(lines, fmt.Sprintf("Minimum(%s)", l))
+			}
+		}
+		if s.Maximum != nil {
+			if l, ok := literal(*s.Maximum, prim); ok {
+				lines = append(lines, fmt.Sprintf("Maximum(%s)", l))
+			}
+		}
+	}
+	if l, ok := literal(s.Default, prim); ok {
+		lines = append(lines, fmt.Sprintf("Default(%s)", l))
+	}
+	if l, ok := literal(s.Example, prim); ok {
+		lines = append(lines, fmt.Sprintf("Example(%s)", l))
+	}
+	return lines
+}
+
+// resources renders the resources. The operations are grouped into resources by their first tag
+// or by the first segment of their path if they have no tag.
+func (g *importer) resources() {
+	groups := make(map[string][]*endpoint)
+	for _, path := range sortedKeys(g.spec.Paths) {
+		item := g.spec.Paths[path]
+		ops := item.operations()
+		for _, verb := range verbs {
+			op, ok := ops[verb]
+			if !ok {
+				continue
+			}
+			var res string
+			if len(op.Tags) > 0 {
+				res = op.Tags[0]
+			} else {
+				for _, seg := range strings.Split(path, "/") {
+					if seg != "" && !strings.HasPrefix(seg, "{") {
+						res = seg
+						break
+					}
+				}
+			}
+			groups[res] = append(groups[res], &endpoint{verb: verb, path: path, item: item, op: op})
+		}
+	}
+	descs := make(map[string]string)
+	for _, t := range g.spec.Tags {
+		descs[t.Name] = t.Description
+	}
+	used := make(map[string]bool)
+	for _, tag := range sortedKeys(groups) {
+		name := sanitize(codegen.SnakeCase(tag), "api")
+		for base, i := name, 2; used[name]; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		used[name] = true
+		g.p("var _ = Resource(%q, func() {", name)
+		if d := descs[tag]; d != "" {
+			g.p("Description(%q)", d)
+		}
+		actions := make(map[string]bool)
+		for _, e := range groups[tag] {
+			g.action(name, e, actions)
+		}
+		g.p("})")
+		g.p("")
+	}
+}
+
+// action renders the action of an endpoint, used lists the names of the actions already defined
+// by the resource.
+func (g *importer) action(res string, e *endpoint, used map[string]bool) {
+	name := e.op.OperationID
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name = sanitize(name, ""); name == "" {
+		var words []string
+		for _, seg := range strings.Split(e.path, "/") {
+			if seg != "" && !strings.HasPrefix(seg, "{") {
+				words = append(words, seg)
+			}
+		}
+		name = sanitize(strings.ToLower(e.verb)+"_"+strings.Join(words, "_"), "action")
+	}
+	for base, i := name, 2; used[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	used[name] = true
+	hint := codegen.Goify(name, true) + codegen.Goify(res, true)
+
+	g.p("Action(%q, func() {", name)
+	if d := e.op.Description; d != "" {
+		g.p("Description(%q)", d)
+	} else if e.op.Summary != "" {
+		g.p("Description(%q)", e.op.Summary)
+	}
+	path, wildcards := g.route(e.path)
+	g.p("Routing(%s(%q))", e.verb, path)
+
+	var params, headers, form []*parameter
+	var body *parameter
+	for _, p := range g.parameters(e) {
+		switch p.In {
+		case "path", "query":
+			params = append(params, p)
+		case "header":
+			headers = append(headers, p)
+		case "formData":
+			form = append(form, p)
+		case "body":
+			body = p
+		}
+	}
+	declared := make(map[string]bool)
+	var required []string
+	if len(params) > 0 || len(wildcards) > 0 {
+		g.p("Params(func() {")
+		for _, p := range params {
+			pname := p.Name
+			if p.In == "path" {
+				if w, ok := wildcards[p.Name]; ok {
+					pname = w
+				}
+			}
+			if declared[pname] {
+				continue
+			}
+			declared[pname] = true
+			g.param("Param", pname, p)
+			if p.Required || p.In == "path" {
+				required = append(required, pname)
+			}
+		}
+		for _, orig := range sortedKeys(wildcards) {
+			if w := wildcards[orig]; !declared[w] {
+				declared[w] = true
+				g.p("Param(%q, String)", w)
+				required = append(required, w)
+			}
+		}
+		if len(required) > 0 {
+			g.p("Required(%s)", quoteAll(required))
+		}
+		g.p("})")
+	}
+	if len(headers) > 0 {
+		g.p("Headers(func() {")
+		required = nil
+		for _, p := range headers {
+			g.param("Header", p.Name, p)
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+		if len(required) > 0 {
+			g.p("Required(%s)", quoteAll(required))
+		}
+		g.p("})")
+	}
+	switch {
+	case body != nil:
+		fn := "OptionalPayload"
+		if body.Required {
+			fn = "Payload"
+		}
+		s := g.resolve(body.Schema)
+		if expr := g.typeExpr(s, false, hint+"Payload"); expr != "" {
+			g.p("%s(%s)", fn, expr)
+		} else {
+			g.p("%s(func() {", fn)
+			g.children(s, hint+"Payload")
+			g.p("})")
+		}
+	case len(form) > 0:
+		required = nil
+		for _, p := range form {
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+		fn := "OptionalPayload"
+		if len(required) > 0 {
+			fn = "Payload"
+		}
+		g.p("%s(func() {", fn)
+		for _, p := range form {
+			g.param("Attribute", p.Name, p)
+		}
+		if len(required) > 0 {
+			g.p("Required(%s)", quoteAll(required))
+		}
+		g.p("})")
+	}
+	if sec := e.op.Security; sec != nil {
+		if len(*sec) == 0 {
+			g.p("NoSecurity()")
+		} else {
+			g.security((*sec)[0])
+		}
+	}
+	g.responses(e.op)
+	g.p("})")
+}
+
+// param renders a parameter, header or form field. Parameters whose type is not supported by
+// the DSL, for example objects, are rendered as strings.
+func (g *importer) param(fn, name string, p *parameter) {
+	var s schema
+	if p.Schema != nil && p.In != "body" {
+		s = *g.resolve(p.Schema)
+	} else {
+		s.validations = p.validations
+	}
+	if p.Description != "" {
+		s.Description = p.Description
+	}
+	expr := g.typeExpr(&s, true, "")
+	elem := strings.TrimSuffix(strings.TrimPrefix(expr, "ArrayOf("), ")")
+	if _, ok := primitives[elem]; !ok || elem == "Any" {
+		s = schema{Description: s.Description, validations: validations{Type: "string"}}
+	}
+	g.attribute(fn, name, &s, true, "")
+}
+
+// responses renders the responses of an operation. Response bodies that refer to a media type or
+// to an array of media types are rendered, the other bodies are not imported.
+func (g *importer) responses(op *operation) {
+	var statuses []int
+	for code := range op.Responses {
+		if s, err := strconv.Atoi(code); err == nil {
+			statuses = append(statuses, s)
+		}
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		r := op.Responses[strconv.Itoa(status)]
+		if r.Ref != "" {
+			if ref, ok := g.spec.Responses[refName(r.Ref)]; ok {
+				r = ref
+			}
+		}
+		name, builtin := responseNames[status]
+		if !builtin {
+			name = codegen.Goify(http.StatusText(status), true)
+			if name == "" {
+				name = fmt.Sprintf("Status%d", status)
+			}
+		}
+		args := []string{name}
+		if !builtin {
+			args[0] = strconv.Quote(name)
+		}
+		if s := g.resolve(r.Schema); s != nil {
+			media := s
+			if s.Type == "array" && s.Items != nil {
+				media = g.resolve(s.Items)
+			}
+			switch {
+			case media.Ref == "" || !g.media[refName(media.Ref)]:
+				g.p("// The body of the %d response is not imported.", status)
+			case media == s:
+				args = append(args, g.vars[refName(s.Ref)])
+			default:
+				args = append(args, "CollectionOf("+g.vars[refName(media.Ref)]+")")
+			}
+		}
+		if builtin {
+			g.p("Response(%s)", strings.Join(args, ", "))
+			continue
+		}
+		g.p("Response(%s, func() {", strings.Join(args, ", "))
+		g.p("Status(%d)", status)
+		if r.Description != "" {
+			g.p("Description(%q)", r.Description)
+		}
+		g.p("})")
+	}
+}
+
+// parameters returns the parameters of the path merged with the parameters of the operation with
+// the references resolved.
+func (g *importer) parameters(e *endpoint) []*parameter {
+	var params []*parameter
+	index := make(map[string]int)
+	for _, p := range append(append([]*parameter{}, e.item.Parameters...), e.op.Parameters...) {
+		if p.Ref != "" {
+			ref, ok := g.spec.Parameters[refName(p.Ref)]
+			if !ok {
+				continue
+			}
+			p = ref
+		}
+		key := p.In + " " + p.Name
+		if i, ok := index[key]; ok {
+			params[i] = p
+			continue
+		}
+		index[key] = len(params)
+		params = append(params, p)
+	}
+	return params
+}
+
+// route converts a path template into a route path and returns the wildcard names indexed by
+// parameter name. Wildcards at the same position in routes that share the same prefix must have
+// the same name, the wildcards are renamed as needed.
+func (g *importer) route(path string) (string, map[string]string) {
+	segs := strings.Split(path, "/")
+	wildcards := make(map[string]string)
+	seen := make(map[string]bool)
+	var prefix []string
+	for i, seg := range segs {
+		if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+			prefix = append(prefix, seg)
+			continue
+		}
+		orig := seg[1 : len(seg)-1]
+		w := sanitize(orig, "param")
+		key := strings.Join(prefix, "/")
+		if existing, ok := g.wildcards[key]; ok {
+			if !seen[existing] {
+				w = existing
+			}
+		} else {
+			g.wildcards[key] = w
+		}
+		seen[w] = true
+		wildcards[orig] = w
+		segs[i] = ":" + w
+		prefix = append(prefix, ":")
+	}
+	return strings.Join(segs, "/"), wildcards
+}
+
+// varName returns a Go variable name based on name that is not used yet.
+func (g *importer) varName(name string) string {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "T" + name
+	}
+	v := name
+	for i := 2; g.varNames[v]; i++ {
+		v = fmt.Sprintf("%s%d", name, i)
+	}
+	g.varNames[v] = true
+	return v
+}
+
+// additional returns the schema of the additional properties of an object or nil if it does not
+// allow additional properties.
+func (s *schema) additional() *schema {
+	switch raw := strings.TrimSpace(string(s.AdditionalProperties)); raw {
+	case "", "false", "null":
+		return nil
+	case "true":
+		return &schema{}
+	}
+	var add schema
+	if err := json.Unmarshal(s.AdditionalProperties, &add); err != nil {
+		return nil
+	}
+	return &add
+}
+
+// isObject returns true if the schema describes an object with properties.
+func isObject(s *schema) bool {
+	return s != nil && (len(s.Properties) > 0 || len(s.AllOf) > 0)
+}
+
+// identifier returns the media type identifier of the media type rendered for the given
+// definition.
+func identifier(name string) string {
+	return "application/vnd." + strings.Replace(codegen.SnakeCase(name), "_", "-", -1) + "+json"
+}
+
+// literal returns the Go literal of a default, example or enum value if it is compatible with
+// the given type.
+func literal(v interface{}, prim design.Primitive) (string, bool) {
+	if f, ok := v.(float64); ok && prim == design.Integer {
+		if f != math.Trunc(f) {
+			return "", false
+		}
+		v = int(f)
+	}
+	if v == nil || !prim.IsCompatible(v) {
+		return "", false
+	}
+	switch actual := v.(type) {
+	case string:
+		return strconv.Quote(actual), true
+	case bool:
+		return strconv.FormatBool(actual), true
+	case int:
+		return strconv.Itoa(actual), true
+	case float64:
+		return strconv.FormatFloat(actual, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// sanitize replaces the characters that are not valid in names with underscores. It returns def
+// if the result is empty.
+func sanitize(name, def string) string {
+	name = strings.Trim(invalidChars.ReplaceAllString(name, "_"), "_")
+	name = underscores.ReplaceAllString(name, "_")
+	if name == "" {
+		return def
+	}
+	return name
+}
+
+// quoteAll returns the comma separated list of the quoted strings.
+func quoteAll(vals []string) string {
+	quoted := make([]string, len(vals))
+	for i, v := range vals {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// sortedKeys returns the sorted keys of the map.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
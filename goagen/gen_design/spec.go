@@ -0,0 +1,341 @@
+package gendesign
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The types below describe the subset of the Swagger 2.0 and OpenAPI 3 documents read by the
+// importer. They differ from the genswagger types used to write specs: nothing is omitted when
+// zero so that for example a minimum of 0 is not lost, and additionalProperties may be a schema.
+type (
+	// spec is a Swagger 2.0 or OpenAPI 3 document. The OpenAPI 3 fields are converted into
+	// their Swagger 2.0 equivalent by normalize.
+	spec struct {
+		Swagger             string                     `json:"swagger"`
+		OpenAPI             string                     `json:"openapi"`
+		Info                *info                      `json:"info"`
+		Host                string                     `json:"host"`
+		BasePath            string                     `json:"basePath"`
+		Schemes             []string                   `json:"schemes"`
+		Consumes            []string                   `json:"consumes"`
+		Produces            []string                   `json:"produces"`
+		Paths               map[string]*pathItem       `json:"paths"`
+		Definitions         map[string]*schema         `json:"definitions"`
+		Parameters          map[string]*parameter      `json:"parameters"`
+		Responses           map[string]*response       `json:"responses"`
+		SecurityDefinitions map[string]*securityScheme `json:"securityDefinitions"`
+		Security            []map[string][]string      `json:"security"`
+		Tags                []*tag                     `json:"tags"`
+		Servers             []*server                  `json:"servers"`
+		Components          *components                `json:"components"`
+	}
+
+	// info is the API metadata.
+	info struct {
+		Title          string `json:"title"`
+		Description    string `json:"description"`
+		Version        string `json:"version"`
+		TermsOfService string `json:"termsOfService"`
+	}
+
+	// pathItem lists the operations of a path.
+	pathItem struct {
+		Get        *operation   `json:"get"`
+		Put        *operation   `json:"put"`
+		Post       *operation   `json:"post"`
+		Delete     *operation   `json:"delete"`
+		Options    *operation   `json:"options"`
+		Head       *operation   `json:"head"`
+		Patch      *operation   `json:"patch"`
+		Parameters []*parameter `json:"parameters"`
+	}
+
+	// operation is an API operation. Security is nil if the operation does not override the
+	// API security requirements.
+	operation struct {
+		Tags        []string               `json:"tags"`
+		Summary     string                 `json:"summary"`
+		Description string                 `json:"description"`
+		OperationID string                 `json:"operationId"`
+		Parameters  []*parameter           `json:"parameters"`
+		RequestBody *requestBody           `json:"requestBody"`
+		Responses   map[string]*response   `json:"responses"`
+		Security    *[]map[string][]string `json:"security"`
+	}
+
+	// parameter is an operation parameter. The validations of Swagger 2.0 parameters are
+	// inlined, OpenAPI 3 parameters use Schema instead.
+	parameter struct {
+		validations
+		Ref         string  `json:"$ref"`
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description"`
+		Required    bool    `json:"required"`
+		Schema      *schema `json:"schema"`
+	}
+
+	// requestBody is an OpenAPI 3 request body.
+	requestBody struct {
+		Ref         string                `json:"$ref"`
+		Description string                `json:"description"`
+		Required    bool                  `json:"required"`
+		Content     map[string]*mediaType `json:"content"`
+	}
+
+	// response is an operation response.
+	response struct {
+		Ref         string                `json:"$ref"`
+		Description string                `json:"description"`
+		Schema      *schema               `json:"schema"`
+		Content     map[string]*mediaType `json:"content"`
+	}
+
+	// mediaType is an OpenAPI 3 request or response content.
+	mediaType struct {
+		Schema *schema `json:"schema"`
+	}
+
+	// schema is a JSON schema.
+	schema struct {
+		validations
+		Ref                  string             `json:"$ref"`
+		Description          string             `json:"description"`
+		Properties           map[string]*schema `json:"properties"`
+		AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+		Required             []string           `json:"required"`
+		AllOf                []*schema          `json:"allOf"`
+	}
+
+	// validations lists the type and validations shared by schemas and parameters.
+	validations struct {
+		Type      string        `json:"type"`
+		Format    string        `json:"format"`
+		Items     *schema       `json:"items"`
+		Default   interface{}   `json:"default"`
+		Example   interface{}   `json:"example"`
+		Enum      []interface{} `json:"enum"`
+		Minimum   *float64      `json:"minimum"`
+		Maximum   *float64      `json:"maximum"`
+		MinLength *int          `json:"minLength"`
+		MaxLength *int          `json:"maxLength"`
+		MinItems  *int          `json:"minItems"`
+		MaxItems  *int          `json:"maxItems"`
+		Pattern   string        `json:"pattern"`
+	}
+
+	// securityScheme is a security scheme definition, Scheme and Flows are only set by
+	// OpenAPI 3 documents.
+	securityScheme struct {
+		Type             string            `json:"type"`
+		Description      string            `json:"description"`
+		Name             string            `json:"name"`
+		In               string            `json:"in"`
+		Flow             string            `json:"flow"`
+		AuthorizationURL string            `json:"authorizationUrl"`
+		TokenURL         string            `json:"tokenUrl"`
+		Scopes           map[string]string `json:"scopes"`
+		Scheme           string            `json:"scheme"`
+		Flows            map[string]*flow  `json:"flows"`
+	}
+
+	// flow is an OpenAPI 3 OAuth2 flow.
+	flow struct {
+		AuthorizationURL string            `json:"authorizationUrl"`
+		TokenURL         string            `json:"tokenUrl"`
+		Scopes           map[string]string `json:"scopes"`
+	}
+
+	// tag describes an operation tag.
+	tag struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	// server is an OpenAPI 3 server.
+	server struct {
+		URL string `json:"url"`
+	}
+
+	// components holds the OpenAPI 3 reusable definitions.
+	components struct {
+		Schemas         map[string]*schema         `json:"schemas"`
+		Parameters      map[string]*parameter      `json:"parameters"`
+		Responses       map[string]*response       `json:"responses"`
+		RequestBodies   map[string]*requestBody    `json:"requestBodies"`
+		SecuritySchemes map[string]*securityScheme `json:"securitySchemes"`
+	}
+)
+
+// loadSpec reads the Swagger or OpenAPI document at the given path. Files with the .yaml or .yml
+// extension are read as YAML, other files as JSON.
+func loadSpec(path string) (*spec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		var raw interface{}
+		if err := yaml.Unmarshal(b, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+		}
+		if b, err = json.Marshal(jsonValue(raw)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+		}
+	}
+	var s spec
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+	}
+	switch {
+	case strings.HasPrefix(s.Swagger, "2."):
+	case strings.HasPrefix(s.OpenAPI, "3."):
+		s.normalize()
+	default:
+		return nil, fmt.Errorf("%s is not a Swagger 2.0 or OpenAPI 3 document", path)
+	}
+	return &s, nil
+}
+
+// normalize converts the OpenAPI 3 fields into their Swagger 2.0 equivalent: the components
+// become definitions, the request bodies become body parameters, the response content becomes
+// the response schema and the servers the host, base path and schemes.
+func (s *spec) normalize() {
+	if len(s.Servers) > 0 {
+		if u, err := url.Parse(s.Servers[0].URL); err == nil {
+			s.Host = u.Host
+			s.BasePath = strings.TrimSuffix(u.Path, "/")
+			if u.Scheme != "" {
+				s.Schemes = []string{u.Scheme}
+			}
+		}
+	}
+	var bodies map[string]*requestBody
+	if c := s.Components; c != nil {
+		s.Definitions = c.Schemas
+		s.Parameters = c.Parameters
+		s.Responses = c.Responses
+		s.SecurityDefinitions = c.SecuritySchemes
+		bodies = c.RequestBodies
+	}
+	for _, r := range s.Responses {
+		r.normalize()
+	}
+	for _, sch := range s.SecurityDefinitions {
+		sch.normalize()
+	}
+	for _, item := range s.Paths {
+		for _, op := range item.operations() {
+			for _, r := range op.Responses {
+				r.normalize()
+			}
+			body := op.RequestBody
+			if body != nil && body.Ref != "" {
+				body = bodies[refName(body.Ref)]
+			}
+			if body != nil {
+				op.Parameters = append(op.Parameters, &parameter{
+					Name:        "body",
+					In:          "body",
+					Description: body.Description,
+					Required:    body.Required,
+					Schema:      contentSchema(body.Content),
+				})
+			}
+		}
+	}
+}
+
+// normalize sets the response schema from its OpenAPI 3 content.
+func (r *response) normalize() {
+	if r.Schema == nil {
+		r.Schema = contentSchema(r.Content)
+	}
+}
+
+// normalize converts the OpenAPI 3 security scheme types and flows into their Swagger 2.0
+// equivalent. HTTP bearer schemes are converted to JWT schemes.
+func (s *securityScheme) normalize() {
+	if s.Type == "http" {
+		s.Type = s.Scheme
+		if s.Scheme == "bearer" {
+			s.Type, s.In, s.Name = "jwt", "header", "Authorization"
+		}
+	}
+	for _, n := range []string{"authorizationCode", "implicit", "password", "clientCredentials"} {
+		if f, ok := s.Flows[n]; ok {
+			s.Flow = map[string]string{
+				"authorizationCode": "accessCode",
+				"implicit":          "implicit",
+				"password":          "password",
+				"clientCredentials": "application",
+			}[n]
+			s.AuthorizationURL, s.TokenURL, s.Scopes = f.AuthorizationURL, f.TokenURL, f.Scopes
+			break
+		}
+	}
+}
+
+// operations returns the operations of the path indexed by HTTP method.
+func (p *pathItem) operations() map[string]*operation {
+	ops := make(map[string]*operation)
+	for verb, op := range map[string]*operation{
+		"GET": p.Get, "PUT": p.Put, "POST": p.Post, "DELETE": p.Delete,
+		"OPTIONS": p.Options, "HEAD": p.Head, "PATCH": p.Patch,
+	} {
+		if op != nil {
+			ops[verb] = op
+		}
+	}
+	return ops
+}
+
+// contentSchema returns the schema of the JSON content if any, the schema of the first content
+// otherwise.
+func contentSchema(content map[string]*mediaType) *schema {
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.Contains(k, "json") {
+			return content[k].Schema
+		}
+	}
+	if len(keys) > 0 {
+		return content[keys[0]].Schema
+	}
+	return nil
+}
+
+// refName returns the name of the definition referred to by a local reference such as
+// "#/definitions/Bottle" or "#/components/schemas/Bottle".
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// jsonValue converts the maps produced by the YAML decoder into maps that can be encoded to JSON.
+func jsonValue(v interface{}) interface{} {
+	switch actual := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(actual))
+		for k, val := range actual {
+			m[fmt.Sprint(k)] = jsonValue(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range actual {
+			actual[i] = jsonValue(val)
+		}
+	}
+	return v
+}
@@ -0,0 +1,446 @@
+package gendiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/gen_export"
+)
+
+// Kinds of changes.
+const (
+	// Added identifies elements present in the new design only.
+	Added ChangeKind = "added"
+	// Removed identifies elements present in the old design only.
+	Removed ChangeKind = "removed"
+	// Changed identifies elements present in both designs whose definition differs.
+	Changed ChangeKind = "changed"
+)
+
+// Subjects of changes.
+const (
+	// RouteSubject identifies action routes.
+	RouteSubject Subject = "route"
+	// ParamSubject identifies action path and query string parameters.
+	ParamSubject Subject = "param"
+	// HeaderSubject identifies action request headers.
+	HeaderSubject Subject = "header"
+	// PayloadSubject identifies action request payloads.
+	PayloadSubject Subject = "payload"
+	// ResponseSubject identifies action responses.
+	ResponseSubject Subject = "response"
+	// TypeSubject identifies user types and media types.
+	TypeSubject Subject = "type"
+	// AttributeSubject identifies the attributes of user types and media types.
+	AttributeSubject Subject = "attribute"
+	// ViewSubject identifies media type views.
+	ViewSubject Subject = "view"
+)
+
+type (
+	// ChangeKind is the kind of a change: Added, Removed or Changed.
+	ChangeKind string
+
+	// Subject is the kind of design element a change applies to.
+	Subject string
+
+	// Change describes a difference between two versions of a design.
+	Change struct {
+		// Kind is the kind of change.
+		Kind ChangeKind `json:"kind"`
+		// Subject is the kind of the changed element.
+		Subject Subject `json:"subject"`
+		// Definition describes the definition that contains the changed element, e.g.
+		// `resource "bottle" action "show"` or `type "Winery"`.
+		Definition string `json:"definition"`
+		// Name identifies the changed element in Definition, e.g. "GET /bottles/:id" for
+		// a route, "404" for a response or "winery.name" for a nested attribute.
+		Name string `json:"name"`
+		// Breaking is true if clients built against the old design may fail against the
+		// new one.
+		Breaking bool `json:"breaking"`
+		// Message describes the change.
+		Message string `json:"message"`
+	}
+
+	// differ accumulates the changes found while comparing two designs.
+	differ struct {
+		changes []*Change
+	}
+
+	// endpoint is an action route indexed by its normalized path.
+	endpoint struct {
+		resource *genexport.Resource
+		action   *genexport.Action
+		route    *genexport.Route
+	}
+)
+
+// Diff compares the old and new design export documents and returns the changes. The changes
+// are listed in a stable order: routes first then the parameters, headers, payloads and responses
+// of the actions and finally the types and media types.
+//
+// The changes are classified from the point of view of the clients built against the old design:
+// removing a route, a parameter, a response or a type, adding a required parameter or attribute,
+// changing the type of an element or making it required is breaking. Adding optional elements is
+// not. Making a parameter or header optional is not breaking but making a type attribute optional
+// is, as types may be used in responses.
+func Diff(old, new *genexport.Document) []*Change {
+	d := &differ{}
+	d.endpoints(old.API, new.API)
+	d.types(old.API, new.API)
+	return d.changes
+}
+
+// String returns the change formatted as "breaking|non-breaking: definition: message".
+func (c *Change) String() string {
+	class := "non-breaking"
+	if c.Breaking {
+		class = "breaking"
+	}
+	return fmt.Sprintf("%s: %s: %s", class, c.Definition, c.Message)
+}
+
+// report records a change.
+func (d *differ) report(kind ChangeKind, subject Subject, def, name string, breaking bool, format string, args ...interface{}) {
+	d.changes = append(d.changes, &Change{
+		Kind:       kind,
+		Subject:    subject,
+		Definition: def,
+		Name:       name,
+		Breaking:   breaking,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// endpoints compares the routes of the two APIs then the actions that serve the routes present
+// in both. Routes are matched by verb and path regardless of the name of the path wildcards so
+// that renaming an action or a path parameter does not show as a route removal.
+func (d *differ) endpoints(old, new *genexport.API) {
+	olds, oldKeys := indexEndpoints(old)
+	news, newKeys := indexEndpoints(new)
+	for _, k := range oldKeys {
+		if _, ok := news[k]; !ok {
+			e := olds[k]
+			d.report(Removed, RouteSubject, actionDef(e), routeName(e.route), true,
+				"route %s removed", routeName(e.route))
+		}
+	}
+	for _, k := range newKeys {
+		if _, ok := olds[k]; !ok {
+			e := news[k]
+			d.report(Added, RouteSubject, actionDef(e), routeName(e.route), false,
+				"route %s added", routeName(e.route))
+		}
+	}
+	seen := make(map[[2]*genexport.Action]bool)
+	for _, k := range oldKeys {
+		o, n := olds[k], news[k]
+		if n == nil || seen[[2]*genexport.Action{o.action, n.action}] {
+			continue
+		}
+		seen[[2]*genexport.Action{o.action, n.action}] = true
+		d.action(actionDef(n), o.action, n.action)
+	}
+}
+
+// action compares the parameters, headers, payload and responses of two actions.
+func (d *differ) action(def string, old, new *genexport.Action) {
+	d.object(def, ParamSubject, "", old.Params, new.Params, true)
+	d.object(def, HeaderSubject, "", old.Headers, new.Headers, true)
+
+	switch {
+	case old.Payload == "" && new.Payload != "":
+		d.report(Added, PayloadSubject, def, new.Payload, !new.PayloadOptional,
+			"payload %s added", new.Payload)
+	case old.Payload != "" && new.Payload == "":
+		d.report(Removed, PayloadSubject, def, old.Payload, true,
+			"payload %s removed", old.Payload)
+	case old.Payload != new.Payload:
+		d.report(Changed, PayloadSubject, def, new.Payload, true,
+			"payload type changed from %s to %s", old.Payload, new.Payload)
+	case old.Payload != "" && old.PayloadOptional && !new.PayloadOptional:
+		d.report(Changed, PayloadSubject, def, new.Payload, true,
+			"payload %s is now required", new.Payload)
+	}
+
+	olds := make(map[int]*genexport.Response)
+	for _, r := range old.Responses {
+		olds[r.Status] = r
+	}
+	news := make(map[int]*genexport.Response)
+	for _, r := range new.Responses {
+		news[r.Status] = r
+	}
+	for _, s := range sortedStatuses(olds) {
+		o, n := olds[s], news[s]
+		name := fmt.Sprint(s)
+		switch {
+		case n == nil:
+			d.report(Removed, ResponseSubject, def, name, true, "response %d removed", s)
+		case o.MediaType != n.MediaType:
+			d.report(Changed, ResponseSubject, def, name, true,
+				"response %d media type changed from %s to %s", s, orNone(o.MediaType), orNone(n.MediaType))
+		case o.Type != n.Type:
+			d.report(Changed, ResponseSubject, def, name, true,
+				"response %d type changed from %s to %s", s, orNone(o.Type), orNone(n.Type))
+		}
+	}
+	for _, s := range sortedStatuses(news) {
+		if _, ok := olds[s]; !ok {
+			d.report(Added, ResponseSubject, def, fmt.Sprint(s), false, "response %d added", s)
+		}
+	}
+}
+
+// types compares the user types and media types of the two APIs. User types are matched by name
+// and media types by identifier.
+func (d *differ) types(old, new *genexport.API) {
+	oldTypes := make(map[string]*genexport.Attribute)
+	newTypes := make(map[string]*genexport.Attribute)
+	for _, t := range old.Types {
+		oldTypes[fmt.Sprintf("type %q", t.Name)] = t.Attribute
+	}
+	for _, t := range new.Types {
+		newTypes[fmt.Sprintf("type %q", t.Name)] = t.Attribute
+	}
+	oldMedia := make(map[string]*genexport.MediaType)
+	newMedia := make(map[string]*genexport.MediaType)
+	for _, mt := range old.MediaTypes {
+		def := fmt.Sprintf("media type %q", mt.Identifier)
+		oldTypes[def], oldMedia[def] = mt.Attribute, mt
+	}
+	for _, mt := range new.MediaTypes {
+		def := fmt.Sprintf("media type %q", mt.Identifier)
+		newTypes[def], newMedia[def] = mt.Attribute, mt
+	}
+
+	for _, def := range sortedKeys(oldTypes) {
+		n, ok := newTypes[def]
+		if !ok {
+			d.report(Removed, TypeSubject, def, "", true, "type removed")
+			continue
+		}
+		if ot, nt := typeName(oldTypes[def]), typeName(n); ot != nt {
+			d.report(Changed, TypeSubject, def, "", true, "type changed from %s to %s", ot, nt)
+			continue
+		}
+		d.object(def, AttributeSubject, "", oldTypes[def], n, false)
+		// The views of collections mirror the views of their element media type.
+		if mt := oldMedia[def]; mt != nil && (mt.Attribute == nil || mt.Attribute.Elem == nil) {
+			d.views(def, mt, newMedia[def])
+		}
+	}
+	for _, def := range sortedKeys(newTypes) {
+		if _, ok := oldTypes[def]; !ok {
+			d.report(Added, TypeSubject, def, "", false, "type added")
+		}
+	}
+}
+
+// views reports the views of the old media type that the new media type no longer defines and
+// the views it adds.
+func (d *differ) views(def string, old, new *genexport.MediaType) {
+	if new == nil {
+		return
+	}
+	has := func(mt *genexport.MediaType, name string) bool {
+		for _, v := range mt.Views {
+			if v.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range old.Views {
+		if !has(new, v.Name) {
+			d.report(Removed, ViewSubject, def, v.Name, true, "view %q removed", v.Name)
+		}
+	}
+	for _, v := range new.Views {
+		if !has(old, v.Name) {
+			d.report(Added, ViewSubject, def, v.Name, false, "view %q added", v.Name)
+		}
+	}
+}
+
+// object compares the attributes of two object attributes, prefix is the path to the objects
+// for nested attributes. request is true if the objects are only used in requests: relaxing a
+// requirement is then not breaking.
+func (d *differ) object(def string, subject Subject, prefix string, old, new *genexport.Attribute, request bool) {
+	oldAtts, newAtts := attributes(old), attributes(new)
+	oldReq, newReq := required(old), required(new)
+	for _, n := range sortedKeys(oldAtts) {
+		name := prefix + n
+		o := oldAtts[n]
+		a, ok := newAtts[n]
+		if !ok {
+			d.report(Removed, subject, def, name, true, "%s %q removed", subject, name)
+			continue
+		}
+		if ot, nt := typeName(o), typeName(a); ot != nt {
+			d.report(Changed, subject, def, name, true,
+				"%s %q type changed from %s to %s", subject, name, ot, nt)
+			continue
+		}
+		switch {
+		case !oldReq[n] && newReq[n]:
+			d.report(Changed, subject, def, name, true, "%s %q is now required", subject, name)
+		case oldReq[n] && !newReq[n]:
+			d.report(Changed, subject, def, name, !request, "%s %q is no longer required", subject, name)
+		}
+		switch oldEnum, newEnum := enum(o), enum(a); {
+		case len(newEnum) == 0:
+		case len(oldEnum) == 0:
+			d.report(Changed, subject, def, name, true,
+				"%s %q is now restricted to the values %s", subject, name, strings.Join(newEnum, ", "))
+		default:
+			if removed := missing(oldEnum, newEnum); len(removed) > 0 {
+				d.report(Changed, subject, def, name, true,
+					"%s %q no longer accepts the values %s", subject, name, strings.Join(removed, ", "))
+			}
+		}
+		if o.Ref == "" && o.Attributes != nil {
+			d.object(def, subject, name+".", o, a, request)
+		}
+	}
+	for _, n := range sortedKeys(newAtts) {
+		if _, ok := oldAtts[n]; !ok {
+			name := prefix + n
+			if newReq[n] {
+				d.report(Added, subject, def, name, true, "required %s %q added", subject, name)
+			} else {
+				d.report(Added, subject, def, name, false, "%s %q added", subject, name)
+			}
+		}
+	}
+}
+
+// indexEndpoints returns the routes of the API indexed by verb and normalized path as well as
+// the sorted index keys.
+func indexEndpoints(api *genexport.API) (map[string]*endpoint, []string) {
+	index := make(map[string]*endpoint)
+	var keys []string
+	for _, res := range api.Resources {
+		for _, a := range res.Actions {
+			for _, r := range a.Routes {
+				k := r.Verb + " " + design.WildcardRegex.ReplaceAllStringFunc(r.FullPath, func(w string) string { return w[:2] })
+				if _, ok := index[k]; ok {
+					continue
+				}
+				index[k] = &endpoint{resource: res, action: a, route: r}
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return index, keys
+}
+
+// actionDef describes the action serving the given endpoint.
+func actionDef(e *endpoint) string {
+	return fmt.Sprintf("resource %q action %q", e.resource.Name, e.action.Name)
+}
+
+// routeName returns the verb and full path of the route.
+func routeName(r *genexport.Route) string {
+	return r.Verb + " " + r.FullPath
+}
+
+// attributes returns the child attributes of an object attribute or nil.
+func attributes(att *genexport.Attribute) map[string]*genexport.Attribute {
+	if att == nil {
+		return nil
+	}
+	return att.Attributes
+}
+
+// required returns the set of required child attribute names of an object attribute.
+func required(att *genexport.Attribute) map[string]bool {
+	req := make(map[string]bool)
+	if att != nil && att.Validation != nil {
+		for _, n := range att.Validation.Required {
+			req[n] = true
+		}
+	}
+	return req
+}
+
+// typeName returns a description of the type of the attribute that identifies user types and
+// media types by name and the element types of arrays and hashes.
+func typeName(att *genexport.Attribute) string {
+	switch {
+	case att == nil:
+		return "none"
+	case att.Ref != "":
+		return att.Ref
+	case att.Key != nil:
+		return fmt.Sprintf("%s<%s, %s>", att.Type, typeName(att.Key), typeName(att.Elem))
+	case att.Elem != nil:
+		return fmt.Sprintf("%s<%s>", att.Type, typeName(att.Elem))
+	}
+	return att.Type
+}
+
+// enum returns the JSON encoding of the enum values of the attribute. The values are compared
+// using their JSON encoding as the documents read from disk decode all numbers as float64.
+func enum(att *genexport.Attribute) []string {
+	if att.Validation == nil {
+		return nil
+	}
+	vals := make([]string, len(att.Validation.Enum))
+	for i, v := range att.Validation.Enum {
+		b, _ := json.Marshal(v)
+		vals[i] = string(b)
+	}
+	return vals
+}
+
+// missing returns the elements of old that are not in new.
+func missing(old, new []string) []string {
+	var res []string
+	for _, o := range old {
+		found := false
+		for _, n := range new {
+			if o == n {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// orNone returns s or "none" if s is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// sortedStatuses returns the sorted keys of the map.
+func sortedStatuses(m map[int]*genexport.Response) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// sortedKeys returns the sorted keys of the map.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,223 @@
+package gendiff_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_diff"
+	"github.com/goadesign/goa/goagen/gen_export"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// export evaluates the given DSL and returns the resulting export document.
+func export(dsl func()) *genexport.Document {
+	dslengine.Reset()
+	dsl()
+	Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+	return genexport.Export(Design)
+}
+
+var _ = Describe("Diff", func() {
+	var oldDSL, newDSL func()
+	var changes []*gendiff.Change
+
+	// changesOf returns the formatted changes of the given subject.
+	changesOf := func(subject gendiff.Subject) []string {
+		var res []string
+		for _, c := range changes {
+			if c.Subject == subject {
+				res = append(res, c.String())
+			}
+		}
+		return res
+	}
+
+	BeforeEach(func() {
+		oldDSL = func() {
+			API("cellar", func() {})
+			var Bottle = MediaType("application/vnd.bottle", func() {
+				Attributes(func() {
+					Attribute("id", Integer)
+					Attribute("name", String)
+					Attribute("color", String, func() { Enum("red", "white", "rose") })
+					Required("id", "name")
+				})
+				View("default", func() {
+					Attribute("id")
+					Attribute("name")
+				})
+				View("tiny", func() {
+					Attribute("id")
+				})
+			})
+			Resource("bottles", func() {
+				BasePath("/bottles")
+				Action("show", func() {
+					Routing(GET("/:id"))
+					Params(func() {
+						Param("id", Integer)
+						Param("fields", String)
+					})
+					Response(OK, Bottle)
+					Response(NotFound)
+				})
+				Action("list", func() {
+					Routing(GET(""))
+					Params(func() {
+						Param("page", Integer)
+						Required("page")
+					})
+					Response(OK, CollectionOf(Bottle))
+				})
+				Action("delete", func() {
+					Routing(DELETE("/:id"))
+					Response(NoContent)
+				})
+			})
+		}
+		newDSL = oldDSL
+	})
+
+	JustBeforeEach(func() {
+		changes = gendiff.Diff(export(oldDSL), export(newDSL))
+	})
+
+	It("reports no change for identical designs", func() {
+		Ω(changes).Should(BeEmpty())
+	})
+
+	Context("with a new version of the design", func() {
+		BeforeEach(func() {
+			newDSL = func() {
+				API("cellar", func() {})
+				var Bottle = MediaType("application/vnd.bottle", func() {
+					Attributes(func() {
+						Attribute("id", String)
+						Attribute("name", String)
+						Attribute("color", String, func() { Enum("red", "white") })
+						Attribute("vintage", Integer)
+						Required("id")
+					})
+					View("default", func() {
+						Attribute("id")
+						Attribute("name")
+					})
+					View("full", func() {
+						Attribute("id")
+						Attribute("vintage")
+					})
+				})
+				Type("Rating", func() {
+					Attribute("stars", Integer)
+				})
+				Resource("bottles", func() {
+					BasePath("/bottles")
+					Action("get", func() {
+						Routing(GET("/:bottleID"))
+						Params(func() {
+							Param("bottleID", Integer)
+							Param("locale", String)
+							Param("view", String)
+							Required("view")
+						})
+						Response(OK, Bottle)
+					})
+					Action("list", func() {
+						Routing(GET(""))
+						Params(func() {
+							Param("page", Integer)
+						})
+						Response(OK, CollectionOf(Bottle))
+						Response(BadRequest)
+					})
+					Action("rate", func() {
+						Routing(PUT("/:bottleID/rating"))
+						Payload("Rating")
+						Response(NoContent)
+					})
+				})
+			}
+		})
+
+		It("reports the added and removed routes", func() {
+			Ω(changesOf(gendiff.RouteSubject)).Should(Equal([]string{
+				`breaking: resource "bottles" action "delete": route DELETE /bottles/:id removed`,
+				`non-breaking: resource "bottles" action "rate": route PUT /bottles/:bottleID/rating added`,
+			}))
+		})
+
+		It("reports the parameter changes of the actions serving the same routes", func() {
+			Ω(changesOf(gendiff.ParamSubject)).Should(Equal([]string{
+				`non-breaking: resource "bottles" action "list": param "page" is no longer required`,
+				`breaking: resource "bottles" action "get": param "fields" removed`,
+				`breaking: resource "bottles" action "get": param "id" removed`,
+				`non-breaking: resource "bottles" action "get": param "bottleID" added`,
+				`non-breaking: resource "bottles" action "get": param "locale" added`,
+				`breaking: resource "bottles" action "get": required param "view" added`,
+			}))
+		})
+
+		It("reports the response changes", func() {
+			Ω(changesOf(gendiff.ResponseSubject)).Should(Equal([]string{
+				`non-breaking: resource "bottles" action "list": response 400 added`,
+				`breaking: resource "bottles" action "get": response 404 removed`,
+			}))
+		})
+
+		It("reports the type changes", func() {
+			Ω(changesOf(gendiff.TypeSubject)).Should(Equal([]string{
+				`non-breaking: type "Rating": type added`,
+			}))
+			Ω(changesOf(gendiff.AttributeSubject)).Should(Equal([]string{
+				`breaking: media type "application/vnd.bottle": attribute "color" no longer accepts the values "rose"`,
+				`breaking: media type "application/vnd.bottle": attribute "id" type changed from integer to string`,
+				`breaking: media type "application/vnd.bottle": attribute "name" is no longer required`,
+				`non-breaking: media type "application/vnd.bottle": attribute "vintage" added`,
+			}))
+			Ω(changesOf(gendiff.ViewSubject)).Should(Equal([]string{
+				`breaking: media type "application/vnd.bottle": view "tiny" removed`,
+				`non-breaking: media type "application/vnd.bottle": view "full" added`,
+			}))
+		})
+
+		It("identifies the changed elements", func() {
+			for _, c := range changes {
+				if c.Subject == gendiff.RouteSubject && c.Kind == gendiff.Removed {
+					Ω(c.Name).Should(Equal("DELETE /bottles/:id"))
+					Ω(c.Breaking).Should(BeTrue())
+					return
+				}
+			}
+			Fail("no change for the removed route")
+		})
+	})
+
+	Context("with a payload that becomes required", func() {
+		BeforeEach(func() {
+			payload := func(optional bool) func() {
+				return func() {
+					API("cellar", func() {})
+					Resource("bottles", func() {
+						Action("create", func() {
+							Routing(POST("/bottles"))
+							if optional {
+								OptionalPayload(func() { Attribute("name", String) })
+							} else {
+								Payload(func() { Attribute("name", String) })
+							}
+							Response(Created)
+						})
+					})
+				}
+			}
+			oldDSL, newDSL = payload(true), payload(false)
+		})
+
+		It("reports a breaking change", func() {
+			Ω(changesOf(gendiff.PayloadSubject)).Should(Equal([]string{
+				`breaking: resource "bottles" action "create": payload CreateBottlesPayload is now required`,
+			}))
+		})
+	})
+})
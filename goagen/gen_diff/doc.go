@@ -0,0 +1,12 @@
+/*
+Package gendiff provides a generator that compares the evaluated design with a previous version of
+the design exported by the genexport generator. The added, removed and changed routes, parameters,
+headers, payloads, responses, types and media types are written to a JSON report, each change is
+classified as breaking or non-breaking for the clients built against the previous version, see
+Diff.
+
+The goagen "diff" command evaluates both versions of the design:
+
+	goagen diff --old github.com/acme/cellar/design/v1 --new github.com/acme/cellar/design --fail-on-breaking
+*/
+package gendiff
@@ -0,0 +1,116 @@
+package gendiff
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/gen_export"
+)
+
+// ReportFile is the name of the report written in the output directory by default.
+const ReportFile = "diff.json"
+
+// Generator compares the evaluated design with a previously exported design.
+type Generator struct {
+	outDir         string // Path to output directory
+	old            string // Path to the export document of the old design
+	report         string // Path to the report file
+	failOnBreaking bool   // Whether breaking changes fail the generation
+}
+
+// Report is the content of the diff report.
+type Report struct {
+	// Old is the version of the old API.
+	Old string `json:"old,omitempty"`
+	// New is the version of the new API.
+	New string `json:"new,omitempty"`
+	// Changes lists the changes between the old and new designs.
+	Changes []*Change `json:"changes"`
+	// Breaking is the number of breaking changes.
+	Breaking int `json:"breaking"`
+	// NonBreaking is the number of non-breaking changes.
+	NonBreaking int `json:"non_breaking"`
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, old, report string
+	var failOnBreaking bool
+	set := flag.NewFlagSet("diff", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.String("design", "", "")
+	set.StringVar(&old, "old", "", "")
+	set.String("new", "", "")
+	set.StringVar(&report, "report", "", "")
+	set.BoolVar(&failOnBreaking, "fail-on-breaking", false, "")
+	set.Parse(os.Args[2:])
+
+	g := NewGenerator(outDir, old, report, failOnBreaking)
+
+	return g.Generate(design.Design)
+}
+
+// NewGenerator returns a generator that compares the design with the design export document
+// (see package genexport) at path old. The report is written to the given path, ReportFile in
+// outDir if empty. failOnBreaking causes Generate to return an error if the comparison finds any
+// breaking change.
+func NewGenerator(outDir, old, report string, failOnBreaking bool) *Generator {
+	if report == "" {
+		report = filepath.Join(outDir, ReportFile)
+	}
+	return &Generator{outDir: outDir, old: old, report: report, failOnBreaking: failOnBreaking}
+}
+
+// Generate compares the design with the old design and writes the report. It returns an error
+// listing the breaking changes if there are any and the generator was created with
+// failOnBreaking.
+func (g *Generator) Generate(api *design.APIDefinition) ([]string, error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
+	}
+	b, err := ioutil.ReadFile(g.old)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read old design: %s", err)
+	}
+	var old genexport.Document
+	if err := json.Unmarshal(b, &old); err != nil {
+		return nil, fmt.Errorf("failed to load old design from %s: %s", g.old, err)
+	}
+	if old.FormatVersion != genexport.FormatVersion || old.API == nil {
+		return nil, fmt.Errorf("unsupported design export document %s, expected format version %s", g.old, genexport.FormatVersion)
+	}
+	new := genexport.Export(api)
+
+	report := Report{Old: old.API.Version, New: new.API.Version, Changes: Diff(&old, new)}
+	if report.Changes == nil {
+		report.Changes = []*Change{}
+	}
+	var breaking []string
+	for _, c := range report.Changes {
+		if c.Breaking {
+			report.Breaking++
+			breaking = append(breaking, c.String())
+		} else {
+			report.NonBreaking++
+		}
+	}
+	if b, err = json.MarshalIndent(&report, "", "  "); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(g.report), 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(g.report, append(b, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	if g.failOnBreaking && len(breaking) > 0 {
+		return nil, fmt.Errorf("design diff found %d breaking change(s), see %s:\n%s", len(breaking), g.report, strings.Join(breaking, "\n"))
+	}
+	return []string{g.report}, nil
+}
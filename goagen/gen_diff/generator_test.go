@@ -0,0 +1,106 @@
+package gendiff_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_diff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var outDir, oldFile string
+	var failOnBreaking bool
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		var err error
+		outDir, err = ioutil.TempDir("", "diff")
+		Ω(err).ShouldNot(HaveOccurred())
+		failOnBreaking = false
+		js, err := export(func() {
+			API("cellar", func() { Version("1.0") })
+			Resource("bottles", func() {
+				Action("show", func() {
+					Routing(GET("/bottles/:id"))
+					Response(OK)
+				})
+				Action("delete", func() {
+					Routing(DELETE("/bottles/:id"))
+					Response(NoContent)
+				})
+			})
+		}).JSON()
+		Ω(err).ShouldNot(HaveOccurred())
+		oldFile = filepath.Join(outDir, "old.json")
+		Ω(ioutil.WriteFile(oldFile, js, 0644)).ShouldNot(HaveOccurred())
+
+		dslengine.Reset()
+		API("cellar", func() { Version("2.0") })
+		Resource("bottles", func() {
+			Action("show", func() {
+				Routing(GET("/bottles/:id"))
+				Response(OK)
+				Response(NotFound)
+			})
+		})
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		os.Args = []string{"goagen", "diff", "--out=" + outDir, "--design=foo", "--old=" + oldFile, "--new=foo"}
+		if failOnBreaking {
+			os.Args = append(os.Args, "--fail-on-breaking=true")
+		}
+		files, genErr = gendiff.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	It("writes the report", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(files).Should(Equal([]string{filepath.Join(outDir, gendiff.ReportFile)}))
+		b, err := ioutil.ReadFile(files[0])
+		Ω(err).ShouldNot(HaveOccurred())
+		var report gendiff.Report
+		Ω(json.Unmarshal(b, &report)).ShouldNot(HaveOccurred())
+		Ω(report.Old).Should(Equal("1.0"))
+		Ω(report.New).Should(Equal("2.0"))
+		Ω(report.Breaking).Should(Equal(1))
+		Ω(report.NonBreaking).Should(Equal(1))
+		Ω(report.Changes).Should(HaveLen(2))
+	})
+
+	Context("with --fail-on-breaking", func() {
+		BeforeEach(func() {
+			failOnBreaking = true
+		})
+
+		It("fails listing the breaking changes", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(genErr.Error()).Should(ContainSubstring(`breaking: resource "bottles" action "delete": route DELETE /bottles/:id removed`))
+			_, err := os.Stat(filepath.Join(outDir, gendiff.ReportFile))
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with an invalid old design", func() {
+		BeforeEach(func() {
+			Ω(ioutil.WriteFile(oldFile, []byte(`{"format_version": "0"}`), 0644)).ShouldNot(HaveOccurred())
+		})
+
+		It("fails", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(genErr.Error()).Should(ContainSubstring("unsupported design export document"))
+		})
+	})
+})
@@ -0,0 +1,14 @@
+/*
+Package gendocs provides a goa generator for a static HTML documentation site.
+
+The site consists of an index page that describes the API and the instructions for each security
+scheme and of one page per resource. The resource pages document each action with its routes,
+the tables of its parameters, headers, payload and response attributes together with their
+validations and an example curl command line. The pages embed their style sheet and do not load
+any external resource so that the site may be served by any static file server.
+
+The "markdown" format produces the same content as a README.md file and one Markdown file per
+resource that may be committed next to the design and reviewed in pull requests. The error
+responses of each action are listed in a separate table.
+*/
+package gendocs
@@ -0,0 +1,13 @@
+package gendocs_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenDocs(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenDocs Suite")
+}
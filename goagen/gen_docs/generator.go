@@ -0,0 +1,694 @@
+package gendocs
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+)
+
+// Generator is the static HTML documentation generator.
+type Generator struct {
+	genfiles []string // Generated files
+	outDir   string   // Destination directory
+	scheme   string   // Scheme used in the curl examples
+	host     string   // Host used in the curl examples
+	format   string   // Output format, FormatHTML or FormatMarkdown
+}
+
+type (
+	// docsResource is the data used to render a resource page.
+	docsResource struct {
+		// Name is the resource name.
+		Name string
+		// File is the name of the resource page file.
+		File string
+		// Description is the resource description.
+		Description string
+		// Actions lists the resource actions.
+		Actions []*docsAction
+	}
+
+	// docsAction is the data used to render the documentation of an action.
+	docsAction struct {
+		// Name is the action name.
+		Name string
+		// Description is the action description.
+		Description string
+		// Routes lists the action routes as method and path pairs.
+		Routes []string
+		// Params lists the path and query string parameters.
+		Params []*docsAttribute
+		// Headers lists the request headers.
+		Headers []*docsAttribute
+		// Payload is the name of the payload type if any.
+		Payload string
+		// PayloadFields lists the payload attributes, nested attributes are listed with their
+		// dotted path.
+		PayloadFields []*docsAttribute
+		// Responses lists the responses sorted by status.
+		Responses []*docsResponse
+		// Security is the name of the security scheme that applies to the action if any.
+		Security string
+		// Scopes lists the scopes required by the action.
+		Scopes []string
+		// Curl is the curl command line of an example request.
+		Curl string
+	}
+
+	// docsAttribute is the data used to render a row of an attribute table.
+	docsAttribute struct {
+		// Name is the attribute name.
+		Name string
+		// In is "path" or "query" for parameters, empty otherwise.
+		In string
+		// Type is the attribute type.
+		Type string
+		// Required is true if the attribute is required.
+		Required bool
+		// Description is the attribute description.
+		Description string
+		// Validations lists the attribute validations and default value.
+		Validations []string
+	}
+
+	// docsResponse is the data used to render the documentation of a response.
+	docsResponse struct {
+		// Status is the response status code.
+		Status int
+		// Name is the response name.
+		Name string
+		// Description is the response description.
+		Description string
+		// MediaType is the response media type identifier if any.
+		MediaType string
+		// Fields lists the response body attributes.
+		Fields []*docsAttribute
+	}
+
+	// docsScheme is the data used to render the instructions of a security scheme.
+	docsScheme struct {
+		// Name is the scheme name.
+		Name string
+		// Kind is the scheme kind: "basic", "apiKey", "jwt" or "oauth2".
+		Kind string
+		// Description is the scheme description.
+		Description string
+		// In is "header" or "query" for API key schemes.
+		In string
+		// Param is the name of the header or query string parameter of API key schemes.
+		Param string
+		// Flow is the OAuth2 flow.
+		Flow string
+		// AuthorizationURL is the OAuth2 authorization URL.
+		AuthorizationURL string
+		// TokenURL is the URL issuing the tokens.
+		TokenURL string
+		// Scopes maps the scope names to their description.
+		Scopes map[string]string
+	}
+)
+
+const (
+	// FormatHTML is the format of the static HTML site.
+	FormatHTML = "html"
+	// FormatMarkdown is the format of the Markdown reference.
+	FormatMarkdown = "markdown"
+)
+
+// maxDepth is the maximum depth of the attributes listed in the tables, the attributes of
+// recursive types are listed up to that depth.
+const maxDepth = 4
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, scheme, host, format string
+
+	set := flag.NewFlagSet("docs", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.String("design", "", "")
+	set.StringVar(&scheme, "scheme", "", "")
+	set.StringVar(&host, "host", "", "")
+	set.StringVar(&format, "format", FormatHTML, "")
+	set.Parse(os.Args[2:])
+
+	g := &Generator{outDir: outDir, scheme: scheme, host: host, format: format}
+
+	return g.Generate(design.Design)
+}
+
+// Generate produces the documentation site or the Markdown reference in the "docs" directory of
+// the output directory.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	index, ext, layout, indexTmpl, resourceTmpl := "index.html", ".html", layoutT, indexT, resourceT
+	switch g.format {
+	case FormatHTML:
+	case FormatMarkdown:
+		index, ext, layout, indexTmpl, resourceTmpl = "README.md", ".md", mdLayoutT, mdIndexT, mdResourceT
+	default:
+		return nil, fmt.Errorf("unsupported format %q, must be %q or %q", g.format, FormatHTML, FormatMarkdown)
+	}
+
+	if g.scheme == "" && len(api.Schemes) > 0 {
+		g.scheme = api.Schemes[0]
+	}
+	if g.scheme == "" {
+		g.scheme = "http"
+	}
+	if g.host == "" {
+		g.host = api.Host
+	}
+	if g.host == "" {
+		g.host = "localhost:8080"
+	}
+
+	outDir := filepath.Join(g.outDir, "docs")
+	render, err := codegen.BeginRender(outDir)
+	if err != nil {
+		return nil, err
+	}
+	defer render.Discard()
+	if err = os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, outDir)
+
+	baseURL := g.scheme + "://" + g.host
+	var resources []*docsResource
+	err = api.IterateResources(func(res *design.ResourceDefinition) error {
+		r := &docsResource{
+			Name:        res.Name,
+			File:        codegen.SnakeCase(codegen.Goify(res.Name, true)) + ext,
+			Description: res.Description,
+		}
+		err := res.IterateActions(func(a *design.ActionDefinition) error {
+			r.Actions = append(r.Actions, action(api, a, baseURL))
+			return nil
+		})
+		resources = append(resources, r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var schemes []*docsScheme
+	for _, s := range api.SecuritySchemes {
+		schemes = append(schemes, scheme(s))
+	}
+
+	data := map[string]interface{}{
+		"API":       api,
+		"BaseURL":   baseURL,
+		"Resources": resources,
+		"Schemes":   schemes,
+	}
+	if err = g.generatePage(filepath.Join(outDir, index), layout+indexTmpl, data); err != nil {
+		return nil, err
+	}
+	for _, r := range resources {
+		data["Resource"] = r
+		if err = g.generatePage(filepath.Join(outDir, r.File), layout+resourceTmpl, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.genfiles, render.Commit()
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for i := len(g.genfiles) - 1; i >= 0; i-- {
+		os.Remove(g.genfiles[i])
+	}
+	g.genfiles = nil
+}
+
+// generatePage renders the page template tmpl.
+func (g *Generator) generatePage(path, tmpl string, data map[string]interface{}) error {
+	file, err := codegen.SourceFileFor(path)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, path)
+	return file.ExecuteTemplate("page", tmpl, template.FuncMap{"cell": cell}, data)
+}
+
+// action computes the documentation of the given action.
+func action(api *design.APIDefinition, a *design.ActionDefinition, baseURL string) *docsAction {
+	da := &docsAction{Name: a.Name, Description: a.Description}
+	pathParams := make(map[string]bool)
+	for _, r := range a.Routes {
+		da.Routes = append(da.Routes, r.Verb+" "+r.FullPath())
+		for _, p := range r.Params() {
+			pathParams[p] = true
+		}
+	}
+	if params := a.AllParams(); params != nil {
+		da.Params = attributes(params, "", 0)
+		for _, p := range da.Params {
+			p.In = "query"
+			if pathParams[p.Name] {
+				p.In = "path"
+			}
+		}
+	}
+	if a.Headers != nil {
+		da.Headers = attributes(a.Headers, "", 0)
+	}
+	if a.Payload != nil {
+		da.Payload = a.Payload.TypeName
+		if a.Payload.Type.IsObject() {
+			da.PayloadFields = attributes(a.Payload.AttributeDefinition, "", 0)
+		}
+	}
+	a.IterateResponses(func(r *design.ResponseDefinition) error {
+		dr := &docsResponse{Status: r.Status, Name: r.Name, Description: r.Description}
+		if mt := api.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			dr.MediaType = mt.Identifier
+			if mt.Type.IsObject() {
+				dr.Fields = attributes(mt.AttributeDefinition, "", 0)
+			}
+		} else {
+			dr.MediaType = r.MediaType
+		}
+		da.Responses = append(da.Responses, dr)
+		return nil
+	})
+	sort.SliceStable(da.Responses, func(i, j int) bool { return da.Responses[i].Status < da.Responses[j].Status })
+	if a.Security != nil && a.Security.Scheme != nil {
+		da.Security = a.Security.Scheme.SchemeName
+		da.Scopes = a.Security.Scopes
+	}
+	if len(a.Routes) > 0 {
+		da.Curl = curl(api, a, baseURL)
+	}
+	return da
+}
+
+// attributes lists the attributes of the given object sorted by name, the attributes of nested
+// objects are listed after their parent prefixed with its name.
+func attributes(att *design.AttributeDefinition, prefix string, depth int) []*docsAttribute {
+	obj := att.Type.ToObject()
+	if obj == nil || depth >= maxDepth {
+		return nil
+	}
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var res []*docsAttribute
+	for _, n := range names {
+		child := obj[n]
+		res = append(res, &docsAttribute{
+			Name:        prefix + n,
+			Type:        typeName(child.Type),
+			Required:    att.IsRequired(n),
+			Description: child.Description,
+			Validations: validations(child),
+		})
+		switch {
+		case child.Type.IsObject():
+			res = append(res, attributes(child, prefix+n+".", depth+1)...)
+		case child.Type.IsArray() && child.Type.ToArray().ElemType.Type.IsObject():
+			res = append(res, attributes(child.Type.ToArray().ElemType, prefix+n+"[].", depth+1)...)
+		}
+	}
+	return res
+}
+
+// typeName returns the name of the given type as displayed in the attribute tables.
+func typeName(t design.DataType) string {
+	switch actual := t.(type) {
+	case *design.MediaTypeDefinition:
+		return actual.TypeName
+	case *design.UserTypeDefinition:
+		return actual.TypeName
+	case *design.Array:
+		return "array of " + typeName(actual.ElemType.Type)
+	case *design.Hash:
+		return "map of " + typeName(actual.KeyType.Type) + " to " + typeName(actual.ElemType.Type)
+	}
+	return t.Name()
+}
+
+// validations describes the validations and default value of the given attribute.
+func validations(att *design.AttributeDefinition) []string {
+	var res []string
+	if att.DefaultValue != nil {
+		res = append(res, "default: "+literal(att.DefaultValue))
+	}
+	v := att.Validation
+	if v == nil {
+		return res
+	}
+	if len(v.Values) > 0 {
+		values := make([]string, len(v.Values))
+		for i, val := range v.Values {
+			values[i] = literal(val)
+		}
+		res = append(res, "one of: "+strings.Join(values, ", "))
+	}
+	if v.Format != "" {
+		res = append(res, "format: "+v.Format)
+	}
+	if v.Pattern != "" {
+		res = append(res, "pattern: "+v.Pattern)
+	}
+	if v.Minimum != nil {
+		res = append(res, fmt.Sprintf("minimum: %v", *v.Minimum))
+	}
+	if v.Maximum != nil {
+		res = append(res, fmt.Sprintf("maximum: %v", *v.Maximum))
+	}
+	if v.MinLength != nil {
+		res = append(res, fmt.Sprintf("minimum length: %d", *v.MinLength))
+	}
+	if v.MaxLength != nil {
+		res = append(res, fmt.Sprintf("maximum length: %d", *v.MaxLength))
+	}
+	return res
+}
+
+// literal returns the JSON representation of the given value.
+func literal(val interface{}) string {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+	return string(b)
+}
+
+// curl returns the command line of an example request made to the first route of the action.
+// The request sets the required query string parameters and headers, the payload and the
+// credentials of the action security scheme using placeholders.
+func curl(api *design.APIDefinition, a *design.ActionDefinition, baseURL string) string {
+	r := api.RandomGenerator()
+	route := a.Routes[0]
+	path := route.FullPath()
+	// Look up the action parameters first as AllParams does not copy the examples.
+	params := a.AllParams().Type.ToObject()
+	if a.Params != nil {
+		for n, att := range a.Params.Type.ToObject() {
+			params[n] = att
+		}
+	}
+	for _, p := range route.Params() {
+		val := "1"
+		if att := params[p]; att != nil {
+			val = fmt.Sprint(example(att, r))
+		}
+		path = strings.Replace(path, ":"+p, url.PathEscape(val), 1)
+		path = strings.Replace(path, "*"+p, val, 1)
+	}
+	query := url.Values{}
+	if a.QueryParams != nil {
+		for n, att := range a.QueryParams.Type.ToObject() {
+			if a.QueryParams.IsRequired(n) {
+				query.Set(n, fmt.Sprint(example(att, r)))
+			}
+		}
+	}
+	args := []string{"curl", "-X", route.Verb}
+	if a.Headers != nil {
+		obj := a.Headers.Type.ToObject()
+		names := make([]string, 0, len(obj))
+		for n := range obj {
+			if a.Headers.IsRequired(n) {
+				names = append(names, n)
+			}
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			args = append(args, "-H", quote(n+": "+fmt.Sprint(example(obj[n], r))))
+		}
+	}
+	if a.Security != nil && a.Security.Scheme != nil {
+		s := a.Security.Scheme
+		switch s.Kind {
+		case design.BasicAuthSecurityKind:
+			args = append(args, "-u", quote("$USERNAME:$PASSWORD"))
+		case design.APIKeySecurityKind:
+			if s.In == "query" {
+				query.Set(s.Name, "$API_KEY")
+			} else {
+				args = append(args, "-H", quote(s.Name+": $API_KEY"))
+			}
+		case design.JWTSecurityKind, design.OAuth2SecurityKind:
+			args = append(args, "-H", quote("Authorization: Bearer $TOKEN"))
+		}
+	}
+	if a.Payload != nil {
+		body, err := json.Marshal(example(a.Payload.AttributeDefinition, r))
+		if err == nil {
+			args = append(args, "-H", quote("Content-Type: application/json"), "-d", quote(string(body)))
+		}
+	}
+	u := baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return strings.Join(append(args, quote(u)), " ")
+}
+
+// example returns the example value of the attribute defined in the design or a generated one.
+// The examples of objects are built from the examples of their attributes.
+func example(att *design.AttributeDefinition, r *design.RandomGenerator) interface{} {
+	return exampleAt(att, r, 0)
+}
+
+func exampleAt(att *design.AttributeDefinition, r *design.RandomGenerator, depth int) interface{} {
+	if att.Example != nil {
+		return att.Example
+	}
+	if obj := att.Type.ToObject(); obj != nil {
+		res := make(map[string]interface{}, len(obj))
+		if depth < maxDepth {
+			for n, child := range obj {
+				res[n] = exampleAt(child, r, depth+1)
+			}
+		}
+		return res
+	}
+	return att.GenerateExample(r)
+}
+
+// cell escapes s so that it may be used in a Markdown table cell.
+func cell(s string) string {
+	s = strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;").Replace(s)
+	return strings.Replace(strings.TrimSpace(s), "\n", "<br>", -1)
+}
+
+// quote quotes s for the shell.
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// scheme computes the instructions of the given security scheme.
+func scheme(s *design.SecuritySchemeDefinition) *docsScheme {
+	ds := &docsScheme{
+		Name:             s.SchemeName,
+		Description:      s.Description,
+		In:               s.In,
+		Param:            s.Name,
+		Flow:             s.Flow,
+		AuthorizationURL: s.AuthorizationURL,
+		TokenURL:         s.TokenURL,
+		Scopes:           s.Scopes,
+	}
+	switch s.Kind {
+	case design.BasicAuthSecurityKind:
+		ds.Kind = "basic"
+	case design.APIKeySecurityKind:
+		ds.Kind = "apiKey"
+	case design.JWTSecurityKind:
+		ds.Kind = "jwt"
+	case design.OAuth2SecurityKind:
+		ds.Kind = "oauth2"
+	}
+	return ds
+}
+
+const layoutT = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ block "title" . }}{{ end }}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #222; display: flex; }
+nav { width: 16em; min-height: 100vh; padding: 1em; background: #f4f5f7; box-sizing: border-box; }
+nav ul { list-style: none; padding: 0; }
+nav a { color: #0366d6; text-decoration: none; }
+main { flex: 1; padding: 1em 2em; max-width: 60em; }
+code, pre { font-family: Menlo, Consolas, monospace; font-size: 0.9em; }
+pre { background: #f4f5f7; padding: 0.8em; overflow-x: auto; white-space: pre-wrap; word-break: break-all; }
+table { border-collapse: collapse; width: 100%; margin: 0.5em 0 1em; }
+th, td { border: 1px solid #dde; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f4f5f7; }
+.route { font-weight: bold; }
+.required { color: #b31d28; }
+section.action { border-top: 1px solid #dde; margin-top: 2em; }
+</style>
+</head>
+<body>
+<nav>
+<p><a href="index.html">{{ html (or .API.Title .API.Name) }}</a></p>
+<ul>
+{{ range .Resources }}<li><a href="{{ .File }}">{{ html .Name }}</a></li>
+{{ end }}</ul>
+</nav>
+<main>
+{{ block "main" . }}{{ end }}
+</main>
+</body>
+</html>
+{{ define "attributes" }}<table>
+<tr><th>Name</th>{{ if (index . 0).In }}<th>In</th>{{ end }}<th>Type</th><th>Description</th><th>Validations</th></tr>
+{{ range . }}<tr><td><code>{{ html .Name }}</code>{{ if .Required }} <span class="required">required</span>{{ end }}</td>{{ if .In }}<td>{{ .In }}</td>{{ end }}<td>{{ html .Type }}</td><td>{{ html .Description }}</td><td>{{ range $i, $v := .Validations }}{{ if $i }}<br>{{ end }}{{ html $v }}{{ end }}</td></tr>
+{{ end }}</table>
+{{ end }}`
+
+const indexT = `{{ define "title" }}{{ html (or .API.Title .API.Name) }}{{ end }}{{ define "main" }}<h1>{{ html (or .API.Title .API.Name) }}</h1>
+{{ if .API.Version }}<p>Version {{ html .API.Version }}</p>
+{{ end }}{{ if .API.Description }}<p>{{ html .API.Description }}</p>
+{{ end }}<p>Base URL: <code>{{ html .BaseURL }}{{ html .API.BasePath }}</code></p>
+{{ if .Schemes }}<h2>Authentication</h2>
+{{ range .Schemes }}<h3 id="{{ html .Name }}">{{ html .Name }}</h3>
+{{ if .Description }}<p>{{ html .Description }}</p>
+{{ end }}{{ if eq .Kind "basic" }}<p>Send the user name and password using HTTP basic authentication, e.g. <code>curl -u "$USERNAME:$PASSWORD"</code>.</p>
+{{ else if eq .Kind "apiKey" }}<p>Send the API key in the <code>{{ html .Param }}</code> {{ if eq .In "query" }}query string parameter{{ else }}header{{ end }}.</p>
+{{ else if eq .Kind "jwt" }}<p>Send the JSON Web Token in the <code>Authorization</code> header using the Bearer scheme, e.g. <code>curl -H "Authorization: Bearer $TOKEN"</code>.{{ if .TokenURL }} Tokens are issued by <code>{{ html .TokenURL }}</code>.{{ end }}</p>
+{{ else if eq .Kind "oauth2" }}<p>Obtain an access token using the OAuth2 <code>{{ html .Flow }}</code> flow and send it in the <code>Authorization</code> header using the Bearer scheme.</p>
+<ul>
+{{ if .AuthorizationURL }}<li>Authorization URL: <code>{{ html .AuthorizationURL }}</code></li>
+{{ end }}{{ if .TokenURL }}<li>Token URL: <code>{{ html .TokenURL }}</code></li>
+{{ end }}</ul>
+{{ end }}{{ if .Scopes }}<table>
+<tr><th>Scope</th><th>Description</th></tr>
+{{ range $name, $desc := .Scopes }}<tr><td><code>{{ html $name }}</code></td><td>{{ html $desc }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ end }}{{ end }}<h2>Resources</h2>
+<ul>
+{{ range .Resources }}<li><a href="{{ .File }}">{{ html .Name }}</a>{{ if .Description }}: {{ html .Description }}{{ end }}</li>
+{{ end }}</ul>
+{{ end }}`
+
+const resourceT = `{{ define "title" }}{{ html .Resource.Name }} - {{ html (or .API.Title .API.Name) }}{{ end }}{{ define "main" }}{{ with .Resource }}<h1>{{ html .Name }}</h1>
+{{ if .Description }}<p>{{ html .Description }}</p>
+{{ end }}{{ range .Actions }}<section class="action" id="{{ html .Name }}">
+<h2>{{ html .Name }}</h2>
+{{ range .Routes }}<p class="route"><code>{{ html . }}</code></p>
+{{ end }}{{ if .Description }}<p>{{ html .Description }}</p>
+{{ end }}{{ if .Security }}<p>Authentication: <a href="index.html#{{ html .Security }}">{{ html .Security }}</a>{{ if .Scopes }}, scopes: {{ range $i, $s := .Scopes }}{{ if $i }}, {{ end }}<code>{{ html $s }}</code>{{ end }}{{ end }}</p>
+{{ end }}{{ if .Params }}<h3>Parameters</h3>
+{{ template "attributes" .Params }}{{ end }}{{ if .Headers }}<h3>Headers</h3>
+{{ template "attributes" .Headers }}{{ end }}{{ if .Payload }}<h3>Payload</h3>
+<p><code>{{ html .Payload }}</code></p>
+{{ if .PayloadFields }}{{ template "attributes" .PayloadFields }}{{ end }}{{ end }}{{ if .Responses }}<h3>Responses</h3>
+{{ range .Responses }}<h4>{{ .Status }} {{ html .Name }}</h4>
+{{ if .Description }}<p>{{ html .Description }}</p>
+{{ end }}{{ if .MediaType }}<p>Media type: <code>{{ html .MediaType }}</code></p>
+{{ end }}{{ if .Fields }}{{ template "attributes" .Fields }}{{ end }}{{ end }}{{ end }}{{ if .Curl }}<h3>Example</h3>
+<pre>{{ html .Curl }}</pre>
+{{ end }}</section>
+{{ end }}{{ end }}{{ end }}`
+
+// mdLayoutT defines the templates shared by the Markdown pages.
+const mdLayoutT = `{{ define "attributes" }}| Name |{{ if (index . 0).In }} In |{{ end }} Type | Description | Validations |
+| --- |{{ if (index . 0).In }} --- |{{ end }} --- | --- | --- |
+{{ range . }}| ` + "`{{ .Name }}`" + `{{ if .Required }} (required){{ end }} |{{ if .In }} {{ .In }} |{{ end }} {{ cell .Type }} | {{ cell .Description }} | {{ range $i, $v := .Validations }}{{ if $i }}<br>{{ end }}{{ cell $v }}{{ end }} |
+{{ end }}{{ end }}`
+
+const mdIndexT = `# {{ or .API.Title .API.Name }}
+{{ if .API.Version }}
+Version {{ .API.Version }}
+{{ end }}{{ if .API.Description }}
+{{ .API.Description }}
+{{ end }}
+Base URL: ` + "`{{ .BaseURL }}{{ .API.BasePath }}`" + `
+{{ if .Schemes }}
+## Authentication
+{{ range .Schemes }}
+### {{ .Name }}
+{{ if .Description }}
+{{ .Description }}
+{{ end }}
+{{ if eq .Kind "basic" }}Send the user name and password using HTTP basic authentication.
+{{ else if eq .Kind "apiKey" }}Send the API key in the ` + "`{{ .Param }}`" + ` {{ if eq .In "query" }}query string parameter{{ else }}header{{ end }}.
+{{ else if eq .Kind "jwt" }}Send the JSON Web Token in the ` + "`Authorization`" + ` header using the Bearer scheme.{{ if .TokenURL }} Tokens are issued by ` + "`{{ .TokenURL }}`" + `.{{ end }}
+{{ else if eq .Kind "oauth2" }}Obtain an access token using the OAuth2 ` + "`{{ .Flow }}`" + ` flow and send it in the ` + "`Authorization`" + ` header using the Bearer scheme.
+{{ if .AuthorizationURL }}
+* Authorization URL: ` + "`{{ .AuthorizationURL }}`" + `{{ end }}{{ if .TokenURL }}
+* Token URL: ` + "`{{ .TokenURL }}`" + `{{ end }}
+{{ end }}{{ if .Scopes }}
+| Scope | Description |
+| --- | --- |
+{{ range $name, $desc := .Scopes }}| ` + "`{{ $name }}`" + ` | {{ cell $desc }} |
+{{ end }}{{ end }}{{ end }}{{ end }}
+## Resources
+{{ range .Resources }}
+* [{{ .Name }}]({{ .File }}){{ if .Description }}: {{ .Description }}{{ end }}{{ end }}
+`
+
+const mdResourceT = `{{ with .Resource }}# {{ .Name }}
+{{ if .Description }}
+{{ .Description }}
+{{ end }}{{ range .Actions }}
+## {{ .Name }}
+{{ range .Routes }}
+` + "`{{ . }}`" + `
+{{ end }}{{ if .Description }}
+{{ .Description }}
+{{ end }}{{ if .Security }}
+Authentication: [{{ .Security }}](README.md#{{ .Security }}){{ if .Scopes }}, scopes: {{ range $i, $s := .Scopes }}{{ if $i }}, {{ end }}` + "`{{ $s }}`" + `{{ end }}{{ end }}
+{{ end }}{{ if .Params }}
+### Parameters
+
+{{ template "attributes" .Params }}{{ end }}{{ if .Headers }}
+### Headers
+
+{{ template "attributes" .Headers }}{{ end }}{{ if .Payload }}
+### Payload
+
+` + "`{{ .Payload }}`" + `
+{{ if .PayloadFields }}
+{{ template "attributes" .PayloadFields }}{{ end }}{{ end }}{{ $errors := false }}{{ range .Responses }}{{ if ge .Status 400 }}{{ $errors = true }}{{ end }}{{ end }}{{ if .Responses }}
+### Responses
+{{ range .Responses }}{{ if lt .Status 400 }}
+#### {{ .Status }} {{ .Name }}
+{{ if .Description }}
+{{ .Description }}
+{{ end }}{{ if .MediaType }}
+Media type: ` + "`{{ .MediaType }}`" + `
+{{ end }}{{ if .Fields }}
+{{ template "attributes" .Fields }}{{ end }}{{ end }}{{ end }}{{ end }}{{ if $errors }}
+### Errors
+
+| Status | Name | Description |
+| --- | --- | --- |
+{{ range .Responses }}{{ if ge .Status 400 }}| {{ .Status }} | {{ .Name }} | {{ cell .Description }} |
+{{ end }}{{ end }}{{ end }}{{ if .Curl }}
+### Example
+
+` + "```" + `sh
+{{ .Curl }}
+` + "```" + `
+{{ end }}{{ end }}{{ end }}`
@@ -0,0 +1,155 @@
+package gendocs_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_docs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	const testgenPackagePath = "github.com/goadesign/goa/goagen/gen_docs/test_"
+
+	var outDir string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		gopath := filepath.SplitList(os.Getenv("GOPATH"))[0]
+		outDir = filepath.Join(gopath, "src", testgenPackagePath)
+		err := os.MkdirAll(outDir, 0777)
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"goagen", "docs", "--out=" + outDir, "--design=foo", "--host=api.example.com"}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gendocs.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	Context("with an action", func() {
+		BeforeEach(func() {
+			jwt := &design.SecuritySchemeDefinition{
+				Kind:       design.JWTSecurityKind,
+				SchemeName: "jwt",
+				TokenURL:   "https://auth.example.com/token",
+				Scopes:     map[string]string{"bottle:write": "Update bottles"},
+			}
+			min := 1.0
+			payload := &design.UserTypeDefinition{
+				TypeName: "UpdateBottlePayload",
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"name": {Type: design.String, Description: "Name of <bottle>", Example: "Merlot"},
+						"vintage": {
+							Type:       design.Integer,
+							Validation: &dslengine.ValidationDefinition{Minimum: &min},
+							Example:    2015,
+						},
+						"winery": {Type: design.Object{
+							"country": {
+								Type:       design.String,
+								Validation: &dslengine.ValidationDefinition{Values: []interface{}{"FR", "US"}},
+								Example:    "FR",
+							},
+						}},
+					},
+					Validation: &dslengine.ValidationDefinition{Required: []string{"name"}},
+				},
+			}
+			action := &design.ActionDefinition{
+				Name:        "update",
+				Description: "Update a bottle",
+				Payload:     payload,
+				Routes: []*design.RouteDefinition{{
+					Verb: "PUT",
+					Path: "/bottles/:id",
+				}},
+				Params: &design.AttributeDefinition{
+					Type: design.Object{
+						"id": {Type: design.Integer, Example: 42},
+					},
+				},
+				Responses: map[string]*design.ResponseDefinition{
+					"NoContent": {Name: "NoContent", Status: 204},
+					"NotFound":  {Name: "NotFound", Status: 404, Description: "Bottle not found"},
+				},
+				Security: &design.SecurityDefinition{Scheme: jwt, Scopes: []string{"bottle:write"}},
+			}
+			design.Design = &design.APIDefinition{
+				Name:            "testapi",
+				Title:           "Cellar",
+				SecuritySchemes: []*design.SecuritySchemeDefinition{jwt},
+				Resources: map[string]*design.ResourceDefinition{
+					"bottle": {
+						Name:    "bottle",
+						Actions: map[string]*design.ActionDefinition{"update": action},
+					},
+				},
+			}
+			action.Parent = design.Design.Resources["bottle"]
+			action.Routes[0].Parent = action
+		})
+
+		It("generates the index page", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(ContainElement(filepath.Join(outDir, "docs", "index.html")))
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "docs", "index.html"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("<h1>Cellar</h1>"))
+			Ω(content).Should(ContainSubstring(`<h3 id="jwt">jwt</h3>`))
+			Ω(content).Should(ContainSubstring("Tokens are issued by <code>https://auth.example.com/token</code>."))
+			Ω(content).Should(ContainSubstring(`<li><a href="bottle.html">bottle</a></li>`))
+		})
+
+		It("generates the resource page", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "docs", "bottle.html"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`<p class="route"><code>PUT /bottles/:id</code></p>`))
+			Ω(content).Should(ContainSubstring(`Authentication: <a href="index.html#jwt">jwt</a>, scopes: <code>bottle:write</code>`))
+			Ω(content).Should(ContainSubstring("<tr><td><code>id</code></td><td>path</td><td>integer</td>"))
+			Ω(content).Should(ContainSubstring(`<tr><td><code>name</code> <span class="required">required</span></td><td>string</td><td>Name of &lt;bottle&gt;</td>`))
+			Ω(content).Should(ContainSubstring("<td>minimum: 1</td>"))
+			Ω(content).Should(ContainSubstring(`<tr><td><code>winery.country</code></td><td>string</td><td></td><td>one of: &#34;FR&#34;, &#34;US&#34;</td></tr>`))
+			Ω(content).Should(ContainSubstring("<h4>404 NotFound</h4>\n<p>Bottle not found</p>"))
+		})
+
+		It("generates the curl example", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "docs", "bottle.html"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`<pre>curl -X PUT -H &#39;Authorization: Bearer $TOKEN&#39; -H &#39;Content-Type: application/json&#39; -d &#39;{&#34;name&#34;:&#34;Merlot&#34;,&#34;vintage&#34;:2015,&#34;winery&#34;:{&#34;country&#34;:&#34;FR&#34;}}&#39; &#39;http://api.example.com/bottles/42&#39;</pre>`))
+		})
+
+		Context("with the markdown format", func() {
+			BeforeEach(func() {
+				os.Args = append(os.Args, "--format=markdown")
+			})
+
+			It("generates the Markdown reference", func() {
+				Ω(genErr).Should(BeNil())
+				Ω(files).Should(ContainElement(filepath.Join(outDir, "docs", "README.md")))
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "docs", "README.md"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring("# Cellar\n"))
+				Ω(content).Should(ContainSubstring("* [bottle](bottle.md)"))
+				content, err = ioutil.ReadFile(filepath.Join(outDir, "docs", "bottle.md"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring("## update\n\n`PUT /bottles/:id`\n"))
+				Ω(content).Should(ContainSubstring("| `id` | path | integer |  |  |"))
+				Ω(content).Should(ContainSubstring("| `winery.country` | string |  | one of: \"FR\", \"US\" |"))
+				Ω(content).Should(ContainSubstring("### Errors\n\n| Status | Name | Description |\n| --- | --- | --- |\n| 404 | NotFound | Bottle not found |"))
+				Ω(content).Should(ContainSubstring("```sh\ncurl -X PUT"))
+			})
+		})
+	})
+})
@@ -0,0 +1,8 @@
+/*
+Package genexport provides a generator that exports the fully evaluated API design as a JSON
+document. The document describes the resources, actions, types, media types, validations and
+metadata of the design so that external tools such as linters, documentation portals or diff
+tools can consume the design without having to run Go code.
+The document format is versioned via the "format_version" field, see FormatVersion.
+*/
+package genexport
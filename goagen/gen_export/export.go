@@ -0,0 +1,425 @@
+package genexport
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// FormatVersion is the version of the export document format. It changes only when the format
+// changes in a backwards incompatible way.
+const FormatVersion = "1"
+
+type (
+	// Document is the design export document. All lists are sorted so that exporting the same
+	// design twice produces identical documents.
+	Document struct {
+		FormatVersion string `json:"format_version"`
+		API           *API   `json:"api"`
+	}
+
+	// API is the exported API definition.
+	API struct {
+		Name            string                       `json:"name"`
+		Title           string                       `json:"title,omitempty"`
+		Description     string                       `json:"description,omitempty"`
+		Version         string                       `json:"version,omitempty"`
+		Host            string                       `json:"host,omitempty"`
+		Schemes         []string                     `json:"schemes,omitempty"`
+		BasePath        string                       `json:"base_path,omitempty"`
+		BaseParams      *Attribute                   `json:"base_params,omitempty"`
+		Consumes        []*Encoding                  `json:"consumes,omitempty"`
+		Produces        []*Encoding                  `json:"produces,omitempty"`
+		Security        *Security                    `json:"security,omitempty"`
+		SecuritySchemes []*SecurityScheme            `json:"security_schemes,omitempty"`
+		Resources       []*Resource                  `json:"resources,omitempty"`
+		Types           []*UserType                  `json:"types,omitempty"`
+		MediaTypes      []*MediaType                 `json:"media_types,omitempty"`
+		Metadata        dslengine.MetadataDefinition `json:"metadata,omitempty"`
+	}
+
+	// Resource is an exported resource definition.
+	Resource struct {
+		Name            string                       `json:"name"`
+		Description     string                       `json:"description,omitempty"`
+		Parent          string                       `json:"parent,omitempty"`
+		BasePath        string                       `json:"base_path,omitempty"`
+		FullPath        string                       `json:"full_path"`
+		Schemes         []string                     `json:"schemes,omitempty"`
+		MediaType       string                       `json:"media_type,omitempty"`
+		CanonicalAction string                       `json:"canonical_action,omitempty"`
+		Params          *Attribute                   `json:"params,omitempty"`
+		Headers         *Attribute                   `json:"headers,omitempty"`
+		Security        *Security                    `json:"security,omitempty"`
+		Actions         []*Action                    `json:"actions,omitempty"`
+		FileServers     []*FileServer                `json:"file_servers,omitempty"`
+		Metadata        dslengine.MetadataDefinition `json:"metadata,omitempty"`
+	}
+
+	// Action is an exported action definition.
+	Action struct {
+		Name            string                       `json:"name"`
+		Description     string                       `json:"description,omitempty"`
+		Schemes         []string                     `json:"schemes,omitempty"`
+		Routes          []*Route                     `json:"routes"`
+		Params          *Attribute                   `json:"params,omitempty"`
+		Headers         *Attribute                   `json:"headers,omitempty"`
+		Payload         string                       `json:"payload,omitempty"`
+		PayloadOptional bool                         `json:"payload_optional,omitempty"`
+		Responses       []*Response                  `json:"responses,omitempty"`
+		Security        *Security                    `json:"security,omitempty"`
+		Metadata        dslengine.MetadataDefinition `json:"metadata,omitempty"`
+	}
+
+	// Route is an exported action route.
+	Route struct {
+		Verb     string `json:"verb"`
+		Path     string `json:"path"`
+		FullPath string `json:"full_path"`
+	}
+
+	// Response is an exported action response definition.
+	Response struct {
+		Name        string                       `json:"name"`
+		Status      int                          `json:"status"`
+		Description string                       `json:"description,omitempty"`
+		MediaType   string                       `json:"media_type,omitempty"`
+		Type        string                       `json:"type,omitempty"`
+		Headers     *Attribute                   `json:"headers,omitempty"`
+		Metadata    dslengine.MetadataDefinition `json:"metadata,omitempty"`
+	}
+
+	// FileServer is an exported file server definition.
+	FileServer struct {
+		RequestPath string    `json:"request_path"`
+		FilePath    string    `json:"file_path"`
+		Description string    `json:"description,omitempty"`
+		Security    *Security `json:"security,omitempty"`
+	}
+
+	// Attribute is an exported attribute definition. Attributes whose type is a user type or a
+	// media type only reference the type by name in Ref, the type itself is exported once in
+	// the API Types or MediaTypes.
+	Attribute struct {
+		Type        string                       `json:"type"`
+		Ref         string                       `json:"ref,omitempty"`
+		Description string                       `json:"description,omitempty"`
+		Default     interface{}                  `json:"default,omitempty"`
+		Validation  *Validation                  `json:"validation,omitempty"`
+		Attributes  map[string]*Attribute        `json:"attributes,omitempty"`
+		Key         *Attribute                   `json:"key,omitempty"`
+		Elem        *Attribute                   `json:"elem,omitempty"`
+		View        string                       `json:"view,omitempty"`
+		Metadata    dslengine.MetadataDefinition `json:"metadata,omitempty"`
+	}
+
+	// Validation lists the validations that apply to an attribute.
+	Validation struct {
+		Enum      []interface{} `json:"enum,omitempty"`
+		Format    string        `json:"format,omitempty"`
+		Pattern   string        `json:"pattern,omitempty"`
+		Minimum   *float64      `json:"minimum,omitempty"`
+		Maximum   *float64      `json:"maximum,omitempty"`
+		MinLength *int          `json:"min_length,omitempty"`
+		MaxLength *int          `json:"max_length,omitempty"`
+		Required  []string      `json:"required,omitempty"`
+	}
+
+	// UserType is an exported user type definition.
+	UserType struct {
+		Name      string     `json:"name"`
+		Attribute *Attribute `json:"attribute"`
+	}
+
+	// MediaType is an exported media type definition.
+	MediaType struct {
+		Identifier string     `json:"identifier"`
+		Name       string     `json:"name"`
+		Attribute  *Attribute `json:"attribute"`
+		Views      []*View    `json:"views,omitempty"`
+		Links      []*Link    `json:"links,omitempty"`
+	}
+
+	// View is an exported media type view, it lists the names of the rendered attributes.
+	View struct {
+		Name       string   `json:"name"`
+		Attributes []string `json:"attributes"`
+	}
+
+	// Link is an exported media type link.
+	Link struct {
+		Name        string `json:"name"`
+		View        string `json:"view,omitempty"`
+		URITemplate string `json:"uri_template,omitempty"`
+	}
+
+	// Encoding is an exported encoding definition.
+	Encoding struct {
+		MIMETypes   []string `json:"mime_types"`
+		PackagePath string   `json:"package_path,omitempty"`
+		Function    string   `json:"function,omitempty"`
+	}
+
+	// Security is an exported security requirement.
+	Security struct {
+		Scheme string   `json:"scheme"`
+		Scopes []string `json:"scopes,omitempty"`
+	}
+
+	// SecurityScheme is an exported security scheme definition.
+	SecurityScheme struct {
+		Name             string            `json:"name"`
+		Type             string            `json:"type"`
+		Description      string            `json:"description,omitempty"`
+		In               string            `json:"in,omitempty"`
+		ParamName        string            `json:"param_name,omitempty"`
+		Scopes           map[string]string `json:"scopes,omitempty"`
+		Flow             string            `json:"flow,omitempty"`
+		TokenURL         string            `json:"token_url,omitempty"`
+		AuthorizationURL string            `json:"authorization_url,omitempty"`
+	}
+)
+
+// Export builds the export document for the given API definition.
+func Export(api *design.APIDefinition) *Document {
+	a := &API{
+		Name:        api.Name,
+		Title:       api.Title,
+		Description: api.Description,
+		Version:     api.Version,
+		Host:        api.Host,
+		Schemes:     api.Schemes,
+		BasePath:    api.BasePath,
+		BaseParams:  exportAttribute(api.BaseParams),
+		Consumes:    exportEncodings(api.Consumes),
+		Produces:    exportEncodings(api.Produces),
+		Security:    exportSecurity(api.Security),
+		Metadata:    api.Metadata,
+	}
+	for _, s := range api.SecuritySchemes {
+		a.SecuritySchemes = append(a.SecuritySchemes, &SecurityScheme{
+			Name:             s.SchemeName,
+			Type:             s.Type,
+			Description:      s.Description,
+			In:               s.In,
+			ParamName:        s.Name,
+			Scopes:           s.Scopes,
+			Flow:             s.Flow,
+			TokenURL:         s.TokenURL,
+			AuthorizationURL: s.AuthorizationURL,
+		})
+	}
+	sort.Sort(bySchemeName(a.SecuritySchemes))
+	api.IterateResources(func(r *design.ResourceDefinition) error {
+		a.Resources = append(a.Resources, exportResource(r))
+		return nil
+	})
+	api.IterateUserTypes(func(u *design.UserTypeDefinition) error {
+		a.Types = append(a.Types, &UserType{Name: u.TypeName, Attribute: exportAttribute(u.AttributeDefinition)})
+		return nil
+	})
+	api.IterateMediaTypes(func(m *design.MediaTypeDefinition) error {
+		a.MediaTypes = append(a.MediaTypes, exportMediaType(m))
+		return nil
+	})
+	return &Document{FormatVersion: FormatVersion, API: a}
+}
+
+// JSON serializes the document into indented JSON.
+func (d *Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// exportResource builds the export of a resource definition.
+func exportResource(r *design.ResourceDefinition) *Resource {
+	res := &Resource{
+		Name:            r.Name,
+		Description:     r.Description,
+		Parent:          r.ParentName,
+		BasePath:        r.BasePath,
+		FullPath:        r.FullPath(),
+		Schemes:         r.Schemes,
+		MediaType:       r.MediaType,
+		CanonicalAction: r.CanonicalActionName,
+		Params:          exportAttribute(r.Params),
+		Headers:         exportAttribute(r.Headers),
+		Security:        exportSecurity(r.Security),
+		Metadata:        r.Metadata,
+	}
+	r.IterateActions(func(a *design.ActionDefinition) error {
+		res.Actions = append(res.Actions, exportAction(a))
+		return nil
+	})
+	r.IterateFileServers(func(fs *design.FileServerDefinition) error {
+		res.FileServers = append(res.FileServers, &FileServer{
+			RequestPath: fs.RequestPath,
+			FilePath:    fs.FilePath,
+			Description: fs.Description,
+			Security:    exportSecurity(fs.Security),
+		})
+		return nil
+	})
+	return res
+}
+
+// exportAction builds the export of an action definition.
+func exportAction(a *design.ActionDefinition) *Action {
+	act := &Action{
+		Name:            a.Name,
+		Description:     a.Description,
+		Schemes:         a.Schemes,
+		Params:          exportAttribute(a.Params),
+		Headers:         exportAttribute(a.Headers),
+		PayloadOptional: a.PayloadOptional,
+		Security:        exportSecurity(a.Security),
+		Metadata:        a.Metadata,
+	}
+	if a.Payload != nil {
+		act.Payload = a.Payload.TypeName
+	}
+	for _, r := range a.Routes {
+		act.Routes = append(act.Routes, &Route{Verb: r.Verb, Path: r.Path, FullPath: r.FullPath()})
+	}
+	a.IterateResponses(func(r *design.ResponseDefinition) error {
+		act.Responses = append(act.Responses, &Response{
+			Name:        r.Name,
+			Status:      r.Status,
+			Description: r.Description,
+			MediaType:   r.MediaType,
+			Type:        typeRef(r.Type),
+			Headers:     exportAttribute(r.Headers),
+			Metadata:    r.Metadata,
+		})
+		return nil
+	})
+	return act
+}
+
+// exportMediaType builds the export of a media type definition.
+func exportMediaType(m *design.MediaTypeDefinition) *MediaType {
+	mt := &MediaType{
+		Identifier: m.Identifier,
+		Name:       m.TypeName,
+		Attribute:  exportAttribute(m.AttributeDefinition),
+	}
+	m.IterateViews(func(v *design.ViewDefinition) error {
+		view := &View{Name: v.Name, Attributes: []string{}}
+		if o := v.Type.ToObject(); o != nil {
+			for n := range o {
+				view.Attributes = append(view.Attributes, n)
+			}
+			sort.Strings(view.Attributes)
+		}
+		mt.Views = append(mt.Views, view)
+		return nil
+	})
+	names := make([]string, 0, len(m.Links))
+	for n := range m.Links {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		l := m.Links[n]
+		mt.Links = append(mt.Links, &Link{Name: l.Name, View: l.View, URITemplate: l.URITemplate})
+	}
+	return mt
+}
+
+// exportAttribute builds the export of an attribute definition. User types and media types are
+// exported as references so that recursive types do not cause infinite recursion.
+func exportAttribute(att *design.AttributeDefinition) *Attribute {
+	if att == nil || att.Type == nil {
+		return nil
+	}
+	a := &Attribute{
+		Type:        att.Type.Name(),
+		Description: att.Description,
+		Default:     att.DefaultValue,
+		Validation:  exportValidation(att.Validation),
+		View:        att.View,
+		Metadata:    att.Metadata,
+	}
+	switch actual := att.Type.(type) {
+	case *design.UserTypeDefinition, *design.MediaTypeDefinition:
+		a.Ref = typeRef(actual)
+	case design.Object:
+		a.Attributes = make(map[string]*Attribute, len(actual))
+		for n, catt := range actual {
+			a.Attributes[n] = exportAttribute(catt)
+		}
+	case *design.Array:
+		a.Elem = exportAttribute(actual.ElemType)
+	case *design.Hash:
+		a.Key = exportAttribute(actual.KeyType)
+		a.Elem = exportAttribute(actual.ElemType)
+	}
+	return a
+}
+
+// exportValidation builds the export of a validation definition.
+func exportValidation(v *dslengine.ValidationDefinition) *Validation {
+	if v == nil {
+		return nil
+	}
+	if len(v.Values) == 0 && v.Format == "" && v.Pattern == "" && v.Minimum == nil &&
+		v.Maximum == nil && v.MinLength == nil && v.MaxLength == nil && len(v.Required) == 0 {
+		return nil
+	}
+	var required []string
+	if len(v.Required) > 0 {
+		required = make([]string, len(v.Required))
+		copy(required, v.Required)
+		sort.Strings(required)
+	}
+	return &Validation{
+		Enum:      v.Values,
+		Format:    v.Format,
+		Pattern:   v.Pattern,
+		Minimum:   v.Minimum,
+		Maximum:   v.Maximum,
+		MinLength: v.MinLength,
+		MaxLength: v.MaxLength,
+		Required:  required,
+	}
+}
+
+// exportEncodings builds the export of a list of encoding definitions.
+func exportEncodings(encs []*design.EncodingDefinition) []*Encoding {
+	if len(encs) == 0 {
+		return nil
+	}
+	res := make([]*Encoding, len(encs))
+	for i, enc := range encs {
+		res[i] = &Encoding{MIMETypes: enc.MIMETypes, PackagePath: enc.PackagePath, Function: enc.Function}
+	}
+	return res
+}
+
+// exportSecurity builds the export of a security requirement.
+func exportSecurity(s *design.SecurityDefinition) *Security {
+	if s == nil || s.Scheme == nil {
+		return nil
+	}
+	return &Security{Scheme: s.Scheme.SchemeName, Scopes: s.Scopes}
+}
+
+// typeRef returns the name of the given type if it is a user type or a media type, the empty
+// string otherwise.
+func typeRef(dt design.DataType) string {
+	switch actual := dt.(type) {
+	case *design.MediaTypeDefinition:
+		return actual.TypeName
+	case *design.UserTypeDefinition:
+		return actual.TypeName
+	}
+	return ""
+}
+
+// bySchemeName sorts security schemes by name.
+type bySchemeName []*SecurityScheme
+
+func (b bySchemeName) Len() int           { return len(b) }
+func (b bySchemeName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b bySchemeName) Less(i, j int) bool { return b[i].Name < b[j].Name }
@@ -0,0 +1,13 @@
+package genexport_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenExport(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenExport Suite")
+}
@@ -0,0 +1,76 @@
+package genexport
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+)
+
+// Generator is the design export generator.
+type Generator struct {
+	genfiles []string // Generated files
+	outDir   string   // Path to output directory
+	format   string   // Export format
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, format string
+	set := flag.NewFlagSet("export", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.StringVar(&format, "format", "json", "")
+	set.String("design", "", "")
+	set.Parse(os.Args[2:])
+
+	g := &Generator{outDir: outDir, format: format}
+
+	return g.Generate(design.Design)
+}
+
+// Generate produces the design export document.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	if g.format != "json" {
+		return nil, fmt.Errorf("unsupported export format %#v, supported formats are: json", g.format)
+	}
+	js, err := Export(api).JSON()
+	if err != nil {
+		return
+	}
+
+	g.outDir = filepath.Join(g.outDir, "export")
+	render, err := codegen.BeginRender(g.outDir)
+	if err != nil {
+		return
+	}
+	defer render.Discard()
+	os.MkdirAll(g.outDir, 0755)
+	g.genfiles = append(g.genfiles, g.outDir)
+	exportFile := filepath.Join(g.outDir, "design.json")
+	if err = codegen.WriteFile(exportFile, append(js, '\n'), 0644); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, exportFile)
+
+	return g.genfiles, render.Commit()
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
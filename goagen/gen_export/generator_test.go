@@ -0,0 +1,164 @@
+package genexport_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_export"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var format string
+
+	BeforeEach(func() {
+		var err error
+		format = "json"
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("exporttest")
+		Ω(err).ShouldNot(HaveOccurred())
+		dslengine.Reset()
+		API("test api", func() {
+			Title("dummy API with no resource")
+			Description("I told you it's dummy")
+		})
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		os.Args = []string{"goagen", "export", "--out=" + testPkg.Abs(), "--design=foo", "--format=" + format}
+		files, genErr = genexport.Generate()
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("with a dummy API", func() {
+		It("generates a dummy export document", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(HaveLen(2))
+			content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "export", "design.json"))
+			Ω(err).ShouldNot(HaveOccurred())
+			var doc genexport.Document
+			err = json.Unmarshal(content, &doc)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(doc.FormatVersion).Should(Equal(genexport.FormatVersion))
+			Ω(doc.API.Name).Should(Equal("test api"))
+			Ω(doc.API.Resources).Should(BeEmpty())
+		})
+	})
+
+	Context("with an unsupported format", func() {
+		BeforeEach(func() {
+			format = "yaml"
+		})
+
+		It("returns an error", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(files).Should(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Export", func() {
+	var doc *genexport.Document
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		API("test", func() {
+			Metadata("owner", "team")
+		})
+		Bottle := MediaType("application/vnd.bottle", func() {
+			TypeName("Bottle")
+			Attributes(func() {
+				Attribute("id", Integer, func() {
+					Minimum(1)
+				})
+				Attribute("name", String, func() {
+					Enum("red", "white")
+				})
+				Required("name", "id")
+			})
+			View("default", func() {
+				Attribute("name")
+				Attribute("id")
+			})
+		})
+		BottlePayload := Type("BottlePayload", func() {
+			Attribute("name", String, func() {
+				MinLength(2)
+			})
+		})
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			Action("create", func() {
+				Routing(POST(""))
+				Payload(BottlePayload)
+				Response(Created, Bottle)
+			})
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Params(func() {
+					Param("id", Integer)
+				})
+				Response(OK, Bottle)
+			})
+		})
+		dslengine.Run()
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		doc = genexport.Export(Design)
+	})
+
+	It("exports the resources and actions sorted by name", func() {
+		Ω(doc.API.Metadata).Should(HaveKeyWithValue("owner", []string{"team"}))
+		Ω(doc.API.Resources).Should(HaveLen(1))
+		res := doc.API.Resources[0]
+		Ω(res.Name).Should(Equal("bottle"))
+		Ω(res.Actions).Should(HaveLen(2))
+		Ω(res.Actions[0].Name).Should(Equal("create"))
+		Ω(res.Actions[0].Payload).Should(Equal("CreateBottlePayload"))
+		Ω(res.Actions[0].Routes[0].FullPath).Should(Equal("/bottles"))
+		Ω(res.Actions[0].Responses[0].Status).Should(Equal(201))
+		Ω(res.Actions[1].Name).Should(Equal("show"))
+		Ω(res.Actions[1].Routes[0].Verb).Should(Equal("GET"))
+		Ω(res.Actions[1].Params.Attributes).Should(HaveKey("id"))
+	})
+
+	It("exports the types and their validations", func() {
+		Ω(doc.API.Types).Should(HaveLen(1))
+		Ω(doc.API.Types[0].Name).Should(Equal("BottlePayload"))
+		name := doc.API.Types[0].Attribute.Attributes["name"]
+		Ω(name.Type).Should(Equal("string"))
+		Ω(*name.Validation.MinLength).Should(Equal(2))
+
+		Ω(doc.API.MediaTypes).Should(HaveLen(1))
+		mt := doc.API.MediaTypes[0]
+		Ω(mt.Identifier).Should(Equal("application/vnd.bottle"))
+		Ω(mt.Attribute.Validation.Required).Should(Equal([]string{"id", "name"}))
+		Ω(mt.Attribute.Attributes["name"].Validation.Enum).Should(Equal([]interface{}{"red", "white"}))
+		Ω(*mt.Attribute.Attributes["id"].Validation.Minimum).Should(Equal(1.0))
+		Ω(mt.Views).Should(HaveLen(1))
+		Ω(mt.Views[0].Attributes).Should(Equal([]string{"id", "name"}))
+	})
+
+	It("produces a stable document", func() {
+		js, err := doc.JSON()
+		Ω(err).ShouldNot(HaveOccurred())
+		js2, err := genexport.Export(Design).JSON()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(js2).Should(Equal(js))
+	})
+})
@@ -3,7 +3,6 @@ package genjs
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -73,9 +72,11 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 	}
 
 	g.outDir = filepath.Join(g.outDir, "js")
-	if err := os.RemoveAll(g.outDir); err != nil {
+	render, err := codegen.BeginRender(g.outDir)
+	if err != nil {
 		return nil, err
 	}
+	defer render.Discard()
 	if err := os.MkdirAll(g.outDir, 0755); err != nil {
 		return nil, err
 	}
@@ -104,7 +105,7 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		}
 	}
 
-	return g.genfiles, nil
+	return g.genfiles, render.Commit()
 }
 
 func (g *Generator) generateJS(jsFile string, api *design.APIDefinition) (_ *design.ActionDefinition, err error) {
@@ -147,7 +148,12 @@ func (g *Generator) generateJS(jsFile string, api *design.APIDefinition) (_ *des
 			if exampleAction == nil && a.Routes[0].Verb == "GET" {
 				exampleAction = a
 			}
-			data := map[string]interface{}{"Action": a}
+			cacheControl, cacheable := a.CacheControl()
+			data := map[string]interface{}{
+				"Action":       a,
+				"Cacheable":    cacheable && a.Routes[0].Verb == "GET",
+				"CacheControl": cacheControl,
+			}
 			funcs := template.FuncMap{"params": params}
 			if err = file.ExecuteTemplate("jsFuncs", jsFuncsT, funcs, data); err != nil {
 				return
@@ -216,7 +222,7 @@ func (g *Generator) generateIndexHTML(htmlFile string, api *design.APIDefinition
 
 func (g *Generator) generateAxiosJS() error {
 	filePath := filepath.Join(g.outDir, "axios.min.js")
-	if err := ioutil.WriteFile(filePath, []byte(axios), 0644); err != nil {
+	if err := codegen.WriteFile(filePath, []byte(axios), 0644); err != nil {
 		return err
 	}
 	g.genfiles = append(g.genfiles, filePath)
@@ -280,6 +286,24 @@ define(['axios'] , function (axios) {
     return obj3;
   }
 
+  // cacheInfo computes the cache metadata of a response from its Cache-Control and Age headers.
+  // hint is the Cache-Control header value declared in the design, it is used when the response
+  // does not include the header.
+  function cacheInfo(resp, hint) {
+    var cc = (resp.headers && resp.headers['cache-control']) || hint || '';
+    var info = { maxAge: 0, age: 0, noCache: false, noStore: false };
+    cc.split(',').forEach(function (directive) {
+      directive = directive.trim().toLowerCase();
+      if (directive === 'no-cache') { info.noCache = true; }
+      else if (directive === 'no-store') { info.noStore = true; }
+      else if (directive.indexOf('max-age=') === 0) { info.maxAge = parseInt(directive.substr(8), 10) || 0; }
+    });
+    var age = parseInt((resp.headers && resp.headers['age']) || '0', 10) || 0;
+    info.age = age + Math.floor((Date.now() - resp.receivedAt) / 1000);
+    info.fresh = !info.noCache && !info.noStore && info.age < info.maxAge;
+    return info;
+  }
+
   return function (scheme, host, timeout) {
     scheme = scheme || '{{.Scheme}}';
     host = host || '{{.Host}}';
@@ -290,6 +314,9 @@ define(['axios'] , function (axios) {
 
     // URL prefix for all API requests.
     var urlPrefix = scheme + '://' + host;
+
+    // Responses of cacheable actions indexed by request method, URL and parameters.
+    var responseCache = {};
 `
 
 const moduleTend = `  return client;
@@ -304,7 +331,9 @@ const jsFuncsT = `{{$params := params .Action}}
   {{end}}{{if $params}}// {{join $params ", "}} {{if gt (len $params) 1}}are{{else}}is{{end}} used to build the request query string.
   {{end}}// config is an optional object to be merged into the config built by the function prior to making the request.
   // The content of the config object is described here: https://github.com/mzabriskie/axios#request-api
-  // This function returns a promise which raises an error if the HTTP response is a 4xx or 5xx.
+  {{if .Cacheable}}// Set config.cache to true to get the cached response if still fresh instead of making the request.
+  // The response cache property contains the cache metadata (maxAge, age, fresh etc.).
+  {{end}}// This function returns a promise which raises an error if the HTTP response is a 4xx or 5xx.
   client.{{$name}} = function (path{{if .Action.Payload}}, data{{end}}{{if $params}}, {{join $params ", "}}{{end}}, config) {
     cfg = {
       timeout: timeout,
@@ -320,8 +349,24 @@ const jsFuncsT = `{{$params := params .Action}}
     if (config) {
       cfg = merge(cfg, config);
     }
-    return client(cfg);
-  }
+{{if .Cacheable}}    var key = cfg.method + ' ' + cfg.url + ' ' + JSON.stringify(cfg.params || {});
+    var cached = responseCache[key];
+    if (cfg.cache && cached) {
+      cached.cache = cacheInfo(cached, {{printf "%q" .CacheControl}});
+      if (cached.cache.fresh) {
+        return Promise.resolve(cached);
+      }
+    }
+    return client(cfg).then(function (resp) {
+      resp.receivedAt = Date.now();
+      resp.cache = cacheInfo(resp, {{printf "%q" .CacheControl}});
+      if (!resp.cache.noStore && resp.cache.maxAge > 0) {
+        responseCache[key] = resp;
+      }
+      return resp;
+    });
+{{else}}    return client(cfg);
+{{end}}  }
 `
 
 const exampleT = `<!doctype html>
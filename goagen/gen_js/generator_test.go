@@ -95,5 +95,30 @@ var _ = Describe("Generate", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(len(strings.Split(string(content), "\n"))).Should(BeNumerically(">=", 13))
 		})
+
+		Context("with a cacheable response", func() {
+			BeforeEach(func() {
+				action := design.Design.Resources["bottle"].Actions["show"]
+				action.Responses = map[string]*design.ResponseDefinition{
+					"ok": {
+						Name:   "ok",
+						Status: 200,
+						Headers: &design.AttributeDefinition{
+							Type: design.Object{
+								"Cache-Control": {Type: design.String, DefaultValue: "max-age=60"},
+							},
+						},
+					},
+				}
+			})
+
+			It("generates the caching logic", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "js", "client.js"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring(`resp.cache = cacheInfo(resp, "max-age=60");`))
+				Ω(content).Should(ContainSubstring("if (cfg.cache && cached) {"))
+			})
+		})
 	})
 })
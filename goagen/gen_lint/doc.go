@@ -0,0 +1,11 @@
+/*
+Package genlint provides a generator that checks the evaluated design against a set of lint rules,
+see Rules. The findings are written to a JSON report listing the rule, severity, offending
+definition and its location in the design package for each finding so that CI jobs can process
+them. The generator fails if any finding has a severity at or above the --fail-on level.
+
+The severity of each rule may be changed with --rules, for example:
+
+	goagen lint -d github.com/acme/cellar/design --rules plural-resource-name=off,unused-type=error
+*/
+package genlint
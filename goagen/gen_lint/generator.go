@@ -0,0 +1,144 @@
+package genlint
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+)
+
+// ReportFile is the name of the report written in the output directory by default.
+const ReportFile = "lint.json"
+
+// Generator is the design linter.
+type Generator struct {
+	outDir     string              // Path to output directory
+	report     string              // Path to the report file
+	severities map[string]Severity // Rule severities overrides
+	failOn     Severity            // Lowest severity that fails the lint
+}
+
+// Report is the content of the lint report.
+type Report struct {
+	// Findings lists the findings sorted by file and line.
+	Findings []*Finding `json:"findings"`
+	// Errors is the number of findings with the error severity.
+	Errors int `json:"errors"`
+	// Warnings is the number of findings with the warning severity.
+	Warnings int `json:"warnings"`
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, report, rules, failOn string
+	set := flag.NewFlagSet("lint", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.String("design", "", "")
+	set.StringVar(&report, "report", "", "")
+	set.StringVar(&rules, "rules", "", "")
+	set.StringVar(&failOn, "fail-on", string(SeverityError), "")
+	set.Parse(os.Args[2:])
+
+	g, err := NewGenerator(outDir, report, rules, failOn)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.Generate(design.Design)
+}
+
+// NewGenerator returns a linter that writes its report to the given path, ReportFile in outDir
+// if empty. rules is a comma separated list of rule severities of the form name=severity and
+// failOn the lowest severity that fails the lint, "warning", "error" or "off" to never fail.
+func NewGenerator(outDir, report, rules, failOn string) (*Generator, error) {
+	if report == "" {
+		report = filepath.Join(outDir, ReportFile)
+	}
+	sev, err := ParseSeverities(rules)
+	if err != nil {
+		return nil, err
+	}
+	switch Severity(failOn) {
+	case SeverityWarning, SeverityError, SeverityOff:
+	default:
+		return nil, fmt.Errorf("invalid --fail-on value %#v, must be one of \"warning\", \"error\" or \"off\"", failOn)
+	}
+	return &Generator{outDir: outDir, report: report, severities: sev, failOn: Severity(failOn)}, nil
+}
+
+// ParseSeverities parses a comma separated list of rule severities of the form name=severity,
+// e.g. "unused-type=error,plural-resource-name=off".
+func ParseSeverities(rules string) (map[string]Severity, error) {
+	sev := make(map[string]Severity)
+	if rules == "" {
+		return sev, nil
+	}
+	for _, r := range strings.Split(rules, ",") {
+		elems := strings.SplitN(strings.TrimSpace(r), "=", 2)
+		if len(elems) != 2 {
+			return nil, fmt.Errorf("invalid rule configuration %#v, must be of the form name=severity", r)
+		}
+		if !isRule(elems[0]) {
+			return nil, fmt.Errorf("unknown rule %#v", elems[0])
+		}
+		switch s := Severity(elems[1]); s {
+		case SeverityOff, SeverityWarning, SeverityError:
+			sev[elems[0]] = s
+		default:
+			return nil, fmt.Errorf("invalid severity %#v for rule %#v, must be one of \"off\", \"warning\" or \"error\"", elems[1], elems[0])
+		}
+	}
+	return sev, nil
+}
+
+// Generate lints the design and writes the report. It returns an error listing the failing
+// findings if any.
+func (g *Generator) Generate(api *design.APIDefinition) ([]string, error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
+	}
+	report := Report{Findings: Lint(api, g.severities)}
+	if report.Findings == nil {
+		report.Findings = []*Finding{}
+	}
+	var failed []string
+	for _, f := range report.Findings {
+		if f.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+		if g.failOn == SeverityWarning || g.failOn == SeverityError && f.Severity == SeverityError {
+			failed = append(failed, f.String())
+		}
+	}
+	b, err := json.MarshalIndent(&report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(g.report), 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(g.report, append(b, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("design lint failed with %d finding(s), see %s:\n%s", len(failed), g.report, strings.Join(failed, "\n"))
+	}
+	return []string{g.report}, nil
+}
+
+// isRule returns true if name is the name of one of the rules.
+func isRule(name string) bool {
+	for _, r := range Rules {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package genlint_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_lint"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var outDir string
+	var failOn string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		var err error
+		outDir, err = ioutil.TempDir("", "lint")
+		Ω(err).ShouldNot(HaveOccurred())
+		failOn = "error"
+		dslengine.Reset()
+		API("cellar", func() {
+			Description("The wine cellar API")
+		})
+		Resource("bottles", func() {
+			Action("show", func() {
+				Routing(GET("/bottles/:id"))
+				Response(OK)
+			})
+		})
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		os.Args = []string{"goagen", "lint", "--out=" + outDir, "--design=foo", "--fail-on=" + failOn}
+		files, genErr = genlint.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	readReport := func() *genlint.Report {
+		b, err := ioutil.ReadFile(filepath.Join(outDir, genlint.ReportFile))
+		Ω(err).ShouldNot(HaveOccurred())
+		var report genlint.Report
+		Ω(json.Unmarshal(b, &report)).ShouldNot(HaveOccurred())
+		return &report
+	}
+
+	It("writes the report and fails on errors", func() {
+		Ω(genErr).Should(HaveOccurred())
+		Ω(genErr.Error()).Should(ContainSubstring(`error: resource "bottles" action "show": GET /bottles/:id does not declare a 404 response [missing-not-found]`))
+		report := readReport()
+		Ω(report.Errors).Should(Equal(1))
+		Ω(report.Warnings).Should(Equal(2))
+		Ω(report.Findings).Should(HaveLen(3))
+	})
+
+	Context("with --fail-on=off", func() {
+		BeforeEach(func() {
+			failOn = "off"
+		})
+
+		It("returns the report", func() {
+			Ω(genErr).ShouldNot(HaveOccurred())
+			Ω(files).Should(Equal([]string{filepath.Join(outDir, genlint.ReportFile)}))
+			Ω(readReport().Findings).Should(HaveLen(3))
+		})
+	})
+})
@@ -0,0 +1,368 @@
+package genlint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+// Severity levels of the findings.
+const (
+	// SeverityOff disables a rule.
+	SeverityOff Severity = "off"
+	// SeverityWarning reports the findings of a rule without failing the lint.
+	SeverityWarning Severity = "warning"
+	// SeverityError reports the findings of a rule and fails the lint.
+	SeverityError Severity = "error"
+)
+
+type (
+	// Severity is the severity of the findings of a rule.
+	Severity string
+
+	// Rule is a lint rule checked against the evaluated design.
+	Rule struct {
+		// Name identifies the rule in the configuration and in the findings.
+		Name string
+		// Description describes what the rule checks.
+		Description string
+		// Severity is the default severity of the rule findings.
+		Severity Severity
+		// Check reports the problems found in the design.
+		Check func(api *design.APIDefinition, report Reporter)
+	}
+
+	// Reporter is the function called by the rules to report a problem with a definition.
+	Reporter func(def dslengine.Definition, format string, args ...interface{})
+
+	// Finding is a problem reported by a rule.
+	Finding struct {
+		// Rule is the name of the rule that reported the finding.
+		Rule string `json:"rule"`
+		// Severity is the severity of the finding.
+		Severity Severity `json:"severity"`
+		// Definition describes the offending definition, e.g. `resource "bottle"`.
+		Definition string `json:"definition"`
+		// File is the path to the design file that defines the offending definition if known.
+		File string `json:"file,omitempty"`
+		// Line is the line of the offending definition in File if known.
+		Line int `json:"line,omitempty"`
+		// Message describes the problem.
+		Message string `json:"message"`
+	}
+)
+
+// Rules lists the lint rules in the order they run.
+var Rules = []*Rule{
+	{
+		Name:        "missing-description",
+		Description: "the API, resources, actions, types and media types have a description",
+		Severity:    SeverityWarning,
+		Check:       checkDescriptions,
+	},
+	{
+		Name:        "plural-resource-name",
+		Description: "the resource names are plural",
+		Severity:    SeverityWarning,
+		Check:       checkPluralNames,
+	},
+	{
+		Name:        "missing-not-found",
+		Description: "the GET actions that identify a resource with a path parameter declare a 404 response",
+		Severity:    SeverityError,
+		Check:       checkNotFound,
+	},
+	{
+		Name:        "unused-type",
+		Description: "the types and media types are used by an action or by another type",
+		Severity:    SeverityWarning,
+		Check:       checkUnusedTypes,
+	},
+	{
+		Name:        "inconsistent-casing",
+		Description: "the attribute and parameter names all use the casing used by most of them, snake_case or camelCase",
+		Severity:    SeverityError,
+		Check:       checkCasing,
+	},
+}
+
+// Lint runs the rules against the design and returns the findings sorted by file and line.
+// severities overrides the default severity of the rules indexed by name, the rules whose
+// severity is SeverityOff do not run.
+func Lint(api *design.APIDefinition, severities map[string]Severity) []*Finding {
+	var findings []*Finding
+	for _, r := range Rules {
+		sev := r.Severity
+		if s, ok := severities[r.Name]; ok {
+			sev = s
+		}
+		if sev == SeverityOff {
+			continue
+		}
+		r.Check(api, func(def dslengine.Definition, format string, args ...interface{}) {
+			file, line := dslengine.Location(def)
+			findings = append(findings, &Finding{
+				Rule:       r.Name,
+				Severity:   sev,
+				Definition: def.Context(),
+				File:       file,
+				Line:       line,
+				Message:    fmt.Sprintf(format, args...),
+			})
+		})
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// String returns the finding formatted as "file:line: severity: definition: message [rule]".
+func (f *Finding) String() string {
+	var loc string
+	if f.File != "" {
+		loc = fmt.Sprintf("%s:%d: ", f.File, f.Line)
+	}
+	return fmt.Sprintf("%s%s: %s: %s [%s]", loc, f.Severity, f.Definition, f.Message, f.Rule)
+}
+
+// checkDescriptions reports the definitions that have no description.
+func checkDescriptions(api *design.APIDefinition, report Reporter) {
+	if api.Description == "" {
+		report(api, "missing description")
+	}
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		if res.Description == "" {
+			report(res, "missing description")
+		}
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			if a.Description == "" {
+				report(a, "missing description")
+			}
+			return nil
+		})
+	})
+	api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		if ut.Description == "" {
+			report(ut, "missing description")
+		}
+		return nil
+	})
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if !isBuiltIn(mt) && mt.Description == "" {
+			report(mt, "missing description")
+		}
+		return nil
+	})
+}
+
+// uncountable lists the words that are both singular and plural.
+var uncountable = map[string]bool{
+	"data": true, "feedback": true, "health": true, "info": true, "information": true,
+	"media": true, "metadata": true, "news": true, "series": true, "software": true,
+}
+
+// checkPluralNames reports the resources whose name is not plural, only the last word of the name
+// is checked.
+func checkPluralNames(api *design.APIDefinition, report Reporter) {
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		words := strings.Split(codegen.SnakeCase(res.Name), "_")
+		last := words[len(words)-1]
+		if !strings.HasSuffix(last, "s") && !uncountable[last] && last != "people" && last != "children" {
+			report(res, "resource name %q is not plural", res.Name)
+		}
+		return nil
+	})
+}
+
+// checkNotFound reports the GET actions whose route ends with a path parameter and that have no
+// 404 response.
+func checkNotFound(api *design.APIDefinition, report Reporter) {
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			for _, r := range a.Responses {
+				if r.Status == 404 {
+					return nil
+				}
+			}
+			for _, r := range a.Routes {
+				wcs := design.WildcardRegex.FindAllStringIndex(r.FullPath(), -1)
+				if r.Verb == "GET" && len(wcs) > 0 && wcs[len(wcs)-1][1] == len(r.FullPath()) {
+					report(a, "GET %s does not declare a 404 response", r.FullPath())
+					return nil
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// checkUnusedTypes reports the user types and media types that are not used by any action, any
+// resource or any other type used by an action or a resource.
+func checkUnusedTypes(api *design.APIDefinition, report Reporter) {
+	used := make(map[string]bool)
+	use := func(att *design.AttributeDefinition) {
+		if att == nil || att.Type == nil {
+			return
+		}
+		att.Walk(func(a *design.AttributeDefinition) error {
+			switch actual := a.Type.(type) {
+			case *design.UserTypeDefinition:
+				used[actual.TypeName] = true
+			case *design.MediaTypeDefinition:
+				used[actual.TypeName] = true
+			}
+			return nil
+		})
+	}
+	useType := func(dt design.DataType) {
+		if dt != nil {
+			use(&design.AttributeDefinition{Type: dt})
+		}
+	}
+	useIdentifier := func(id string) {
+		if mt := api.MediaTypeWithIdentifier(id); mt != nil {
+			useType(mt)
+		}
+	}
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		useIdentifier(res.MediaType)
+		use(res.BaseParams)
+		use(res.Params)
+		use(res.Headers)
+		for _, r := range res.Responses {
+			useType(r.Type)
+			useIdentifier(r.MediaType)
+		}
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			use(a.Params)
+			use(a.Headers)
+			if a.Payload != nil {
+				useType(a.Payload)
+			}
+			for _, r := range a.Responses {
+				useType(r.Type)
+				useIdentifier(r.MediaType)
+			}
+			return nil
+		})
+	})
+	api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		if !used[ut.TypeName] {
+			report(ut, "type %q is not used", ut.TypeName)
+		}
+		return nil
+	})
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if !isBuiltIn(mt) && !used[mt.TypeName] {
+			report(mt, "media type %q is not used", mt.Identifier)
+		}
+		return nil
+	})
+}
+
+// Casing styles of the attribute names.
+const (
+	snakeCase = "snake_case"
+	camelCase = "camelCase"
+)
+
+// checkCasing reports the attribute and parameter names whose casing differs from the casing used
+// by most names. Names made of a single lower case word are compatible with both styles.
+func checkCasing(api *design.APIDefinition, report Reporter) {
+	type name struct {
+		def   dslengine.Definition
+		name  string
+		style string
+	}
+	var names []*name
+	seen := make(map[*design.AttributeDefinition]bool)
+	collect := func(def dslengine.Definition, att *design.AttributeDefinition) {
+		if att == nil || att.Type == nil {
+			return
+		}
+		att.Walk(func(a *design.AttributeDefinition) error {
+			if seen[a] {
+				return nil
+			}
+			seen[a] = true
+			obj, ok := a.Type.(design.Object)
+			if !ok {
+				return nil
+			}
+			for n := range obj {
+				names = append(names, &name{def: def, name: n, style: casing(n)})
+			}
+			return nil
+		})
+	}
+	// Collect the type attributes first so that they are reported against the types rather
+	// than against the actions that use them.
+	api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		collect(ut, ut.AttributeDefinition)
+		return nil
+	})
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if !isBuiltIn(mt) {
+			collect(mt, mt.AttributeDefinition)
+		}
+		return nil
+	})
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			collect(a, a.Params)
+			return nil
+		})
+	})
+
+	counts := make(map[string]int)
+	for _, n := range names {
+		counts[n.style]++
+	}
+	expected := snakeCase
+	if counts[camelCase] > counts[snakeCase] {
+		expected = camelCase
+	}
+	sort.SliceStable(names, func(i, j int) bool { return names[i].name < names[j].name })
+	for _, n := range names {
+		if n.style != "" && n.style != expected {
+			report(n.def, "name %q is not %s", n.name, expected)
+		}
+	}
+}
+
+// casing returns the casing style of name: snakeCase, camelCase, "other" for other styles such as
+// PascalCase or kebab-case or the empty string for single lower case words.
+func casing(name string) string {
+	hasUpper := strings.IndexFunc(name, unicode.IsUpper) >= 0
+	switch {
+	case strings.ContainsAny(name, "- ") || len(name) > 0 && unicode.IsUpper(rune(name[0])):
+		return "other"
+	case strings.Contains(name, "_"):
+		if hasUpper {
+			return "other"
+		}
+		return snakeCase
+	case hasUpper:
+		return camelCase
+	default:
+		return ""
+	}
+}
+
+// isBuiltIn returns true if mt is not declared explicitly by the design: the error and maintenance
+// media types and the media types generated by CollectionOf.
+func isBuiltIn(mt *design.MediaTypeDefinition) bool {
+	if mt.Identifier == design.ErrorMediaIdentifier || mt.Identifier == design.MaintenanceMediaIdentifier {
+		return true
+	}
+	return design.GeneratedMediaTypes[design.CanonicalIdentifier(mt.Identifier)] == mt
+}
@@ -0,0 +1,156 @@
+package genlint_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_lint"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lint", func() {
+	var severities map[string]genlint.Severity
+	var findings []*genlint.Finding
+
+	// findingsOf returns the messages of the findings reported by the given rule.
+	findingsOf := func(rule string) []string {
+		var msgs []string
+		for _, f := range findings {
+			if f.Rule == rule {
+				msgs = append(msgs, f.Definition+": "+f.Message)
+			}
+		}
+		return msgs
+	}
+
+	BeforeEach(func() {
+		severities = nil
+		dslengine.Reset()
+		API("cellar", func() {
+			Description("The wine cellar API")
+		})
+		var Winery = Type("Winery", func() {
+			Description("A winery")
+			Attribute("name", String)
+			Attribute("countryCode", String)
+		})
+		Type("Unused", func() {
+			Description("An unused type")
+			Attribute("first_name", String)
+		})
+		var Bottle = MediaType("application/vnd.bottle", func() {
+			Description("A bottle of wine")
+			Attributes(func() {
+				Attribute("id", Integer)
+				Attribute("winery", Winery)
+				Attribute("vintage_year", Integer)
+			})
+			View("default", func() {
+				Attribute("id")
+				Attribute("winery")
+				Attribute("vintage_year")
+			})
+		})
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			Action("show", func() {
+				Routing(GET("/:bottleID"))
+				Params(func() { Param("bottleID", Integer) })
+				Response(OK, Bottle)
+			})
+			Action("list", func() {
+				Description("List the bottles")
+				Routing(GET(""))
+				Response(OK, CollectionOf(Bottle))
+			})
+		})
+		Resource("wineries", func() {
+			Description("The wineries")
+			Action("show", func() {
+				Description("Show a winery")
+				Routing(GET("/wineries/:id"))
+				Response(OK)
+				Response(NotFound)
+			})
+		})
+	})
+
+	JustBeforeEach(func() {
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		findings = genlint.Lint(Design, severities)
+	})
+
+	It("reports the definitions without description", func() {
+		Ω(findingsOf("missing-description")).Should(ConsistOf(
+			`resource "bottle": missing description`,
+			`resource "bottle" action "show": missing description`,
+		))
+	})
+
+	It("reports the resources whose name is not plural", func() {
+		Ω(findingsOf("plural-resource-name")).Should(ConsistOf(`resource "bottle": resource name "bottle" is not plural`))
+	})
+
+	It("reports the GET by id actions without a 404 response", func() {
+		Ω(findingsOf("missing-not-found")).Should(ConsistOf(`resource "bottle" action "show": GET /bottles/:bottleID does not declare a 404 response`))
+	})
+
+	It("reports the unused types", func() {
+		Ω(findingsOf("unused-type")).Should(ConsistOf(`type "Unused": type "Unused" is not used`))
+	})
+
+	It("reports the names that do not use the most common casing", func() {
+		Ω(findingsOf("inconsistent-casing")).Should(ConsistOf(
+			`type "Winery": name "countryCode" is not snake_case`,
+			`resource "bottle" action "show": name "bottleID" is not snake_case`,
+		))
+	})
+
+	It("records the location of the offending definitions", func() {
+		for _, f := range findings {
+			if f.Definition == `type "Unused"` {
+				Ω(f.File).Should(Equal("rules_test.go"))
+				Ω(f.Line).Should(BeNumerically(">", 0))
+				return
+			}
+		}
+		Fail("no finding for the unused type")
+	})
+
+	Context("with severity overrides", func() {
+		BeforeEach(func() {
+			severities = map[string]genlint.Severity{
+				"plural-resource-name": genlint.SeverityOff,
+				"unused-type":          genlint.SeverityError,
+			}
+		})
+
+		It("uses the configured severities", func() {
+			Ω(findingsOf("plural-resource-name")).Should(BeEmpty())
+			for _, f := range findings {
+				if f.Rule == "unused-type" {
+					Ω(f.Severity).Should(Equal(genlint.SeverityError))
+				}
+			}
+		})
+	})
+})
+
+var _ = Describe("ParseSeverities", func() {
+	It("parses the rule severities", func() {
+		sev, err := genlint.ParseSeverities("unused-type=error, plural-resource-name=off")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(sev).Should(Equal(map[string]genlint.Severity{
+			"unused-type":          genlint.SeverityError,
+			"plural-resource-name": genlint.SeverityOff,
+		}))
+	})
+
+	It("rejects unknown rules and severities", func() {
+		_, err := genlint.ParseSeverities("foo=error")
+		Ω(err).Should(MatchError(`unknown rule "foo"`))
+		_, err = genlint.ParseSeverities("unused-type=fatal")
+		Ω(err).Should(HaveOccurred())
+	})
+})
@@ -19,6 +19,7 @@ type Generator struct {
 	outDir   string   //Path to output directory
 	target   string   // Name of generated "app" package
 	force    bool     // Whether to override existing files
+	metrics  bool     // Whether to mount the Prometheus metrics middleware and endpoint
 	genfiles []string // Generated files
 }
 
@@ -26,7 +27,7 @@ type Generator struct {
 func Generate() (files []string, err error) {
 	var (
 		outDir, target string
-		force          bool
+		force, metrics bool
 	)
 
 	set := flag.NewFlagSet("main", flag.PanicOnError)
@@ -34,11 +35,15 @@ func Generate() (files []string, err error) {
 	set.String("design", "", "")
 	set.StringVar(&target, "pkg", "app", "")
 	set.BoolVar(&force, "force", false, "")
+	set.BoolVar(&metrics, "metrics", false, "")
 	set.Parse(os.Args[2:])
 
 	target = codegen.Goify(target, false)
-	g := &Generator{outDir: outDir, target: target, force: force}
+	g := &Generator{outDir: outDir, target: target, force: force, metrics: metrics}
+	// Reserve the package name for the duration of the generation only so that generating
+	// again in the same process produces the same package name.
 	codegen.Reserved[target] = true
+	defer delete(codegen.Reserved, target)
 
 	return g.Generate(design.Design)
 }
@@ -155,10 +160,14 @@ func (g *Generator) createMainFile(mainFile string, api *design.APIDefinition, f
 		codegen.SimpleImport("github.com/goadesign/goa/middleware"),
 		codegen.SimpleImport(appPkg),
 	}
+	if g.metrics {
+		imports = append(imports, codegen.NewImport("goaprometheus", "github.com/goadesign/goa/middleware/prometheus"))
+	}
 	file.WriteHeader("", "main", imports)
 	data := map[string]interface{}{
-		"Name": api.Name,
-		"API":  api,
+		"Name":    api.Name,
+		"API":     api,
+		"Metrics": g.metrics,
 	}
 	if err = file.ExecuteTemplate("main", mainT, funcs, data); err != nil {
 		return err
@@ -184,10 +193,12 @@ func (g *Generator) okResp(a *design.ActionDefinition) map[string]interface{} {
 	}
 	view := "default"
 	if _, ok := mt.Views["default"]; !ok {
-		for v := range mt.Views {
-			view = v
-			break
-		}
+		mt.IterateViews(func(v *design.ViewDefinition) error {
+			if view == "default" {
+				view = v.Name
+			}
+			return nil
+		})
 	}
 	pmt, _, err := mt.Project(view)
 	if err != nil {
@@ -222,14 +233,20 @@ func main() {
 	// Mount middleware
 	service.Use(middleware.RequestID())
 	service.Use(middleware.LogRequest(true))
-	service.Use(middleware.ErrorHandler(service, true))
+{{ if .Metrics }}	service.Use(goaprometheus.Middleware(nil))
+{{ end }}	service.Use(middleware.ErrorHandler(service, true))
 	service.Use(middleware.Recover())
 {{ $api := .API }}
 {{ range $name, $res := $api.Resources }}{{ $name := goify $res.Name true }} // Mount "{{$res.Name}}" controller
 	{{ $tmp := tempvar }}{{ $tmp }} := New{{ $name }}Controller(service)
 	{{ targetPkg }}.Mount{{ $name }}Controller(service, {{ $tmp }})
+{{ end }}{{ if $api.Health }}
+	// Mount the liveness and readiness probe endpoints
+	{{ targetPkg }}.MountHealth(service)
+{{ end }}{{ if .Metrics }}
+	// Serve the Prometheus metrics
+	goaprometheus.Mount(service, "/metrics", nil)
 {{ end }}
-
 	// Start service
 	if err := service.ListenAndServe(":8080"); err != nil {
 		service.LogError("startup", "err", err)
@@ -55,4 +55,36 @@ var _ = Describe("Generate", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 		})
 	})
+
+	Context("with the metrics flag", func() {
+		BeforeEach(func() {
+			os.Args = append(os.Args, "--metrics")
+			design.Design = &design.APIDefinition{Name: "test api"}
+		})
+
+		It("mounts the Prometheus middleware and metrics endpoint", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`goaprometheus "github.com/goadesign/goa/middleware/prometheus"`))
+			Ω(string(content)).Should(ContainSubstring("service.Use(goaprometheus.Middleware(nil))\n\tservice.Use(middleware.ErrorHandler(service, true))"))
+			Ω(string(content)).Should(ContainSubstring(`goaprometheus.Mount(service, "/metrics", nil)`))
+		})
+	})
+
+	Context("with health checks", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				Name:   "test api",
+				Health: &design.HealthDefinition{LivenessPath: "/healthz", ReadinessPath: "/readyz"},
+			}
+		})
+
+		It("mounts the health check endpoints", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(".MountHealth(service)"))
+		})
+	})
 })
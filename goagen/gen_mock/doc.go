@@ -0,0 +1,16 @@
+/*
+Package genmock provides a generator for a mock server. The generated "mockserver" main package
+mounts all the action routes and writes example responses synthesized from the design so that
+clients can be developed against the API before any controller is implemented. The examples
+satisfy the attribute validations and use the examples defined in the design if any, media type
+bodies only contain the attributes of the view selected by the "view" query string parameter or
+of the default view.
+
+The mock server writes the first success response of each action, the X-Mock-Status request header
+selects another response by status code:
+
+	goagen mock -d github.com/acme/cellar/design
+	go run ./mockserver --addr :8080
+	curl -H "X-Mock-Status: 404" localhost:8080/bottles/1
+*/
+package genmock
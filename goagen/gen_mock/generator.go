@@ -0,0 +1,357 @@
+package genmock
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+)
+
+// MockStatusHeader is the name of the request header that selects the response written by the
+// mock server by status code.
+const MockStatusHeader = "X-Mock-Status"
+
+type (
+	// Generator is the mock server generator.
+	Generator struct {
+		genfiles   []string // Generated files
+		outDir     string   // Path to output directory
+		stdcontext bool     // Whether to import the standard library context package
+	}
+
+	// mockAction is the data used to render the handler of an action.
+	mockAction struct {
+		Name      string
+		Routes    []*design.RouteDefinition
+		Responses []*mockResponse
+	}
+
+	// mockResponse is an example response of an action.
+	mockResponse struct {
+		Status      int
+		ContentType string
+		Headers     map[string]string // Example header values indexed by name
+		View        string            // Name of the view used when the request does not select one
+		Bodies      map[string]string // Example JSON bodies indexed by view name
+	}
+)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var (
+		outDir     string
+		stdcontext bool
+	)
+
+	set := flag.NewFlagSet("mock", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.String("design", "", "")
+	set.BoolVar(&stdcontext, "stdcontext", false, "")
+	set.Parse(os.Args[2:])
+
+	g := &Generator{outDir: outDir, stdcontext: stdcontext}
+
+	return g.Generate(design.Design)
+}
+
+// Generate produces the mock server main package in the "mockserver" directory of the output
+// directory.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	outDir := filepath.Join(g.outDir, "mockserver")
+	render, err := codegen.BeginRender(outDir)
+	if err != nil {
+		return nil, err
+	}
+	defer render.Discard()
+	if err = os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, outDir)
+
+	mainFile := filepath.Join(outDir, "main.go")
+	file, err := codegen.SourceFileFor(mainFile)
+	if err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, mainFile)
+	title := fmt.Sprintf("%s: Mock Server", api.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.ContextImport(g.stdcontext),
+		codegen.SimpleImport("flag"),
+		codegen.SimpleImport("io"),
+		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("strconv"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("github.com/goadesign/goa/middleware"),
+	}
+	if err = file.WriteHeader(title, "main", imports); err != nil {
+		return nil, err
+	}
+	resources := make(map[string][]*mockAction)
+	err = api.IterateResources(func(res *design.ResourceDefinition) error {
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			ma, err := mock(api, a)
+			if err != nil {
+				return err
+			}
+			resources[res.Name] = append(resources[res.Name], ma)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{
+		"API":          api,
+		"Resources":    resources,
+		"StatusHeader": MockStatusHeader,
+	}
+	if err = file.ExecuteTemplate("main", mainT, nil, data); err != nil {
+		return nil, err
+	}
+	if err = file.FormatCode(); err != nil {
+		return nil, err
+	}
+
+	return g.genfiles, render.Commit()
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for i := len(g.genfiles) - 1; i >= 0; i-- {
+		os.Remove(g.genfiles[i])
+	}
+	g.genfiles = nil
+}
+
+// mock computes the example responses of the action sorted by status, the first success response
+// comes first. Actions that define no response get an empty 204 response.
+func mock(api *design.APIDefinition, a *design.ActionDefinition) (*mockAction, error) {
+	ma := &mockAction{Name: a.Name, Routes: a.Routes}
+	for _, r := range a.Responses {
+		mr, err := mockResp(api, r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", r.Context(), err)
+		}
+		ma.Responses = append(ma.Responses, mr)
+	}
+	if len(ma.Responses) == 0 {
+		ma.Responses = []*mockResponse{{Status: 204}}
+	}
+	success := func(s int) bool { return s >= 200 && s < 300 }
+	sort.SliceStable(ma.Responses, func(i, j int) bool {
+		si, sj := ma.Responses[i].Status, ma.Responses[j].Status
+		if success(si) != success(sj) {
+			return success(si)
+		}
+		return si < sj
+	})
+	return ma, nil
+}
+
+// mockResp produces the example headers and bodies of a response. The bodies of media type
+// responses are produced for each view from the projected media type so that they only contain
+// the view attributes. The examples satisfy the attribute validations and use the examples
+// defined in the design if any.
+func mockResp(api *design.APIDefinition, r *design.ResponseDefinition) (*mockResponse, error) {
+	mr := &mockResponse{Status: r.Status, Bodies: make(map[string]string)}
+	if r.Headers != nil {
+		if o := r.Headers.Type.ToObject(); o != nil {
+			mr.Headers = make(map[string]string, len(o))
+			for n, att := range o {
+				mr.Headers[n] = fmt.Sprint(example(att, api.RandomGenerator(), 0))
+			}
+		}
+	}
+	var mt *design.MediaTypeDefinition
+	if r.MediaType != "" {
+		if mt = api.MediaTypeWithIdentifier(r.MediaType); mt == nil && design.CanonicalIdentifier(r.MediaType) == design.CanonicalIdentifier(design.ErrorMedia.Identifier) {
+			mt = design.ErrorMedia
+		}
+	}
+	switch {
+	case mt != nil:
+		mr.ContentType = mt.Identifier
+		mr.View = "default"
+		views := make([]string, 0, len(mt.Views))
+		for v := range mt.Views {
+			views = append(views, v)
+		}
+		sort.Strings(views)
+		for _, v := range views {
+			p, _, err := mt.Project(v)
+			if err != nil {
+				return nil, err
+			}
+			body, err := json.Marshal(example(p.AttributeDefinition, api.RandomGenerator(), 0))
+			if err != nil {
+				return nil, err
+			}
+			mr.Bodies[v] = string(body)
+		}
+	case r.Type != nil:
+		mr.ContentType = "application/json"
+		body, err := json.Marshal(example(&design.AttributeDefinition{Type: r.Type}, api.RandomGenerator(), 0))
+		if err != nil {
+			return nil, err
+		}
+		mr.Bodies[""] = string(body)
+	}
+	return mr, nil
+}
+
+// maxDepth is the maximum depth of the generated examples, the attributes of objects nested
+// deeper are omitted so that the examples of recursive types are finite.
+const maxDepth = 5
+
+// example returns an example value of the attribute. Objects and arrays are generated element by
+// element so that the examples of all the nested attributes satisfy their validations, the
+// leaves use the example defined in the design or computed when the design was finalized.
+func example(att *design.AttributeDefinition, r *design.RandomGenerator, depth int) interface{} {
+	switch {
+	case att.Type.IsObject():
+		if depth >= maxDepth {
+			return nil
+		}
+		o := att.Type.ToObject()
+		names := make([]string, 0, len(o))
+		for n := range o {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		res := make(map[string]interface{}, len(o))
+		for _, n := range names {
+			if v := example(o[n], r, depth+1); v != nil {
+				res[n] = v
+			}
+		}
+		return res
+	case att.Type.IsArray():
+		if depth >= maxDepth {
+			return []interface{}{}
+		}
+		count := r.Int()%3 + 1
+		if v := att.Validation; v != nil {
+			if v.MinLength != nil && count < *v.MinLength {
+				count = *v.MinLength
+			}
+			if v.MaxLength != nil && count > *v.MaxLength {
+				count = *v.MaxLength
+			}
+		}
+		res := make([]interface{}, count)
+		for i := range res {
+			res[i] = example(att.Type.ToArray().ElemType, r, depth+1)
+		}
+		return res
+	case att.Example != nil:
+		return att.Example
+	}
+	return att.GenerateExample(r)
+}
+
+const mainT = `// mockResponse is an example response of an action.
+type mockResponse struct {
+	status      int
+	contentType string
+	headers     map[string]string
+	view        string            // default view
+	bodies      map[string]string // JSON bodies indexed by view name
+}
+
+func main() {
+	var addr string
+	flag.StringVar(&addr, "addr", ":8080", "listen address")
+	flag.Parse()
+
+	// Create service
+	service := goa.New({{ printf "%q" .API.Name }})
+
+	// Setup the encoder used to write the error responses
+	service.Encoder.Register(goa.NewJSONEncoder, "application/json", "*/*")
+
+	// Mount middleware
+	service.Use(middleware.RequestID())
+	service.Use(middleware.LogRequest(true))
+	service.Use(middleware.ErrorHandler(service, true))
+	service.Use(middleware.Recover())
+{{ range $res, $actions := .Resources }}
+	// Mount "{{ $res }}" mock controller
+	{{ $ctrl := tempvar }}{{ $ctrl }} := service.NewController({{ printf "%q" (printf "%sMockController" (goify $res true)) }})
+{{ range $actions }}	mount({{ $ctrl }}, {{ printf "%q" .Name }}, []*mockResponse{
+{{ range .Responses }}		{
+			status: {{ .Status }},
+{{ if .ContentType }}			contentType: {{ printf "%q" .ContentType }},
+{{ end }}{{ if .Headers }}			headers: map[string]string{
+{{ range $n, $v := .Headers }}				{{ printf "%q" $n }}: {{ printf "%q" $v }},
+{{ end }}			},
+{{ end }}{{ if .View }}			view: {{ printf "%q" .View }},
+{{ end }}{{ if .Bodies }}			bodies: map[string]string{
+{{ range $v, $b := .Bodies }}				{{ printf "%q" $v }}: {{ printf "%q" $b }},
+{{ end }}			},
+{{ end }}		},
+{{ end }}	}{{ range .Routes }}, {{ printf "%q" .Verb }}, {{ printf "%q" .FullPath }}{{ end }})
+{{ end }}{{ end }}
+	// Start service
+	service.LogInfo("mock server", "addr", addr)
+	if err := service.ListenAndServe(addr); err != nil {
+		service.LogError("startup", "err", err)
+	}
+}
+
+// mount mounts the handler writing the example responses of an action onto the given routes
+// listed as method and path pairs. The handler writes the first response unless the request
+// {{ .StatusHeader }} header selects another one by status code. The "view" query string
+// parameter selects the view used to render the body of media type responses.
+func mount(ctrl *goa.Controller, action string, responses []*mockResponse, routes ...string) {
+	h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		res := responses[0]
+		if s := req.Header.Get({{ printf "%q" .StatusHeader }}); s != "" {
+			status, err := strconv.Atoi(s)
+			if err != nil {
+				return goa.ErrBadRequest("invalid {{ .StatusHeader }} header %#v", s)
+			}
+			found := false
+			for _, r := range responses {
+				if r.status == status {
+					res, found = r, true
+					break
+				}
+			}
+			if !found {
+				return goa.ErrBadRequest("action %s does not define a %d response", action, status)
+			}
+		}
+		body, ok := res.bodies[req.URL.Query().Get("view")]
+		if !ok {
+			body = res.bodies[res.view]
+		}
+		for n, v := range res.headers {
+			rw.Header().Set(n, v)
+		}
+		if body != "" {
+			rw.Header().Set("Content-Type", res.contentType)
+		}
+		rw.WriteHeader(res.status)
+		_, err := io.WriteString(rw, body)
+		return err
+	}
+	for i := 0; i+1 < len(routes); i += 2 {
+		ctrl.Service.Mux.Handle(routes[i], routes[i+1], ctrl.MuxHandler(action, h, nil))
+	}
+}
+`
@@ -0,0 +1,143 @@
+package genmock_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	const outPackagePath = "github.com/goadesign/goa/goagen/gen_mock/mocktest"
+
+	var outDir string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		gopath := filepath.SplitList(os.Getenv("GOPATH"))[0]
+		outDir = filepath.Join(gopath, "src", outPackagePath)
+		Ω(os.MkdirAll(outDir, 0777)).ShouldNot(HaveOccurred())
+		os.Args = []string{"goagen", "mock", "--out=" + outDir, "--design=foo"}
+
+		dslengine.Reset()
+		API("cellar", func() {})
+		var Bottle = MediaType("application/vnd.bottle", func() {
+			Attributes(func() {
+				Attribute("id", Integer, func() { Minimum(1); Maximum(10) })
+				Attribute("name", String, func() { Example("Number 8") })
+				Attribute("color", String, func() { Enum("red", "white") })
+				Attribute("tags", ArrayOf(String), func() { MinLength(4) })
+			})
+			View("default", func() {
+				Attribute("id")
+				Attribute("name")
+				Attribute("color")
+				Attribute("tags")
+			})
+			View("tiny", func() {
+				Attribute("id")
+			})
+		})
+		Resource("bottles", func() {
+			BasePath("/bottles")
+			Action("show", func() {
+				Routing(GET("/:id"), GET("/:id/details"))
+				Response(NotFound)
+				Response(OK, Bottle)
+			})
+			Action("delete", func() {
+				Routing(DELETE("/:id"))
+			})
+		})
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genmock.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	// mainCode returns the content of the generated main.go file.
+	mainCode := func() string {
+		b, err := ioutil.ReadFile(filepath.Join(outDir, "mockserver", "main.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(b)
+	}
+
+	// body returns the example body of the given view of the show action.
+	body := func(view string) map[string]interface{} {
+		m := regexp.MustCompile(`\t"` + view + `": +(".*"),\n`).FindStringSubmatch(mainCode())
+		Ω(m).Should(HaveLen(2))
+		js, err := strconv.Unquote(m[1])
+		Ω(err).ShouldNot(HaveOccurred())
+		var res map[string]interface{}
+		Ω(json.Unmarshal([]byte(js), &res)).ShouldNot(HaveOccurred())
+		return res
+	}
+
+	It("generates the mock server", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(files).Should(ContainElement(filepath.Join(outDir, "mockserver", "main.go")))
+		code := mainCode()
+		Ω(code).Should(ContainSubstring("package main"))
+		Ω(code).Should(ContainSubstring(`ctrl.Service.Mux.Handle(routes[i], routes[i+1], ctrl.MuxHandler(action, h, nil))`))
+		Ω(code).Should(ContainSubstring(`}, "GET", "/bottles/:id", "GET", "/bottles/:id/details")`))
+	})
+
+	It("imports the golang.org/x/net/context package without --stdcontext", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(mainCode()).Should(ContainSubstring(`"golang.org/x/net/context"`))
+	})
+
+	Context("with --stdcontext", func() {
+		BeforeEach(func() {
+			os.Args = append(os.Args, "--stdcontext")
+		})
+
+		It("imports the standard library context package", func() {
+			Ω(genErr).ShouldNot(HaveOccurred())
+			code := mainCode()
+			Ω(code).Should(ContainSubstring("\t\"context\"\n"))
+			Ω(code).ShouldNot(ContainSubstring(`"golang.org/x/net/context"`))
+		})
+	})
+
+	It("writes the success response first", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(mainCode()).Should(MatchRegexp(`mount\(\w+, "show", \[\]\*mockResponse\{\s+\{\s+status:\s+200,\s+contentType: "application/vnd.bottle",\s+view:\s+"default",`))
+	})
+
+	It("writes a 204 response for the actions that define no response", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(mainCode()).Should(MatchRegexp(`mount\(\w+, "delete", \[\]\*mockResponse\{\s+\{\s+status: 204,\s+\},\s+\}, "DELETE", "/bottles/:id"\)`))
+	})
+
+	It("produces examples that satisfy the validations", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		b := body("default")
+		Ω(b["id"]).Should(BeNumerically(">=", 1))
+		Ω(b["id"]).Should(BeNumerically("<=", 10))
+		Ω(b["name"]).Should(Equal("Number 8"))
+		Ω([]string{"red", "white"}).Should(ContainElement(b["color"]))
+		Ω(len(b["tags"].([]interface{}))).Should(BeNumerically(">=", 4))
+	})
+
+	It("renders the bodies of each view", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(body("tiny")).Should(HaveLen(1))
+		Ω(body("tiny")).Should(HaveKey("id"))
+	})
+})
@@ -0,0 +1,24 @@
+/*
+Package genproto provides a goa generator for Protocol Buffers definitions.
+
+The generator produces a .proto file that declares a gRPC service per resource with one method
+per action and a message for each user type, media type and payload of the design. The request
+message of a method lists the action parameters and headers and holds the payload in its
+"payload" field, the response message is the message of the first success response body or
+google.protobuf.Empty. Array and primitive types are wrapped in messages with a single "items" or
+"value" field. The fields keep the attribute names as JSON names so that the JSON representation
+of the messages matches the HTTP API.
+
+Fields are numbered in alphabetical order unless the attribute sets the "proto:field:number"
+metadata.
+
+Media types that set the "protobuf:message" metadata to the Go type protoc generates for their
+message get methods converting them to and from the message in the application package, so that
+the actions may also render "application/x-protobuf" bodies.
+
+The generator optionally produces the "grpcbridge" package that implements the controllers of the
+generated application package by calling the gRPC service handlers generated by protoc. Conversely
+the client generator --grpc flag generates clients that implement the resource client interfaces by
+calling the gRPC services, see ActionRPC.
+*/
+package genproto
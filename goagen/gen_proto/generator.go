@@ -0,0 +1,700 @@
+package genproto
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+)
+
+// Generator is the Protocol Buffers generator.
+type Generator struct {
+	genfiles []string // Generated files
+	outDir   string   // Destination directory
+	pkg      string   // Protocol Buffers package name
+	goPkg    string   // Value of the go_package option
+	bridge   bool     // Whether to generate the gRPC to HTTP bridge
+	target   string   // Name of the generated application package
+}
+
+type (
+	// protoMessage is the data used to render a message definition.
+	protoMessage struct {
+		// Name is the message name.
+		Name string
+		// Description is the message description.
+		Description string
+		// Indent is the indentation of the definition, nested messages are indented.
+		Indent string
+		// Fields lists the message fields sorted by number.
+		Fields []*protoField
+		// Messages lists the nested messages that describe inline objects.
+		Messages []*protoMessage
+	}
+
+	// protoField is the data used to render a message field.
+	protoField struct {
+		// Name is the field name.
+		Name string
+		// JSONName is the attribute name, empty if it is the default JSON name of the field.
+		JSONName string
+		// Type is the field type.
+		Type string
+		// Repeated is true for the fields describing arrays.
+		Repeated bool
+		// Number is the field number.
+		Number int
+		// Description is the field description.
+		Description string
+	}
+
+	// protoService is the data used to render the service of a resource and its bridge.
+	protoService struct {
+		// Name is the service name.
+		Name string
+		// Resource is the name of the resource.
+		Resource string
+		// Description is the resource description.
+		Description string
+		// RPCs lists the service methods.
+		RPCs []*RPC
+	}
+
+	// RPC describes the gRPC method of an action, it is the data used to render the method, its
+	// bridge and the gRPC client generated by the client generator.
+	RPC struct {
+		// Service is the name of the service of the action resource.
+		Service string
+		// Name is the method name.
+		Name string
+		// Description is the action description.
+		Description string
+		// Context is the name of the action context type.
+		Context string
+		// Request is the name of the request message.
+		Request string
+		// Response is the name of the response message.
+		Response string
+		// Params lists the names of the action parameters and headers.
+		Params []string
+		// Payload is true if the action has a payload.
+		Payload bool
+		// Status is the status of the HTTP responses corresponding to the method responses.
+		Status int
+		// ContentType is the content type of the HTTP responses corresponding to the method
+		// responses.
+		ContentType string
+		// Unwrap is the name of the response message field holding the HTTP response body,
+		// empty if the response body is the message itself.
+		Unwrap string
+	}
+
+	// builder builds the messages of the file.
+	builder struct {
+		messages map[string]*protoMessage
+		imports  map[string]bool
+	}
+)
+
+const (
+	// emptyType is the type of the responses of actions that have no response body.
+	emptyType = "google.protobuf.Empty"
+	// timestampType is the type of date time attributes.
+	timestampType = "google.protobuf.Timestamp"
+	// valueType is the type of attributes of type Any.
+	valueType = "google.protobuf.Value"
+	// listType is the type of array attributes that cannot be repeated fields.
+	listType = "google.protobuf.ListValue"
+	// structType is the type of hash attributes that cannot be map fields.
+	structType = "google.protobuf.Struct"
+)
+
+// wellKnownImports maps the well-known types to the file defining them.
+var wellKnownImports = map[string]string{
+	emptyType:     "google/protobuf/empty.proto",
+	timestampType: "google/protobuf/timestamp.proto",
+	valueType:     "google/protobuf/struct.proto",
+	listType:      "google/protobuf/struct.proto",
+	structType:    "google/protobuf/struct.proto",
+}
+
+// invalidCharsRegex matches the characters that may not appear in field names.
+var invalidCharsRegex = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// separatorsRegex matches the characters that separate words in attribute names.
+var separatorsRegex = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, pkg, goPkg, target string
+	var bridge bool
+
+	set := flag.NewFlagSet("proto", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.String("design", "", "")
+	set.StringVar(&pkg, "package", "", "")
+	set.StringVar(&goPkg, "go-package", "", "")
+	set.BoolVar(&bridge, "bridge", false, "")
+	set.StringVar(&target, "pkg", "app", "")
+	set.Parse(os.Args[2:])
+
+	g := &Generator{outDir: outDir, pkg: pkg, goPkg: goPkg, bridge: bridge, target: target}
+
+	return g.Generate(design.Design)
+}
+
+// Generate produces the .proto file in the "proto" directory of the output directory and the
+// bridge package in the "grpcbridge" directory if requested.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	if g.pkg == "" {
+		g.pkg = fieldName(api.Name)
+	}
+	if g.goPkg == "" {
+		if outPkg, err := codegen.PackagePath(g.outDir); err == nil {
+			g.goPkg = path.Join(outPkg, "proto") + ";pb"
+		} else if g.bridge {
+			return nil, fmt.Errorf("--go-package is required to generate the bridge when the output directory is not in a Go workspace: %s", err)
+		}
+	}
+
+	services, messages, imports, err := build(api)
+	if err != nil {
+		return nil, err
+	}
+
+	protoDir := filepath.Join(g.outDir, "proto")
+	if err = g.generateDir(protoDir, func() error {
+		return g.generateProto(filepath.Join(protoDir, g.pkg+".proto"), services, messages, imports)
+	}); err != nil {
+		return nil, err
+	}
+	if g.bridge {
+		bridgeDir := filepath.Join(g.outDir, "grpcbridge")
+		if err = g.generateDir(bridgeDir, func() error {
+			return g.generateBridge(filepath.Join(bridgeDir, "bridge.go"), api, services)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for i := len(g.genfiles) - 1; i >= 0; i-- {
+		os.Remove(g.genfiles[i])
+	}
+	g.genfiles = nil
+}
+
+// generateDir renders the files produced by gen in dir and writes the files that changed.
+func (g *Generator) generateDir(dir string, gen func() error) error {
+	render, err := codegen.BeginRender(dir)
+	if err != nil {
+		return err
+	}
+	defer render.Discard()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, dir)
+	if err := gen(); err != nil {
+		return err
+	}
+	return render.Commit()
+}
+
+func (g *Generator) generateProto(protoFile string, services []*protoService, messages []*protoMessage, imports []string) error {
+	file, err := codegen.SourceFileFor(protoFile)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, protoFile)
+	data := map[string]interface{}{
+		"Version":   codegen.Version,
+		"Package":   g.pkg,
+		"GoPackage": g.goPkg,
+		"Imports":   imports,
+		"Services":  services,
+		"Messages":  messages,
+	}
+	return file.ExecuteTemplate("proto", protoT, template.FuncMap{"comment": comment}, data)
+}
+
+func (g *Generator) generateBridge(bridgeFile string, api *design.APIDefinition, services []*protoService) error {
+	file, err := codegen.SourceFileFor(bridgeFile)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, bridgeFile)
+	outPkg, err := codegen.PackagePath(g.outDir)
+	if err != nil {
+		return err
+	}
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport(path.Join(outPkg, g.target)),
+		codegen.NewImport("pb", strings.SplitN(g.goPkg, ";", 2)[0]),
+		codegen.SimpleImport("google.golang.org/grpc/codes"),
+		codegen.SimpleImport("google.golang.org/grpc/status"),
+		codegen.SimpleImport("google.golang.org/protobuf/encoding/protojson"),
+		codegen.SimpleImport("google.golang.org/protobuf/proto"),
+	}
+	title := fmt.Sprintf("%s: gRPC Bridge", api.Context())
+	if err := file.WriteHeader(title, "grpcbridge", imports); err != nil {
+		return err
+	}
+	data := map[string]interface{}{
+		"Target":   g.target,
+		"Services": services,
+	}
+	if err := file.ExecuteTemplate("bridge", bridgeT, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// build computes the services of the resources and the messages they refer to. The messages are
+// sorted by name and the imports list the files that define the well-known types in use.
+func build(api *design.APIDefinition) ([]*protoService, []*protoMessage, []string, error) {
+	b := &builder{messages: make(map[string]*protoMessage), imports: make(map[string]bool)}
+	api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		b.userType(ut)
+		return nil
+	})
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		b.userType(mt.UserTypeDefinition)
+		return nil
+	})
+
+	var services []*protoService
+	err := api.IterateResources(func(res *design.ResourceDefinition) error {
+		svc := &protoService{
+			Name:        serviceName(res),
+			Resource:    res.Name,
+			Description: res.Description,
+		}
+		err := res.IterateActions(func(a *design.ActionDefinition) error {
+			if a.WebSocket() {
+				return nil
+			}
+			rpc, err := b.rpc(a)
+			if err != nil {
+				return err
+			}
+			svc.RPCs = append(svc.RPCs, rpc)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(svc.RPCs) > 0 {
+			services = append(services, svc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	names := make([]string, 0, len(b.messages))
+	for n := range b.messages {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	messages := make([]*protoMessage, len(names))
+	for i, n := range names {
+		messages[i] = b.messages[n]
+	}
+	imports := make([]string, 0, len(b.imports))
+	for i := range b.imports {
+		imports = append(imports, i)
+	}
+	sort.Strings(imports)
+	return services, messages, imports, nil
+}
+
+// rpc computes the method of the given action together with its request message. The request
+// message lists the action parameters and headers and holds the payload in the "payload" field.
+// The response message is the message of the body of the first success response.
+func (b *builder) rpc(a *design.ActionDefinition) (*RPC, error) {
+	name := codegen.Goify(a.Name, true)
+	resName := codegen.Goify(a.Parent.Name, true)
+	rpc := &RPC{
+		Service:     serviceName(a.Parent),
+		Name:        name,
+		Description: a.Description,
+		Context:     name + resName + "Context",
+		Request:     name + resName + "Request",
+		Response:    emptyType,
+		Status:      204,
+	}
+	if _, ok := b.messages[rpc.Request]; ok {
+		return nil, fmt.Errorf("%s: request message %s conflicts with a type of the same name", a.Context(), rpc.Request)
+	}
+
+	req := make(design.Object)
+	var required []string
+	for _, atts := range []*design.AttributeDefinition{a.AllParams(), a.Headers} {
+		if atts == nil || !atts.Type.IsObject() {
+			continue
+		}
+		for n, att := range atts.Type.ToObject() {
+			req[n] = att
+			rpc.Params = append(rpc.Params, n)
+		}
+		required = append(required, atts.AllRequired()...)
+	}
+	sort.Strings(rpc.Params)
+	if a.Payload != nil {
+		req["payload"] = &design.AttributeDefinition{Type: a.Payload}
+		rpc.Payload = true
+	}
+	reqAtt := &design.AttributeDefinition{Type: req}
+	b.messages[rpc.Request] = b.message(rpc.Request, "", "", reqAtt)
+
+	var success []*design.ResponseDefinition
+	for _, r := range a.Responses {
+		if r.Status >= 200 && r.Status < 300 {
+			success = append(success, r)
+		}
+	}
+	sort.Slice(success, func(i, j int) bool { return success[i].Status < success[j].Status })
+	if len(success) > 0 {
+		r := success[0]
+		rpc.Status = r.Status
+		var ut *design.UserTypeDefinition
+		if mt := design.Design.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			ut = mt.UserTypeDefinition
+			rpc.ContentType = mt.Identifier
+		} else if t, ok := r.Type.(*design.UserTypeDefinition); ok {
+			ut = t
+			rpc.ContentType = "application/json"
+		}
+		if ut != nil {
+			rpc.Response = b.userType(ut)
+			if !ut.Type.IsObject() {
+				rpc.Unwrap = wrapperField(ut.AttributeDefinition)
+			}
+		}
+	}
+	if rpc.Response == emptyType {
+		b.imports[wellKnownImports[emptyType]] = true
+	}
+	return rpc, nil
+}
+
+// ActionRPC returns the method of the gRPC service generated for the given action, nil for
+// websocket actions which have no method.
+func ActionRPC(a *design.ActionDefinition) *RPC {
+	if a.WebSocket() {
+		return nil
+	}
+	b := &builder{messages: make(map[string]*protoMessage), imports: make(map[string]bool)}
+	rpc, _ := b.rpc(a)
+	return rpc
+}
+
+// serviceName returns the name of the gRPC service of the given resource.
+func serviceName(res *design.ResourceDefinition) string {
+	return codegen.Goify(res.Name, true) + "Service"
+}
+
+// userType returns the name of the message of the given type, creating the message if needed.
+func (b *builder) userType(ut *design.UserTypeDefinition) string {
+	name := codegen.Goify(ut.TypeName, true)
+	if _, ok := b.messages[name]; !ok {
+		// Register the name first so that recursive types refer to the message being built.
+		b.messages[name] = nil
+		b.messages[name] = b.message(name, ut.Description, "", ut.AttributeDefinition)
+	}
+	return name
+}
+
+// message builds the message describing the given attribute. Objects map to messages with one
+// field per attribute, the other types are wrapped in a message with a single field.
+func (b *builder) message(name, desc, indent string, att *design.AttributeDefinition) *protoMessage {
+	m := &protoMessage{Name: name, Description: desc, Indent: indent}
+	if !att.Type.IsObject() {
+		f := &protoField{Name: wrapperField(att), Number: 1}
+		f.Type, f.Repeated = b.typeRef(att, m, f.Name)
+		m.Fields = []*protoField{f}
+		return m
+	}
+	obj := att.Type.ToObject()
+	names := make([]string, 0, len(obj))
+	max := 0
+	for n, a := range obj {
+		names = append(names, n)
+		if num := a.ProtoFieldNumber(); num > max {
+			max = num
+		}
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		f := &protoField{Name: fieldName(n), Number: obj[n].ProtoFieldNumber(), Description: obj[n].Description}
+		if f.Number == 0 {
+			max++
+			if max == 19000 {
+				max = 20000
+			}
+			f.Number = max
+		}
+		if jsonName(f.Name) != n {
+			f.JSONName = n
+		}
+		f.Type, f.Repeated = b.typeRef(obj[n], m, n)
+		m.Fields = append(m.Fields, f)
+	}
+	sort.Slice(m.Fields, func(i, j int) bool { return m.Fields[i].Number < m.Fields[j].Number })
+	return m
+}
+
+// typeRef returns the type of the field describing the given attribute and whether the field is
+// repeated. Inline objects are described by messages nested in parent and named after the
+// attribute.
+func (b *builder) typeRef(att *design.AttributeDefinition, parent *protoMessage, attName string) (string, bool) {
+	switch actual := att.Type.(type) {
+	case *design.MediaTypeDefinition:
+		return b.userType(actual.UserTypeDefinition), false
+	case *design.UserTypeDefinition:
+		return b.userType(actual), false
+	case *design.Array:
+		if actual.ElemType.Type.IsArray() {
+			return b.wellKnown(listType), true
+		}
+		if actual.ElemType.Type.IsHash() {
+			return b.wellKnown(structType), true
+		}
+		t, _ := b.typeRef(actual.ElemType, parent, attName)
+		return t, true
+	case *design.Hash:
+		var key string
+		switch actual.KeyType.Type.Kind() {
+		case design.IntegerKind:
+			key = "int64"
+		case design.BooleanKind:
+			key = "bool"
+		default:
+			key = "string"
+		}
+		var elem string
+		switch {
+		case actual.ElemType.Type.IsArray():
+			elem = b.wellKnown(listType)
+		case actual.ElemType.Type.IsHash():
+			elem = b.wellKnown(structType)
+		default:
+			elem, _ = b.typeRef(actual.ElemType, parent, attName)
+		}
+		return "map<" + key + ", " + elem + ">", false
+	case design.Object:
+		nested := b.message(codegen.Goify(attName, true), att.Description, parent.Indent+"  ", att)
+		parent.Messages = append(parent.Messages, nested)
+		return nested.Name, false
+	}
+	switch att.Type.Kind() {
+	case design.BooleanKind:
+		return "bool", false
+	case design.IntegerKind:
+		return "int64", false
+	case design.NumberKind:
+		return "double", false
+	case design.DateTimeKind:
+		return b.wellKnown(timestampType), false
+	case design.AnyKind:
+		return b.wellKnown(valueType), false
+	}
+	return "string", false
+}
+
+// wellKnown records the import of the file defining the given well-known type and returns it.
+func (b *builder) wellKnown(t string) string {
+	b.imports[wellKnownImports[t]] = true
+	return t
+}
+
+// wrapperField returns the name of the single field of the messages that wrap attributes that
+// are not objects.
+func wrapperField(att *design.AttributeDefinition) string {
+	if att.Type.IsArray() {
+		return "items"
+	}
+	return "value"
+}
+
+// fieldName returns the snake_case field name of the attribute with the given name.
+func fieldName(name string) string {
+	var parts []string
+	for _, p := range separatorsRegex.Split(name, -1) {
+		if p != "" {
+			parts = append(parts, codegen.SnakeCase(p))
+		}
+	}
+	n := invalidCharsRegex.ReplaceAllString(strings.ToLower(strings.Join(parts, "_")), "_")
+	if n == "" || n[0] < 'a' || n[0] > 'z' {
+		n = "f_" + n
+	}
+	return n
+}
+
+// jsonName returns the JSON name protoc computes for the field with the given name.
+func jsonName(field string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range field {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// comment formats the given text as a comment with the given indentation.
+func comment(indent, text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(indent+"// "+strings.TrimSpace(l), " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+const protoT = `// Code generated by goagen {{ .Version }}, DO NOT EDIT.
+
+syntax = "proto3";
+
+package {{ .Package }};
+{{ if .Imports }}
+{{ range .Imports }}import "{{ . }}";
+{{ end }}{{ end }}{{ if .GoPackage }}
+option go_package = "{{ .GoPackage }}";
+{{ end }}{{ range .Services }}
+{{ if .Description }}{{ comment "" .Description }}{{ end }}service {{ .Name }} {
+{{ range .RPCs }}{{ if .Description }}{{ comment "  " .Description }}{{ end }}  rpc {{ .Name }}({{ .Request }}) returns ({{ .Response }});
+{{ end }}}
+{{ end }}{{ range .Messages }}
+{{ template "message" . }}{{ end }}{{ define "message" }}{{ if .Description }}{{ comment .Indent .Description }}{{ end }}{{ .Indent }}message {{ .Name }} {
+{{ range .Messages }}{{ template "message" . }}{{ end }}{{ $indent := .Indent }}{{ range .Fields }}{{ if .Description }}{{ comment (printf "%s  " $indent) .Description }}{{ end }}{{ $indent }}  {{ if .Repeated }}repeated {{ end }}{{ .Type }} {{ .Name }} = {{ .Number }}{{ if .JSONName }} [json_name = {{ printf "%q" .JSONName }}]{{ end }};
+{{ end }}{{ .Indent }}}
+{{ end }}`
+
+const bridgeT = `{{ $target := .Target }}{{ range .Services }}{{ $bridge := printf "%sBridge" (goify .Resource true) }}
+// {{ $bridge }} implements the {{ $target }}.{{ goify .Resource true }}Controller interface by
+// calling the handlers of the {{ .Name }} gRPC service.
+type {{ $bridge }} struct {
+	*goa.Controller
+	srv pb.{{ .Name }}Server
+}
+
+// New{{ $bridge }} creates a controller that serves the {{ .Resource }} actions with the given
+// gRPC service implementation, mount it with {{ $target }}.Mount{{ goify .Resource true }}Controller.
+func New{{ $bridge }}(service *goa.Service, srv pb.{{ .Name }}Server) *{{ $bridge }} {
+	return &{{ $bridge }}{Controller: service.NewController({{ printf "%q" $bridge }}), srv: srv}
+}
+{{ range .RPCs }}
+// {{ .Name }} calls the {{ .Name }} gRPC handler.
+func (b *{{ $bridge }}) {{ .Name }}(ctx *{{ $target }}.{{ .Context }}) error {
+	in := &pb.{{ .Request }}{}
+	if err := decodeRequest(map[string]interface{}{
+{{ range .Params }}		{{ printf "%q" . }}: ctx.{{ goify . true }},
+{{ end }}{{ if .Payload }}		"payload": ctx.Payload,
+{{ end }}	}, in); err != nil {
+		return goa.ErrBadRequest(err)
+	}
+{{ if eq .Response "google.protobuf.Empty" }}	if _, err := b.srv.{{ .Name }}(ctx, in); err != nil {
+		return grpcError(err)
+	}
+	ctx.ResponseData.WriteHeader({{ .Status }})
+	return nil
+{{ else }}	out, err := b.srv.{{ .Name }}(ctx, in)
+	if err != nil {
+		return grpcError(err)
+	}
+	return writeResponse(ctx.ResponseData, {{ .Status }}, {{ printf "%q" .ContentType }}, out, {{ printf "%q" .Unwrap }})
+{{ end }}}
+{{ end }}{{ end }}
+// decodeRequest initializes the request message m from the action parameters and payload.
+func decodeRequest(fields map[string]interface{}, m proto.Message) error {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, m)
+}
+
+// writeResponse writes the JSON representation of m. unwrap names the field holding the body of
+// the messages that wrap arrays or primitive values.
+func writeResponse(rw *goa.ResponseData, code int, contentType string, m proto.Message, unwrap string) error {
+	body, err := protojson.MarshalOptions{EmitUnpopulated: unwrap != ""}.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if unwrap != "" {
+		var wrapper map[string]json.RawMessage
+		if err := json.Unmarshal(body, &wrapper); err != nil {
+			return err
+		}
+		body = wrapper[unwrap]
+	}
+	rw.Header().Set("Content-Type", contentType)
+	rw.WriteHeader(code)
+	_, err = rw.Write(body)
+	return err
+}
+
+// httpStatus maps the gRPC status codes to HTTP statuses.
+var httpStatus = map[codes.Code]int{
+	codes.Canceled:           499,
+	codes.InvalidArgument:    400,
+	codes.DeadlineExceeded:   504,
+	codes.NotFound:           404,
+	codes.AlreadyExists:      409,
+	codes.PermissionDenied:   403,
+	codes.Unauthenticated:    401,
+	codes.ResourceExhausted:  429,
+	codes.FailedPrecondition: 400,
+	codes.Aborted:            409,
+	codes.OutOfRange:         400,
+	codes.Unimplemented:      501,
+	codes.Unavailable:        503,
+}
+
+// grpcError converts the gRPC status errors returned by the handlers into goa errors whose
+// status corresponds to the gRPC status code.
+func grpcError(err error) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	st, ok := httpStatus[s.Code()]
+	if !ok {
+		st = 500
+	}
+	return goa.NewErrorClass(s.Code().String(), st)(s.Message())
+}
+`
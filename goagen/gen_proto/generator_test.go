@@ -0,0 +1,173 @@
+package genproto_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_proto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	const testgenPackagePath = "github.com/goadesign/goa/goagen/gen_proto/test_"
+
+	var outDir string
+	var args []string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		gopath := filepath.SplitList(os.Getenv("GOPATH"))[0]
+		outDir = filepath.Join(gopath, "src", testgenPackagePath)
+		err := os.MkdirAll(outDir, 0777)
+		Ω(err).ShouldNot(HaveOccurred())
+		args = []string{"goagen", "proto", "--out=" + outDir, "--design=foo"}
+	})
+
+	JustBeforeEach(func() {
+		os.Args = args
+		files, genErr = genproto.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	Context("with an action", func() {
+		BeforeEach(func() {
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "Bottle",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{
+							"id":         {Type: design.Integer},
+							"created_at": {Type: design.DateTime},
+							"tags":       {Type: &design.Array{ElemType: &design.AttributeDefinition{Type: design.String}}},
+							"winery": {Type: design.Object{
+								"name": {Type: design.String},
+							}},
+							"rating": {
+								Type:     design.Number,
+								Metadata: dslengine.MetadataDefinition{"proto:field:number": {"10"}},
+							},
+						},
+					},
+				},
+				Identifier: "application/vnd.bottle+json",
+			}
+			payload := &design.UserTypeDefinition{
+				TypeName: "UpdateBottlePayload",
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"name": {Type: design.String}},
+				},
+			}
+			update := &design.ActionDefinition{
+				Name:        "update",
+				Description: "Update a bottle",
+				Payload:     payload,
+				Routes: []*design.RouteDefinition{{
+					Verb: "PUT",
+					Path: "/bottles/:id",
+				}},
+				Params: &design.AttributeDefinition{
+					Type: design.Object{
+						"id": {Type: design.Integer},
+					},
+				},
+				Headers: &design.AttributeDefinition{
+					Type: design.Object{
+						"X-Request-Id": {Type: design.String},
+					},
+				},
+				Responses: map[string]*design.ResponseDefinition{
+					"OK":       {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+					"NotFound": {Name: "NotFound", Status: 404},
+				},
+			}
+			remove := &design.ActionDefinition{
+				Name: "delete",
+				Routes: []*design.RouteDefinition{{
+					Verb: "DELETE",
+					Path: "/bottles/:id",
+				}},
+				Responses: map[string]*design.ResponseDefinition{
+					"NoContent": {Name: "NoContent", Status: 204},
+				},
+			}
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"bottle": {
+						Name:        "bottle",
+						Description: "Bottles of wine",
+						Actions:     map[string]*design.ActionDefinition{"update": update, "delete": remove},
+					},
+				},
+				MediaTypes: map[string]*design.MediaTypeDefinition{bottle.Identifier: bottle},
+			}
+			for _, a := range []*design.ActionDefinition{update, remove} {
+				a.Parent = design.Design.Resources["bottle"]
+				a.Routes[0].Parent = a
+			}
+		})
+
+		It("generates the service", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(ContainElement(filepath.Join(outDir, "proto", "testapi.proto")))
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "proto", "testapi.proto"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`syntax = "proto3";`))
+			Ω(content).Should(ContainSubstring("package testapi;"))
+			Ω(content).Should(ContainSubstring(`option go_package = "` + testgenPackagePath + `/proto;pb";`))
+			Ω(content).Should(ContainSubstring("// Bottles of wine\nservice BottleService {"))
+			Ω(content).Should(ContainSubstring("  // Update a bottle\n  rpc Update(UpdateBottleRequest) returns (Bottle);"))
+			Ω(content).Should(ContainSubstring("  rpc Delete(DeleteBottleRequest) returns (google.protobuf.Empty);"))
+			Ω(content).Should(ContainSubstring(`import "google/protobuf/empty.proto";`))
+		})
+
+		It("generates the messages", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "proto", "testapi.proto"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`import "google/protobuf/timestamp.proto";`))
+			Ω(content).Should(ContainSubstring("message Bottle {\n  message Winery {\n    string name = 1;\n  }\n"))
+			Ω(content).Should(ContainSubstring(`  google.protobuf.Timestamp created_at = 11 [json_name = "created_at"];`))
+			Ω(content).Should(ContainSubstring("  int64 id = 12;"))
+			Ω(content).Should(ContainSubstring("  double rating = 10;"))
+			Ω(content).Should(ContainSubstring("  repeated string tags = 13;"))
+			Ω(content).Should(ContainSubstring("  Winery winery = 14;"))
+			Ω(content).Should(ContainSubstring("message UpdateBottleRequest {\n" +
+				`  string x_request_id = 1 [json_name = "X-Request-Id"];` + "\n  int64 id = 2;\n  UpdateBottlePayload payload = 3;\n"))
+			Ω(content).Should(ContainSubstring("message UpdateBottlePayload {"))
+		})
+
+		It("does not generate the bridge", func() {
+			Ω(genErr).Should(BeNil())
+			_, err := os.Stat(filepath.Join(outDir, "grpcbridge"))
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
+
+		Context("with the bridge", func() {
+			BeforeEach(func() {
+				args = append(args, "--bridge")
+			})
+
+			It("generates the bridge controllers", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "grpcbridge", "bridge.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring(`pb "` + testgenPackagePath + `/proto"`))
+				Ω(content).Should(ContainSubstring("func NewBottleBridge(service *goa.Service, srv pb.BottleServiceServer) *BottleBridge {"))
+				Ω(content).Should(ContainSubstring("func (b *BottleBridge) Update(ctx *app.UpdateBottleContext) error {"))
+				Ω(content).Should(ContainSubstring(`"X-Request-Id": ctx.XRequestID,`))
+				Ω(content).Should(ContainSubstring(`"payload":      ctx.Payload,`))
+				Ω(content).Should(ContainSubstring(`return writeResponse(ctx.ResponseData, 200, "application/vnd.bottle+json", out, "")`))
+				Ω(content).Should(ContainSubstring("ctx.ResponseData.WriteHeader(204)"))
+			})
+		})
+	})
+})
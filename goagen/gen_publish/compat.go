@@ -0,0 +1,129 @@
+package genpublish
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/goagen/gen_schema"
+)
+
+// Incompatibilities compares two versions of a JSON schema and returns a description of each
+// change that may break the clients of the previous version:
+//
+//   - the type of a value changes
+//   - a property is removed
+//   - a property becomes required
+//   - a value is removed from an enum or an enum is added
+//
+// References to definitions are followed, the definitions must be included in the schemas.
+func Incompatibilities(prev, next []byte) ([]string, error) {
+	var p, n genschema.JSONSchema
+	if err := json.Unmarshal(prev, &p); err != nil {
+		return nil, fmt.Errorf("invalid previous schema: %s", err)
+	}
+	if err := json.Unmarshal(next, &n); err != nil {
+		return nil, fmt.Errorf("invalid schema: %s", err)
+	}
+	c := &comparator{prevDefs: p.Definitions, nextDefs: n.Definitions, seen: make(map[string]bool)}
+	c.compare("", &p, &n)
+	return c.issues, nil
+}
+
+// comparator records the incompatibilities found while comparing two schemas.
+type comparator struct {
+	prevDefs, nextDefs map[string]*genschema.JSONSchema
+	seen               map[string]bool
+	issues             []string
+}
+
+// compare compares the schemas of the value at the given path.
+func (c *comparator) compare(path string, prev, next *genschema.JSONSchema) {
+	if prev == nil || next == nil {
+		return
+	}
+	if prev.Ref != "" || next.Ref != "" {
+		key := path + "|" + prev.Ref + "|" + next.Ref
+		if c.seen[key] {
+			return
+		}
+		c.seen[key] = true
+		c.compare(path, c.resolve(prev, c.prevDefs), c.resolve(next, c.nextDefs))
+		return
+	}
+	at := path
+	if at == "" {
+		at = "root"
+	}
+	if prev.Type != next.Type && prev.Type != "" {
+		c.add("%s: type changed from %s to %s", at, prev.Type, next.Type)
+		return
+	}
+	if len(next.Enum) > 0 {
+		if len(prev.Enum) == 0 {
+			c.add("%s: enum added", at)
+		} else {
+			for _, v := range prev.Enum {
+				if !contains(next.Enum, v) {
+					c.add("%s: enum value %v removed", at, v)
+				}
+			}
+		}
+	}
+	for _, r := range next.Required {
+		if !containsString(prev.Required, r) {
+			c.add("%s: property %q is now required", at, r)
+		}
+	}
+	names := make([]string, 0, len(prev.Properties))
+	for name := range prev.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		np, ok := next.Properties[name]
+		if !ok {
+			c.add("%s: property %q removed", at, name)
+			continue
+		}
+		c.compare(strings.TrimPrefix(path+"."+name, "."), prev.Properties[name], np)
+	}
+	c.compare(path+"[]", prev.Items, next.Items)
+}
+
+// resolve returns the definition s refers to if any, s otherwise.
+func (c *comparator) resolve(s *genschema.JSONSchema, defs map[string]*genschema.JSONSchema) *genschema.JSONSchema {
+	if s.Ref == "" {
+		return s
+	}
+	if d, ok := defs[strings.TrimPrefix(s.Ref, "#/definitions/")]; ok {
+		return d
+	}
+	return nil
+}
+
+// add records an incompatibility.
+func (c *comparator) add(format string, args ...interface{}) {
+	c.issues = append(c.issues, fmt.Sprintf(format, args...))
+}
+
+// contains returns true if vals contains a value equal to v.
+func contains(vals []interface{}, v interface{}) bool {
+	for _, e := range vals {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString returns true if vals contains v.
+func containsString(vals []string, v string) bool {
+	for _, e := range vals {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package genpublish_test
+
+import (
+	"github.com/goadesign/goa/goagen/gen_publish"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Incompatibilities", func() {
+	var prev, next string
+	var issues []string
+
+	JustBeforeEach(func() {
+		var err error
+		issues, err = genpublish.Incompatibilities([]byte(prev), []byte(next))
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Context("with an added optional property", func() {
+		BeforeEach(func() {
+			prev = `{"type":"object","properties":{"name":{"type":"string"}}}`
+			next = `{"type":"object","properties":{"name":{"type":"string"},"vintage":{"type":"integer"}}}`
+		})
+
+		It("is compatible", func() {
+			Ω(issues).Should(BeEmpty())
+		})
+	})
+
+	Context("with breaking changes", func() {
+		BeforeEach(func() {
+			prev = `{"type":"object","properties":{"name":{"type":"string"},"color":{"type":"string","enum":["red","white"]},"years":{"type":"array","items":{"type":"integer"}}}}`
+			next = `{"type":"object","required":["name"],"properties":{"name":{"type":"string"},"color":{"type":"string","enum":["red"]},"years":{"type":"array","items":{"type":"string"}}}}`
+		})
+
+		It("lists them", func() {
+			Ω(issues).Should(ConsistOf(
+				`root: property "name" is now required`,
+				`color: enum value white removed`,
+				`years[]: type changed from integer to string`,
+			))
+		})
+	})
+
+	Context("with changes in referenced definitions", func() {
+		BeforeEach(func() {
+			prev = `{"type":"object","properties":{"winery":{"$ref":"#/definitions/Winery"}},"definitions":{"Winery":{"type":"object","properties":{"name":{"type":"string"}}}}}`
+			next = `{"type":"object","properties":{"winery":{"$ref":"#/definitions/Winery"}},"definitions":{"Winery":{"type":"object","properties":{}}}}`
+		})
+
+		It("follows the references", func() {
+			Ω(issues).Should(ConsistOf(`winery: property "name" removed`))
+		})
+	})
+})
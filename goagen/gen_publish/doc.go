@@ -0,0 +1,14 @@
+/*
+Package genpublish provides a generator that publishes the JSON schemas of the API types and media
+types to a schema registry. Each type is published as a separate subject named after the API and
+the type, e.g. "cellar.Bottle", together with the definitions it refers to. The Protocol Buffers
+files found in the proto directory, if any, are published as well.
+
+The generator supports Confluent compatible registries and plain HTTP registries. Confluent
+registries check the compatibility of the schemas with the latest published version of their
+subject. Plain HTTP registries store the schemas at the URL made of the registry URL and the
+subject name, the generator retrieves the previously published schema and checks the compatibility
+locally, see Incompatibilities. No schema is published if any of the new schemas is incompatible
+with its previous version.
+*/
+package genpublish
@@ -0,0 +1,13 @@
+package genpublish_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenPublish(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenPublish Suite")
+}
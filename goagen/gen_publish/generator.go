@@ -0,0 +1,189 @@
+package genpublish
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_schema"
+)
+
+// Generator is the schema registry publisher.
+type Generator struct {
+	outDir   string // Path to output directory
+	registry string // URL of the schema registry
+	kind     string // Kind of registry, "confluent" or "http"
+	prefix   string // Prefix of the subject names
+	protoDir string // Path to the directory containing the Protocol Buffers files
+	force    bool   // Whether to publish schemas that fail the compatibility check
+}
+
+// refRegex matches the JSON schema references to definitions.
+var refRegex = regexp.MustCompile(`"\$ref":"#/definitions/([^"]+)"`)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, registry, kind, prefix, protoDir string
+	var force bool
+	set := flag.NewFlagSet("publish", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.String("design", "", "")
+	set.StringVar(&registry, "registry", "", "")
+	set.StringVar(&kind, "kind", "confluent", "")
+	set.StringVar(&prefix, "subject-prefix", "", "")
+	set.StringVar(&protoDir, "proto-dir", "", "")
+	set.BoolVar(&force, "force", false, "")
+	set.Parse(os.Args[2:])
+
+	g := &Generator{outDir: outDir, registry: registry, kind: kind, prefix: prefix, protoDir: protoDir, force: force}
+
+	return g.Generate(design.Design)
+}
+
+// Generate publishes the API schemas. It does not produce any file.
+func (g *Generator) Generate(api *design.APIDefinition) ([]string, error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
+	}
+	if g.registry == "" {
+		return nil, fmt.Errorf("missing schema registry URL, set it with --registry")
+	}
+	reg, err := NewRegistry(g.kind, g.registry)
+	if err != nil {
+		return nil, err
+	}
+	prefix := g.prefix
+	if prefix == "" {
+		prefix = codegen.SnakeCase(api.Name)
+	}
+	schemas, err := Schemas(api, prefix)
+	if err != nil {
+		return nil, err
+	}
+	protoDir := g.protoDir
+	if protoDir == "" {
+		protoDir = filepath.Join(g.outDir, "proto")
+	}
+	protos, err := ProtoSchemas(protoDir, prefix)
+	if err != nil {
+		return nil, err
+	}
+	schemas = append(schemas, protos...)
+
+	if !g.force {
+		var problems []string
+		for _, s := range schemas {
+			issues, err := reg.Check(s)
+			if err != nil {
+				return nil, err
+			}
+			for _, i := range issues {
+				problems = append(problems, fmt.Sprintf("%s: %s", s.Subject, i))
+			}
+		}
+		if len(problems) > 0 {
+			return nil, fmt.Errorf("incompatible schema changes, nothing was published:\n%s", strings.Join(problems, "\n"))
+		}
+	}
+	for _, s := range schemas {
+		if err := reg.Publish(s); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// Schemas returns the JSON schemas of the API user types and media types. Each schema contains
+// the definitions it refers to so that it can be used on its own.
+func Schemas(api *design.APIDefinition, prefix string) ([]*Schema, error) {
+	genschema.APISchema(api)
+	api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		genschema.TypeRef(api, ut)
+		return nil
+	})
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		genschema.MediaTypeRef(api, mt)
+		return nil
+	})
+	names := make([]string, 0, len(genschema.Definitions))
+	for n := range genschema.Definitions {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	schemas := make([]*Schema, len(names))
+	for i, n := range names {
+		doc := *genschema.Definitions[n]
+		doc.Definitions = make(map[string]*genschema.JSONSchema)
+		refs, err := referencedDefinitions(n)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range refs {
+			doc.Definitions[r] = genschema.Definitions[r]
+		}
+		content, err := doc.JSON()
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = &Schema{Subject: prefix + "." + n, Type: JSONSchemaType, Content: string(content)}
+	}
+	return schemas, nil
+}
+
+// ProtoSchemas returns the schemas of the Protocol Buffers files found in dir. It returns no
+// schema if dir does not exist.
+func ProtoSchemas(dir, prefix string) ([]*Schema, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.proto"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	schemas := make([]*Schema, len(files))
+	for i, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(f), ".proto")
+		schemas[i] = &Schema{Subject: prefix + "." + name, Type: ProtobufType, Content: string(b)}
+	}
+	return schemas, nil
+}
+
+// referencedDefinitions returns the names of the definitions the definition with the given name
+// refers to directly or indirectly.
+func referencedDefinitions(name string) ([]string, error) {
+	seen := map[string]bool{}
+	todo := []string{name}
+	for len(todo) > 0 {
+		def, ok := genschema.Definitions[todo[0]]
+		todo = todo[1:]
+		if !ok {
+			continue
+		}
+		b, err := json.Marshal(def)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range refRegex.FindAllStringSubmatch(string(b), -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				todo = append(todo, m[1])
+			}
+		}
+	}
+	refs := make([]string, 0, len(seen))
+	for r := range seen {
+		refs = append(refs, r)
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
@@ -0,0 +1,198 @@
+package genpublish_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_publish"
+	"github.com/goadesign/goa/goagen/gen_schema"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var outDir string
+	var kind string
+	var server *httptest.Server
+	var mu sync.Mutex
+	var published map[string]string
+	var handler http.HandlerFunc
+	var genErr error
+
+	BeforeEach(func() {
+		var err error
+		outDir, err = ioutil.TempDir("", "publish")
+		Ω(err).ShouldNot(HaveOccurred())
+		published = make(map[string]string)
+		genschema.Definitions = make(map[string]*genschema.JSONSchema)
+		dslengine.Reset()
+		API("cellar", func() {})
+		var Winery = Type("Winery", func() {
+			Attribute("name", String)
+		})
+		MediaType("application/vnd.bottle", func() {
+			TypeName("Bottle")
+			Attributes(func() {
+				Attribute("name", String)
+				Attribute("winery", Winery)
+			})
+			View("default", func() {
+				Attribute("name")
+				Attribute("winery")
+			})
+		})
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		server = httptest.NewServer(handler)
+		os.Args = []string{"goagen", "publish", "--out=" + outDir, "--design=foo", "--registry=" + server.URL, "--kind=" + kind}
+		_, genErr = genpublish.Generate()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(outDir)
+	})
+
+	Context("with a Confluent registry", func() {
+		var checked []string
+
+		BeforeEach(func() {
+			kind = "confluent"
+			checked = nil
+			handler = func(rw http.ResponseWriter, req *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				var body map[string]string
+				json.NewDecoder(req.Body).Decode(&body)
+				switch {
+				case strings.HasPrefix(req.URL.Path, "/compatibility/subjects/"):
+					checked = append(checked, strings.Split(req.URL.Path, "/")[3])
+					rw.WriteHeader(404)
+					rw.Write([]byte(`{"error_code":40401,"message":"Subject not found"}`))
+				case strings.HasPrefix(req.URL.Path, "/subjects/"):
+					Ω(body["schemaType"]).Should(Equal("JSON"))
+					published[strings.Split(req.URL.Path, "/")[2]] = body["schema"]
+					rw.Write([]byte(`{"id":1}`))
+				default:
+					rw.WriteHeader(400)
+				}
+			}
+		})
+
+		It("publishes one subject per type", func() {
+			Ω(genErr).ShouldNot(HaveOccurred())
+			Ω(checked).Should(ConsistOf("cellar.Bottle", "cellar.Winery"))
+			Ω(published).Should(HaveLen(2))
+			Ω(published).Should(HaveKey("cellar.Winery"))
+		})
+
+		It("includes the referenced definitions", func() {
+			var doc genschema.JSONSchema
+			Ω(json.Unmarshal([]byte(published["cellar.Bottle"]), &doc)).ShouldNot(HaveOccurred())
+			Ω(doc.Schema).Should(Equal(genschema.SchemaRef))
+			Ω(doc.Definitions).Should(HaveKey("Winery"))
+			Ω(doc.Properties["winery"].Ref).Should(Equal("#/definitions/Winery"))
+		})
+
+		Context("with proto files", func() {
+			BeforeEach(func() {
+				dir := filepath.Join(outDir, "proto")
+				Ω(os.MkdirAll(dir, 0755)).ShouldNot(HaveOccurred())
+				Ω(ioutil.WriteFile(filepath.Join(dir, "cellar.proto"), []byte(`syntax = "proto3";`), 0644)).ShouldNot(HaveOccurred())
+				next := handler
+				handler = func(rw http.ResponseWriter, req *http.Request) {
+					if req.URL.Path == "/subjects/cellar.cellar/versions" {
+						var body map[string]string
+						json.NewDecoder(req.Body).Decode(&body)
+						Ω(body["schemaType"]).Should(Equal("PROTOBUF"))
+						mu.Lock()
+						published["cellar.cellar"] = body["schema"]
+						mu.Unlock()
+						rw.Write([]byte(`{"id":2}`))
+						return
+					}
+					next(rw, req)
+				}
+			})
+
+			It("publishes them", func() {
+				Ω(genErr).ShouldNot(HaveOccurred())
+				Ω(published).Should(HaveKeyWithValue("cellar.cellar", `syntax = "proto3";`))
+			})
+		})
+
+		Context("with incompatible changes", func() {
+			BeforeEach(func() {
+				handler = func(rw http.ResponseWriter, req *http.Request) {
+					mu.Lock()
+					defer mu.Unlock()
+					if strings.HasPrefix(req.URL.Path, "/compatibility/") {
+						rw.Write([]byte(`{"is_compatible":false,"messages":["property removed"]}`))
+						return
+					}
+					published[req.URL.Path] = ""
+				}
+			})
+
+			It("does not publish anything", func() {
+				Ω(genErr).Should(HaveOccurred())
+				Ω(genErr.Error()).Should(ContainSubstring("cellar.Bottle: property removed"))
+				Ω(published).Should(BeEmpty())
+			})
+		})
+	})
+
+	Context("with a plain HTTP registry", func() {
+		var previous map[string]string
+
+		BeforeEach(func() {
+			kind = "http"
+			previous = make(map[string]string)
+			handler = func(rw http.ResponseWriter, req *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				subject := strings.TrimPrefix(req.URL.Path, "/")
+				switch req.Method {
+				case "GET":
+					if s, ok := previous[subject]; ok {
+						rw.Write([]byte(s))
+						return
+					}
+					rw.WriteHeader(404)
+				case "PUT":
+					b, _ := ioutil.ReadAll(req.Body)
+					published[subject] = string(b)
+					rw.WriteHeader(201)
+				}
+			}
+		})
+
+		It("publishes the schemas", func() {
+			Ω(genErr).ShouldNot(HaveOccurred())
+			Ω(published).Should(HaveLen(2))
+		})
+
+		Context("with a previous version that has more properties", func() {
+			BeforeEach(func() {
+				previous["cellar.Winery"] = `{"type":"object","properties":{"name":{"type":"string"},"country":{"type":"string"}}}`
+			})
+
+			It("fails the compatibility check", func() {
+				Ω(genErr).Should(HaveOccurred())
+				Ω(genErr.Error()).Should(ContainSubstring(`cellar.Winery: root: property "country" removed`))
+				Ω(published).Should(BeEmpty())
+			})
+		})
+	})
+})
@@ -0,0 +1,210 @@
+package genpublish
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Schema types as defined by the Confluent schema registry API.
+const (
+	// JSONSchemaType is the type of JSON schemas.
+	JSONSchemaType = "JSON"
+	// ProtobufType is the type of Protocol Buffers schemas.
+	ProtobufType = "PROTOBUF"
+)
+
+// confluentContentType is the content type of the Confluent schema registry API requests.
+const confluentContentType = "application/vnd.schemaregistry.v1+json"
+
+type (
+	// Schema is a schema published to a registry.
+	Schema struct {
+		// Subject is the name under which the schema is registered.
+		Subject string
+		// Type is the schema type, JSONSchemaType or ProtobufType.
+		Type string
+		// Content is the schema document.
+		Content string
+	}
+
+	// Registry is the interface implemented by the schema registry clients.
+	Registry interface {
+		// Check returns the reasons why the schema is not compatible with the latest
+		// version published under the same subject, if any.
+		Check(s *Schema) ([]string, error)
+		// Publish registers the schema as the latest version of its subject.
+		Publish(s *Schema) error
+	}
+
+	// confluentRegistry is a client of Confluent compatible schema registries.
+	confluentRegistry struct {
+		url    *url.URL
+		client *http.Client
+	}
+
+	// httpRegistry stores the schemas at the registry URL followed by the subject name.
+	httpRegistry struct {
+		url    *url.URL
+		client *http.Client
+	}
+
+	// confluentError is the body of the Confluent schema registry error responses.
+	confluentError struct {
+		ErrorCode int    `json:"error_code"`
+		Message   string `json:"message"`
+	}
+)
+
+// NewRegistry returns the client for the registry of the given kind, "confluent" or "http",
+// located at the given URL. Credentials may be given in the URL user information, they are sent
+// using basic authentication.
+func NewRegistry(kind, rawURL string) (Registry, error) {
+	u, err := url.Parse(strings.TrimSuffix(rawURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid schema registry URL %#v", rawURL)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch kind {
+	case "confluent":
+		return &confluentRegistry{url: u, client: client}, nil
+	case "http":
+		return &httpRegistry{url: u, client: client}, nil
+	default:
+		return nil, fmt.Errorf(`invalid registry kind %#v, must be "confluent" or "http"`, kind)
+	}
+}
+
+// Check uses the registry compatibility endpoint, subjects that were never published are always
+// compatible.
+func (r *confluentRegistry) Check(s *Schema) ([]string, error) {
+	var res struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages"`
+	}
+	path := fmt.Sprintf("/compatibility/subjects/%s/versions/latest?verbose=true", url.PathEscape(s.Subject))
+	status, err := r.do(path, s, &res)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if res.IsCompatible {
+		return nil, nil
+	}
+	if len(res.Messages) == 0 {
+		return []string{"incompatible with the latest version"}, nil
+	}
+	return res.Messages, nil
+}
+
+// Publish registers a new version of the subject. The registry returns the existing version if
+// the schema is already registered.
+func (r *confluentRegistry) Publish(s *Schema) error {
+	var res struct {
+		ID int `json:"id"`
+	}
+	_, err := r.do(fmt.Sprintf("/subjects/%s/versions", url.PathEscape(s.Subject)), s, &res)
+	return err
+}
+
+// do posts the schema to the given registry path and decodes the response into res. It returns
+// the response status code and an error if the request failed.
+func (r *confluentRegistry) do(path string, s *Schema, res interface{}) (int, error) {
+	body, err := json.Marshal(map[string]string{"schemaType": s.Type, "schema": s.Content})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest("POST", r.url.String()+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", confluentContentType)
+	req.Header.Set("Accept", confluentContentType)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 300 {
+		var cerr confluentError
+		if json.Unmarshal(b, &cerr) == nil && cerr.Message != "" {
+			return resp.StatusCode, fmt.Errorf("schema registry error for %s: %s (%d)", s.Subject, cerr.Message, cerr.ErrorCode)
+		}
+		return resp.StatusCode, fmt.Errorf("schema registry error for %s: %s", s.Subject, resp.Status)
+	}
+	return resp.StatusCode, json.Unmarshal(b, res)
+}
+
+// Check retrieves the latest published version of the schema and compares it with s. Only JSON
+// schemas are checked.
+func (r *httpRegistry) Check(s *Schema) ([]string, error) {
+	if s.Type != JSONSchemaType {
+		return nil, nil
+	}
+	prev, err := r.latest(s.Subject)
+	if err != nil || prev == nil {
+		return nil, err
+	}
+	return Incompatibilities(prev, []byte(s.Content))
+}
+
+// Publish stores the schema unless it is identical to the latest published version.
+func (r *httpRegistry) Publish(s *Schema) error {
+	prev, err := r.latest(s.Subject)
+	if err != nil {
+		return err
+	}
+	if prev != nil && string(prev) == s.Content {
+		return nil
+	}
+	req, err := http.NewRequest("PUT", r.subjectURL(s.Subject), strings.NewReader(s.Content))
+	if err != nil {
+		return err
+	}
+	if s.Type == JSONSchemaType {
+		req.Header.Set("Content-Type", "application/schema+json")
+	} else {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("schema registry error for %s: %s", s.Subject, resp.Status)
+	}
+	return nil
+}
+
+// latest returns the latest published version of the subject schema, nil if there is none.
+func (r *httpRegistry) latest(subject string) ([]byte, error) {
+	resp, err := r.client.Get(r.subjectURL(subject))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("schema registry error for %s: %s", subject, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// subjectURL returns the URL of the subject schema.
+func (r *httpRegistry) subjectURL(subject string) string {
+	return r.url.String() + "/" + url.PathEscape(subject)
+}
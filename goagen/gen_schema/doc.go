@@ -5,5 +5,9 @@ This JSON schema can be used to generate API documentation, ruby and Go API clie
 See the blog post (https://blog.heroku.com/archives/2014/1/8/json_schema_for_heroku_platform_api)
 describing how Heroku leverages the JSON Hyper-schema standard (http://json-schema.org/latest/json-schema-hypermedia.html)
 for more information.
+
+The generator produces draft 7 schemas by default, the --draft flag selects draft 4 instead. The
+--split flag also produces one schema file per user type and media type whose references to other
+types point to the files of these types.
 */
 package genschema
@@ -0,0 +1,129 @@
+package genschema
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Draft identifies the version of the JSON schema specification used to serialize schemas.
+type Draft string
+
+const (
+	// Draft04 is the JSON schema draft 4, the draft used by the Swagger specification.
+	Draft04 Draft = "04"
+	// Draft07 is the JSON schema draft 7.
+	Draft07 Draft = "07"
+)
+
+// SchemaRef returns the href of the hyper-schema of the draft.
+func (d Draft) SchemaRef() string {
+	if d == Draft07 {
+		return "http://json-schema.org/draft-07/hyper-schema#"
+	}
+	return SchemaRef
+}
+
+// Validate returns an error if the draft is not supported.
+func (d Draft) Validate() error {
+	if d != Draft04 && d != Draft07 {
+		return fmt.Errorf("unsupported JSON schema draft %q, must be %q or %q", d, Draft04, Draft07)
+	}
+	return nil
+}
+
+// DraftJSON serializes the schema into JSON using the keywords of the given draft. The JSONSchema
+// struct uses the draft 4 keywords, draft 7 renames "id" to "$id" and the hyper-schema link
+// keywords.
+func (s *JSONSchema) DraftJSON(d Draft) ([]byte, error) {
+	return s.marshal(d, "", nil)
+}
+
+// marshal serializes the schema with the keywords of the given draft. id overrides the schema
+// identifier if not empty and ref, if not nil, computes the new value of each "$ref" keyword.
+func (s *JSONSchema) marshal(d Draft, id string, ref func(string) string) ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	if _, ok := m["$ref"]; !ok {
+		m["$schema"] = SchemaRef
+	}
+	if id != "" {
+		m["id"] = id
+	}
+	convertSchema(m, d, ref)
+	return json.Marshal(m)
+}
+
+// convertSchema converts the keywords of the given decoded draft 4 schema to the given draft.
+func convertSchema(m map[string]interface{}, d Draft, ref func(string) string) {
+	if v, ok := m["$schema"].(string); ok && v == SchemaRef {
+		m["$schema"] = d.SchemaRef()
+	}
+	if v, ok := m["$ref"].(string); ok && ref != nil {
+		m["$ref"] = ref(v)
+	}
+	if d == Draft07 {
+		rename(m, "id", "$id")
+	}
+	if items, ok := m["items"].(map[string]interface{}); ok {
+		convertSchema(items, d, ref)
+	}
+	for _, key := range []string{"properties", "definitions"} {
+		if schemas, ok := m[key].(map[string]interface{}); ok {
+			for _, s := range schemas {
+				if s, ok := s.(map[string]interface{}); ok {
+					convertSchema(s, d, ref)
+				}
+			}
+		}
+	}
+	if anyOf, ok := m["anyOf"].([]interface{}); ok {
+		for _, s := range anyOf {
+			if s, ok := s.(map[string]interface{}); ok {
+				convertSchema(s, d, ref)
+			}
+		}
+	}
+	if links, ok := m["links"].([]interface{}); ok {
+		for _, l := range links {
+			if l, ok := l.(map[string]interface{}); ok {
+				convertLink(l, d, ref)
+			}
+		}
+	}
+}
+
+// convertLink converts the keywords of the given decoded draft 4 link to the given draft.
+func convertLink(l map[string]interface{}, d Draft, ref func(string) string) {
+	for _, key := range []string{"schema", "targetSchema"} {
+		if s, ok := l[key].(map[string]interface{}); ok {
+			convertSchema(s, d, ref)
+		}
+	}
+	if d != Draft07 {
+		return
+	}
+	rename(l, "schema", "submissionSchema")
+	rename(l, "encType", "submissionMediaType")
+	rename(l, "mediaType", "targetMediaType")
+	if method, ok := l["method"]; ok {
+		delete(l, "method")
+		l["targetHints"] = map[string]interface{}{"allow": []interface{}{method}}
+	}
+}
+
+// rename renames the key of the given map if present.
+func rename(m map[string]interface{}, from, to string) {
+	if v, ok := m[from]; ok {
+		delete(m, from)
+		m[to] = v
+	}
+}
@@ -2,11 +2,13 @@ package genschema
 
 import (
 	"flag"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/utils"
 )
 
@@ -14,22 +16,28 @@ import (
 type Generator struct {
 	genfiles []string // Generated files
 	outDir   string   // Path to output directory
+	draft    Draft    // JSON schema draft of the generated schemas
+	split    bool     // Whether to generate one schema file per type
 }
 
 // Generate is the generator entry point called by the meta generator.
 func Generate() (files []string, err error) {
-	var outDir string
+	var outDir, draft string
+	var split bool
 	set := flag.NewFlagSet("app", flag.PanicOnError)
 	set.StringVar(&outDir, "out", "", "")
 	set.String("design", "", "")
+	set.StringVar(&draft, "draft", string(Draft07), "")
+	set.BoolVar(&split, "split", false, "")
 	set.Parse(os.Args[2:])
 
-	g := &Generator{outDir: outDir}
+	g := &Generator{outDir: outDir, draft: Draft(draft), split: split}
 
 	return g.Generate(design.Design)
 }
 
-// Generate produces the skeleton main.
+// Generate produces the API JSON hyper schema and, if split is set, one schema file per user type
+// and media type.
 func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
 	go utils.Catch(nil, func() { g.Cleanup() })
 
@@ -40,22 +48,77 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 	}()
 
 	s := APISchema(api)
-	js, err := s.JSON()
+	js, err := s.DraftJSON(g.draft)
 	if err != nil {
 		return
 	}
 
 	g.outDir = filepath.Join(g.outDir, "schema")
-	os.RemoveAll(g.outDir)
+	render, err := codegen.BeginRender(g.outDir)
+	if err != nil {
+		return
+	}
+	defer render.Discard()
 	os.MkdirAll(g.outDir, 0755)
 	g.genfiles = append(g.genfiles, g.outDir)
 	schemaFile := filepath.Join(g.outDir, "schema.json")
-	if err = ioutil.WriteFile(schemaFile, js, 0644); err != nil {
+	if err = codegen.WriteFile(schemaFile, js, 0644); err != nil {
 		return
 	}
 	g.genfiles = append(g.genfiles, schemaFile)
+	if g.split {
+		if err = g.generateTypeSchemas(api); err != nil {
+			return
+		}
+	}
+
+	return g.genfiles, render.Commit()
+}
+
+// generateTypeSchemas writes the schema of each user type and media type in its own file. The
+// references to other types point to the files of these types so that the schemas may be used
+// independently of the API schema.
+func (g *Generator) generateTypeSchemas(api *design.APIDefinition) error {
+	names := make(map[string]bool)
+	api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		GenerateTypeDefinition(api, ut)
+		names[ut.TypeName] = true
+		return nil
+	})
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		GenerateMediaTypeDefinition(api, mt)
+		names[mt.TypeName] = true
+		return nil
+	})
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+	ref := func(r string) string {
+		if strings.HasPrefix(r, "#/definitions/") {
+			return TypeSchemaFile(strings.TrimPrefix(r, "#/definitions/"))
+		}
+		return r
+	}
+	for _, n := range sorted {
+		js, err := Definitions[n].marshal(g.draft, TypeSchemaFile(n), ref)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(g.outDir, TypeSchemaFile(n))
+		if err := codegen.WriteFile(file, js, 0644); err != nil {
+			return err
+		}
+		g.genfiles = append(g.genfiles, file)
+	}
+	return nil
+}
 
-	return g.genfiles, nil
+// TypeSchemaFile returns the name of the file holding the schema of the type with the given name
+// when the schemas are split.
+func TypeSchemaFile(typeName string) string {
+	return typeName + ".json"
 }
 
 // Cleanup removes all the files generated by this generator during the last invokation of Generate.
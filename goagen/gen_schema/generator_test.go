@@ -56,5 +56,77 @@ var _ = Describe("Generate", func() {
 			err = json.Unmarshal(content, &s)
 			Ω(err).ShouldNot(HaveOccurred())
 		})
+
+		It("uses draft 7", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "schema", "schema.json"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`"$schema":"http://json-schema.org/draft-07/hyper-schema#"`))
+			Ω(content).Should(ContainSubstring(`"$id":"http:/schema"`))
+			Ω(content).Should(ContainSubstring(`"targetHints":{"allow":["GET"]}`))
+		})
+
+		Context("with draft 4", func() {
+			BeforeEach(func() {
+				os.Args = append(os.Args, "--draft=04")
+			})
+
+			It("uses draft 4", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "schema", "schema.json"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring(`"$schema":"http://json-schema.org/draft-04/hyper-schema"`))
+				Ω(content).Should(ContainSubstring(`"id":"http:/schema"`))
+				Ω(content).Should(ContainSubstring(`"method":"GET"`))
+			})
+		})
+
+		Context("with an unsupported draft", func() {
+			BeforeEach(func() {
+				os.Args = append(os.Args, "--draft=06")
+			})
+
+			It("returns an error", func() {
+				Ω(genErr).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("with split schemas", func() {
+		BeforeEach(func() {
+			genschema.Definitions = make(map[string]*genschema.JSONSchema)
+			winery := &design.UserTypeDefinition{
+				TypeName: "Winery",
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"name": {Type: design.String}},
+				},
+			}
+			bottle := &design.UserTypeDefinition{
+				TypeName: "Bottle",
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"id":     {Type: design.Integer},
+						"winery": {Type: winery},
+					},
+				},
+			}
+			design.Design = &design.APIDefinition{
+				Name:  "test api",
+				Types: map[string]*design.UserTypeDefinition{"Bottle": bottle, "Winery": winery},
+			}
+			os.Args = append(os.Args, "--split")
+		})
+
+		It("generates one file per type", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(ContainElement(filepath.Join(testPkg.Abs(), "schema", "Bottle.json")))
+			Ω(files).Should(ContainElement(filepath.Join(testPkg.Abs(), "schema", "Winery.json")))
+			content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "schema", "Bottle.json"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`"$id":"Bottle.json"`))
+			Ω(content).Should(ContainSubstring(`"$schema":"http://json-schema.org/draft-07/hyper-schema#"`))
+			Ω(content).Should(ContainSubstring(`"winery":{"$ref":"Winery.json"}`))
+			Ω(content).Should(ContainSubstring(`"id":{"format":"int64","type":"integer"}`))
+		})
 	})
 })
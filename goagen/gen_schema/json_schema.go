@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
 )
 
 type (
@@ -46,6 +49,10 @@ type (
 
 		// Union
 		AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+
+		// Extensions lists the vendor extensions ("x-" fields) of the schema, see
+		// VendorExtensions.
+		Extensions map[string]interface{} `json:"-"`
 	}
 
 	// JSONType is the JSON type enum.
@@ -112,6 +119,63 @@ func NewJSONSchema() *JSONSchema {
 	return &js
 }
 
+// MarshalJSON serializes the schema into JSON including its vendor extensions.
+func (s JSONSchema) MarshalJSON() ([]byte, error) {
+	type schema JSONSchema // prevents infinite recursion
+	return MarshalWithExtensions(schema(s), s.Extensions)
+}
+
+// VendorExtensions returns the vendor extensions defined by the "swagger:extension:x-xxx"
+// metadata. The values that are valid JSON are decoded so that numbers, booleans, arrays and
+// objects are rendered as is, the other values are rendered as strings. The keys that do not start
+// with "x-" are ignored. VendorExtensions returns nil if there is no extension.
+func VendorExtensions(mdata dslengine.MetadataDefinition) map[string]interface{} {
+	var extensions map[string]interface{}
+	for key, values := range mdata {
+		if !strings.HasPrefix(key, "swagger:extension:") || len(values) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(key, "swagger:extension:")
+		if !strings.HasPrefix(name, "x-") {
+			continue
+		}
+		vals := make([]interface{}, len(values))
+		for i, v := range values {
+			var val interface{}
+			if err := json.Unmarshal([]byte(v), &val); err != nil {
+				val = v
+			}
+			vals[i] = val
+		}
+		if extensions == nil {
+			extensions = make(map[string]interface{})
+		}
+		if len(vals) == 1 {
+			extensions[name] = vals[0]
+		} else {
+			extensions[name] = vals
+		}
+	}
+	return extensions
+}
+
+// MarshalWithExtensions serializes v into JSON and adds the given vendor extensions to the
+// resulting object.
+func MarshalWithExtensions(v interface{}, extensions map[string]interface{}) ([]byte, error) {
+	js, err := json.Marshal(v)
+	if err != nil || len(extensions) == 0 {
+		return js, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(js, &m); err != nil {
+		return nil, err
+	}
+	for k, ext := range extensions {
+		m[k] = ext
+	}
+	return json.Marshal(m)
+}
+
 // JSON serializes the schema into JSON.
 // It makes sure the "$schema" standard field is set if needed prior to delegating to the standard
 // JSON marshaler.
@@ -189,7 +253,7 @@ func GenerateResourceDefinition(api *design.APIDefinition, r *design.ResourceDef
 		}
 		var targetSchema *JSONSchema
 		var identifier string
-		for _, resp := range a.Responses {
+		a.IterateResponses(func(resp *design.ResponseDefinition) error {
 			if mt, ok := api.MediaTypes[resp.MediaType]; ok {
 				if identifier == "" {
 					identifier = mt.Identifier
@@ -206,7 +270,8 @@ func GenerateResourceDefinition(api *design.APIDefinition, r *design.ResourceDef
 					targetSchema.AnyOf = append(targetSchema.AnyOf, TypeSchema(api, mt))
 				}
 			}
-		}
+			return nil
+		})
 		for i, r := range a.Routes {
 			link := JSONLink{
 				Title:        a.Name,
@@ -405,6 +470,7 @@ func buildAttributeSchema(api *design.APIDefinition, s *JSONSchema, at *design.A
 	s.DefaultValue = toStringMap(at.DefaultValue)
 	s.Description = at.Description
 	s.Example = at.Example
+	s.Extensions = VendorExtensions(at.Metadata)
 	val := at.Validation
 	if val == nil {
 		return s
@@ -500,6 +566,7 @@ func buildMediaTypeSchema(api *design.APIDefinition, mt *design.MediaTypeDefinit
 		lnames[i] = n
 		i++
 	}
+	sort.Strings(lnames)
 	for _, ln := range lnames {
 		l := mt.Links[ln]
 		att := l.Attribute() // cannot be nil if DSL validated
@@ -3,13 +3,13 @@ package genswagger
 import (
 	"encoding/json"
 	"flag"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/utils"
 )
 
@@ -17,22 +17,44 @@ import (
 type Generator struct {
 	genfiles []string // Generated files
 	outDir   string   // Path to output directory
+	split    bool     // Whether to generate one spec per resource
+	validate bool     // Whether to validate the specs before writing them
+}
+
+// Index lists the specs generated for each resource when the output is split. Its format is the
+// format of the Swagger UI configuration so that it can be given to the UI "configUrl" option.
+type Index struct {
+	// URLs lists the specs.
+	URLs []*IndexEntry `json:"urls"`
+}
+
+// IndexEntry describes a spec listed in the index.
+type IndexEntry struct {
+	// URL of the spec relative to the index.
+	URL string `json:"url"`
+	// Name is the name of the resource described by the spec.
+	Name string `json:"name"`
 }
 
 // Generate is the generator entry point called by the meta generator.
 func Generate() (files []string, err error) {
 	var outDir string
+	var split bool
 	set := flag.NewFlagSet("swagger", flag.PanicOnError)
 	set.StringVar(&outDir, "out", "", "")
 	set.String("design", "", "")
+	var validate bool
+	set.BoolVar(&split, "split", false, "")
+	set.BoolVar(&validate, "validate", true, "")
 	set.Parse(os.Args[2:])
 
-	g := &Generator{outDir: outDir}
+	g := &Generator{outDir: outDir, split: split, validate: validate}
 
 	return g.Generate(design.Design)
 }
 
-// Generate produces the skeleton main.
+// Generate produces the swagger JSON and YAML specs and, if split is set, the specs of each
+// resource together with the index listing them.
 func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
 	go utils.Catch(nil, func() { g.Cleanup() })
 
@@ -46,42 +68,97 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 	if err != nil {
 		return nil, err
 	}
+	if g.validate {
+		if err = s.Validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	swaggerDir := filepath.Join(g.outDir, "swagger")
-	os.RemoveAll(swaggerDir)
+	render, err := codegen.BeginRender(swaggerDir)
+	if err != nil {
+		return nil, err
+	}
+	defer render.Discard()
 	if err = os.MkdirAll(swaggerDir, 0755); err != nil {
 		return nil, err
 	}
 	g.genfiles = append(g.genfiles, swaggerDir)
 
+	if err = g.writeSpec(swaggerDir, "swagger", s); err != nil {
+		return nil, err
+	}
+	if g.split {
+		resourcesDir := filepath.Join(swaggerDir, "resources")
+		if err = os.MkdirAll(resourcesDir, 0755); err != nil {
+			return nil, err
+		}
+		g.genfiles = append(g.genfiles, resourcesDir)
+		index := &Index{}
+		err = api.IterateResources(func(res *design.ResourceDefinition) error {
+			rs, err := NewResource(api, res)
+			if err != nil {
+				return err
+			}
+			if g.validate {
+				if err := rs.Validate(); err != nil {
+					return err
+				}
+			}
+			name := codegen.SnakeCase(codegen.Goify(res.Name, true))
+			if err := g.writeSpec(resourcesDir, name, rs); err != nil {
+				return err
+			}
+			index.URLs = append(index.URLs, &IndexEntry{URL: "resources/" + name + ".json", Name: res.Name})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		rawJSON, err := json.Marshal(index)
+		if err != nil {
+			return nil, err
+		}
+		indexFile := filepath.Join(swaggerDir, "index.json")
+		if err := codegen.WriteFile(indexFile, rawJSON, 0644); err != nil {
+			return nil, err
+		}
+		g.genfiles = append(g.genfiles, indexFile)
+	}
+
+	return g.genfiles, render.Commit()
+}
+
+// writeSpec writes the JSON and YAML serializations of the given spec in the files with the given
+// base name.
+func (g *Generator) writeSpec(dir, base string, s *Swagger) error {
 	// JSON
 	rawJSON, err := json.Marshal(s)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	swaggerFile := filepath.Join(swaggerDir, "swagger.json")
-	if err := ioutil.WriteFile(swaggerFile, rawJSON, 0644); err != nil {
-		return nil, err
+	swaggerFile := filepath.Join(dir, base+".json")
+	if err := codegen.WriteFile(swaggerFile, rawJSON, 0644); err != nil {
+		return err
 	}
 	g.genfiles = append(g.genfiles, swaggerFile)
 
 	// YAML
 	var yamlSource interface{}
 	if err = json.Unmarshal(rawJSON, &yamlSource); err != nil {
-		return nil, err
+		return err
 	}
 
 	rawYAML, err := yaml.Marshal(yamlSource)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	swaggerFile = filepath.Join(swaggerDir, "swagger.yaml")
-	if err := ioutil.WriteFile(swaggerFile, rawYAML, 0644); err != nil {
-		return nil, err
+	swaggerFile = filepath.Join(dir, base+".yaml")
+	if err := codegen.WriteFile(swaggerFile, rawYAML, 0644); err != nil {
+		return err
 	}
 	g.genfiles = append(g.genfiles, swaggerFile)
-
-	return g.genfiles, nil
+	return nil
 }
 
 // Cleanup removes all the files generated by this generator during the last invokation of Generate.
@@ -1,6 +1,7 @@
 package genswagger
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -28,7 +29,10 @@ type (
 		Responses           map[string]*Response             `json:"responses,omitempty"`
 		SecurityDefinitions map[string]*SecurityDefinition   `json:"securityDefinitions,omitempty"`
 		Tags                []*Tag                           `json:"tags,omitempty"`
+		TagGroups           []*TagGroup                      `json:"x-tagGroups,omitempty"`
 		ExternalDocs        *ExternalDocs                    `json:"externalDocs,omitempty"`
+		// Extensions lists the vendor extensions defined by the API metadata.
+		Extensions map[string]interface{} `json:"-"`
 	}
 
 	// Info provides metadata about the API. The metadata can be used by the clients if needed,
@@ -63,6 +67,8 @@ type (
 		// Parameters is the list of parameters that are applicable for all the operations
 		// described under this path.
 		Parameters []*Parameter `json:"parameters,omitempty"`
+		// Extensions lists the vendor extensions defined by the resource metadata.
+		Extensions map[string]interface{} `json:"-"`
 	}
 
 	// Operation describes a single API operation on a path.
@@ -95,6 +101,8 @@ type (
 		Deprecated bool `json:"deprecated,omitempty"`
 		// Secury is a declaration of which security schemes are applied for this operation.
 		Security []map[string][]string `json:"security,omitempty"`
+		// Extensions lists the vendor extensions defined by the action metadata.
+		Extensions map[string]interface{} `json:"-"`
 	}
 
 	// Parameter describes a single operation parameter.
@@ -144,6 +152,8 @@ type (
 		UniqueItems      bool          `json:"uniqueItems,omitempty"`
 		Enum             []interface{} `json:"enum,omitempty"`
 		MultipleOf       float64       `json:"multipleOf,omitempty"`
+		// Extensions lists the vendor extensions defined by the attribute metadata.
+		Extensions map[string]interface{} `json:"-"`
 	}
 
 	// Response describes an operation response.
@@ -160,6 +170,8 @@ type (
 		// Ref references a global API response.
 		// This field is exclusive with the other fields of Response.
 		Ref string `json:"$ref,omitempty"`
+		// Extensions lists the vendor extensions defined by the response metadata.
+		Extensions map[string]interface{} `json:"-"`
 	}
 
 	// Header represents a header parameter.
@@ -217,6 +229,21 @@ type (
 		TokenURL string `json:"tokenUrl,omitempty"`
 		// Scopes list the  available scopes for the OAuth2 security scheme.
 		Scopes map[string]string `json:"scopes,omitempty"`
+		// CodeChallengeMethods lists the PKCE code challenge methods supported by the
+		// "accessCode" flow. Swagger 2.0 does not support PKCE so the list is rendered as a
+		// vendor extension.
+		CodeChallengeMethods []string `json:"x-code-challenge-methods,omitempty"`
+		// Environments lists the OAuth2 URLs of each deployment environment as a vendor
+		// extension.
+		Environments map[string]*SecurityEnvironment `json:"x-environments,omitempty"`
+	}
+
+	// SecurityEnvironment contains the OAuth2 URLs used in a deployment environment.
+	SecurityEnvironment struct {
+		// AuthorizationURL is the authorization URL used in the environment.
+		AuthorizationURL string `json:"authorizationUrl,omitempty"`
+		// TokenURL is the token URL used in the environment.
+		TokenURL string `json:"tokenUrl,omitempty"`
 	}
 
 	// Scope corresponds to an available scope for an OAuth2 security scheme.
@@ -275,14 +302,72 @@ type (
 		// ExternalDocs is additional external documentation for this tag.
 		ExternalDocs *ExternalDocs `json:"externalDocs,omitempty"`
 	}
+
+	// TagGroup groups tags in the documentation UIs that support the "x-tagGroups" vendor
+	// extension, such as ReDoc.
+	TagGroup struct {
+		// Name of the group.
+		Name string `json:"name"`
+		// Tags lists the names of the tags of the group.
+		Tags []string `json:"tags"`
+	}
 )
 
+// MarshalJSON serializes the swagger object into JSON including its vendor extensions.
+func (s Swagger) MarshalJSON() ([]byte, error) {
+	type swagger Swagger // prevents infinite recursion
+	return genschema.MarshalWithExtensions(swagger(s), s.Extensions)
+}
+
+// MarshalJSON serializes the path into JSON including its vendor extensions.
+func (p Path) MarshalJSON() ([]byte, error) {
+	type path Path
+	return genschema.MarshalWithExtensions(path(p), p.Extensions)
+}
+
+// MarshalJSON serializes the operation into JSON including its vendor extensions.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type operation Operation
+	return genschema.MarshalWithExtensions(operation(o), o.Extensions)
+}
+
+// MarshalJSON serializes the parameter into JSON including its vendor extensions.
+func (p Parameter) MarshalJSON() ([]byte, error) {
+	type parameter Parameter
+	return genschema.MarshalWithExtensions(parameter(p), p.Extensions)
+}
+
+// MarshalJSON serializes the response into JSON including its vendor extensions.
+func (r Response) MarshalJSON() ([]byte, error) {
+	type response Response
+	return genschema.MarshalWithExtensions(response(r), r.Extensions)
+}
+
 // New creates a Swagger spec from an API definition.
 func New(api *design.APIDefinition) (*Swagger, error) {
+	return newSwagger(api, nil)
+}
+
+// NewResource creates a Swagger spec that only describes the given resource of the API
+// definition. The spec only contains the definitions and tags used by the resource actions.
+func NewResource(api *design.APIDefinition, res *design.ResourceDefinition) (*Swagger, error) {
+	s, err := newSwagger(api, res)
+	if err != nil || s == nil {
+		return s, err
+	}
+	if s.Definitions, err = usedDefinitions(s); err != nil {
+		return nil, err
+	}
+	s.Tags, s.TagGroups = usedTags(s)
+	return s, nil
+}
+
+// newSwagger creates the Swagger spec of the API or of the given resource if not nil.
+func newSwagger(api *design.APIDefinition, only *design.ResourceDefinition) (*Swagger, error) {
 	if api == nil {
 		return nil, nil
 	}
-	tags := tagsFromDefinition(api.Metadata)
+	tags := tagsFromAPI(api)
 	basePath := api.BasePath
 	if hasAbsoluteRoutes(api) {
 		basePath = ""
@@ -324,8 +409,10 @@ func New(api *design.APIDefinition) (*Swagger, error) {
 		Produces:            produces,
 		Parameters:          paramMap,
 		Tags:                tags,
+		TagGroups:           tagGroupsFromDefinition(api.Metadata),
 		ExternalDocs:        docsFromDefinition(api.Docs),
 		SecurityDefinitions: securityDefsFromDefinition(api.SecuritySchemes),
+		Extensions:          genschema.VendorExtensions(api.Metadata),
 	}
 
 	err = api.IterateResponses(func(r *design.ResponseDefinition) error {
@@ -343,6 +430,9 @@ func New(api *design.APIDefinition) (*Swagger, error) {
 		return nil, err
 	}
 	err = api.IterateResources(func(res *design.ResourceDefinition) error {
+		if only != nil && res != only {
+			return nil
+		}
 		err := res.IterateFileServers(func(fs *design.FileServerDefinition) error {
 			return buildPathFromFileServer(s, api, fs)
 		})
@@ -355,6 +445,12 @@ func New(api *design.APIDefinition) (*Swagger, error) {
 					return err
 				}
 			}
+			for i, alias := range a.AliasRoutes() {
+				operationID := fmt.Sprintf("%s#%s#renamed%d", res.Name, a.Name, i)
+				if err := buildPath(s, api, alias.Route, basePath, operationID, true); err != nil {
+					return err
+				}
+			}
 			return nil
 		})
 	})
@@ -373,6 +469,106 @@ func New(api *design.APIDefinition) (*Swagger, error) {
 	return s, nil
 }
 
+// usedDefinitions returns the definitions referenced by the paths and responses of the spec,
+// directly or through other definitions.
+func usedDefinitions(s *Swagger) (map[string]*genschema.JSONSchema, error) {
+	const prefix = "#/definitions/"
+	used := make(map[string]*genschema.JSONSchema)
+	var queue []string
+	collect := func(v interface{}) error {
+		js, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var raw interface{}
+		if err := json.Unmarshal(js, &raw); err != nil {
+			return err
+		}
+		for _, ref := range refs(raw) {
+			name := strings.TrimPrefix(ref, prefix)
+			if _, ok := used[name]; ok || !strings.HasPrefix(ref, prefix) {
+				continue
+			}
+			if def, ok := s.Definitions[name]; ok {
+				used[name] = def
+				queue = append(queue, name)
+			}
+		}
+		return nil
+	}
+	if err := collect(s.Paths); err != nil {
+		return nil, err
+	}
+	if err := collect(s.Responses); err != nil {
+		return nil, err
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if err := collect(used[name]); err != nil {
+			return nil, err
+		}
+	}
+	if len(used) == 0 {
+		return nil, nil
+	}
+	return used, nil
+}
+
+// refs returns the values of the "$ref" fields found in the given decoded JSON value.
+func refs(v interface{}) []string {
+	var res []string
+	switch actual := v.(type) {
+	case map[string]interface{}:
+		for k, val := range actual {
+			if ref, ok := val.(string); ok && k == "$ref" {
+				res = append(res, ref)
+				continue
+			}
+			res = append(res, refs(val)...)
+		}
+	case []interface{}:
+		for _, val := range actual {
+			res = append(res, refs(val)...)
+		}
+	}
+	return res
+}
+
+// usedTags returns the tags and tag groups used by the operations of the spec.
+func usedTags(s *Swagger) ([]*Tag, []*TagGroup) {
+	used := make(map[string]bool)
+	for _, p := range s.Paths {
+		for _, o := range []*Operation{p.Get, p.Put, p.Post, p.Delete, p.Options, p.Head, p.Patch} {
+			if o == nil {
+				continue
+			}
+			for _, t := range o.Tags {
+				used[t] = true
+			}
+		}
+	}
+	var tags []*Tag
+	for _, t := range s.Tags {
+		if used[t.Name] {
+			tags = append(tags, t)
+		}
+	}
+	var groups []*TagGroup
+	for _, g := range s.TagGroups {
+		var names []string
+		for _, n := range g.Tags {
+			if used[n] {
+				names = append(names, n)
+			}
+		}
+		if len(names) > 0 {
+			groups = append(groups, &TagGroup{Name: g.Name, Tags: names})
+		}
+	}
+	return tags, groups
+}
+
 // hasAbsoluteRoutes returns true if any action exposed by the API uses an absolute route of if the
 // API has file servers. This is needed as Swagger does not support exceptions to the base path so
 // if the API has any absolute route the base path must be "/" and all routes must be absolutes.
@@ -417,6 +613,17 @@ func securityDefsFromDefinition(schemes []*design.SecuritySchemeDefinition) map[
 			AuthorizationURL: scheme.AuthorizationURL,
 			TokenURL:         scheme.TokenURL,
 			Scopes:           scheme.Scopes,
+
+			CodeChallengeMethods: scheme.CodeChallengeMethods,
+		}
+		for name, env := range scheme.Environments {
+			if def.Environments == nil {
+				def.Environments = make(map[string]*SecurityEnvironment)
+			}
+			def.Environments[name] = &SecurityEnvironment{
+				AuthorizationURL: env.AuthorizationURL,
+				TokenURL:         env.TokenURL,
+			}
 		}
 		if scheme.Kind == design.JWTSecurityKind {
 			if def.TokenURL != "" {
@@ -447,13 +654,99 @@ func scopesMapList(scopes map[string]string) string {
 	return strings.Join(lines, "\n")
 }
 
+// tagsFromAPI returns the tags defined on the API, its resources and its actions. The tags listed
+// in the "swagger:tag-order" API metadata come first in the listed order, the others follow in
+// alphabetical order. The description and docs of a tag defined multiple times are the first ones
+// found in the API, the resources and the actions.
+func tagsFromAPI(api *design.APIDefinition) []*Tag {
+	byName := make(map[string]*Tag)
+	var names []string
+	add := func(mdata dslengine.MetadataDefinition) {
+		for _, t := range tagsFromDefinition(mdata) {
+			existing, ok := byName[t.Name]
+			if !ok {
+				byName[t.Name] = t
+				names = append(names, t.Name)
+				continue
+			}
+			if existing.Description == "" {
+				existing.Description = t.Description
+			}
+			if existing.ExternalDocs == nil {
+				existing.ExternalDocs = t.ExternalDocs
+			}
+		}
+	}
+	add(api.Metadata)
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		add(res.Metadata)
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			add(a.Metadata)
+			return nil
+		})
+	})
+	var tags []*Tag
+	for _, n := range orderNames(names, api.Metadata["swagger:tag-order"]) {
+		tags = append(tags, byName[n])
+	}
+	return tags
+}
+
+// tagGroupsFromDefinition returns the tag groups defined by the "swagger:tag-group:xxx" metadata.
+// The groups listed in the "swagger:tag-group-order" metadata come first in the listed order,
+// the others follow in alphabetical order.
+func tagGroupsFromDefinition(mdata dslengine.MetadataDefinition) []*TagGroup {
+	const prefix = "swagger:tag-group:"
+	var names []string
+	for key := range mdata {
+		if strings.HasPrefix(key, prefix) {
+			names = append(names, strings.TrimPrefix(key, prefix))
+		}
+	}
+	var groups []*TagGroup
+	for _, n := range orderNames(names, mdata["swagger:tag-group-order"]) {
+		groups = append(groups, &TagGroup{Name: n, Tags: mdata[prefix+n]})
+	}
+	return groups
+}
+
+// orderNames sorts names so that the names listed in order come first in the listed order and
+// the others follow in alphabetical order.
+func orderNames(names, order []string) []string {
+	rank := make(map[string]int, len(order))
+	for i, n := range order {
+		if _, ok := rank[n]; !ok {
+			rank[n] = i
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		ri, iok := rank[names[i]]
+		rj, jok := rank[names[j]]
+		switch {
+		case iok && jok:
+			return ri < rj
+		case iok != jok:
+			return iok
+		default:
+			return names[i] < names[j]
+		}
+	})
+	return names
+}
+
 func tagsFromDefinition(mdata dslengine.MetadataDefinition) (tags []*Tag) {
-	for key, value := range mdata {
+	keys := make([]string, 0, len(mdata))
+	for key := range mdata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var value []string
 		chunks := strings.Split(key, ":")
 		if len(chunks) != 3 {
 			continue
 		}
-		if chunks[0] != "swagger" && chunks[1] != "tag" {
+		if chunks[0] != "swagger" || chunks[1] != "tag" {
 			continue
 		}
 
@@ -560,6 +853,7 @@ func paramFor(at *design.AttributeDefinition, name, in string, required bool) *P
 		p.Items = itemsFromDefinition(at.Type.ToArray().ElemType)
 	}
 	initValidations(at, p)
+	p.Extensions = genschema.VendorExtensions(at.Metadata)
 	return p
 }
 
@@ -623,6 +917,7 @@ func responseSpecFromDefinition(s *Swagger, api *design.APIDefinition, r *design
 		Description: r.Description,
 		Schema:      schema,
 		Headers:     headers,
+		Extensions:  genschema.VendorExtensions(r.Metadata),
 	}, nil
 }
 
@@ -723,7 +1018,7 @@ func buildPathFromFileServer(s *Swagger, api *design.APIDefinition, fs *design.F
 	var path *Path
 	var ok bool
 	if path, ok = s.Paths[key]; !ok {
-		path = new(Path)
+		path = &Path{Extensions: genschema.VendorExtensions(fs.Parent.Metadata)}
 		s.Paths[key] = path
 	}
 	path.Get = operation
@@ -733,6 +1028,24 @@ func buildPathFromFileServer(s *Swagger, api *design.APIDefinition, fs *design.F
 
 func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *design.RouteDefinition, basePath string) error {
 	action := route.Parent
+	operationID := fmt.Sprintf("%s#%s", action.Parent.Name, action.Name)
+	index := 0
+	for i, rt := range action.Routes {
+		if rt == route {
+			index = i
+			break
+		}
+	}
+	if index > 0 {
+		operationID = fmt.Sprintf("%s#%d", operationID, index)
+	}
+	return buildPath(s, api, route, basePath, operationID, false)
+}
+
+// buildPath adds the operation corresponding to the given route to the swagger paths.
+// deprecated is true for the alias routes kept after renaming a resource or an action.
+func buildPath(s *Swagger, api *design.APIDefinition, route *design.RouteDefinition, basePath, operationID string, deprecated bool) error {
+	action := route.Parent
 
 	tagNames := tagNamesFromDefinitions(action.Parent.Metadata, action.Metadata)
 	params, err := paramsFromDefinition(action.AllParams(), route.FullPath())
@@ -763,18 +1076,6 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		params = append(params, pp)
 	}
 
-	operationID := fmt.Sprintf("%s#%s", action.Parent.Name, action.Name)
-	index := 0
-	for i, rt := range action.Routes {
-		if rt == route {
-			index = i
-			break
-		}
-	}
-	if index > 0 {
-		operationID = fmt.Sprintf("%s#%d", operationID, index)
-	}
-
 	schemes := action.Schemes
 	if len(schemes) == 0 {
 		schemes = api.Schemes
@@ -789,7 +1090,8 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		Parameters:   params,
 		Responses:    responses,
 		Schemes:      schemes,
-		Deprecated:   false,
+		Deprecated:   deprecated,
+		Extensions:   genschema.VendorExtensions(action.Metadata),
 	}
 
 	applySecurity(operation, action.Security)
@@ -813,7 +1115,7 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 	var path *Path
 	var ok bool
 	if path, ok = s.Paths[key]; !ok {
-		path = new(Path)
+		path = &Path{Extensions: genschema.VendorExtensions(action.Parent.Metadata)}
 		s.Paths[key] = path
 	}
 	switch route.Verb {
@@ -440,4 +440,150 @@ var _ = Describe("New", func() {
 			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
 		})
 	})
+
+	Context("with tags", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				SwaggerTag("Wineries", "Browse the wineries")
+				SwaggerTag("Bottles")
+				SwaggerTagGroup("Cellar", "Wineries", "Bottles")
+				SwaggerTagGroup("Administration", "Users")
+			})
+			Resource("bottle", func() {
+				SwaggerTag("Bottles", "Manage the bottles")
+				Action("rate", func() {
+					SwaggerTag("Ratings")
+					Routing(PUT("/rate"))
+					Response(NoContent)
+				})
+			})
+			Resource("user", func() {
+				SwaggerTag("Users", "Manage the users")
+				Action("show", func() {
+					Routing(GET("/users"))
+					Response(NoContent)
+				})
+			})
+		})
+
+		It("lists the tags in order", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(swagger.Tags).Should(Equal([]*genswagger.Tag{
+				{Name: "Wineries", Description: "Browse the wineries"},
+				{Name: "Bottles", Description: "Manage the bottles"},
+				{Name: "Ratings"},
+				{Name: "Users", Description: "Manage the users"},
+			}))
+			Ω(swagger.Paths["/rate"].Put.Tags).Should(Equal([]string{"Bottles", "Ratings"}))
+		})
+
+		It("lists the tag groups in order", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(swagger.TagGroups).Should(Equal([]*genswagger.TagGroup{
+				{Name: "Cellar", Tags: []string{"Wineries", "Bottles"}},
+				{Name: "Administration", Tags: []string{"Users"}},
+			}))
+		})
+
+		It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+	})
+
+	Context("with multiple resources", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				SwaggerTag("Bottles")
+				SwaggerTag("Users")
+				SwaggerTagGroup("All", "Bottles", "Users")
+			})
+			Winery := Type("Winery", func() {
+				Attribute("name", String)
+			})
+			BottlePayload := Type("BottlePayload", func() {
+				Attribute("winery", Winery)
+			})
+			UserPayload := Type("UserPayload", func() {
+				Attribute("name", String)
+			})
+			Resource("bottle", func() {
+				SwaggerTag("Bottles")
+				Action("create", func() {
+					Routing(POST("/bottles"))
+					Payload(BottlePayload)
+					Response(NoContent)
+				})
+			})
+			Resource("user", func() {
+				SwaggerTag("Users")
+				Action("create", func() {
+					Routing(POST("/users"))
+					Payload(UserPayload)
+					Response(NoContent)
+				})
+			})
+		})
+
+		It("describes a single resource", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			s, err := genswagger.NewResource(Design, Design.Resources["bottle"])
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(s.Paths).Should(HaveLen(1))
+			Ω(s.Paths).Should(HaveKey("/bottles"))
+			Ω(s.Definitions).Should(HaveLen(2))
+			Ω(s.Definitions).Should(HaveKey("CreateBottlePayload"))
+			Ω(s.Definitions).Should(HaveKey("Winery"))
+			Ω(s.Tags).Should(Equal([]*genswagger.Tag{{Name: "Bottles"}}))
+			Ω(s.TagGroups).Should(Equal([]*genswagger.TagGroup{{Name: "All", Tags: []string{"Bottles"}}}))
+			validateSwagger(s)
+		})
+	})
+
+	Context("with vendor extensions", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				Metadata("swagger:extension:x-api", `{"a":1}`)
+				Metadata("swagger:extension:ignored", "ignored")
+			})
+			Resource("res", func() {
+				Metadata("swagger:extension:x-resource", "true")
+				Action("list", func() {
+					Metadata("swagger:extension:x-action", "a value")
+					Routing(POST("/list"))
+					Params(func() {
+						Param("q", String, func() {
+							Metadata("swagger:extension:x-param", "1")
+						})
+					})
+					Payload(func() {
+						Attribute("name", String, func() {
+							Metadata("swagger:extension:x-attribute", "a", "b")
+						})
+					})
+					Response(NoContent)
+				})
+			})
+		})
+
+		It("sets the extensions", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(swagger.Extensions).Should(Equal(map[string]interface{}{"x-api": map[string]interface{}{"a": 1.0}}))
+			path := swagger.Paths["/list"]
+			Ω(path).ShouldNot(BeNil())
+			Ω(path.Extensions).Should(Equal(map[string]interface{}{"x-resource": true}))
+			Ω(path.Post.Extensions).Should(Equal(map[string]interface{}{"x-action": "a value"}))
+			Ω(path.Post.Parameters[0].Extensions).Should(Equal(map[string]interface{}{"x-param": 1.0}))
+		})
+
+		It("serializes the extensions", func() {
+			b, err := json.Marshal(swagger)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(ContainSubstring(`"x-api":{"a":1}`))
+			Ω(string(b)).Should(ContainSubstring(`"x-resource":true`))
+			Ω(string(b)).Should(ContainSubstring(`"x-action":"a value"`))
+			Ω(string(b)).Should(ContainSubstring(`"x-param":1`))
+			Ω(string(b)).Should(ContainSubstring(`"x-attribute":["a","b"]`))
+			Ω(string(b)).ShouldNot(ContainSubstring("ignored"))
+		})
+
+		It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+	})
 })
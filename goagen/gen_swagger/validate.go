@@ -0,0 +1,257 @@
+package genswagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type (
+	// ValidationError describes a Swagger 2.0 rule broken by a spec.
+	ValidationError struct {
+		// Location describes the design element that produced the invalid part of the spec,
+		// e.g. `action "show" of resource "bottle"`.
+		Location string
+		// Message describes the error.
+		Message string
+	}
+
+	// ValidationErrors lists the rules broken by a spec.
+	ValidationErrors []*ValidationError
+)
+
+// pathParamRegex captures the parameters of a Swagger path template.
+var pathParamRegex = regexp.MustCompile(`{([^}]+)}`)
+
+// simpleTypes lists the types allowed for the parameters that are not in the request body and
+// for the response headers.
+var simpleTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"array":   true,
+}
+
+// Error returns the error message.
+func (e *ValidationError) Error() string {
+	if e.Location == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Location, e.Message)
+}
+
+// Error returns the messages of all the errors, one per line.
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return "invalid swagger spec:\n" + strings.Join(msgs, "\n")
+}
+
+// Validate checks the spec against the Swagger 2.0 rules that the JSON schema of the specification
+// cannot express or that the generated specs may break: path parameters must match the path
+// templates, operation IDs must be unique, parameters must have valid types and locations,
+// operations must define responses and the security requirements and references must resolve.
+// Validate returns nil or a ValidationErrors error whose locations point to the offending design
+// elements.
+func (s *Swagger) Validate() error {
+	var errs ValidationErrors
+	report := func(location, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Location: location, Message: fmt.Sprintf(format, args...)})
+	}
+
+	paths := make([]string, 0, len(s.Paths))
+	for p := range s.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	ids := make(map[string]string)
+	for _, p := range paths {
+		path := s.Paths[p]
+		for _, op := range []struct {
+			method string
+			o      *Operation
+		}{
+			{"GET", path.Get}, {"PUT", path.Put}, {"POST", path.Post}, {"DELETE", path.Delete},
+			{"OPTIONS", path.Options}, {"HEAD", path.Head}, {"PATCH", path.Patch},
+		} {
+			if op.o == nil {
+				continue
+			}
+			loc := fmt.Sprintf("%s %s (%s)", op.method, p, designLocation(op.o.OperationID))
+			if op.o.OperationID != "" {
+				if other, ok := ids[op.o.OperationID]; ok {
+					report(loc, "operation ID %q is also used by %s", op.o.OperationID, other)
+				} else {
+					ids[op.o.OperationID] = op.method + " " + p
+				}
+			}
+			s.validateParams(p, op.o, func(format string, args ...interface{}) {
+				report(loc, format, args...)
+			})
+			if len(op.o.Responses) == 0 {
+				report(loc, "operation does not define any response")
+			}
+			for _, code := range sortedKeys(op.o.Responses) {
+				headers := op.o.Responses[code].Headers
+				names := make([]string, 0, len(headers))
+				for n := range headers {
+					names = append(names, n)
+				}
+				sort.Strings(names)
+				for _, n := range names {
+					if t := headers[n].Type; !simpleTypes[t] {
+						report(loc, "header %q of response %s has invalid type %q", n, code, t)
+					}
+				}
+			}
+			for _, req := range op.o.Security {
+				for scheme, scopes := range req {
+					def, ok := s.SecurityDefinitions[scheme]
+					if !ok {
+						report(loc, "security scheme %q is not defined", scheme)
+						continue
+					}
+					for _, scope := range scopes {
+						if _, ok := def.Scopes[scope]; !ok && def.Type == "oauth2" {
+							report(loc, "scope %q is not defined by security scheme %q", scope, scheme)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if err := s.validateRefs(func(format string, args ...interface{}) {
+		report("", format, args...)
+	}); err != nil {
+		return err
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateParams checks the parameters of the given operation.
+func (s *Swagger) validateParams(path string, o *Operation, report func(string, ...interface{})) {
+	inPath := make(map[string]bool)
+	seen := make(map[string]bool)
+	body, form := 0, false
+	for _, p := range o.Parameters {
+		key := p.In + " " + p.Name
+		if seen[key] {
+			report("%s parameter %q is defined more than once", p.In, p.Name)
+		}
+		seen[key] = true
+		switch p.In {
+		case "body":
+			body++
+			continue
+		case "formData":
+			form = true
+		case "path":
+			inPath[p.Name] = true
+			if !p.Required {
+				report("path parameter %q must be required", p.Name)
+			}
+		case "query", "header":
+		default:
+			report("parameter %q has invalid location %q", p.Name, p.In)
+		}
+		if !simpleTypes[p.Type] && !(p.Type == "file" && p.In == "formData") {
+			report("%s parameter %q has invalid type %q, only primitive types and arrays of primitive types may be used outside of the request body", p.In, p.Name, p.Type)
+		}
+		if p.Type == "array" {
+			if p.Items == nil {
+				report("%s parameter %q is an array but does not define its items", p.In, p.Name)
+			} else if !simpleTypes[p.Items.Type] {
+				report("%s parameter %q has items of invalid type %q", p.In, p.Name, p.Items.Type)
+			}
+		}
+	}
+	if body > 1 {
+		report("operation defines %d body parameters, at most one is allowed", body)
+	}
+	if body > 0 && form {
+		report("operation defines both body and form parameters")
+	}
+	for _, m := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		if !inPath[m[1]] {
+			report("path parameter %q is not defined", m[1])
+		}
+		delete(inPath, m[1])
+	}
+	var extra []string
+	for n := range inPath {
+		extra = append(extra, n)
+	}
+	sort.Strings(extra)
+	for _, n := range extra {
+		report("path parameter %q does not appear in the path", n)
+	}
+}
+
+// validateRefs checks that the references of the spec resolve.
+func (s *Swagger) validateRefs(report func(string, ...interface{})) error {
+	js, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(js, &raw); err != nil {
+		return err
+	}
+	all := refs(raw)
+	sort.Strings(all)
+	reported := make(map[string]bool)
+	for _, ref := range all {
+		if reported[ref] {
+			continue
+		}
+		var ok bool
+		switch {
+		case strings.HasPrefix(ref, "#/definitions/"):
+			_, ok = s.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+		case strings.HasPrefix(ref, "#/parameters/"):
+			_, ok = s.Parameters[strings.TrimPrefix(ref, "#/parameters/")]
+		case strings.HasPrefix(ref, "#/responses/"):
+			_, ok = s.Responses[strings.TrimPrefix(ref, "#/responses/")]
+		default:
+			// External references are not resolved.
+			ok = true
+		}
+		if !ok {
+			report("reference %q does not resolve", ref)
+			reported[ref] = true
+		}
+	}
+	return nil
+}
+
+// designLocation describes the design element that produced the operation with the given ID.
+func designLocation(operationID string) string {
+	parts := strings.SplitN(operationID, "#", 3)
+	if len(parts) < 2 {
+		return fmt.Sprintf("operation %q", operationID)
+	}
+	if strings.HasPrefix(parts[1], "/") {
+		return fmt.Sprintf("file server %q of resource %q", parts[1], parts[0])
+	}
+	return fmt.Sprintf("action %q of resource %q", parts[1], parts[0])
+}
+
+// sortedKeys returns the sorted keys of the given responses.
+func sortedKeys(responses map[string]*Response) []string {
+	keys := make([]string, 0, len(responses))
+	for k := range responses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+package genswagger_test
+
+import (
+	"github.com/goadesign/goa/goagen/gen_schema"
+	"github.com/goadesign/goa/goagen/gen_swagger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	var swagger *genswagger.Swagger
+	var validateErr error
+
+	BeforeEach(func() {
+		swagger = &genswagger.Swagger{
+			Swagger: "2.0",
+			Info:    &genswagger.Info{Title: "test"},
+			Paths: map[string]*genswagger.Path{
+				"/bottles/{id}": {Get: &genswagger.Operation{
+					OperationID: "bottle#show",
+					Parameters: []*genswagger.Parameter{
+						{Name: "id", In: "path", Type: "integer", Required: true},
+						{Name: "view", In: "query", Type: "string"},
+					},
+					Responses: map[string]*genswagger.Response{
+						"200": {Description: "OK", Schema: &genschema.JSONSchema{Ref: "#/definitions/Bottle"}},
+					},
+				}},
+			},
+			Definitions: map[string]*genschema.JSONSchema{
+				"Bottle": {Type: genschema.JSONObject},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		validateErr = swagger.Validate()
+	})
+
+	It("accepts a valid spec", func() {
+		Ω(validateErr).ShouldNot(HaveOccurred())
+	})
+
+	Context("with an undefined path parameter", func() {
+		BeforeEach(func() {
+			op := swagger.Paths["/bottles/{id}"].Get
+			op.Parameters = op.Parameters[1:]
+		})
+
+		It("points to the action", func() {
+			Ω(validateErr).Should(HaveOccurred())
+			Ω(validateErr.Error()).Should(ContainSubstring(`GET /bottles/{id} (action "show" of resource "bottle"): path parameter "id" is not defined`))
+		})
+	})
+
+	Context("with an invalid query parameter type", func() {
+		BeforeEach(func() {
+			op := swagger.Paths["/bottles/{id}"].Get
+			op.Parameters[1].Type = "object"
+		})
+
+		It("reports the parameter", func() {
+			Ω(validateErr).Should(HaveOccurred())
+			Ω(validateErr.Error()).Should(ContainSubstring(`query parameter "view" has invalid type "object"`))
+		})
+	})
+
+	Context("with a duplicate operation ID", func() {
+		BeforeEach(func() {
+			swagger.Paths["/bottles"] = &genswagger.Path{Get: &genswagger.Operation{
+				OperationID: "bottle#show",
+				Responses:   map[string]*genswagger.Response{"200": {Description: "OK"}},
+			}}
+		})
+
+		It("reports both operations", func() {
+			Ω(validateErr).Should(HaveOccurred())
+			Ω(validateErr.Error()).Should(ContainSubstring(`operation ID "bottle#show" is also used by GET /bottles`))
+		})
+	})
+
+	Context("with an unresolved reference", func() {
+		BeforeEach(func() {
+			swagger.Definitions = nil
+		})
+
+		It("reports the reference", func() {
+			Ω(validateErr).Should(HaveOccurred())
+			Ω(validateErr.(genswagger.ValidationErrors)).Should(HaveLen(1))
+			Ω(validateErr.Error()).Should(ContainSubstring(`reference "#/definitions/Bottle" does not resolve`))
+		})
+	})
+
+	Context("with an undefined security scheme", func() {
+		BeforeEach(func() {
+			op := swagger.Paths["/bottles/{id}"].Get
+			op.Security = []map[string][]string{{"jwt": {"bottle:read"}}}
+		})
+
+		It("reports the scheme", func() {
+			Ω(validateErr).Should(HaveOccurred())
+			Ω(validateErr.Error()).Should(ContainSubstring(`security scheme "jwt" is not defined`))
+		})
+	})
+})
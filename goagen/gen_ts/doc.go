@@ -0,0 +1,12 @@
+/*
+Package gents provides a goa generator for a TypeScript client module.
+
+The module declares an interface for each user type, media type and payload of the design and a
+Client class with one typed method per action. The methods rely on the fetch API to make the
+requests and resolve to the decoded body of the action success response. Attributes that
+enumerate their values are typed with the union of the values.
+
+The generator also produces a function per action route that builds the request path from the
+route parameters.
+*/
+package gents
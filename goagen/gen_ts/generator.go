@@ -0,0 +1,501 @@
+package gents
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+)
+
+// Generator is the TypeScript client code generator.
+type Generator struct {
+	genfiles []string      // Generated files
+	outDir   string        // Destination directory
+	timeout  time.Duration // Default timeout of the requests made by the client
+	scheme   string        // Default scheme used by the client
+	host     string        // Default host addressed by the client
+}
+
+type (
+	// tsType is the data used to render a TypeScript interface or type alias.
+	tsType struct {
+		// Name is the name of the TypeScript type.
+		Name string
+		// Description is the type description.
+		Description string
+		// Fields lists the interface fields, nil for type aliases.
+		Fields []*tsField
+		// Alias is the aliased type for types that are not objects.
+		Alias string
+	}
+
+	// tsField is the data used to render an interface field.
+	tsField struct {
+		// Name is the field name, quoted if not a valid identifier.
+		Name string
+		// Key is the attribute name.
+		Key string
+		// Type is the TypeScript type of the field.
+		Type string
+		// Optional is true if the field may be omitted.
+		Optional bool
+		// Description is the field description.
+		Description string
+	}
+
+	// tsAction is the data used to render a client method.
+	tsAction struct {
+		// Name is the method name.
+		Name string
+		// Description is the action description.
+		Description string
+		// Verb is the HTTP method of the first action route.
+		Verb string
+		// Path is the full path of the first action route.
+		Path string
+		// Payload is the TypeScript type of the request body, empty if none.
+		Payload string
+		// Params is the name of the parameters interface, empty if the action has no query
+		// string parameters or headers.
+		Params string
+		// ParamsOptional is true if no parameter is required.
+		ParamsOptional bool
+		// Query lists the query string parameters.
+		Query []*tsField
+		// Headers lists the request headers.
+		Headers []*tsField
+		// Result is the TypeScript type of the response body.
+		Result string
+		// Routes lists the path builder functions.
+		Routes []*tsRoute
+	}
+
+	// tsRoute is the data used to render a path builder function.
+	tsRoute struct {
+		// Name is the function name.
+		Name string
+		// Params lists the function parameters.
+		Params []*tsField
+		// Template is the path template literal.
+		Template string
+	}
+)
+
+// identifierRegex matches valid TypeScript identifiers.
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var (
+		outDir       string
+		timeout      time.Duration
+		scheme, host string
+	)
+
+	set := flag.NewFlagSet("ts", flag.PanicOnError)
+	set.StringVar(&outDir, "out", "", "")
+	set.String("design", "", "")
+	set.DurationVar(&timeout, "timeout", time.Duration(20)*time.Second, "")
+	set.StringVar(&scheme, "scheme", "", "")
+	set.StringVar(&host, "host", "", "")
+	set.Parse(os.Args[2:])
+
+	g := &Generator{outDir: outDir, timeout: timeout, scheme: scheme, host: host}
+
+	return g.Generate(design.Design)
+}
+
+// Generate produces the TypeScript client module.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	if g.scheme == "" && len(api.Schemes) > 0 {
+		g.scheme = api.Schemes[0]
+	}
+	if g.scheme == "" {
+		g.scheme = "http"
+	}
+	if g.host == "" {
+		g.host = api.Host
+	}
+
+	g.outDir = filepath.Join(g.outDir, "ts")
+	render, err := codegen.BeginRender(g.outDir)
+	if err != nil {
+		return nil, err
+	}
+	defer render.Discard()
+	if err := os.MkdirAll(g.outDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, g.outDir)
+
+	if err = g.generateTS(filepath.Join(g.outDir, "client.ts"), api); err != nil {
+		return
+	}
+
+	return g.genfiles, render.Commit()
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
+
+func (g *Generator) generateTS(tsFile string, api *design.APIDefinition) error {
+	file, err := codegen.SourceFileFor(tsFile)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, tsFile)
+
+	types := make(map[string]*tsType)
+	addType := func(name, desc string, att *design.AttributeDefinition) {
+		if _, ok := types[name]; ok {
+			return
+		}
+		t := &tsType{Name: name, Description: desc}
+		if att.Type.IsObject() {
+			t.Fields = fields(att)
+		} else {
+			t.Alias = typeRef(att)
+		}
+		types[name] = t
+	}
+	api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		addType(typeName(ut.TypeName), ut.Description, ut.AttributeDefinition)
+		return nil
+	})
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		addType(typeName(mt.TypeName), mt.Description, mt.AttributeDefinition)
+		return nil
+	})
+
+	var actions []*tsAction
+	err = api.IterateResources(func(res *design.ResourceDefinition) error {
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			if a.Payload != nil {
+				addType(typeName(a.Payload.TypeName), a.Payload.Description, a.Payload.AttributeDefinition)
+			}
+			actions = append(actions, newAction(a))
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(types))
+	for n := range types {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	sorted := make([]*tsType, len(names))
+	for i, n := range names {
+		sorted[i] = types[n]
+	}
+
+	data := map[string]interface{}{
+		"API":     api,
+		"BaseURL": baseURL(g.scheme, g.host),
+		"Timeout": int64(g.timeout / time.Millisecond),
+		"Types":   sorted,
+		"Actions": actions,
+	}
+	return file.ExecuteTemplate("ts", tsT, nil, data)
+}
+
+// newAction computes the data needed to render the client method of the given action.
+func newAction(a *design.ActionDefinition) *tsAction {
+	name := codegen.Goify(a.Name+strings.Title(a.Parent.Name), false)
+	ta := &tsAction{
+		Name:           name,
+		Description:    a.Description,
+		Verb:           a.Routes[0].Verb,
+		Path:           a.Routes[0].FullPath(),
+		Result:         "void",
+		ParamsOptional: true,
+	}
+	if a.Payload != nil {
+		ta.Payload = typeName(a.Payload.TypeName)
+	}
+	if a.QueryParams != nil && a.QueryParams.Type.IsObject() {
+		ta.Query = fields(a.QueryParams)
+	}
+	if a.Headers != nil && a.Headers.Type.IsObject() {
+		ta.Headers = fields(a.Headers)
+	}
+	if len(ta.Query) > 0 || len(ta.Headers) > 0 {
+		ta.Params = codegen.Goify(name, true) + "Params"
+		for _, f := range append(append([]*tsField{}, ta.Query...), ta.Headers...) {
+			if !f.Optional {
+				ta.ParamsOptional = false
+			}
+		}
+	}
+	a.IterateResponses(func(r *design.ResponseDefinition) error {
+		if ta.Result != "void" || r.Status < 200 || r.Status >= 300 {
+			return nil
+		}
+		if mt := design.Design.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			ta.Result = typeName(mt.TypeName)
+		}
+		return nil
+	})
+	for i, r := range a.Routes {
+		route := &tsRoute{Name: name + "Path"}
+		if i > 0 {
+			route.Name += fmt.Sprint(i + 1)
+		}
+		tmpl := r.FullPath()
+		for _, p := range r.Params() {
+			pname := codegen.Goify(p, false)
+			var att *design.AttributeDefinition
+			if a.Params != nil {
+				att = a.Params.Type.ToObject()[p]
+			}
+			ptype := "string"
+			if att != nil {
+				ptype = typeRef(att)
+			}
+			route.Params = append(route.Params, &tsField{Name: pname, Type: ptype})
+			tmpl = strings.Replace(tmpl, ":"+p, "${encodeURIComponent(String("+pname+"))}", 1)
+			tmpl = strings.Replace(tmpl, "*"+p, "${encodeURI(String("+pname+"))}", 1)
+		}
+		route.Template = "`" + tmpl + "`"
+		ta.Routes = append(ta.Routes, route)
+	}
+	return ta
+}
+
+// fields returns the interface fields corresponding to the attributes of the given object.
+func fields(att *design.AttributeDefinition) []*tsField {
+	obj := att.Type.ToObject()
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fs := make([]*tsField, len(names))
+	for i, n := range names {
+		fs[i] = &tsField{
+			Name:        fieldName(n),
+			Key:         n,
+			Type:        typeRef(obj[n]),
+			Optional:    !att.IsRequired(n),
+			Description: obj[n].Description,
+		}
+	}
+	return fs
+}
+
+// typeRef returns the TypeScript type of the given attribute. Attributes that enumerate their
+// values are typed with the union of the values.
+func typeRef(att *design.AttributeDefinition) string {
+	if att.Validation != nil && len(att.Validation.Values) > 0 {
+		values := make([]string, 0, len(att.Validation.Values))
+		for _, v := range att.Validation.Values {
+			b, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			values = append(values, string(b))
+		}
+		if len(values) > 0 {
+			return strings.Join(values, " | ")
+		}
+	}
+	switch actual := att.Type.(type) {
+	case *design.MediaTypeDefinition:
+		return typeName(actual.TypeName)
+	case *design.UserTypeDefinition:
+		return typeName(actual.TypeName)
+	case *design.Array:
+		elem := typeRef(actual.ElemType)
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case *design.Hash:
+		return "Record<string, " + typeRef(actual.ElemType) + ">"
+	case design.Object:
+		fs := fields(att)
+		elems := make([]string, len(fs))
+		for i, f := range fs {
+			opt := ""
+			if f.Optional {
+				opt = "?"
+			}
+			elems[i] = f.Name + opt + ": " + f.Type
+		}
+		return "{ " + strings.Join(elems, "; ") + " }"
+	}
+	switch att.Type.Kind() {
+	case design.BooleanKind:
+		return "boolean"
+	case design.IntegerKind, design.NumberKind:
+		return "number"
+	case design.StringKind, design.DateTimeKind, design.UUIDKind:
+		return "string"
+	}
+	return "unknown"
+}
+
+// typeName returns the TypeScript name of the type with the given design name.
+func typeName(name string) string {
+	return codegen.Goify(name, true)
+}
+
+// fieldName returns the TypeScript interface field name for the given attribute name, quoted
+// if it is not a valid identifier.
+func fieldName(name string) string {
+	if identifierRegex.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+// baseURL returns the default URL prefix of the requests.
+func baseURL(scheme, host string) string {
+	if host == "" {
+		return ""
+	}
+	return scheme + "://" + host
+}
+
+const tsT = `// This module exports a client for the {{ .API.Name }} API{{ if .BaseURL }} hosted at {{ .BaseURL }}{{ end }}.
+// It uses the fetch API to make the requests.
+
+/** ClientOptions configures the client. */
+export interface ClientOptions {
+  /** baseURL is the URL prefix of all the requests{{ if .BaseURL }}, defaults to "{{ .BaseURL }}"{{ end }}. */
+  baseURL?: string;
+  /** headers are sent with all the requests. */
+  headers?: Record<string, string>;
+  /** timeout is the request timeout in milliseconds, defaults to {{ .Timeout }}. */
+  timeout?: number;
+  /** fetch is the fetch implementation, defaults to the global fetch function. */
+  fetch?: typeof fetch;
+}
+
+/** APIError is the error raised when the API responds with a status other than 2xx. */
+export class APIError extends Error {
+  constructor(public readonly status: number, public readonly body: unknown) {
+    super(` + "`" + `request failed with status ${status}` + "`" + `);
+    this.name = "APIError";
+  }
+}
+{{ range .Types }}
+{{ if .Description }}/** {{ .Description }} */
+{{ end }}{{ if .Fields }}export interface {{ .Name }} {
+{{ range .Fields }}{{ if .Description }}  /** {{ .Description }} */
+{{ end }}  {{ .Name }}{{ if .Optional }}?{{ end }}: {{ .Type }};
+{{ end }}}
+{{ else if .Alias }}export type {{ .Name }} = {{ .Alias }};
+{{ else }}export interface {{ .Name }} {}
+{{ end }}{{ end }}{{ range .Actions }}{{ if .Params }}
+/** {{ .Params }} lists the query string parameters and headers of {{ .Name }}. */
+export interface {{ .Params }} {
+{{ range .Query }}  {{ .Name }}{{ if .Optional }}?{{ end }}: {{ .Type }};
+{{ end }}{{ range .Headers }}  {{ .Name }}{{ if .Optional }}?{{ end }}: {{ .Type }};
+{{ end }}}
+{{ end }}{{ range .Routes }}
+/** {{ .Name }} builds the request path. */
+export function {{ .Name }}({{ range $i, $p := .Params }}{{ if $i }}, {{ end }}{{ $p.Name }}: {{ $p.Type }}{{ end }}): string {
+  return {{ .Template }};
+}
+{{ end }}{{ end }}
+/** Client gives access to the {{ .API.Name }} API. */
+export class Client {
+  private readonly baseURL: string;
+  private readonly headers: Record<string, string>;
+  private readonly timeout: number;
+  private readonly fetch: typeof fetch;
+
+  constructor(options: ClientOptions = {}) {
+    this.baseURL = options.baseURL ?? {{ printf "%q" .BaseURL }};
+    this.headers = options.headers ?? {};
+    this.timeout = options.timeout ?? {{ .Timeout }};
+    this.fetch = options.fetch ?? fetch.bind(globalThis);
+  }
+{{ range .Actions }}
+  /**
+   * {{ if .Description }}{{ .Description }}{{ else }}{{ .Name }} makes a {{ .Verb }} request to "{{ .Path }}".{{ end }}
+   * path is the request path, see {{ (index .Routes 0).Name }}.
+   */
+  async {{ .Name }}(path: string{{ if .Payload }}, payload: {{ .Payload }}{{ end }}{{ if .Params }}, params: {{ .Params }}{{ if .ParamsOptional }} = {}{{ end }}{{ end }}, init?: RequestInit): Promise<{{ .Result }}> {
+{{ if .Query }}    const query: Record<string, unknown> = {
+{{ range .Query }}      {{ printf "%q" .Key }}: params[{{ printf "%q" .Key }}],
+{{ end }}    };
+{{ end }}{{ if .Headers }}    const headers: Record<string, unknown> = {
+{{ range .Headers }}      {{ printf "%q" .Key }}: params[{{ printf "%q" .Key }}],
+{{ end }}    };
+{{ end }}    return this.request<{{ .Result }}>({{ printf "%q" .Verb }}, path, {{ if .Payload }}payload{{ else }}undefined{{ end }}, {{ if .Query }}query{{ else }}{}{{ end }}, {{ if .Headers }}headers{{ else }}{}{{ end }}, init);
+  }
+{{ end }}
+  private async request<T>(method: string, path: string, body: unknown, query: Record<string, unknown>, headers: Record<string, unknown>, init?: RequestInit): Promise<T> {
+    const url = new URL(this.baseURL + path, typeof location === "undefined" ? undefined : location.href);
+    for (const [name, value] of Object.entries(query)) {
+      if (value === undefined || value === null) {
+        continue;
+      }
+      for (const v of Array.isArray(value) ? value : [value]) {
+        url.searchParams.append(name, String(v));
+      }
+    }
+    const h = new Headers(this.headers);
+    for (const [name, value] of Object.entries(headers)) {
+      if (value !== undefined && value !== null) {
+        h.set(name, String(value));
+      }
+    }
+    h.set("Accept", "application/json");
+    if (body !== undefined) {
+      h.set("Content-Type", "application/json");
+    }
+    const controller = new AbortController();
+    const timer = setTimeout(() => controller.abort(), this.timeout);
+    try {
+      const resp = await this.fetch(url.toString(), {
+        signal: controller.signal,
+        ...init,
+        method,
+        headers: h,
+        body: body === undefined ? undefined : JSON.stringify(body),
+      });
+      const text = await resp.text();
+      let data: unknown = undefined;
+      if (text) {
+        try {
+          data = JSON.parse(text);
+        } catch {
+          data = text;
+        }
+      }
+      if (!resp.ok) {
+        throw new APIError(resp.status, data);
+      }
+      return data as T;
+    } finally {
+      clearTimeout(timer);
+    }
+  }
+}
+`
@@ -0,0 +1,143 @@
+package gents_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_ts"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	const testgenPackagePath = "github.com/goadesign/goa/goagen/gen_ts/test_"
+
+	var outDir string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		gopath := filepath.SplitList(os.Getenv("GOPATH"))[0]
+		outDir = filepath.Join(gopath, "src", testgenPackagePath)
+		err := os.MkdirAll(outDir, 0777)
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"goagen", "ts", "--out=" + outDir, "--design=foo", "--host=baz"}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gents.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	Context("with a dummy API", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				Name:        "testapi",
+				Title:       "dummy API with no resource",
+				Description: "I told you it's dummy",
+			}
+		})
+
+		It("generates the client class", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(HaveLen(2))
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "ts", "client.ts"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("export class Client {"))
+			Ω(content).Should(ContainSubstring(`this.baseURL = options.baseURL ?? "http://baz";`))
+		})
+	})
+
+	Context("with an action", func() {
+		BeforeEach(func() {
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					TypeName: "Bottle",
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{
+							"id":    {Type: design.Integer},
+							"color": {Type: design.String, Validation: &dslengine.ValidationDefinition{Values: []interface{}{"red", "white"}}},
+							"tags":  {Type: &design.Array{ElemType: &design.AttributeDefinition{Type: design.String}}},
+						},
+						Validation: &dslengine.ValidationDefinition{Required: []string{"id"}},
+					},
+				},
+				Identifier: "application/vnd.bottle+json",
+			}
+			payload := &design.UserTypeDefinition{
+				TypeName: "UpdateBottlePayload",
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"name": {Type: design.String}},
+				},
+			}
+			action := &design.ActionDefinition{
+				Name:    "update",
+				Payload: payload,
+				Routes: []*design.RouteDefinition{{
+					Verb: "PUT",
+					Path: "/bottles/:id",
+				}},
+				Params: &design.AttributeDefinition{
+					Type: design.Object{
+						"id":    {Type: design.Integer},
+						"force": {Type: design.Boolean},
+					},
+				},
+				QueryParams: &design.AttributeDefinition{
+					Type: design.Object{
+						"force": {Type: design.Boolean},
+					},
+				},
+				Headers: &design.AttributeDefinition{
+					Type: design.Object{
+						"X-Request-Id": {Type: design.String},
+					},
+				},
+				Responses: map[string]*design.ResponseDefinition{
+					"OK": {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+				},
+			}
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"bottle": {
+						Name:    "bottle",
+						Actions: map[string]*design.ActionDefinition{"update": action},
+					},
+				},
+				MediaTypes: map[string]*design.MediaTypeDefinition{bottle.Identifier: bottle},
+			}
+			action.Parent = design.Design.Resources["bottle"]
+			action.Routes[0].Parent = action
+		})
+
+		It("generates the type interfaces", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "ts", "client.ts"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("export interface Bottle {"))
+			Ω(content).Should(ContainSubstring(`  color?: "red" | "white";`))
+			Ω(content).Should(ContainSubstring("  id: number;"))
+			Ω(content).Should(ContainSubstring("  tags?: string[];"))
+			Ω(content).Should(ContainSubstring("export interface UpdateBottlePayload {"))
+		})
+
+		It("generates the typed action method", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "ts", "client.ts"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("export interface UpdateBottleParams {"))
+			Ω(content).Should(ContainSubstring(`  "X-Request-Id"?: string;`))
+			Ω(content).Should(ContainSubstring("async updateBottle(path: string, payload: UpdateBottlePayload, params: UpdateBottleParams = {}, init?: RequestInit): Promise<Bottle> {"))
+			Ω(content).Should(ContainSubstring(`return this.request<Bottle>("PUT", path, payload, query, headers, init);`))
+			Ω(content).Should(ContainSubstring("export function updateBottlePath(id: number): string {"))
+			Ω(content).Should(ContainSubstring("return `/bottles/${encodeURIComponent(String(id))}`;"))
+		})
+	})
+})
@@ -2,18 +2,27 @@ package main
 
 import (
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_design"
 	"github.com/goadesign/goa/goagen/meta"
 	"github.com/goadesign/goa/goagen/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
+// watchDebounce is the duration without design changes after which watch mode regenerates the
+// artifacts.
+const watchDebounce = 500 * time.Millisecond
+
 func main() {
 	var (
 		files            []string
@@ -33,10 +42,24 @@ the "app" command generates the code that supports the service controllers.
 The "bootstrap" command runs the "app", "main", "client" and "swagger" commands generating the
 controllers supporting code and main skeleton code (if not already present) as well as a client
 package and tool and the Swagger specification for the API.
+
+Default flag values may be set in a goagen.yaml file located in the design package directory.
+Top level entries apply to all commands, entries nested under a command name apply to that
+command only. Flags given on the command line override the values read from the file.
+
+The --post-gen flag (or the "post-gen" configuration entries) lists shell commands run after a
+successful generation with the paths of the generated files written to their standard input one
+per line, for example:
+
+  goagen bootstrap -d github.com/acme/cellar/design --post-gen "xargs gofmt -s -w"
 `}
 	var (
 		cwd, designPkg string
+		headerFile     string
+		templateDir    string
 		debug          bool
+		watch, dryRun  bool
+		postGen        []string
 	)
 	cwd, err = os.Getwd()
 	if err != nil {
@@ -45,12 +68,23 @@ package and tool and the Swagger specification for the API.
 	}
 	rootCmd.PersistentFlags().StringVarP(&cwd, "out", "o", cwd, "output directory")
 	rootCmd.PersistentFlags().StringVarP(&designPkg, "design", "d", "", "design package import path")
+	rootCmd.PersistentFlags().StringVar(&headerFile, "header-file", "", "path to a template rendered at the top of the generated Go files in place of the goagen banner.")
+	rootCmd.PersistentFlags().StringVar(&templateDir, "template-dir", "", `path to a directory containing user templates that override the built-in templates by name, e.g. "app/controller.tmpl".`)
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug mode, does not cleanup temporary files.")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "print the diff of the changes to the generated files instead of writing them.")
+	rootCmd.PersistentFlags().BoolVar(&watch, "watch", false, "regenerate the artifacts each time the design package changes, stop with CTRL-C.")
+	rootCmd.PersistentFlags().StringArrayVar(&postGen, "post-gen", nil, "shell command run after a successful generation with the list of generated files on stdin, may be repeated.")
 
 	// appCmd implements the "app" command.
 	var (
 		pkg    string
 		notest bool
+		pool   bool
+		stdctx bool
+		head   bool
+		icpt   bool
+		shard  bool
+		serve  bool
 	)
 	appCmd := &cobra.Command{
 		Use:   "app",
@@ -59,11 +93,18 @@ package and tool and the Swagger specification for the API.
 	}
 	appCmd.Flags().StringVar(&pkg, "pkg", "app", "Name of generated Go package containing controllers supporting code (contexts, media types, user types etc.)")
 	appCmd.Flags().BoolVar(&notest, "notest", false, "Prevent generation of test helpers")
+	appCmd.Flags().BoolVar(&pool, "pool", false, "Reuse action contexts and request data via sync.Pools, controllers must not retain contexts once actions return")
+	appCmd.Flags().BoolVar(&stdctx, "stdcontext", false, `Import the standard library "context" package instead of "golang.org/x/net/context"`)
+	appCmd.Flags().BoolVar(&head, "head", false, "Mount a HEAD handler for each GET route, HEAD requests run the GET action and respond with headers only")
+	appCmd.Flags().BoolVar(&icpt, "interceptors", false, "Generate optional per action interceptor interfaces, controllers that implement them get their Before and After methods called around the action")
+	appCmd.Flags().BoolVar(&shard, "shard", false, "Generate one package per resource and an application package aliasing their declarations, so that changing a resource only recompiles its package")
+	appCmd.Flags().BoolVar(&serve, "swagger", false, "Generate the MountSwagger function serving the Swagger spec at /swagger.json and the Swagger UI and ReDoc pages at /swagger and /redoc")
 	rootCmd.AddCommand(appCmd)
 
 	// mainCmd implements the "main" command.
 	var (
-		force bool
+		force   bool
+		metrics bool
 	)
 	mainCmd := &cobra.Command{
 		Use:   "main",
@@ -71,25 +112,70 @@ package and tool and the Swagger specification for the API.
 		Run:   func(c *cobra.Command, _ []string) { files, err = run("genmain", c) },
 	}
 	mainCmd.Flags().BoolVar(&force, "force", false, "overwrite existing files")
+	mainCmd.Flags().BoolVar(&metrics, "metrics", false, "Mount the Prometheus metrics middleware and serve the metrics at /metrics")
 	rootCmd.AddCommand(mainCmd)
 
 	// clientCmd implements the "client" command.
+	var grpcPkg string
 	clientCmd := &cobra.Command{
 		Use:   "client",
 		Short: "Generate client package and tool",
 		Run:   func(c *cobra.Command, _ []string) { files, err = run("genclient", c) },
 	}
 	clientCmd.Flags().StringVar(&pkg, "pkg", "client", "Name of generated client Go package")
+	clientCmd.Flags().BoolVar(&stdctx, "stdcontext", false, `Import the standard library "context" package instead of "golang.org/x/net/context"`)
+	clientCmd.Flags().StringVar(&grpcPkg, "grpc", "", `import path of the Go package generated by protoc from the file produced by the "proto" command, generates gRPC clients that implement the resource client interfaces`)
 	rootCmd.AddCommand(clientCmd)
 
 	// swaggerCmd implements the "swagger" command.
 	swaggerCmd := &cobra.Command{
 		Use:   "swagger",
 		Short: "Generate Swagger",
-		Run:   func(c *cobra.Command, _ []string) { files, err = run("genswagger", c) },
+		Long: `Generate the Swagger JSON and YAML specs in the "swagger" directory of the output directory.
+The --split flag also generates one spec per resource in the "swagger/resources" directory and
+the "swagger/index.json" file listing them, for APIs too large for the documentation UIs and API
+gateways to load a single spec. The index uses the format of the Swagger UI configuration so that
+it can be given to the UI "configUrl" option.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("genswagger", c) },
 	}
+	var splitSwagger, validateSwagger bool
+	swaggerCmd.Flags().BoolVar(&splitSwagger, "split", false, "Also generate one spec per resource and an index listing them")
+	swaggerCmd.Flags().BoolVar(&validateSwagger, "validate", true, "Validate the generated specs and fail if they break Swagger 2.0 rules")
 	rootCmd.AddCommand(swaggerCmd)
 
+	// mockCmd implements the "mock" command.
+	mockCmd := &cobra.Command{
+		Use:   "mock",
+		Short: "Generate a mock server",
+		Long: `Generate a runnable mock server in the "mockserver" directory of the output directory. The
+server mounts all the action routes and writes example responses synthesized from the design: the
+examples satisfy the attribute validations and media type bodies are rendered with the view given
+in the "view" query string parameter, the default view otherwise. The first success response is
+written unless the X-Mock-Status request header selects another response by status code.
+
+  go run ./mockserver --addr :8080`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("genmock", c) },
+	}
+	mockCmd.Flags().BoolVar(&stdctx, "stdcontext", false, `Import the standard library "context" package instead of "golang.org/x/net/context"`)
+	rootCmd.AddCommand(mockCmd)
+
+	// adminCmd implements the "admin" command.
+	var adminPrefix string
+	adminCmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Generate an admin UI",
+		Long: `Generate an "admin" package serving a minimal HTML/JavaScript admin interface for the
+resources that define CRUD actions: a GET action whose path has no wildcard lists the resource
+instances, the GET, PUT or PATCH and DELETE actions whose path adds a wildcard to the list path
+show, update and delete instances and the POST action on the list path creates them. The create
+and edit forms are derived from the action payloads and their validations. Mount the UI with
+admin.MountController(service).`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("genadmin", c) },
+	}
+	adminCmd.Flags().StringVar(&adminPrefix, "prefix", "/admin", "path under which the admin UI is mounted")
+	adminCmd.Flags().BoolVar(&stdctx, "stdcontext", false, `Import the standard library "context" package instead of "golang.org/x/net/context"`)
+	rootCmd.AddCommand(adminCmd)
+
 	// jsCmd implements the "js" command.
 	var (
 		timeout      = time.Duration(20) * time.Second
@@ -107,31 +193,195 @@ package and tool and the Swagger specification for the API.
 	jsCmd.Flags().BoolVar(&noexample, "noexample", false, `Skip generation of example HTML and controller`)
 	rootCmd.AddCommand(jsCmd)
 
+	// tsCmd implements the "ts" command.
+	tsCmd := &cobra.Command{
+		Use:   "ts",
+		Short: "Generate TypeScript client",
+		Long: `Generate a TypeScript client module in the "ts" directory of the output directory. The module
+declares an interface for each type, media type and payload of the design and a Client class with
+one typed method per action that makes the request with the fetch API. Attributes that enumerate
+their values are typed with the union of the values.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("gents", c) },
+	}
+	tsCmd.Flags().DurationVar(&timeout, "timeout", timeout, `the default duration before the requests time out.`)
+	tsCmd.Flags().StringVar(&scheme, "scheme", "", `the default URL scheme used to make requests to the API, defaults to the scheme defined in the API design if any.`)
+	tsCmd.Flags().StringVar(&host, "host", "", `the default API hostname, defaults to the hostname defined in the API design if any`)
+	rootCmd.AddCommand(tsCmd)
+
+	// protoCmd implements the "proto" command.
+	var protoPkg, goPkg string
+	var bridge bool
+	protoCmd := &cobra.Command{
+		Use:   "proto",
+		Short: "Generate Protocol Buffers definitions",
+		Long: `Generate a .proto file in the "proto" directory of the output directory. The file defines a gRPC
+service per resource with one method per action and the messages of the types, media types and
+payloads. The request message of a method lists the action parameters and headers and holds the
+payload in its "payload" field. The --bridge flag also generates the "grpcbridge" package that
+implements the controllers of the application package by calling the gRPC service handlers
+generated by protoc, so that the same handlers serve both the gRPC and the HTTP requests.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("genproto", c) },
+	}
+	protoCmd.Flags().StringVar(&protoPkg, "package", "", "Protocol Buffers package name, defaults to the snake case API name")
+	protoCmd.Flags().StringVar(&goPkg, "go-package", "", `value of the go_package option, defaults to the "proto" directory of the output directory with package name "pb"`)
+	protoCmd.Flags().BoolVar(&bridge, "bridge", false, "Generate the package adapting the gRPC service handlers to the application controllers")
+	protoCmd.Flags().StringVar(&pkg, "pkg", "app", "Name of the generated application package used by the bridge")
+	rootCmd.AddCommand(protoCmd)
+
+	// docsCmd implements the "docs" command.
+	var format string
+	docsCmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate static HTML documentation",
+		Long: `Generate a static HTML documentation site in the "docs" directory of the output directory. The
+site has an index page with the authentication instructions and one page per resource that
+documents the action parameters, headers, payloads and responses with their validations and
+gives an example curl command line for each action. The pages are self-contained and may be
+served by any static file server.
+
+The "markdown" format produces a README.md file and one Markdown file per resource instead so that
+the API reference can be kept in the repository and reviewed together with the design changes.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("gendocs", c) },
+	}
+	docsCmd.Flags().StringVar(&format, "format", "html", `output format: "html" or "markdown"`)
+	docsCmd.Flags().StringVar(&scheme, "scheme", "", `the URL scheme used in the examples, defaults to the scheme defined in the API design if any.`)
+	docsCmd.Flags().StringVar(&host, "host", "", `the API hostname used in the examples, defaults to the hostname defined in the API design if any`)
+	rootCmd.AddCommand(docsCmd)
+
+	// asyncapiCmd implements the "asyncapi" command.
+	asyncapiCmd := &cobra.Command{
+		Use:   "asyncapi",
+		Short: "Generate AsyncAPI for the streaming actions",
+		Long: `Generate an AsyncAPI document in the "asyncapi" directory of the output directory describing the
+actions that use websockets or stream their responses with server-sent events. The document lists
+one channel per route with the messages sent by the API and, for websockets, the messages sent by
+the clients.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("genasyncapi", c) },
+	}
+	rootCmd.AddCommand(asyncapiCmd)
+
 	// schemaCmd implements the "schema" command.
 	schemaCmd := &cobra.Command{
 		Use:   "schema",
 		Short: "Generate JSON Schema",
-		Run:   func(c *cobra.Command, _ []string) { files, err = run("genschema", c) },
+		Long: `Generate the API JSON hyper schema in the "schema" directory of the output directory. The
+--split flag also writes the schema of each user type and media type in its own file, the
+references to other types point to the files of these types so that the schemas can be used by
+other services to validate the API requests and responses.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("genschema", c) },
 	}
+	var draft string
+	var split bool
+	schemaCmd.Flags().StringVar(&draft, "draft", "07", `JSON schema draft of the generated schemas: "04" or "07"`)
+	schemaCmd.Flags().BoolVar(&split, "split", false, "Generate one schema file per user type and media type")
 	rootCmd.AddCommand(schemaCmd)
 
+	// exportCmd implements the "export" command.
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export design as a JSON document",
+		Run:   func(c *cobra.Command, _ []string) { files, err = run("genexport", c) },
+	}
+	exportCmd.Flags().StringVar(&format, "format", "json", "Export format, only json is supported")
+	rootCmd.AddCommand(exportCmd)
+
+	// publishCmd implements the "publish" command.
+	var registry, kind, prefix, protoDir string
+	var forcePublish bool
+	publishCmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish the JSON schemas of the API types to a schema registry",
+		Long: `Publish the JSON schemas of the API types and media types to a schema registry, one subject
+per type. The Protocol Buffers files found in the proto directory are published as well. The
+schemas are checked for compatibility with the previously published versions and nothing is
+published if any check fails.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("genpublish", c) },
+	}
+	publishCmd.Flags().StringVar(&registry, "registry", "", "URL of the schema registry, credentials may be given in the URL user information")
+	publishCmd.Flags().StringVar(&kind, "kind", "confluent", `registry kind: "confluent" for Confluent compatible registries or "http" for registries that store the schemas at the registry URL followed by the subject name`)
+	publishCmd.Flags().StringVar(&prefix, "subject-prefix", "", "prefix of the subject names, defaults to the snake case API name")
+	publishCmd.Flags().StringVar(&protoDir, "proto-dir", "", `directory containing the .proto files to publish, defaults to the "proto" directory of the output directory`)
+	publishCmd.Flags().BoolVar(&forcePublish, "force", false, "publish the schemas even if they are not compatible with the previously published versions")
+	rootCmd.AddCommand(publishCmd)
+
+	// lintCmd implements the "lint" command.
+	var report, rules, failOn string
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check the design against lint rules",
+		Long: `Check the design against lint rules and write the findings to a JSON report. The rules are:
+
+  missing-description   the API, resources, actions, types and media types have a description
+  plural-resource-name  the resource names are plural
+  missing-not-found     the GET actions identifying a resource with a path parameter declare a 404 response
+  unused-type           the types and media types are used by an action or by another type
+  inconsistent-casing   the attribute and parameter names use the same casing, snake_case or camelCase
+
+The command fails if any finding has a severity at or above the --fail-on level.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = run("genlint", c) },
+	}
+	lintCmd.Flags().StringVar(&report, "report", "", `path to the JSON report, defaults to "lint.json" in the output directory`)
+	lintCmd.Flags().StringVar(&rules, "rules", "", `comma separated rule severities of the form name=severity where severity is "off", "warning" or "error", e.g. "unused-type=error,plural-resource-name=off"`)
+	lintCmd.Flags().StringVar(&failOn, "fail-on", "error", `lowest severity of the findings that fail the command: "warning", "error" or "off"`)
+	rootCmd.AddCommand(lintCmd)
+
+	// diffCmd implements the "diff" command.
+	var oldPkg, newPkg string
+	var failOnBreaking bool
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Report the changes between two versions of a design",
+		Long: `Evaluate two versions of a design and write the added, removed and changed routes, parameters,
+headers, payloads, responses, types and media types to a JSON report. Each change is classified as
+breaking or non-breaking for the clients built against the old version: removing an element,
+adding a required parameter or attribute or changing the type of an element is breaking, adding
+an optional element is not.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = runDiff(c, oldPkg, newPkg) },
+	}
+	diffCmd.Flags().StringVar(&oldPkg, "old", "", "import path of the old version of the design package")
+	diffCmd.Flags().StringVar(&newPkg, "new", "", "import path of the new version of the design package, defaults to --design")
+	diffCmd.Flags().StringVar(&report, "report", "", `path to the JSON report, defaults to "diff.json" in the output directory`)
+	diffCmd.Flags().BoolVar(&failOnBreaking, "fail-on-breaking", false, "fail the command if any change is breaking")
+	rootCmd.AddCommand(diffCmd)
+
 	// genCmd implements the "gen" command.
 	var (
 		pkgPath string
+		opts    []string
 	)
 	genCmd := &cobra.Command{
 		Use:   "gen",
 		Short: "Run third-party generator",
-		Run:   func(c *cobra.Command, _ []string) { files, err = runGen(c) },
+		Long: `Run a third-party generator against the design.
+
+The generator package must implement the NewGenerator function, see codegen.GeneratorFactory.
+NewGenerator is given the options specified with --opt and returns a codegen.Generator which is
+then run against the evaluated design. Packages that implement the Generate global function
+instead are called directly, they do not support --opt.`,
+		Run: func(c *cobra.Command, _ []string) { files, err = runGen(c, pkgPath, opts) },
 	}
-	genCmd.Flags().StringVar(&pkgPath, "pkg-path", "", "Package import path of generator. The package must implement the Generate global function.")
+	genCmd.Flags().StringVar(&pkgPath, "pkg", "", "Package import path of generator.")
+	genCmd.Flags().StringVar(&pkgPath, "pkg-path", "", "Package import path of generator, deprecated alias of --pkg.")
+	genCmd.Flags().StringSliceVar(&opts, "opt", nil, "Generator option of the form name=value, may be repeated.")
 	rootCmd.AddCommand(genCmd)
 
 	// boostrapCmd implements the "bootstrap" command.
+	var fromSwagger string
 	bootCmd := &cobra.Command{
 		Use:   "bootstrap",
 		Short: `Equivalent to running the "app", "main", "client" and "swagger" commands.`,
+		Long: `Equivalent to running the "app", "main", "client" and "swagger" commands.
+
+With --from-swagger the command instead writes a design package approximating the given Swagger
+2.0 or OpenAPI 3 document (JSON or YAML) in the "design" directory of the output directory. The
+package describes the API, its security schemes, resources, actions, types and media types with
+their validations. Review and complete the design, then run "goagen bootstrap -d" with the import
+path of the design package to generate the service.`,
 		Run: func(c *cobra.Command, a []string) {
+			if fromSwagger != "" {
+				files, err = gendesign.NewGenerator(fromSwagger, cwd).Generate()
+				return
+			}
 			appCmd.Run(c, a)
 			if err != nil {
 				return
@@ -154,19 +404,33 @@ package and tool and the Swagger specification for the API.
 			files = append(prev, files...)
 		},
 	}
+	bootCmd.Flags().StringVar(&fromSwagger, "from-swagger", "", "path to a Swagger or OpenAPI document to generate the design package from")
 	rootCmd.AddCommand(bootCmd)
 
 	// Now proceed with code generation
 	cleanup := func() {
-		for _, f := range files {
-			os.RemoveAll(f)
-		}
+		codegen.RemoveGenerated(files)
 	}
 
+	stop := make(chan struct{})
 	go utils.Catch(nil, func() {
 		terminatedByUser = true
+		close(stop)
 	})
 
+	hooks := func(files []string) error {
+		cmd, _, _ := rootCmd.Find(os.Args[1:])
+		return runHooks(cmd.Name(), designPkg, postGen, files)
+	}
+
+	if watch {
+		if err := watchDesign(rootCmd, &designPkg, stop, &files, &err, hooks); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	rootCmd.Execute()
 
 	if terminatedByUser {
@@ -180,6 +444,70 @@ package and tool and the Swagger specification for the API.
 		os.Exit(1)
 	}
 
+	if !dryRun {
+		fmt.Println(strings.Join(relPaths(files), "\n"))
+		if err := hooks(files); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
+// watchDesign runs the command given on the command line then runs it again each time the
+// design package source files change until stop is closed. designPkg, files and err are set by
+// the command runs. Generation errors do not stop the watch, they are reported and the next
+// change triggers a new run. hooks is called with the files changed by each successful run.
+func watchDesign(rootCmd *cobra.Command, designPkg *string, stop <-chan struct{}, files *[]string, err *error, hooks func([]string) error) error {
+	gen := func() {
+		start := time.Now()
+		rootCmd.Execute()
+		if *err != nil {
+			fmt.Fprintln(os.Stderr, (*err).Error())
+			return
+		}
+		changed := changedFiles(*files, start)
+		fmt.Printf("regenerated %d file(s)\n", len(changed))
+		for _, f := range relPaths(changed) {
+			fmt.Println("  " + f)
+		}
+		if herr := hooks(changed); herr != nil {
+			fmt.Fprintln(os.Stderr, herr.Error())
+		}
+	}
+	gen()
+	if *designPkg == "" {
+		return fmt.Errorf("missing design package path, specify it with --design")
+	}
+	dir, perr := codegen.PackageSourcePath(*designPkg)
+	if perr != nil {
+		return fmt.Errorf("invalid design package import path: %s", perr)
+	}
+	fmt.Printf("watching %s for changes...\n", dir)
+	return utils.WatchDir(dir, watchDebounce, stop, func(design []string) {
+		fmt.Printf("design changed: %s\n", strings.Join(relPaths(design), ", "))
+		gen()
+	})
+}
+
+// changedFiles returns the files and the files of the directories listed in files that were
+// modified after since. Generators do not write the files whose content does not change so that
+// only the files actually impacted by the design changes are returned.
+func changedFiles(files []string, since time.Time) []string {
+	var changed []string
+	for _, f := range files {
+		filepath.Walk(f, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && !info.ModTime().Before(since) {
+				changed = append(changed, path)
+			}
+			return nil
+		})
+	}
+	return changed
+}
+
+// relPaths returns the paths relative to the current working directory, the paths that cannot be
+// made relative are returned unchanged.
+func relPaths(files []string) []string {
 	rels := make([]string, len(files))
 	cd, _ := os.Getwd()
 	for i, f := range files {
@@ -190,7 +518,45 @@ package and tool and the Swagger specification for the API.
 			rels[i] = f
 		}
 	}
-	fmt.Println(strings.Join(rels, "\n"))
+	return rels
+}
+
+// runHooks runs the post-generation hooks with the generated files given as input. The hooks
+// given on the command line take precedence over the hooks configured for the command in the
+// design package configuration file.
+func runHooks(name, designPkg string, hooks, files []string) error {
+	if len(hooks) == 0 && designPkg != "" {
+		if dir, err := codegen.PackageSourcePath(designPkg); err == nil {
+			cfg, err := utils.LoadConfig(dir)
+			if err != nil {
+				return err
+			}
+			if cfg != nil {
+				hooks = cfg.Hooks(name)
+			}
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+	var generated []string
+	seen := make(map[string]bool)
+	for _, f := range files {
+		filepath.Walk(f, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && !seen[path] {
+				seen[path] = true
+				generated = append(generated, path)
+			}
+			return nil
+		})
+	}
+	generated = relPaths(generated)
+	for _, h := range hooks {
+		if err := utils.RunHook(h, generated); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func run(pkg string, c *cobra.Command) ([]string, error) {
@@ -203,11 +569,13 @@ func run(pkg string, c *cobra.Command) ([]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid package import path: %s", err)
 	}
-	return generate(pkgName, pkgPath, c)
+	return generate(pkg[3:], pkgName+".Generate", pkgPath, false, nil, c)
 }
 
-func runGen(c *cobra.Command) ([]string, error) {
-	pkgPath := c.Flag("pkg-path").Value.String()
+func runGen(c *cobra.Command, pkgPath string, opts []string) ([]string, error) {
+	if pkgPath == "" {
+		return nil, fmt.Errorf("missing generator package import path, use --pkg")
+	}
 	pkgSrcPath, err := codegen.PackageSourcePath(pkgPath)
 	if err != nil {
 		return nil, fmt.Errorf("invalid plugin package import path: %s", err)
@@ -216,26 +584,154 @@ func runGen(c *cobra.Command) ([]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid plugin package import path: %s", err)
 	}
-	return generate(pkgName, pkgPath, c)
+	plugin, err := definesFunc(pkgSrcPath, "NewGenerator")
+	if err != nil {
+		return nil, fmt.Errorf("invalid plugin package: %s", err)
+	}
+	if !plugin {
+		if len(opts) > 0 {
+			return nil, fmt.Errorf("generator %s does not implement NewGenerator and does not support --opt", pkgPath)
+		}
+		return generate("gen", pkgName+".Generate", pkgPath, false, nil, c)
+	}
+	extra := make(map[string]string, len(opts))
+	for _, o := range opts {
+		elems := strings.SplitN(o, "=", 2)
+		if len(elems) != 2 || elems[0] == "" {
+			return nil, fmt.Errorf("invalid generator option %#v, must be of the form name=value", o)
+		}
+		if f := c.Flags().Lookup(elems[0]); f != nil {
+			return nil, fmt.Errorf("invalid generator option %#v, %s is a goagen flag", o, elems[0])
+		}
+		extra[elems[0]] = elems[1]
+	}
+	return generate("gen", pkgName+".NewGenerator", pkgPath, true, extra, c)
 }
 
-func generate(pkgName, pkgPath string, c *cobra.Command) ([]string, error) {
+// runDiff exports the old design to a temporary directory then runs the diff generator against the
+// new design.
+func runDiff(c *cobra.Command, oldPkg, newPkg string) ([]string, error) {
+	if newPkg == "" {
+		newPkg = c.Flag("design").Value.String()
+	}
+	if oldPkg == "" || newPkg == "" {
+		return nil, fmt.Errorf("missing design package import path, use --old and --new")
+	}
+	tmpDir, err := ioutil.TempDir("", "goagen-diff")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	export, err := meta.NewGenerator(
+		"genexport.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_export")},
+		map[string]string{"design": oldPkg, "out": tmpDir, "debug": c.Flag("debug").Value.String()},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := export.Generate(); err != nil {
+		return nil, fmt.Errorf("failed to evaluate old design %s: %s", oldPkg, err)
+	}
+	extra := map[string]string{"design": newPkg, "old": filepath.Join(tmpDir, "export", "design.json")}
+	return generate("diff", "gendiff.Generate", "github.com/goadesign/goa/goagen/gen_diff", false, extra, c)
+}
+
+// configFlags returns the flag values configured for the given command in the configuration file
+// of the design package. It returns an error if the file configures unknown commands or flags.
+func configFlags(name, designPkg string, root *cobra.Command) (map[string]string, error) {
+	if designPkg == "" {
+		return nil, nil
+	}
+	dir, err := codegen.PackageSourcePath(designPkg)
+	if err != nil {
+		return nil, nil // reported by the generator
+	}
+	cfg, err := utils.LoadConfig(dir)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+	for k := range cfg.Global {
+		if root.PersistentFlags().Lookup(k) == nil {
+			return nil, fmt.Errorf("%s: %#v is not a flag common to all commands", cfg.Path, k)
+		}
+	}
+	for _, n := range cfg.CommandNames() {
+		cmd, _, err := root.Find([]string{n})
+		if err != nil || cmd == root {
+			return nil, fmt.Errorf("%s: unknown command %#v", cfg.Path, n)
+		}
+		for k := range cfg.Commands[n] {
+			if cmd.Flags().Lookup(k) == nil && root.PersistentFlags().Lookup(k) == nil {
+				return nil, fmt.Errorf("%s: unknown flag %#v for command %#v", cfg.Path, k, n)
+			}
+		}
+	}
+	return cfg.Flags(name), nil
+}
+
+// definesFunc returns true if the Go package in dir declares a function with the given name.
+func definesFunc(dir, name string) (bool, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return false, err
+	}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == name {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// generate compiles and runs the generator tool that calls genfunc. name is the name of the
+// command that runs the generator, the flags not given on the command line default to the values
+// configured for it in the design package configuration file if any. plugin indicates whether
+// genfunc is the factory of a generator plugin, extra contains the plugin options.
+func generate(name, genfunc, pkgPath string, plugin bool, extra map[string]string, c *cobra.Command) ([]string, error) {
 	m := make(map[string]string)
 	c.Flags().Visit(func(f *pflag.Flag) {
-		if f.Name != "pkg-path" {
+		switch f.Name {
+		case "watch", "post-gen":
+		case "pkg", "pkg-path", "opt":
+			if c.Name() != "gen" {
+				m[f.Name] = f.Value.String()
+			}
+		default:
 			m[f.Name] = f.Value.String()
 		}
 	})
+	defaults, err := configFlags(name, m["design"], c.Root())
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range defaults {
+		if _, ok := m[k]; ok || k == "watch" || k == "post-gen" || name == "gen" && (k == "pkg" || k == "pkg-path" || k == "opt") {
+			continue
+		}
+		m[k] = v
+	}
 	if _, ok := m["out"]; !ok {
 		m["out"] = c.Flag("out").DefValue
 	}
+	for k, v := range extra {
+		m[k] = v
+	}
 	gen, err := meta.NewGenerator(
-		pkgName+".Generate",
+		genfunc,
 		[]*codegen.ImportSpec{codegen.SimpleImport(pkgPath)},
 		m,
 	)
 	if err != nil {
 		return nil, err
 	}
+	gen.Plugin = plugin
 	return gen.Generate()
 }
@@ -24,6 +24,11 @@ type Generator struct {
 	// func <Genfunc>([]dslengine.Root) ([]string, error)
 	Genfunc string
 
+	// Plugin indicates that Genfunc is the factory function of a generator plugin, see
+	// codegen.GeneratorFactory. The tool then runs the generator returned by the factory with
+	// codegen.RunPlugin instead of calling Genfunc.
+	Plugin bool
+
 	// Imports list the imports that are specific for that generator that
 	// should be added to the main Go file.
 	Imports []*codegen.ImportSpec
@@ -38,6 +43,21 @@ type Generator struct {
 	// DesignPkgPath is the Go import path to the design package.
 	DesignPkgPath string
 
+	// Banner is the template used to render the banner of the generated Go source files, see
+	// codegen.SetHeaderBanner. The default goagen banner is used if empty.
+	Banner string
+
+	// TemplateDir is the directory containing the user templates that override the built-in
+	// templates of the generator, see codegen.LoadTemplateOverrides. The overrides of a
+	// generator are read from the subdirectory named after the generator package without the
+	// "gen" prefix, e.g. "app" for the genapp package.
+	TemplateDir string
+
+	// DryRun causes Generate to run the generator against a temporary copy of the output
+	// directory and to print the unified diff of the changes it would make instead of making
+	// them.
+	DryRun bool
+
 	debug bool
 }
 
@@ -46,7 +66,8 @@ type Generator struct {
 func NewGenerator(genfunc string, imports []*codegen.ImportSpec, flags map[string]string) (*Generator, error) {
 	var (
 		outDir, designPkgPath string
-		debug                 bool
+		debug, dryRun         bool
+		banner, templateDir   string
 	)
 
 	if o, ok := flags["out"]; ok {
@@ -62,6 +83,39 @@ func NewGenerator(genfunc string, imports []*codegen.ImportSpec, flags map[strin
 			return nil, fmt.Errorf("failed to parse debug flag: %s", err)
 		}
 	}
+	if d, ok := flags["dry-run"]; ok {
+		var err error
+		dryRun, err = strconv.ParseBool(d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dry-run flag: %s", err)
+		}
+	}
+	if h, ok := flags["header-file"]; ok {
+		b, err := ioutil.ReadFile(h)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header file: %s", err)
+		}
+		banner = string(b)
+	}
+	if t, ok := flags["template-dir"]; ok {
+		info, err := os.Stat(t)
+		if err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("invalid template directory %#v", t)
+		}
+		templateDir, err = filepath.Abs(t)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The banner, the template overrides and the dry run mode are handled by the generator
+	// tool, the generators do not define the flags.
+	genflags := make(map[string]string, len(flags))
+	for k, v := range flags {
+		if k != "header-file" && k != "template-dir" && k != "dry-run" {
+			genflags[k] = v
+		}
+	}
+	flags = genflags
 
 	return &Generator{
 		Genfunc:       genfunc,
@@ -69,6 +123,9 @@ func NewGenerator(genfunc string, imports []*codegen.ImportSpec, flags map[strin
 		Flags:         flags,
 		OutDir:        outDir,
 		DesignPkgPath: designPkgPath,
+		Banner:        banner,
+		TemplateDir:   templateDir,
+		DryRun:        dryRun,
 		debug:         debug,
 	}, nil
 }
@@ -76,7 +133,11 @@ func NewGenerator(genfunc string, imports []*codegen.ImportSpec, flags map[strin
 // Generate compiles and runs the generator and returns the generated filenames.
 func (m *Generator) Generate() ([]string, error) {
 	// Sanity checks
-	if os.Getenv("GOPATH") == "" {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if _, mod := codegen.ModuleFor(wd); mod == "" && os.Getenv("GOPATH") == "" {
 		return nil, fmt.Errorf("GOPATH not set")
 	}
 	if m.OutDir == "" {
@@ -87,15 +148,14 @@ func (m *Generator) Generate() ([]string, error) {
 	}
 
 	// Create output directory
-	if err := os.MkdirAll(m.OutDir, 0755); err != nil {
-		return nil, err
+	if !m.DryRun {
+		if err := os.MkdirAll(m.OutDir, 0755); err != nil {
+			return nil, err
+		}
 	}
 
-	// Create temporary workspace used for generation
-	wd, err := os.Getwd()
-	if err != nil {
-		return nil, err
-	}
+	// Create temporary workspace used for generation, the workspace lives in the working
+	// directory so that it belongs to the same GOPATH or Go module.
 	tmpDir, err := ioutil.TempDir(wd, "goagen")
 	if err != nil {
 		if _, ok := err.(*os.PathError); ok {
@@ -137,7 +197,10 @@ func (m *Generator) Generate() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	return m.spawn(genbin)
+	if m.DryRun {
+		return nil, m.dryRun(genbin, tmpDir)
+	}
+	return m.spawn(genbin, m.Flags, "", nil)
 }
 
 func (m *Generator) generateToolSourceCode(pkg *codegen.Package) {
@@ -148,6 +211,13 @@ func (m *Generator) generateToolSourceCode(pkg *codegen.Package) {
 		codegen.SimpleImport("github.com/goadesign/goa/dslengine"),
 		codegen.NewImport("_", filepath.ToSlash(m.DesignPkgPath)),
 	)
+	if m.Plugin {
+		imports = append(imports, codegen.SimpleImport("os"))
+	}
+	if m.Banner != "" || m.TemplateDir != "" || m.Plugin {
+		// Use a name that cannot conflict with the generator imports.
+		imports = append(imports, codegen.NewImport("goacodegen", "github.com/goadesign/goa/goagen/codegen"))
+	}
 	file.WriteHeader("Code Generator", "main", imports)
 	tmpl, err := template.New("generator").Parse(mainTmpl)
 	if err != nil {
@@ -157,10 +227,22 @@ func (m *Generator) generateToolSourceCode(pkg *codegen.Package) {
 	if err != nil {
 		panic(err)
 	}
+	var templateDir string
+	if m.TemplateDir != "" {
+		genPkg := strings.SplitN(m.Genfunc, ".", 2)[0]
+		templateDir = filepath.Join(m.TemplateDir, strings.TrimPrefix(genPkg, "gen"))
+	}
+	var plugin string
+	if m.Plugin {
+		plugin = "true"
+	}
 	context := map[string]string{
 		"Genfunc":       m.Genfunc,
+		"Plugin":        plugin,
 		"DesignPackage": m.DesignPkgPath,
 		"PkgName":       pkgName,
+		"Banner":        m.Banner,
+		"TemplateDir":   templateDir,
 	}
 	err = tmpl.Execute(file, context)
 	if err != nil {
@@ -168,17 +250,20 @@ func (m *Generator) generateToolSourceCode(pkg *codegen.Package) {
 	}
 }
 
-// spawn runs the compiled generator using the arguments initialized by Kingpin
-// when parsing the command line.
-func (m *Generator) spawn(genbin string) ([]string, error) {
-	args := make([]string, len(m.Flags))
+// spawn runs the compiled generator using the given flags. dir is the working directory of the
+// generator and env its environment, the current working directory and environment are used if
+// empty.
+func (m *Generator) spawn(genbin string, flags map[string]string, dir string, env []string) ([]string, error) {
+	args := make([]string, len(flags))
 	i := 0
-	for k, v := range m.Flags {
+	for k, v := range flags {
 		args[i] = fmt.Sprintf("--%s=%s", k, v)
 		i++
 	}
 	sort.Strings(args)
 	cmd := exec.Command(genbin, args...)
+	cmd.Dir = dir
+	cmd.Env = env
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("%s\n%s", err, string(out))
@@ -190,6 +275,138 @@ func (m *Generator) spawn(genbin string) ([]string, error) {
 	return res, nil
 }
 
+// dryRun runs the compiled generator against a copy of the output directory created in a
+// temporary GOPATH or Go module and prints the diff between the copy and the output directory.
+// The copy has the same import path as the output directory so that the generated code is
+// identical. tmpDir is the directory containing the generator tool, it is not copied.
+func (m *Generator) dryRun(genbin, tmpDir string) error {
+	outDir, err := filepath.Abs(m.OutDir)
+	if err != nil {
+		return err
+	}
+	outPkg, err := codegen.PackagePath(outDir)
+	if err != nil {
+		return fmt.Errorf("dry run requires the output directory to be in the GOPATH or in a Go module: %s", err)
+	}
+	gopath, err := ioutil.TempDir("", "goagen")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(gopath)
+
+	// mirrorDir returns the path to the copy of the package with the given import path.
+	mirrorDir := func(pkg string) string {
+		return filepath.Join(gopath, "src", filepath.FromSlash(pkg))
+	}
+	env := append(os.Environ(), "GOPATH="+gopath+string(filepath.ListSeparator)+os.Getenv("GOPATH"))
+	if root, mod := codegen.ModuleFor(outDir); mod != "" {
+		// Copy the whole module so that the generator finds the module packages and
+		// computes the same import paths.
+		if err := copyDir(root, gopath, tmpDir); err != nil {
+			return err
+		}
+		mirrorDir = func(pkg string) string {
+			return filepath.Join(gopath, filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(pkg, mod), "/")))
+		}
+		env = nil
+	}
+	mirror := mirrorDir(outPkg)
+	if err := copyDir(outDir, mirror, tmpDir); err != nil {
+		return err
+	}
+
+	// Run the generator in the mirror of the working directory so that the command line
+	// recorded in the generated files does not change.
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	var dir string
+	if wdPkg, err := codegen.PackagePath(wd); err == nil {
+		dir = mirrorDir(wdPkg)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	flags := make(map[string]string, len(m.Flags))
+	for k, v := range m.Flags {
+		flags[k] = v
+	}
+	flags["out"] = mirror
+	files, err := m.spawn(genbin, flags, dir, env)
+	if err != nil {
+		return err
+	}
+
+	// Diff the generated files and the files of the generated directories.
+	changed := make(map[string]bool)
+	for _, f := range files {
+		rel, err := filepath.Rel(mirror, f)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		for _, root := range []string{mirror, outDir} {
+			filepath.Walk(filepath.Join(root, rel), func(path string, info os.FileInfo, err error) error {
+				if err == nil && info.Mode().IsRegular() {
+					r, _ := filepath.Rel(root, path)
+					changed[r] = true
+				}
+				return nil
+			})
+		}
+	}
+	paths := make([]string, 0, len(changed))
+	for p := range changed {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		actual, _ := ioutil.ReadFile(filepath.Join(outDir, p))
+		gen, _ := ioutil.ReadFile(filepath.Join(mirror, p))
+		name := filepath.Join(outDir, p)
+		if r, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(r, "..") {
+			name = r
+		}
+		fmt.Print(codegen.UnifiedDiff(filepath.ToSlash(name), actual, gen))
+	}
+	return nil
+}
+
+// copyDir copies the files of the src directory to dst. Hidden directories, vendor directories
+// and the skip directory are not copied. dst is created empty if src does not exist.
+func copyDir(src, dst, skip string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == src && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			if path == skip || path != src && (strings.HasPrefix(info.Name(), ".") || info.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, b, info.Mode())
+	})
+}
+
 const mainTmpl = `
 func main() {
 	// Check if there were errors while running the first DSL pass
@@ -197,8 +414,14 @@ func main() {
 
 	// Now run the secondary DSLs
 	dslengine.FailOnError(dslengine.Run())
-
-	files, err := {{.Genfunc}}()
+{{if .Banner}}
+	// Use the custom banner in the headers of the generated files
+	dslengine.FailOnError(goacodegen.SetHeaderBanner({{printf "%q" .Banner}}))
+{{end}}{{if .TemplateDir}}
+	// Load the user templates that override the generator templates
+	dslengine.FailOnError(goacodegen.LoadTemplateOverrides({{printf "%q" .TemplateDir}}))
+{{end}}
+{{if .Plugin}}	files, err := goacodegen.RunPlugin({{.Genfunc}}, os.Args[1:]){{else}}	files, err := {{.Genfunc}}(){{end}}
 	dslengine.FailOnError(err)
 
 	// We're done
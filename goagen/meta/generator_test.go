@@ -271,3 +271,94 @@ func Generate() ([]string, error) {
 }
 `
 )
+
+var _ = Describe("NewGenerator", func() {
+	var flags map[string]string
+	var m *meta.Generator
+	var newErr error
+
+	BeforeEach(func() {
+		flags = map[string]string{"out": "out", "design": "design"}
+	})
+
+	JustBeforeEach(func() {
+		m, newErr = meta.NewGenerator("genapp.Generate", nil, flags)
+	})
+
+	Context("with a header file", func() {
+		var headerFile string
+
+		BeforeEach(func() {
+			f, err := ioutil.TempFile("", "header")
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = f.WriteString("Copyright Acme")
+			Ω(err).ShouldNot(HaveOccurred())
+			f.Close()
+			headerFile = f.Name()
+			flags["header-file"] = headerFile
+		})
+
+		AfterEach(func() {
+			os.Remove(headerFile)
+		})
+
+		It("loads the banner and does not forward the flag to the generator", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(m.Banner).Should(Equal("Copyright Acme"))
+			Ω(m.Flags).Should(Equal(map[string]string{"out": "out", "design": "design"}))
+		})
+	})
+
+	Context("with the dry-run flag", func() {
+		BeforeEach(func() {
+			flags["dry-run"] = "true"
+		})
+
+		It("enables the dry run mode and does not forward the flag to the generator", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(m.DryRun).Should(BeTrue())
+			Ω(m.Flags).Should(Equal(map[string]string{"out": "out", "design": "design"}))
+		})
+	})
+
+	Context("with a template directory", func() {
+		var templateDir string
+
+		BeforeEach(func() {
+			var err error
+			templateDir, err = ioutil.TempDir("", "templates")
+			Ω(err).ShouldNot(HaveOccurred())
+			flags["template-dir"] = templateDir
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(templateDir)
+		})
+
+		It("records the directory and does not forward the flag to the generator", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(m.TemplateDir).Should(Equal(templateDir))
+			Ω(m.Flags).Should(Equal(map[string]string{"out": "out", "design": "design"}))
+		})
+	})
+
+	Context("with a missing template directory", func() {
+		BeforeEach(func() {
+			flags["template-dir"] = "/does/not/exist"
+		})
+
+		It("fails", func() {
+			Ω(newErr).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a missing header file", func() {
+		BeforeEach(func() {
+			flags["header-file"] = "/does/not/exist"
+		})
+
+		It("fails", func() {
+			Ω(newErr).Should(HaveOccurred())
+		})
+	})
+})
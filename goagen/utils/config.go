@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFile is the name of the goagen configuration file looked up in the design package
+// directory.
+const ConfigFile = "goagen.yaml"
+
+// HooksKey is the configuration entry that lists the post-generation hook commands, see Hooks.
+const HooksKey = "post-gen"
+
+// pathFlags lists the flags whose relative values are resolved against the directory of the
+// configuration file.
+var pathFlags = map[string]bool{"out": true, "header-file": true, "template-dir": true}
+
+// Config contains the default flag values read from a goagen configuration file. The top level
+// scalar entries of the file apply to all the commands and the top level maps contain the flags
+// of the command with the same name, e.g.:
+//
+//	out: ..                # Applies to all commands
+//	app:
+//	  notest: true         # Applies to "goagen app" and "goagen bootstrap"
+//	client:
+//	  pkg: apiclient
+//
+// Command specific values override the values that apply to all commands, flags given on the
+// command line override both. The "post-gen" entries list the commands run after a successful
+// generation, a single command may also be given as a string:
+//
+//	post-gen:
+//	  - go vet ./...
+//	app:
+//	  post-gen: xargs gofmt -s -w
+type Config struct {
+	// Path is the path to the configuration file.
+	Path string
+	// Global contains the flags that apply to all the commands indexed by name.
+	Global map[string]string
+	// Commands contains the flags of each command indexed by command name then flag name.
+	Commands map[string]map[string]string
+	// PostGen contains the post-generation hook commands indexed by command name, the hooks
+	// that apply to all commands are indexed by the empty string.
+	PostGen map[string][]string
+}
+
+// LoadConfig reads the configuration file in dir. It returns nil and no error if there is no
+// such file. Relative paths given to the "out", "header-file" and "template-dir" flags are made
+// absolute using the directory of the file so that the generated files do not depend on the
+// working directory goagen runs in.
+func LoadConfig(dir string) (*Config, error) {
+	path := filepath.Join(dir, ConfigFile)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %s", path, err)
+	}
+	c := &Config{
+		Path:     path,
+		Global:   make(map[string]string),
+		Commands: make(map[string]map[string]string),
+		PostGen:  make(map[string][]string),
+	}
+	for k, v := range raw {
+		if k == HooksKey {
+			hooks, err := configHooks(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid configuration file %s: %s: %s", path, k, err)
+			}
+			c.PostGen[""] = hooks
+			continue
+		}
+		if m, ok := v.(map[interface{}]interface{}); ok {
+			flags := make(map[string]string, len(m))
+			for fk, fv := range m {
+				name := fmt.Sprint(fk)
+				if name == HooksKey {
+					hooks, err := configHooks(fv)
+					if err != nil {
+						return nil, fmt.Errorf("invalid configuration file %s: %s.%s: %s", path, k, name, err)
+					}
+					c.PostGen[k] = hooks
+					continue
+				}
+				val, err := configValue(dir, name, fv)
+				if err != nil {
+					return nil, fmt.Errorf("invalid configuration file %s: %s.%s: %s", path, k, name, err)
+				}
+				flags[name] = val
+			}
+			c.Commands[k] = flags
+			continue
+		}
+		val, err := configValue(dir, k, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration file %s: %s: %s", path, k, err)
+		}
+		c.Global[k] = val
+	}
+	return c, nil
+}
+
+// Flags returns the flag values that apply to the given command indexed by flag name.
+func (c *Config) Flags(command string) map[string]string {
+	flags := make(map[string]string, len(c.Global))
+	for k, v := range c.Global {
+		flags[k] = v
+	}
+	for k, v := range c.Commands[command] {
+		flags[k] = v
+	}
+	return flags
+}
+
+// Hooks returns the post-generation hook commands that apply to the given command: the hooks
+// that apply to all commands followed by the command specific hooks.
+func (c *Config) Hooks(command string) []string {
+	hooks := append([]string{}, c.PostGen[""]...)
+	return append(hooks, c.PostGen[command]...)
+}
+
+// configHooks returns the hook commands listed by a "post-gen" configuration entry.
+func configHooks(v interface{}) ([]string, error) {
+	switch actual := v.(type) {
+	case string:
+		return []string{actual}, nil
+	case []interface{}:
+		hooks := make([]string, len(actual))
+		for i, e := range actual {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("hook commands must be strings")
+			}
+			hooks[i] = s
+		}
+		return hooks, nil
+	}
+	return nil, fmt.Errorf("must be a command or a list of commands")
+}
+
+// configValue returns the command line representation of the value of a configuration entry.
+func configValue(dir, name string, v interface{}) (string, error) {
+	switch actual := v.(type) {
+	case nil:
+		return "", fmt.Errorf("missing value")
+	case map[interface{}]interface{}:
+		return "", fmt.Errorf("nested maps are not supported")
+	case []interface{}:
+		vals := make([]string, len(actual))
+		for i, e := range actual {
+			vals[i] = fmt.Sprint(e)
+		}
+		return strings.Join(vals, ","), nil
+	}
+	val := fmt.Sprint(v)
+	if pathFlags[name] && !filepath.IsAbs(val) {
+		val = filepath.Join(dir, val)
+	}
+	return val, nil
+}
+
+// CommandNames returns the sorted names of the commands configured in c.
+func (c *Config) CommandNames() []string {
+	names := make([]string, 0, len(c.Commands))
+	for n := range c.Commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,130 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LoadConfig", func() {
+	var dir, content string
+	var config *utils.Config
+	var err error
+
+	BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "config")
+		Ω(err).ShouldNot(HaveOccurred())
+		content = ""
+	})
+
+	JustBeforeEach(func() {
+		if content != "" {
+			err = ioutil.WriteFile(filepath.Join(dir, utils.ConfigFile), []byte(content), 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+		config, err = utils.LoadConfig(dir)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("with no configuration file", func() {
+		It("returns nil", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(config).Should(BeNil())
+		})
+	})
+
+	Context("with global and command flags", func() {
+		BeforeEach(func() {
+			content = "pkg: api\nnotest: false\napp:\n  notest: true\nclient:\n  tool: cellar-cli\n"
+		})
+
+		It("overrides the global flags with the command flags", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(config.Path).Should(Equal(filepath.Join(dir, utils.ConfigFile)))
+			Ω(config.Flags("app")).Should(Equal(map[string]string{"pkg": "api", "notest": "true"}))
+			Ω(config.Flags("client")).Should(Equal(map[string]string{"pkg": "api", "notest": "false", "tool": "cellar-cli"}))
+			Ω(config.Flags("swagger")).Should(Equal(map[string]string{"pkg": "api", "notest": "false"}))
+			Ω(config.CommandNames()).Should(Equal([]string{"app", "client"}))
+		})
+	})
+
+	Context("with relative paths", func() {
+		BeforeEach(func() {
+			content = "out: ..\nheader-file: header.txt\napp:\n  template-dir: tmpl\n  pkg: gen\nclient:\n  out: /tmp/client\n"
+		})
+
+		It("resolves them against the directory of the file", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			flags := config.Flags("app")
+			Ω(flags["out"]).Should(Equal(filepath.Dir(dir)))
+			Ω(flags["header-file"]).Should(Equal(filepath.Join(dir, "header.txt")))
+			Ω(flags["template-dir"]).Should(Equal(filepath.Join(dir, "tmpl")))
+			Ω(flags["pkg"]).Should(Equal("gen"))
+			Ω(config.Flags("client")["out"]).Should(Equal("/tmp/client"))
+		})
+	})
+
+	Context("with post-gen hooks", func() {
+		BeforeEach(func() {
+			content = "post-gen:\n  - go vet ./...\n  - go build ./...\napp:\n  post-gen: xargs gofmt -s -w\n"
+		})
+
+		It("accepts lists and strings", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(config.Hooks("app")).Should(Equal([]string{"go vet ./...", "go build ./...", "xargs gofmt -s -w"}))
+			Ω(config.Hooks("client")).Should(Equal([]string{"go vet ./...", "go build ./..."}))
+			Ω(config.Flags("app")).Should(BeEmpty())
+		})
+	})
+
+	Context("with invalid post-gen hooks", func() {
+		BeforeEach(func() {
+			content = "post-gen:\n  cmd: go vet\n"
+		})
+
+		It("fails", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("post-gen"))
+		})
+	})
+
+	Context("with nested maps", func() {
+		BeforeEach(func() {
+			content = "app:\n  design:\n    pkg: design\n"
+		})
+
+		It("fails", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("app.design: nested maps are not supported"))
+		})
+	})
+
+	Context("with null values", func() {
+		BeforeEach(func() {
+			content = "pkg:\n"
+		})
+
+		It("fails", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("pkg: missing value"))
+		})
+	})
+
+	Context("with invalid YAML", func() {
+		BeforeEach(func() {
+			content = "app: [\n"
+		})
+
+		It("fails", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("invalid configuration file"))
+		})
+	})
+})
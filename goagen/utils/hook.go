@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// RunHook runs the given post-generation hook command with the shell. The paths of the generated
+// files are written to the command standard input one per line so that hooks may process them,
+// e.g. "xargs gofmt -s -w". The command output is forwarded to the goagen standard output and
+// error.
+func RunHook(command string, files []string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var input string
+	if len(files) > 0 {
+		input = strings.Join(files, "\n") + "\n"
+	}
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("post-gen hook %q failed: %w", command, err)
+	}
+	return nil
+}
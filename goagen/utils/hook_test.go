@@ -0,0 +1,49 @@
+package utils_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/goadesign/goa/goagen/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RunHook", func() {
+	var dir string
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip("hooks run with cmd on Windows")
+		}
+		var err error
+		dir, err = ioutil.TempDir("", "hook")
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("writes the generated files to the command standard input", func() {
+		out := filepath.Join(dir, "files.txt")
+		files := []string{filepath.Join(dir, "app", "controllers.go"), filepath.Join(dir, "client", "client.go")}
+		Ω(utils.RunHook("cat > "+out, files)).ShouldNot(HaveOccurred())
+		b, err := ioutil.ReadFile(out)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(b)).Should(Equal(files[0] + "\n" + files[1] + "\n"))
+	})
+
+	It("returns the error of failing commands", func() {
+		err := utils.RunHook("exit 3", nil)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring(`post-gen hook "exit 3" failed`))
+		var exitErr *exec.ExitError
+		Ω(errors.As(err, &exitErr)).Should(BeTrue())
+		Ω(exitErr.ExitCode()).Should(Equal(3))
+	})
+})
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchInterval is the interval at which WatchDir polls the watched files.
+const watchInterval = 250 * time.Millisecond
+
+// WatchDir polls the Go source files under dir until stop is closed. When files are created,
+// modified or deleted it waits until no other change happens for the debounce duration then
+// calls changed with the sorted paths of the files that changed. Debouncing makes it possible to
+// save many files at once (e.g. when switching branches) and get a single notification.
+func WatchDir(dir string, debounce time.Duration, stop <-chan struct{}, changed func(files []string)) error {
+	prev, err := scanGoFiles(dir)
+	if err != nil {
+		return err
+	}
+	var (
+		pending    = make(map[string]bool)
+		lastChange time.Time
+		ticker     = time.NewTicker(watchInterval)
+	)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case now := <-ticker.C:
+			cur, err := scanGoFiles(dir)
+			if err != nil {
+				return err
+			}
+			for path, mod := range cur {
+				if pmod, ok := prev[path]; !ok || !pmod.Equal(mod) {
+					pending[path] = true
+					lastChange = now
+				}
+			}
+			for path := range prev {
+				if _, ok := cur[path]; !ok {
+					pending[path] = true
+					lastChange = now
+				}
+			}
+			prev = cur
+			if len(pending) == 0 || now.Sub(lastChange) < debounce {
+				continue
+			}
+			files := make([]string, 0, len(pending))
+			for path := range pending {
+				files = append(files, path)
+			}
+			sort.Strings(files)
+			pending = make(map[string]bool)
+			changed(files)
+		}
+	}
+}
+
+// scanGoFiles returns the modification times of the Go files under dir indexed by path. Hidden
+// directories are skipped.
+func scanGoFiles(dir string) (map[string]time.Time, error) {
+	files := make(map[string]time.Time)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".go" {
+			files[path] = info.ModTime()
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,71 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa/goagen/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WatchDir", func() {
+	var dir string
+	var stop chan struct{}
+	var done chan error
+	var mu sync.Mutex
+	var calls [][]string
+
+	received := func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([][]string(nil), calls...)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "watch")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ioutil.WriteFile(filepath.Join(dir, "design.go"), []byte("package design"), 0644)).ShouldNot(HaveOccurred())
+		Ω(ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644)).ShouldNot(HaveOccurred())
+		calls = nil
+		stop = make(chan struct{})
+		done = make(chan error, 1)
+		go func() {
+			done <- utils.WatchDir(dir, 300*time.Millisecond, stop, func(files []string) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, files)
+			})
+		}()
+		// Let WatchDir record the initial state of the directory.
+		time.Sleep(50 * time.Millisecond)
+	})
+
+	AfterEach(func() {
+		close(stop)
+		Eventually(done).Should(Receive(BeNil()))
+		os.RemoveAll(dir)
+	})
+
+	It("calls back once for a burst of changes", func() {
+		for i := 1; i <= 3; i++ {
+			mod := time.Now().Add(time.Duration(i) * time.Second)
+			Ω(os.Chtimes(filepath.Join(dir, "design.go"), mod, mod)).ShouldNot(HaveOccurred())
+			time.Sleep(20 * time.Millisecond)
+		}
+		Ω(ioutil.WriteFile(filepath.Join(dir, "types.go"), []byte("package design"), 0644)).ShouldNot(HaveOccurred())
+		Ω(ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("more notes"), 0644)).ShouldNot(HaveOccurred())
+
+		Eventually(received, 3*time.Second).Should(HaveLen(1))
+		Consistently(received, time.Second).Should(HaveLen(1))
+		Ω(received()[0]).Should(Equal([]string{filepath.Join(dir, "design.go"), filepath.Join(dir, "types.go")}))
+	})
+
+	It("does not call back when nothing changes", func() {
+		Consistently(received, time.Second).Should(BeEmpty())
+	})
+})
@@ -0,0 +1,162 @@
+package goatest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+// Kinds of test security schemes, see AuthScheme.
+const (
+	// BasicAuthKind identifies basic auth schemes.
+	BasicAuthKind = "basic"
+	// APIKeyKind identifies API key schemes.
+	APIKeyKind = "apiKey"
+	// JWTKind identifies JWT schemes.
+	JWTKind = "jwt"
+	// OAuth2Kind identifies OAuth2 schemes.
+	OAuth2Kind = "oauth2"
+)
+
+var (
+	// ErrUnauthorized is the error returned by the test auth middlewares when a request has no
+	// credentials or credentials created for another scheme.
+	ErrUnauthorized = goa.NewErrorClass("unauthorized", 401)
+
+	// ErrForbidden is the error returned by the test auth middlewares when the request
+	// credentials do not grant all the scopes required by the action.
+	ErrForbidden = goa.NewErrorClass("forbidden", 403)
+)
+
+// credentialsPrefix is the prefix of the test credentials.
+const credentialsPrefix = "goatest:"
+
+type (
+	// AuthScheme is a security scheme used to test the authorization of requests without
+	// having to produce real credentials: Sign adds credentials granting the given scopes to a
+	// request and Middleware returns an auth middleware that accepts these credentials only.
+	// The generated test package creates one AuthScheme per security scheme of the design.
+	AuthScheme struct {
+		// Name is the name of the security scheme in the design.
+		Name string
+		// Kind is the kind of security scheme, one of BasicAuthKind, APIKeyKind, JWTKind or
+		// OAuth2Kind.
+		Kind string
+		// In is where API keys and JWT tokens are sent: goa.LocHeader or goa.LocQuery.
+		In goa.Location
+		// Key is the name of the header or querystring parameter that holds API keys and JWT
+		// tokens.
+		Key string
+	}
+
+	// AuthCase describes a request sent to test the authorization of an action and the status
+	// of the expected response.
+	AuthCase struct {
+		// Name describes the case.
+		Name string
+		// Request builds the request to send, it is called once per run.
+		Request func() *http.Request
+		// Scheme is the name of the scheme used to sign the request, empty if the request
+		// has no credentials.
+		Scheme string
+		// Scopes lists the scopes granted by the request credentials.
+		Scopes []string
+		// Status is the expected response status code.
+		Status int
+	}
+)
+
+// Sign adds credentials for the scheme granting the given scopes to req and returns it.
+func (s *AuthScheme) Sign(req *http.Request, scopes ...string) *http.Request {
+	token := credentialsPrefix + s.Name + ":" + strings.Join(scopes, ",")
+	switch s.Kind {
+	case BasicAuthKind:
+		req.SetBasicAuth(s.Name, credentialsPrefix+s.Name)
+	case OAuth2Kind:
+		req.Header.Set("Authorization", "Bearer "+token)
+	default:
+		if s.In == goa.LocQuery {
+			q := req.URL.Query()
+			q.Set(s.Key, token)
+			req.URL.RawQuery = q.Encode()
+			break
+		}
+		if s.Kind == JWTKind && http.CanonicalHeaderKey(s.Key) == "Authorization" {
+			token = "Bearer " + token
+		}
+		req.Header.Set(s.Key, token)
+	}
+	return req
+}
+
+// Middleware returns an auth middleware that accepts the credentials created by Sign. The
+// middleware fails with ErrUnauthorized if the request credentials are missing or were created
+// for another scheme and with ErrForbidden if they do not grant all the scopes required by the
+// action, see goa.ContextRequiredScopes.
+func (s *AuthScheme) Middleware() goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			scopes, ok := s.credentials(req)
+			if !ok {
+				return ErrUnauthorized("missing or invalid %s credentials", s.Name)
+			}
+			granted := make(map[string]bool, len(scopes))
+			for _, scope := range scopes {
+				granted[scope] = true
+			}
+			for _, scope := range goa.ContextRequiredScopes(ctx) {
+				if !granted[scope] {
+					return ErrForbidden("missing scope %q", scope)
+				}
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// credentials returns the scopes granted by the request credentials and true if the request has
+// credentials created by Sign for the scheme, false otherwise.
+func (s *AuthScheme) credentials(req *http.Request) ([]string, bool) {
+	var token string
+	switch s.Kind {
+	case BasicAuthKind:
+		user, pass, ok := req.BasicAuth()
+		if !ok || user != s.Name || pass != credentialsPrefix+s.Name {
+			return nil, false
+		}
+		return nil, true
+	case OAuth2Kind:
+		token = strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	default:
+		if s.In == goa.LocQuery {
+			token = req.URL.Query().Get(s.Key)
+		} else {
+			token = strings.TrimPrefix(req.Header.Get(s.Key), "Bearer ")
+		}
+	}
+	prefix := credentialsPrefix + s.Name + ":"
+	if !strings.HasPrefix(token, prefix) {
+		return nil, false
+	}
+	if scopes := strings.TrimPrefix(token, prefix); scopes != "" {
+		return strings.Split(scopes, ","), true
+	}
+	return nil, true
+}
+
+// RunAuthCases sends the requests described by cases to the server, signing them with the
+// corresponding schemes, and checks the response status codes.
+func (s *Server) RunAuthCases(schemes map[string]*AuthScheme, cases []*AuthCase) {
+	for _, c := range cases {
+		req := c.Request()
+		if c.Scheme != "" {
+			schemes[c.Scheme].Sign(req, c.Scopes...)
+		}
+		rw := s.Do(req)
+		if rw.Code != c.Status {
+			s.t.Errorf("%s: invalid response status code: got %d, expected %d, body: %s", c.Name, rw.Code, c.Status, rw.Body.String())
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package goatest
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+)
+
+type (
+	// TInterface is the subset of testing.TB used by the test server to report failures.
+	TInterface interface {
+		Errorf(format string, args ...interface{})
+		Fatalf(format string, args ...interface{})
+	}
+
+	// Server serves requests in memory using a goa service. Tests mount the controllers under
+	// test on the service so that only these controllers get exercised, typically using the
+	// generated MountXxxController functions and request builders:
+	//
+	//	srv := goatest.NewServer(t)
+	//	app.MountBottleController(srv.Service, NewBottleController(srv.Service))
+	//	var bottle app.Bottle
+	//	srv.Do(test.NewShowBottleRequest(1)).AssertStatus(200).Decode(&bottle)
+	//
+	// Tests that need a network endpoint, for example to exercise the generated client, call
+	// Start and Close:
+	//
+	//	defer srv.Close()
+	//	c := client.New(http.DefaultClient)
+	//	c.Host = strings.TrimPrefix(srv.Start(), "http://")
+	//
+	Server struct {
+		// Service is the service the controllers under test are mounted on.
+		Service *goa.Service
+		// Log contains the service logs.
+		Log *bytes.Buffer
+
+		t    TInterface
+		http *httptest.Server
+	}
+
+	// Response is the response to a request sent to a test server. The assertion methods report
+	// failures to the test and return the response so that calls can be chained.
+	Response struct {
+		*httptest.ResponseRecorder
+
+		t   TInterface
+		log *bytes.Buffer
+	}
+)
+
+// NewServer creates a test server whose service logs in memory and uses the JSON encoder and
+// decoder as well as the LogRequest and ErrorHandler middlewares.
+func NewServer(t TInterface) *Server {
+	var logBuf bytes.Buffer
+	s := goa.New("test")
+	s.WithLogger(goa.NewLogger(log.New(&logBuf, "", log.Ltime)))
+	s.Use(middleware.LogRequest(true))
+	s.Use(middleware.ErrorHandler(s, true))
+	s.Decoder.Register(goa.NewJSONDecoder, "*/*")
+	s.Encoder.Register(goa.NewJSONEncoder, "*/*")
+	return &Server{Service: s, Log: &logBuf, t: t}
+}
+
+// Do serves the given request and returns the recorded response.
+func (s *Server) Do(req *http.Request) *Response {
+	rw := httptest.NewRecorder()
+	s.Service.Mux.ServeHTTP(rw, req)
+	return &Response{ResponseRecorder: rw, t: s.t, log: s.Log}
+}
+
+// Start starts serving the service on a loopback network address and returns the server base URL,
+// for example "http://127.0.0.1:52431". Calling Start on a started server returns the same URL.
+func (s *Server) Start() string {
+	if s.http == nil {
+		s.http = httptest.NewServer(s.Service.Mux)
+	}
+	return s.http.URL
+}
+
+// URL returns the base URL of the started server or the empty string if Start was not called or
+// the server was closed.
+func (s *Server) URL() string {
+	if s.http == nil {
+		return ""
+	}
+	return s.http.URL
+}
+
+// Close shuts down the server started with Start and blocks until all its outstanding requests
+// have completed. Close does nothing if the server is not started.
+func (s *Server) Close() {
+	if s.http == nil {
+		return
+	}
+	s.http.Close()
+	s.http = nil
+}
+
+// AssertStatus checks the response status code.
+func (r *Response) AssertStatus(status int) *Response {
+	if r.Code != status {
+		r.t.Errorf("invalid response status code: got %d, expected %d, logs:\n%s", r.Code, status, r.log)
+	}
+	return r
+}
+
+// AssertHeader checks the value of the response header with the given name.
+func (r *Response) AssertHeader(name, value string) *Response {
+	if v := r.Header().Get(name); v != value {
+		r.t.Errorf("invalid response header %s: got %q, expected %q", name, v, value)
+	}
+	return r
+}
+
+// AssertContentType checks the media type of the response Content-Type header ignoring its
+// parameters.
+func (r *Response) AssertContentType(contentType string) *Response {
+	if ct, _, err := mime.ParseMediaType(r.Header().Get("Content-Type")); err != nil || ct != contentType {
+		r.t.Errorf("invalid response content type: got %q, expected %q", r.Header().Get("Content-Type"), contentType)
+	}
+	return r
+}
+
+// AssertError checks that the response body is an error with the given code.
+func (r *Response) AssertError(code string) *Response {
+	var e goa.Error
+	r.Decode(&e)
+	if e.Code != code {
+		r.t.Errorf("invalid response error code: got %q, expected %q (detail: %s)", e.Code, code, e.Detail)
+	}
+	return r
+}
+
+// Decode decodes the JSON response body into v, it fails the test if the body cannot be decoded.
+func (r *Response) Decode(v interface{}) *Response {
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.Fatalf("failed to decode response body %q: %s", r.Body.String(), err)
+	}
+	return r
+}
@@ -0,0 +1,118 @@
+package goatest_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recorder records the failures reported by the test server.
+type recorder struct {
+	errors []string
+	fatals []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.fatals = append(r.fatals, fmt.Sprintf(format, args...))
+}
+
+var _ = Describe("Server", func() {
+	var t *recorder
+	var srv *goatest.Server
+
+	BeforeEach(func() {
+		t = &recorder{}
+		srv = goatest.NewServer(t)
+		ctrl := srv.Service.NewController("bottles")
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(200)
+			_, err := rw.Write([]byte(`{"name":"muscadet"}`))
+			return err
+		}
+		srv.Service.Mux.Handle("GET", "/bottles", ctrl.MuxHandler("list", h, nil))
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	Describe("Do", func() {
+		It("serves the request in memory", func() {
+			var body map[string]string
+			srv.Do(httptest.NewRequest("GET", "/bottles", nil)).
+				AssertStatus(200).
+				AssertContentType("application/json").
+				Decode(&body)
+			Ω(t.errors).Should(BeEmpty())
+			Ω(t.fatals).Should(BeEmpty())
+			Ω(body).Should(HaveKeyWithValue("name", "muscadet"))
+			Ω(srv.Log.String()).Should(ContainSubstring("GET"))
+		})
+
+		It("reports failed assertions", func() {
+			srv.Do(httptest.NewRequest("GET", "/wines", nil)).
+				AssertStatus(200).
+				AssertError(goa.ErrNotFound("").Code)
+			Ω(t.errors).Should(HaveLen(1))
+			Ω(t.errors[0]).Should(ContainSubstring("got 404, expected 200"))
+		})
+	})
+
+	Describe("Start", func() {
+		var url string
+
+		BeforeEach(func() {
+			url = srv.Start()
+		})
+
+		It("serves the service over the network", func() {
+			Ω(url).Should(HavePrefix("http://127.0.0.1:"))
+			resp, err := http.Get(url + "/bottles")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer resp.Body.Close()
+			b, err := ioutil.ReadAll(resp.Body)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(resp.StatusCode).Should(Equal(200))
+			Ω(string(b)).Should(Equal(`{"name":"muscadet"}`))
+		})
+
+		It("returns the base URL", func() {
+			Ω(srv.URL()).Should(Equal(url))
+			Ω(srv.Start()).Should(Equal(url))
+		})
+
+		Context("once closed", func() {
+			BeforeEach(func() {
+				srv.Close()
+			})
+
+			It("stops serving", func() {
+				Ω(srv.URL()).Should(BeEmpty())
+				_, err := http.Get(url + "/bottles")
+				Ω(err).Should(HaveOccurred())
+			})
+
+			It("can be closed again", func() {
+				Ω(srv.Close).ShouldNot(Panic())
+			})
+		})
+	})
+
+	Describe("URL", func() {
+		It("is empty when the server is not started", func() {
+			Ω(srv.URL()).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,206 @@
+package goa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// HealthOK is the status of healthy checks and services.
+	HealthOK = "ok"
+	// HealthError is the status of failed checks and of the services with failed checks.
+	HealthError = "error"
+)
+
+type (
+	// HealthCheck checks a dependency of the service, e.g. pings a database. It returns an error
+	// if the dependency is unhealthy. The context is canceled when the check times out.
+	HealthCheck func(ctx context.Context) error
+
+	// HealthChecker is the registry of the checks that determine the health of a service. The
+	// liveness checks tell whether the service is running properly and should be restarted
+	// otherwise, the readiness checks whether the service can handle requests. Kubernetes
+	// probes call the handlers mounted by the generated MountHealth function, see
+	// LivenessHandler and ReadinessHandler.
+	HealthChecker struct {
+		// Timeout is the maximum duration of each check. Defaults to 5s.
+		Timeout time.Duration
+		// Paths lists the paths of the liveness and readiness endpoints, the generated
+		// MountHealth function sets it. Middleware such as middleware.Drain use it to keep
+		// serving the probes.
+		Paths []string
+
+		mu     sync.Mutex
+		checks []*namedHealthCheck
+	}
+
+	// HealthReport is the aggregated status of the checks written to the responses of the
+	// health handlers.
+	HealthReport struct {
+		// Status is HealthOK if all the checks succeeded, HealthError otherwise.
+		Status string `json:"status"`
+		// Checks contains the result of each check indexed by check name.
+		Checks map[string]*HealthCheckResult `json:"checks,omitempty"`
+	}
+
+	// HealthCheckResult is the result of a single check.
+	HealthCheckResult struct {
+		// Status is HealthOK if the check succeeded, HealthError otherwise.
+		Status string `json:"status"`
+		// Error is the error returned by the check if any.
+		Error string `json:"error,omitempty"`
+		// Duration is the time the check took to complete or time out.
+		Duration string `json:"duration"`
+	}
+
+	// namedHealthCheck is a check registered with a HealthChecker.
+	namedHealthCheck struct {
+		name     string
+		check    HealthCheck
+		liveness bool
+	}
+)
+
+// NewHealthChecker returns a health checker with no check. Services created with New come with
+// one, see Service.Health.
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{Timeout: 5 * time.Second}
+}
+
+// PingHealthCheck returns a check that pings p, e.g. a *sql.DB.
+func PingHealthCheck(p interface {
+	PingContext(context.Context) error
+}) HealthCheck {
+	return p.PingContext
+}
+
+// AddLiveness registers a liveness check. Since a service that is not running properly cannot
+// handle requests either the liveness checks also run as part of the readiness probe. Liveness
+// checks should not check the dependencies of the service: restarting it does not help if they
+// are unavailable.
+func (c *HealthChecker) AddLiveness(name string, check HealthCheck) {
+	c.add(name, check, true)
+}
+
+// AddReadiness registers a readiness check, typically a dependency check.
+func (c *HealthChecker) AddReadiness(name string, check HealthCheck) {
+	c.add(name, check, false)
+}
+
+// Liveness runs the liveness checks concurrently and returns their aggregated status.
+func (c *HealthChecker) Liveness(ctx context.Context) *HealthReport {
+	return c.run(ctx, true)
+}
+
+// Readiness runs all the checks concurrently and returns their aggregated status.
+func (c *HealthChecker) Readiness(ctx context.Context) *HealthReport {
+	return c.run(ctx, false)
+}
+
+// LivenessHandler returns a handler that runs the liveness checks and responds with the report
+// as JSON with status 200 if all the checks succeeded, 503 otherwise.
+func (c *HealthChecker) LivenessHandler() Handler {
+	return healthHandler(c.Liveness)
+}
+
+// ReadinessHandler returns a handler that runs all the checks and responds with the report as
+// JSON with status 200 if all the checks succeeded, 503 otherwise.
+func (c *HealthChecker) ReadinessHandler() Handler {
+	return healthHandler(c.Readiness)
+}
+
+// add registers a check.
+func (c *HealthChecker) add(name string, check HealthCheck, liveness bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, &namedHealthCheck{name: name, check: check, liveness: liveness})
+}
+
+// run runs the liveness checks or all the checks concurrently. Checks that do not return once
+// timed out are reported as failed without waiting for them.
+func (c *HealthChecker) run(ctx context.Context, livenessOnly bool) *HealthReport {
+	c.mu.Lock()
+	var checks []*namedHealthCheck
+	for _, nc := range c.checks {
+		if nc.liveness || !livenessOnly {
+			checks = append(checks, nc)
+		}
+	}
+	c.mu.Unlock()
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	report := &HealthReport{Status: HealthOK}
+	if len(checks) == 0 {
+		return report
+	}
+	report.Checks = make(map[string]*HealthCheckResult, len(checks))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc *namedHealthCheck) {
+			defer wg.Done()
+			startedAt := time.Now()
+			err := runHealthCheck(ctx, nc.check, timeout)
+			res := &HealthCheckResult{Status: HealthOK, Duration: time.Since(startedAt).String()}
+			if err != nil {
+				res.Status = HealthError
+				res.Error = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = res
+			if err != nil {
+				report.Status = HealthError
+			}
+		}(nc)
+	}
+	wg.Wait()
+	return report
+}
+
+// runHealthCheck runs check with the given timeout and returns its error. It recovers from the
+// check panics and returns an error if the check does not return in time.
+func runHealthCheck(ctx context.Context, check HealthCheck, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// healthHandler returns a handler that writes the report produced by run.
+func healthHandler(run func(context.Context) *HealthReport) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		report := run(ctx)
+		status := http.StatusOK
+		if report.Status != HealthOK {
+			status = http.StatusServiceUnavailable
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("Cache-Control", "no-store")
+		rw.WriteHeader(status)
+		return json.NewEncoder(rw).Encode(report)
+	}
+}
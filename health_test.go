@@ -0,0 +1,93 @@
+package goa_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthChecker", func() {
+	var checker *goa.HealthChecker
+
+	ok := func(ctx context.Context) error { return nil }
+	failed := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	BeforeEach(func() {
+		checker = goa.NewHealthChecker()
+	})
+
+	It("reports a service with no check as healthy", func() {
+		report := checker.Readiness(context.Background())
+		Ω(report.Status).Should(Equal(goa.HealthOK))
+		Ω(report.Checks).Should(BeEmpty())
+	})
+
+	It("only runs the liveness checks for the liveness probe", func() {
+		checker.AddLiveness("goroutines", ok)
+		checker.AddReadiness("db", failed)
+		report := checker.Liveness(context.Background())
+		Ω(report.Status).Should(Equal(goa.HealthOK))
+		Ω(report.Checks).Should(HaveLen(1))
+		Ω(report.Checks).Should(HaveKey("goroutines"))
+	})
+
+	It("runs all the checks for the readiness probe", func() {
+		checker.AddLiveness("goroutines", ok)
+		checker.AddReadiness("db", failed)
+		report := checker.Readiness(context.Background())
+		Ω(report.Status).Should(Equal(goa.HealthError))
+		Ω(report.Checks["goroutines"].Status).Should(Equal(goa.HealthOK))
+		Ω(report.Checks["db"].Status).Should(Equal(goa.HealthError))
+		Ω(report.Checks["db"].Error).Should(Equal("connection refused"))
+	})
+
+	It("fails the checks that time out", func() {
+		checker.Timeout = 10 * time.Millisecond
+		block := make(chan struct{})
+		defer close(block)
+		checker.AddReadiness("cache", func(ctx context.Context) error {
+			<-block
+			return nil
+		})
+		report := checker.Readiness(context.Background())
+		Ω(report.Status).Should(Equal(goa.HealthError))
+		Ω(report.Checks["cache"].Error).Should(Equal(context.DeadlineExceeded.Error()))
+	})
+
+	It("fails the checks that panic", func() {
+		checker.AddReadiness("queue", func(ctx context.Context) error { panic("boom") })
+		report := checker.Readiness(context.Background())
+		Ω(report.Checks["queue"].Error).Should(Equal("panic: boom"))
+	})
+
+	Describe("ReadinessHandler", func() {
+		It("responds with 503 and the report if a check fails", func() {
+			checker.AddReadiness("db", failed)
+			rw := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/readyz", nil)
+			err := checker.ReadinessHandler()(context.Background(), rw, req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(503))
+			Ω(rw.Header().Get("Content-Type")).Should(Equal("application/json"))
+			var report goa.HealthReport
+			Ω(json.Unmarshal(rw.Body.Bytes(), &report)).Should(Succeed())
+			Ω(report.Status).Should(Equal(goa.HealthError))
+			Ω(report.Checks["db"].Error).Should(Equal("connection refused"))
+		})
+
+		It("responds with 200 if all the checks succeed", func() {
+			checker.AddReadiness("db", ok)
+			rw := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/readyz", nil)
+			Ω(checker.ReadinessHandler()(context.Background(), rw, req)).Should(Succeed())
+			Ω(rw.Code).Should(Equal(200))
+		})
+	})
+})
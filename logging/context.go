@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/goadesign/goa"
+)
+
+// ContextKeyvals returns the names of the controller and action handling the request the given
+// context belongs to as "ctrl" and "action" key/value pairs, nil if the context is not a request
+// context. The other request scoped fields such as the request ID ("req_id") or the trace and span
+// IDs are added to the context logger by the corresponding middleware.
+//
+// The adapters add these fields to the loggers returned by their context accessor functions, e.g.
+// goazap.Logger, so that the user code logs them automatically.
+func ContextKeyvals(ctx context.Context) []interface{} {
+	var keyvals []interface{}
+	if ctrl := goa.ContextController(ctx); ctrl != "<unknown>" {
+		keyvals = append(keyvals, "ctrl", ctrl)
+	}
+	if action := goa.ContextAction(ctx); action != "<unknown>" {
+		keyvals = append(keyvals, "action", action)
+	}
+	return keyvals
+}
@@ -14,8 +14,9 @@ Usage:
 package goalog15
 
 import (
+	"context"
+
 	"github.com/goadesign/goa"
-	"golang.org/x/net/context"
 	"gopkg.in/inconshreveable/log15.v2"
 )
 
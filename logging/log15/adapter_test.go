@@ -1,11 +1,12 @@
 package goalog15_test
 
 import (
+	"context"
+
 	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/logging/log15"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"golang.org/x/net/context"
 	"gopkg.in/inconshreveable/log15.v2"
 )
 
@@ -10,21 +10,26 @@ Usage:
 
     // In handlers:
     goalogrus.Entry(ctx).Info("foo", "bar")
+
+The entries returned by Entry include the names of the controller and action handling the
+request. Use FromEntryWithLevels to log the goa messages with levels other than info and error.
 */
 package goalogrus
 
 import (
+	"context"
 	"fmt"
 
-	"golang.org/x/net/context"
-
 	"github.com/Sirupsen/logrus"
 	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging"
 )
 
 // adapter is the logrus goa logger adapter.
 type adapter struct {
 	*logrus.Entry
+	infoLevel  logrus.Level
+	errorLevel logrus.Level
 }
 
 // New wraps a logrus logger into a goa logger.
@@ -32,15 +37,26 @@ func New(logger *logrus.Logger) goa.LogAdapter {
 	return FromEntry(logrus.NewEntry(logger))
 }
 
-// FromEntry wraps a logrus log entry into a goa logger.
+// FromEntry wraps a logrus log entry into a goa logger. The informational messages are logged with
+// the info level and the errors with the error level.
 func FromEntry(entry *logrus.Entry) goa.LogAdapter {
-	return &adapter{Entry: entry}
+	return FromEntryWithLevels(entry, logrus.InfoLevel, logrus.ErrorLevel)
+}
+
+// FromEntryWithLevels wraps a logrus log entry into a goa logger that logs the informational
+// messages and the errors with the given levels.
+func FromEntryWithLevels(entry *logrus.Entry, info, err logrus.Level) goa.LogAdapter {
+	return &adapter{Entry: entry, infoLevel: info, errorLevel: err}
 }
 
-// Entry returns the logrus log entry stored in the given context if any, nil otherwise.
+// Entry returns the logrus log entry stored in the given context if any, nil otherwise. The
+// returned entry includes the request scoped fields, see logging.ContextKeyvals.
 func Entry(ctx context.Context) *logrus.Entry {
 	logger := goa.ContextLogger(ctx)
 	if a, ok := logger.(*adapter); ok {
+		if keyvals := logging.ContextKeyvals(ctx); len(keyvals) > 0 {
+			return a.Entry.WithFields(data2rus(keyvals))
+		}
 		return a.Entry
 	}
 	return nil
@@ -48,17 +64,35 @@ func Entry(ctx context.Context) *logrus.Entry {
 
 // Info logs messages using logrus.
 func (a *adapter) Info(msg string, data ...interface{}) {
-	a.Entry.WithFields(data2rus(data)).Info(msg)
+	logAt(a.Entry.WithFields(data2rus(data)), a.infoLevel, msg)
 }
 
 // Error logs errors using logrus.
 func (a *adapter) Error(msg string, data ...interface{}) {
-	a.Entry.WithFields(data2rus(data)).Error(msg)
+	logAt(a.Entry.WithFields(data2rus(data)), a.errorLevel, msg)
 }
 
 // New creates a new logger given a context.
 func (a *adapter) New(data ...interface{}) goa.LogAdapter {
-	return &adapter{Entry: a.Entry.WithFields(data2rus(data))}
+	return &adapter{Entry: a.Entry.WithFields(data2rus(data)), infoLevel: a.infoLevel, errorLevel: a.errorLevel}
+}
+
+// logAt logs msg with the given level.
+func logAt(entry *logrus.Entry, level logrus.Level, msg string) {
+	switch level {
+	case logrus.PanicLevel:
+		entry.Panic(msg)
+	case logrus.FatalLevel:
+		entry.Fatal(msg)
+	case logrus.ErrorLevel:
+		entry.Error(msg)
+	case logrus.WarnLevel:
+		entry.Warn(msg)
+	case logrus.InfoLevel:
+		entry.Info(msg)
+	default:
+		entry.Debug(msg)
+	}
 }
 
 func data2rus(keyvals []interface{}) logrus.Fields {
@@ -2,8 +2,7 @@ package goalogrus_test
 
 import (
 	"bytes"
-
-	"golang.org/x/net/context"
+	"context"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/goadesign/goa"
@@ -58,5 +57,30 @@ var _ = Describe("FromEntry", func() {
 		It("extracts the log entry", func() {
 			Ω(goalogrus.Entry(ctx)).Should(Equal(entry))
 		})
+
+		It("adds the request scoped fields", func() {
+			ctx = goa.WithAction(ctx, "show")
+			Ω(goalogrus.Entry(ctx).Data).Should(HaveKeyWithValue("action", "show"))
+		})
+	})
+})
+
+var _ = Describe("FromEntryWithLevels", func() {
+	var buf bytes.Buffer
+	var adapter goa.LogAdapter
+
+	BeforeEach(func() {
+		buf.Reset()
+		logger := logrus.New()
+		logger.Out = &buf
+		logger.Level = logrus.DebugLevel
+		adapter = goalogrus.FromEntryWithLevels(logrus.NewEntry(logger), logrus.DebugLevel, logrus.WarnLevel)
+	})
+
+	It("maps the levels", func() {
+		adapter.Info("msg")
+		Ω(buf.String()).Should(ContainSubstring("level=debug"))
+		adapter.Error("msg")
+		Ω(buf.String()).Should(ContainSubstring("level=warning"))
 	})
 })
@@ -0,0 +1,86 @@
+/*
+Package goazap contains an adapter that makes it possible to configure goa so it uses zap as
+logger backend.
+Usage:
+
+    logger, _ := zap.NewProduction()
+    // Initialize logger handler using zap package
+    service.WithLogger(goazap.New(logger))
+    // ... Proceed with configuring and starting the goa service
+
+    // In handlers:
+    goazap.Logger(ctx).Info("foo", zap.String("bar", "baz"))
+*/
+package goazap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// adapter is the zap goa logger adapter.
+type adapter struct {
+	*zap.Logger
+	infoLevel  zapcore.Level
+	errorLevel zapcore.Level
+}
+
+// New wraps a zap logger into a goa logger. The informational messages are logged with the info
+// level and the errors with the error level.
+func New(logger *zap.Logger) goa.LogAdapter {
+	return NewWithLevels(logger, zap.InfoLevel, zap.ErrorLevel)
+}
+
+// NewWithLevels wraps a zap logger into a goa logger that logs the informational messages and the
+// errors with the given levels.
+func NewWithLevels(logger *zap.Logger, info, err zapcore.Level) goa.LogAdapter {
+	return &adapter{Logger: logger, infoLevel: info, errorLevel: err}
+}
+
+// Logger returns the zap logger stored in the given context if any, nil otherwise. The returned
+// logger includes the request scoped fields, see logging.ContextKeyvals.
+func Logger(ctx context.Context) *zap.Logger {
+	logger := goa.ContextLogger(ctx)
+	if a, ok := logger.(*adapter); ok {
+		return a.Logger.With(data2zap(logging.ContextKeyvals(ctx))...)
+	}
+	return nil
+}
+
+// Info logs messages using zap.
+func (a *adapter) Info(msg string, data ...interface{}) {
+	if ce := a.Logger.Check(a.infoLevel, msg); ce != nil {
+		ce.Write(data2zap(data)...)
+	}
+}
+
+// Error logs errors using zap.
+func (a *adapter) Error(msg string, data ...interface{}) {
+	if ce := a.Logger.Check(a.errorLevel, msg); ce != nil {
+		ce.Write(data2zap(data)...)
+	}
+}
+
+// New creates a new logger given a context.
+func (a *adapter) New(data ...interface{}) goa.LogAdapter {
+	return &adapter{Logger: a.Logger.With(data2zap(data)...), infoLevel: a.infoLevel, errorLevel: a.errorLevel}
+}
+
+func data2zap(keyvals []interface{}) []zapcore.Field {
+	n := (len(keyvals) + 1) / 2
+	res := make([]zapcore.Field, 0, n)
+	for i := 0; i < len(keyvals); i += 2 {
+		k := keyvals[i]
+		var v interface{} = goa.ErrMissingLogValue
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		res = append(res, zap.Any(fmt.Sprintf("%v", k), v))
+	}
+	return res
+}
@@ -0,0 +1,74 @@
+package goazap_test
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging/zap"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var _ = Describe("goazap", func() {
+	var buf bytes.Buffer
+	var logger *zap.Logger
+	var adapter goa.LogAdapter
+
+	BeforeEach(func() {
+		buf.Reset()
+		enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+		logger = zap.New(zapcore.NewCore(enc, zapcore.AddSync(&buf), zap.DebugLevel))
+		adapter = goazap.New(logger)
+	})
+
+	It("adapts info messages", func() {
+		adapter.Info("msg", "key", "val")
+		Ω(buf.String()).Should(ContainSubstring(`"level":"info"`))
+		Ω(buf.String()).Should(ContainSubstring(`"msg":"msg"`))
+		Ω(buf.String()).Should(ContainSubstring(`"key":"val"`))
+	})
+
+	It("adapts error messages", func() {
+		adapter.Error("msg")
+		Ω(buf.String()).Should(ContainSubstring(`"level":"error"`))
+	})
+
+	It("logs missing values", func() {
+		adapter.Info("msg", "key")
+		Ω(buf.String()).Should(ContainSubstring(`"key":"` + goa.ErrMissingLogValue + `"`))
+	})
+
+	It("appends to the logger context", func() {
+		adapter.New("req_id", "abc").Info("msg")
+		Ω(buf.String()).Should(ContainSubstring(`"req_id":"abc"`))
+	})
+
+	Context("with custom levels", func() {
+		BeforeEach(func() {
+			adapter = goazap.NewWithLevels(logger, zap.DebugLevel, zap.WarnLevel)
+		})
+
+		It("maps the levels", func() {
+			adapter.Info("msg")
+			Ω(buf.String()).Should(ContainSubstring(`"level":"debug"`))
+			adapter.Error("msg")
+			Ω(buf.String()).Should(ContainSubstring(`"level":"warn"`))
+		})
+	})
+
+	Context("Logger", func() {
+		It("returns the logger with the request scoped fields", func() {
+			ctx := goa.WithLogger(context.Background(), adapter)
+			ctx = goa.WithAction(ctx, "show")
+			goazap.Logger(ctx).Info("msg")
+			Ω(buf.String()).Should(ContainSubstring(`"action":"show"`))
+		})
+
+		It("returns nil if the context logger is not a zap logger", func() {
+			Ω(goazap.Logger(context.Background())).Should(BeNil())
+		})
+	})
+})
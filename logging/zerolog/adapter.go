@@ -0,0 +1,86 @@
+/*
+Package goazerolog contains an adapter that makes it possible to configure goa so it uses zerolog
+as logger backend.
+Usage:
+
+    logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+    // Initialize logger handler using zerolog package
+    service.WithLogger(goazerolog.New(logger))
+    // ... Proceed with configuring and starting the goa service
+
+    // In handlers:
+    goazerolog.Logger(ctx).Info().Str("bar", "baz").Msg("foo")
+*/
+package goazerolog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging"
+	"github.com/rs/zerolog"
+)
+
+// adapter is the zerolog goa logger adapter.
+type adapter struct {
+	zerolog.Logger
+	infoLevel  zerolog.Level
+	errorLevel zerolog.Level
+}
+
+// New wraps a zerolog logger into a goa logger. The informational messages are logged with the
+// info level and the errors with the error level.
+func New(logger zerolog.Logger) goa.LogAdapter {
+	return NewWithLevels(logger, zerolog.InfoLevel, zerolog.ErrorLevel)
+}
+
+// NewWithLevels wraps a zerolog logger into a goa logger that logs the informational messages and
+// the errors with the given levels.
+func NewWithLevels(logger zerolog.Logger, info, err zerolog.Level) goa.LogAdapter {
+	return &adapter{Logger: logger, infoLevel: info, errorLevel: err}
+}
+
+// Logger returns the zerolog logger stored in the given context if any, nil otherwise. The
+// returned logger includes the request scoped fields, see logging.ContextKeyvals.
+func Logger(ctx context.Context) *zerolog.Logger {
+	logger := goa.ContextLogger(ctx)
+	if a, ok := logger.(*adapter); ok {
+		l := a.Logger.With().Fields(data2zero(logging.ContextKeyvals(ctx))).Logger()
+		return &l
+	}
+	return nil
+}
+
+// Info logs messages using zerolog.
+func (a *adapter) Info(msg string, data ...interface{}) {
+	a.Logger.WithLevel(a.infoLevel).Fields(data2zero(data)).Msg(msg)
+}
+
+// Error logs errors using zerolog.
+func (a *adapter) Error(msg string, data ...interface{}) {
+	a.Logger.WithLevel(a.errorLevel).Fields(data2zero(data)).Msg(msg)
+}
+
+// New creates a new logger given a context.
+func (a *adapter) New(data ...interface{}) goa.LogAdapter {
+	return &adapter{
+		Logger:     a.Logger.With().Fields(data2zero(data)).Logger(),
+		infoLevel:  a.infoLevel,
+		errorLevel: a.errorLevel,
+	}
+}
+
+func data2zero(keyvals []interface{}) map[string]interface{} {
+	n := (len(keyvals) + 1) / 2
+	res := make(map[string]interface{}, n)
+	for i := 0; i < len(keyvals); i += 2 {
+		k := keyvals[i]
+		var v interface{} = goa.ErrMissingLogValue
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		res[fmt.Sprintf("%v", k)] = v
+	}
+	return res
+}
@@ -0,0 +1,67 @@
+package goazerolog_test
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging/zerolog"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+)
+
+var _ = Describe("goazerolog", func() {
+	var buf bytes.Buffer
+	var logger zerolog.Logger
+	var adapter goa.LogAdapter
+
+	BeforeEach(func() {
+		buf.Reset()
+		logger = zerolog.New(&buf)
+		adapter = goazerolog.New(logger)
+	})
+
+	It("adapts info messages", func() {
+		adapter.Info("msg", "key", "val")
+		Ω(buf.String()).Should(ContainSubstring(`"level":"info"`))
+		Ω(buf.String()).Should(ContainSubstring(`"message":"msg"`))
+		Ω(buf.String()).Should(ContainSubstring(`"key":"val"`))
+	})
+
+	It("adapts error messages", func() {
+		adapter.Error("msg")
+		Ω(buf.String()).Should(ContainSubstring(`"level":"error"`))
+	})
+
+	It("appends to the logger context", func() {
+		adapter.New("req_id", "abc").Info("msg")
+		Ω(buf.String()).Should(ContainSubstring(`"req_id":"abc"`))
+	})
+
+	Context("with custom levels", func() {
+		BeforeEach(func() {
+			adapter = goazerolog.NewWithLevels(logger, zerolog.DebugLevel, zerolog.WarnLevel)
+		})
+
+		It("maps the levels", func() {
+			adapter.Info("msg")
+			Ω(buf.String()).Should(ContainSubstring(`"level":"debug"`))
+			adapter.Error("msg")
+			Ω(buf.String()).Should(ContainSubstring(`"level":"warn"`))
+		})
+	})
+
+	Context("Logger", func() {
+		It("returns the logger with the request scoped fields", func() {
+			ctx := goa.WithLogger(context.Background(), adapter)
+			ctx = goa.WithAction(ctx, "show")
+			goazerolog.Logger(ctx).Info().Msg("msg")
+			Ω(buf.String()).Should(ContainSubstring(`"action":"show"`))
+		})
+
+		It("returns nil if the context logger is not a zerolog logger", func() {
+			Ω(goazerolog.Logger(context.Background())).Should(BeNil())
+		})
+	})
+})
@@ -2,12 +2,12 @@ package goa_test
 
 import (
 	"bytes"
+	"context"
 	"log"
 
 	"github.com/goadesign/goa"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"golang.org/x/net/context"
 )
 
 var _ = Describe("Info", func() {
@@ -0,0 +1,147 @@
+package goa
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// MaintenanceProvider is the interface used by the generated code to check whether a
+	// resource is in maintenance mode. Resources must enable maintenance mode in their design
+	// with the "maintenance" metadata for the provider to be queried. Implementations may
+	// be backed by a feature flag service, a configuration file etc. MaintenanceSwitch provides
+	// an in-memory implementation driven by an admin endpoint.
+	MaintenanceProvider interface {
+		// InMaintenance returns true if the resource with the given name is in maintenance
+		// mode together with the time after which clients should retry. A zero duration
+		// means the default retry hint defined in the design.
+		InMaintenance(resource string) (bool, time.Duration)
+	}
+
+	// MaintenanceSwitch is a MaintenanceProvider whose state can be changed at runtime by
+	// calling Enable and Disable or via its admin HTTP endpoint, see ServeHTTP.
+	MaintenanceSwitch struct {
+		mu        sync.RWMutex
+		resources map[string]time.Duration
+	}
+)
+
+// AllResources is the resource name that puts all the resources of the service in maintenance
+// mode when given to MaintenanceSwitch.Enable, see also middleware.Drain which rejects all the
+// requests made to the service in this case.
+const AllResources = "*"
+
+// NewMaintenanceSwitch returns a maintenance switch with no resource in maintenance mode.
+func NewMaintenanceSwitch() *MaintenanceSwitch {
+	return &MaintenanceSwitch{resources: make(map[string]time.Duration)}
+}
+
+// InMaintenance returns true if the resource with the given name is in maintenance mode according
+// to the service maintenance provider together with the number of seconds clients should wait
+// before retrying. retryAfter is used when the provider does not specify a retry hint. The
+// resource name is the name used in the design.
+func (service *Service) InMaintenance(resource string, retryAfter time.Duration) (int, bool) {
+	if service.Maintenance == nil {
+		return 0, false
+	}
+	on, d := service.Maintenance.InMaintenance(resource)
+	if !on {
+		return 0, false
+	}
+	if d <= 0 {
+		d = retryAfter
+	}
+	return int((d + time.Second - 1) / time.Second), true
+}
+
+// Enable puts the resource with the given name in maintenance mode, AllResources puts the whole
+// service in maintenance mode. retryAfter is the time after which clients should retry, a zero
+// value means the default defined in the design.
+func (s *MaintenanceSwitch) Enable(resource string, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resources[resource] = retryAfter
+}
+
+// Disable takes the resource with the given name out of maintenance mode.
+func (s *MaintenanceSwitch) Disable(resource string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.resources, resource)
+}
+
+// InMaintenance implements MaintenanceProvider. A resource is in maintenance mode if it was
+// enabled by name or if AllResources was enabled, the retry hint of the resource takes precedence.
+func (s *MaintenanceSwitch) InMaintenance(resource string) (bool, time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if d, ok := s.resources[resource]; ok {
+		return true, d
+	}
+	d, ok := s.resources[AllResources]
+	return ok, d
+}
+
+// Resources returns the sorted names of the resources in maintenance mode.
+func (s *MaintenanceSwitch) Resources() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.resources))
+	for n := range s.resources {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServeHTTP implements the admin endpoint of the maintenance switch. It is meant to be mounted
+// on an internal listener, for example:
+//
+//	http.Handle("/admin/maintenance", sw)
+//
+// GET lists the resources in maintenance mode, PUT puts the resource given by the "resource"
+// query string parameter in maintenance mode with the optional "retry_after" duration (e.g.
+// "90s") and DELETE takes it out of maintenance mode. All methods respond with a JSON object
+// that maps the names of the resources in maintenance mode to their retry hint in seconds.
+func (s *MaintenanceSwitch) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	resource := q.Get("resource")
+	switch req.Method {
+	case "GET", "HEAD":
+	case "PUT", "POST":
+		if resource == "" {
+			http.Error(rw, "missing resource", http.StatusBadRequest)
+			return
+		}
+		var d time.Duration
+		if ra := q.Get("retry_after"); ra != "" {
+			var err error
+			if d, err = time.ParseDuration(ra); err != nil || d < 0 {
+				http.Error(rw, "invalid retry_after, must be a duration such as 90s", http.StatusBadRequest)
+				return
+			}
+		}
+		s.Enable(resource, d)
+	case "DELETE":
+		if resource == "" {
+			http.Error(rw, "missing resource", http.StatusBadRequest)
+			return
+		}
+		s.Disable(resource)
+	default:
+		rw.Header().Set("Allow", "GET, HEAD, PUT, POST, DELETE")
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.RLock()
+	state := make(map[string]int, len(s.resources))
+	for n, d := range s.resources {
+		state[n] = int((d + time.Second - 1) / time.Second)
+	}
+	s.mu.RUnlock()
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(state)
+}
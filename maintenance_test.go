@@ -0,0 +1,94 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MaintenanceSwitch", func() {
+	var sw *goa.MaintenanceSwitch
+	var service *goa.Service
+
+	BeforeEach(func() {
+		sw = goa.NewMaintenanceSwitch()
+		service = goa.New("test")
+		service.Maintenance = sw
+	})
+
+	It("reports the resources in maintenance mode", func() {
+		sw.Enable("bottle", 90*time.Second)
+		retryAfter, ok := service.InMaintenance("bottle", time.Minute)
+		Ω(ok).Should(BeTrue())
+		Ω(retryAfter).Should(Equal(90))
+		_, ok = service.InMaintenance("account", time.Minute)
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("uses the default retry hint", func() {
+		sw.Enable("bottle", 0)
+		retryAfter, ok := service.InMaintenance("bottle", 1500*time.Millisecond)
+		Ω(ok).Should(BeTrue())
+		Ω(retryAfter).Should(Equal(2))
+	})
+
+	It("takes resources out of maintenance mode", func() {
+		sw.Enable("bottle", 0)
+		sw.Disable("bottle")
+		_, ok := service.InMaintenance("bottle", time.Minute)
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("puts all the resources in maintenance mode", func() {
+		sw.Enable(goa.AllResources, time.Minute)
+		sw.Enable("bottle", 90*time.Second)
+		retryAfter, ok := service.InMaintenance("account", 0)
+		Ω(ok).Should(BeTrue())
+		Ω(retryAfter).Should(Equal(60))
+		retryAfter, _ = service.InMaintenance("bottle", 0)
+		Ω(retryAfter).Should(Equal(90))
+		sw.Disable(goa.AllResources)
+		_, ok = service.InMaintenance("account", 0)
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("never reports maintenance without provider", func() {
+		service.Maintenance = nil
+		_, ok := service.InMaintenance("bottle", time.Minute)
+		Ω(ok).Should(BeFalse())
+	})
+
+	Context("admin endpoint", func() {
+		serve := func(method, url string) *httptest.ResponseRecorder {
+			rw := httptest.NewRecorder()
+			req, _ := http.NewRequest(method, url, nil)
+			sw.ServeHTTP(rw, req)
+			return rw
+		}
+
+		It("puts resources in maintenance mode", func() {
+			rw := serve("PUT", "/admin/maintenance?resource=bottle&retry_after=2m")
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.String()).Should(MatchJSON(`{"bottle":120}`))
+			Ω(sw.Resources()).Should(Equal([]string{"bottle"}))
+		})
+
+		It("takes resources out of maintenance mode", func() {
+			sw.Enable("bottle", 0)
+			sw.Enable("account", 0)
+			rw := serve("DELETE", "/admin/maintenance?resource=bottle")
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.String()).Should(MatchJSON(`{"account":0}`))
+		})
+
+		It("rejects invalid requests", func() {
+			Ω(serve("PUT", "/admin/maintenance").Code).Should(Equal(400))
+			Ω(serve("PUT", "/admin/maintenance?resource=bottle&retry_after=soon").Code).Should(Equal(400))
+			Ω(serve("PATCH", "/admin/maintenance?resource=bottle").Code).Should(Equal(405))
+		})
+	})
+})
@@ -0,0 +1,350 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+// redactedValue replaces the values of the redacted fields and query string parameters.
+const redactedValue = "REDACTED"
+
+type (
+	// AccessLogEntry describes a request handled by the service, it is produced by the AccessLog
+	// middleware.
+	AccessLogEntry struct {
+		// Time is the time the request was received.
+		Time time.Time
+		// RemoteAddr is the address of the client, see AccessLogOptions.
+		RemoteAddr string
+		// Principal identifies the authenticated caller, see AccessLogOptions.
+		Principal string
+		// Method is the request HTTP method.
+		Method string
+		// URI is the request URI, i.e. the path and query string.
+		URI string
+		// Proto is the request protocol, e.g. "HTTP/1.1".
+		Proto string
+		// Status is the response HTTP status code.
+		Status int
+		// Bytes is the number of response body bytes written.
+		Bytes int
+		// Duration is the time spent handling the request.
+		Duration time.Duration
+		// RequestID is the ID of the request set by the RequestID middleware if any.
+		RequestID string
+		// Ctrl is the name of the controller that handled the request.
+		Ctrl string
+		// Action is the name of the action that handled the request.
+		Action string
+		// ErrorCode is the code of the error returned by the action if any.
+		ErrorCode string
+		// Referer is the value of the request Referer header.
+		Referer string
+		// UserAgent is the value of the request User-Agent header.
+		UserAgent string
+	}
+
+	// AccessLogFormat writes an entry to the given buffer as a single line, without the
+	// trailing newline.
+	AccessLogFormat func(b *bytes.Buffer, e *AccessLogEntry)
+
+	// AccessLogOptions configures the AccessLog middleware.
+	AccessLogOptions struct {
+		// Output is the writer the entries are written to, one line per entry. Defaults to
+		// os.Stdout.
+		Output io.Writer
+		// Format writes the entries. Defaults to CombinedLogFormat.
+		Format AccessLogFormat
+		// Principal returns the identity of the authenticated caller, for example the
+		// subject of the request JWT token. Defaults to the user name of the request basic
+		// authentication credentials if any.
+		Principal func(ctx context.Context, req *http.Request) string
+		// SampleRate is the proportion of the successful requests that are logged, between 0
+		// and 1. The requests that fail with a status of 400 or more are always logged.
+		// Defaults to 1.
+		SampleRate float64
+		// RedactFields lists the names of the entry fields whose values are replaced with
+		// "REDACTED". The names are the keys used by the JSON and logfmt formats, e.g.
+		// "remote_addr", "principal", "referer" or "user_agent".
+		RedactFields []string
+		// RedactParams lists the names of the query string parameters whose values are
+		// replaced with "REDACTED" in the entry URI and referer, e.g. "access_token".
+		RedactParams []string
+	}
+)
+
+// CombinedLogFormat writes the entries using the Apache combined log format. The format does not
+// include the request ID and duration, use JSONLogFormat or LogfmtLogFormat to log them.
+func CombinedLogFormat(b *bytes.Buffer, e *AccessLogEntry) {
+	b.WriteString(orDash(e.RemoteAddr))
+	b.WriteString(" - ")
+	b.WriteString(orDash(e.Principal))
+	b.WriteString(" [")
+	b.WriteString(e.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	b.WriteString(`] "`)
+	b.WriteString(e.Method)
+	b.WriteByte(' ')
+	b.WriteString(e.URI)
+	b.WriteByte(' ')
+	b.WriteString(e.Proto)
+	b.WriteString(`" `)
+	b.WriteString(strconv.Itoa(e.Status))
+	b.WriteByte(' ')
+	if e.Bytes == 0 {
+		b.WriteByte('-')
+	} else {
+		b.WriteString(strconv.Itoa(e.Bytes))
+	}
+	b.WriteString(` "`)
+	b.WriteString(escapeQuotes(orDash(e.Referer)))
+	b.WriteString(`" "`)
+	b.WriteString(escapeQuotes(orDash(e.UserAgent)))
+	b.WriteByte('"')
+}
+
+// JSONLogFormat writes the entries as JSON objects.
+func JSONLogFormat(b *bytes.Buffer, e *AccessLogEntry) {
+	kvs := e.Keyvals()
+	b.WriteByte('{')
+	for i := 0; i < len(kvs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(kvs[i])
+		v, _ := json.Marshal(kvs[i+1])
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+}
+
+// LogfmtLogFormat writes the entries using the logfmt key=value format.
+func LogfmtLogFormat(b *bytes.Buffer, e *AccessLogEntry) {
+	kvs := e.Keyvals()
+	for i := 0; i < len(kvs); i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(kvs[i].(string))
+		b.WriteByte('=')
+		switch v := kvs[i+1].(type) {
+		case string:
+			if v == "" || strings.ContainsAny(v, " =\"\\") || strings.IndexFunc(v, isControl) >= 0 {
+				v = strconv.Quote(v)
+			}
+			b.WriteString(v)
+		case int:
+			b.WriteString(strconv.Itoa(v))
+		}
+	}
+}
+
+// AccessLog returns a middleware that writes one entry per request to the output configured in
+// opts, which may be nil. The entries describe the request method, URI, response status, size and
+// latency as well as the request ID and the authenticated caller. The middleware should be
+// mounted before the ErrorHandler middleware so that it logs the status of the error responses:
+//
+//	service.Use(middleware.RequestID())
+//	service.Use(middleware.AccessLog(&middleware.AccessLogOptions{
+//		Format:       middleware.JSONLogFormat,
+//		RedactParams: []string{"access_token"},
+//	}))
+//	service.Use(middleware.ErrorHandler(service, false))
+//
+// Contrary to LogRequest it does not use the service logger so that the access logs may be
+// written to a dedicated output in a format understood by log processing tools.
+func AccessLog(opts *AccessLogOptions) goa.Middleware {
+	var o AccessLogOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Output == nil {
+		o.Output = os.Stdout
+	}
+	if o.Format == nil {
+		o.Format = CombinedLogFormat
+	}
+	if o.Principal == nil {
+		o.Principal = basicAuthPrincipal
+	}
+	if o.SampleRate <= 0 || o.SampleRate > 1 {
+		o.SampleRate = 1
+	}
+	var mu sync.Mutex
+	pool := sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			startedAt := time.Now()
+			err := h(ctx, rw, req)
+			e := &AccessLogEntry{
+				Time:       startedAt,
+				RemoteAddr: from(req),
+				Principal:  o.Principal(ctx, req),
+				Method:     req.Method,
+				URI:        redactURI(req.URL.RequestURI(), o.RedactParams),
+				Proto:      req.Proto,
+				Duration:   time.Since(startedAt),
+				RequestID:  accessLogRequestID(ctx, rw, req),
+				Ctrl:       goa.ContextController(ctx),
+				Action:     goa.ContextAction(ctx),
+				Referer:    redactURI(req.Referer(), o.RedactParams),
+				UserAgent:  req.UserAgent(),
+			}
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				e.Status = resp.Status
+				e.Bytes = resp.Length
+				e.ErrorCode = resp.ErrorCode
+			}
+			if e.Status < 400 && o.SampleRate < 1 && rand.Float64() >= o.SampleRate {
+				return err
+			}
+			e.redact(o.RedactFields)
+
+			b := pool.Get().(*bytes.Buffer)
+			b.Reset()
+			o.Format(b, e)
+			b.WriteByte('\n')
+			mu.Lock()
+			o.Output.Write(b.Bytes())
+			mu.Unlock()
+			pool.Put(b)
+			return err
+		}
+	}
+}
+
+// Keyvals returns the entry fields as a list of key/value pairs, the values are strings or ints.
+// Empty optional fields are omitted.
+func (e *AccessLogEntry) Keyvals() []interface{} {
+	keyvals := []interface{}{
+		"time", e.Time.Format(time.RFC3339Nano),
+		"remote_addr", e.RemoteAddr,
+		"method", e.Method,
+		"uri", e.URI,
+		"proto", e.Proto,
+		"status", e.Status,
+		"bytes", e.Bytes,
+		"duration", e.Duration.String(),
+	}
+	opt := []string{
+		"principal", e.Principal,
+		"request_id", e.RequestID,
+		"ctrl", e.Ctrl,
+		"action", e.Action,
+		"error", e.ErrorCode,
+		"referer", e.Referer,
+		"user_agent", e.UserAgent,
+	}
+	for i := 0; i < len(opt); i += 2 {
+		if opt[i+1] != "" {
+			keyvals = append(keyvals, opt[i], opt[i+1])
+		}
+	}
+	return keyvals
+}
+
+// redact replaces the values of the given fields with "REDACTED".
+func (e *AccessLogEntry) redact(fields []string) {
+	for _, f := range fields {
+		var v *string
+		switch f {
+		case "remote_addr":
+			v = &e.RemoteAddr
+		case "principal":
+			v = &e.Principal
+		case "uri":
+			v = &e.URI
+		case "request_id":
+			v = &e.RequestID
+		case "referer":
+			v = &e.Referer
+		case "user_agent":
+			v = &e.UserAgent
+		}
+		if v != nil && *v != "" {
+			*v = redactedValue
+		}
+	}
+}
+
+// redactURI replaces the values of the given query string parameters of uri with "REDACTED".
+func redactURI(uri string, params []string) string {
+	idx := strings.IndexByte(uri, '?')
+	if idx < 0 || len(params) == 0 {
+		return uri
+	}
+	query := strings.Split(uri[idx+1:], "&")
+	redacted := false
+	for i, kv := range query {
+		k := kv
+		if eq := strings.IndexByte(kv, '='); eq >= 0 {
+			k = kv[:eq]
+		}
+		if uk, err := url.QueryUnescape(k); err == nil {
+			for _, p := range params {
+				if uk == p {
+					query[i] = k + "=" + redactedValue
+					redacted = true
+					break
+				}
+			}
+		}
+	}
+	if !redacted {
+		return uri
+	}
+	return uri[:idx+1] + strings.Join(query, "&")
+}
+
+// basicAuthPrincipal is the default AccessLogOptions Principal function.
+func basicAuthPrincipal(_ context.Context, req *http.Request) string {
+	user, _, _ := req.BasicAuth()
+	return user
+}
+
+// accessLogRequestID returns the ID of the request. The RequestID middleware may be mounted before
+// or after the AccessLog middleware so the ID is looked up in the context, the response headers
+// and the request headers.
+func accessLogRequestID(ctx context.Context, rw http.ResponseWriter, req *http.Request) string {
+	if id := ContextRequestID(ctx); id != "" {
+		return id
+	}
+	if id := rw.Header().Get(RequestIDHeader); id != "" {
+		return id
+	}
+	return req.Header.Get(RequestIDHeader)
+}
+
+// orDash returns "-" if s is empty and s otherwise.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// escapeQuotes escapes the double quotes and backslashes of s.
+func escapeQuotes(s string) string {
+	if !strings.ContainsAny(s, `"\`) {
+		return s
+	}
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// isControl returns true if r is a control character.
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
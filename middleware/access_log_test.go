@@ -0,0 +1,126 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AccessLog", func() {
+	var out *bytes.Buffer
+	var opts *middleware.AccessLogOptions
+	var status int
+	var req *http.Request
+
+	BeforeEach(func() {
+		out = new(bytes.Buffer)
+		opts = &middleware.AccessLogOptions{Output: out}
+		status = 200
+		var err error
+		req, err = http.NewRequest("GET", "/bottles?q=merlot&access_token=secret", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		req.RemoteAddr = "10.0.0.1:4242"
+		req.Header.Set("User-Agent", "cellar-cli")
+		req.Header.Set(middleware.RequestIDHeader, "abc123")
+		req.SetBasicAuth("alice", "password")
+	})
+
+	JustBeforeEach(func() {
+		service := newService(nil)
+		service.Use(middleware.RequestID())
+		service.Use(middleware.AccessLog(opts))
+		ctrl := service.NewController("BottleController")
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return service.Send(ctx, status, "ok")
+		}
+		ctrl.MuxHandler("list", h, nil)(httptest.NewRecorder(), req, nil)
+	})
+
+	It("writes entries in the combined log format", func() {
+		Ω(out.String()).Should(MatchRegexp(`^10\.0\.0\.1 - alice \[[^\]]+\] "GET /bottles\?q=merlot&access_token=secret HTTP/1\.1" 200 5 "-" "cellar-cli"\n$`))
+	})
+
+	Context("using the JSON format", func() {
+		BeforeEach(func() {
+			opts.Format = middleware.JSONLogFormat
+		})
+
+		It("writes JSON objects", func() {
+			var entry map[string]interface{}
+			Ω(json.Unmarshal(out.Bytes(), &entry)).ShouldNot(HaveOccurred())
+			Ω(entry["method"]).Should(Equal("GET"))
+			Ω(entry["status"]).Should(BeEquivalentTo(200))
+			Ω(entry["bytes"]).Should(BeEquivalentTo(5))
+			Ω(entry["request_id"]).Should(Equal("abc123"))
+			Ω(entry["principal"]).Should(Equal("alice"))
+			Ω(entry["ctrl"]).Should(Equal("BottleController"))
+			Ω(entry["action"]).Should(Equal("list"))
+			Ω(entry).Should(HaveKey("duration"))
+		})
+	})
+
+	Context("using the logfmt format", func() {
+		BeforeEach(func() {
+			opts.Format = middleware.LogfmtLogFormat
+			req.Header.Set("User-Agent", "cellar cli")
+		})
+
+		It("writes key/value pairs", func() {
+			Ω(out.String()).Should(ContainSubstring(` method=GET uri="/bottles?q=merlot&access_token=secret" proto=HTTP/1.1 status=200 bytes=5 `))
+			Ω(out.String()).Should(ContainSubstring(` request_id=abc123 `))
+			Ω(out.String()).Should(ContainSubstring(` user_agent="cellar cli"`))
+		})
+	})
+
+	Context("with redaction", func() {
+		BeforeEach(func() {
+			opts.RedactFields = []string{"principal", "remote_addr"}
+			opts.RedactParams = []string{"access_token"}
+		})
+
+		It("redacts the fields and query string parameters", func() {
+			Ω(out.String()).Should(HavePrefix(`REDACTED - REDACTED [`))
+			Ω(out.String()).Should(ContainSubstring(`"GET /bottles?q=merlot&access_token=REDACTED HTTP/1.1"`))
+			Ω(out.String()).ShouldNot(ContainSubstring("secret"))
+		})
+	})
+
+	Context("with sampling", func() {
+		BeforeEach(func() {
+			opts.SampleRate = 0.000001
+		})
+
+		It("skips successful requests", func() {
+			Ω(out.Len()).Should(BeZero())
+		})
+
+		Context("and a failed request", func() {
+			BeforeEach(func() {
+				status = 500
+			})
+
+			It("logs the request", func() {
+				Ω(out.String()).Should(ContainSubstring(`" 500 `))
+			})
+		})
+	})
+
+	Context("with a custom principal", func() {
+		BeforeEach(func() {
+			opts.Principal = func(ctx context.Context, req *http.Request) string {
+				return goa.ContextAction(ctx) + "-caller"
+			}
+		})
+
+		It("logs the principal", func() {
+			Ω(out.String()).Should(HavePrefix(`10.0.0.1 - list-caller [`))
+		})
+	})
+})
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+// The outcomes of the audited requests.
+const (
+	// AuditSuccess is the outcome of the requests that succeeded.
+	AuditSuccess = "success"
+	// AuditDenied is the outcome of the requests rejected with a 401 or 403 status.
+	AuditDenied = "denied"
+	// AuditFailure is the outcome of the other requests that failed.
+	AuditFailure = "failure"
+)
+
+type (
+	// AuditEvent records who did what, it is produced by the Audit middleware for each audited
+	// request.
+	AuditEvent struct {
+		// Time is the time the request was received.
+		Time time.Time
+		// Principal identifies the authenticated caller, see goa.RequestData.
+		Principal string
+		// Ctrl is the name of the controller that handled the request.
+		Ctrl string
+		// Action is the name of the action that handled the request.
+		Action string
+		// Method is the request HTTP method.
+		Method string
+		// Path is the request URL path.
+		Path string
+		// ParamsHash is the hex encoded SHA-256 hash of the request parameters and payload.
+		// It makes it possible to correlate requests made with the same arguments without
+		// recording them.
+		ParamsHash string
+		// Status is the response HTTP status code.
+		Status int
+		// Outcome is one of AuditSuccess, AuditDenied or AuditFailure.
+		Outcome string
+		// ErrorCode is the code of the error returned by the action if any.
+		ErrorCode string
+		// RequestID is the ID of the request set by the RequestID middleware if any.
+		RequestID string
+	}
+
+	// AuditOptions configures the Audit middleware.
+	AuditOptions struct {
+		// Sink records the events. Defaults to logging the events with the context logger.
+		Sink func(ctx context.Context, e *AuditEvent)
+		// OptIn only audits the actions that define the "audit:include" metadata. All the
+		// actions but the ones that define the "audit:exclude" metadata are audited by
+		// default.
+		OptIn bool
+		// Principal returns the identity of the caller. Defaults to the principal recorded in
+		// the request data by the security middleware.
+		Principal func(ctx context.Context) string
+	}
+
+	// auditKey is the private type used to store the audit decision of the request in
+	// contexts.
+	auditKey struct{}
+)
+
+// Audit returns a middleware that records an event describing who made each request, to which
+// action, with which arguments and with which outcome to the sink configured in opts, which may be
+// nil. The principal comes from the security middleware run by the generated security handlers,
+// see goa.RequestData. The middleware should be mounted on the service before the ErrorHandler
+// middleware so that it records the final status of the requests:
+//
+//	service.Use(middleware.RequestID())
+//	service.Use(middleware.Audit(&middleware.AuditOptions{Sink: auditLog.Record}))
+//	service.Use(middleware.ErrorHandler(service, false))
+//
+// Actions are included or excluded with the "audit:include" and "audit:exclude" metadata, see
+// AuditHandler.
+func Audit(opts *AuditOptions) goa.Middleware {
+	var o AuditOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Sink == nil {
+		o.Sink = logAuditEvent
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			audited := !o.OptIn
+			startedAt := time.Now()
+			err := h(context.WithValue(ctx, auditKey{}, &audited), rw, req)
+			if !audited {
+				return err
+			}
+			e := &AuditEvent{
+				Time:      startedAt,
+				Ctrl:      goa.ContextController(ctx),
+				Action:    goa.ContextAction(ctx),
+				Method:    req.Method,
+				Path:      req.URL.Path,
+				RequestID: ContextRequestID(ctx),
+			}
+			r := goa.ContextRequest(ctx)
+			if o.Principal != nil {
+				e.Principal = o.Principal(ctx)
+			} else if r != nil {
+				e.Principal = r.Principal
+			}
+			if r != nil {
+				e.ParamsHash = paramsHash(r)
+			}
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				e.Status = resp.Status
+				e.ErrorCode = resp.ErrorCode
+			}
+			if err != nil && e.Status == 0 {
+				e.Status = http.StatusInternalServerError
+				var gerr *goa.Error
+				if errors.As(err, &gerr) {
+					e.Status = gerr.Status
+					e.ErrorCode = gerr.Code
+				}
+			}
+			switch {
+			case e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden:
+				e.Outcome = AuditDenied
+			case e.Status >= 400:
+				e.Outcome = AuditFailure
+			default:
+				e.Outcome = AuditSuccess
+			}
+			o.Sink(ctx, e)
+			return err
+		}
+	}
+}
+
+// AuditHandler returns a handler that includes the requests handled by h in the audit log
+// recorded by the Audit middleware if audited is true and excludes them otherwise. The generated
+// code applies it to the actions that define the "audit:include" or "audit:exclude" metadata.
+func AuditHandler(audited bool, h goa.Handler) goa.Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		if a, ok := ctx.Value(auditKey{}).(*bool); ok {
+			*a = audited
+		}
+		return h(ctx, rw, req)
+	}
+}
+
+// Keyvals returns the event fields as a list of key/value pairs suitable for logging.
+func (e *AuditEvent) Keyvals() []interface{} {
+	keyvals := []interface{}{
+		"principal", e.Principal,
+		"ctrl", e.Ctrl,
+		"action", e.Action,
+		"method", e.Method,
+		"path", e.Path,
+		"params_hash", e.ParamsHash,
+		"status", e.Status,
+		"outcome", e.Outcome,
+	}
+	if e.ErrorCode != "" {
+		keyvals = append(keyvals, "error", e.ErrorCode)
+	}
+	return keyvals
+}
+
+// logAuditEvent is the default audit sink, it logs the event with the context logger.
+func logAuditEvent(ctx context.Context, e *AuditEvent) {
+	goa.LogInfo(ctx, "audit", e.Keyvals()...)
+}
+
+// paramsHash computes the hash of the request parameters and payload.
+func paramsHash(r *goa.RequestData) string {
+	h := sha256.New()
+	names := make([]string, 0, len(r.Params))
+	for n := range r.Params {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		h.Write([]byte(n))
+		h.Write([]byte{'='})
+		h.Write([]byte(strings.Join(r.Params[n], ",")))
+		h.Write([]byte{'\n'})
+	}
+	if r.Payload != nil {
+		json.NewEncoder(h).Encode(r.Payload)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
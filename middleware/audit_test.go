@@ -0,0 +1,105 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit", func() {
+	var opts *middleware.AuditOptions
+	var events []*middleware.AuditEvent
+	var handler goa.Handler
+	var params url.Values
+
+	serve := func() {
+		s := newService(nil)
+		s.Use(middleware.Audit(opts))
+		s.Use(middleware.ErrorHandler(s, false))
+		ctrl := s.NewController("BottleController")
+		req, _ := http.NewRequest("DELETE", "/bottles/1", nil)
+		ctrl.MuxHandler("delete", handler, nil)(httptest.NewRecorder(), req, params)
+	}
+
+	BeforeEach(func() {
+		events = nil
+		params = url.Values{"id": {"1"}}
+		opts = &middleware.AuditOptions{
+			Sink: func(ctx context.Context, e *middleware.AuditEvent) {
+				events = append(events, e)
+			},
+		}
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			goa.ContextRequest(ctx).Principal = "alice"
+			goa.ContextResponse(ctx).WriteHeader(204)
+			return nil
+		}
+	})
+
+	It("records who did what", func() {
+		serve()
+		Ω(events).Should(HaveLen(1))
+		e := events[0]
+		Ω(e.Principal).Should(Equal("alice"))
+		Ω(e.Ctrl).Should(Equal("BottleController"))
+		Ω(e.Action).Should(Equal("delete"))
+		Ω(e.Method).Should(Equal("DELETE"))
+		Ω(e.Path).Should(Equal("/bottles/1"))
+		Ω(e.Status).Should(Equal(204))
+		Ω(e.Outcome).Should(Equal(middleware.AuditSuccess))
+		Ω(e.ParamsHash).Should(HaveLen(64))
+	})
+
+	It("hashes the parameters", func() {
+		serve()
+		hash := events[0].ParamsHash
+		params = url.Values{"id": {"2"}}
+		serve()
+		Ω(events[1].ParamsHash).ShouldNot(Equal(hash))
+	})
+
+	It("records denied requests", func() {
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return goa.NewErrorClass("unauthorized", 401)("invalid token")
+		}
+		serve()
+		Ω(events[0].Outcome).Should(Equal(middleware.AuditDenied))
+		Ω(events[0].Status).Should(Equal(401))
+		Ω(events[0].Principal).Should(BeEmpty())
+	})
+
+	It("records failed requests", func() {
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return goa.ErrNotFound("no bottle")
+		}
+		serve()
+		Ω(events[0].Outcome).Should(Equal(middleware.AuditFailure))
+		Ω(events[0].ErrorCode).Should(Equal("not_found"))
+	})
+
+	It("excludes the actions wrapped with AuditHandler", func() {
+		handler = middleware.AuditHandler(false, handler)
+		serve()
+		Ω(events).Should(BeEmpty())
+	})
+
+	Context("with opt-in auditing", func() {
+		BeforeEach(func() {
+			opts.OptIn = true
+		})
+
+		It("only audits the included actions", func() {
+			serve()
+			Ω(events).Should(BeEmpty())
+			handler = middleware.AuditHandler(true, handler)
+			serve()
+			Ω(events).Should(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+// ErrCircuitOpen is the error returned to requests rejected because the circuit of the route is
+// open.
+var ErrCircuitOpen = goa.NewErrorClass("circuit_open", 503)
+
+// The states of a circuit.
+const (
+	// CircuitClosed is the state of circuits that let requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen is the state of circuits that reject requests.
+	CircuitOpen
+	// CircuitHalfOpen is the state of circuits that let a few trial requests through to decide
+	// whether to close again.
+	CircuitHalfOpen
+)
+
+type (
+	// CircuitState is the state of a circuit.
+	CircuitState int
+
+	// CircuitBreakerOptions configures the CircuitBreaker middleware.
+	CircuitBreakerOptions struct {
+		// FailureThreshold is the number of consecutive failed requests that opens the
+		// circuit. Defaults to 5.
+		FailureThreshold int
+		// OpenTimeout is the duration the circuit stays open before letting trial requests
+		// through. Defaults to 30s.
+		OpenTimeout time.Duration
+		// HalfOpenRequests is the number of trial requests handled concurrently while the
+		// circuit is half-open, the circuit closes once as many trial requests succeed.
+		// Defaults to 1.
+		HalfOpenRequests int
+		// IsFailure decides whether a request failed given the error returned by the handler
+		// and the response status. Defaults to failing requests that return an error other
+		// than a goa error with a status lower than 500 or that respond with a 5xx status.
+		IsFailure func(err error, status int) bool
+		// Fallback handles the requests received while the circuit is open, e.g. to serve
+		// stale content. The requests are rejected with ErrCircuitOpen by default.
+		Fallback goa.Handler
+		// OnStateChange is called each time the circuit of a route changes state with the
+		// context of the request that triggered the change. It is called while the circuit
+		// is locked and must not block.
+		OnStateChange func(ctx context.Context, from, to CircuitState)
+	}
+
+	// circuit holds the state of the circuit of a route.
+	circuit struct {
+		opts     *CircuitBreakerOptions
+		mu       sync.Mutex
+		state    CircuitState
+		failures int
+		// successes counts the successful trial requests while half-open.
+		successes int
+		// trials counts the trial requests being handled while half-open.
+		trials   int
+		openedAt time.Time
+		// generation is incremented on each state change so that the outcome of requests
+		// admitted in a previous state is ignored.
+		generation int
+	}
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker returns a middleware that keeps a circuit for each route it wraps so that a
+// failing file server or backend does not take out the whole service. The circuit opens once
+// FailureThreshold consecutive requests fail, the requests are then handled by the fallback
+// handler until OpenTimeout elapses. The circuit then becomes half-open and lets up to
+// HalfOpenRequests trial requests through: it closes once as many succeed and opens again as
+// soon as one fails. Since controllers wrap each route independently the middleware may be
+// mounted on a controller to protect each of its routes, including the file servers:
+//
+//	ctrl := service.NewController("public")
+//	ctrl.Use(middleware.CircuitBreaker(&middleware.CircuitBreakerOptions{
+//		FailureThreshold: 3,
+//		OpenTimeout:      10 * time.Second,
+//	}))
+//
+// The middleware should be mounted after the ErrorHandler middleware so that it sees the errors
+// returned by the actions.
+func CircuitBreaker(opts *CircuitBreakerOptions) goa.Middleware {
+	var o CircuitBreakerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = 5
+	}
+	if o.OpenTimeout <= 0 {
+		o.OpenTimeout = 30 * time.Second
+	}
+	if o.HalfOpenRequests <= 0 {
+		o.HalfOpenRequests = 1
+	}
+	if o.IsFailure == nil {
+		o.IsFailure = isFailure
+	}
+	return func(h goa.Handler) goa.Handler {
+		c := &circuit{opts: &o}
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			gen, retryAfter, ok := c.admit(ctx)
+			if !ok {
+				key := []string{"goa", "circuit", goa.ContextController(ctx), goa.ContextAction(ctx), "rejected"}
+				goa.IncrCounter(key, 1.0)
+				if o.Fallback != nil {
+					return o.Fallback(ctx, rw, req)
+				}
+				rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				return ErrCircuitOpen("circuit open, retry in %s", retryAfter)
+			}
+			err := h(ctx, rw, req)
+			var status int
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				status = resp.Status
+			}
+			c.done(ctx, gen, o.IsFailure(err, status))
+			return err
+		}
+	}
+}
+
+// isFailure is the default CircuitBreakerOptions IsFailure function.
+func isFailure(err error, status int) bool {
+	if err != nil {
+		if gerr, ok := err.(*goa.Error); ok {
+			return gerr.Status >= 500
+		}
+		return err != context.Canceled
+	}
+	return status >= 500
+}
+
+// admit decides whether a request may be handled. It returns the generation of the circuit the
+// request is admitted in and how long until the circuit lets trial requests through otherwise.
+func (c *circuit) admit(ctx context.Context) (gen int, retryAfter time.Duration, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.state {
+	case CircuitOpen:
+		elapsed := time.Since(c.openedAt)
+		if elapsed < c.opts.OpenTimeout {
+			return 0, c.opts.OpenTimeout - elapsed, false
+		}
+		c.setState(ctx, CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if c.trials >= c.opts.HalfOpenRequests {
+			return 0, time.Second, false
+		}
+		c.trials++
+	}
+	return c.generation, 0, true
+}
+
+// done records the outcome of a request admitted in the given generation.
+func (c *circuit) done(ctx context.Context, gen int, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.generation {
+		return
+	}
+	switch c.state {
+	case CircuitClosed:
+		if !failed {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= c.opts.FailureThreshold {
+			c.setState(ctx, CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		c.trials--
+		if failed {
+			c.setState(ctx, CircuitOpen)
+			return
+		}
+		c.successes++
+		if c.successes >= c.opts.HalfOpenRequests {
+			c.setState(ctx, CircuitClosed)
+		}
+	}
+}
+
+// setState changes the state of the circuit and resets its counters. The caller must hold the
+// lock.
+func (c *circuit) setState(ctx context.Context, state CircuitState) {
+	from := c.state
+	c.state = state
+	c.generation++
+	c.failures, c.successes, c.trials = 0, 0, 0
+	if state == CircuitOpen {
+		c.openedAt = time.Now()
+	}
+	goa.LogInfo(ctx, "circuit breaker", "from", from.String(), "to", state.String())
+	goa.IncrCounter([]string{"goa", "circuit", goa.ContextController(ctx), goa.ContextAction(ctx), state.String()}, 1.0)
+	if c.opts.OnStateChange != nil {
+		c.opts.OnStateChange(ctx, from, state)
+	}
+}
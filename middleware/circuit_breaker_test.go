@@ -0,0 +1,145 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CircuitBreaker", func() {
+	var opts *middleware.CircuitBreakerOptions
+	var fail bool
+	var calls int
+	var transitions []middleware.CircuitState
+	var h goa.Handler
+
+	serve := func() (*testResponseWriter, error) {
+		req, _ := http.NewRequest("GET", "/assets/app.js", nil)
+		rw := newTestResponseWriter()
+		ctx := newContext(newService(nil), rw, req, nil)
+		return rw, h(ctx, rw, req)
+	}
+
+	BeforeEach(func() {
+		fail = true
+		calls = 0
+		transitions = nil
+		opts = &middleware.CircuitBreakerOptions{
+			FailureThreshold: 2,
+			OpenTimeout:      20 * time.Millisecond,
+			OnStateChange: func(_ context.Context, _, to middleware.CircuitState) {
+				transitions = append(transitions, to)
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		h = middleware.CircuitBreaker(opts)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			calls++
+			if fail {
+				return errors.New("backend unavailable")
+			}
+			goa.ContextResponse(ctx).WriteHeader(200)
+			return nil
+		})
+	})
+
+	It("opens the circuit after consecutive failures", func() {
+		serve()
+		serve()
+		rw, err := serve()
+		Ω(calls).Should(Equal(2))
+		Ω(err).Should(HaveOccurred())
+		Ω(middleware.ErrCircuitOpen.Is(err)).Should(BeTrue())
+		Ω(rw.Header().Get("Retry-After")).Should(Equal("1"))
+		Ω(transitions).Should(Equal([]middleware.CircuitState{middleware.CircuitOpen}))
+	})
+
+	It("resets the failure count on success", func() {
+		serve()
+		fail = false
+		serve()
+		fail = true
+		serve()
+		_, err := serve()
+		Ω(middleware.ErrCircuitOpen.Is(err)).Should(BeFalse())
+		Ω(calls).Should(Equal(4))
+	})
+
+	It("does not count client errors as failures", func() {
+		opts.FailureThreshold = 1
+		h = middleware.CircuitBreaker(opts)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			calls++
+			return goa.ErrNotFound("no such file")
+		})
+		serve()
+		serve()
+		Ω(calls).Should(Equal(2))
+		Ω(transitions).Should(BeEmpty())
+	})
+
+	It("closes the circuit once a trial request succeeds", func() {
+		serve()
+		serve()
+		time.Sleep(30 * time.Millisecond)
+		fail = false
+		_, err := serve()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(transitions).Should(Equal([]middleware.CircuitState{
+			middleware.CircuitOpen, middleware.CircuitHalfOpen, middleware.CircuitClosed,
+		}))
+	})
+
+	It("opens the circuit again when a trial request fails", func() {
+		serve()
+		serve()
+		time.Sleep(30 * time.Millisecond)
+		serve()
+		_, err := serve()
+		Ω(middleware.ErrCircuitOpen.Is(err)).Should(BeTrue())
+		Ω(calls).Should(Equal(3))
+		Ω(transitions).Should(Equal([]middleware.CircuitState{
+			middleware.CircuitOpen, middleware.CircuitHalfOpen, middleware.CircuitOpen,
+		}))
+	})
+
+	Context("with a fallback handler", func() {
+		BeforeEach(func() {
+			opts.Fallback = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				goa.ContextResponse(ctx).WriteHeader(203)
+				return nil
+			}
+		})
+
+		It("handles the requests with the fallback while open", func() {
+			serve()
+			serve()
+			rw, err := serve()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Status).Should(Equal(203))
+		})
+	})
+
+	It("keeps a circuit per route", func() {
+		mw := middleware.CircuitBreaker(opts)
+		failing := mw(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return errors.New("backend unavailable")
+		})
+		healthy := mw(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return nil
+		})
+		req, _ := http.NewRequest("GET", "/", nil)
+		rw := newTestResponseWriter()
+		ctx := newContext(newService(nil), rw, req, nil)
+		failing(ctx, rw, req)
+		failing(ctx, rw, req)
+		Ω(middleware.ErrCircuitOpen.Is(failing(ctx, rw, req))).Should(BeTrue())
+		Ω(healthy(ctx, rw, req)).ShouldNot(HaveOccurred())
+	})
+})
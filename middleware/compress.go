@@ -0,0 +1,340 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goadesign/goa"
+)
+
+// DefaultCompressSkipTypes lists the media types whose content is already compressed and that the
+// Compress middleware sends as is by default. Entries ending with a slash match all the media
+// types with that prefix.
+var DefaultCompressSkipTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/zstd",
+}
+
+type (
+	// Compressor is the interface implemented by the writers that compress response bodies.
+	// Compressors are pooled and reset for each response.
+	Compressor interface {
+		io.WriteCloser
+		// Reset discards the state of the compressor and makes it write to w.
+		Reset(w io.Writer)
+	}
+
+	// CompressorFunc creates a compressor that writes to w using the given compression level.
+	CompressorFunc func(w io.Writer, level int) (Compressor, error)
+
+	// CompressOptions configures the Compress middleware.
+	CompressOptions struct {
+		// Level is the compression level passed to the compressors. Defaults to
+		// gzip.DefaultCompression.
+		Level int
+		// MinSize is the length in bytes under which response bodies are sent uncompressed.
+		// Defaults to 1024.
+		MinSize int
+		// Encodings lists the content codings the middleware may use in order of preference,
+		// the preference breaks ties between the codings accepted with the same quality by
+		// the client. Defaults to "gzip" and "deflate".
+		Encodings []string
+		// Compressors adds compressors for content codings other than "gzip" and "deflate",
+		// for example "br" using a third party brotli package. The codings must also be listed
+		// in Encodings.
+		Compressors map[string]CompressorFunc
+		// SkipTypes lists the media types that are never compressed. Defaults to
+		// DefaultCompressSkipTypes.
+		SkipTypes []string
+	}
+
+	// compressor holds the settings and writer pools of the Compress middleware.
+	compressor struct {
+		opts  CompressOptions
+		funcs map[string]CompressorFunc
+		pools map[string]*sync.Pool
+	}
+
+	// compressWriter buffers the response body until it is long enough to decide whether to
+	// compress it.
+	compressWriter struct {
+		http.ResponseWriter
+		c        *compressor
+		encoding string
+		buf      bytes.Buffer
+		status   int
+		decided  bool
+		skip     bool
+		cw       Compressor
+	}
+
+	// skipCompressionKey is the context key used to store the response writer of the Compress
+	// middleware.
+	skipCompressionKey struct{}
+)
+
+// Compress returns a middleware that compresses the response bodies using the content coding
+// negotiated with the request Accept-Encoding header. Responses that are shorter than the minimum
+// size, that have a media type listed in the skip types or that are already encoded are sent as
+// is, as are the responses of the actions wrapped with SkipCompression. The compressors are pooled
+// and reused across requests. opts may be nil, see CompressOptions for the default values.
+func Compress(opts *CompressOptions) goa.Middleware {
+	var o CompressOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == 0 {
+		o.Level = gzip.DefaultCompression
+	}
+	if o.MinSize <= 0 {
+		o.MinSize = 1024
+	}
+	if len(o.Encodings) == 0 {
+		o.Encodings = []string{"gzip", "deflate"}
+	}
+	if o.SkipTypes == nil {
+		o.SkipTypes = DefaultCompressSkipTypes
+	}
+	c := &compressor{
+		opts: o,
+		funcs: map[string]CompressorFunc{
+			"gzip": func(w io.Writer, level int) (Compressor, error) {
+				return gzip.NewWriterLevel(w, level)
+			},
+			"deflate": func(w io.Writer, level int) (Compressor, error) {
+				return flate.NewWriter(w, level)
+			},
+		},
+		pools: make(map[string]*sync.Pool),
+	}
+	for enc, fn := range o.Compressors {
+		c.funcs[enc] = fn
+	}
+	for _, enc := range o.Encodings {
+		fn, ok := c.funcs[enc]
+		if !ok {
+			panic(fmt.Sprintf("compress: no compressor for content coding %q", enc)) // bug
+		}
+		if _, err := fn(io.Discard, o.Level); err != nil {
+			panic(fmt.Sprintf("compress: invalid level for content coding %q: %s", enc, err)) // bug
+		}
+		c.pools[enc] = &sync.Pool{New: func() interface{} {
+			cw, _ := fn(io.Discard, o.Level)
+			return cw
+		}}
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp := goa.ContextResponse(ctx)
+			if resp == nil || req.Method == "HEAD" || req.Header.Get("Sec-WebSocket-Key") != "" {
+				return h(ctx, rw, req)
+			}
+			resp.Header().Add("Vary", "Accept-Encoding")
+			enc := c.negotiate(req.Header.Get("Accept-Encoding"))
+			if enc == "" {
+				return h(ctx, rw, req)
+			}
+			w := &compressWriter{ResponseWriter: resp.SwitchWriter(nil), c: c, encoding: enc}
+			resp.SwitchWriter(w)
+			err := h(context.WithValue(ctx, skipCompressionKey{}, w), rw, req)
+			if cerr := w.close(); err == nil {
+				err = cerr
+			}
+			resp.SwitchWriter(w.ResponseWriter)
+			return err
+		}
+	}
+}
+
+// SkipCompression returns a middleware that prevents the Compress middleware from compressing the
+// responses of the handler. The generated code applies it to the actions that define the
+// "compress:skip" metadata.
+func SkipCompression(h goa.Handler) goa.Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		if w, ok := ctx.Value(skipCompressionKey{}).(*compressWriter); ok {
+			w.skip = true
+		}
+		return h(ctx, rw, req)
+	}
+}
+
+// negotiate returns the content coding to use given the request Accept-Encoding header value, an
+// empty string if the response must not be compressed.
+func (c *compressor) negotiate(accept string) string {
+	if accept == "" {
+		return ""
+	}
+	qs := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		elems := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(elems[0]))
+		q := 1.0
+		for _, param := range elems[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				if q, err = strconv.ParseFloat(param[2:], 64); err != nil {
+					q = 0
+				}
+			}
+		}
+		qs[coding] = q
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range c.opts.Encodings {
+		q, ok := qs[enc]
+		if !ok {
+			q, ok = qs["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// skipType returns true if the response with the given Content-Type header value must not be
+// compressed.
+func (c *compressor) skipType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range c.opts.SkipTypes {
+		if mt == t || strings.HasSuffix(t, "/") && strings.HasPrefix(mt, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteHeader records the status code, the header is written once the response body is long
+// enough to decide whether to compress it.
+func (w *compressWriter) WriteHeader(status int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers b until the response is long enough to be compressed and then writes it to the
+// compressor.
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		n, _ := w.buf.Write(b)
+		if w.buf.Len() < w.c.opts.MinSize {
+			return n, nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	if w.cw != nil {
+		return w.cw.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush writes the buffered response and flushes the compressor and the underlying writer.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if err := w.decide(w.buf.Len() >= w.c.opts.MinSize); err != nil {
+			return
+		}
+	}
+	if f, ok := w.cw.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets handlers take over the connection, the response is not compressed.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not implement http.Hijacker")
+	}
+	w.decided = true
+	return h.Hijack()
+}
+
+// decide writes the response header and the buffered body, compressed if long is true and the
+// response may be compressed.
+func (w *compressWriter) decide(long bool) error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Type") == "" && w.buf.Len() > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf.Bytes()))
+	}
+	compress := long && !w.skip &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified &&
+		header.Get("Content-Encoding") == "" && header.Get("Content-Range") == "" &&
+		!w.c.skipType(header.Get("Content-Type"))
+	if compress {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			// The compressed representation is not byte for byte identical.
+			header.Set("ETag", "W/"+etag)
+		}
+		w.cw = w.c.pools[w.encoding].Get().(Compressor)
+		w.cw.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.cw != nil {
+		_, err = w.cw.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// close writes the response if it was not written yet and releases the compressor.
+func (w *compressWriter) close() error {
+	if !w.decided {
+		if w.status == 0 {
+			return nil
+		}
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.cw == nil {
+		return nil
+	}
+	err := w.cw.Close()
+	w.cw.Reset(io.Discard)
+	w.c.pools[w.encoding].Put(w.cw)
+	w.cw = nil
+	return err
+}
@@ -0,0 +1,124 @@
+package middleware_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compress", func() {
+	var opts *middleware.CompressOptions
+	var acceptEncoding, contentType string
+	var body []byte
+	var handler goa.Handler
+	var rw *testResponseWriter
+	var err error
+
+	BeforeEach(func() {
+		opts = nil
+		acceptEncoding = "gzip"
+		contentType = "application/json"
+		body = []byte(strings.Repeat(`{"name":"bottle"}`, 100))
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp := goa.ContextResponse(ctx)
+			resp.Header().Set("Content-Type", contentType)
+			resp.WriteHeader(200)
+			_, err := resp.Write(body)
+			return err
+		}
+	})
+
+	JustBeforeEach(func() {
+		service := newService(nil)
+		req, _ := http.NewRequest("GET", "/bottles", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rw = newTestResponseWriter()
+		ctx := newContext(service, rw, req, nil)
+		err = middleware.Compress(opts)(handler)(ctx, rw, req)
+	})
+
+	It("compresses the response with gzip", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Status).Should(Equal(200))
+		Ω(rw.ParentHeader.Get("Content-Encoding")).Should(Equal("gzip"))
+		Ω(rw.ParentHeader.Get("Vary")).Should(Equal("Accept-Encoding"))
+		gz, err := gzip.NewReader(bytes.NewReader(rw.Body))
+		Ω(err).ShouldNot(HaveOccurred())
+		b, err := ioutil.ReadAll(gz)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(b).Should(Equal(body))
+	})
+
+	Context("with a client preferring deflate", func() {
+		BeforeEach(func() {
+			acceptEncoding = "gzip;q=0.5, deflate"
+		})
+
+		It("compresses the response with deflate", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(Equal("deflate"))
+			b, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(rw.Body)))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(b).Should(Equal(body))
+		})
+	})
+
+	Context("with a client that does not accept compressed responses", func() {
+		BeforeEach(func() {
+			acceptEncoding = "identity, gzip;q=0"
+		})
+
+		It("does not compress the response", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(rw.Body).Should(Equal(body))
+		})
+	})
+
+	Context("with a response shorter than the minimum size", func() {
+		BeforeEach(func() {
+			opts = &middleware.CompressOptions{MinSize: 4096}
+		})
+
+		It("does not compress the response", func() {
+			Ω(rw.Status).Should(Equal(200))
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(rw.Body).Should(Equal(body))
+		})
+	})
+
+	Context("with an already compressed media type", func() {
+		BeforeEach(func() {
+			contentType = "image/png"
+		})
+
+		It("does not compress the response", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(rw.Body).Should(Equal(body))
+		})
+	})
+
+	Context("with an action that skips compression", func() {
+		BeforeEach(func() {
+			handler = middleware.SkipCompression(handler)
+		})
+
+		It("does not compress the response", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(rw.Body).Should(Equal(body))
+		})
+	})
+
+	Context("with an unknown content coding", func() {
+		It("panics", func() {
+			Ω(func() { middleware.Compress(&middleware.CompressOptions{Encodings: []string{"br"}}) }).Should(Panic())
+		})
+	})
+})
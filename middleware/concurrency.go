@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+// ErrOverloaded is the error returned to requests rejected because the concurrency limit of the
+// action is reached.
+var ErrOverloaded = goa.NewErrorClass("overloaded", 503)
+
+type (
+	// ConcurrencyOptions configures a ConcurrencyLimiter.
+	ConcurrencyOptions struct {
+		// Name is used to build the keys of the limiter metrics, e.g. "bottle.show".
+		Name string
+		// InitialLimit is the number of concurrent requests allowed initially. Defaults to 20.
+		InitialLimit int
+		// MinLimit is the lowest value the limit can decrease to. Defaults to 1.
+		MinLimit int
+		// MaxLimit is the highest value the limit can increase to. Defaults to 10 times the
+		// initial limit.
+		MaxLimit int
+		// Latency is the target latency: requests that take longer decrease the limit.
+		// Defaults to 100ms.
+		Latency time.Duration
+		// Backoff is the factor applied to the limit when it decreases. Defaults to 0.9.
+		Backoff float64
+	}
+
+	// ConcurrencyLimiter limits the number of requests handled concurrently using an additive
+	// increase/multiplicative decrease (AIMD) algorithm. The limit increases by one each time a
+	// request completes within the target latency while the limiter is at least half used and
+	// is multiplied by the backoff factor each time a request exceeds the target latency or
+	// times out. Requests received while the limit is reached are rejected with ErrOverloaded
+	// so that latency sensitive endpoints shed load instead of queueing it.
+	ConcurrencyLimiter struct {
+		opts     ConcurrencyOptions
+		mu       sync.Mutex
+		limit    float64
+		inflight int
+	}
+)
+
+// NewConcurrencyLimiter returns a limiter configured with opts, see ConcurrencyOptions for the
+// default values.
+func NewConcurrencyLimiter(opts *ConcurrencyOptions) *ConcurrencyLimiter {
+	var o ConcurrencyOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.InitialLimit <= 0 {
+		o.InitialLimit = 20
+	}
+	if o.MinLimit <= 0 {
+		o.MinLimit = 1
+	}
+	if o.MaxLimit <= 0 {
+		o.MaxLimit = 10 * o.InitialLimit
+	}
+	if o.Latency <= 0 {
+		o.Latency = 100 * time.Millisecond
+	}
+	if o.Backoff <= 0 || o.Backoff >= 1 {
+		o.Backoff = 0.9
+	}
+	return &ConcurrencyLimiter{opts: o, limit: float64(o.InitialLimit)}
+}
+
+// Limit returns the current number of concurrent requests allowed by the limiter.
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// AdaptiveConcurrency returns a middleware that rejects requests with ErrOverloaded when the
+// number of requests being handled reaches the limit computed by l. Use a different limiter for
+// each action, for example:
+//
+//	h = middleware.AdaptiveConcurrency(middleware.NewConcurrencyLimiter(&middleware.ConcurrencyOptions{
+//		Name:    "bottle.show",
+//		Latency: 50 * time.Millisecond,
+//	}))(h)
+//
+// goagen generates this code for the actions that define the "concurrency:limit" metadata.
+func AdaptiveConcurrency(l *ConcurrencyLimiter) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			busy, ok := l.acquire()
+			if !ok {
+				goa.IncrCounter([]string{"goa", "concurrency", l.opts.Name, "rejected"}, 1.0)
+				rw.Header().Set("Retry-After", "1")
+				return ErrOverloaded("concurrency limit of %d requests reached", l.Limit())
+			}
+			start := time.Now()
+			err := h(ctx, rw, req)
+			l.release(busy, time.Since(start), ctx.Err() == context.DeadlineExceeded)
+			return err
+		}
+	}
+}
+
+// acquire reserves a slot for a request. It returns false if the limit is reached and whether
+// the limiter is at least half used otherwise.
+func (l *ConcurrencyLimiter) acquire() (busy bool, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= int(l.limit) {
+		return false, false
+	}
+	l.inflight++
+	return float64(l.inflight) >= l.limit/2, true
+}
+
+// release frees the slot of a request and adjusts the limit given the request latency.
+func (l *ConcurrencyLimiter) release(busy bool, latency time.Duration, timedOut bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	switch {
+	case timedOut || latency > l.opts.Latency:
+		l.limit = math.Max(float64(l.opts.MinLimit), math.Floor(l.limit*l.opts.Backoff))
+	case busy:
+		l.limit = math.Min(float64(l.opts.MaxLimit), l.limit+1)
+	}
+	goa.SetGauge([]string{"goa", "concurrency", l.opts.Name, "limit"}, float32(l.limit))
+}
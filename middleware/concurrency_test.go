@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AdaptiveConcurrency", func() {
+	var service *goa.Service
+	var req *http.Request
+	var rw *testResponseWriter
+	var ctx context.Context
+
+	BeforeEach(func() {
+		var err error
+		service = newService(nil)
+		req, err = http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw = newTestResponseWriter()
+		ctx = newContext(service, rw, req, nil)
+	})
+
+	It("rejects requests once the limit is reached", func() {
+		l := middleware.NewConcurrencyLimiter(&middleware.ConcurrencyOptions{InitialLimit: 1})
+		var inner error
+		h := middleware.AdaptiveConcurrency(l)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return nil
+		})
+		blocked := middleware.AdaptiveConcurrency(l)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			inner = h(ctx, rw, req)
+			return nil
+		})
+		Ω(blocked(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(inner).Should(HaveOccurred())
+		Ω(inner.(*goa.Error).Status).Should(Equal(503))
+		Ω(rw.Header().Get("Retry-After")).Should(Equal("1"))
+	})
+
+	It("increases the limit when busy requests complete within the target latency", func() {
+		l := middleware.NewConcurrencyLimiter(&middleware.ConcurrencyOptions{InitialLimit: 2, Latency: time.Second})
+		h := middleware.AdaptiveConcurrency(l)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return nil
+		})
+		Ω(h(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(l.Limit()).Should(Equal(3))
+	})
+
+	It("decreases the limit when requests exceed the target latency", func() {
+		l := middleware.NewConcurrencyLimiter(&middleware.ConcurrencyOptions{InitialLimit: 10, Latency: time.Millisecond})
+		h := middleware.AdaptiveConcurrency(l)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+		Ω(h(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(l.Limit()).Should(Equal(9))
+	})
+
+	It("does not decrease the limit below the minimum", func() {
+		l := middleware.NewConcurrencyLimiter(&middleware.ConcurrencyOptions{InitialLimit: 2, MinLimit: 2, Latency: time.Millisecond})
+		h := middleware.AdaptiveConcurrency(l)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+		Ω(h(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(l.Limit()).Should(Equal(2))
+	})
+})
@@ -6,3 +6,6 @@ type middlewareKey int
 
 // ReqIDKey is the context key used by the RequestID middleware to store the request ID value.
 const reqIDKey middlewareKey = 1
+
+// featuresKey is the context key used by the FeatureFlags middleware to store the request features.
+const featuresKey middlewareKey = 2
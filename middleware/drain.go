@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// DrainerOptions configures a Drainer.
+	DrainerOptions struct {
+		// ExemptPaths lists request paths that are served even in maintenance mode in
+		// addition to the health check endpoints mounted by the generated MountHealth
+		// function, see goa.HealthChecker.Paths.
+		ExemptPaths []string
+		// RetryAfter is the time after which clients should retry the requests rejected in
+		// maintenance mode when the service maintenance provider does not specify one.
+		// Defaults to 30s.
+		RetryAfter time.Duration
+	}
+
+	// Drainer rejects the requests made to a service whose maintenance provider reports that
+	// all its resources are in maintenance mode (see goa.AllResources) and tracks the requests
+	// being handled so that deploys can wait for them to complete before shutting down, see
+	// Drain.
+	Drainer struct {
+		service  *goa.Service
+		opts     DrainerOptions
+		exempt   map[string]bool
+		mu       sync.Mutex
+		inflight int
+		// idle is closed when the last request in flight completes.
+		idle chan struct{}
+	}
+)
+
+// NewDrainer returns a drainer for the given service configured with opts, which may be nil.
+func NewDrainer(service *goa.Service, opts *DrainerOptions) *Drainer {
+	var o DrainerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.RetryAfter <= 0 {
+		o.RetryAfter = 30 * time.Second
+	}
+	exempt := make(map[string]bool, len(o.ExemptPaths))
+	for _, p := range o.ExemptPaths {
+		exempt[p] = true
+	}
+	return &Drainer{service: service, opts: o, exempt: exempt}
+}
+
+// Drain returns a middleware that responds to the requests with a 503 Service Unavailable
+// response and a Retry-After header while the service is in maintenance mode, except for the
+// requests made to the health check endpoints and to the exempt paths. The response body uses
+// the same media type as the responses sent by the resources in maintenance mode. The middleware
+// should be mounted first so that rejected requests are cheap. Use it together with a
+// goa.MaintenanceSwitch and graceful shutdown for deploys, for example:
+//
+//	sw := goa.NewMaintenanceSwitch()
+//	service.Maintenance = sw
+//	d := middleware.NewDrainer(service, nil)
+//	service.Use(middleware.Drain(d))
+//	service.Health.AddReadiness("drain", d.Check)
+//	// ...
+//	<-stop
+//	sw.Enable(goa.AllResources, 0) // new requests get a 503, the readiness probe fails
+//	d.Wait(ctx)                    // wait for the requests in flight
+//	server.Shutdown(ctx)
+//
+// The switch admin endpoint may also be used to put the service in maintenance mode, see
+// goa.MaintenanceSwitch.ServeHTTP.
+func Drain(d *Drainer) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if d.exempted(req.URL.Path) {
+				return h(ctx, rw, req)
+			}
+			// Record the request before checking the maintenance mode so that Wait
+			// accounts for all the requests that are not rejected.
+			d.acquire()
+			defer d.release()
+			retryAfter, ok := d.service.InMaintenance(goa.AllResources, d.opts.RetryAfter)
+			if !ok {
+				return h(ctx, rw, req)
+			}
+			rw.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			rw.Header().Set("Content-Type", "application/vnd.goa.maintenance+json")
+			return d.service.Send(ctx, http.StatusServiceUnavailable, map[string]interface{}{
+				"message":     "service is under maintenance",
+				"retry_after": retryAfter,
+			})
+		}
+	}
+}
+
+// Draining returns true if the service is in maintenance mode.
+func (d *Drainer) Draining() bool {
+	_, ok := d.service.InMaintenance(goa.AllResources, 0)
+	return ok
+}
+
+// InFlight returns the number of requests being handled, the requests made to the exempt paths
+// are not counted.
+func (d *Drainer) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inflight
+}
+
+// Check is a goa.HealthCheck that fails while the service is in maintenance mode so that load
+// balancers stop sending traffic to the instance being drained, for example:
+//
+//	service.Health.AddReadiness("drain", d.Check)
+func (d *Drainer) Check(ctx context.Context) error {
+	if d.Draining() {
+		return errors.New("service is in maintenance mode")
+	}
+	return nil
+}
+
+// Wait blocks until no request is being handled or the context is done, it returns the context
+// error in the latter case. Put the service in maintenance mode first so that no new request
+// gets handled.
+func (d *Drainer) Wait(ctx context.Context) error {
+	d.mu.Lock()
+	if d.inflight == 0 {
+		d.mu.Unlock()
+		return nil
+	}
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+	idle := d.idle
+	d.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// exempted returns true if requests made to the given path are served in maintenance mode.
+func (d *Drainer) exempted(path string) bool {
+	if d.exempt[path] {
+		return true
+	}
+	if d.service.Health == nil {
+		return false
+	}
+	for _, p := range d.service.Health.Paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// acquire records a request in flight.
+func (d *Drainer) acquire() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inflight++
+}
+
+// release records the completion of a request and notifies the waiters once idle.
+func (d *Drainer) release() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inflight--
+	if d.inflight == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
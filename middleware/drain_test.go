@@ -0,0 +1,126 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drain", func() {
+	var service *goa.Service
+	var sw *goa.MaintenanceSwitch
+	var d *middleware.Drainer
+	var h goa.Handler
+	var called bool
+
+	serve := func(path string) (*testResponseWriter, error) {
+		req, _ := http.NewRequest("GET", path, nil)
+		rw := newTestResponseWriter()
+		ctx := newContext(service, rw, req, nil)
+		return rw, h(ctx, rw, req)
+	}
+
+	BeforeEach(func() {
+		service = newService(nil)
+		service.Health.Paths = []string{"/live", "/ready"}
+		sw = goa.NewMaintenanceSwitch()
+		service.Maintenance = sw
+		d = middleware.NewDrainer(service, &middleware.DrainerOptions{RetryAfter: 90 * time.Second, ExemptPaths: []string{"/version"}})
+		called = false
+		h = middleware.Drain(d)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called = true
+			return nil
+		})
+	})
+
+	It("handles the requests by default", func() {
+		_, err := serve("/bottles")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(called).Should(BeTrue())
+		Ω(d.Check(context.Background())).ShouldNot(HaveOccurred())
+	})
+
+	It("handles the requests made to resources in maintenance mode", func() {
+		sw.Enable("bottle", 0)
+		serve("/bottles")
+		Ω(called).Should(BeTrue())
+	})
+
+	Context("in maintenance mode", func() {
+		BeforeEach(func() {
+			sw.Enable(goa.AllResources, 0)
+		})
+
+		It("rejects the requests", func() {
+			rw, err := serve("/bottles")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(called).Should(BeFalse())
+			Ω(rw.Status).Should(Equal(503))
+			Ω(rw.Header().Get("Retry-After")).Should(Equal("90"))
+			Ω(rw.Header().Get("Content-Type")).Should(Equal("application/vnd.goa.maintenance+json"))
+			Ω(string(rw.Body)).Should(MatchJSON(`{"message":"service is under maintenance","retry_after":90}`))
+		})
+
+		It("uses the retry hint of the maintenance switch", func() {
+			sw.Enable(goa.AllResources, 10*time.Second)
+			rw, _ := serve("/bottles")
+			Ω(rw.Header().Get("Retry-After")).Should(Equal("10"))
+		})
+
+		It("handles the requests made to the health endpoints", func() {
+			_, err := serve("/ready")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(called).Should(BeTrue())
+		})
+
+		It("handles the requests made to the exempt paths", func() {
+			serve("/version")
+			Ω(called).Should(BeTrue())
+		})
+
+		It("does not exempt other health paths", func() {
+			serve("/healthz")
+			Ω(called).Should(BeFalse())
+		})
+
+		It("fails the readiness check", func() {
+			Ω(d.Check(context.Background())).Should(HaveOccurred())
+		})
+
+		It("handles the requests again once disabled", func() {
+			sw.Disable(goa.AllResources)
+			_, err := serve("/bottles")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(called).Should(BeTrue())
+		})
+	})
+
+	It("waits for the requests in flight", func() {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		h = middleware.Drain(d)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			close(started)
+			<-release
+			return nil
+		})
+		go serve("/bottles")
+		<-started
+		Ω(d.InFlight()).Should(Equal(1))
+		sw.Enable(goa.AllResources, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		Ω(d.Wait(ctx)).Should(Equal(context.DeadlineExceeded))
+
+		done := make(chan error)
+		go func() { done <- d.Wait(context.Background()) }()
+		close(release)
+		Eventually(done).Should(Receive(BeNil()))
+		Ω(d.InFlight()).Should(BeZero())
+	})
+})
@@ -1,17 +1,20 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/goadesign/goa"
-	"golang.org/x/net/context"
 )
 
 // ErrorHandler turns a Go error into an HTTP response. It should be placed in the middleware chain
 // below the logger middleware so the logger properly logs the HTTP response. ErrorHandler
-// understands instances of goa.Error and returns the status and response body embodied in them,
-// it turns other Go error types into a 500 internal error response.
-// If verbose is false the details of internal errors is not included in HTTP responses.
+// understands instances of goa.Error, including errors that wrap one, and returns the status and
+// response body embodied in them, it turns other Go error types into a 500 internal error response.
+// If verbose is false the details of internal errors is not included in HTTP responses. The
+// request ID set by the RequestID middleware is included in the "request_id" metadata of the
+// goa.Error responses.
 func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -20,9 +23,11 @@ func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 				return nil
 			}
 
+			reqID := ContextRequestID(ctx)
 			status := http.StatusInternalServerError
 			var respBody interface{}
-			if err, ok := e.(*goa.Error); ok {
+			var err *goa.Error
+			if errors.As(e, &err) {
 				status = err.Status
 				respBody = err
 				goa.ContextResponse(ctx).ErrorCode = err.Code
@@ -32,18 +37,36 @@ func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 				rw.Header().Set("Content-Type", "text/plain")
 			}
 			if status >= 500 && status < 600 {
-				reqID := ctx.Value(reqIDKey)
-				if reqID == nil {
-					reqID = shortID()
-					ctx = context.WithValue(ctx, reqIDKey, reqID)
+				id := reqID
+				if id == "" {
+					id = shortID()
+					ctx = context.WithValue(ctx, reqIDKey, id)
 				}
-				goa.LogError(ctx, "uncaught error", "id", reqID, "msg", respBody)
+				goa.LogError(ctx, "uncaught error", "id", id, "msg", respBody)
 				if !verbose {
 					rw.Header().Set("Content-Type", goa.ErrorMediaIdentifier)
-					respBody = goa.ErrInternal("%s [%s]", http.StatusText(http.StatusInternalServerError), reqID)
+					respBody = goa.ErrInternal("%s [%s]", http.StatusText(http.StatusInternalServerError), id)
 				}
 			}
+			if ge, ok := respBody.(*goa.Error); ok {
+				respBody = withRequestID(ge, reqID)
+			}
 			return service.Send(ctx, status, respBody)
 		}
 	}
 }
+
+// withRequestID returns a copy of err whose metadata includes the given request ID so that clients
+// may report it, err is returned as is if id is empty.
+func withRequestID(err *goa.Error, id string) *goa.Error {
+	if id == "" {
+		return err
+	}
+	c := *err
+	c.MetaValues = make(map[string]interface{}, len(err.MetaValues)+1)
+	for k, v := range err.MetaValues {
+		c.MetaValues[k] = v
+	}
+	c.MetaValues["request_id"] = id
+	return &c
+}
@@ -2,14 +2,13 @@ package middleware_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/middleware"
 	. "github.com/onsi/ginkgo"
@@ -101,4 +100,23 @@ var _ = Describe("ErrorHandler", func() {
 			Ω(fmt.Sprintf("%v", decoded)).Should(Equal(fmt.Sprintf("%v", *gerr)))
 		})
 	})
+
+	Context("with a handler returning an error wrapping a goa error", func() {
+		BeforeEach(func() {
+			service = newService(nil)
+			h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return fmt.Errorf("show bottle: %w", goa.ErrNotFound("bottle 42"))
+			}
+		})
+
+		It("uses the goa error", func() {
+			var decoded goa.Error
+			Ω(rw.Status).Should(Equal(404))
+			Ω(rw.ParentHeader["Content-Type"]).Should(Equal([]string{goa.ErrorMediaIdentifier}))
+			err := service.Decoder.Decode(&decoded, bytes.NewBuffer(rw.Body), "application/json")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(decoded.Code).Should(Equal("not_found"))
+			Ω(decoded.Detail).Should(Equal("bottle 42"))
+		})
+	})
 })
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// ETagOptions configures the ETag middleware.
+	ETagOptions struct {
+		// Weak produces weak ETags, i.e. prefixed with "W/", for representations that are
+		// semantically equivalent but may not be byte for byte identical.
+		Weak bool
+		// MaxSize is the size of the largest response body hashed in bytes, longer responses
+		// are sent without ETag. Defaults to 1MB.
+		MaxSize int
+		// Hash returns the hash used to compute the ETags. Defaults to sha1.New.
+		Hash func() hash.Hash
+	}
+
+	// etagWriter buffers the response body to compute its ETag.
+	etagWriter struct {
+		http.ResponseWriter
+		opts   *ETagOptions
+		status int
+		buf    bytes.Buffer
+		// passthrough is true once the response is written as is, e.g. because it is too
+		// long or was flushed.
+		passthrough bool
+	}
+)
+
+// ETag returns a middleware that sets the ETag header of the successful responses to GET requests
+// to a hash of the response body unless the handler sets it, and responds with 304 Not Modified
+// and no body when the request If-None-Match header matches it. This saves the bandwidth used to
+// send unchanged representations without changing the controllers. The response body is
+// buffered so the middleware should be mounted after the Compress middleware to hash the encoded
+// media type rather than its compressed representation. opts may be nil, see ETagOptions for the
+// default values.
+func ETag(opts *ETagOptions) goa.Middleware {
+	var o ETagOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxSize <= 0 {
+		o.MaxSize = 1 << 20
+	}
+	if o.Hash == nil {
+		o.Hash = sha1.New
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp := goa.ContextResponse(ctx)
+			if resp == nil || req.Method != "GET" || req.Header.Get("Sec-WebSocket-Key") != "" {
+				return h(ctx, rw, req)
+			}
+			w := &etagWriter{ResponseWriter: resp.SwitchWriter(nil), opts: &o}
+			resp.SwitchWriter(w)
+			err := h(ctx, rw, req)
+			resp.SwitchWriter(w.ResponseWriter)
+			if w.passthrough || w.status == 0 {
+				return err
+			}
+			header := w.Header()
+			etag := header.Get("ETag")
+			if etag == "" && w.status == http.StatusOK {
+				hash := o.Hash()
+				hash.Write(w.buf.Bytes())
+				etag = `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+				if o.Weak {
+					etag = "W/" + etag
+				}
+				header.Set("ETag", etag)
+			}
+			if w.status == http.StatusOK && etag != "" && etagMatch(req.Header.Get("If-None-Match"), etag) {
+				header.Del("Content-Type")
+				header.Del("Content-Length")
+				resp.Status = http.StatusNotModified
+				resp.Length = 0
+				w.ResponseWriter.WriteHeader(http.StatusNotModified)
+				return err
+			}
+			w.ResponseWriter.WriteHeader(w.status)
+			if _, werr := w.ResponseWriter.Write(w.buf.Bytes()); err == nil {
+				err = werr
+			}
+			return err
+		}
+	}
+}
+
+// WriteHeader records the status code, the header is written once the response is complete.
+func (w *etagWriter) WriteHeader(status int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers b until the response is complete or exceeds the maximum size.
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.buf.Len()+len(b) > w.opts.MaxSize {
+		if err := w.pass(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// Flush writes the buffered response as is and flushes the underlying writer.
+func (w *etagWriter) Flush() {
+	if !w.passthrough {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if err := w.pass(); err != nil {
+			return
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// pass writes the response header and the buffered body and writes the rest of the response as
+// is.
+func (w *etagWriter) pass() error {
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// etagMatch returns true if the If-None-Match header value ifNoneMatch matches etag using the weak
+// comparison defined by RFC 7232.
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ETag", func() {
+	var opts *middleware.ETagOptions
+	var method string
+	var ifNoneMatch string
+	var body interface{}
+	var etag string
+	var rw *httptest.ResponseRecorder
+	var resp *goa.ResponseData
+
+	BeforeEach(func() {
+		opts = nil
+		method = "GET"
+		ifNoneMatch = ""
+		body = map[string]string{"name": "merlot"}
+		etag = ""
+	})
+
+	JustBeforeEach(func() {
+		service := newService(nil)
+		service.Use(middleware.ETag(opts))
+		ctrl := service.NewController("bottles")
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp = goa.ContextResponse(ctx)
+			if etag != "" {
+				rw.Header().Set("ETag", etag)
+			}
+			return service.Send(ctx, 200, body)
+		}
+		req, err := http.NewRequest(method, "/bottles/1", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rw = httptest.NewRecorder()
+		ctrl.MuxHandler("show", h, nil)(rw, req, nil)
+	})
+
+	It("sets the ETag header to the hash of the response body", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("ETag")).Should(MatchRegexp(`^"[0-9a-f]{40}"$`))
+		Ω(rw.Body.String()).Should(Equal(`{"name":"merlot"}` + "\n"))
+	})
+
+	Context("with a matching If-None-Match header", func() {
+		BeforeEach(func() {
+			ifNoneMatch = `"other", W/"` + sha1Hex(`{"name":"merlot"}`+"\n") + `"`
+		})
+
+		It("responds with 304 Not Modified and no body", func() {
+			Ω(rw.Code).Should(Equal(304))
+			Ω(rw.Body.Len()).Should(BeZero())
+			Ω(rw.Header().Get("ETag")).ShouldNot(BeEmpty())
+			Ω(resp.Status).Should(Equal(304))
+			Ω(resp.Length).Should(BeZero())
+		})
+	})
+
+	Context("with a non matching If-None-Match header", func() {
+		BeforeEach(func() {
+			ifNoneMatch = `"other"`
+		})
+
+		It("sends the response", func() {
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.Len()).ShouldNot(BeZero())
+		})
+	})
+
+	Context("with an ETag set by the handler", func() {
+		BeforeEach(func() {
+			etag = `"v42"`
+			ifNoneMatch = `"v42"`
+		})
+
+		It("uses it", func() {
+			Ω(rw.Code).Should(Equal(304))
+			Ω(rw.Header().Get("ETag")).Should(Equal(`"v42"`))
+		})
+	})
+
+	Context("with weak ETags", func() {
+		BeforeEach(func() {
+			opts = &middleware.ETagOptions{Weak: true}
+		})
+
+		It("sets a weak ETag", func() {
+			Ω(rw.Header().Get("ETag")).Should(HavePrefix(`W/"`))
+		})
+	})
+
+	Context("with a response longer than the maximum size", func() {
+		BeforeEach(func() {
+			opts = &middleware.ETagOptions{MaxSize: 10}
+			body = strings.Repeat("a", 100)
+		})
+
+		It("sends the response without ETag", func() {
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get("ETag")).Should(BeEmpty())
+			Ω(rw.Body.Len()).Should(Equal(103))
+		})
+	})
+
+	Context("with a request other than GET", func() {
+		BeforeEach(func() {
+			method = "POST"
+		})
+
+		It("does not set the ETag", func() {
+			Ω(rw.Header().Get("ETag")).Should(BeEmpty())
+		})
+	})
+})
+
+func sha1Hex(s string) string {
+	h := sha1.Sum([]byte(s))
+	return hex.EncodeToString(h[:])
+}
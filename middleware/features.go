@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+const (
+	// FeatureFlagsHeader is the name of the header used to transmit feature flags.
+	FeatureFlagsHeader = "X-Feature-Flags"
+
+	// FeatureFlagsCookie is the name of the cookie used to transmit feature flags.
+	FeatureFlagsCookie = "feature_flags"
+)
+
+// Features is the set of feature flags set on a request indexed by flag name. The values are the
+// flag variants, flags set without a variant have an empty value.
+type Features map[string]string
+
+// FeatureFlagsWith behaves like the middleware FeatureFlags, but it takes the names of the header
+// and cookie used to transmit the flags as arguments. An empty name disables the corresponding
+// source.
+func FeatureFlagsWith(header, cookie string) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			features := make(Features)
+			if cookie != "" {
+				if c, err := req.Cookie(cookie); err == nil {
+					features.parse(c.Value)
+				}
+			}
+			if header != "" {
+				for _, v := range req.Header[http.CanonicalHeaderKey(header)] {
+					features.parse(v)
+				}
+			}
+			ctx = context.WithValue(ctx, featuresKey, features)
+
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// FeatureFlags is a middleware that parses the feature flags set on the request and stores them
+// in the context. Retrieve them using ContextFeatures. The flags are read from the FeatureFlagsCookie
+// cookie and from the FeatureFlagsHeader header, flags set in the header override the ones set in
+// the cookie. Both values consist of a comma separated list of flag names optionally followed by
+// "=" and the flag variant, for example:
+//
+//	X-Feature-Flags: new_checkout=treatment, dark_mode
+//
+func FeatureFlags() goa.Middleware {
+	return FeatureFlagsWith(FeatureFlagsHeader, FeatureFlagsCookie)
+}
+
+// ContextFeatures extracts the feature flags from the context. It returns an empty set if the
+// FeatureFlags middleware did not run.
+func ContextFeatures(ctx context.Context) Features {
+	if f, ok := ctx.Value(featuresKey).(Features); ok {
+		return f
+	}
+	return Features{}
+}
+
+// Enabled returns true if the flag with the given name is set.
+func (f Features) Enabled(name string) bool {
+	_, ok := f[name]
+	return ok
+}
+
+// Variant returns the variant of the flag with the given name, the empty string if the flag is not
+// set or was set without a variant.
+func (f Features) Variant(name string) string {
+	return f[name]
+}
+
+// parse adds the flags listed in the given header or cookie value to the set.
+func (f Features) parse(val string) {
+	for _, elem := range strings.Split(val, ",") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+		name, variant := elem, ""
+		if i := strings.Index(elem, "="); i >= 0 {
+			name, variant = strings.TrimSpace(elem[:i]), strings.TrimSpace(elem[i+1:])
+		}
+		if name != "" {
+			f[name] = variant
+		}
+	}
+}
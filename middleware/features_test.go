@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FeatureFlags", func() {
+	var ctx context.Context
+	var rw http.ResponseWriter
+	var req *http.Request
+	var service *goa.Service
+	var features middleware.Features
+
+	BeforeEach(func() {
+		service = newService(nil)
+
+		var err error
+		req, err = http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw = new(testResponseWriter)
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		ctx = newContext(service, rw, req, url.Values{})
+		features = nil
+	})
+
+	JustBeforeEach(func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			features = middleware.ContextFeatures(ctx)
+			return service.Send(ctx, 200, "ok")
+		}
+		Ω(middleware.FeatureFlags()(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+	})
+
+	Context("with flags set in the header", func() {
+		BeforeEach(func() {
+			req.Header.Set("X-Feature-Flags", "new_checkout=treatment, dark_mode,,")
+		})
+
+		It("sets the features in the context", func() {
+			Ω(features).Should(HaveLen(2))
+			Ω(features.Enabled("new_checkout")).Should(BeTrue())
+			Ω(features.Variant("new_checkout")).Should(Equal("treatment"))
+			Ω(features.Enabled("dark_mode")).Should(BeTrue())
+			Ω(features.Variant("dark_mode")).Should(BeEmpty())
+			Ω(features.Enabled("unknown")).Should(BeFalse())
+		})
+	})
+
+	Context("with flags set in the cookie and the header", func() {
+		BeforeEach(func() {
+			req.Header.Set("Cookie", `feature_flags="new_checkout=control,beta"`)
+			req.Header.Set("X-Feature-Flags", "new_checkout=treatment")
+		})
+
+		It("merges the flags giving precedence to the header", func() {
+			Ω(features).Should(HaveLen(2))
+			Ω(features.Variant("new_checkout")).Should(Equal("treatment"))
+			Ω(features.Enabled("beta")).Should(BeTrue())
+		})
+	})
+
+	Context("with no flag", func() {
+		It("sets an empty feature set", func() {
+			Ω(features).ShouldNot(BeNil())
+			Ω(features).Should(BeEmpty())
+		})
+	})
+})
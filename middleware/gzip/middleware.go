@@ -2,13 +2,12 @@ package gzip
 
 import (
 	"compress/gzip"
+	"context"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"sync"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa"
 )
 
@@ -3,12 +3,11 @@ package gzip_test
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"io"
 	"net/http"
 	"strings"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa"
 	gzm "github.com/goadesign/goa/middleware/gzip"
 	. "github.com/onsi/ginkgo"
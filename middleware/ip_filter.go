@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+// ErrIPForbidden is the error returned to requests rejected because of their client IP address.
+var ErrIPForbidden = goa.NewErrorClass("ip_forbidden", 403)
+
+// IPFilterOptions configures the IPFilter middleware. The lists contain IP addresses or CIDR
+// ranges, e.g. "10.0.0.0/8" or "2001:db8::/32".
+type IPFilterOptions struct {
+	// Allow lists the client addresses allowed to make requests. All addresses are allowed if
+	// empty.
+	Allow []string
+	// Deny lists the client addresses not allowed to make requests, it takes precedence over
+	// Allow.
+	Deny []string
+	// TrustedProxies lists the addresses of the proxies whose forwarding headers are trusted
+	// to compute the client address, see IPFilter.
+	TrustedProxies []string
+	// ForwardedHeader is the name of the header the trusted proxies set to forward the
+	// client address, defaults to "X-Forwarded-For". The header must contain a comma
+	// separated list of addresses, the RFC 7239 "Forwarded" header is also supported. Other
+	// forwarding headers are ignored as they may be set by the client.
+	ForwardedHeader string
+}
+
+// IPFilter returns a middleware that rejects the requests made by clients whose IP address is not
+// in the allow list or is in the deny list with ErrIPForbidden. The client address is the request
+// remote address unless it is a trusted proxy: the addresses listed in the forwarding header set
+// by the trusted proxies (see IPFilterOptions.ForwardedHeader) are then walked from the last to
+// the first and the client address is the first one that is not a trusted proxy. Forwarded
+// addresses set by untrusted clients are thus ignored. The middleware may be mounted on the
+// service or on the controllers that require a specific filter, for example:
+//
+//	ctrl := service.NewController("admin")
+//	ctrl.Use(middleware.IPFilter(&middleware.IPFilterOptions{
+//		Allow:          []string{"10.0.0.0/8"},
+//		TrustedProxies: []string{"10.1.0.0/16"},
+//	}))
+//
+// IPFilter panics if a list contains an invalid address.
+func IPFilter(opts *IPFilterOptions) goa.Middleware {
+	var o IPFilterOptions
+	if opts != nil {
+		o = *opts
+	}
+	allow := parseCIDRs(o.Allow)
+	deny := parseCIDRs(o.Deny)
+	trusted := parseCIDRs(o.TrustedProxies)
+	header := o.ForwardedHeader
+	if header == "" {
+		header = "X-Forwarded-For"
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			ip := clientIP(req, header, trusted)
+			if ip == nil || containsIP(deny, ip) || (len(allow) > 0 && !containsIP(allow, ip)) {
+				addr := "unknown"
+				if ip != nil {
+					addr = ip.String()
+				}
+				return ErrIPForbidden("client address %s is not allowed", addr)
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// clientIP returns the IP address of the client that made the request given the forwarding header
+// set by the trusted proxies, nil if it cannot be determined.
+func clientIP(req *http.Request, header string, trusted []*net.IPNet) net.IP {
+	ip := parseIP(req.RemoteAddr)
+	if ip == nil || !containsIP(trusted, ip) {
+		return ip
+	}
+	hops := forwardedFor(req.Header, header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip = parseIP(hops[i])
+		if ip == nil || !containsIP(trusted, ip) {
+			return ip
+		}
+	}
+	return ip
+}
+
+// forwardedFor returns the addresses listed in the given forwarding header in order. The "for"
+// parameters are read if the header is the RFC 7239 Forwarded header.
+func forwardedFor(h http.Header, header string) []string {
+	var hops []string
+	rfc7239 := strings.EqualFold(header, "Forwarded")
+	for _, v := range h.Values(header) {
+		for _, elem := range strings.Split(v, ",") {
+			if !rfc7239 {
+				hops = append(hops, strings.TrimSpace(elem))
+				continue
+			}
+			for _, pair := range strings.Split(elem, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+					hops = append(hops, strings.Trim(kv[1], `"`))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseIP parses an IP address optionally followed by a port, IPv6 addresses may be enclosed in
+// brackets. It returns nil if addr is not a valid address, e.g. "unknown" or an obfuscated
+// identifier.
+func parseIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+}
+
+// parseCIDRs parses the given IP addresses and CIDR ranges.
+func parseCIDRs(addrs []string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(addrs))
+	for i, addr := range addrs {
+		if !strings.Contains(addr, "/") {
+			if ip := net.ParseIP(addr); ip != nil {
+				bits := 8 * net.IPv6len
+				if ip4 := ip.To4(); ip4 != nil {
+					ip, bits = ip4, 8*net.IPv4len
+				}
+				nets[i] = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+				continue
+			}
+		}
+		_, n, err := net.ParseCIDR(addr)
+		if err != nil {
+			panic(fmt.Sprintf("ip filter: invalid address %q", addr)) // bug
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// containsIP returns true if one of the given networks contains ip.
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IPFilter", func() {
+	var opts *middleware.IPFilterOptions
+	var req *http.Request
+	var called bool
+	var err error
+
+	BeforeEach(func() {
+		opts = &middleware.IPFilterOptions{}
+		req, _ = http.NewRequest("GET", "/admin", nil)
+		req.RemoteAddr = "192.0.2.10:4242"
+		called = false
+	})
+
+	JustBeforeEach(func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called = true
+			return nil
+		}
+		rw := newTestResponseWriter()
+		ctx := newContext(newService(nil), rw, req, nil)
+		err = middleware.IPFilter(opts)(h)(ctx, rw, req)
+	})
+
+	It("allows all clients by default", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(called).Should(BeTrue())
+	})
+
+	Context("with an allow list", func() {
+		BeforeEach(func() {
+			opts.Allow = []string{"10.0.0.0/8", "192.0.2.11"}
+		})
+
+		It("rejects the other clients", func() {
+			Ω(called).Should(BeFalse())
+			Ω(err).Should(HaveOccurred())
+			Ω(middleware.ErrIPForbidden.Is(err)).Should(BeTrue())
+			Ω(err.(*goa.Error).Status).Should(Equal(403))
+		})
+
+		Context("and an allowed client", func() {
+			BeforeEach(func() {
+				req.RemoteAddr = "10.2.3.4:4242"
+			})
+
+			It("handles the request", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("with a deny list", func() {
+		BeforeEach(func() {
+			opts.Allow = []string{"192.0.2.0/24"}
+			opts.Deny = []string{"192.0.2.10"}
+		})
+
+		It("rejects the denied clients", func() {
+			Ω(middleware.ErrIPForbidden.Is(err)).Should(BeTrue())
+		})
+	})
+
+	Context("with a request forwarded by an untrusted proxy", func() {
+		BeforeEach(func() {
+			opts.Deny = []string{"192.0.2.10"}
+			req.Header.Set("X-Forwarded-For", "10.2.3.4")
+		})
+
+		It("ignores the forwarding headers", func() {
+			Ω(middleware.ErrIPForbidden.Is(err)).Should(BeTrue())
+		})
+	})
+
+	Context("with a request forwarded by trusted proxies", func() {
+		BeforeEach(func() {
+			opts.Allow = []string{"10.0.0.0/8"}
+			opts.TrustedProxies = []string{"192.0.2.0/24", "172.16.0.1"}
+			req.Header.Set("X-Forwarded-For", "203.0.113.1, 10.2.3.4, 172.16.0.1")
+		})
+
+		It("uses the first address that is not a trusted proxy", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		Context("with a spoofed Forwarded header", func() {
+			BeforeEach(func() {
+				opts.TrustedProxies = []string{"192.0.2.0/24"}
+				req.Header.Set("Forwarded", "for=10.2.3.4")
+				req.Header.Set("X-Forwarded-For", "10.2.3.4, 203.0.113.1")
+			})
+
+			It("only reads the header set by the proxy", func() {
+				Ω(middleware.ErrIPForbidden.Is(err)).Should(BeTrue())
+				Ω(err.Error()).Should(ContainSubstring("203.0.113.1"))
+			})
+		})
+
+		Context("using the Forwarded header", func() {
+			BeforeEach(func() {
+				opts.ForwardedHeader = "Forwarded"
+				req.Header.Set("Forwarded", `for=10.2.3.4;proto=https, for="[2001:db8::17]:4711"`)
+			})
+
+			It("uses the Forwarded header", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.Error()).Should(ContainSubstring("2001:db8::17"))
+			})
+		})
+
+		Context("using a custom header", func() {
+			BeforeEach(func() {
+				opts.ForwardedHeader = "X-Client-Ip"
+				req.Header.Add("X-Client-Ip", "203.0.113.1")
+				req.Header.Add("X-Client-Ip", "10.2.3.4")
+			})
+
+			It("uses the right-most untrusted address of the header", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	It("panics on invalid addresses", func() {
+		Ω(func() { middleware.IPFilter(&middleware.IPFilterOptions{Allow: []string{"nope"}}) }).Should(Panic())
+	})
+})
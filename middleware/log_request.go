@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
@@ -11,22 +12,20 @@ import (
 	"time"
 
 	"github.com/goadesign/goa"
-
-	"golang.org/x/net/context"
 )
 
 // LogRequest creates a request logger middleware.
 // This middleware is aware of the RequestID middleware and if registered after it leverages the
 // request ID for logging.
 // If verbose is true then the middlware logs the request and response bodies.
+// Use AccessLog to produce access logs in a standard format in production.
 func LogRequest(verbose bool) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
-			reqID := ctx.Value(reqIDKey)
-			if reqID == nil {
-				reqID = shortID()
+			// The RequestID middleware adds the request ID to the log context.
+			if ctx.Value(reqIDKey) == nil {
+				ctx = goa.WithLogContext(ctx, "req_id", shortID())
 			}
-			ctx = goa.WithLogContext(ctx, "req_id", reqID)
 			startedAt := time.Now()
 			r := goa.ContextRequest(ctx)
 			goa.LogInfo(ctx, "started", r.Method, r.URL.String(), "from", from(req),
@@ -1,11 +1,10 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/goadesign/goa"
-
-	"golang.org/x/net/context"
 )
 
 // loggingResponseWriter wraps an http.ResponseWriter and writes only raw
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// MethodOverrideOptions configures the MethodOverride mux.
+	MethodOverrideOptions struct {
+		// Allowed lists the methods POST requests may be overridden with. Defaults to PUT,
+		// PATCH and DELETE.
+		Allowed []string
+		// Header is the name of the request header holding the method. Defaults to
+		// "X-HTTP-Method-Override".
+		Header string
+		// Param is the name of the query string parameter holding the method, the header takes
+		// precedence. Defaults to "_method".
+		Param string
+	}
+
+	// methodOverrideMux overrides the method of the requests before dispatching them.
+	methodOverrideMux struct {
+		goa.ServeMux
+		allowed map[string]bool
+		header  string
+		param   string
+	}
+)
+
+// MethodOverride returns a mux that overrides the method of POST requests with the method given
+// in the X-HTTP-Method-Override header or the "_method" query string parameter before
+// dispatching them to mux, for clients stuck behind proxies that only allow GET and POST.
+// Overrides with methods that are not allowed are ignored. opts may be nil.
+//
+// The method must be rewritten before the request is routed so MethodOverride wraps the service
+// mux instead of being a middleware, install it before mounting the controllers:
+//
+//	service := goa.New("api")
+//	service.Mux = middleware.MethodOverride(service.Mux, nil)
+func MethodOverride(mux goa.ServeMux, opts *MethodOverrideOptions) goa.ServeMux {
+	var o MethodOverrideOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Allowed == nil {
+		o.Allowed = []string{"PUT", "PATCH", "DELETE"}
+	}
+	if o.Header == "" {
+		o.Header = "X-HTTP-Method-Override"
+	}
+	if o.Param == "" {
+		o.Param = "_method"
+	}
+	allowed := make(map[string]bool, len(o.Allowed))
+	for _, m := range o.Allowed {
+		allowed[strings.ToUpper(m)] = true
+	}
+	return &methodOverrideMux{ServeMux: mux, allowed: allowed, header: o.Header, param: o.Param}
+}
+
+// ServeHTTP overrides the request method if needed and dispatches the request.
+func (m *methodOverrideMux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method == "POST" {
+		method := req.Header.Get(m.header)
+		query := req.URL.Query()
+		if _, ok := query[m.param]; ok {
+			if method == "" {
+				method = query.Get(m.param)
+			}
+			query.Del(m.param)
+			req.URL.RawQuery = query.Encode()
+		}
+		if method = strings.ToUpper(method); m.allowed[method] {
+			req.Method = method
+		}
+	}
+	m.ServeMux.ServeHTTP(rw, req)
+}
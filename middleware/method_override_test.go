@@ -0,0 +1,66 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MethodOverride", func() {
+	var opts *middleware.MethodOverrideOptions
+	var method string
+	var params url.Values
+
+	serve := func(req *http.Request) {
+		mux := middleware.MethodOverride(goa.NewMux(), opts)
+		handler := func(rw http.ResponseWriter, req *http.Request, p url.Values) {
+			method = req.Method
+			params = p
+		}
+		mux.Handle("POST", "/bottles/:id", handler)
+		mux.Handle("DELETE", "/bottles/:id", handler)
+		mux.Handle("PATCH", "/bottles/:id", handler)
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	BeforeEach(func() {
+		opts = nil
+		method = ""
+		params = nil
+	})
+
+	It("overrides the method with the header", func() {
+		req, _ := http.NewRequest("POST", "/bottles/1", nil)
+		req.Header.Set("X-HTTP-Method-Override", "delete")
+		serve(req)
+		Ω(method).Should(Equal("DELETE"))
+	})
+
+	It("overrides the method with the query string parameter", func() {
+		req, _ := http.NewRequest("POST", "/bottles/1?_method=PATCH&vintage=2012", nil)
+		serve(req)
+		Ω(method).Should(Equal("PATCH"))
+		Ω(params).ShouldNot(HaveKey("_method"))
+		Ω(params.Get("vintage")).Should(Equal("2012"))
+	})
+
+	It("ignores the methods that are not allowed", func() {
+		opts = &middleware.MethodOverrideOptions{Allowed: []string{"PATCH"}}
+		req, _ := http.NewRequest("POST", "/bottles/1", nil)
+		req.Header.Set("X-HTTP-Method-Override", "DELETE")
+		serve(req)
+		Ω(method).Should(Equal("POST"))
+	})
+
+	It("only overrides POST requests", func() {
+		req, _ := http.NewRequest("PATCH", "/bottles/1", nil)
+		req.Header.Set("X-HTTP-Method-Override", "DELETE")
+		serve(req)
+		Ω(method).Should(Equal("PATCH"))
+	})
+})
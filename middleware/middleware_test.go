@@ -1,11 +1,11 @@
 package middleware_test
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 
 	"github.com/goadesign/goa"
-	"golang.org/x/net/context"
 )
 
 // Helper that sets up a "working" service
@@ -0,0 +1,161 @@
+/*
+Package goaprometheus contains a middleware that exposes the request metrics of a goa service to
+Prometheus. The metrics are labeled with the names of the controller and the action that handle
+the requests, these names come from the generated code so that the number of time series is
+bounded by the number of actions in the design.
+Usage:
+
+	// Mount the middleware before the error handler so that it records the final status codes
+	service.Use(middleware.LogRequest(true))
+	service.Use(goaprometheus.Middleware(nil))
+	service.Use(middleware.ErrorHandler(service, true))
+
+	// Serve the metrics gathered by the default registry
+	goaprometheus.Mount(service, "/metrics", nil)
+
+The middleware records the following metrics, prefixed with "goa_http_" by default:
+
+	requests_total            counter of handled requests by ctrl, action, method and code
+	request_duration_seconds  histogram of the request durations by ctrl, action, method and code
+	requests_in_flight        gauge of the requests being handled by ctrl and action
+	response_size_bytes       histogram of the response body lengths by ctrl and action
+*/
+package goaprometheus
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Options configures the metrics recorded by the middleware.
+type Options struct {
+	// Namespace is the first component of the metric names. Defaults to "goa".
+	Namespace string
+	// Subsystem is the second component of the metric names. Defaults to "http".
+	Subsystem string
+	// DurationBuckets lists the upper bounds in seconds of the request duration histogram
+	// buckets. Defaults to prometheus.DefBuckets.
+	DurationBuckets []float64
+	// SizeBuckets lists the upper bounds in bytes of the response size histogram buckets.
+	// Defaults to 100B, 1KB, 10KB, 100KB, 1MB and 10MB.
+	SizeBuckets []float64
+	// Registerer registers the metrics. Defaults to prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+}
+
+// Middleware returns a middleware that records the number, duration and response size of the
+// requests handled by the service as well as the number of requests in flight. opts may be nil,
+// see Options for the default values. Calling Middleware more than once with the same registerer
+// and names reuses the metrics registered by the first call.
+func Middleware(opts *Options) goa.Middleware {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Namespace == "" {
+		o.Namespace = "goa"
+	}
+	if o.Subsystem == "" {
+		o.Subsystem = "http"
+	}
+	if o.DurationBuckets == nil {
+		o.DurationBuckets = prometheus.DefBuckets
+	}
+	if o.SizeBuckets == nil {
+		o.SizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+	}
+	if o.Registerer == nil {
+		o.Registerer = prometheus.DefaultRegisterer
+	}
+
+	requests := register(o.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: o.Namespace,
+		Subsystem: o.Subsystem,
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests handled by the service.",
+	}, []string{"ctrl", "action", "method", "code"})).(*prometheus.CounterVec)
+
+	durations := register(o.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: o.Namespace,
+		Subsystem: o.Subsystem,
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the HTTP requests handled by the service.",
+		Buckets:   o.DurationBuckets,
+	}, []string{"ctrl", "action", "method", "code"})).(*prometheus.HistogramVec)
+
+	inFlight := register(o.Registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: o.Namespace,
+		Subsystem: o.Subsystem,
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests being handled by the service.",
+	}, []string{"ctrl", "action"})).(*prometheus.GaugeVec)
+
+	sizes := register(o.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: o.Namespace,
+		Subsystem: o.Subsystem,
+		Name:      "response_size_bytes",
+		Help:      "Length of the HTTP response bodies written by the service.",
+		Buckets:   o.SizeBuckets,
+	}, []string{"ctrl", "action"})).(*prometheus.HistogramVec)
+
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			ctrl, action := goa.ContextController(ctx), goa.ContextAction(ctx)
+			gauge := inFlight.WithLabelValues(ctrl, action)
+			gauge.Inc()
+			start := time.Now()
+
+			err := h(ctx, rw, req)
+
+			gauge.Dec()
+			status, length := http.StatusOK, 0
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				length = resp.Length
+				if resp.Status != 0 {
+					status = resp.Status
+				} else if err != nil {
+					// The error is written by an error handler mounted before the middleware.
+					status = http.StatusInternalServerError
+				}
+			}
+			code := strconv.Itoa(status)
+			requests.WithLabelValues(ctrl, action, req.Method, code).Inc()
+			durations.WithLabelValues(ctrl, action, req.Method, code).Observe(time.Since(start).Seconds())
+			sizes.WithLabelValues(ctrl, action).Observe(float64(length))
+			return err
+		}
+	}
+}
+
+// Mount mounts a handler that serves the metrics gathered by g on GET requests made to path. g
+// defaults to prometheus.DefaultGatherer. The handler does not run the service middleware so that
+// scraping the metrics does not alter them.
+func Mount(service *goa.Service, path string, g prometheus.Gatherer) {
+	if g == nil {
+		g = prometheus.DefaultGatherer
+	}
+	h := promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+	service.Mux.Handle("GET", path, func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		h.ServeHTTP(rw, req)
+	})
+	service.LogInfo("mount", "ctrl", "Metrics", "action", "Serve", "route", "GET "+path)
+}
+
+// register registers c with r and returns it or returns the collector already registered with
+// the same description.
+func register(r prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := r.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err) // bug
+	}
+	return c
+}
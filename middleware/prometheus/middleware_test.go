@@ -0,0 +1,95 @@
+package goaprometheus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/prometheus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type TestResponseWriter struct {
+	ParentHeader http.Header
+	Body         []byte
+	Status       int
+}
+
+func (t *TestResponseWriter) Header() http.Header {
+	return t.ParentHeader
+}
+
+func (t *TestResponseWriter) Write(b []byte) (int, error) {
+	t.Body = append(t.Body, b...)
+	return len(b), nil
+}
+
+func (t *TestResponseWriter) WriteHeader(s int) {
+	t.Status = s
+}
+
+var _ = Describe("Middleware", func() {
+	var registry *prometheus.Registry
+	var handler goa.Handler
+	var err error
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp := goa.ContextResponse(ctx)
+			resp.WriteHeader(http.StatusCreated)
+			resp.Write([]byte("created"))
+			return nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		service := goa.New("test")
+		ctrl := service.NewController("BottleController")
+		req, _ := http.NewRequest("POST", "/bottles", nil)
+		rw := &TestResponseWriter{ParentHeader: make(http.Header)}
+		ctx := goa.NewContext(goa.WithAction(ctrl.Context, "create"), rw, req, nil)
+		err = goaprometheus.Middleware(&goaprometheus.Options{Registerer: registry})(handler)(ctx, rw, req)
+	})
+
+	It("counts the requests by controller and action", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		expected := `
+# HELP goa_http_requests_total Number of HTTP requests handled by the service.
+# TYPE goa_http_requests_total counter
+goa_http_requests_total{action="create",code="201",ctrl="BottleController",method="POST"} 1
+# HELP goa_http_requests_in_flight Number of HTTP requests being handled by the service.
+# TYPE goa_http_requests_in_flight gauge
+goa_http_requests_in_flight{action="create",ctrl="BottleController"} 0
+`
+		Ω(testutil.GatherAndCompare(registry, strings.NewReader(expected),
+			"goa_http_requests_total", "goa_http_requests_in_flight")).Should(Succeed())
+	})
+
+	It("records the durations and response sizes", func() {
+		Ω(testutil.GatherAndCount(registry, "goa_http_request_duration_seconds", "goa_http_response_size_bytes")).Should(Equal(2))
+	})
+
+	Context("with a handler returning an error", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return errors.New("boom")
+			}
+		})
+
+		It("counts the request as an internal error", func() {
+			Ω(err).Should(HaveOccurred())
+			expected := `
+# HELP goa_http_requests_total Number of HTTP requests handled by the service.
+# TYPE goa_http_requests_total counter
+goa_http_requests_total{action="create",code="500",ctrl="BottleController",method="POST"} 1
+`
+			Ω(testutil.GatherAndCompare(registry, strings.NewReader(expected), "goa_http_requests_total")).Should(Succeed())
+		})
+	})
+})
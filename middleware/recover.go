@@ -1,18 +1,76 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-	"runtime"
+	"net/url"
+	"runtime/debug"
 	"strings"
 
 	"github.com/goadesign/goa"
+)
+
+type (
+	// PanicReport describes a panic recovered by the Recover middleware. It is given to the
+	// panic reporter to send it to an error tracking service such as Sentry or Bugsnag.
+	PanicReport struct {
+		// Value is the value given to panic.
+		Value interface{}
+		// Stack is the full stack trace of the goroutine that panicked.
+		Stack []byte
+		// Ctrl is the name of the controller handling the request.
+		Ctrl string
+		// Action is the name of the action handling the request.
+		Action string
+		// RequestID is the ID of the request set by the RequestID middleware if any.
+		RequestID string
+		// Method is the request HTTP method.
+		Method string
+		// URL is the request URL.
+		URL string
+		// Header is a copy of the request headers.
+		Header http.Header
+		// RemoteAddr is the address of the client.
+		RemoteAddr string
+		// Params contains the request path and querystring parameters.
+		Params url.Values
+		// Payload is the decoded request body if any.
+		Payload interface{}
+	}
+
+	// PanicReporter reports a recovered panic. The context is the context of the request that
+	// caused the panic so that reporters may retrieve any value stored in it.
+	PanicReporter func(ctx context.Context, r *PanicReport)
 
-	"golang.org/x/net/context"
+	// RecoverOptions configures the Recover middleware.
+	RecoverOptions struct {
+		// Reporter is called synchronously with each recovered panic. It must not panic.
+		Reporter PanicReporter
+	}
 )
 
 // Recover is a middleware that recovers panics and maps them to errors.
 func Recover() goa.Middleware {
+	return RecoverWithOptions(nil)
+}
+
+// RecoverWithOptions returns a middleware that recovers panics and maps them to 500 goa errors
+// whose detail contains the panic message and stack trace. The reporter given in opts, which may
+// be nil, is called with each panic, its full stack and a snapshot of the request, for example:
+//
+//	service.Use(middleware.RecoverWithOptions(&middleware.RecoverOptions{
+//		Reporter: func(ctx context.Context, r *middleware.PanicReport) {
+//			sentry.CaptureMessage(fmt.Sprintf("%v\n%s", r.Value, r.Stack))
+//		},
+//	}))
+//
+// The ErrorHandler middleware only sends the details of the errors to clients in verbose mode.
+func RecoverWithOptions(opts *RecoverOptions) goa.Middleware {
+	var o RecoverOptions
+	if opts != nil {
+		o = *opts
+	}
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) (err error) {
 			defer func() {
@@ -26,15 +84,40 @@ func Recover() goa.Middleware {
 					default:
 						msg = "unknown panic"
 					}
-					const size = 64 << 10 // 64KB
-					buf := make([]byte, size)
-					buf = buf[:runtime.Stack(buf, false)]
-					lines := strings.Split(string(buf), "\n")
-					stack := lines[3:]
-					err = fmt.Errorf("%s\n%s", msg, strings.Join(stack, "\n"))
+					stack := debug.Stack()
+					if o.Reporter != nil {
+						o.Reporter(ctx, newPanicReport(ctx, req, r, stack))
+					}
+					// Skip the goroutine header and the debug.Stack frames.
+					lines := strings.Split(string(stack), "\n")
+					if len(lines) > 5 {
+						lines = lines[5:]
+					}
+					err = goa.ErrInternal("%s\n%s", msg, strings.Join(lines, "\n"))
 				}
 			}()
 			return h(ctx, rw, req)
 		}
 	}
 }
+
+// newPanicReport builds the report of a panic that occurred while handling req.
+func newPanicReport(ctx context.Context, req *http.Request, value interface{}, stack []byte) *PanicReport {
+	r := &PanicReport{Value: value, Stack: stack}
+	if ctx != nil {
+		r.Ctrl = goa.ContextController(ctx)
+		r.Action = goa.ContextAction(ctx)
+		r.RequestID = ContextRequestID(ctx)
+		if data := goa.ContextRequest(ctx); data != nil {
+			r.Params = data.Params
+			r.Payload = data.Payload
+		}
+	}
+	if req != nil {
+		r.Method = req.Method
+		r.URL = req.URL.String()
+		r.Header = req.Header.Clone()
+		r.RemoteAddr = from(req)
+	}
+	return r
+}
@@ -1,10 +1,10 @@
 package middleware_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-
-	"golang.org/x/net/context"
+	"net/url"
 
 	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/middleware"
@@ -28,9 +28,19 @@ var _ = Describe("Recover", func() {
 			}
 		})
 
+		It("creates an internal error from the panic message", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.Error).Status).Should(Equal(500))
+		})
+
+		It("includes the stack trace starting at the panic", func() {
+			detail := err.(*goa.Error).Detail
+			Ω(detail).Should(MatchRegexp(`(?s)^panic: boom\npanic\(`))
+		})
+
 		It("creates an error from the panic message", func() {
 			Ω(err).Should(HaveOccurred())
-			Ω(err.Error()).Should(HavePrefix("panic: boom\n"))
+			Ω(err.(*goa.Error).Detail).Should(HavePrefix("panic: boom\n"))
 		})
 	})
 
@@ -43,7 +53,7 @@ var _ = Describe("Recover", func() {
 
 		It("creates an error from the panic error message", func() {
 			Ω(err).Should(HaveOccurred())
-			Ω(err.Error()).Should(HavePrefix("panic: boom\n"))
+			Ω(err.(*goa.Error).Detail).Should(HavePrefix("panic: boom\n"))
 		})
 	})
 
@@ -56,7 +66,48 @@ var _ = Describe("Recover", func() {
 
 		It("creates a generic error message", func() {
 			Ω(err).Should(HaveOccurred())
-			Ω(err.Error()).Should(HavePrefix("unknown panic\n"))
+			Ω(err.(*goa.Error).Detail).Should(HavePrefix("unknown panic\n"))
 		})
 	})
 })
+
+var _ = Describe("RecoverWithOptions", func() {
+	var reports []*middleware.PanicReport
+	var err error
+
+	BeforeEach(func() {
+		reports = nil
+		opts := &middleware.RecoverOptions{
+			Reporter: func(ctx context.Context, r *middleware.PanicReport) {
+				reports = append(reports, r)
+			},
+		}
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			panic("boom")
+		}
+		req, _ := http.NewRequest("POST", "/bottles?sort=name", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		rw := newTestResponseWriter()
+		ctx := newContext(newService(nil), rw, req, url.Values{"sort": {"name"}})
+		ctx = goa.WithAction(ctx, "create")
+		err = middleware.RecoverWithOptions(opts)(h)(ctx, rw, req)
+	})
+
+	It("maps the panic to an internal error", func() {
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrInternal.Is(err)).Should(BeTrue())
+	})
+
+	It("reports the panic with the stack and request snapshot", func() {
+		Ω(reports).Should(HaveLen(1))
+		r := reports[0]
+		Ω(r.Value).Should(Equal("boom"))
+		Ω(string(r.Stack)).Should(ContainSubstring("recover_test.go"))
+		Ω(r.Ctrl).Should(Equal("test"))
+		Ω(r.Action).Should(Equal("create"))
+		Ω(r.Method).Should(Equal("POST"))
+		Ω(r.URL).Should(Equal("/bottles?sort=name"))
+		Ω(r.Header.Get("Authorization")).Should(Equal("Bearer token"))
+		Ω(r.Params.Get("sort")).Should(Equal("name"))
+	})
+})
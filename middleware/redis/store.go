@@ -0,0 +1,69 @@
+/*
+Package goaredis contains a session store that keeps the sessions of the Sessions middleware in
+Redis so that they are shared by all the instances of a service.
+Usage:
+
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	service.Use(middleware.Sessions(&middleware.SessionOptions{
+		Secret: []byte(os.Getenv("SESSION_SECRET")),
+		Store:  goaredis.New(client, "cellar:session:"),
+	}))
+
+The sessions are stored as JSON objects under the given prefix followed by the session ID and
+expire with the session cookies.
+*/
+package goaredis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/goadesign/goa/middleware"
+	"gopkg.in/redis.v5"
+)
+
+// store is the Redis session store.
+type store struct {
+	client *redis.Client
+	prefix string
+}
+
+// New returns a session store that keeps the sessions in Redis under keys made of the given
+// prefix followed by the session IDs.
+func New(client *redis.Client, prefix string) middleware.SessionStore {
+	return &store{client: client, prefix: prefix}
+}
+
+// Load returns the values of the session with the given ID.
+func (s *store) Load(_ context.Context, id string) (map[string]string, error) {
+	data, err := s.client.Get(s.prefix + id).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = make(map[string]string)
+	}
+	return values, nil
+}
+
+// Save stores the values of the session with the given ID.
+func (s *store) Save(_ context.Context, id string, values map[string]string, ttl time.Duration) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(s.prefix+id, data, ttl).Err()
+}
+
+// Delete deletes the session with the given ID.
+func (s *store) Delete(_ context.Context, id string) error {
+	return s.client.Del(s.prefix + id).Err()
+}
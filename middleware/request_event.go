@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// RequestEvent is the structured event produced by the RequestEvents middleware for each
+	// request. It carries the request size and latency indicators (SLIs) and the time spent in
+	// each phase of the request handling.
+	RequestEvent struct {
+		// Ctrl is the name of the controller that handled the request.
+		Ctrl string
+		// Action is the name of the action that handled the request.
+		Action string
+		// Method is the request HTTP method.
+		Method string
+		// Path is the request URL path.
+		Path string
+		// Principal identifies the caller, see RequestEventOptions.
+		Principal string
+		// Status is the response HTTP status code.
+		Status int
+		// ErrorCode is the code of the error returned by the action if any.
+		ErrorCode string
+		// RequestBytes is the number of request body bytes read.
+		RequestBytes int64
+		// ResponseBytes is the number of response body bytes written.
+		ResponseBytes int
+		// Duration is the total time spent handling the request.
+		Duration time.Duration
+		// Phases contains the time spent in each phase of the request handling indexed by
+		// phase name, see goa.PhaseDecode, goa.PhaseValidate, goa.PhaseController and
+		// goa.PhaseEncode.
+		Phases map[string]time.Duration
+	}
+
+	// RequestEventOptions configures the RequestEvents middleware.
+	RequestEventOptions struct {
+		// Principal returns the identity of the caller, for example the subject of the
+		// request JWT token. The event principal is empty if Principal is nil.
+		Principal func(ctx context.Context) string
+		// Emit is called with the event once the request has been handled. Emit defaults
+		// to logging the event with the context logger.
+		Emit func(ctx context.Context, e *RequestEvent)
+	}
+)
+
+// RequestEvents creates a middleware that produces one structured event per request describing
+// the request and response sizes, the total latency and the latency of each phase: body decoding,
+// validation, controller and response encoding. The phase timings are recorded by goa and the
+// generated controller code so the middleware may be mounted anywhere in the middleware chain.
+// The events are emitted with the function given in opts, which may be nil.
+func RequestEvents(opts *RequestEventOptions) goa.Middleware {
+	var o RequestEventOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Emit == nil {
+		o.Emit = logRequestEvent
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			startedAt := time.Now()
+			timings := goa.ContextTimings(ctx)
+			if timings != nil {
+				startedAt = timings.Start
+			}
+			err := h(ctx, rw, req)
+			e := &RequestEvent{
+				Ctrl:     goa.ContextController(ctx),
+				Action:   goa.ContextAction(ctx),
+				Method:   req.Method,
+				Path:     req.URL.Path,
+				Duration: time.Since(startedAt),
+			}
+			if o.Principal != nil {
+				e.Principal = o.Principal(ctx)
+			}
+			if r := goa.ContextRequest(ctx); r != nil {
+				e.RequestBytes = r.Length
+			}
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				e.Status = resp.Status
+				e.ErrorCode = resp.ErrorCode
+				e.ResponseBytes = resp.Length
+			}
+			if timings != nil {
+				e.Phases = timings.Phases()
+			}
+			o.Emit(ctx, e)
+			return err
+		}
+	}
+}
+
+// Keyvals returns the event fields as a list of key/value pairs suitable for logging.
+func (e *RequestEvent) Keyvals() []interface{} {
+	keyvals := []interface{}{
+		"ctrl", e.Ctrl,
+		"action", e.Action,
+		"method", e.Method,
+		"path", e.Path,
+		"status", e.Status,
+		"req_bytes", e.RequestBytes,
+		"resp_bytes", e.ResponseBytes,
+		"duration", e.Duration.String(),
+	}
+	if e.Principal != "" {
+		keyvals = append(keyvals, "principal", e.Principal)
+	}
+	if e.ErrorCode != "" {
+		keyvals = append(keyvals, "error", e.ErrorCode)
+	}
+	phases := make([]string, 0, len(e.Phases))
+	for p := range e.Phases {
+		phases = append(phases, p)
+	}
+	sort.Strings(phases)
+	for _, p := range phases {
+		keyvals = append(keyvals, p, e.Phases[p].String())
+	}
+	return keyvals
+}
+
+// logRequestEvent is the default event emitter, it logs the event with the context logger.
+func logRequestEvent(ctx context.Context, e *RequestEvent) {
+	goa.LogInfo(ctx, "request", e.Keyvals()...)
+}
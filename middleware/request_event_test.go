@@ -0,0 +1,82 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestEvents", func() {
+	var service *goa.Service
+	var logger *testLogger
+	var events []*middleware.RequestEvent
+	var opts *middleware.RequestEventOptions
+	var payload interface{}
+
+	BeforeEach(func() {
+		logger = new(testLogger)
+		service = newService(logger)
+		events = nil
+		payload = nil
+		opts = &middleware.RequestEventOptions{
+			Principal: func(context.Context) string { return "alice" },
+			Emit: func(ctx context.Context, e *middleware.RequestEvent) {
+				events = append(events, e)
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		service.Use(middleware.RequestEvents(opts))
+		ctrl := service.NewController("bottles")
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			defer goa.MeasurePhase(ctx, goa.PhaseController)()
+			return service.Send(ctx, 201, payload)
+		}
+		unm := func(ctx context.Context, service *goa.Service, req *http.Request) error {
+			return service.DecodeRequest(req, &payload)
+		}
+		body := []byte(`{"name":"merlot"}`)
+		req, err := http.NewRequest("POST", "/bottles", bytes.NewBuffer(body))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.ContentLength = int64(len(body))
+		ctrl.MuxHandler("create", h, unm)(httptest.NewRecorder(), req, nil)
+	})
+
+	It("emits one event per request", func() {
+		Ω(events).Should(HaveLen(1))
+		e := events[0]
+		Ω(e.Ctrl).Should(Equal("bottles"))
+		Ω(e.Action).Should(Equal("create"))
+		Ω(e.Method).Should(Equal("POST"))
+		Ω(e.Path).Should(Equal("/bottles"))
+		Ω(e.Principal).Should(Equal("alice"))
+		Ω(e.Status).Should(Equal(201))
+		Ω(e.RequestBytes).Should(BeEquivalentTo(17))
+		Ω(e.ResponseBytes).Should(Equal(18))
+		Ω(e.Duration).Should(BeNumerically(">", 0))
+		Ω(e.Phases).Should(HaveKey(goa.PhaseDecode))
+		Ω(e.Phases).Should(HaveKey(goa.PhaseController))
+		Ω(e.Phases).Should(HaveKey(goa.PhaseEncode))
+	})
+
+	Context("with no emitter", func() {
+		BeforeEach(func() {
+			opts = nil
+		})
+
+		It("logs the event", func() {
+			Ω(logger.InfoEntries).ShouldNot(BeEmpty())
+			entry := logger.InfoEntries[len(logger.InfoEntries)-1]
+			Ω(entry.Msg).Should(Equal("request"))
+			Ω(entry.Data).Should(ContainElement("controller"))
+			Ω(entry.Data).Should(ContainElement("resp_bytes"))
+		})
+	})
+})
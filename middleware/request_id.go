@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
@@ -9,12 +10,15 @@ import (
 	"sync/atomic"
 
 	"github.com/goadesign/goa"
-
-	"golang.org/x/net/context"
+	"github.com/goadesign/goa/client"
 )
 
 // RequestIDHeader is the name of the header used to transmit the request ID.
-const RequestIDHeader = "X-Request-Id"
+const RequestIDHeader = client.RequestIDHeader
+
+// maxRequestIDLength is the maximum length of the request IDs read from the request headers, a new
+// ID is generated for requests that send longer IDs.
+const maxRequestIDLength = 128
 
 // Counter used to create new request ids.
 var reqID int64
@@ -41,10 +45,13 @@ func RequestIDWithHeader(requestIDHeader string) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 			id := req.Header.Get(requestIDHeader)
-			if id == "" {
+			if id == "" || len(id) > maxRequestIDLength {
 				id = fmt.Sprintf("%s-%d", reqPrefix, atomic.AddInt64(&reqID, 1))
 			}
 			ctx = context.WithValue(ctx, reqIDKey, id)
+			ctx = client.WithRequestID(ctx, id)
+			ctx = goa.WithLogContext(ctx, "req_id", id)
+			rw.Header().Set(requestIDHeader, id)
 
 			return h(ctx, rw, req)
 		}
@@ -52,8 +59,11 @@ func RequestIDWithHeader(requestIDHeader string) goa.Middleware {
 }
 
 // RequestID is a middleware that injects a request ID into the context of each request.
-// Retrieve it using ctx.Value(ReqIDKey). If the incoming request has a RequestIDHeader header then
-// that value is used else a random value is generated.
+// Retrieve it using ContextRequestID. If the incoming request has a RequestIDHeader header then
+// that value is used else a random value is generated. The ID is added to the log context as
+// "req_id", included in the metadata of the errors sent by ErrorHandler and in the response
+// RequestIDHeader header. The generated clients forward it to the services called with the
+// request context so that the logs and errors of a request share its ID across services.
 func RequestID() goa.Middleware {
 	return RequestIDWithHeader(RequestIDHeader)
 }
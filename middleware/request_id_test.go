@@ -1,12 +1,13 @@
 package middleware_test
 
 import (
+	"context"
 	"net/http"
 	"net/url"
-
-	"golang.org/x/net/context"
+	"strings"
 
 	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
 	"github.com/goadesign/goa/middleware"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -27,7 +28,7 @@ var _ = Describe("RequestID", func() {
 		req, err = http.NewRequest("GET", "/goo", nil)
 		Ω(err).ShouldNot(HaveOccurred())
 		req.Header.Set("X-Request-Id", reqID)
-		rw = new(testResponseWriter)
+		rw = newTestResponseWriter()
 		params = url.Values{"query": []string{"value"}}
 		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
 		ctx = newContext(service, rw, req, params)
@@ -43,4 +44,52 @@ var _ = Describe("RequestID", func() {
 		Ω(rg(ctx, rw, req)).ShouldNot(HaveOccurred())
 		Ω(middleware.ContextRequestID(newCtx)).Should(Equal(reqID))
 	})
+
+	It("sends the request ID in the response", func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return service.Send(ctx, 200, "ok")
+		}
+		Ω(middleware.RequestID()(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(rw.Header().Get("X-Request-Id")).Should(Equal(reqID))
+	})
+
+	It("replaces request IDs that are too long", func() {
+		req.Header.Set("X-Request-Id", strings.Repeat("a", 200))
+		var newCtx context.Context
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			newCtx = ctx
+			return nil
+		}
+		Ω(middleware.RequestID()(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(middleware.ContextRequestID(newCtx)).ShouldNot(BeEmpty())
+		Ω(len(middleware.ContextRequestID(newCtx))).Should(BeNumerically("<", 200))
+	})
+
+	It("includes the request ID in the error responses", func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return goa.ErrBadRequest("bad")
+		}
+		eh := middleware.RequestID()(middleware.ErrorHandler(service, false)(h))
+		Ω(eh(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(string(rw.(*testResponseWriter).Body)).Should(ContainSubstring(`"meta":{"request_id":"request id"}`))
+	})
+
+	It("forwards the request ID with the clients", func() {
+		var forwarded string
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			c := client.New(nil)
+			c.Use(client.RequestIDMiddleware())
+			c.Use(func(client.RoundTripper) client.RoundTripper {
+				return client.RoundTripperFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+					forwarded = req.Header.Get("X-Request-Id")
+					return &http.Response{StatusCode: 204, Body: http.NoBody}, nil
+				})
+			})
+			out, _ := http.NewRequest("GET", "http://cellar/accounts/1", nil)
+			_, err := c.Do(ctx, out)
+			return err
+		}
+		Ω(middleware.RequestID()(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(forwarded).Should(Equal(reqID))
+	})
 })
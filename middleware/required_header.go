@@ -1,12 +1,11 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"regexp"
 
 	"github.com/goadesign/goa"
-
-	"golang.org/x/net/context"
 )
 
 // RequireHeader requires a request header to match a value pattern. If the
@@ -1,12 +1,11 @@
 package middleware_test
 
 import (
+	"context"
 	"net/http"
 	"regexp"
 	"strings"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/middleware"
 	. "github.com/onsi/ginkgo"
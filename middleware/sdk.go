@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+)
+
+// sdkKeyReplacer replaces the characters that metric sinks use to separate key components.
+var sdkKeyReplacer = strings.NewReplacer(".", "_", " ", "_")
+
+// SDKMetrics is a middleware that records the distribution of the generated clients that send
+// requests to the service. Generated clients set the client.SDKLanguageHeader and
+// client.SDKVersionHeader headers, SDKMetrics increments the goa.sdk.<language>.<version> counter
+// for each request, using "unknown" for the requests that do not set the headers. The version and
+// language are also added to the request log context.
+//
+// The counters make it possible to check which versions of the clients are still in use before
+// deprecating an API version.
+func SDKMetrics() goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			lang := req.Header.Get(client.SDKLanguageHeader)
+			version := req.Header.Get(client.SDKVersionHeader)
+			if lang != "" || version != "" {
+				ctx = goa.WithLogContext(ctx, "sdk", lang, "sdkVersion", version)
+			}
+			goa.IncrCounter([]string{"goa", "sdk", sdkKey(lang), sdkKey(version)}, 1.0)
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// sdkKey returns the metric key component for the given header value.
+func sdkKey(val string) string {
+	if val == "" {
+		return "unknown"
+	}
+	return sdkKeyReplacer.Replace(val)
+}
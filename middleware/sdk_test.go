@@ -0,0 +1,70 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SDKMetrics", func() {
+	var sink *metrics.InmemSink
+	var service *goa.Service
+	var req *http.Request
+	var rw http.ResponseWriter
+	var ctx context.Context
+
+	BeforeEach(func() {
+		sink = metrics.NewInmemSink(time.Minute, time.Minute)
+		conf := metrics.DefaultConfig("test")
+		conf.EnableHostname = false
+		conf.EnableRuntimeMetrics = false
+		Ω(goa.NewMetrics(conf, sink)).ShouldNot(HaveOccurred())
+
+		service = newService(nil)
+		var err error
+		req, err = http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw = new(testResponseWriter)
+		ctx = newContext(service, rw, req, url.Values{})
+	})
+
+	counter := func(name string) float64 {
+		for _, interval := range sink.Data() {
+			interval.RLock()
+			c, ok := interval.Counters[name]
+			interval.RUnlock()
+			if ok {
+				return float64(c.Sum)
+			}
+		}
+		return 0
+	}
+
+	serve := func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return nil
+		}
+		Ω(middleware.SDKMetrics()(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+	}
+
+	It("counts the requests per SDK language and version", func() {
+		req.Header.Set(client.SDKLanguageHeader, "go")
+		req.Header.Set(client.SDKVersionHeader, "1.0+goagen.1.2.0")
+		serve()
+		serve()
+		Ω(counter("test.goa.sdk.go.1_0+goagen_1_2_0")).Should(Equal(2.0))
+	})
+
+	It("counts the requests that do not come from a generated client", func() {
+		serve()
+		Ω(counter("test.goa.sdk.unknown.unknown")).Should(Equal(1.0))
+	})
+})
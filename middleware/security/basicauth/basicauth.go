@@ -1,10 +1,10 @@
 package basicauth
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/goadesign/goa"
-	"golang.org/x/net/context"
 )
 
 // ErrBasicAuthFailed means it wasn't able to authenticate you with your login/password.
@@ -18,13 +18,16 @@ var ErrBasicAuthFailed = goa.NewErrorClass("basic_auth_failed", 401)
 // It doesn't get simpler than that.
 //
 // If you want to handle the username and password checks dynamically,
-// copy the source of `New`, it's 8 lines and you can tweak at will.
+// copy the source of `New`, it's a few lines and you can tweak at will.
 func New(username, password string) goa.Middleware {
 	middleware, _ := goa.NewMiddleware(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		u, p, ok := r.BasicAuth()
 		if !ok || u != username || p != password {
 			return ErrBasicAuthFailed("Authentication failed")
 		}
+		if req := goa.ContextRequest(ctx); req != nil {
+			req.Principal = u
+		}
 		return nil
 	})
 	return middleware
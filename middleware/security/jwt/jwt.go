@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"context"
 	"crypto/rsa"
 	"fmt"
 	"net/http"
@@ -9,7 +10,6 @@ import (
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/goadesign/goa"
-	"golang.org/x/net/context"
 )
 
 // New returns a middleware to be used with the JWTSecurity DSL definitions of goa.  It supports the
@@ -120,6 +120,11 @@ func New(validationKeys interface{}, validationFunc goa.Middleware, scheme *goa.
 			}
 
 			ctx = context.WithValue(ctx, jwtKey, token)
+			if sub, ok := token.Claims["sub"].(string); ok {
+				if r := goa.ContextRequest(ctx); r != nil {
+					r.Principal = sub
+				}
+			}
 			if validationFunc != nil {
 				nextHandler = validationFunc(nextHandler)
 			}
@@ -1,6 +1,7 @@
 package jwt_test
 
 import (
+	"context"
 	"crypto/rsa"
 	"net/http"
 	"net/http/httptest"
@@ -10,7 +11,6 @@ import (
 	"github.com/goadesign/goa/middleware/security/jwt"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"golang.org/x/net/context"
 )
 
 var _ = Describe("Middleware", func() {
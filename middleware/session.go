@@ -0,0 +1,471 @@
+package middleware
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// SessionStore stores the values of the sessions server side, the session cookie then only
+	// holds the session ID. See NewMemorySessionStore and package goaredis for implementations.
+	SessionStore interface {
+		// Load returns the values of the session with the given ID, nil if there is no such
+		// session or if it expired.
+		Load(ctx context.Context, id string) (map[string]string, error)
+		// Save stores the values of the session with the given ID for the given duration.
+		Save(ctx context.Context, id string, values map[string]string, ttl time.Duration) error
+		// Delete deletes the session with the given ID.
+		Delete(ctx context.Context, id string) error
+	}
+
+	// SessionOptions configures the Sessions middleware.
+	SessionOptions struct {
+		// Secret is the key used to sign and encrypt the session cookies, it should be at
+		// least 32 bytes long. Required.
+		Secret []byte
+		// Encrypt encrypts the session cookies so that clients cannot read their content.
+		// The cookies are only signed by default.
+		Encrypt bool
+		// Store stores the session values server side. The values are stored in the session
+		// cookie if nil.
+		Store SessionStore
+		// CookieName is the name of the session cookie. Defaults to "goa_session".
+		CookieName string
+		// MaxAge is the duration of the sessions since their last modification. Defaults to
+		// 24 hours.
+		MaxAge time.Duration
+		// Path is the path of the session cookie. Defaults to "/".
+		Path string
+		// Domain is the domain of the session cookie, none by default.
+		Domain string
+		// Secure restricts the session cookie to HTTPS requests. It should be set in
+		// production.
+		Secure bool
+		// SameSite is the SameSite attribute of the session cookie. Defaults to
+		// http.SameSiteLaxMode.
+		SameSite http.SameSite
+	}
+
+	// Session holds the values associated with a client across requests. The values are
+	// saved once the handler starts writing the response or returns, see Sessions.
+	Session struct {
+		id        string
+		values    map[string]string
+		oldID     string
+		modified  bool
+		destroyed bool
+	}
+
+	// MemorySessionStore is a SessionStore that keeps the sessions in memory. It is suitable
+	// for tests and for services that run a single instance. Expired sessions are removed when
+	// loaded and by a sweep that runs at most once per minute when sessions are saved.
+	MemorySessionStore struct {
+		mu       sync.Mutex
+		sessions map[string]memorySession
+		sweptAt  time.Time
+	}
+
+	// memorySession is a session stored by MemorySessionStore.
+	memorySession struct {
+		values    map[string]string
+		expiresAt time.Time
+	}
+
+	// sessionCookie is the content of the session cookies.
+	sessionCookie struct {
+		// ID is the session ID when using a store.
+		ID string `json:"id,omitempty"`
+		// Values are the session values when not using a store.
+		Values map[string]string `json:"v,omitempty"`
+		// Expires is the Unix time the session expires at.
+		Expires int64 `json:"e"`
+	}
+
+	// sessionCodec signs, encrypts and decodes the session cookies.
+	sessionCodec struct {
+		signKey []byte
+		aead    cipher.AEAD
+	}
+
+	// sessionWriter saves the session before the response header is written.
+	sessionWriter struct {
+		http.ResponseWriter
+		commit func()
+	}
+
+	// sessionKey is the private type used to store the session in contexts.
+	sessionKey struct{}
+)
+
+// Sessions returns a middleware that loads the session of the client from the session cookie of
+// the request and stores it in the request context, see ContextSession. A new empty session is
+// created if the request has no valid session cookie. Modified sessions are saved and the
+// session cookie set before the response header is written. The cookies are signed with the
+// secret and optionally encrypted, the session values are stored in the cookie unless a store is
+// configured. Sessions panics if the secret is empty, for example:
+//
+//	service.Use(middleware.Sessions(&middleware.SessionOptions{
+//		Secret: []byte(os.Getenv("SESSION_SECRET")),
+//		Store:  middleware.NewMemorySessionStore(),
+//		Secure: true,
+//	}))
+//
+// Actions then use ContextSession to retrieve the session of the request:
+//
+//	func (c *AccountController) Login(ctx *app.LoginAccountContext) error {
+//		// ... authenticate the user
+//		s := middleware.ContextSession(ctx)
+//		s.Renew()
+//		s.Set("user", user.ID)
+//		return ctx.NoContent()
+//	}
+func Sessions(opts *SessionOptions) goa.Middleware {
+	var o SessionOptions
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.Secret) == 0 {
+		panic("session: secret is required") // bug
+	}
+	if o.CookieName == "" {
+		o.CookieName = "goa_session"
+	}
+	if o.MaxAge <= 0 {
+		o.MaxAge = 24 * time.Hour
+	}
+	if o.Path == "" {
+		o.Path = "/"
+	}
+	if o.SameSite == 0 {
+		o.SameSite = http.SameSiteLaxMode
+	}
+	codec := newSessionCodec(o.Secret, o.Encrypt)
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			s := loadSession(ctx, req, &o, codec)
+			ctx = context.WithValue(ctx, sessionKey{}, s)
+			var once sync.Once
+			commit := func() { once.Do(func() { saveSession(ctx, rw, s, &o, codec) }) }
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				w := &sessionWriter{ResponseWriter: resp.SwitchWriter(nil), commit: commit}
+				resp.SwitchWriter(w)
+				defer resp.SwitchWriter(w.ResponseWriter)
+			}
+			err := h(ctx, rw, req)
+			commit()
+			return err
+		}
+	}
+}
+
+// ContextSession returns the session stored in the context by the Sessions middleware, nil if
+// there is none.
+func ContextSession(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// ID returns the session ID, an empty string if the session values are stored in the cookie.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Get returns the value stored under the given key, an empty string if there is none.
+func (s *Session) Get(key string) string {
+	return s.values[key]
+}
+
+// Set stores a value under the given key.
+func (s *Session) Set(key, value string) {
+	s.destroyed = false
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	s.values[key] = value
+	s.modified = true
+}
+
+// Delete deletes the value stored under the given key.
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.modified = true
+	}
+}
+
+// Renew gives the session a new ID while keeping its values. Call it when the privileges of the
+// client change, e.g. on login, to prevent session fixation attacks.
+func (s *Session) Renew() {
+	s.renewID()
+	s.modified = true
+}
+
+// Destroy deletes the session values and the session cookie. Values set afterwards are saved in a
+// new session.
+func (s *Session) Destroy() {
+	s.renewID()
+	s.values = nil
+	s.modified = false
+	s.destroyed = true
+}
+
+// renewID gives the session a new ID if it is stored in a store and records the previous ID so
+// that the stored session gets deleted.
+func (s *Session) renewID() {
+	if s.id == "" {
+		return
+	}
+	if s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = newSessionID()
+}
+
+// memorySweepInterval is the minimum duration between two sweeps of the expired sessions of a
+// MemorySessionStore.
+const memorySweepInterval = time.Minute
+
+// NewMemorySessionStore returns a session store that keeps the sessions in memory.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]memorySession)}
+}
+
+// Load returns the values of the session with the given ID.
+func (m *MemorySessionStore) Load(_ context.Context, id string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(s.expiresAt) {
+		delete(m.sessions, id)
+		return nil, nil
+	}
+	return copyValues(s.values), nil
+}
+
+// Save stores the values of the session with the given ID. It also removes the expired sessions
+// if the last sweep is more than a minute old.
+func (m *MemorySessionStore) Save(_ context.Context, id string, values map[string]string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if now.Sub(m.sweptAt) >= memorySweepInterval {
+		m.sweptAt = now
+		for k, s := range m.sessions {
+			if now.After(s.expiresAt) {
+				delete(m.sessions, k)
+			}
+		}
+	}
+	m.sessions[id] = memorySession{values: copyValues(values), expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Delete deletes the session with the given ID.
+func (m *MemorySessionStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+// WriteHeader saves the session and writes the header.
+func (w *sessionWriter) WriteHeader(status int) {
+	w.commit()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write saves the session and writes b.
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	w.commit()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush saves the session and flushes the underlying writer.
+func (w *sessionWriter) Flush() {
+	w.commit()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// loadSession loads the session of the request, it returns a new session if there is none.
+func loadSession(ctx context.Context, req *http.Request, o *SessionOptions, codec *sessionCodec) *Session {
+	s := &Session{}
+	if o.Store != nil {
+		s.id = newSessionID()
+	}
+	cookie, err := req.Cookie(o.CookieName)
+	if err != nil {
+		return s
+	}
+	var content sessionCookie
+	if err := codec.decode(o.CookieName, cookie.Value, &content); err != nil {
+		return s
+	}
+	if time.Now().Unix() > content.Expires {
+		return s
+	}
+	if o.Store == nil {
+		s.values = content.Values
+		return s
+	}
+	if content.ID == "" {
+		return s
+	}
+	values, err := o.Store.Load(ctx, content.ID)
+	if err != nil {
+		goa.LogError(ctx, "failed to load session", "err", err)
+		return s
+	}
+	if values != nil {
+		s.id = content.ID
+		s.values = values
+	}
+	return s
+}
+
+// saveSession saves the session if it was modified and sets the session cookie accordingly.
+func saveSession(ctx context.Context, rw http.ResponseWriter, s *Session, o *SessionOptions, codec *sessionCodec) {
+	cookie := &http.Cookie{
+		Name:     o.CookieName,
+		Path:     o.Path,
+		Domain:   o.Domain,
+		Secure:   o.Secure,
+		HttpOnly: true,
+		SameSite: o.SameSite,
+	}
+	if o.Store != nil && s.oldID != "" {
+		if err := o.Store.Delete(ctx, s.oldID); err != nil {
+			goa.LogError(ctx, "failed to delete session", "err", err)
+		}
+	}
+	if s.destroyed {
+		cookie.MaxAge = -1
+		http.SetCookie(rw, cookie)
+		return
+	}
+	if !s.modified {
+		return
+	}
+	content := sessionCookie{Expires: time.Now().Add(o.MaxAge).Unix()}
+	if o.Store != nil {
+		if err := o.Store.Save(ctx, s.id, s.values, o.MaxAge); err != nil {
+			goa.LogError(ctx, "failed to save session", "err", err)
+			return
+		}
+		content.ID = s.id
+	} else {
+		content.Values = s.values
+	}
+	value, err := codec.encode(o.CookieName, &content)
+	if err != nil {
+		goa.LogError(ctx, "failed to encode session", "err", err)
+		return
+	}
+	cookie.Value = value
+	cookie.MaxAge = int(o.MaxAge.Seconds())
+	http.SetCookie(rw, cookie)
+}
+
+// newSessionCodec derives the signing and encryption keys from the secret.
+func newSessionCodec(secret []byte, encrypt bool) *sessionCodec {
+	derive := func(label string) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(label))
+		return mac.Sum(nil)
+	}
+	c := &sessionCodec{signKey: derive("goa session signing")}
+	if encrypt {
+		block, _ := aes.NewCipher(derive("goa session encryption"))
+		c.aead, _ = cipher.NewGCM(block)
+	}
+	return c
+}
+
+// encode serializes, encrypts if configured and signs v. The signature covers the cookie name so
+// that values cannot be moved across cookies.
+func (c *sessionCodec) encode(name string, v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	if c.aead != nil {
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return "", err
+		}
+		data = c.aead.Seal(nonce, nonce, data, []byte(name))
+	}
+	value := base64.RawURLEncoding.EncodeToString(data)
+	return value + "." + base64.RawURLEncoding.EncodeToString(c.sign(name, value)), nil
+}
+
+// decode verifies the signature of the cookie value, decrypts it if configured and deserializes
+// it into v.
+func (c *sessionCodec) decode(name, cookie string, v interface{}) error {
+	idx := strings.LastIndexByte(cookie, '.')
+	if idx < 0 {
+		return errors.New("invalid session cookie")
+	}
+	value := cookie[:idx]
+	sig, err := base64.RawURLEncoding.DecodeString(cookie[idx+1:])
+	if err != nil || !hmac.Equal(sig, c.sign(name, value)) {
+		return errors.New("invalid session cookie signature")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	if c.aead != nil {
+		ns := c.aead.NonceSize()
+		if len(data) < ns {
+			return errors.New("invalid session cookie")
+		}
+		if data, err = c.aead.Open(nil, data[:ns], data[ns:], []byte(name)); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+// sign computes the signature of the cookie value.
+func (c *sessionCodec) sign(name, value string) []byte {
+	mac := hmac.New(sha256.New, c.signKey)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// newSessionID returns a random session ID.
+func newSessionID() string {
+	b := make([]byte, 32)
+	io.ReadFull(rand.Reader, b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// copyValues returns a copy of the session values.
+func copyValues(values map[string]string) map[string]string {
+	c := make(map[string]string, len(values))
+	for k, v := range values {
+		c[k] = v
+	}
+	return c
+}
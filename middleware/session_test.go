@@ -0,0 +1,180 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sessions", func() {
+	var opts *middleware.SessionOptions
+	var action func(s *middleware.Session)
+	var session *middleware.Session
+
+	serve := func(cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		service := newService(nil)
+		service.Use(middleware.Sessions(opts))
+		ctrl := service.NewController("accounts")
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			session = middleware.ContextSession(ctx)
+			if action != nil {
+				action(session)
+			}
+			return service.Send(ctx, 200, "ok")
+		}
+		req, _ := http.NewRequest("GET", "/accounts/1", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rw := httptest.NewRecorder()
+		ctrl.MuxHandler("show", h, nil)(rw, req, nil)
+		return rw
+	}
+
+	cookie := func(rw *httptest.ResponseRecorder) *http.Cookie {
+		cookies := rw.Result().Cookies()
+		if len(cookies) == 0 {
+			return nil
+		}
+		return cookies[0]
+	}
+
+	BeforeEach(func() {
+		opts = &middleware.SessionOptions{Secret: []byte("0123456789abcdef0123456789abcdef")}
+		action = nil
+		session = nil
+	})
+
+	It("creates empty sessions", func() {
+		rw := serve()
+		Ω(session).ShouldNot(BeNil())
+		Ω(session.Get("user")).Should(BeEmpty())
+		Ω(cookie(rw)).Should(BeNil())
+	})
+
+	It("stores the values in a signed cookie", func() {
+		action = func(s *middleware.Session) { s.Set("user", "alice") }
+		c := cookie(serve())
+		Ω(c).ShouldNot(BeNil())
+		Ω(c.Name).Should(Equal("goa_session"))
+		Ω(c.HttpOnly).Should(BeTrue())
+		Ω(c.MaxAge).Should(Equal(86400))
+
+		action = nil
+		serve(c)
+		Ω(session.Get("user")).Should(Equal("alice"))
+	})
+
+	It("ignores tampered cookies", func() {
+		action = func(s *middleware.Session) { s.Set("user", "alice") }
+		c := cookie(serve())
+		c.Value = strings.Replace(c.Value, c.Value[:4], "AAAA", 1)
+
+		action = nil
+		serve(c)
+		Ω(session.Get("user")).Should(BeEmpty())
+	})
+
+	It("deletes the cookie of destroyed sessions", func() {
+		action = func(s *middleware.Session) { s.Set("user", "alice") }
+		c := cookie(serve())
+
+		action = func(s *middleware.Session) { s.Destroy() }
+		rw := serve(c)
+		Ω(cookie(rw).MaxAge).Should(BeNumerically("<", 0))
+	})
+
+	It("sets the cookie before the response header is written", func() {
+		action = func(s *middleware.Session) { s.Set("user", "alice") }
+		rw := serve()
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("Set-Cookie")).Should(HavePrefix("goa_session="))
+	})
+
+	Context("with encryption", func() {
+		BeforeEach(func() {
+			opts.Encrypt = true
+		})
+
+		It("hides the values from the clients", func() {
+			action = func(s *middleware.Session) { s.Set("user", "alice") }
+			c := cookie(serve())
+			Ω(c.Value).ShouldNot(ContainSubstring("YWxpY2"))
+
+			action = nil
+			serve(c)
+			Ω(session.Get("user")).Should(Equal("alice"))
+		})
+	})
+
+	Context("with a store", func() {
+		var store *middleware.MemorySessionStore
+
+		BeforeEach(func() {
+			store = middleware.NewMemorySessionStore()
+			opts.Store = store
+		})
+
+		It("stores the values server side", func() {
+			action = func(s *middleware.Session) { s.Set("user", "alice") }
+			c := cookie(serve())
+			id := session.ID()
+			Ω(id).ShouldNot(BeEmpty())
+			values, err := store.Load(context.Background(), id)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(values).Should(HaveKeyWithValue("user", "alice"))
+
+			action = nil
+			serve(c)
+			Ω(session.ID()).Should(Equal(id))
+			Ω(session.Get("user")).Should(Equal("alice"))
+		})
+
+		It("renews the session ID", func() {
+			action = func(s *middleware.Session) { s.Set("user", "alice") }
+			c := cookie(serve())
+			id := session.ID()
+
+			action = func(s *middleware.Session) { s.Renew() }
+			c2 := cookie(serve(c))
+			Ω(session.ID()).ShouldNot(Equal(id))
+			Ω(store.Load(context.Background(), id)).Should(BeNil())
+
+			action = nil
+			serve(c2)
+			Ω(session.Get("user")).Should(Equal("alice"))
+		})
+
+		It("expires the sessions", func() {
+			ctx := context.Background()
+			Ω(store.Save(ctx, "old", map[string]string{"user": "alice"}, -time.Second)).ShouldNot(HaveOccurred())
+			Ω(store.Save(ctx, "new", map[string]string{"user": "bob"}, time.Hour)).ShouldNot(HaveOccurred())
+			Ω(store.Load(ctx, "old")).Should(BeNil())
+			Ω(store.Load(ctx, "new")).Should(HaveKeyWithValue("user", "bob"))
+		})
+
+		It("deletes destroyed sessions", func() {
+			action = func(s *middleware.Session) { s.Set("user", "alice") }
+			c := cookie(serve())
+			id := session.ID()
+
+			action = func(s *middleware.Session) { s.Destroy() }
+			serve(c)
+			Ω(store.Load(context.Background(), id)).Should(BeNil())
+		})
+	})
+
+	It("panics without secret", func() {
+		Ω(func() { middleware.Sessions(nil) }).Should(Panic())
+	})
+
+	It("returns nil outside of a session", func() {
+		Ω(middleware.ContextSession(context.Background())).Should(BeNil())
+	})
+})
@@ -1,12 +1,11 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	"github.com/goadesign/goa"
-
-	"golang.org/x/net/context"
 )
 
 // Timeout sets a global timeout for all controller actions.
@@ -38,6 +37,10 @@ import (
 // 	}
 //
 // Controller actions can check if a timeout is set by calling the context Deadline method.
+//
+// Actions that must not run past a deadline may use the Timeout DSL instead, the generated code
+// then cancels their requests and responds with a 504 status once the timeout expires, see
+// goa.TimeoutHandler.
 func Timeout(timeout time.Duration) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -1,12 +1,11 @@
 package middleware_test
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa/middleware"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+// TracerOptions configures the Tracer middleware.
+type TracerOptions struct {
+	// SampleRate is the proportion of the traces started by the service that are sampled,
+	// between 0 and 1. Defaults to 1. The requests that carry a sampling decision keep it.
+	SampleRate float64
+	// Recorder records the sampled spans once finished, e.g. to report them to Zipkin. The
+	// spans are propagated but not recorded if nil.
+	Recorder goa.SpanRecorder
+}
+
+// Tracer returns a middleware that creates a span for each request and stores it in the request
+// context, see goa.ContextSpan. The span continues the trace propagated by the request B3 headers
+// (see goa.TraceIDHeader) if any, a new trace is started otherwise. Spans are named after the
+// controller and action that handle the request, e.g. "Bottle.show" for the "show" action of the
+// "BottleController" controller. The trace and span IDs are added to the request log context.
+//
+// The generated clients propagate the span found in the context of the requests they make so
+// that traces span service boundaries, see client.TraceMiddleware.
+func Tracer(opts *TracerOptions) goa.Middleware {
+	var o TracerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.SampleRate <= 0 || o.SampleRate > 1 {
+		o.SampleRate = 1
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			name := strings.TrimSuffix(goa.ContextController(ctx), "Controller") + "." + goa.ContextAction(ctx)
+			span := goa.StartSpan(name, "server", o.Recorder)
+			span.Sampled = o.SampleRate == 1 || rand.Float64() < o.SampleRate
+			if traceID := req.Header.Get(goa.TraceIDHeader); traceID != "" {
+				span.TraceID = traceID
+				span.ParentSpanID = req.Header.Get(goa.SpanIDHeader)
+				if sampled, ok := sampled(req.Header); ok {
+					span.Sampled = sampled
+				}
+			}
+			span.SetTag("http.method", req.Method)
+			span.SetTag("http.path", req.URL.Path)
+			ctx = goa.WithSpan(ctx, span)
+			ctx = goa.WithLogContext(ctx, "trace", span.TraceID, "span", span.SpanID)
+
+			err := h(ctx, rw, req)
+
+			if resp := goa.ContextResponse(ctx); resp != nil && resp.Status != 0 {
+				span.SetTag("http.status_code", strconv.Itoa(resp.Status))
+			}
+			if err != nil {
+				span.SetTag("error", err.Error())
+			}
+			span.Finish()
+			return err
+		}
+	}
+}
+
+// sampled returns the sampling decision propagated by the given headers and true, false if the
+// headers do not carry one.
+func sampled(h http.Header) (bool, bool) {
+	if h.Get(goa.FlagsHeader) == "1" {
+		return true, true
+	}
+	switch h.Get(goa.SampledHeader) {
+	case "1", "true":
+		return true, true
+	case "0", "false":
+		return false, true
+	}
+	return false, false
+}
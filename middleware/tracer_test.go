@@ -0,0 +1,114 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracer", func() {
+	const traceID = "463ac35c9f6413ad48485a3953bb6124"
+	const spanID = "a2fb4a1d1a96d312"
+
+	var req *http.Request
+	var recorded []*goa.Span
+	var span *goa.Span
+	var handler goa.Handler
+	var err error
+
+	BeforeEach(func() {
+		req, _ = http.NewRequest("GET", "/bottles/1", nil)
+		recorded = nil
+		span = nil
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			span = goa.ContextSpan(ctx)
+			goa.ContextResponse(ctx).WriteHeader(200)
+			return nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		service := newService(nil)
+		rw := newTestResponseWriter()
+		ctx := newContext(service, rw, req, nil)
+		rec := goa.SpanRecorderFunc(func(s *goa.Span) { recorded = append(recorded, s) })
+		err = middleware.Tracer(&middleware.TracerOptions{Recorder: rec})(handler)(ctx, rw, req)
+	})
+
+	It("starts a new trace", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(span).ShouldNot(BeNil())
+		Ω(span.TraceID).Should(HaveLen(32))
+		Ω(span.SpanID).Should(HaveLen(16))
+		Ω(span.ParentSpanID).Should(BeEmpty())
+		Ω(span.Kind).Should(Equal("server"))
+		Ω(span.Tags["http.status_code"]).Should(Equal("200"))
+		Ω(recorded).Should(ConsistOf(span))
+	})
+
+	Context("with a request propagating a trace", func() {
+		BeforeEach(func() {
+			req.Header.Set(goa.TraceIDHeader, traceID)
+			req.Header.Set(goa.SpanIDHeader, spanID)
+			req.Header.Set(goa.SampledHeader, "1")
+		})
+
+		It("continues the trace", func() {
+			Ω(span.TraceID).Should(Equal(traceID))
+			Ω(span.ParentSpanID).Should(Equal(spanID))
+			Ω(span.SpanID).ShouldNot(Equal(spanID))
+			Ω(recorded).Should(HaveLen(1))
+		})
+	})
+
+	Context("with a request propagating a trace that is not sampled", func() {
+		BeforeEach(func() {
+			req.Header.Set(goa.TraceIDHeader, traceID)
+			req.Header.Set(goa.SpanIDHeader, spanID)
+			req.Header.Set(goa.SampledHeader, "0")
+		})
+
+		It("does not record the span", func() {
+			Ω(span.Sampled).Should(BeFalse())
+			Ω(recorded).Should(BeEmpty())
+		})
+	})
+
+	Context("with a handler making requests with a client", func() {
+		var outgoing http.Header
+
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				span = goa.ContextSpan(ctx)
+				c := client.New(nil)
+				c.Use(client.TraceMiddleware())
+				c.Use(func(client.RoundTripper) client.RoundTripper {
+					return client.RoundTripperFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+						outgoing = req.Header
+						return &http.Response{StatusCode: 204, Body: http.NoBody}, nil
+					})
+				})
+				out, _ := http.NewRequest("GET", "http://cellar/accounts/1", nil)
+				_, err := c.Do(client.WithAction(ctx, "account", "show"), out)
+				return err
+			}
+		})
+
+		It("propagates the trace", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(outgoing.Get(goa.TraceIDHeader)).Should(Equal(span.TraceID))
+			Ω(outgoing.Get(goa.ParentSpanIDHeader)).Should(Equal(span.SpanID))
+			Ω(outgoing.Get(goa.SampledHeader)).Should(Equal("1"))
+			Ω(recorded).Should(HaveLen(2))
+			Ω(recorded[0].Name).Should(Equal("account.show"))
+			Ω(recorded[0].Kind).Should(Equal("client"))
+			Ω(recorded[0].SpanID).Should(Equal(outgoing.Get(goa.SpanIDHeader)))
+			Ω(recorded[0].Tags["http.status_code"]).Should(Equal("204"))
+		})
+	})
+})
@@ -3,6 +3,9 @@ package goa
 import (
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/dimfeld/httptreemux"
 )
@@ -15,6 +18,27 @@ type (
 	// ServeMux is the interface implemented by the service request muxes.
 	// It implements http.Handler and makes it possible to register request handlers for
 	// specific HTTP methods and request path via the Handle method.
+	//
+	// The default mux created by NewMux is based on httptreemux, the mux/httprouter and
+	// mux/chi packages provide muxes based on httprouter and chi. Use Service.UseMux to pick
+	// another mux. Muxes must abide by the following contract so that the generated code
+	// works with any of them:
+	//
+	// - The paths given to Handle use the design syntax: ":name" matches a path segment
+	// and "*name" matches the rest of the path. Muxes translate them to their own syntax.
+	//
+	// - The values given to the mux handlers contain the request query string values
+	// merged with the path parameter values, see MuxValues. The generated NewXxxContext
+	// functions read the parameters from these values.
+	//
+	// - Requests that match no handler are given to the not found handler. If the path
+	// matches handlers registered for other methods the Allow response header is set to
+	// the list of these methods first so that the service responds with 405 Method Not
+	// Allowed, or 200 to OPTIONS requests.
+	//
+	// Note that the matching semantics differ between muxes, for example httprouter and
+	// chi do not accept some of the conflicting routes httptreemux accepts such as
+	// "/bottles/:id" and "/bottles/new".
 	ServeMux interface {
 		http.Handler
 		// Handle sets the MuxHandler for a given HTTP method and path.
@@ -39,6 +63,9 @@ type (
 	}
 )
 
+// wildcardRegex matches the wildcards of a request path.
+var wildcardRegex = regexp.MustCompile(`/(?::|\*)([a-zA-Z0-9_]+)`)
+
 // NewMux returns a Mux.
 func NewMux() ServeMux {
 	return &mux{
@@ -50,29 +77,67 @@ func NewMux() ServeMux {
 // Handle sets the handler for the given verb and path.
 func (m *mux) Handle(method, path string, handle MuxHandler) {
 	hthandle := func(rw http.ResponseWriter, req *http.Request, htparams map[string]string) {
-		params := req.URL.Query()
-		for n, p := range htparams {
-			params.Set(n, p)
-		}
-		handle(rw, req, params)
+		handle(rw, req, MuxValues(req, htparams))
 	}
 	m.handles[method+path] = handle
 	m.router.Handle(method, path, hthandle)
 }
 
 // HandleNotFound sets the MuxHandler invoked for requests that don't match any
-// handler registered with Handle.
+// handler registered with Handle. Requests whose path matches handlers registered for other
+// methods get the Allow response header set to the list of these methods prior to invoking the
+// handler.
 func (m *mux) HandleNotFound(handle MuxHandler) {
 	nfh := func(rw http.ResponseWriter, req *http.Request) {
 		handle(rw, req, nil)
 	}
 	m.router.NotFoundHandler = nfh
 	mna := func(rw http.ResponseWriter, req *http.Request, methods map[string]httptreemux.HandlerFunc) {
+		rw.Header().Set("Allow", allowHeader(methods))
 		handle(rw, req, nil)
 	}
 	m.router.MethodNotAllowedHandler = mna
 }
 
+// allowHeader computes the value of the Allow header from the methods of the handlers registered
+// for a path.
+func allowHeader(methods map[string]httptreemux.HandlerFunc) string {
+	allowed := make([]string, 0, len(methods))
+	for m := range methods {
+		allowed = append(allowed, m)
+	}
+	return AllowHeader(allowed)
+}
+
+// MuxValues returns the values given to mux handlers: the request query string values merged with
+// the given path parameter values, the path parameters take precedence.
+func MuxValues(req *http.Request, pathParams map[string]string) url.Values {
+	params := req.URL.Query()
+	for n, p := range pathParams {
+		params.Set(n, p)
+	}
+	return params
+}
+
+// AllowHeader returns the value of the Allow response header set by muxes when the request path
+// matches handlers registered for other methods. OPTIONS is always allowed and so is HEAD when GET
+// is allowed as the default mux serves HEAD requests with the GET handlers.
+func AllowHeader(methods []string) string {
+	set := map[string]struct{}{"OPTIONS": {}}
+	for _, m := range methods {
+		set[m] = struct{}{}
+		if m == "GET" {
+			set["HEAD"] = struct{}{}
+		}
+	}
+	allowed := make([]string, 0, len(set))
+	for m := range set {
+		allowed = append(allowed, m)
+	}
+	sort.Strings(allowed)
+	return strings.Join(allowed, ", ")
+}
+
 // Lookup returns the MuxHandler associated with the given method and path.
 func (m *mux) Lookup(method, path string) MuxHandler {
 	return m.handles[method+path]
@@ -82,3 +147,24 @@ func (m *mux) Lookup(method, path string) MuxHandler {
 func (m *mux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	m.router.ServeHTTP(rw, req)
 }
+
+// PermanentRedirectHandler returns a mux handler that redirects requests to the given path using
+// a 308 Permanent Redirect response. The path may define wildcards which get replaced with the
+// values of the corresponding request path parameters. The request query string is preserved.
+// The generated code uses PermanentRedirectHandler to keep serving the routes of renamed resources
+// and actions.
+func PermanentRedirectHandler(path string) MuxHandler {
+	return func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		target := wildcardRegex.ReplaceAllStringFunc(path, func(w string) string {
+			v := params.Get(w[2:])
+			if w[1] == ':' {
+				v = url.PathEscape(v)
+			}
+			return "/" + v
+		})
+		if req.URL.RawQuery != "" {
+			target += "?" + req.URL.RawQuery
+		}
+		http.Redirect(rw, req, target, http.StatusPermanentRedirect)
+	}
+}
@@ -0,0 +1,13 @@
+package goachi_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestChi(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Chi Suite")
+}
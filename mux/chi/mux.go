@@ -0,0 +1,122 @@
+// Package goachi provides a goa mux based on chi (https://github.com/go-chi/chi). Use it with:
+//
+//	service := goa.New("api")
+//	service.UseMux(goachi.New(nil))
+//
+// The mux translates the design path wildcards to the chi syntax: ":name" becomes "{name}" and
+// "*name" becomes "*". Routes registered directly on the chi router use the chi syntax.
+package goachi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/go-chi/chi"
+	"github.com/goadesign/goa"
+)
+
+// mux implements goa.ServeMux with a chi router.
+type mux struct {
+	router  chi.Router
+	handles map[string]goa.MuxHandler
+	// methods lists the methods of the registered handlers to compute the Allow header.
+	methods map[string]bool
+}
+
+// wildcardRegex matches the wildcards of a design path.
+var wildcardRegex = regexp.MustCompile(`/(:|\*)([a-zA-Z0-9_]+)`)
+
+// New returns a goa mux that routes the requests with the given router, nil means a new router.
+func New(router chi.Router) goa.ServeMux {
+	if router == nil {
+		router = chi.NewRouter()
+	}
+	return &mux{
+		router:  router,
+		handles: make(map[string]goa.MuxHandler),
+		methods: make(map[string]bool),
+	}
+}
+
+// Handle sets the handler for the given verb and path.
+func (m *mux) Handle(method, path string, handle goa.MuxHandler) {
+	var names []string
+	var catchAll string
+	pattern := wildcardRegex.ReplaceAllStringFunc(path, func(w string) string {
+		if w[1] == '*' {
+			catchAll = w[2:]
+			return "/*"
+		}
+		names = append(names, w[2:])
+		return "/{" + w[2:] + "}"
+	})
+	m.handles[method+path] = handle
+	m.methods[method] = true
+	m.router.MethodFunc(method, pattern, func(rw http.ResponseWriter, req *http.Request) {
+		params := make(map[string]string, len(names)+1)
+		for _, n := range names {
+			params[n] = unescape(chi.URLParam(req, n))
+		}
+		if catchAll != "" {
+			params[catchAll] = unescape(chi.URLParam(req, "*"))
+		}
+		handle(rw, req, goa.MuxValues(req, params))
+	})
+}
+
+// HandleNotFound sets the MuxHandler invoked for requests that don't match any handler registered
+// with Handle. Requests whose path matches handlers registered for other methods get the Allow
+// response header set to the list of these methods prior to invoking the handler.
+func (m *mux) HandleNotFound(handle goa.MuxHandler) {
+	m.router.NotFound(func(rw http.ResponseWriter, req *http.Request) {
+		handle(rw, req, nil)
+	})
+	m.router.MethodNotAllowed(func(rw http.ResponseWriter, req *http.Request) {
+		path := req.URL.RawPath
+		if path == "" {
+			path = req.URL.Path
+		}
+		var allowed []string
+		for meth := range m.methods {
+			if m.router.Match(chi.NewRouteContext(), meth, path) {
+				allowed = append(allowed, meth)
+			}
+		}
+		rw.Header().Set("Allow", goa.AllowHeader(allowed))
+		handle(rw, req, nil)
+	})
+}
+
+// Lookup returns the MuxHandler associated with the given method and path.
+func (m *mux) Lookup(method, path string) goa.MuxHandler {
+	return m.handles[method+path]
+}
+
+// ServeHTTP is the function called back by the underlying HTTP server to handle incoming requests.
+// HEAD requests that match no handler are routed to the GET handlers like the default mux does.
+func (m *mux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method == "HEAD" {
+		path := req.URL.RawPath
+		if path == "" {
+			path = req.URL.Path
+		}
+		if !m.router.Match(chi.NewRouteContext(), "HEAD", path) && m.router.Match(chi.NewRouteContext(), "GET", path) {
+			rctx := chi.NewRouteContext()
+			rctx.Routes = m.router
+			rctx.RouteMethod = "GET"
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		}
+	}
+	m.router.ServeHTTP(rw, req)
+}
+
+// unescape returns the unescaped path parameter value, chi matches the raw path of requests whose
+// path contains escaped characters.
+func unescape(v string) string {
+	if u, err := url.PathUnescape(v); err == nil {
+		return u
+	}
+	return v
+}
@@ -0,0 +1,74 @@
+package goachi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/mux/chi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mux", func() {
+	var mux goa.ServeMux
+	var params url.Values
+	var notFound bool
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, req)
+		return rw
+	}
+
+	BeforeEach(func() {
+		params = nil
+		notFound = false
+		mux = goachi.New(nil)
+		handler := func(rw http.ResponseWriter, req *http.Request, p url.Values) {
+			params = p
+		}
+		mux.Handle("GET", "/accounts/:accountID/bottles/:id", handler)
+		mux.Handle("DELETE", "/accounts/:accountID/bottles/:id", handler)
+		mux.Handle("GET", "/files/*filepath", handler)
+		mux.HandleNotFound(func(rw http.ResponseWriter, req *http.Request, p url.Values) {
+			notFound = true
+		})
+	})
+
+	It("merges the path parameters with the query string values", func() {
+		serve("GET", "/accounts/1/bottles/42?vintage=1978")
+		Ω(params.Get("accountID")).Should(Equal("1"))
+		Ω(params.Get("id")).Should(Equal("42"))
+		Ω(params.Get("vintage")).Should(Equal("1978"))
+	})
+
+	It("sets the catch-all parameter", func() {
+		serve("GET", "/files/css/main.css")
+		Ω(params.Get("filepath")).Should(Equal("css/main.css"))
+	})
+
+	It("looks up the handlers", func() {
+		Ω(mux.Lookup("DELETE", "/accounts/:accountID/bottles/:id")).ShouldNot(BeNil())
+		Ω(mux.Lookup("PUT", "/accounts/:accountID/bottles/:id")).Should(BeNil())
+	})
+
+	It("serves HEAD requests with the GET handlers", func() {
+		serve("HEAD", "/accounts/1/bottles/42")
+		Ω(notFound).Should(BeFalse())
+		Ω(params.Get("id")).Should(Equal("42"))
+	})
+
+	It("calls the not found handler", func() {
+		serve("GET", "/wines")
+		Ω(notFound).Should(BeTrue())
+	})
+
+	It("sets the allowed methods when the path matches other methods", func() {
+		rw := serve("PUT", "/accounts/1/bottles/42")
+		Ω(notFound).Should(BeTrue())
+		Ω(rw.Header().Get("Allow")).Should(Equal("DELETE, GET, HEAD, OPTIONS"))
+	})
+})
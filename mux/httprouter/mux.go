@@ -0,0 +1,92 @@
+// Package goahttprouter provides a goa mux based on httprouter
+// (https://github.com/julienschmidt/httprouter). Use it with:
+//
+//	service := goa.New("api")
+//	service.UseMux(goahttprouter.New(nil))
+//
+// httprouter does not accept routes that conflict with wildcards, for example "/bottles/:id"
+// and "/bottles/new" cannot both be registered.
+package goahttprouter
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+	"github.com/julienschmidt/httprouter"
+)
+
+// mux implements goa.ServeMux with a httprouter router.
+type mux struct {
+	router  *httprouter.Router
+	handles map[string]goa.MuxHandler
+}
+
+// New returns a goa mux that routes the requests with the given router, nil means a new router.
+// The mux handles the OPTIONS requests that match no handler itself so that the service responds
+// to them consistently with the default mux.
+func New(router *httprouter.Router) goa.ServeMux {
+	if router == nil {
+		router = httprouter.New()
+	}
+	router.HandleOPTIONS = false
+	router.HandleMethodNotAllowed = true
+	return &mux{router: router, handles: make(map[string]goa.MuxHandler)}
+}
+
+// Handle sets the handler for the given verb and path.
+func (m *mux) Handle(method, path string, handle goa.MuxHandler) {
+	var catchAll string
+	if idx := strings.Index(path, "*"); idx > -1 {
+		catchAll = path[idx+1:]
+	}
+	m.handles[method+path] = handle
+	m.router.Handle(method, path, func(rw http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		params := make(map[string]string, len(ps))
+		for _, p := range ps {
+			params[p.Key] = p.Value
+		}
+		if catchAll != "" {
+			// httprouter includes the leading slash in catch-all values, httptreemux does not.
+			params[catchAll] = strings.TrimPrefix(params[catchAll], "/")
+		}
+		handle(rw, req, goa.MuxValues(req, params))
+	})
+}
+
+// HandleNotFound sets the MuxHandler invoked for requests that don't match any handler registered
+// with Handle. Requests whose path matches handlers registered for other methods get the Allow
+// response header set to the list of these methods prior to invoking the handler.
+func (m *mux) HandleNotFound(handle goa.MuxHandler) {
+	m.router.NotFound = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		handle(rw, req, nil)
+	})
+	m.router.MethodNotAllowed = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		// httprouter sets the Allow header, normalize it to match the default mux.
+		methods := strings.Split(rw.Header().Get("Allow"), ",")
+		for i, meth := range methods {
+			methods[i] = strings.TrimSpace(meth)
+		}
+		rw.Header().Set("Allow", goa.AllowHeader(methods))
+		handle(rw, req, nil)
+	})
+}
+
+// Lookup returns the MuxHandler associated with the given method and path.
+func (m *mux) Lookup(method, path string) goa.MuxHandler {
+	return m.handles[method+path]
+}
+
+// ServeHTTP is the function called back by the underlying HTTP server to handle incoming requests.
+// HEAD requests that match no handler are routed to the GET handlers like the default mux does.
+func (m *mux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method == "HEAD" {
+		if h, _, _ := m.router.Lookup("HEAD", req.URL.Path); h == nil {
+			if h, ps, _ := m.router.Lookup("GET", req.URL.Path); h != nil {
+				h(rw, req, ps)
+				return
+			}
+		}
+	}
+	m.router.ServeHTTP(rw, req)
+}
@@ -66,4 +66,18 @@ var _ = Describe("Mux", func() {
 		})
 	})
 
+	Context("with a permanent redirect handler", func() {
+		BeforeEach(func() {
+			var err error
+			req, err = http.NewRequest("POST", "/bottles/42/rate?vintage=1978", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			mux.Handle("POST", "/bottles/:id/rate", goa.PermanentRedirectHandler("/wines/:id/taste"))
+		})
+
+		It("redirects to the new path", func() {
+			Ω(rw.Status).Should(Equal(308))
+			Ω(rw.ParentHeader.Get("Location")).Should(Equal("/wines/42/taste?vintage=1978"))
+		})
+	})
+
 })
@@ -0,0 +1,109 @@
+package goa
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// PayloadStream decodes the elements of a JSON array request body one at a time so that actions
+// importing large numbers of items do not need to load the entire body in memory. The number of
+// bytes read is bounded by the controller MaxRequestBodyLength and the number of elements by the
+// length validations of the payload.
+type PayloadStream struct {
+	body     io.ReadCloser
+	dec      *json.Decoder
+	min, max int
+	count    int
+	started  bool
+	done     bool
+	err      error
+}
+
+// NewPayloadStream returns a stream that decodes the elements of the JSON array contained in the
+// request body. minItems and maxItems bound the number of elements, 0 means no bound. The stream
+// returns an error on the first call to Next if the request content type is not JSON.
+func NewPayloadStream(req *http.Request, minItems, maxItems int) *PayloadStream {
+	s := &PayloadStream{body: req.Body, dec: json.NewDecoder(req.Body), min: minItems, max: maxItems}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		if mt, _, err := mime.ParseMediaType(ct); err != nil || !isJSON(mt) {
+			s.err = ErrInvalidEncoding("streamed payloads must be JSON, got content type %#v", ct)
+		}
+	}
+	return s
+}
+
+// Next decodes the next element of the array into v. It returns io.EOF once all the elements have
+// been decoded. Next returns an error if the body is not a valid JSON array, if it exceeds the
+// maximum request body length or if the number of elements does not satisfy the bounds given to
+// NewPayloadStream. Errors are sticky: once Next fails it always returns the same error.
+func (s *PayloadStream) Next(v interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.done {
+		return io.EOF
+	}
+	if !s.started {
+		s.started = true
+		tok, err := s.dec.Token()
+		if err != nil {
+			return s.fail(err)
+		}
+		if d, ok := tok.(json.Delim); !ok || d != '[' {
+			return s.fail(fmt.Errorf("payload must be a JSON array"))
+		}
+	}
+	if !s.dec.More() {
+		if _, err := s.dec.Token(); err != nil {
+			return s.fail(err)
+		}
+		if s.count < s.min {
+			s.err = ErrInvalidRequest("length of payload must be greater or equal than %d but got %d elements", s.min, s.count).
+				WithFields("context", "payload", "length", s.count, "limit", s.min, "min", true)
+			return s.err
+		}
+		s.done = true
+		return io.EOF
+	}
+	if s.max > 0 && s.count >= s.max {
+		s.err = ErrInvalidRequest("length of payload must be lesser or equal than %d", s.max).
+			WithFields("context", "payload", "length", s.count+1, "limit", s.max, "min", false)
+		return s.err
+	}
+	if err := s.dec.Decode(v); err != nil {
+		return s.fail(err)
+	}
+	s.count++
+	return nil
+}
+
+// Count returns the number of elements decoded so far.
+func (s *PayloadStream) Count() int {
+	return s.count
+}
+
+// Close closes the request body.
+func (s *PayloadStream) Close() error {
+	return s.body.Close()
+}
+
+// fail records the error produced by reading or decoding the body.
+func (s *PayloadStream) fail(err error) error {
+	if err.Error() == "http: request body too large" {
+		s.err = ErrRequestBodyTooLarge("body length exceeds the maximum request body length")
+	} else if ErrRequestBodyTooLarge.Is(err) {
+		s.err = AsError(err)
+	} else {
+		s.err = ErrInvalidEncoding(err)
+	}
+	return s.err
+}
+
+// isJSON returns true if the given media type is JSON or a JSON based media type.
+func isJSON(mt string) bool {
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
@@ -0,0 +1,150 @@
+package goa_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type streamedBottle struct {
+	Name string `json:"name"`
+}
+
+var _ = Describe("PayloadStream", func() {
+	var body, contentType string
+	var min, max int
+	var bodyLimit int64
+	var stream *goa.PayloadStream
+
+	BeforeEach(func() {
+		body = `[{"name":"a"},{"name":"b"},{"name":"c"}]`
+		contentType = "application/json"
+		min, max = 0, 0
+		bodyLimit = 0
+	})
+
+	JustBeforeEach(func() {
+		req := httptest.NewRequest("POST", "/bottles/import", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if bodyLimit > 0 {
+			req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, bodyLimit)
+		}
+		stream = goa.NewPayloadStream(req, min, max)
+	})
+
+	// next decodes all the elements of the stream and returns their names and the error that
+	// ended the stream.
+	next := func() ([]string, error) {
+		var names []string
+		for {
+			var b streamedBottle
+			if err := stream.Next(&b); err != nil {
+				return names, err
+			}
+			names = append(names, b.Name)
+		}
+	}
+
+	It("decodes the elements one at a time", func() {
+		names, err := next()
+		Ω(err).Should(Equal(io.EOF))
+		Ω(names).Should(Equal([]string{"a", "b", "c"}))
+		Ω(stream.Count()).Should(Equal(3))
+		Ω(stream.Next(&streamedBottle{})).Should(Equal(io.EOF))
+	})
+
+	Context("with an empty array", func() {
+		BeforeEach(func() {
+			body = `[]`
+		})
+
+		It("returns io.EOF", func() {
+			names, err := next()
+			Ω(err).Should(Equal(io.EOF))
+			Ω(names).Should(BeEmpty())
+		})
+	})
+
+	Context("with more elements than allowed", func() {
+		BeforeEach(func() {
+			max = 2
+		})
+
+		It("returns a bad request error after the last allowed element", func() {
+			names, err := next()
+			Ω(names).Should(Equal([]string{"a", "b"}))
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.Error).Status).Should(Equal(400))
+			Ω(err.Error()).Should(ContainSubstring("lesser or equal than 2"))
+		})
+	})
+
+	Context("with fewer elements than required", func() {
+		BeforeEach(func() {
+			min = 4
+		})
+
+		It("returns a bad request error at the end of the array", func() {
+			names, err := next()
+			Ω(names).Should(HaveLen(3))
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.Error).Status).Should(Equal(400))
+			Ω(err.Error()).Should(ContainSubstring("greater or equal than 4"))
+		})
+	})
+
+	Context("with a body that is not an array", func() {
+		BeforeEach(func() {
+			body = `{"name":"a"}`
+		})
+
+		It("returns an invalid encoding error", func() {
+			_, err := next()
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("payload must be a JSON array"))
+		})
+	})
+
+	Context("with an invalid element", func() {
+		BeforeEach(func() {
+			body = `[{"name":"a"},{"name":`
+		})
+
+		It("returns the elements decoded before the error", func() {
+			names, err := next()
+			Ω(names).Should(Equal([]string{"a"}))
+			Ω(err).Should(HaveOccurred())
+			Ω(err).ShouldNot(Equal(io.EOF))
+			Ω(err.(*goa.Error).Status).Should(Equal(400))
+		})
+	})
+
+	Context("with a body exceeding the maximum length", func() {
+		BeforeEach(func() {
+			bodyLimit = 20
+		})
+
+		It("returns a request body too large error", func() {
+			_, err := next()
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.Error).Status).Should(Equal(413))
+		})
+	})
+
+	Context("with a content type that is not JSON", func() {
+		BeforeEach(func() {
+			contentType = "application/xml"
+		})
+
+		It("returns an invalid encoding error", func() {
+			_, err := next()
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("must be JSON"))
+		})
+	})
+})
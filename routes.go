@@ -0,0 +1,58 @@
+package goa
+
+import "reflect"
+
+// Route describes a route mounted on a service, see Service.Routes.
+type Route struct {
+	// Method is the HTTP method of the route.
+	Method string
+	// Path is the route path pattern using the design wildcard syntax, e.g.
+	// "/bottles/:id" or "/assets/*filepath".
+	Path string
+	// Resource is the name of the design resource that defines the route, empty for the
+	// routes that are not defined by a resource such as the Swagger specification.
+	Resource string
+	// Controller is the name of the controller that handles the requests.
+	Controller string
+	// Action is the name of the controller action that handles the requests as given to
+	// Controller.MuxHandler, "serve" for file servers.
+	Action string
+	// Redirect is the path the requests are redirected to if the route is the old route of a
+	// renamed resource or action, empty otherwise.
+	Redirect string
+}
+
+// AddRoute records a route mounted on the service. The generated Mount functions call it for each
+// route they register with the service mux.
+func (service *Service) AddRoute(r Route) {
+	service.routes = append(service.routes, r)
+}
+
+// Routes returns the routes mounted on the service in the order they were mounted. The route table
+// may be used to validate the service at startup, to document it or to register it with a
+// gateway.
+func (service *Service) Routes() []Route {
+	routes := make([]Route, len(service.routes))
+	copy(routes, service.routes)
+	return routes
+}
+
+// ControllerName returns the name of the controller implemented by ctrl: the name given to
+// Service.NewController if ctrl embeds the controller it returns, the name of the ctrl type
+// otherwise.
+func ControllerName(ctrl Muxer) string {
+	if c, ok := ctrl.(interface{ controller() *Controller }); ok {
+		return c.controller().Name
+	}
+	t := reflect.TypeOf(ctrl)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// controller makes it possible to retrieve the controllers embedded by user types, see
+// ControllerName.
+func (ctrl *Controller) controller() *Controller {
+	return ctrl
+}
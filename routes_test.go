@@ -0,0 +1,44 @@
+package goa_test
+
+import (
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type bottleController struct {
+	*goa.Controller
+}
+
+var _ = Describe("Routes", func() {
+	var s *goa.Service
+
+	BeforeEach(func() {
+		s = goa.New("test")
+	})
+
+	It("returns the routes in the order they were mounted", func() {
+		list := goa.Route{Method: "GET", Path: "/bottles", Resource: "bottle", Controller: "BottleController", Action: "List"}
+		show := goa.Route{Method: "GET", Path: "/bottles/:id", Resource: "bottle", Controller: "BottleController", Action: "Show"}
+		s.AddRoute(list)
+		s.AddRoute(show)
+		Ω(s.Routes()).Should(Equal([]goa.Route{list, show}))
+	})
+
+	It("returns a copy of the route table", func() {
+		s.AddRoute(goa.Route{Method: "GET", Path: "/bottles"})
+		s.Routes()[0].Path = "/wines"
+		Ω(s.Routes()[0].Path).Should(Equal("/bottles"))
+	})
+
+	Describe("ControllerName", func() {
+		It("returns the name of the embedded controller", func() {
+			ctrl := &bottleController{Controller: s.NewController("BottleController")}
+			Ω(goa.ControllerName(ctrl)).Should(Equal("BottleController"))
+		})
+
+		It("returns the name of the controller", func() {
+			Ω(goa.ControllerName(s.NewController("WineController"))).Should(Equal("WineController"))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package goa
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -9,9 +11,9 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
-
-	"golang.org/x/net/context"
+	"sync"
 )
 
 const (
@@ -39,9 +41,30 @@ type (
 		Decoder *HTTPDecoder
 		// Response body encoder
 		Encoder *HTTPEncoder
-
-		middleware []Middleware       // Middleware chain
-		cancel     context.CancelFunc // Service context cancel signal trigger
+		// Maintenance tells which resources are in maintenance mode, see MaintenanceProvider.
+		// No resource is ever in maintenance mode if Maintenance is nil.
+		Maintenance MaintenanceProvider
+		// Health is the registry of the checks run by the liveness and readiness probes, see
+		// HealthChecker.
+		Health *HealthChecker
+		// TLS configures the HTTPS servers started by ListenAndServeTLS,
+		// ListenAndServeAutoTLS and the goaautocert package. The defaults described by
+		// TLSOptions apply if nil.
+		TLS *TLSOptions
+		// CertManager provides the certificates served by ListenAndServeAutoTLS, e.g.
+		// goaautocert.NewManager(nil).
+		CertManager CertManager
+		// PoolRequestData causes the controllers to reuse the request and response data of
+		// the requests once handled, see RequestData and ResponseData. Middleware and handlers
+		// must not retain the request context once they return. The controllers generated
+		// with goagen app --pool set it.
+		PoolRequestData bool
+
+		middleware []Middleware          // Middleware chain
+		named      map[string]Middleware // Named middleware, see UseNamed
+		namedMu    sync.RWMutex          // Guards named
+		routes     []Route               // Mounted routes, see Routes
+		cancel     context.CancelFunc    // Service context cancel signal trigger
 	}
 
 	// Controller defines the common fields and behavior of generated controllers.
@@ -55,6 +78,10 @@ type (
 		// MaxRequestBodyLength is the maximum length read from request bodies.
 		// Set to 0 to remove the limit altogether. Defaults to 1GB.
 		MaxRequestBodyLength int64
+		// MaxDecompressedBodyLength is the maximum length of the request bodies sent with the
+		// gzip or deflate content coding once decompressed. Set to 0 to remove the limit
+		// altogether. Defaults to 1GB.
+		MaxDecompressedBodyLength int64
 
 		middleware []Middleware // Controller specific middleware if any
 	}
@@ -81,28 +108,43 @@ func New(name string) *Service {
 		stdlog       = log.New(os.Stderr, "", log.LstdFlags)
 		ctx          = WithLogger(context.Background(), NewLogger(stdlog))
 		cctx, cancel = context.WithCancel(ctx)
-		mux          = NewMux()
 		service      = &Service{
 			Name:    name,
 			Context: cctx,
-			Mux:     mux,
 			Decoder: NewHTTPDecoder(),
 			Encoder: NewHTTPEncoder(),
+			Health:  NewHealthChecker(),
 
 			cancel: cancel,
 		}
-		notFoundHandler Handler
 	)
+	service.UseMux(NewMux())
+
+	return service
+}
 
-	// Setup default NotFound handler
+// UseMux sets the mux used by the service to route the requests and registers the service not
+// found handler with it. The default mux created by New is based on httptreemux, see ServeMux for
+// the contract other muxes must implement. UseMux must be called before the controllers are
+// mounted.
+func (service *Service) UseMux(mux ServeMux) {
+	var notFoundHandler Handler
 	mux.HandleNotFound(func(rw http.ResponseWriter, req *http.Request, params url.Values) {
-		if resp := ContextResponse(ctx); resp != nil && resp.Written() {
+		if resp := ContextResponse(service.Context); resp != nil && resp.Written() {
 			return
 		}
 		// Use closure to do lazy computation of middleware chain so all middlewares are
 		// registered.
 		if notFoundHandler == nil {
-			notFoundHandler = func(_ context.Context, _ http.ResponseWriter, req *http.Request) error {
+			notFoundHandler = func(_ context.Context, rw http.ResponseWriter, req *http.Request) error {
+				if allow := rw.Header().Get("Allow"); allow != "" {
+					if req.Method == "OPTIONS" {
+						rw.Header().Set("Content-Length", "0")
+						rw.WriteHeader(http.StatusOK)
+						return nil
+					}
+					return ErrMethodNotAllowed("%s %s, allowed methods: %s", req.Method, req.URL.Path, allow)
+				}
 				return ErrNotFound(req.URL.Path)
 			}
 			chain := service.middleware
@@ -114,15 +156,19 @@ func New(name string) *Service {
 		ctx := NewContext(service.Context, rw, req, params)
 		err := notFoundHandler(ctx, ContextResponse(ctx), req)
 		if !ContextResponse(ctx).Written() {
-			service.Send(ctx, 404, err)
+			status := 404
+			var e *Error
+			if errors.As(err, &e) {
+				status = e.Status
+			}
+			service.Send(ctx, status, err)
 		}
 	})
-
-	return service
+	service.Mux = mux
 }
 
 // CancelAll sends a cancel signals to all request handlers via the context.
-// See https://godoc.org/golang.org/x/net/context for details on how to handle the signal.
+// See https://golang.org/pkg/context for details on how to handle the signal.
 func (service *Service) CancelAll() {
 	service.cancel()
 }
@@ -134,6 +180,43 @@ func (service *Service) Use(m Middleware) {
 	service.middleware = append(service.middleware, m)
 }
 
+// UseNamed registers a middleware under the given name. The generated code applies named
+// middleware to the actions whose design reference them with the UseMiddleware DSL, see
+// NamedHandler. Register the named middleware before starting the server.
+func (service *Service) UseNamed(name string, m Middleware) {
+	service.namedMu.Lock()
+	defer service.namedMu.Unlock()
+	if service.named == nil {
+		service.named = make(map[string]Middleware)
+	}
+	service.named[name] = m
+}
+
+// NamedHandler returns a handler that runs the middleware registered under the given name with
+// UseNamed around h. The middleware is looked up and wrapped around h once, when the first
+// request is handled, so that it may be registered after the controllers are mounted and so that
+// stateful middleware such as middleware.CircuitBreaker keep their state across requests. The
+// handler returns a NoNamedMiddleware error if there is none at that time.
+func (service *Service) NamedHandler(name string, h Handler) Handler {
+	var (
+		once    sync.Once
+		handler Handler
+	)
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		once.Do(func() {
+			service.namedMu.RLock()
+			defer service.namedMu.RUnlock()
+			if m, ok := service.named[name]; ok {
+				handler = m(h)
+			}
+		})
+		if handler == nil {
+			return NoNamedMiddleware(name)
+		}
+		return handler(ctx, rw, req)
+	}
+}
+
 // WithLogger sets the logger used internally by the service and by Log.
 func (service *Service) WithLogger(logger LogAdapter) {
 	service.Context = WithLogger(service.Context, logger)
@@ -155,20 +238,23 @@ func (service *Service) ListenAndServe(addr string) error {
 	return http.ListenAndServe(addr, service.Mux)
 }
 
-// ListenAndServeTLS starts a HTTPS server and sets up a listener on the given host/port.
+// ListenAndServeTLS starts a HTTPS server and sets up a listener on the given host/port. The
+// server TLS configuration is described by the service TLS options.
 func (service *Service) ListenAndServeTLS(addr, certFile, keyFile string) error {
 	service.LogInfo("listen", "transport", "https", "addr", addr)
-	return http.ListenAndServeTLS(addr, certFile, keyFile, service.Mux)
+	srv := &http.Server{Addr: addr, Handler: service.Mux, TLSConfig: service.TLS.TLSConfig()}
+	return srv.ListenAndServeTLS(certFile, keyFile)
 }
 
 // NewController returns a controller for the given resource. This method is mainly intended for
 // use by the generated code. User code shouldn't have to call it directly.
 func (service *Service) NewController(name string) *Controller {
 	return &Controller{
-		Name:                 name,
-		Service:              service,
-		Context:              context.WithValue(service.Context, ctrlKey, name),
-		MaxRequestBodyLength: 1073741824, // 1 GB
+		Name:                      name,
+		Service:                   service,
+		Context:                   context.WithValue(service.Context, ctrlKey, name),
+		MaxRequestBodyLength:      1073741824, // 1 GB
+		MaxDecompressedBodyLength: 1073741824, // 1 GB
 	}
 }
 
@@ -190,13 +276,14 @@ func (service *Service) ServeFiles(path, filename string) error {
 }
 
 // DecodeRequest uses the HTTP decoder to unmarshal the request body into the provided value based
-// on the request Content-Type header.
+// on the request Content-Type header. The bodies of the requests handled by controllers are
+// decompressed beforehand if sent with the gzip or deflate content coding.
 func (service *Service) DecodeRequest(req *http.Request, v interface{}) error {
 	body, contentType := req.Body, req.Header.Get("Content-Type")
 	defer body.Close()
 
 	if err := service.Decoder.Decode(v, body, contentType); err != nil {
-		return fmt.Errorf("failed to decode request body with content type %#v: %s", contentType, err)
+		return fmt.Errorf("failed to decode request body with content type %#v: %w", contentType, err)
 	}
 
 	return nil
@@ -205,6 +292,7 @@ func (service *Service) DecodeRequest(req *http.Request, v interface{}) error {
 // EncodeResponse uses the HTTP encoder to marshal and write the response body based on the request
 // Accept header.
 func (service *Service) EncodeResponse(ctx context.Context, v interface{}) error {
+	defer MeasurePhase(ctx, PhaseEncode)()
 	accept := ContextRequest(ctx).Header.Get("Accept")
 	return service.Encoder.Encode(v, ContextResponse(ctx), accept)
 }
@@ -232,6 +320,21 @@ func (ctrl *Controller) Use(m Middleware) {
 	ctrl.middleware = append(ctrl.middleware, m)
 }
 
+// HeadHandler returns a handler that serves HEAD requests by running h and discarding the response
+// body it writes. The response Content-Length header is set to the length of the discarded body
+// unless h sets it. This function is intended for the controller generated code.
+func HeadHandler(h Handler) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		resp := ContextResponse(ctx)
+		hw := &headResponseWriter{ResponseWriter: resp.ResponseWriter}
+		resp.SwitchWriter(hw)
+		err := h(ctx, rw, req)
+		resp.SwitchWriter(hw.ResponseWriter)
+		hw.flush()
+		return err
+	}
+}
+
 // MuxHandler wraps a request handler into a MuxHandler. The MuxHandler initializes the request
 // context by loading the request state, invokes the handler and in case of error invokes the
 // controller (if there is one) or Service error handler.
@@ -258,10 +361,16 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 				if err == nil {
 					err = fmt.Errorf("unknown error")
 				}
-				body := ErrInvalidEncoding(err)
-				if err.Error() == "http: request body too large" {
+				var body *Error
+				switch {
+				case err.Error() == "http: request body too large":
 					status = 413
 					body = ErrRequestBodyTooLarge("body length exceeds %d bytes", ctrl.MaxRequestBodyLength)
+				case ErrRequestBodyTooLarge.Is(err), ErrUnsupportedEncoding.Is(err):
+					body = AsError(err)
+					status = body.Status
+				default:
+					body = ErrInvalidEncoding(err)
 				}
 				return ctrl.Service.Send(ctx, status, body)
 			}
@@ -274,17 +383,33 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 		}
 
 		// Build context
-		ctx := NewContext(WithAction(ctrl.Context, name), rw, req, params)
+		ctx := WithTimings(WithAction(ctrl.Context, name))
+		if ctrl.Service.PoolRequestData {
+			var release func()
+			ctx, release = newPooledContext(ctx, rw, req, params)
+			defer release()
+		} else {
+			ctx = NewContext(ctx, rw, req, params)
+		}
 
 		// Protect against request bodies with unreasonable length
 		if ctrl.MaxRequestBodyLength > 0 {
 			req.Body = http.MaxBytesReader(rw, req.Body, ctrl.MaxRequestBodyLength)
 		}
 
-		// Load body if any
+		// Count the request body bytes
+		if req.Body != nil {
+			req.Body = &countingReader{ReadCloser: req.Body, data: ContextRequest(ctx)}
+		}
+
+		// Load body if any, a negative content length means the body is chunked
 		var err error
-		if req.ContentLength > 0 && unm != nil {
-			err = unm(ctx, ctrl.Service, req)
+		if req.ContentLength != 0 && unm != nil {
+			done := MeasurePhase(ctx, PhaseDecode)
+			if err = decompressRequest(req, ctrl.MaxDecompressedBodyLength); err == nil {
+				err = unm(ctx, ctrl.Service, req)
+			}
+			done()
 		}
 
 		// Handle invalid payload
@@ -406,3 +531,38 @@ type byName []os.FileInfo
 func (s byName) Len() int           { return len(s) }
 func (s byName) Less(i, j int) bool { return s[i].Name() < s[j].Name() }
 func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// headResponseWriter discards the response body and delays writing the response status so that
+// the Content-Length header can be computed.
+type headResponseWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+// WriteHeader records the response status.
+func (w *headResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write records the length of the discarded response body.
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.length += len(b)
+	return len(b), nil
+}
+
+// flush writes the response status and Content-Length header if a response was written.
+func (w *headResponseWriter) flush() {
+	if w.status == 0 {
+		return
+	}
+	if w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.length))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -2,13 +2,14 @@ package goa_test
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 
-	"golang.org/x/net/context"
-
 	"github.com/goadesign/goa"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -35,6 +36,26 @@ var _ = Describe("Service", func() {
 		})
 	})
 
+	Describe("UseMux", func() {
+		var mux goa.ServeMux
+
+		BeforeEach(func() {
+			mux = goa.NewMux()
+			s.UseMux(mux)
+		})
+
+		It("sets the service mux", func() {
+			Ω(s.Mux).Should(BeIdenticalTo(mux))
+		})
+
+		It("registers the not found handler", func() {
+			req, _ := http.NewRequest("GET", "/foo", nil)
+			rw := &TestResponseWriter{ParentHeader: make(http.Header)}
+			mux.ServeHTTP(rw, req)
+			Ω(string(rw.Body)).Should(Equal(`{"code":"not_found","status":404,"detail":"/foo"}` + "\n"))
+		})
+	})
+
 	Describe("NotFound", func() {
 		var rw *TestResponseWriter
 		var req *http.Request
@@ -81,6 +102,34 @@ var _ = Describe("Service", func() {
 				Ω(middlewareCalled).Should(Equal(1))
 			})
 		})
+
+		Context("with handlers registered for other methods", func() {
+			BeforeEach(func() {
+				ctrl := s.NewController("test")
+				h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error { return nil }
+				s.Mux.Handle("GET", "/foo", ctrl.MuxHandler("show", h, nil))
+				s.Mux.Handle("POST", "/foo", ctrl.MuxHandler("create", h, nil))
+				req, _ = http.NewRequest("PUT", "/foo", nil)
+			})
+
+			It("responds with 405 and the allowed methods", func() {
+				Ω(rw.Status).Should(Equal(405))
+				Ω(rw.ParentHeader.Get("Allow")).Should(Equal("GET, HEAD, OPTIONS, POST"))
+				Ω(string(rw.Body)).Should(ContainSubstring(`"code":"method_not_allowed"`))
+			})
+
+			Context("using OPTIONS", func() {
+				BeforeEach(func() {
+					req, _ = http.NewRequest("OPTIONS", "/foo", nil)
+				})
+
+				It("responds with the allowed methods", func() {
+					Ω(rw.Status).Should(Equal(200))
+					Ω(rw.ParentHeader.Get("Allow")).Should(Equal("GET, HEAD, OPTIONS, POST"))
+					Ω(rw.Body).Should(BeEmpty())
+				})
+			})
+		})
 	})
 
 	Describe("MaxRequestBodyLength", func() {
@@ -110,6 +159,186 @@ var _ = Describe("Service", func() {
 		})
 	})
 
+	Describe("compressed request bodies", func() {
+		var encoding string
+		var body []byte
+		var maxLength int64
+		var rw *TestResponseWriter
+		var payload string
+
+		BeforeEach(func() {
+			encoding = "gzip"
+			maxLength = 0
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write([]byte(`"decompressed"`))
+			gz.Close()
+			body = buf.Bytes()
+			payload = ""
+		})
+
+		JustBeforeEach(func() {
+			req, _ := http.NewRequest("POST", "/foo", bytes.NewReader(body))
+			req.Header.Set("Content-Encoding", encoding)
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			ctrl := s.NewController("test")
+			if maxLength > 0 {
+				ctrl.MaxDecompressedBodyLength = maxLength
+			}
+			unmarshaler := func(ctx context.Context, service *goa.Service, req *http.Request) error {
+				return service.DecodeRequest(req, &payload)
+			}
+			handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				rw.WriteHeader(204)
+				return nil
+			}
+			ctrl.MuxHandler("create", handler, unmarshaler)(rw, req, nil)
+		})
+
+		It("decodes gzip bodies", func() {
+			Ω(rw.Status).Should(Equal(204))
+			Ω(payload).Should(Equal("decompressed"))
+		})
+
+		Context("with a deflate body", func() {
+			BeforeEach(func() {
+				encoding = "deflate"
+				var buf bytes.Buffer
+				fw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+				fw.Write([]byte(`"deflated"`))
+				fw.Close()
+				body = buf.Bytes()
+			})
+
+			It("decodes the body", func() {
+				Ω(rw.Status).Should(Equal(204))
+				Ω(payload).Should(Equal("deflated"))
+			})
+		})
+
+		Context("with a body exceeding the maximum decompressed length", func() {
+			BeforeEach(func() {
+				maxLength = 8
+			})
+
+			It("prevents reading more bytes", func() {
+				Ω(rw.Status).Should(Equal(413))
+				Ω(string(rw.Body)).Should(Equal(`{"code":"request_too_large","status":413,"detail":"decompressed body length exceeds 8 bytes"}` + "\n"))
+			})
+		})
+
+		Context("with an unsupported content coding", func() {
+			BeforeEach(func() {
+				encoding = "br"
+			})
+
+			It("rejects the request", func() {
+				Ω(rw.Status).Should(Equal(415))
+				Ω(payload).Should(BeEmpty())
+			})
+		})
+	})
+
+	Describe("HeadHandler", func() {
+		var rw *TestResponseWriter
+		var handler goa.Handler
+
+		JustBeforeEach(func() {
+			req, _ := http.NewRequest("HEAD", "/foo", nil)
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			ctrl := s.NewController("test")
+			ctrl.MuxHandler("show", goa.HeadHandler(handler), nil)(rw, req, nil)
+		})
+
+		Context("with a handler writing a body", func() {
+			BeforeEach(func() {
+				handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					return s.Send(ctx, 200, "ok")
+				}
+			})
+
+			It("responds with headers only", func() {
+				Ω(rw.Status).Should(Equal(200))
+				Ω(rw.Body).Should(BeEmpty())
+				Ω(rw.ParentHeader.Get("Content-Length")).Should(Equal("5"))
+			})
+		})
+
+		Context("with a handler setting the content length", func() {
+			BeforeEach(func() {
+				handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					rw.Header().Set("Content-Length", "42")
+					rw.WriteHeader(204)
+					return nil
+				}
+			})
+
+			It("keeps the content length", func() {
+				Ω(rw.Status).Should(Equal(204))
+				Ω(rw.ParentHeader.Get("Content-Length")).Should(Equal("42"))
+			})
+		})
+	})
+
+	Describe("NamedHandler", func() {
+		var rw *TestResponseWriter
+		var calls []string
+
+		serve := func() {
+			req, _ := http.NewRequest("GET", "/foo", nil)
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				calls = append(calls, "handler")
+				return s.Send(ctx, 200, "ok")
+			}
+			ctrl := s.NewController("test")
+			ctrl.MuxHandler("show", s.NamedHandler("authz", h), nil)(rw, req, nil)
+		}
+
+		BeforeEach(func() {
+			calls = nil
+		})
+
+		It("runs the middleware registered under the name", func() {
+			s.UseNamed("authz", func(h goa.Handler) goa.Handler {
+				return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					calls = append(calls, "authz")
+					return h(ctx, rw, req)
+				}
+			})
+			serve()
+			Ω(calls).Should(Equal([]string{"authz", "handler"}))
+			Ω(rw.Status).Should(Equal(200))
+		})
+
+		It("keeps the state of the middleware across requests", func() {
+			s.UseNamed("authz", func(h goa.Handler) goa.Handler {
+				var count int
+				return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					count++
+					calls = append(calls, fmt.Sprintf("authz %d", count))
+					return h(ctx, rw, req)
+				}
+			})
+			h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return s.Send(ctx, 200, "ok")
+			}
+			ctrl := s.NewController("test")
+			mh := ctrl.MuxHandler("show", s.NamedHandler("authz", h), nil)
+			for i := 0; i < 3; i++ {
+				req, _ := http.NewRequest("GET", "/foo", nil)
+				mh(&TestResponseWriter{ParentHeader: make(http.Header)}, req, nil)
+			}
+			Ω(calls).Should(Equal([]string{"authz 1", "authz 2", "authz 3"}))
+		})
+
+		It("fails if no middleware is registered under the name", func() {
+			serve()
+			Ω(calls).Should(BeEmpty())
+			Ω(rw.Status).Should(Equal(500))
+		})
+	})
+
 	Describe("MuxHandler", func() {
 		var handler goa.Handler
 		var unmarshaler goa.Unmarshaler
@@ -247,6 +476,35 @@ var _ = Describe("Service", func() {
 				})
 			})
 
+			Context("with pooled request data", func() {
+				var payloads []interface{}
+				var codes []string
+
+				BeforeEach(func() {
+					payloads, codes = nil, nil
+					s.PoolRequestData = true
+					handler = func(c context.Context, rw http.ResponseWriter, req *http.Request) error {
+						payloads = append(payloads, goa.ContextRequest(c).Payload)
+						codes = append(codes, goa.ContextResponse(c).ErrorCode)
+						goa.ContextRequest(c).Payload = "leaked"
+						goa.ContextResponse(c).ErrorCode = "leaked"
+						rw.WriteHeader(respStatus)
+						return nil
+					}
+				})
+
+				It("does not leak the values of released requests into the next ones", func() {
+					for i := 0; i < 10; i++ {
+						muxHandler(&TestResponseWriter{ParentHeader: make(http.Header)}, r, p)
+					}
+					Ω(payloads).Should(HaveLen(11))
+					for i := range payloads {
+						Ω(payloads[i]).Should(BeNil())
+						Ω(codes[i]).Should(BeEmpty())
+					}
+				})
+			})
+
 			Context("with different payload types", func() {
 				content := []byte(`{"hello": "world"}`)
 				decodedContent := map[string]interface{}{"hello": "world"}
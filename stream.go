@@ -0,0 +1,286 @@
+package goa
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// StreamBlock causes Send to block until there is room in the stream buffer.
+	StreamBlock StreamPolicy = "block"
+
+	// StreamDropOldest causes Send to discard the oldest queued message when the stream buffer
+	// is full.
+	StreamDropOldest StreamPolicy = "drop_oldest"
+
+	// StreamDropNewest causes Send to discard the message being sent when the stream buffer is
+	// full.
+	StreamDropNewest StreamPolicy = "drop_newest"
+
+	// DefaultStreamBufferSize is the number of messages queued by streams that do not specify a
+	// buffer size.
+	DefaultStreamBufferSize = 16
+
+	// NDJSONContentType is the content type of the streams that send newline delimited JSON,
+	// one message per line.
+	NDJSONContentType = "application/x-ndjson"
+)
+
+type (
+	// StreamPolicy defines the behavior of a stream when the client does not consume messages
+	// as fast as they are produced and the stream buffer is full.
+	StreamPolicy string
+
+	// StreamOptions configures the flow control of a stream.
+	StreamOptions struct {
+		// Name is used to build the keys of the stream metrics, e.g. "bottle.watch".
+		Name string
+		// BufferSize is the maximum number of messages queued for the client. Defaults to
+		// DefaultStreamBufferSize.
+		BufferSize int
+		// HighWatermark is the number of queued messages above which the stream reports
+		// being congested. Defaults to BufferSize.
+		HighWatermark int
+		// Policy defines how Send behaves when the buffer is full. Defaults to StreamBlock.
+		Policy StreamPolicy
+		// NDJSON causes the stream to send newline delimited JSON whatever the request
+		// Accept header, e.g. for export endpoints consumed by line oriented tools.
+		NDJSON bool
+	}
+
+	// StreamStats contains the queue metrics of a stream.
+	StreamStats struct {
+		// Queued is the number of messages waiting to be written to the client.
+		Queued int
+		// Sent is the number of messages written to the client.
+		Sent int64
+		// Dropped is the number of messages discarded because the buffer was full.
+		Dropped int64
+	}
+
+	// Stream sends a response body incrementally, one message at a time. Messages are encoded
+	// with the service encoder and queued in a bounded buffer drained by a dedicated goroutine
+	// so that a slow client cannot cause unbounded memory growth. Messages sent to clients that
+	// accept the "text/event-stream" content type are framed as server-sent events, messages
+	// sent to clients that accept the "application/x-ndjson" content type are encoded as JSON
+	// on a single line. Each message is flushed to the client as soon as it is written.
+	Stream struct {
+		ctx     context.Context
+		service *Service
+		resp    *ResponseData
+		opts    StreamOptions
+		sse     bool
+		ndjson  bool
+		queue   chan []byte
+		done    chan struct{}
+		mu      sync.Mutex // serializes sends and close
+		closed  bool
+		sent    int64
+		dropped int64
+		errMu   sync.Mutex
+		err     error
+	}
+)
+
+// NewStream writes the response headers with the given status code and returns a stream used to
+// send the response body. The stream must be closed once all messages have been sent.
+func (service *Service) NewStream(ctx context.Context, code int, opts *StreamOptions) *Stream {
+	var o StreamOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = DefaultStreamBufferSize
+	}
+	if o.HighWatermark <= 0 || o.HighWatermark > o.BufferSize {
+		o.HighWatermark = o.BufferSize
+	}
+	if o.Policy == "" {
+		o.Policy = StreamBlock
+	}
+	resp := ContextResponse(ctx)
+	var accept string
+	if req := ContextRequest(ctx); req != nil {
+		accept = req.Header.Get("Accept")
+	}
+	switch {
+	case o.NDJSON || strings.Contains(accept, NDJSONContentType):
+		resp.Header().Set("Content-Type", NDJSONContentType)
+	case strings.Contains(accept, "text/event-stream"):
+		resp.Header().Set("Content-Type", "text/event-stream")
+	}
+	contentType := resp.Header().Get("Content-Type")
+	s := &Stream{
+		ctx:     ctx,
+		service: service,
+		resp:    resp,
+		opts:    o,
+		sse:     strings.HasPrefix(contentType, "text/event-stream"),
+		ndjson:  strings.HasPrefix(contentType, NDJSONContentType),
+		queue:   make(chan []byte, o.BufferSize),
+		done:    make(chan struct{}),
+	}
+	resp.WriteHeader(code)
+	go s.run()
+	return s
+}
+
+// Send encodes v and queues it for sending. If the buffer is full Send blocks, drops the oldest
+// queued message or drops v depending on the stream policy. Send returns an error if the stream
+// is closed, if writing a previous message failed or if the request context is done.
+func (s *Stream) Send(v interface{}) error {
+	if err := s.Err(); err != nil {
+		return err
+	}
+	msg, err := s.encode(v)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return fmt.Errorf("stream is closed")
+	}
+	switch s.opts.Policy {
+	case StreamDropNewest:
+		select {
+		case s.queue <- msg:
+		default:
+			s.drop()
+		}
+	case StreamDropOldest:
+		for {
+			select {
+			case s.queue <- msg:
+				s.gauge()
+				return nil
+			default:
+			}
+			select {
+			case <-s.queue:
+				s.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case s.queue <- msg:
+		case <-s.done:
+			return s.Err()
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+	s.gauge()
+	return nil
+}
+
+// Close waits for the queued messages to be written and releases the stream resources. It
+// returns the error that caused the stream to stop if any.
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return s.Err()
+}
+
+// Err returns the error that caused the stream to stop, nil if the stream is still running.
+func (s *Stream) Err() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.err
+}
+
+// Congested returns true if the number of queued messages exceeds the stream high watermark.
+func (s *Stream) Congested() bool {
+	return len(s.queue) > s.opts.HighWatermark
+}
+
+// Stats returns the stream queue metrics.
+func (s *Stream) Stats() StreamStats {
+	return StreamStats{
+		Queued:  len(s.queue),
+		Sent:    atomic.LoadInt64(&s.sent),
+		Dropped: atomic.LoadInt64(&s.dropped),
+	}
+}
+
+// run writes the queued messages to the client until the stream is closed or fails.
+func (s *Stream) run() {
+	defer close(s.done)
+	flusher, _ := s.resp.ResponseWriter.(http.Flusher)
+	for {
+		select {
+		case msg, ok := <-s.queue:
+			if !ok {
+				return
+			}
+			if _, err := s.resp.Write(msg); err != nil {
+				s.fail(err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			atomic.AddInt64(&s.sent, 1)
+			s.gauge()
+		case <-s.ctx.Done():
+			s.fail(s.ctx.Err())
+			return
+		}
+	}
+}
+
+// encode encodes v with the service encoder, framing the result as an event if needed. NDJSON
+// streams encode v as JSON regardless of the service encoders.
+func (s *Stream) encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if s.ndjson {
+		if err := NewNDJSONEncoder(&buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	accept := ""
+	if req := ContextRequest(s.ctx); req != nil {
+		accept = req.Header.Get("Accept")
+	}
+	if err := s.service.Encoder.Encode(v, &buf, accept); err != nil {
+		return nil, err
+	}
+	if !s.sse {
+		return buf.Bytes(), nil
+	}
+	var ev bytes.Buffer
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		ev.WriteString("data: ")
+		ev.WriteString(line)
+		ev.WriteString("\n")
+	}
+	ev.WriteString("\n")
+	return ev.Bytes(), nil
+}
+
+func (s *Stream) fail(err error) {
+	s.errMu.Lock()
+	s.err = err
+	s.errMu.Unlock()
+}
+
+func (s *Stream) drop() {
+	atomic.AddInt64(&s.dropped, 1)
+	IncrCounter([]string{"goa", "stream", s.opts.Name, "dropped"}, 1.0)
+}
+
+func (s *Stream) gauge() {
+	SetGauge([]string{"goa", "stream", s.opts.Name, "queued"}, float32(len(s.queue)))
+}
@@ -0,0 +1,161 @@
+package goa_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// blockingResponseWriter blocks the first write until released.
+type blockingResponseWriter struct {
+	*TestResponseWriter
+	writing chan struct{}
+	release chan struct{}
+	blocked bool
+}
+
+func (b *blockingResponseWriter) Write(p []byte) (int, error) {
+	if !b.blocked {
+		b.blocked = true
+		close(b.writing)
+		<-b.release
+	}
+	return b.TestResponseWriter.Write(p)
+}
+
+var _ = Describe("Stream", func() {
+	var service *goa.Service
+	var rw *TestResponseWriter
+	var req *http.Request
+	var ctx context.Context
+	var opts *goa.StreamOptions
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		rw = &TestResponseWriter{ParentHeader: http.Header{}}
+		var err error
+		req, err = http.NewRequest("GET", "/bottles", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		opts = &goa.StreamOptions{Name: "bottle.watch"}
+	})
+
+	Context("with the default options", func() {
+		It("sends the messages in order", func() {
+			ctx = goa.NewContext(context.Background(), rw, req, url.Values{})
+			s := service.NewStream(ctx, 200, opts)
+			for i := 1; i <= 3; i++ {
+				Ω(s.Send(i)).ShouldNot(HaveOccurred())
+			}
+			Ω(s.Close()).ShouldNot(HaveOccurred())
+			Ω(rw.Status).Should(Equal(200))
+			Ω(string(rw.Body)).Should(Equal("1\n2\n3\n"))
+			Ω(s.Stats()).Should(Equal(goa.StreamStats{Sent: 3}))
+			Ω(s.Send(4)).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a client accepting server-sent events", func() {
+		It("frames the messages as events", func() {
+			req.Header.Set("Accept", "text/event-stream")
+			ctx = goa.NewContext(context.Background(), rw, req, url.Values{})
+			s := service.NewStream(ctx, 200, opts)
+			Ω(s.Send(map[string]int{"id": 1})).ShouldNot(HaveOccurred())
+			Ω(s.Close()).ShouldNot(HaveOccurred())
+			Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal("text/event-stream"))
+			Ω(string(rw.Body)).Should(Equal("data: {\"id\":1}\n\n"))
+		})
+	})
+
+	Context("with a client accepting newline delimited JSON", func() {
+		It("sends one message per line", func() {
+			req.Header.Set("Accept", "application/x-ndjson")
+			ctx = goa.NewContext(context.Background(), rw, req, url.Values{})
+			s := service.NewStream(ctx, 200, opts)
+			Ω(s.Send(map[string]string{"name": "a\nb"})).ShouldNot(HaveOccurred())
+			Ω(s.Send(map[string]int{"id": 2})).ShouldNot(HaveOccurred())
+			Ω(s.Close()).ShouldNot(HaveOccurred())
+			Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal("application/x-ndjson"))
+			Ω(string(rw.Body)).Should(Equal("{\"name\":\"a\\nb\"}\n{\"id\":2}\n"))
+		})
+	})
+
+	Context("with the NDJSON option", func() {
+		It("sends newline delimited JSON whatever the service encoders", func() {
+			service.Encoder = goa.NewHTTPEncoder()
+			service.Encoder.Register(goa.NewXMLEncoder, "*/*")
+			opts.NDJSON = true
+			ctx = goa.NewContext(context.Background(), rw, req, url.Values{})
+			s := service.NewStream(ctx, 200, opts)
+			Ω(s.Send(map[string]int{"id": 1})).ShouldNot(HaveOccurred())
+			Ω(s.Close()).ShouldNot(HaveOccurred())
+			Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal("application/x-ndjson"))
+			Ω(string(rw.Body)).Should(Equal("{\"id\":1}\n"))
+		})
+	})
+
+	Context("with a slow client", func() {
+		var brw *blockingResponseWriter
+		var s *goa.Stream
+
+		BeforeEach(func() {
+			brw = &blockingResponseWriter{
+				TestResponseWriter: rw,
+				writing:            make(chan struct{}),
+				release:            make(chan struct{}),
+			}
+			opts.BufferSize = 1
+		})
+
+		JustBeforeEach(func() {
+			ctx = goa.NewContext(context.Background(), brw, req, url.Values{})
+			s = service.NewStream(ctx, 200, opts)
+			Ω(s.Send(1)).ShouldNot(HaveOccurred())
+			<-brw.writing
+			Ω(s.Send(2)).ShouldNot(HaveOccurred())
+			Ω(s.Congested()).Should(BeFalse())
+			Ω(s.Send(3)).ShouldNot(HaveOccurred())
+			close(brw.release)
+			Ω(s.Close()).ShouldNot(HaveOccurred())
+		})
+
+		Context("and the drop newest policy", func() {
+			BeforeEach(func() {
+				opts.Policy = goa.StreamDropNewest
+			})
+
+			It("drops the message being sent", func() {
+				Ω(string(rw.Body)).Should(Equal("1\n2\n"))
+				Ω(s.Stats()).Should(Equal(goa.StreamStats{Sent: 2, Dropped: 1}))
+			})
+		})
+
+		Context("and the drop oldest policy", func() {
+			BeforeEach(func() {
+				opts.Policy = goa.StreamDropOldest
+			})
+
+			It("drops the oldest queued message", func() {
+				Ω(string(rw.Body)).Should(Equal("1\n3\n"))
+				Ω(s.Stats()).Should(Equal(goa.StreamStats{Sent: 2, Dropped: 1}))
+			})
+		})
+	})
+
+	Context("with a canceled request", func() {
+		It("stops the stream", func() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(goa.NewContext(context.Background(), rw, req, url.Values{}))
+			opts.BufferSize = 1
+			s := service.NewStream(ctx, 200, opts)
+			cancel()
+			Eventually(s.Err).Should(Equal(context.Canceled))
+			Ω(s.Close()).Should(Equal(context.Canceled))
+			Ω(s.Send(1)).Should(Equal(context.Canceled))
+		})
+	})
+})
@@ -0,0 +1,115 @@
+package goa
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// timeoutWriter is the response writer given to the handlers wrapped by TimeoutHandler. It
+// buffers the response so that it can be discarded if the handler does not complete in time.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+// TimeoutHandler returns a handler that runs h with a context canceled after the given timeout.
+// The response written by h is buffered and sent once h returns. If h does not return in time the
+// buffered response is discarded, the writes made by h afterwards fail with http.ErrHandlerTimeout
+// and the returned handler returns an ErrTimeout error without waiting for h so that the error
+// handler responds with a 504 status. Since the response is buffered h cannot flush it early.
+// The pooled request and response data of services with PoolRequestData set are not put back
+// into the pools when h times out as h may still be using them.
+// This function is intended for the controller generated code of the actions that define a
+// timeout with the Timeout DSL. User code should not need to call it directly.
+func TimeoutHandler(timeout time.Duration, h Handler) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		resp := ContextResponse(ctx)
+		tw := &timeoutWriter{header: make(http.Header)}
+		for k, vs := range resp.Header() {
+			tw.header[k] = append([]string(nil), vs...)
+		}
+		tresp := &ResponseData{ResponseWriter: tw}
+		tctx := context.WithValue(ctx, respKey, tresp)
+
+		done := make(chan error, 1)
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			done <- h(tctx, tw, req)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case err := <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			dst := resp.Header()
+			for k := range dst {
+				if _, ok := tw.header[k]; !ok {
+					dst.Del(k)
+				}
+			}
+			for k, vs := range tw.header {
+				dst[k] = vs
+			}
+			resp.ErrorCode = tresp.ErrorCode
+			if tw.status != 0 {
+				resp.WriteHeader(tw.status)
+			}
+			if tw.buf.Len() > 0 {
+				resp.Write(tw.buf.Bytes())
+			}
+			return err
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			detachPooledData(ctx)
+			if ctx.Err() == context.DeadlineExceeded {
+				return ErrTimeout("action timed out after %s", timeout)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// Header returns the buffered response headers.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader records the response status code unless the handler timed out.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+// Write buffers the response body, it fails with http.ErrHandlerTimeout if the handler timed out.
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
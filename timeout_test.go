@@ -0,0 +1,114 @@
+package goa_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TimeoutHandler", func() {
+	var handler goa.Handler
+	var rw *httptest.ResponseRecorder
+
+	serve := func() error {
+		rw = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/exports", nil)
+		ctx := goa.NewContext(nil, rw, req, nil)
+		return goa.TimeoutHandler(50*time.Millisecond, handler)(ctx, rw, req)
+	}
+
+	Context("with a handler that completes in time", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				if _, ok := ctx.Deadline(); !ok {
+					return goa.ErrInternal("no deadline")
+				}
+				resp := goa.ContextResponse(ctx)
+				resp.Header().Set("Content-Type", "text/plain")
+				resp.WriteHeader(201)
+				resp.Write([]byte("done"))
+				return nil
+			}
+		})
+
+		It("sends the response", func() {
+			err := serve()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(201))
+			Ω(rw.Header().Get("Content-Type")).Should(Equal("text/plain"))
+			Ω(rw.Body.String()).Should(Equal("done"))
+		})
+	})
+
+	Context("with a handler that does not complete in time", func() {
+		var writeErr chan error
+
+		BeforeEach(func() {
+			writeErr = make(chan error, 1)
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				resp := goa.ContextResponse(ctx)
+				resp.WriteHeader(200)
+				resp.Write([]byte("partial"))
+				<-ctx.Done()
+				_, err := resp.Write([]byte("late"))
+				writeErr <- err
+				return ctx.Err()
+			}
+		})
+
+		It("returns a timeout error and discards the response", func() {
+			err := serve()
+			Ω(err).Should(HaveOccurred())
+			Ω(goa.ErrTimeout.Is(err)).Should(BeTrue())
+			Ω(err.(*goa.Error).Status).Should(Equal(504))
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.String()).Should(BeEmpty())
+			Eventually(writeErr).Should(Receive(Equal(http.ErrHandlerTimeout)))
+		})
+	})
+
+	Context("with a service that pools the request data", func() {
+		var params chan url.Values
+
+		BeforeEach(func() {
+			params = make(chan url.Values, 1)
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				<-ctx.Done()
+				time.Sleep(20 * time.Millisecond)
+				params <- goa.ContextRequest(ctx).Params
+				return ctx.Err()
+			}
+		})
+
+		It("does not release the request data used by the timed out handler", func() {
+			service := goa.New("test")
+			service.PoolRequestData = true
+			ctrl := service.NewController("test")
+			h := ctrl.MuxHandler("show", goa.TimeoutHandler(10*time.Millisecond, handler), nil)
+			rw = httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/exports?sort=asc", nil)
+			h(rw, req, url.Values{"sort": {"asc"}})
+			var p url.Values
+			Eventually(params).Should(Receive(&p))
+			Ω(p.Get("sort")).Should(Equal("asc"))
+		})
+	})
+
+	Context("with a handler that panics", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				panic("boom")
+			}
+		})
+
+		It("propagates the panic", func() {
+			Ω(func() { serve() }).Should(Panic())
+		})
+	})
+})
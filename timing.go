@@ -0,0 +1,109 @@
+package goa
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Names of the request phases measured by goa and the generated code.
+const (
+	// PhaseDecode is the time spent reading and decoding the request body.
+	PhaseDecode = "decode"
+	// PhaseValidate is the time spent initializing the action context from the request
+	// parameters and validating the request parameters and payload.
+	PhaseValidate = "validate"
+	// PhaseController is the time spent running the controller action.
+	PhaseController = "controller"
+	// PhaseEncode is the time spent encoding and writing the response body.
+	PhaseEncode = "encode"
+)
+
+type (
+	// RequestTimings records the time spent by a request in each phase of its handling.
+	// The durations are exclusive: the time spent in a phase measured while another phase
+	// is being measured is not counted twice, for example the time spent encoding the
+	// response is not included in the controller duration.
+	RequestTimings struct {
+		// Start is the time the service started handling the request.
+		Start time.Time
+
+		mu     sync.Mutex
+		phases map[string]time.Duration
+		total  time.Duration
+	}
+
+	// countingReader records the number of request body bytes read in the request data.
+	countingReader struct {
+		io.ReadCloser
+		data *RequestData
+	}
+)
+
+// WithTimings creates a context holding new request timings starting now.
+func WithTimings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timingsKey, &RequestTimings{Start: time.Now()})
+}
+
+// ContextTimings extracts the request timings from the given context.
+func ContextTimings(ctx context.Context) *RequestTimings {
+	if t := ctx.Value(timingsKey); t != nil {
+		return t.(*RequestTimings)
+	}
+	return nil
+}
+
+// MeasurePhase starts measuring the given phase of the request and returns the function that
+// stops the measure and records the duration in the context request timings. MeasurePhase does
+// nothing if the context does not hold request timings. Usage:
+//
+//	defer goa.MeasurePhase(ctx, goa.PhaseController)()
+func MeasurePhase(ctx context.Context, phase string) func() {
+	t := ContextTimings(ctx)
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	nested := t.measured()
+	return func() {
+		d := time.Since(start) - (t.measured() - nested)
+		t.Add(phase, d)
+	}
+}
+
+// Add adds d to the duration of the given phase.
+func (t *RequestTimings) Add(phase string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phases == nil {
+		t.phases = make(map[string]time.Duration)
+	}
+	t.phases[phase] += d
+	t.total += d
+}
+
+// Phases returns the durations recorded so far indexed by phase name.
+func (t *RequestTimings) Phases() map[string]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	phases := make(map[string]time.Duration, len(t.phases))
+	for p, d := range t.phases {
+		phases[p] = d
+	}
+	return phases
+}
+
+// measured returns the sum of the durations recorded so far.
+func (t *RequestTimings) measured() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+// Read reads from the request body and records the number of bytes read.
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.data.Length += int64(n)
+	return n, err
+}
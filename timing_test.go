@@ -0,0 +1,43 @@
+package goa_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MeasurePhase", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = goa.WithTimings(context.Background())
+	})
+
+	It("records the phase duration", func() {
+		done := goa.MeasurePhase(ctx, goa.PhaseController)
+		time.Sleep(10 * time.Millisecond)
+		done()
+		phases := goa.ContextTimings(ctx).Phases()
+		Ω(phases).Should(HaveLen(1))
+		Ω(phases[goa.PhaseController]).Should(BeNumerically(">=", 10*time.Millisecond))
+	})
+
+	It("excludes the nested phases", func() {
+		done := goa.MeasurePhase(ctx, goa.PhaseController)
+		encode := goa.MeasurePhase(ctx, goa.PhaseEncode)
+		time.Sleep(20 * time.Millisecond)
+		encode()
+		done()
+		phases := goa.ContextTimings(ctx).Phases()
+		Ω(phases[goa.PhaseEncode]).Should(BeNumerically(">=", 20*time.Millisecond))
+		Ω(phases[goa.PhaseController]).Should(BeNumerically("<", 20*time.Millisecond))
+	})
+
+	It("does nothing when the context holds no timings", func() {
+		goa.MeasurePhase(context.Background(), goa.PhaseDecode)()
+		Ω(goa.ContextTimings(context.Background())).Should(BeNil())
+	})
+})
@@ -0,0 +1,92 @@
+package goa
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+)
+
+// CertManager obtains and renews the certificates served by ListenAndServeAutoTLS, see
+// Service.CertManager. The goaautocert package provides a manager backed by Let's Encrypt, it
+// lives in its own package so that services that do not need it do not depend on the ACME client.
+type CertManager interface {
+	// Manage returns the TLS configuration that serves the certificates of the given domains
+	// and the handler of the HTTP requests sent by the certificate authority to validate the
+	// domains. Only the GetCertificate and NextProtos fields of the configuration are used, the
+	// other TLS settings come from the service TLS options.
+	Manage(domains []string) (*tls.Config, http.Handler, error)
+}
+
+// TLSOptions configures the HTTPS servers started by ListenAndServeTLS, ListenAndServeAutoTLS
+// and the goaautocert package, see Service.TLS.
+type TLSOptions struct {
+	// MinVersion is the minimum TLS version accepted by the server, e.g. tls.VersionTLS13.
+	// Defaults to tls.VersionTLS12.
+	MinVersion uint16
+	// CipherSuites lists the cipher suites enabled for TLS 1.2 connections, nil means the Go
+	// defaults. The TLS 1.3 cipher suites are not configurable.
+	CipherSuites []uint16
+	// ClientCAs is the pool of certificate authorities used to verify client certificates.
+	ClientCAs *x509.CertPool
+	// ClientAuth is the client certificate policy. Defaults to tls.RequireAndVerifyClientCert
+	// if ClientCAs is set, tls.NoClientCert otherwise.
+	ClientAuth tls.ClientAuthType
+}
+
+// TLSConfig returns the TLS configuration described by the options, o may be nil. Use it to
+// configure servers started by other means than the Service ListenAndServe methods.
+func (o *TLSOptions) TLSConfig() *tls.Config {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o == nil {
+		return cfg
+	}
+	if o.MinVersion != 0 {
+		cfg.MinVersion = o.MinVersion
+	}
+	cfg.CipherSuites = o.CipherSuites
+	cfg.ClientCAs = o.ClientCAs
+	cfg.ClientAuth = o.ClientAuth
+	if o.ClientCAs != nil && o.ClientAuth == tls.NoClientCert {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg
+}
+
+// ListenAndServeAutoTLS starts a HTTPS server listening on port 443 that serves certificates for
+// the given domains obtained and renewed automatically by the service certificate manager. It
+// also starts a HTTP server listening on port 80 that answers the certificate authority
+// challenges. When one of the servers fails ListenAndServeAutoTLS closes the other one and
+// returns the error. Use goaautocert.ListenAndServe to listen on other addresses.
+func (service *Service) ListenAndServeAutoTLS(domains ...string) error {
+	if service.CertManager == nil {
+		return errors.New("ListenAndServeAutoTLS requires a certificate manager, see Service.CertManager")
+	}
+	if len(domains) == 0 {
+		return errors.New("ListenAndServeAutoTLS requires at least one domain")
+	}
+	certs, challenges, err := service.CertManager.Manage(domains)
+	if err != nil {
+		return err
+	}
+	cfg := service.TLS.TLSConfig()
+	cfg.GetCertificate = certs.GetCertificate
+	cfg.NextProtos = certs.NextProtos
+
+	httpSrv := &http.Server{Addr: ":80", Handler: challenges}
+	httpsSrv := &http.Server{Addr: ":443", Handler: service.Mux, TLSConfig: cfg}
+	errc := make(chan error, 2)
+	go func() {
+		service.LogInfo("listen", "transport", "http", "addr", httpSrv.Addr, "acme", true)
+		errc <- httpSrv.ListenAndServe()
+	}()
+	go func() {
+		service.LogInfo("listen", "transport", "https", "addr", httpsSrv.Addr, "domains", domains)
+		errc <- httpsSrv.ListenAndServeTLS("", "")
+	}()
+	err = <-errc
+	httpSrv.Close()
+	httpsSrv.Close()
+	<-errc
+	return err
+}
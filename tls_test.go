@@ -0,0 +1,78 @@
+package goa_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TLSOptions", func() {
+	It("defaults to TLS 1.2 without client certificates", func() {
+		var opts *goa.TLSOptions
+		cfg := opts.TLSConfig()
+		Ω(cfg.MinVersion).Should(BeEquivalentTo(tls.VersionTLS12))
+		Ω(cfg.ClientAuth).Should(Equal(tls.NoClientCert))
+	})
+
+	It("applies the options", func() {
+		opts := &goa.TLSOptions{
+			MinVersion:   tls.VersionTLS13,
+			CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		}
+		cfg := opts.TLSConfig()
+		Ω(cfg.MinVersion).Should(BeEquivalentTo(tls.VersionTLS13))
+		Ω(cfg.CipherSuites).Should(Equal(opts.CipherSuites))
+	})
+
+	It("requires client certificates when given client CAs", func() {
+		pool := x509.NewCertPool()
+		cfg := (&goa.TLSOptions{ClientCAs: pool}).TLSConfig()
+		Ω(cfg.ClientCAs).Should(BeIdenticalTo(pool))
+		Ω(cfg.ClientAuth).Should(Equal(tls.RequireAndVerifyClientCert))
+
+		cfg = (&goa.TLSOptions{ClientCAs: pool, ClientAuth: tls.VerifyClientCertIfGiven}).TLSConfig()
+		Ω(cfg.ClientAuth).Should(Equal(tls.VerifyClientCertIfGiven))
+	})
+})
+
+// failingCertManager is a goa.CertManager that fails to manage any domain.
+type failingCertManager struct {
+	domains []string
+}
+
+func (m *failingCertManager) Manage(domains []string) (*tls.Config, http.Handler, error) {
+	m.domains = domains
+	return nil, nil, errors.New("unauthorized domain")
+}
+
+var _ = Describe("ListenAndServeAutoTLS", func() {
+	var service *goa.Service
+
+	BeforeEach(func() {
+		service = goa.New("test")
+	})
+
+	It("requires a certificate manager", func() {
+		err := service.ListenAndServeAutoTLS("example.com")
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring("certificate manager"))
+	})
+
+	It("requires domains", func() {
+		service.CertManager = &failingCertManager{}
+		Ω(service.ListenAndServeAutoTLS()).Should(HaveOccurred())
+	})
+
+	It("returns the certificate manager errors", func() {
+		m := &failingCertManager{}
+		service.CertManager = m
+		err := service.ListenAndServeAutoTLS("example.com", "www.example.com")
+		Ω(err).Should(MatchError("unauthorized domain"))
+		Ω(m.domains).Should(Equal([]string{"example.com", "www.example.com"}))
+	})
+})
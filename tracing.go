@@ -0,0 +1,159 @@
+package goa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// The headers used to propagate traces across services, they follow the Zipkin B3 propagation
+// format so that the traces may be continued by the services using Zipkin or OpenTracing tracers.
+const (
+	// TraceIDHeader is the name of the header that carries the trace ID.
+	TraceIDHeader = "X-B3-TraceId"
+	// SpanIDHeader is the name of the header that carries the ID of the caller span.
+	SpanIDHeader = "X-B3-SpanId"
+	// ParentSpanIDHeader is the name of the header that carries the ID of the parent of the
+	// caller span.
+	ParentSpanIDHeader = "X-B3-ParentSpanId"
+	// SampledHeader is the name of the header that carries the sampling decision, "1" if the
+	// trace is sampled and "0" otherwise.
+	SampledHeader = "X-B3-Sampled"
+	// FlagsHeader is the name of the header that forces the sampling of a trace when set to "1".
+	FlagsHeader = "X-B3-Flags"
+)
+
+type (
+	// Span records the handling of a request by a service or the request made by a client as
+	// part of a trace. The tracer middleware stores the span of the request in the request
+	// context, see ContextSpan.
+	Span struct {
+		// Name is the name of the operation, "resource.action" for the spans created by goa.
+		Name string
+		// Kind is "server" for the spans of the requests handled by the service and
+		// "client" for the spans of the requests made by the clients.
+		Kind string
+		// TraceID is the ID of the trace, a 32 characters hex string.
+		TraceID string
+		// SpanID is the ID of the span, a 16 characters hex string.
+		SpanID string
+		// ParentSpanID is the ID of the parent span, empty for the root span of a trace.
+		ParentSpanID string
+		// Sampled is true if the span is recorded once finished.
+		Sampled bool
+		// Start is the time the span started.
+		Start time.Time
+		// Duration is the span duration, set by Finish.
+		Duration time.Duration
+		// Tags lists the annotations of the span, e.g. "http.status_code".
+		Tags map[string]string
+
+		recorder SpanRecorder
+	}
+
+	// SpanRecorder is the interface implemented by the types that report finished spans to a
+	// tracing system, e.g. Zipkin.
+	SpanRecorder interface {
+		// RecordSpan records the finished span.
+		RecordSpan(span *Span)
+	}
+
+	// SpanRecorderFunc is a function that implements SpanRecorder.
+	SpanRecorderFunc func(span *Span)
+)
+
+// RecordSpan calls f.
+func (f SpanRecorderFunc) RecordSpan(span *Span) {
+	f(span)
+}
+
+// StartSpan creates the root span of a new trace. The span is recorded with rec when finished if
+// rec is not nil and the span is sampled.
+func StartSpan(name, kind string, rec SpanRecorder) *Span {
+	return &Span{
+		Name:     name,
+		Kind:     kind,
+		TraceID:  NewTraceID(),
+		SpanID:   NewSpanID(),
+		Start:    time.Now(),
+		Tags:     make(map[string]string),
+		recorder: rec,
+	}
+}
+
+// NewTraceID returns a random trace ID.
+func NewTraceID() string {
+	return randomHex(16)
+}
+
+// NewSpanID returns a random span ID.
+func NewSpanID() string {
+	return randomHex(8)
+}
+
+// WithSpan creates a context holding the given span.
+func WithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey, span)
+}
+
+// ContextSpan extracts the span from the given context, nil if there is none.
+func ContextSpan(ctx context.Context) *Span {
+	if s := ctx.Value(spanKey); s != nil {
+		return s.(*Span)
+	}
+	return nil
+}
+
+// Child creates a span of the same trace whose parent is s. The child is sampled and recorded
+// like s.
+func (s *Span) Child(name, kind string) *Span {
+	return &Span{
+		Name:         name,
+		Kind:         kind,
+		TraceID:      s.TraceID,
+		SpanID:       NewSpanID(),
+		ParentSpanID: s.SpanID,
+		Sampled:      s.Sampled,
+		Start:        time.Now(),
+		Tags:         make(map[string]string),
+		recorder:     s.recorder,
+	}
+}
+
+// SetTag annotates the span.
+func (s *Span) SetTag(key, value string) {
+	s.Tags[key] = value
+}
+
+// Inject sets the headers that propagate the span to the service receiving the request.
+func (s *Span) Inject(h http.Header) {
+	h.Set(TraceIDHeader, s.TraceID)
+	h.Set(SpanIDHeader, s.SpanID)
+	if s.ParentSpanID != "" {
+		h.Set(ParentSpanIDHeader, s.ParentSpanID)
+	} else {
+		h.Del(ParentSpanIDHeader)
+	}
+	if s.Sampled {
+		h.Set(SampledHeader, "1")
+	} else {
+		h.Set(SampledHeader, "0")
+	}
+}
+
+// Finish sets the span duration and records the span if it is sampled.
+func (s *Span) Finish() {
+	s.Duration = time.Since(s.Start)
+	if s.Sampled && s.recorder != nil {
+		s.recorder.RecordSpan(s)
+	}
+}
+
+// randomHex returns the hex representation of n random bytes.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
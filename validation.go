@@ -6,6 +6,8 @@ import (
 	"net/mail"
 	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/satori/go.uuid"
@@ -44,11 +46,17 @@ const (
 
 	// FormatRegexp Regexp defines regular expression syntax accepted by RE2.
 	FormatRegexp = "regexp"
+
+	// FormatHostPort defines "host:port" network address values where host is a RFC1123 host
+	// name or an IP address (IPv6 addresses must be enclosed in square brackets) and port a
+	// port number.
+	FormatHostPort = "hostport"
 )
 
 var (
-	// Regular expression used to validate RFC1035 hostnames*/
-	hostnameRegex = regexp.MustCompile(`^[[:alnum:]][[:alnum:]\-]{0,61}[[:alnum:]]|[[:alpha:]]$`)
+	// Regular expression used to validate RFC1035 hostnames (as relaxed by RFC1123 to allow
+	// labels that start with a digit).
+	hostnameRegex = regexp.MustCompile(`^([[:alnum:]]([[:alnum:]\-]{0,61}[[:alnum:]])?)(\.[[:alnum:]]([[:alnum:]\-]{0,61}[[:alnum:]])?)*\.?$`)
 
 	// Simple regular expression for IPv4 values, more rigorous checking is done via net.ParseIP
 	ipv4Regex = regexp.MustCompile(`^(?:[0-9]{1,3}\.){3}[0-9]{1,3}$`)
@@ -68,6 +76,7 @@ var (
 //     - "mac": IEEE 802 MAC-48, EUI-48 or EUI-64 MAC address value
 //     - "cidr": RFC4632 and RFC4291 CIDR notation IP address value
 //     - "regexp": Regular expression syntax accepted by RE2
+//     - "hostport": "host:port" network address value
 func ValidateFormat(f Format, val string) error {
 	var err error
 	switch f {
@@ -78,19 +87,27 @@ func ValidateFormat(f Format, val string) error {
 	case FormatEmail:
 		_, err = mail.ParseAddress(val)
 	case FormatHostname:
-		if !hostnameRegex.MatchString(val) {
-			err = fmt.Errorf("hostname value '%s' does not match %s",
-				val, hostnameRegex.String())
-		}
+		err = validateHostname(val)
 	case FormatIPv4, FormatIPv6:
 		ip := net.ParseIP(val)
 		if ip == nil {
 			err = fmt.Errorf("\"%s\" is an invalid %s value", val, f)
 		}
-		if f == "ipv4" {
+		if f == FormatIPv4 {
 			if !ipv4Regex.MatchString(val) {
 				err = fmt.Errorf("\"%s\" is an invalid ipv4 value", val)
 			}
+		} else if !strings.Contains(val, ":") {
+			err = fmt.Errorf("\"%s\" is an invalid ipv6 value", val)
+		}
+	case FormatHostPort:
+		var host, port string
+		if host, port, err = net.SplitHostPort(val); err == nil {
+			if p, perr := strconv.Atoi(port); perr != nil || p < 0 || p > 65535 {
+				err = fmt.Errorf("\"%s\" is an invalid port number", port)
+			} else if net.ParseIP(host) == nil {
+				err = validateHostname(host)
+			}
 		}
 	case FormatURI:
 		_, err = url.ParseRequestURI(val)
@@ -110,6 +127,14 @@ func ValidateFormat(f Format, val string) error {
 	return nil
 }
 
+// validateHostname returns an error if val is not a valid RFC1123 host name.
+func validateHostname(val string) error {
+	if len(val) > 253 || !hostnameRegex.MatchString(val) {
+		return fmt.Errorf("hostname value '%s' does not match %s", val, hostnameRegex.String())
+	}
+	return nil
+}
+
 // knownPatterns records the compiled patterns.
 var knownPatterns = make(map[string]*regexp.Regexp)
 
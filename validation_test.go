@@ -179,6 +179,54 @@ var _ = Describe("ValidateFormat", func() {
 
 	})
 
+	Context("IPv6 with an IPv4 value", func() {
+		BeforeEach(func() {
+			f = goa.FormatIPv6
+			val = "192.168.0.1"
+		})
+
+		It("does not validate", func() {
+			Ω(valErr).Should(HaveOccurred())
+		})
+	})
+
+	Context("Hostname with multiple labels", func() {
+		BeforeEach(func() {
+			f = goa.FormatHostname
+		})
+
+		It("validates fully qualified names", func() {
+			Ω(goa.ValidateFormat(f, "api.v1.goa.design")).ShouldNot(HaveOccurred())
+			Ω(goa.ValidateFormat(f, "1and1.com")).ShouldNot(HaveOccurred())
+		})
+
+		It("does not validate invalid labels", func() {
+			Ω(goa.ValidateFormat(f, "goa..design")).Should(HaveOccurred())
+			Ω(goa.ValidateFormat(f, "-goa.design")).Should(HaveOccurred())
+			Ω(goa.ValidateFormat(f, "goa.design/foo")).Should(HaveOccurred())
+		})
+	})
+
+	Context("HostPort", func() {
+		BeforeEach(func() {
+			f = goa.FormatHostPort
+		})
+
+		It("validates host names, IPv4 and IPv6 addresses with ports", func() {
+			Ω(goa.ValidateFormat(f, "goa.design:80")).ShouldNot(HaveOccurred())
+			Ω(goa.ValidateFormat(f, "192.168.0.1:8080")).ShouldNot(HaveOccurred())
+			Ω(goa.ValidateFormat(f, "[::1]:443")).ShouldNot(HaveOccurred())
+		})
+
+		It("does not validate invalid values", func() {
+			Ω(goa.ValidateFormat(f, "goa.design")).Should(HaveOccurred())
+			Ω(goa.ValidateFormat(f, "goa.design:http")).Should(HaveOccurred())
+			Ω(goa.ValidateFormat(f, "goa.design:70000")).Should(HaveOccurred())
+			Ω(goa.ValidateFormat(f, "::1:443")).Should(HaveOccurred())
+			Ω(goa.ValidateFormat(f, "_hi_:80")).Should(HaveOccurred())
+		})
+	})
+
 	Context("URI", func() {
 		BeforeEach(func() {
 			f = goa.FormatURI